@@ -33,7 +33,7 @@ import (
 
 func TestDoCleanup(t *testing.T) {
 
-	environments, errs := environment.LoadEnvironmentList("", "test-resources/integration-multi-environment/environments.yaml", util.CreateTestFileSystem())
+	environments, errs := environment.LoadEnvironmentList("", "test-resources/integration-multi-environment/environments.yaml", util.CreateTestFileSystem(), util.DefaultLogger())
 	for _, err := range errs {
 		assert.NilError(t, err)
 	}
@@ -150,7 +150,7 @@ func validation_uploadDownloadedConfigs(t *testing.T, fs afero.Fs, downloadFolde
 // Deletes all configs that end with "_suffix", where suffix == suffixTest+suffixTimestamp
 func cleanupEnvironmentConfigs(t *testing.T, fs afero.Fs, envFile, suffix string) {
 	util.Log.Info("BEGIN CLEANUP PROCESS")
-	environments, errs := environment.LoadEnvironmentList("", envFile, fs)
+	environments, errs := environment.LoadEnvironmentList("", envFile, fs, util.DefaultLogger())
 	FailOnAnyError(errs, "loading of environments failed")
 
 	apis := api.NewApis()
@@ -25,6 +25,7 @@ import (
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 	"github.com/spf13/afero"
 
 	"gotest.tools/assert"
@@ -38,10 +39,10 @@ func TestIntegrationContinueDeploymentOnError(t *testing.T) {
 
 	RunIntegrationWithCleanup(t, allConfigsFolder, allConfigsEnvironmentsFile, "AllConfigs", func(fs afero.Fs) {
 
-		environments, errs := environment.LoadEnvironmentList("", allConfigsEnvironmentsFile, fs)
+		environments, errs := environment.LoadEnvironmentList("", allConfigsEnvironmentsFile, fs, util.DefaultLogger())
 		assert.Check(t, len(errs) == 0, "didn't expect errors loading test environments")
 
-		projects, err := project.LoadProjectsToDeploy(fs, "", api.NewApis(), allConfigsFolder)
+		projects, err := project.LoadProjectsToDeploy(fs, "", api.NewApis(), allConfigsFolder, util.DefaultLogger())
 		assert.NilError(t, err)
 
 		statusCode := RunImpl([]string{
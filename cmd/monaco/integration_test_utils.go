@@ -123,7 +123,7 @@ func getTransformerFunc(suffix string) func(line string) string {
 // Deletes all configs that end with "_suffix", where suffix == suffixTest+suffixTimestamp
 func cleanupIntegrationTest(t *testing.T, fs afero.Fs, envFile, suffix string) {
 
-	environments, errs := environment.LoadEnvironmentList("", envFile, fs)
+	environments, errs := environment.LoadEnvironmentList("", envFile, fs, util.DefaultLogger())
 	FailOnAnyError(errs, "loading of environments failed")
 
 	apis := api.NewApis()
@@ -17,13 +17,29 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/bundle"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/deploy"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/diff"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/generate"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/graph"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/list"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/secret"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/tracing"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/version"
+	"github.com/jcelliott/lumber"
 	"github.com/spf13/afero"
 	"github.com/urfave/cli/v2"
 )
@@ -40,13 +56,37 @@ func Run(args []string) int {
 func RunImpl(args []string, fs afero.Fs) (statusCode int) {
 	var app *cli.App
 
+	// additional apis are known to all commands, so they are loaded before the command line is parsed
+	if err := api.LoadApiDefinitions(fs, os.Getenv("MONACO_API_DEFINITIONS")); err != nil {
+		util.Log.Error("%s\n", err)
+		return 1
+	}
+
 	if newCli, ok := os.LookupEnv("NEW_CLI"); ok && newCli != "0" {
 		app = buildExperimentalCli(fs)
 	} else {
 		app = buildCli(fs)
 	}
 
-	err := app.Run(args)
+	ctx, stop := cancelOnSignal()
+	defer stop()
+
+	// the commands don't pass the context to requests and lookups of secrets, their waits are cancelled with it
+	rest.SetContext(ctx)
+	secret.SetContext(ctx)
+
+	// the log files are complete even if the run was interrupted
+	defer util.CloseLogging()
+
+	// spans are exported before the log files are closed, so export failures are logged
+	defer tracing.Shutdown()
+
+	err := app.RunContext(ctx, args)
+
+	if errors.Is(err, context.Canceled) {
+		util.Log.Error("%s\n", err)
+		return interruptedStatusCode
+	}
 
 	if err != nil {
 		util.Log.Error("%s\n", err)
@@ -96,22 +136,45 @@ Examples:
 `
 
 	app.Before = func(c *cli.Context) error {
-		err := util.SetupLogging(c.Bool("verbose"))
+		envFile, err := loadEnvFile(c, fs)
+		if err != nil {
+			return err
+		}
+
+		err = util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
 
 		if err != nil {
 			return err
 		}
 
 		util.Log.Info("Dynatrace Monitoring as Code v" + version.MonitoringAsCode)
+		logEnvFile(envFile)
+
+		if err := configureHttpClient(c, fs); err != nil {
+			return err
+		}
 
-		return nil
+		return tracing.Setup(c.String("trace-endpoint"))
 	}
 
-	app.Flags = []cli.Flag{
+	app.Flags = append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:    "verbose",
 			Aliases: []string{"v"},
 		},
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+			Aliases: []string{"q"},
+		},
+		&cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+		},
+		&cli.BoolFlag{
+			Name:  "timestamps",
+			Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+		},
 		&cli.PathFlag{
 			Name:      "environments",
 			Usage:     "Yaml file containing environments to deploy to",
@@ -119,9 +182,9 @@ Examples:
 			Required:  true,
 			TakesFile: true,
 		},
-		&cli.StringFlag{
+		&cli.StringSliceFlag{
 			Name:        "specific-environment",
-			Usage:       "Specific environment (from list) to deploy to",
+			Usage:       "Specific environment (from list) to deploy to. Can be repeated or comma-separated to deploy to several environments",
 			Aliases:     []string{"se"},
 			DefaultText: "none",
 		},
@@ -131,17 +194,129 @@ Examples:
 			Aliases:     []string{"p"},
 			DefaultText: "none",
 		},
+		&cli.PathFlag{
+			Name:      "projects-file",
+			Usage:     "File listing the projects to deploy, separated by newlines or commas (also deploys any dependent configurations)",
+			TakesFile: true,
+		},
+		&cli.BoolFlag{
+			Name:    "strict-projects",
+			Aliases: []string{"no-auto-dependencies"},
+			Usage:   "Only deploy the specified projects, and fail if they depend on other projects instead of deploying them too",
+		},
 		&cli.BoolFlag{
 			Name:    "dry-run",
 			Aliases: []string{"d"},
 			Usage:   "Switches to just validation instead of actual deployment",
 		},
+		&cli.BoolFlag{
+			Name:  "diff",
+			Usage: "With --dry-run, show the fields which would change in the configs which would be updated",
+		},
 		&cli.BoolFlag{
 			Name:    "continue-on-error",
 			Usage:   "Proceed deployment even if config upload fails",
 			Aliases: []string{"c"},
 		},
-	}
+		&cli.IntFlag{
+			Name:  "max-errors",
+			Usage: "Abort a deployment continuing on error once the given number of configs failed. 0 aborts on the first error, a negative number never aborts",
+			Value: -1,
+		},
+		&cli.IntFlag{
+			Name:    "parallel",
+			Usage:   "Number of configs deployed concurrently. Configs are only deployed after the configs they reference",
+			EnvVars: []string{"MONACO_PARALLEL"},
+			Value:   1,
+		},
+		&cli.PathFlag{
+			Name:      "report",
+			Usage:     "Writes a json report of all processed configs to the given file",
+			TakesFile: true,
+		},
+		&cli.PathFlag{
+			Name:      "metrics-file",
+			Usage:     "Writes metrics of the run in the Prometheus text format to the given file",
+			TakesFile: true,
+		},
+		&cli.BoolFlag{
+			Name:  "skip-env-check",
+			Usage: "Skip the check for missing environment variables referenced in configs before the deployment",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-preflight",
+			Usage: "Skip the check that all environments are reachable and accept their tokens before the deployment",
+		},
+		&cli.PathFlag{
+			Name:      "id-cache",
+			Usage:     "Json file caching the ids of deployed configs, to update the same objects on subsequent deployments",
+			TakesFile: true,
+		},
+		&cli.BoolFlag{
+			Name:  "reset-id-cache",
+			Usage: "Ignore the ids of the id cache and overwrite it with the ids of this deployment",
+		},
+		&cli.BoolFlag{
+			Name:  "validate-schemas",
+			Usage: "Validate the rendered configs against the json schema of their config type",
+		},
+		&cli.PathFlag{
+			Name:      "schema-dir",
+			Usage:     "Directory containing json schemas named after the config type (e.g. dashboard.json), replacing the bundled ones. Enables schema validation",
+			TakesFile: true,
+		},
+		&cli.PathFlag{
+			Name:      "bundle",
+			Usage:     "Deploys the configs of a bundle created by the bundle command, instead of the projects in the working directory",
+			TakesFile: true,
+		},
+		&cli.BoolFlag{
+			Name:    "yes",
+			Aliases: []string{"force"},
+			Usage:   "Deploy to environments flagged as production without asking to confirm by typing their names",
+			EnvVars: []string{"MONACO_SKIP_CONFIRMATION"},
+		},
+		&cli.PathFlag{
+			Name:      "state-file",
+			Usage:     "File storing the checksums of the deployed configs. Configs unchanged since the last deployment are skipped",
+			TakesFile: true,
+		},
+		&cli.BoolFlag{
+			Name:  "ignore-state",
+			Usage: "Deploy all configs, including the ones unchanged since the last deployment according to the state file",
+		},
+		&cli.BoolFlag{
+			Name:  "refresh",
+			Usage: "Check that the objects of unchanged configs still exist in the environment, and deploy them again if not",
+		},
+		&cli.BoolFlag{
+			Name:  "allow-duplicate-names",
+			Usage: "Only warn about configs of the same project and api sharing their name, instead of failing before the deployment",
+		},
+		&cli.PathFlag{
+			Name:      "run-state",
+			Usage:     "Json file recording the configs deployed by a failed or interrupted deployment, which is removed once a deployment succeeds. Defaults to '" + deploy.DefaultRunStateFile + "' if --resume or --no-resume is set",
+			TakesFile: true,
+		},
+		&cli.BoolFlag{
+			Name:  "resume",
+			Usage: "Resume the deployment recorded in the run state, skipping the configs it deployed which are unchanged since",
+		},
+		&cli.BoolFlag{
+			Name:  "no-resume",
+			Usage: "Discard the run state of previous deployments, so all configs are deployed",
+		},
+		&cli.DurationFlag{
+			Name:  "resume-max-age",
+			Usage: "Age after which a run state is not resumed anymore, e.g. 12h (0 = never expires)",
+			Value: deploy.DefaultResumeMaxAge,
+		},
+		&cli.StringFlag{
+			Name:    "trace-endpoint",
+			Usage:   "OTLP endpoint of the OpenTelemetry collector receiving traces of the deployment, e.g. http://localhost:4318",
+			EnvVars: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+	}, append(httpClientFlags(), envFileFlags()...)...)
 
 	app.Action = func(ctx *cli.Context) error {
 		if ctx.NArg() > 1 {
@@ -157,22 +332,56 @@ Examples:
 			workingDir = "."
 		}
 
-		return deploy.Deploy(
-			workingDir,
-			fs,
-			ctx.Path("environments"),
-			ctx.String("specific-environment"),
-			ctx.String("project"),
-			ctx.Bool("dry-run"),
-			ctx.Bool("continue-on-error"),
-		)
+		_, err := deploy.Deploy(ctx.Context, fs, workingDir, ctx.Path("environments"), deployOptions(ctx))
+		return err
 	}
 
 	return app
 }
 
+// deployOptions returns the options of a deployment set by the flags of the deploy command. Deployments to production
+// environments are confirmed on the console, unless the confirmation is skipped or the input is no terminal, e.g. in
+// pipelines.
+func deployOptions(ctx *cli.Context) deploy.Options {
+	var confirm deploy.Confirmation
+	if !ctx.Bool("yes") && util.IsInteractiveInput() {
+		confirm = deploy.NewConsoleConfirmation(os.Stdin, os.Stderr)
+	}
+
+	return deploy.Options{
+		SpecificEnvironment: strings.Join(ctx.StringSlice("specific-environment"), ","),
+		Project:             ctx.String("project"),
+		ProjectsFile:        ctx.Path("projects-file"),
+		StrictProjects:      ctx.Bool("strict-projects"),
+		DryRun:              ctx.Bool("dry-run"),
+		Diff:                ctx.Bool("diff"),
+		ContinueOnError:     ctx.Bool("continue-on-error"),
+		MaxErrors:           ctx.Int("max-errors"),
+		Parallel:            ctx.Int("parallel"),
+		ReportFile:          ctx.Path("report"),
+		MetricsFile:         ctx.Path("metrics-file"),
+		SkipEnvCheck:        ctx.Bool("skip-env-check"),
+		SkipPreflight:       ctx.Bool("skip-preflight"),
+		IdCacheFile:         ctx.Path("id-cache"),
+		ResetIdCache:        ctx.Bool("reset-id-cache"),
+		ValidateSchemas:     ctx.Bool("validate-schemas"),
+		SchemaDir:           ctx.Path("schema-dir"),
+		BundleFile:          ctx.Path("bundle"),
+		StateFile:           ctx.Path("state-file"),
+		IgnoreState:         ctx.Bool("ignore-state"),
+		Refresh:             ctx.Bool("refresh"),
+		AllowDuplicateNames: ctx.Bool("allow-duplicate-names"),
+		RunStateFile:        ctx.Path("run-state"),
+		Resume:              ctx.Bool("resume"),
+		NoResume:            ctx.Bool("no-resume"),
+		ResumeMaxAge:        ctx.Duration("resume-max-age"),
+		Confirm:             confirm,
+	}
+}
+
 func buildExperimentalCli(fs afero.Fs) *cli.App {
-	fmt.Print(`You are using the new CLI structure which is currently in Beta.
+	// the notice is written to stderr, to keep the output of commands like list parsable
+	fmt.Fprint(os.Stderr, `You are using the new CLI structure which is currently in Beta.
 
 Please provide feedback here:
   https://github.com/dynatrace-oss/dynatrace-monitoring-as-code/issues/45.
@@ -208,7 +417,14 @@ Examples:
 `
 	deployCommand := getDeployCommand(fs)
 	downloadCommand := getDownloadCommand(fs)
-	app.Commands = []*cli.Command{&deployCommand, &downloadCommand}
+	diffCommand := getDiffCommand(fs)
+	validateCommand := getValidateCommand(fs)
+	listCommand := getListCommand(fs)
+	graphCommand := getGraphCommand(fs)
+	bundleCommand := getBundleCommand(fs)
+	generateCommand := getGenerateCommand(fs)
+	convertCommand := getConvertCommand(fs)
+	app.Commands = []*cli.Command{&deployCommand, &downloadCommand, &diffCommand, &validateCommand, &listCommand, &graphCommand, &bundleCommand, &generateCommand, &convertCommand}
 
 	return app
 }
@@ -219,21 +435,44 @@ func getDeployCommand(fs afero.Fs) cli.Command {
 		UsageText: "deploy [command options] [working directory]",
 		ArgsUsage: "[working directory]",
 		Before: func(c *cli.Context) error {
-			err := util.SetupLogging(c.Bool("verbose"))
+			envFile, err := loadEnvFile(c, fs)
+			if err != nil {
+				return err
+			}
+
+			err = util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
 
 			if err != nil {
 				return err
 			}
 
 			util.Log.Info("Dynatrace Monitoring as Code v" + version.MonitoringAsCode)
+			logEnvFile(envFile)
 
-			return nil
+			if err := configureHttpClient(c, fs); err != nil {
+				return err
+			}
+
+			return tracing.Setup(c.String("trace-endpoint"))
 		},
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:    "verbose",
 				Aliases: []string{"v"},
 			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
 			&cli.PathFlag{
 				Name:      "environments",
 				Usage:     "Yaml file containing environment to deploy to",
@@ -241,9 +480,9 @@ func getDeployCommand(fs afero.Fs) cli.Command {
 				Required:  true,
 				TakesFile: true,
 			},
-			&cli.StringFlag{
+			&cli.StringSliceFlag{
 				Name:    "specific-environment",
-				Usage:   "Specific environment (from list) to deploy to",
+				Usage:   "Specific environment (from list) to deploy to. Can be repeated or comma-separated to deploy to several environments",
 				Aliases: []string{"s"},
 			},
 			&cli.StringFlag{
@@ -251,17 +490,129 @@ func getDeployCommand(fs afero.Fs) cli.Command {
 				Usage:   "Project configuration to deploy (also deploys any dependent configurations)",
 				Aliases: []string{"p"},
 			},
+			&cli.PathFlag{
+				Name:      "projects-file",
+				Usage:     "File listing the projects to deploy, separated by newlines or commas (also deploys any dependent configurations)",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:    "strict-projects",
+				Aliases: []string{"no-auto-dependencies"},
+				Usage:   "Only deploy the specified projects, and fail if they depend on other projects instead of deploying them too",
+			},
 			&cli.BoolFlag{
 				Name:    "dry-run",
 				Aliases: []string{"d"},
 				Usage:   "Switches to just validation instead of actual deployment",
 			},
+			&cli.BoolFlag{
+				Name:  "diff",
+				Usage: "With --dry-run, show the fields which would change in the configs which would be updated",
+			},
 			&cli.BoolFlag{
 				Name:    "continue-on-error",
 				Usage:   "Proceed deployment even if config upload fails",
 				Aliases: []string{"c"},
 			},
-		},
+			&cli.IntFlag{
+				Name:  "max-errors",
+				Usage: "Abort a deployment continuing on error once the given number of configs failed. 0 aborts on the first error, a negative number never aborts",
+				Value: -1,
+			},
+			&cli.IntFlag{
+				Name:    "parallel",
+				Usage:   "Number of configs deployed concurrently. Configs are only deployed after the configs they reference",
+				EnvVars: []string{"MONACO_PARALLEL"},
+				Value:   1,
+			},
+			&cli.PathFlag{
+				Name:      "report",
+				Usage:     "Writes a json report of all processed configs to the given file",
+				TakesFile: true,
+			},
+			&cli.PathFlag{
+				Name:      "metrics-file",
+				Usage:     "Writes metrics of the run in the Prometheus text format to the given file",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  "skip-env-check",
+				Usage: "Skip the check for missing environment variables referenced in configs before the deployment",
+			},
+			&cli.BoolFlag{
+				Name:  "skip-preflight",
+				Usage: "Skip the check that all environments are reachable and accept their tokens before the deployment",
+			},
+			&cli.PathFlag{
+				Name:      "id-cache",
+				Usage:     "Json file caching the ids of deployed configs, to update the same objects on subsequent deployments",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  "reset-id-cache",
+				Usage: "Ignore the ids of the id cache and overwrite it with the ids of this deployment",
+			},
+			&cli.BoolFlag{
+				Name:  "validate-schemas",
+				Usage: "Validate the rendered configs against the json schema of their config type",
+			},
+			&cli.PathFlag{
+				Name:      "schema-dir",
+				Usage:     "Directory containing json schemas named after the config type (e.g. dashboard.json), replacing the bundled ones. Enables schema validation",
+				TakesFile: true,
+			},
+			&cli.PathFlag{
+				Name:      "bundle",
+				Usage:     "Deploys the configs of a bundle created by the bundle command, instead of the projects in the working directory",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"force"},
+				Usage:   "Deploy to environments flagged as production without asking to confirm by typing their names",
+				EnvVars: []string{"MONACO_SKIP_CONFIRMATION"},
+			},
+			&cli.PathFlag{
+				Name:      "state-file",
+				Usage:     "File storing the checksums of the deployed configs. Configs unchanged since the last deployment are skipped",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  "ignore-state",
+				Usage: "Deploy all configs, including the ones unchanged since the last deployment according to the state file",
+			},
+			&cli.BoolFlag{
+				Name:  "refresh",
+				Usage: "Check that the objects of unchanged configs still exist in the environment, and deploy them again if not",
+			},
+			&cli.BoolFlag{
+				Name:  "allow-duplicate-names",
+				Usage: "Only warn about configs of the same project and api sharing their name, instead of failing before the deployment",
+			},
+			&cli.PathFlag{
+				Name:      "run-state",
+				Usage:     "Json file recording the configs deployed by a failed or interrupted deployment, which is removed once a deployment succeeds. Defaults to '" + deploy.DefaultRunStateFile + "' if --resume or --no-resume is set",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  "resume",
+				Usage: "Resume the deployment recorded in the run state, skipping the configs it deployed which are unchanged since",
+			},
+			&cli.BoolFlag{
+				Name:  "no-resume",
+				Usage: "Discard the run state of previous deployments, so all configs are deployed",
+			},
+			&cli.DurationFlag{
+				Name:  "resume-max-age",
+				Usage: "Age after which a run state is not resumed anymore, e.g. 12h (0 = never expires)",
+				Value: deploy.DefaultResumeMaxAge,
+			},
+			&cli.StringFlag{
+				Name:    "trace-endpoint",
+				Usage:   "OTLP endpoint of the OpenTelemetry collector receiving traces of the deployment, e.g. http://localhost:4318",
+				EnvVars: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+			},
+		}, append(httpClientFlags(), envFileFlags()...)...),
 		Action: func(ctx *cli.Context) error {
 			if ctx.NArg() > 1 {
 				util.Log.Error("Too many arguments! Either specify a relative path to the working directory, or omit it for using the current working directory.")
@@ -276,15 +627,8 @@ func getDeployCommand(fs afero.Fs) cli.Command {
 				workingDir = "."
 			}
 
-			return deploy.Deploy(
-				workingDir,
-				fs,
-				ctx.Path("environments"),
-				ctx.String("specific-environment"),
-				ctx.String("project"),
-				ctx.Bool("dry-run"),
-				ctx.Bool("continue-on-error"),
-			)
+			_, err := deploy.Deploy(ctx.Context, fs, workingDir, ctx.Path("environments"), deployOptions(ctx))
+			return err
 		},
 	}
 	return command
@@ -295,21 +639,40 @@ func getDownloadCommand(fs afero.Fs) cli.Command {
 		Usage:     "download the given environment",
 		UsageText: "download [command options] [working directory]",
 		Before: func(c *cli.Context) error {
-			err := util.SetupLogging(c.Bool("verbose"))
+			envFile, err := loadEnvFile(c, fs)
+			if err != nil {
+				return err
+			}
+
+			err = util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
 
 			if err != nil {
 				return err
 			}
 
 			util.Log.Info("Dynatrace Monitoring as Code v" + version.MonitoringAsCode)
+			logEnvFile(envFile)
 
-			return nil
+			return configureHttpClient(c, fs)
 		},
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:    "verbose",
 				Aliases: []string{"v"},
 			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
 			&cli.PathFlag{
 				Name:      "environments",
 				Usage:     "Yaml file containing environment to deploy to",
@@ -327,7 +690,39 @@ func getDownloadCommand(fs afero.Fs) cli.Command {
 				Usage:   "Comma separated list of API's to download ",
 				Aliases: []string{"p"},
 			},
-		},
+			&cli.StringFlag{
+				Name:  "exclude-api",
+				Usage: "Comma separated list of API's to skip during download. Takes precedence over downloadSpecificAPI",
+			},
+			&cli.IntFlag{
+				Name:    "parallel",
+				Usage:   "Number of configs downloaded concurrently",
+				EnvVars: []string{"MONACO_PARALLEL"},
+				Value:   1,
+			},
+			&cli.StringFlag{
+				Name:  "name-filter",
+				Usage: "Only download configs with a name matching this glob pattern, or regular expression if prefixed with 'regex:'",
+			},
+			&cli.StringSliceFlag{
+				Name:  "filter-by-tag",
+				Usage: "Only download configs carrying one of these tags, given as key or key:value. APIs whose configs don't carry tags are skipped",
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "Only download settings modified since this duration (e.g. 24h or 7d), RFC 3339 timestamp or date. Other APIs are downloaded in full",
+			},
+			&cli.PathFlag{
+				Name:      "output-dir",
+				Usage:     "Directory the download is written to instead of the working directory. It is created if missing and needs to be empty, unless --overwrite is set",
+				Aliases:   []string{"o"},
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  "overwrite",
+				Usage: "Download into the output directory even if it is not empty, replacing files of downloaded configs",
+			},
+		}, append(httpClientFlags(), envFileFlags()...)...),
 		Action: func(ctx *cli.Context) error {
 			var workingDir string
 
@@ -337,14 +732,748 @@ func getDownloadCommand(fs afero.Fs) cli.Command {
 				workingDir = "."
 			}
 
+			if outputDir := ctx.Path("output-dir"); outputDir != "" {
+				if ctx.Args().Present() {
+					return fmt.Errorf("the working directory and --output-dir can't be combined")
+				}
+				if err := download.PrepareOutputDir(fs, outputDir, ctx.Bool("overwrite")); err != nil {
+					return err
+				}
+				workingDir = outputDir
+			} else if ctx.Bool("overwrite") {
+				return fmt.Errorf("--overwrite requires --output-dir")
+			}
+
 			return download.GetConfigsFilterByEnvironment(
+				ctx.Context,
 				workingDir,
 				fs,
 				ctx.Path("environments"),
 				ctx.String("specific-environment"),
 				ctx.String("downloadSpecificAPI"),
+				ctx.String("exclude-api"),
+				ctx.Int("parallel"),
+				ctx.String("name-filter"),
+				ctx.StringSlice("filter-by-tag"),
+				ctx.String("since"),
+				util.DefaultLogger(),
 			)
 		},
 	}
 	return command
 }
+
+func getDiffCommand(fs afero.Fs) cli.Command {
+	command := cli.Command{
+		Name:      "diff",
+		Usage:     "shows the differences between the local configs and the configs of the given environment",
+		UsageText: "diff [command options] [working directory]",
+		ArgsUsage: "[working directory]",
+		Before: func(c *cli.Context) error {
+			envFile, err := loadEnvFile(c, fs)
+			if err != nil {
+				return err
+			}
+
+			err = util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
+
+			if err != nil {
+				return err
+			}
+
+			util.Log.Info("Dynatrace Monitoring as Code v" + version.MonitoringAsCode)
+			logEnvFile(envFile)
+
+			return configureHttpClient(c, fs)
+		},
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
+			&cli.PathFlag{
+				Name:      "environments",
+				Usage:     "Yaml file containing environments to compare with",
+				Aliases:   []string{"e"},
+				Required:  true,
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:    "specific-environment",
+				Usage:   "Specific environment (from list) to compare with",
+				Aliases: []string{"s"},
+			},
+			&cli.StringFlag{
+				Name:    "project",
+				Usage:   "Project configuration to compare (also compares any dependent configurations)",
+				Aliases: []string{"p"},
+			},
+			&cli.StringSliceFlag{
+				Name:        "ignore-fields",
+				Usage:       "Fields excluded from the comparison, either by name or by path (e.g. tiles.bounds)",
+				DefaultText: strings.Join(diff.DefaultIgnoredFields, ", "),
+			},
+			&cli.BoolFlag{
+				Name:  "fail-on-diff",
+				Usage: "Exit with a non-zero exit code if any difference is found",
+			},
+		}, append(httpClientFlags(), envFileFlags()...)...),
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 1 {
+				util.Log.Error("Too many arguments! Either specify a relative path to the working directory, or omit it for using the current working directory.")
+				cli.ShowAppHelpAndExit(ctx, 1)
+			}
+
+			var workingDir string
+
+			if ctx.Args().Present() {
+				workingDir = ctx.Args().First()
+			} else {
+				workingDir = "."
+			}
+
+			ignoredFields := diff.DefaultIgnoredFields
+			if ctx.IsSet("ignore-fields") {
+				ignoredFields = ctx.StringSlice("ignore-fields")
+			}
+
+			return diff.Diff(
+				workingDir,
+				fs,
+				ctx.Path("environments"),
+				ctx.String("specific-environment"),
+				ctx.String("project"),
+				ignoredFields,
+				ctx.Bool("fail-on-diff"),
+			)
+		},
+	}
+	return command
+}
+
+// httpClientFlags returns the flags configuring the communication with the Dynatrace API, which are shared by all
+// commands talking to an environment
+func httpClientFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.DurationFlag{
+			Name:    "http-timeout",
+			Usage:   "Timeout of a single request to the Dynatrace API, e.g. 30s or 2m (0 = no timeout)",
+			EnvVars: []string{"MONACO_HTTP_TIMEOUT"},
+			Value:   rest.DefaultHttpTimeout,
+		},
+		&cli.Float64Flag{
+			Name:    "requests-per-second",
+			Usage:   "Maximal number of requests per second sent to the Dynatrace API (0 = unlimited)",
+			EnvVars: []string{"MONACO_MAX_RPS"},
+		},
+		&cli.StringFlag{
+			Name:    "proxy",
+			Aliases: []string{"proxy-url"},
+			Usage:   "Proxy used for all requests to the Dynatrace API, e.g. http://proxy.example.com:8080. Takes precedence over HTTPS_PROXY and HTTP_PROXY",
+			EnvVars: []string{"MONACO_PROXY_URL"},
+		},
+		&cli.StringFlag{
+			Name:    "proxy-user",
+			Usage:   "User authenticating at the proxy using basic auth, instead of credentials embedded in the proxy url",
+			EnvVars: []string{"MONACO_PROXY_USER"},
+		},
+		&cli.StringFlag{
+			Name:    "proxy-password",
+			Usage:   "Password of the proxy user. Prefer the environment variable, so the password doesn't show up in the process list",
+			EnvVars: []string{"MONACO_PROXY_PASSWORD"},
+		},
+		&cli.PathFlag{
+			Name:      "ca-cert",
+			Usage:     "PEM file containing additional CA certificates to trust, e.g. for Dynatrace Managed environments using a private CA",
+			EnvVars:   []string{"MONACO_CA_CERT"},
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:    "user-agent",
+			Usage:   "Replaces the User-Agent monaco/<version> of all requests to the Dynatrace API, e.g. to identify the pipeline running monaco",
+			EnvVars: []string{"MONACO_USER_AGENT"},
+		},
+		&cli.StringFlag{
+			Name:    "request-id-header",
+			Usage:   "Header carrying the id of each request, which is also written to the request log, to correlate requests with the access logs of the environment",
+			EnvVars: []string{"MONACO_REQUEST_ID_HEADER"},
+			Value:   rest.DefaultRequestIdHeader,
+		},
+		&cli.StringFlag{
+			Name:        "run-id",
+			Usage:       "Id sent in the " + rest.RunIdHeader + " header of all requests of the run, e.g. the id of the pipeline running monaco",
+			EnvVars:     []string{"MONACO_RUN_ID"},
+			DefaultText: "unique id of each run",
+		},
+	}
+}
+
+// envFileFlags returns the flags loading environment variables from an env file, which are shared by all commands
+// rendering templates
+func envFileFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.PathFlag{
+			Name:      "env-file",
+			Usage:     "File of KEY=VALUE lines defining environment variables, e.g. for templates and tokens (default: .env in the working directory, if it exists)",
+			EnvVars:   []string{"MONACO_ENV_FILE"},
+			TakesFile: true,
+		},
+		&cli.BoolFlag{
+			Name:  "override-env",
+			Usage: "Variables of the env file override environment variables which are already set",
+		},
+	}
+}
+
+// loadEnvFile sets the environment variables of the env file given by --env-file or, if none is given, of the .env
+// file in the working directory. It returns the loaded file, which is empty if no file was loaded.
+func loadEnvFile(ctx *cli.Context, fs afero.Fs) (string, error) {
+	file := ctx.Path("env-file")
+
+	if file == "" {
+		workingDir := "."
+		if ctx.Args().Present() {
+			workingDir = ctx.Args().First()
+		}
+
+		file = filepath.Join(workingDir, ".env")
+		if exists, err := afero.Exists(fs, file); err != nil || !exists {
+			return "", err
+		}
+	}
+
+	_, err := util.LoadEnvFile(fs, file, ctx.Bool("override-env"))
+	if err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+func logEnvFile(envFile string) {
+	if envFile != "" {
+		util.Log.Info("Loaded environment variables from %s", envFile)
+	}
+}
+
+func configureHttpClient(ctx *cli.Context, fs afero.Fs) error {
+	if err := rest.SetHttpTimeout(ctx.Duration("http-timeout")); err != nil {
+		return err
+	}
+
+	if err := rest.SetMaxRequestsPerSecond(ctx.Float64("requests-per-second"), util.DefaultLogger()); err != nil {
+		return err
+	}
+
+	if err := rest.SetProxy(ctx.String("proxy")); err != nil {
+		return err
+	}
+
+	if err := rest.SetProxyCredentials(ctx.String("proxy-user"), ctx.String("proxy-password")); err != nil {
+		return err
+	}
+
+	if err := rest.SetUserAgent(ctx.String("user-agent")); err != nil {
+		return err
+	}
+
+	if err := rest.SetRequestIdHeader(ctx.String("request-id-header")); err != nil {
+		return err
+	}
+
+	if err := rest.SetRunId(ctx.String("run-id")); err != nil {
+		return err
+	}
+	util.Log.Debug("Requests are sent with run id %s", rest.RunId())
+
+	if err := rest.SetCaCertificates(fs, ctx.Path("ca-cert"), util.DefaultLogger()); err != nil {
+		return err
+	}
+
+	// secret backends are connected the same way as Dynatrace environments
+	secret.SetHttpClientFactory(rest.NewUnloggedHttpClient)
+	return nil
+}
+
+func getValidateCommand(fs afero.Fs) cli.Command {
+	command := cli.Command{
+		Name:      "validate",
+		Usage:     "validates the configs of the given environments, without connecting to them",
+		UsageText: "validate [command options] [working directory]",
+		ArgsUsage: "[working directory]",
+		Before: func(c *cli.Context) error {
+			envFile, err := loadEnvFile(c, fs)
+			if err != nil {
+				return err
+			}
+
+			err = util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
+
+			if err != nil {
+				return err
+			}
+
+			util.Log.Info("Dynatrace Monitoring as Code v" + version.MonitoringAsCode)
+			logEnvFile(envFile)
+
+			return nil
+		},
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
+			&cli.PathFlag{
+				Name:      "environments",
+				Usage:     "Yaml file containing environments to validate the configs for",
+				Aliases:   []string{"e"},
+				Required:  true,
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:    "specific-environment",
+				Usage:   "Specific environment (from list) to validate the configs for",
+				Aliases: []string{"s"},
+			},
+			&cli.StringFlag{
+				Name:    "project",
+				Usage:   "Project configuration to validate (also validates any dependent configurations)",
+				Aliases: []string{"p"},
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Fail validation on template files not referenced by any config and on configs referencing missing template files, instead of warning",
+			},
+			&cli.BoolFlag{
+				Name:  "validate-schemas",
+				Usage: "Validate the rendered configs against the json schema of their config type",
+			},
+			&cli.PathFlag{
+				Name:      "schema-dir",
+				Usage:     "Directory containing json schemas named after the config type (e.g. dashboard.json), replacing the bundled ones. Enables schema validation",
+				TakesFile: true,
+			},
+		}, envFileFlags()...),
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 1 {
+				util.Log.Error("Too many arguments! Either specify a relative path to the working directory, or omit it for using the current working directory.")
+				cli.ShowAppHelpAndExit(ctx, 1)
+			}
+
+			var workingDir string
+
+			if ctx.Args().Present() {
+				workingDir = ctx.Args().First()
+			} else {
+				workingDir = "."
+			}
+
+			return deploy.Validate(
+				workingDir,
+				fs,
+				ctx.Path("environments"),
+				ctx.String("specific-environment"),
+				ctx.String("project"),
+				ctx.Bool("strict"),
+				ctx.Bool("validate-schemas"),
+				ctx.Path("schema-dir"),
+				util.DefaultLogger(),
+			)
+		},
+	}
+	return command
+}
+
+func getListCommand(fs afero.Fs) cli.Command {
+	command := cli.Command{
+		Name:      "list",
+		Usage:     "lists the projects and configs found in the working directory and the references between them, without connecting to any environment",
+		UsageText: "list [command options] [working directory]",
+		ArgsUsage: "[working directory]",
+		Before: func(c *cli.Context) error {
+			return util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
+		},
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
+			&cli.StringFlag{
+				Name:    "project",
+				Usage:   "Project to list (also lists any projects it depends on)",
+				Aliases: []string{"p"},
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format, either text or json",
+				Value: list.FormatText,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 1 {
+				util.Log.Error("Too many arguments! Either specify a relative path to the working directory, or omit it for using the current working directory.")
+				cli.ShowAppHelpAndExit(ctx, 1)
+			}
+
+			var workingDir string
+
+			if ctx.Args().Present() {
+				workingDir = ctx.Args().First()
+			} else {
+				workingDir = "."
+			}
+
+			// the console log shares stdout with the listing, so only problems are logged to keep the json parsable
+			if ctx.String("format") == list.FormatJson && !ctx.Bool("verbose") {
+				util.Log.Level(lumber.WARN)
+			}
+
+			return list.List(
+				workingDir,
+				fs,
+				ctx.String("project"),
+				ctx.String("format"),
+				os.Stdout,
+			)
+		},
+	}
+	return command
+}
+
+func getGraphCommand(fs afero.Fs) cli.Command {
+	command := cli.Command{
+		Name:      "graph",
+		Usage:     "writes the dependency graph of the configs found in the working directory in the DOT format of Graphviz or as Mermaid flowchart, without connecting to any environment",
+		UsageText: "graph [command options] [working directory]",
+		ArgsUsage: "[working directory]",
+		Before: func(c *cli.Context) error {
+			return util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
+		},
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
+			&cli.StringFlag{
+				Name:    "project",
+				Usage:   "Project to graph (also graphs any projects it depends on)",
+				Aliases: []string{"p"},
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format, either dot or mermaid",
+				Value: graph.FormatDot,
+			},
+			&cli.PathFlag{
+				Name:      "output",
+				Usage:     "Writes the graph to the given file instead of stdout",
+				Aliases:   []string{"o"},
+				TakesFile: true,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 1 {
+				util.Log.Error("Too many arguments! Either specify a relative path to the working directory, or omit it for using the current working directory.")
+				cli.ShowAppHelpAndExit(ctx, 1)
+			}
+
+			var workingDir string
+
+			if ctx.Args().Present() {
+				workingDir = ctx.Args().First()
+			} else {
+				workingDir = "."
+			}
+
+			outputFile := ctx.Path("output")
+
+			if outputFile == "" {
+				// the console log shares stdout with the graph, so only problems are logged to keep the graph renderable
+				if !ctx.Bool("verbose") {
+					util.Log.Level(lumber.WARN)
+				}
+
+				return graph.Write(workingDir, fs, ctx.String("project"), ctx.String("format"), os.Stdout)
+			}
+
+			var out bytes.Buffer
+			if err := graph.Write(workingDir, fs, ctx.String("project"), ctx.String("format"), &out); err != nil {
+				return err
+			}
+
+			if err := afero.WriteFile(fs, outputFile, out.Bytes(), 0644); err != nil {
+				return fmt.Errorf("failed to write graph to %s: %w", outputFile, err)
+			}
+
+			util.Log.Info("Wrote graph to %s", outputFile)
+			return nil
+		},
+	}
+	return command
+}
+
+func getBundleCommand(fs afero.Fs) cli.Command {
+	command := cli.Command{
+		Name:      "bundle",
+		Usage:     "renders the configs for an environment into a bundle, which can be deployed without the projects using deploy --bundle",
+		UsageText: "bundle [command options] [working directory]",
+		ArgsUsage: "[working directory]",
+		Before: func(c *cli.Context) error {
+			envFile, err := loadEnvFile(c, fs)
+			if err != nil {
+				return err
+			}
+
+			if err := util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps")); err != nil {
+				return err
+			}
+
+			logEnvFile(envFile)
+			return nil
+		},
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
+			&cli.PathFlag{
+				Name:      "environments",
+				Usage:     "Yaml file containing the environment to render the configs for",
+				Aliases:   []string{"e"},
+				Required:  true,
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:    "specific-environment",
+				Usage:   "Specific environment (from list) to render the configs for. Required if the file contains several environments",
+				Aliases: []string{"s"},
+			},
+			&cli.StringFlag{
+				Name:    "project",
+				Usage:   "Project configuration to bundle (also bundles any dependent configurations)",
+				Aliases: []string{"p"},
+			},
+			&cli.PathFlag{
+				Name:      "output",
+				Usage:     "Zip file the bundle is written to",
+				Aliases:   []string{"o"},
+				Value:     "bundle.zip",
+				TakesFile: true,
+			},
+		}, envFileFlags()...),
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 1 {
+				util.Log.Error("Too many arguments! Either specify a relative path to the working directory, or omit it for using the current working directory.")
+				cli.ShowAppHelpAndExit(ctx, 1)
+			}
+
+			var workingDir string
+
+			if ctx.Args().Present() {
+				workingDir = ctx.Args().First()
+			} else {
+				workingDir = "."
+			}
+
+			return bundle.Create(
+				workingDir,
+				fs,
+				ctx.Path("environments"),
+				ctx.String("specific-environment"),
+				ctx.String("project"),
+				ctx.Path("output"),
+			)
+		},
+	}
+	return command
+}
+
+func getGenerateCommand(fs afero.Fs) cli.Command {
+	projectCommand := cli.Command{
+		Name:      "project",
+		Usage:     "creates a new project with example configs and, if missing, an environments file in the working directory",
+		UsageText: "generate project [command options] <project name> [working directory]",
+		ArgsUsage: "<project name> [working directory]",
+		Before: func(c *cli.Context) error {
+			return util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
+		},
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
+			&cli.StringSliceFlag{
+				Name:    "api",
+				Usage:   "Api to create an example config of, can be repeated or contain a comma separated list of apis (default: " + strings.Join(generate.DefaultApis, ", ") + ")",
+				Aliases: []string{"a"},
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() < 1 || ctx.NArg() > 2 {
+				util.Log.Error("Wrong number of arguments! Specify the name of the project, optionally followed by a relative path to the working directory.")
+				cli.ShowSubcommandHelpAndExit(ctx, 1)
+			}
+
+			workingDir := "."
+			if ctx.NArg() == 2 {
+				workingDir = ctx.Args().Get(1)
+			}
+
+			return generate.Project(
+				workingDir,
+				fs,
+				ctx.Args().First(),
+				ctx.StringSlice("api"),
+			)
+		},
+	}
+
+	command := cli.Command{
+		Name:        "generate",
+		Usage:       "generates the files of new projects",
+		Subcommands: []*cli.Command{&projectCommand},
+	}
+	return command
+}
+
+func getConvertCommand(fs afero.Fs) cli.Command {
+	command := cli.Command{
+		Name:      "convert",
+		Usage:     "converts the projects in the working directory from the legacy config format to the current one",
+		UsageText: "convert [command options] [working directory]",
+		ArgsUsage: "[working directory]",
+		Before: func(c *cli.Context) error {
+			return util.SetupLogging(c.Bool("verbose"), c.Bool("quiet"), c.Bool("no-color"), c.Bool("timestamps"))
+		},
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   "Only log warnings and errors to the console, the log file still contains all messages",
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Don't color the console output, which is otherwise colored if it is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "timestamps",
+				Usage: "Prefix log lines with ISO-8601 times in milliseconds instead of local times in seconds",
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Usage:   "Show the changes converting the projects, without changing any file",
+				Aliases: []string{"d"},
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 1 {
+				util.Log.Error("Too many arguments! Either specify a relative path to the working directory, or omit it for using the current working directory.")
+				cli.ShowAppHelpAndExit(ctx, 1)
+			}
+
+			var workingDir string
+
+			if ctx.Args().Present() {
+				workingDir = ctx.Args().First()
+			} else {
+				workingDir = "."
+			}
+
+			conversion, err := project.ConvertLegacyConfigs(fs, filepath.Clean(workingDir), ctx.Bool("dry-run"))
+			if err != nil {
+				return err
+			}
+
+			if err := conversion.Write(os.Stdout, ctx.Bool("dry-run")); err != nil {
+				return err
+			}
+
+			if len(conversion.Issues) > 0 {
+				return fmt.Errorf("%d legacy constructs must be converted manually", len(conversion.Issues))
+			}
+			return nil
+		},
+	}
+	return command
+}
@@ -39,10 +39,10 @@ func TestIntegrationMultiProject(t *testing.T) {
 
 	RunIntegrationWithCleanup(t, multiProjectFolder, multiProjectEnvironmentsFile, "MultiProject", func(fs afero.Fs) {
 
-		environments, errs := environment.LoadEnvironmentList("", multiProjectEnvironmentsFile, fs)
+		environments, errs := environment.LoadEnvironmentList("", multiProjectEnvironmentsFile, fs, util.DefaultLogger())
 		assert.Check(t, len(errs) == 0, "didn't expect errors loading test environments")
 
-		projects, err := project.LoadProjectsToDeploy(fs, "", api.NewApis(), multiProjectFolder)
+		projects, err := project.LoadProjectsToDeploy(fs, "", api.NewApis(), multiProjectFolder, util.DefaultLogger())
 		assert.NilError(t, err)
 
 		statusCode := RunImpl([]string{
@@ -88,10 +88,10 @@ func TestIntegrationMultiProjectSingleProject(t *testing.T) {
 
 	RunIntegrationWithCleanup(t, multiProjectFolder, multiProjectEnvironmentsFile, "MultiProjectSingleProject", func(fs afero.Fs) {
 
-		environments, errs := environment.LoadEnvironmentList("", multiProjectEnvironmentsFile, fs)
+		environments, errs := environment.LoadEnvironmentList("", multiProjectEnvironmentsFile, fs, util.DefaultLogger())
 		FailOnAnyError(errs, "loading of environments failed")
 
-		projects, err := project.LoadProjectsToDeploy(fs, "star-trek", api.NewApis(), multiProjectFolder)
+		projects, err := project.LoadProjectsToDeploy(fs, "star-trek", api.NewApis(), multiProjectFolder, util.DefaultLogger())
 		assert.NilError(t, err)
 
 		assert.Equal(t, projects[0].GetId(), "test-resources/integration-multi-project/cinema-infrastructure", "Check if dependent project `cinema-infrastructure` is loaded and will be deployed first.")
@@ -39,10 +39,10 @@ func TestIntegrationMultiEnvironment(t *testing.T) {
 
 	RunIntegrationWithCleanup(t, folder, environmentsFile, "MultiEnvironment", func(fs afero.Fs) {
 
-		environments, errs := environment.LoadEnvironmentList("", environmentsFile, fs)
+		environments, errs := environment.LoadEnvironmentList("", environmentsFile, fs, util.DefaultLogger())
 		assert.Check(t, len(errs) == 0, "didn't expect errors loading test environments")
 
-		projects, err := project.LoadProjectsToDeploy(fs, "", api.NewApis(), folder)
+		projects, err := project.LoadProjectsToDeploy(fs, "", api.NewApis(), folder, util.DefaultLogger())
 		assert.NilError(t, err)
 
 		statusCode := RunImpl([]string{
@@ -75,10 +75,10 @@ func TestIntegrationMultiEnvironmentSingleProject(t *testing.T) {
 
 	RunIntegrationWithCleanup(t, folder, environmentsFile, "MultiEnvironmentSingleProject", func(fs afero.Fs) {
 
-		environments, errs := environment.LoadEnvironmentList("", environmentsFile, fs)
+		environments, errs := environment.LoadEnvironmentList("", environmentsFile, fs, util.DefaultLogger())
 		FailOnAnyError(errs, "loading of environments failed")
 
-		projects, err := project.LoadProjectsToDeploy(fs, "cinema-infrastructure", api.NewApis(), folder)
+		projects, err := project.LoadProjectsToDeploy(fs, "cinema-infrastructure", api.NewApis(), folder, util.DefaultLogger())
 		assert.NilError(t, err)
 
 		statusCode := RunImpl([]string{
@@ -99,10 +99,10 @@ func TestIntegrationMultiEnvironmentSingleProjectWithDependency(t *testing.T) {
 
 	RunIntegrationWithCleanup(t, folder, environmentsFile, "MultiEnvironmentSingleProjectWithDependency", func(fs afero.Fs) {
 
-		environments, errs := environment.LoadEnvironmentList("", environmentsFile, fs)
+		environments, errs := environment.LoadEnvironmentList("", environmentsFile, fs, util.DefaultLogger())
 		FailOnAnyError(errs, "loading of environments failed")
 
-		projects, err := project.LoadProjectsToDeploy(fs, "star-trek", api.NewApis(), folder)
+		projects, err := project.LoadProjectsToDeploy(fs, "star-trek", api.NewApis(), folder, util.DefaultLogger())
 		assert.NilError(t, err)
 
 		assert.Check(t, len(projects) == 2, "Projects should be star-trek and the dependency cinema-infrastructure")
@@ -125,10 +125,10 @@ func TestIntegrationMultiEnvironmentSingleEnvironment(t *testing.T) {
 
 	RunIntegrationWithCleanup(t, folder, environmentsFile, "MultiEnvironmentSingleEnvironment", func(fs afero.Fs) {
 
-		environments, errs := environment.LoadEnvironmentList("", environmentsFile, fs)
+		environments, errs := environment.LoadEnvironmentList("", environmentsFile, fs, util.DefaultLogger())
 		FailOnAnyError(errs, "loading of environments failed")
 
-		projects, err := project.LoadProjectsToDeploy(fs, "star-trek", api.NewApis(), folder)
+		projects, err := project.LoadProjectsToDeploy(fs, "star-trek", api.NewApis(), folder, util.DefaultLogger())
 		assert.NilError(t, err)
 
 		// remove environment odt69781, just keep dav48679
@@ -0,0 +1,54 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+// interruptedStatusCode is returned if a run was interrupted by a signal, following the shell convention of 128 + SIGINT
+const interruptedStatusCode = 130
+
+// cancelOnSignal returns a context which is cancelled on the first SIGINT or SIGTERM. Commands then stop starting new
+// work and finish the requests in progress. A second signal terminates monaco immediately, as the default handling
+// is restored after the first one. The returned function releases the signal handling.
+func cancelOnSignal() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case received := <-signals:
+			signal.Stop(signals)
+			util.Log.Warn("Received %s: finishing the requests in progress. Send it again to terminate immediately", received)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
@@ -29,20 +29,43 @@ var apiMap = map[string]apiInput{
 	// Early adopter API !
 	"alerting-profile": {
 		apiPath: "/api/config/v1/alertingProfiles",
+		constraints: []Constraint{
+			{Field: "displayName", Required: true, MaxLength: 100},
+			{Field: "rules[].severityLevel", Required: true, Enum: []string{"AVAILABILITY", "CUSTOM_ALERT", "ERROR", "MONITORING_UNAVAILABLE", "PERFORMANCE", "RESOURCE_CONTENTION"}},
+			{Field: "rules[].tagFilter.includeMode", Enum: []string{"INCLUDE_ALL", "INCLUDE_ANY", "NONE"}},
+		},
 	},
 	"management-zone": {
 		apiPath: "/api/config/v1/managementZones",
+		constraints: []Constraint{
+			{Field: "name", Required: true, MaxLength: 500},
+			{Field: "rules[].type", Required: true},
+		},
 	},
 	"auto-tag": {
 		apiPath: "/api/config/v1/autoTags",
+		constraints: []Constraint{
+			{Field: "name", Required: true, MaxLength: 500},
+			{Field: "rules[].type", Required: true},
+		},
 	},
 	// Early adopter API !
 	"dashboard": {
 		apiPath:                      "/api/config/v1/dashboards",
 		propertyNameOfGetAllResponse: "dashboards",
+		constraints: []Constraint{
+			{Field: "dashboardMetadata.name", Required: true, MaxLength: 500},
+			{Field: "tiles", Required: true},
+			{Field: "tiles[].tileType", Required: true},
+		},
 	},
 	"notification": {
 		apiPath: "/api/config/v1/notifications",
+		constraints: []Constraint{
+			{Field: "name", Required: true},
+			{Field: "type", Required: true, Enum: []string{"ANSIBLETOWER", "EMAIL", "JIRA", "OPS_GENIE", "PAGER_DUTY", "SERVICE_NOW", "SLACK", "TRELLO", "VICTOROPS", "WEBHOOK", "XMATTERS"}},
+			{Field: "alertingProfile", Required: true},
+		},
 	},
 	"extension": {
 		apiPath:                      "/api/config/v1/extensions",
@@ -136,6 +159,12 @@ var apiMap = map[string]apiInput{
 	},
 	"maintenance-window": {
 		apiPath: "/api/config/v1/maintenanceWindows",
+		constraints: []Constraint{
+			{Field: "name", Required: true},
+			{Field: "type", Required: true, Enum: []string{"PLANNED", "UNPLANNED"}},
+			{Field: "suppression", Required: true, Enum: []string{"DETECT_PROBLEMS_AND_ALERT", "DETECT_PROBLEMS_DONT_ALERT", "DONT_DETECT_PROBLEMS"}},
+			{Field: "schedule.recurrenceType", Required: true, Enum: []string{"DAILY", "MONTHLY", "ONCE", "WEEKLY"}},
+		},
 	},
 	"request-naming-service": {
 		apiPath: "/api/config/v1/service/requestNaming",
@@ -146,6 +175,11 @@ var apiMap = map[string]apiInput{
 	"slo": {
 		apiPath:                      "/api/v2/slo",
 		propertyNameOfGetAllResponse: "slo",
+		constraints: []Constraint{
+			{Field: "name", Required: true, MaxLength: 200},
+			{Field: "evaluationType", Required: true, Enum: []string{"AGGREGATE"}},
+			{Field: "timeframe", Required: true},
+		},
 	},
 
 	// Early adopter API !
@@ -239,39 +273,102 @@ var apiMap = map[string]apiInput{
 		apiPath:                  "/api/config/v1/geographicRegions/ipAddressMappings",
 		isSingleConfigurationApi: true,
 	},
+
+	// Cluster APIs of Dynatrace Managed, deployed to the managed-cluster-url of the environment
+	"cluster-smtp": {
+		apiPath:                  "/api/v1.0/onpremise/smtp",
+		isSingleConfigurationApi: true,
+		isClusterApi:             true,
+	},
+
+	// Settings 2.0 objects of all schemas, identified by their schemaId and scope instead of a name
+	"settings": {
+		apiPath:                      "/api/v2/settings/objects",
+		propertyNameOfGetAllResponse: "items",
+		isSettingsApi:                true,
+	},
+
+	// Automation APIs of the Dynatrace platform, whose objects are identified by an id derived from their config
+	"workflow": {
+		apiPath:         "/platform/automation/v1/workflows",
+		isAutomationApi: true,
+		constraints: []Constraint{
+			{Field: "title", Required: true, MaxLength: 200},
+		},
+	},
+	"scheduling-rule": {
+		apiPath:         "/platform/automation/v1/scheduling-rules",
+		isAutomationApi: true,
+		constraints: []Constraint{
+			{Field: "title", Required: true, MaxLength: 200},
+			{Field: "ruleType", Required: true, Enum: []string{"fixed_offset", "grouping", "recurrence", "relative_offset"}},
+		},
+	},
 }
 
 var standardApiPropertyNameOfGetAllResponse = "values"
 
+// defaultIdPropertyName is the property holding the ids of listed objects, unless an api defines another id-field
+const defaultIdPropertyName = "id"
+
 type Api interface {
 	GetUrl(environment environment.Environment) string
 	GetUrlFromEnvironmentUrl(environmentUrl string) string
 	GetId() string
 	GetApiPath() string
 	GetPropertyNameOfGetAllResponse() string
+	// GetIdPropertyName returns the property holding the id of the objects listed by the API, which is "id" unless
+	// the API is defined with another id-field
+	GetIdPropertyName() string
 	IsStandardApi() bool
 	IsSingleConfigurationApi() bool
+	// IsPlatformApi returns true, if the API requires OAuth authentication (Bearer token) instead of an API token
+	IsPlatformApi() bool
+	// IsClusterApi returns true, if the API is a cluster API of Dynatrace Managed, which is accessed using the url and
+	// token of the cluster instead of the environment
+	IsClusterApi() bool
+	// IsSettingsApi returns true, if the API manages Settings 2.0 objects, whose configs define the schemaId and scope
+	// of the object
+	IsSettingsApi() bool
+	// IsAutomationApi returns true, if the API is an automation API of the Dynatrace platform, e.g. for workflows. Its
+	// objects are identified by an id monaco derives from their config, instead of their name.
+	IsAutomationApi() bool
+	// GetConstraints returns the constraints the payloads of the API must satisfy, see CheckConstraints
+	GetConstraints() []Constraint
 	NewIdValue() Value
 }
 
 type apiInput struct {
 	apiPath                      string
 	propertyNameOfGetAllResponse string
+	idPropertyName               string
 	isSingleConfigurationApi     bool
+	isPlatformApi                bool
+	isClusterApi                 bool
+	isSettingsApi                bool
+	isAutomationApi              bool
+	// constraints are checked when configs of the api are validated, see CheckConstraints
+	constraints []Constraint
 }
 
 type apiImpl struct {
 	id                           string
 	apiPath                      string
 	propertyNameOfGetAllResponse string
+	idPropertyName               string
 	isSingleConfigurationApi     bool
+	isPlatformApi                bool
+	isClusterApi                 bool
+	isSettingsApi                bool
+	isAutomationApi              bool
+	constraints                  []Constraint
 }
 
 func NewApis() map[string]Api {
 
 	apis := make(map[string]Api)
 
-	for id, details := range apiMap {
+	for id, details := range apiInputs() {
 		apis[id] = newApi(id, details)
 	}
 
@@ -279,6 +376,29 @@ func NewApis() map[string]Api {
 }
 
 func newApi(id string, input apiInput) Api {
+	a := newApiOfKind(id, input)
+	a.(*apiImpl).constraints = input.constraints
+	a.(*apiImpl).idPropertyName = input.idPropertyName
+	return a
+}
+
+func newApiOfKind(id string, input apiInput) Api {
+	if input.isAutomationApi {
+		return NewAutomationApi(id, input.apiPath)
+	}
+
+	if input.isPlatformApi {
+		return NewPlatformApi(id, input.apiPath, input.propertyNameOfGetAllResponse)
+	}
+
+	if input.isClusterApi {
+		return NewClusterApi(id, input.apiPath, input.propertyNameOfGetAllResponse, input.isSingleConfigurationApi)
+	}
+
+	if input.isSettingsApi {
+		return NewSettingsApi(id, input.apiPath)
+	}
+
 	if input.isSingleConfigurationApi {
 		return NewSingleConfigurationApi(id, input.apiPath)
 	}
@@ -300,6 +420,60 @@ func NewSingleConfigurationApi(id string, apiPath string) Api {
 	return NewApi(id, apiPath, "", true)
 }
 
+// NewPlatformApi creates an API of the Dynatrace platform, which requires OAuth authentication instead of an API
+// token. If propertyNameOfGetAllResponse is empty, "values" is used.
+func NewPlatformApi(id string, apiPath string, propertyNameOfGetAllResponse string) Api {
+	if propertyNameOfGetAllResponse == "" {
+		propertyNameOfGetAllResponse = standardApiPropertyNameOfGetAllResponse
+	}
+
+	return &apiImpl{
+		id:                           id,
+		apiPath:                      apiPath,
+		propertyNameOfGetAllResponse: propertyNameOfGetAllResponse,
+		isPlatformApi:                true,
+	}
+}
+
+// NewClusterApi creates a cluster API of Dynatrace Managed, which is accessed using the url and token of the cluster.
+// If propertyNameOfGetAllResponse is empty, "values" is used for APIs which are not single configuration APIs.
+func NewClusterApi(id string, apiPath string, propertyNameOfGetAllResponse string, isSingleConfigurationApi bool) Api {
+	if propertyNameOfGetAllResponse == "" && !isSingleConfigurationApi {
+		propertyNameOfGetAllResponse = standardApiPropertyNameOfGetAllResponse
+	}
+
+	return &apiImpl{
+		id:                           id,
+		apiPath:                      apiPath,
+		propertyNameOfGetAllResponse: propertyNameOfGetAllResponse,
+		isSingleConfigurationApi:     isSingleConfigurationApi,
+		isClusterApi:                 true,
+	}
+}
+
+// NewSettingsApi creates an API for Settings 2.0 objects. Objects are listed per schema, and deployed to the schemaId
+// and scope defined by their config.
+func NewSettingsApi(id string, apiPath string) Api {
+	return &apiImpl{
+		id:                           id,
+		apiPath:                      apiPath,
+		propertyNameOfGetAllResponse: "items",
+		isSettingsApi:                true,
+	}
+}
+
+// NewAutomationApi creates an automation API of the Dynatrace platform, which requires OAuth authentication. Objects
+// are listed from the results of its responses, and deployed to the id derived from their config.
+func NewAutomationApi(id string, apiPath string) Api {
+	return &apiImpl{
+		id:                           id,
+		apiPath:                      apiPath,
+		propertyNameOfGetAllResponse: "results",
+		isPlatformApi:                true,
+		isAutomationApi:              true,
+	}
+}
+
 func NewApi(id string, apiPath string, propertyNameOfGetAllResponse string, isSingleConfigurationApi bool) Api {
 
 	// TODO log warning if the user tries to create an API with a id not present in map above
@@ -333,6 +507,13 @@ func (a *apiImpl) GetPropertyNameOfGetAllResponse() string {
 	return a.propertyNameOfGetAllResponse
 }
 
+func (a *apiImpl) GetIdPropertyName() string {
+	if a.idPropertyName == "" {
+		return defaultIdPropertyName
+	}
+	return a.idPropertyName
+}
+
 func (a *apiImpl) IsStandardApi() bool {
 	return a.propertyNameOfGetAllResponse == standardApiPropertyNameOfGetAllResponse
 }
@@ -341,6 +522,26 @@ func (a *apiImpl) IsSingleConfigurationApi() bool {
 	return a.isSingleConfigurationApi
 }
 
+func (a *apiImpl) IsPlatformApi() bool {
+	return a.isPlatformApi
+}
+
+func (a *apiImpl) IsClusterApi() bool {
+	return a.isClusterApi
+}
+
+func (a *apiImpl) IsSettingsApi() bool {
+	return a.isSettingsApi
+}
+
+func (a *apiImpl) IsAutomationApi() bool {
+	return a.isAutomationApi
+}
+
+func (a *apiImpl) GetConstraints() []Constraint {
+	return a.constraints
+}
+
 // Returns a Value which contains the api's id as
 // Id and Name attribute
 func (a *apiImpl) NewIdValue() Value {
@@ -351,14 +552,14 @@ func (a *apiImpl) NewIdValue() Value {
 }
 
 func IsApi(dir string) bool {
-	_, ok := apiMap[dir]
+	_, ok := apiInputs()[dir]
 	return ok
 }
 
 // tests if part of project folder path contains an API
 // folders with API in path are not valid projects
 func ContainsApiName(path string) bool {
-	for api := range apiMap {
+	for api := range apiInputs() {
 		if strings.Contains(path, api) {
 			return true
 		}
@@ -0,0 +1,170 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// customApis are the apis defined by the user in addition to the built-in apis of apiMap, by id
+var customApis = map[string]apiInput{}
+
+// allApis are the built-in and custom apis, by id. It is updated by LoadApiDefinitions, so lookups don't merge the
+// apis again.
+var allApis = mergeApiInputs(apiMap, customApis)
+
+// apiDefinitions is the content of an api definitions file
+type apiDefinitions struct {
+	Apis []apiDefinition `yaml:"apis"`
+}
+
+// apiDefinition defines an api in an api definitions file
+type apiDefinition struct {
+	Id                           string       `yaml:"id"`
+	Path                         string       `yaml:"path"`
+	PropertyNameOfGetAllResponse string       `yaml:"list-property"`
+	IdPropertyName               string       `yaml:"id-field"`
+	SingleConfiguration          bool         `yaml:"single-configuration"`
+	Platform                     bool         `yaml:"platform"`
+	Cluster                      bool         `yaml:"cluster"`
+	Constraints                  []Constraint `yaml:"constraints"`
+}
+
+// apiIdPattern matches valid api ids, which are used as folder names of configs
+var apiIdPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// LoadApiDefinitions reads the apis defined in the yaml file and makes them available in addition to the built-in
+// apis. Apis defined by an earlier call are replaced. If the file name is empty, only the built-in apis are available.
+//
+// The file contains a list of apis:
+//
+//	apis:
+//	  - id: my-settings
+//	    path: /api/v2/settings/objects
+//	    list-property: items
+//	    id-field: objectId
+//	    single-configuration: false
+//	    constraints:
+//	      - field: name
+//	        required: true
+//	        max-length: 100
+//	      - field: rules[].type
+//	        enum: [HOST, SERVICE]
+func LoadApiDefinitions(fs afero.Fs, fileName string) error {
+	customApis = map[string]apiInput{}
+	allApis = mergeApiInputs(apiMap, customApis)
+
+	if fileName == "" {
+		return nil
+	}
+
+	content, err := afero.ReadFile(fs, fileName)
+	if err != nil {
+		return fmt.Errorf("could not read api definitions %s: %w", fileName, err)
+	}
+
+	definitions, err := parseApiDefinitions(content)
+	if err != nil {
+		return fmt.Errorf("invalid api definitions %s: %w", fileName, err)
+	}
+
+	customApis = definitions
+	allApis = mergeApiInputs(apiMap, customApis)
+	return nil
+}
+
+func parseApiDefinitions(content []byte) (map[string]apiInput, error) {
+	var definitions apiDefinitions
+	if err := yaml.UnmarshalStrict(content, &definitions); err != nil {
+		return nil, err
+	}
+
+	apis := make(map[string]apiInput, len(definitions.Apis))
+
+	for i, definition := range definitions.Apis {
+		if err := definition.check(); err != nil {
+			return nil, fmt.Errorf("api %d: %w", i+1, err)
+		}
+
+		if _, builtIn := apiMap[definition.Id]; builtIn {
+			return nil, fmt.Errorf("api %s conflicts with the built-in api of the same id", definition.Id)
+		}
+		if _, duplicate := apis[definition.Id]; duplicate {
+			return nil, fmt.Errorf("api %s is defined more than once", definition.Id)
+		}
+
+		apis[definition.Id] = apiInput{
+			apiPath:                      strings.TrimRight(definition.Path, "/"),
+			propertyNameOfGetAllResponse: definition.PropertyNameOfGetAllResponse,
+			idPropertyName:               definition.IdPropertyName,
+			isSingleConfigurationApi:     definition.SingleConfiguration,
+			isPlatformApi:                definition.Platform,
+			isClusterApi:                 definition.Cluster,
+			constraints:                  definition.Constraints,
+		}
+	}
+
+	return apis, nil
+}
+
+func (d apiDefinition) check() error {
+	if !apiIdPattern.MatchString(d.Id) {
+		return fmt.Errorf("id %q must consist of lower case letters, digits and dashes", d.Id)
+	}
+	if !strings.HasPrefix(d.Path, "/") {
+		return fmt.Errorf("path %q of api %s must start with /", d.Path, d.Id)
+	}
+	if d.Platform && d.Cluster {
+		return fmt.Errorf("api %s can't be a platform and a cluster api", d.Id)
+	}
+	if d.Platform && d.SingleConfiguration {
+		return fmt.Errorf("platform api %s can't be a single configuration api", d.Id)
+	}
+	if d.SingleConfiguration && d.PropertyNameOfGetAllResponse != "" {
+		return fmt.Errorf("single configuration api %s can't define a list-property", d.Id)
+	}
+	if d.SingleConfiguration && d.IdPropertyName != "" {
+		return fmt.Errorf("single configuration api %s can't define an id-field", d.Id)
+	}
+	for _, constraint := range d.Constraints {
+		if err := constraint.checkDefinition(); err != nil {
+			return fmt.Errorf("invalid constraint of api %s: %w", d.Id, err)
+		}
+	}
+	return nil
+}
+
+// apiInputs returns the built-in and custom apis. The map must not be modified.
+func apiInputs() map[string]apiInput {
+	return allApis
+}
+
+func mergeApiInputs(builtIn map[string]apiInput, custom map[string]apiInput) map[string]apiInput {
+	inputs := make(map[string]apiInput, len(builtIn)+len(custom))
+	for id, input := range builtIn {
+		inputs[id] = input
+	}
+	for id, input := range custom {
+		inputs[id] = input
+	}
+	return inputs
+}
@@ -0,0 +1,194 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func writeApiDefinitions(t *testing.T, content string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, "apis.yaml", []byte(content), 0644)
+	assert.NilError(t, err)
+	return fs
+}
+
+func TestLoadApiDefinitionsAddsApisToBuiltInApis(t *testing.T) {
+	fs := writeApiDefinitions(t, `apis:
+  - id: my-settings
+    path: /api/v2/settings/objects/
+    list-property: items
+    id-field: objectId
+  - id: my-values
+    path: /api/config/v1/my/values
+  - id: my-single-setting
+    path: /api/config/v1/my/setting
+    single-configuration: true
+  - id: my-cluster-setting
+    path: /api/v1.0/onpremise/setting
+    single-configuration: true
+    cluster: true
+`)
+
+	err := LoadApiDefinitions(fs, "apis.yaml")
+	defer LoadApiDefinitions(fs, "")
+	assert.NilError(t, err)
+
+	apis := NewApis()
+	assert.Equal(t, len(apis), len(apiMap)+4)
+	assert.Assert(t, IsApi("my-settings"))
+	assert.Assert(t, IsApi("dashboard"))
+	assert.Assert(t, ContainsApiName("project/my-single-setting/setting.yaml"))
+
+	settings := apis["my-settings"]
+	assert.Equal(t, settings.GetUrlFromEnvironmentUrl("https://env"), "https://env/api/v2/settings/objects")
+	assert.Equal(t, settings.GetPropertyNameOfGetAllResponse(), "items")
+	assert.Assert(t, !settings.IsStandardApi())
+	assert.Equal(t, settings.GetIdPropertyName(), "objectId")
+	assert.Equal(t, apis["my-values"].GetIdPropertyName(), "id")
+	assert.Equal(t, apis["dashboard"].GetIdPropertyName(), "id")
+
+	assert.Assert(t, apis["my-values"].IsStandardApi())
+	assert.Assert(t, apis["my-single-setting"].IsSingleConfigurationApi())
+	assert.Assert(t, !apis["my-single-setting"].IsClusterApi())
+	assert.Assert(t, apis["my-cluster-setting"].IsClusterApi())
+}
+
+func TestLoadApiDefinitionsWithoutFileOnlyKeepsBuiltInApis(t *testing.T) {
+	fs := writeApiDefinitions(t, `apis:
+  - id: my-settings
+    path: /api/v2/settings/objects
+`)
+
+	err := LoadApiDefinitions(fs, "apis.yaml")
+	assert.NilError(t, err)
+
+	err = LoadApiDefinitions(fs, "")
+	assert.NilError(t, err)
+
+	assert.Assert(t, !IsApi("my-settings"))
+	assert.Equal(t, len(NewApis()), len(apiMap))
+}
+
+func TestLoadApiDefinitionsRejectsInvalidDefinitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			"conflict with built-in api",
+			"apis:\n  - id: dashboard\n    path: /api/config/v1/dashboards\n",
+			"api dashboard conflicts with the built-in api of the same id",
+		},
+		{
+			"duplicate api",
+			"apis:\n  - id: my-api\n    path: /api/a\n  - id: my-api\n    path: /api/b\n",
+			"api my-api is defined more than once",
+		},
+		{
+			"invalid id",
+			"apis:\n  - id: My_Api\n    path: /api/a\n",
+			"must consist of lower case letters, digits and dashes",
+		},
+		{
+			"relative path",
+			"apis:\n  - id: my-api\n    path: api/a\n",
+			"must start with /",
+		},
+		{
+			"platform and cluster api",
+			"apis:\n  - id: my-api\n    path: /api/a\n    platform: true\n    cluster: true\n",
+			"can't be a platform and a cluster api",
+		},
+		{
+			"single configuration api with list property",
+			"apis:\n  - id: my-api\n    path: /api/a\n    single-configuration: true\n    list-property: items\n",
+			"can't define a list-property",
+		},
+		{
+			"id field of single configuration api",
+			"apis:\n  - id: my-api\n    path: /api/a\n    single-configuration: true\n    id-field: objectId\n",
+			"can't define an id-field",
+		},
+		{
+			"constraint without rule",
+			"apis:\n  - id: my-api\n    path: /api/a\n    constraints:\n      - field: name\n",
+			"invalid constraint of api my-api: constraint of field name must define required, max-length or enum",
+		},
+		{
+			"constraint of invalid field",
+			"apis:\n  - id: my-api\n    path: /api/a\n    constraints:\n      - field: rules..type\n        required: true\n",
+			"invalid constraint of api my-api: invalid field \"rules..type\"",
+		},
+		{
+			"unknown property",
+			"apis:\n  - id: my-api\n    path: /api/a\n    name: My Api\n",
+			"field name not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := writeApiDefinitions(t, test.content)
+
+			err := LoadApiDefinitions(fs, "apis.yaml")
+			defer LoadApiDefinitions(fs, "")
+
+			assert.ErrorContains(t, err, test.expected)
+			assert.Equal(t, len(NewApis()), len(apiMap))
+		})
+	}
+}
+
+func TestLoadApiDefinitionsAddsConstraintsOfApis(t *testing.T) {
+	fs := writeApiDefinitions(t, `apis:
+  - id: my-values
+    path: /api/config/v1/my/values
+    constraints:
+      - field: name
+        required: true
+        max-length: 10
+      - field: rules[].type
+        enum: [HOST, SERVICE]
+`)
+
+	err := LoadApiDefinitions(fs, "apis.yaml")
+	defer LoadApiDefinitions(fs, "")
+	assert.NilError(t, err)
+
+	myValues := NewApis()["my-values"]
+	assert.DeepEqual(t, myValues.GetConstraints(), []Constraint{
+		{Field: "name", Required: true, MaxLength: 10},
+		{Field: "rules[].type", Enum: []string{"HOST", "SERVICE"}},
+	})
+
+	err = CheckConstraints(myValues, []byte(`{"name": "my values", "rules": [{"type": "PROCESS_GROUP"}]}`))
+	assert.Error(t, err, "payload violates the constraints of my-values:"+
+		"\n\t$.rules[0].type: expected one of HOST, SERVICE, got \"PROCESS_GROUP\"")
+}
+
+func TestLoadApiDefinitionsFailsOnMissingFile(t *testing.T) {
+	err := LoadApiDefinitions(afero.NewMemMapFs(), "apis.yaml")
+	assert.ErrorContains(t, err, "could not read api definitions apis.yaml")
+}
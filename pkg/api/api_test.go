@@ -73,6 +73,40 @@ func TestIsSingleConfigurationApi(t *testing.T) {
 	assert.Equal(t, true, isSingleConfigurationApi)
 }
 
+func TestIsPlatformApi(t *testing.T) {
+	assert.Equal(t, false, testDashboardApi.IsPlatformApi())
+
+	platformApi := NewPlatformApi("platform-api", "/platform/classic/environment-api/v2/settings/objects", "")
+	assert.Equal(t, true, platformApi.IsPlatformApi())
+	assert.Equal(t, "values", platformApi.GetPropertyNameOfGetAllResponse())
+}
+
+func TestIsClusterApi(t *testing.T) {
+	assert.Equal(t, false, testDashboardApi.IsClusterApi())
+
+	smtp := NewApis()["cluster-smtp"]
+	assert.Equal(t, true, smtp.IsClusterApi())
+	assert.Equal(t, true, smtp.IsSingleConfigurationApi())
+
+	clusterApi := NewClusterApi("cluster-api", "/api/cluster/v2/tokens", "", false)
+	assert.Equal(t, true, clusterApi.IsClusterApi())
+	assert.Equal(t, "values", clusterApi.GetPropertyNameOfGetAllResponse())
+}
+
+func TestIsAutomationApi(t *testing.T) {
+	assert.Equal(t, false, testDashboardApi.IsAutomationApi())
+
+	for _, id := range []string{"workflow", "scheduling-rule"} {
+		automationApi := NewApis()[id]
+		assert.Equal(t, true, automationApi.IsAutomationApi(), id)
+		assert.Equal(t, true, automationApi.IsPlatformApi(), id)
+		assert.Equal(t, "results", automationApi.GetPropertyNameOfGetAllResponse(), id)
+		assert.Equal(t, "title", automationApi.GetConstraints()[0].Field, id)
+	}
+
+	assert.Equal(t, "https://env/platform/automation/v1/workflows", NewApis()["workflow"].GetUrlFromEnvironmentUrl("https://env"))
+}
+
 func TestNewIdValue(t *testing.T) {
 	value := testHostsAutoUpdateApi.NewIdValue()
 	assert.Equal(t, hostsAutoUpdateApiId, value.Name)
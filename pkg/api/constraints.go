@@ -0,0 +1,171 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Constraint is a rule the payloads of an api must satisfy, which is checked when configs are validated, to find
+// violations without a round-trip to the environment. It is lighter than a schema, as it only constrains single fields.
+type Constraint struct {
+	// Field is the path of the constrained value. Nested fields are separated by dots, `[]` selects all items of an
+	// array, e.g. `rules[].severityLevel`.
+	Field string `yaml:"field"`
+	// Required fields must be present and not null, if the object containing them is present
+	Required bool `yaml:"required"`
+	// MaxLength is the maximum number of characters of a string. 0 means no limit.
+	MaxLength int `yaml:"max-length"`
+	// Enum contains the allowed values of a string. If empty, all values are allowed.
+	Enum []string `yaml:"enum"`
+}
+
+// ConstraintViolation is a value of a payload which doesn't satisfy a constraint of its api
+type ConstraintViolation struct {
+	// Path of the violating value, e.g. `$.rules[2].severityLevel`
+	Path    string
+	Message string
+}
+
+func (v ConstraintViolation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ConstraintError contains all violations of the constraints of the api by a payload
+type ConstraintError struct {
+	Api        string
+	Violations []ConstraintViolation
+}
+
+func (e *ConstraintError) Error() string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("payload violates the constraints of %s:", e.Api))
+	for _, violation := range e.Violations {
+		message.WriteString("\n\t" + violation.String())
+	}
+	return message.String()
+}
+
+// CheckConstraints checks the payload against all constraints of the api and returns a *ConstraintError containing all
+// violations. Payloads of apis without constraints are not checked.
+func CheckConstraints(a Api, payload []byte) error {
+	constraints := a.GetConstraints()
+	if len(constraints) == 0 {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return err
+	}
+
+	var violations []ConstraintViolation
+	for _, constraint := range constraints {
+		violations = append(violations, constraint.check(value, strings.Split(constraint.Field, "."), "$")...)
+	}
+
+	if len(violations) > 0 {
+		return &ConstraintError{Api: a.GetId(), Violations: violations}
+	}
+	return nil
+}
+
+// check walks along the remaining segments of the field and checks the values found at their end
+func (c Constraint) check(value interface{}, segments []string, path string) []ConstraintViolation {
+	if len(segments) == 0 {
+		return c.checkValue(value, path)
+	}
+
+	object, isObject := value.(map[string]interface{})
+	if !isObject {
+		return nil
+	}
+
+	name := strings.TrimSuffix(segments[0], "[]")
+	isArray := name != segments[0]
+	path = path + "." + name
+
+	field, found := object[name]
+	if !found || field == nil {
+		if c.Required && len(segments) == 1 {
+			return []ConstraintViolation{{Path: path, Message: "required field is missing"}}
+		}
+		return nil
+	}
+
+	if !isArray {
+		return c.check(field, segments[1:], path)
+	}
+
+	items, isItems := field.([]interface{})
+	if !isItems {
+		return []ConstraintViolation{{Path: path, Message: "expected an array"}}
+	}
+
+	var violations []ConstraintViolation
+	for i, item := range items {
+		violations = append(violations, c.check(item, segments[1:], fmt.Sprintf("%s[%d]", path, i))...)
+	}
+	return violations
+}
+
+func (c Constraint) checkValue(value interface{}, path string) []ConstraintViolation {
+	if c.MaxLength == 0 && len(c.Enum) == 0 {
+		return nil
+	}
+
+	s, isString := value.(string)
+	if !isString {
+		return []ConstraintViolation{{Path: path, Message: "expected a string"}}
+	}
+
+	var violations []ConstraintViolation
+	if length := len([]rune(s)); c.MaxLength > 0 && length > c.MaxLength {
+		violations = append(violations, ConstraintViolation{Path: path, Message: fmt.Sprintf("expected at most %d characters, got %d", c.MaxLength, length)})
+	}
+	if len(c.Enum) > 0 && !containsString(c.Enum, s) {
+		violations = append(violations, ConstraintViolation{Path: path, Message: fmt.Sprintf("expected one of %s, got %q", strings.Join(c.Enum, ", "), s)})
+	}
+	return violations
+}
+
+// checkDefinition verifies that the constraint of an api definitions file constrains a field
+func (c Constraint) checkDefinition() error {
+	for _, segment := range strings.Split(c.Field, ".") {
+		if strings.TrimSuffix(segment, "[]") == "" {
+			return fmt.Errorf("invalid field %q", c.Field)
+		}
+	}
+	if !c.Required && c.MaxLength == 0 && len(c.Enum) == 0 {
+		return fmt.Errorf("constraint of field %s must define required, max-length or enum", c.Field)
+	}
+	if c.MaxLength < 0 {
+		return fmt.Errorf("max-length of field %s must not be negative", c.Field)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCheckConstraintsReportsAllViolationsOfAlertingProfile(t *testing.T) {
+	profile := NewApis()["alerting-profile"]
+
+	err := CheckConstraints(profile, []byte(`{
+  "name": "profile",
+  "rules": [
+    {"severityLevel": "AVAILABILITY", "tagFilter": {"includeMode": "INCLUDE_ANY"}},
+    {"severityLevel": "WARNING", "tagFilter": {"includeMode": "ALL"}},
+    {"tagFilter": null}
+  ]
+}`))
+
+	assert.Error(t, err, `payload violates the constraints of alerting-profile:
+	$.displayName: required field is missing
+	$.rules[1].severityLevel: expected one of AVAILABILITY, CUSTOM_ALERT, ERROR, MONITORING_UNAVAILABLE, PERFORMANCE, RESOURCE_CONTENTION, got "WARNING"
+	$.rules[2].severityLevel: required field is missing
+	$.rules[1].tagFilter.includeMode: expected one of INCLUDE_ALL, INCLUDE_ANY, NONE, got "ALL"`)
+}
+
+func TestCheckConstraintsReportsViolationsOfNestedFields(t *testing.T) {
+	dashboard := NewApis()["dashboard"]
+
+	longName := strings.Repeat("a", 501)
+
+	err := CheckConstraints(dashboard, []byte(`{"dashboardMetadata": {"name": "`+longName+`"}, "tiles": [{"name": "tile"}, {"tileType": "MARKDOWN"}]}`))
+	assert.Error(t, err, `payload violates the constraints of dashboard:
+	$.dashboardMetadata.name: expected at most 500 characters, got 501
+	$.tiles[0].tileType: required field is missing`)
+
+	err = CheckConstraints(dashboard, []byte(`{"dashboardMetadata": {"name": 42}, "tiles": {}}`))
+	assert.Error(t, err, `payload violates the constraints of dashboard:
+	$.dashboardMetadata.name: expected a string
+	$.tiles: expected an array`)
+}
+
+func TestCheckConstraintsAcceptsValidPayloads(t *testing.T) {
+	apis := NewApis()
+
+	payloads := map[string]string{
+		"alerting-profile":   `{"displayName": "profile", "rules": [{"severityLevel": "ERROR", "tagFilter": {"includeMode": "NONE"}}]}`,
+		"maintenance-window": `{"name": "window", "type": "PLANNED", "suppression": "DONT_DETECT_PROBLEMS", "schedule": {"recurrenceType": "ONCE"}}`,
+		"slo":                `{"name": "availability", "evaluationType": "AGGREGATE", "timeframe": "-1d"}`,
+		"management-zone":    `{"name": "zone"}`,
+	}
+
+	for apiId, payload := range payloads {
+		assert.NilError(t, CheckConstraints(apis[apiId], []byte(payload)), apiId)
+	}
+}
+
+func TestCheckConstraintsIgnoresApisWithoutConstraints(t *testing.T) {
+	assert.NilError(t, CheckConstraints(NewApis()["reports"], []byte(`{}`)))
+	assert.NilError(t, CheckConstraints(NewStandardApi("alerting-profile", "/api/config/v1/alertingProfiles"), []byte(`{}`)))
+}
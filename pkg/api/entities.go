@@ -16,6 +16,8 @@
 
 package api
 
+import "encoding/json"
+
 type ValuesResponse struct {
 	Values []Value `json:"values"`
 }
@@ -52,4 +54,59 @@ type DynatraceEntity struct {
 	Id          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+
+	// Created is true if the entity didn't exist before and was created by an upsert. It is not part of API responses.
+	Created bool `json:"-"`
+
+	// Payload is the rendered json of the config the entity was deployed from. It is used to resolve references to
+	// properties of the config and is not part of API responses.
+	Payload []byte `json:"-"`
+
+	// Response is the body the API returned when the entity was created or updated. It is used to resolve references
+	// to fields of the response and is empty, if the API returned no body.
+	Response []byte `json:"-"`
+
+	// Planned is true if the entity was not deployed, e.g. in a dry run, so references to fields of its response
+	// resolve to a placeholder
+	Planned bool `json:"-"`
+}
+
+// SettingsObject is a Settings 2.0 object to deploy. The external id identifies the object in its schema and scope,
+// so it is updated instead of created again on later deployments.
+type SettingsObject struct {
+	// Name is the name of the config the object is deployed from. It is not part of the object.
+	Name       string
+	SchemaId   string
+	Scope      string
+	ExternalId string
+	// Value is the rendered json of the config
+	Value []byte
+}
+
+// DownloadedSettingsObject is a Settings 2.0 object read from an environment
+type DownloadedSettingsObject struct {
+	ObjectId   string          `json:"objectId"`
+	ExternalId string          `json:"externalId"`
+	SchemaId   string          `json:"schemaId"`
+	Scope      string          `json:"scope"`
+	Summary    string          `json:"summary"`
+	Value      json.RawMessage `json:"value"`
+
+	// ModificationInfo is nil, if the environment doesn't return when the object was modified
+	ModificationInfo *SettingsModificationInfo `json:"modificationInfo,omitempty"`
+}
+
+// DownloadedAutomationObject is an object of an automation API read from an environment, e.g. a workflow
+type DownloadedAutomationObject struct {
+	Id    string
+	Title string
+	// Payload is the json of the object as returned by the API
+	Payload json.RawMessage
+}
+
+// SettingsModificationInfo contains when a settings object was created and last modified, in milliseconds since the
+// epoch. A time is 0, if it is unknown.
+type SettingsModificationInfo struct {
+	CreatedTime      int64 `json:"createdTime"`
+	LastModifiedTime int64 `json:"lastModifiedTime"`
 }
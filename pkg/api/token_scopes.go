@@ -0,0 +1,67 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+// TokenScope is a permission of an API token, e.g. ReadConfig ("Read configuration")
+type TokenScope struct {
+	Id   string
+	Name string
+}
+
+func (s TokenScope) String() string {
+	return s.Id + " (" + s.Name + ")"
+}
+
+var (
+	readConfigScope         = TokenScope{Id: "ReadConfig", Name: "Read configuration"}
+	writeConfigScope        = TokenScope{Id: "WriteConfig", Name: "Write configuration"}
+	captureRequestDataScope = TokenScope{Id: "CaptureRequestData", Name: "Capture request data"}
+	dataPrivacyScope        = TokenScope{Id: "DataPrivacy", Name: "Change data privacy settings"}
+	dataExportScope         = TokenScope{Id: "DataExport", Name: "Access problem and event feed, metrics, and topology"}
+	syntheticScope          = TokenScope{Id: "ExternalSyntheticIntegration", Name: "Create and read synthetic monitors, locations, and nodes"}
+	readSloScope            = TokenScope{Id: "slo.read", Name: "Read SLO"}
+	writeSloScope           = TokenScope{Id: "slo.write", Name: "Write SLOs"}
+	readCredentialsScope    = TokenScope{Id: "credentialVault.read", Name: "Read credential vault entries"}
+	writeCredentialsScope   = TokenScope{Id: "credentialVault.write", Name: "Write credential vault entries"}
+	readSettingsScope       = TokenScope{Id: "settings.read", Name: "Read settings"}
+	writeSettingsScope      = TokenScope{Id: "settings.write", Name: "Write settings"}
+	readWorkflowsScope      = TokenScope{Id: "automation:workflows:read", Name: "Read workflows"}
+	writeWorkflowsScope     = TokenScope{Id: "automation:workflows:write", Name: "Write workflows"}
+	readRulesScope          = TokenScope{Id: "automation:rules:read", Name: "Read scheduling rules"}
+	writeRulesScope         = TokenScope{Id: "automation:rules:write", Name: "Write scheduling rules"}
+)
+
+// tokenScopes are the scopes required by the apis not accessed with the ReadConfig and WriteConfig scopes
+var tokenScopes = map[string][]TokenScope{
+	"request-attributes": {readConfigScope, captureRequestDataScope},
+	"data-privacy":       {readConfigScope, dataPrivacyScope},
+	"synthetic-location": {dataExportScope, syntheticScope},
+	"synthetic-monitor":  {syntheticScope},
+	"slo":                {readSloScope, writeSloScope},
+	"credential-vault":   {readCredentialsScope, writeCredentialsScope},
+	"settings":           {readSettingsScope, writeSettingsScope},
+	"workflow":           {readWorkflowsScope, writeWorkflowsScope},
+	"scheduling-rule":    {readRulesScope, writeRulesScope},
+}
+
+// GetTokenScopes returns the scopes an API token requires to read and write the configs of the api with the given id
+func GetTokenScopes(apiId string) []TokenScope {
+	if scopes, found := tokenScopes[apiId]; found {
+		return scopes
+	}
+	return []TokenScope{readConfigScope, writeConfigScope}
+}
@@ -0,0 +1,358 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// manifestFile is the name of the manifest inside the bundle
+const manifestFile = "manifest.json"
+
+// manifestVersion is increased on incompatible changes of the bundle format
+const manifestVersion = 1
+
+// manifest describes the contents of a bundle. Configs are listed in the order they are deployed.
+type manifest struct {
+	Version     int             `json:"version"`
+	Environment string          `json:"environment"`
+	Configs     []manifestEntry `json:"configs"`
+}
+
+// manifestEntry is a bundled config. Ids use / as separator and are relative to the working directory the bundle was
+// created in.
+type manifestEntry struct {
+	Project      string   `json:"project"`
+	Api          string   `json:"api"`
+	Id           string   `json:"id"`
+	Name         string   `json:"name"`
+	File         string   `json:"file"`
+	References   []string `json:"references"`
+	SkipIfExists bool     `json:"skipIfExists,omitempty"`
+
+	// Parameters are the rendered parameters of settings, which are required in addition to the name, and the name
+	// of the token the config is deployed with
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+func (e manifestEntry) referenceId() string {
+	return strings.Join([]string{e.Project, e.Api, e.Id}, "/")
+}
+
+// Bundle is a loaded bundle, ready to be deployed
+type Bundle struct {
+	// Environment is the environment the bundle was rendered for
+	Environment string
+	Projects    []project.Project
+}
+
+// Create renders the configs of the projects for the environment and writes them to a zip file, together with a
+// manifest listing them in the order of their dependencies. No environment is contacted: ids of referenced configs
+// are stored as placeholders and only resolved when the bundle is deployed. Configs skipped for the environment are
+// not bundled.
+func Create(workingDir string, fs afero.Fs, environmentsFile string, specificEnvironment string, proj string,
+	bundleFile string) error {
+
+	environments, errs := environment.LoadEnvironmentList(specificEnvironment, environmentsFile, fs, util.DefaultLogger())
+	if len(errs) > 0 {
+		util.PrintErrors(errs)
+		return fmt.Errorf("Errors while loading environments! Check log!")
+	}
+	if len(environments) != 1 {
+		return fmt.Errorf("a bundle is rendered for a single environment, but %d environments were selected: specify one with --specific-environment", len(environments))
+	}
+
+	var env environment.Environment
+	for _, e := range environments {
+		env = e
+	}
+
+	workingDir = filepath.Clean(workingDir)
+
+	projects, err := project.LoadProjectsToDeploy(fs, proj, api.NewApis(), workingDir, util.DefaultLogger())
+	if err != nil {
+		return err
+	}
+
+	result := manifest{Version: manifestVersion, Environment: env.GetId(), Configs: make([]manifestEntry, 0)}
+	payloads := make(map[string][]byte)
+
+	if errs := render(env, projects, workingDir, &result, payloads); len(errs) > 0 {
+		util.Log.Error("Rendering of the bundle for %s failed with %d error(s):", env.GetId(), len(errs))
+		util.PrintErrors(errs)
+		return fmt.Errorf("Errors while rendering the bundle! Check log!")
+	}
+
+	if err := write(fs, bundleFile, result, payloads); err != nil {
+		return fmt.Errorf("could not write bundle %s: %w", bundleFile, err)
+	}
+
+	util.Log.Info("Bundle with %d configs for %s written to %s", len(result.Configs), env.GetId(), bundleFile)
+	return nil
+}
+
+// render adds all configs of the projects to the manifest and their payloads to payloads, keyed by their file name
+func render(env environment.Environment, projects []project.Project, workingDir string, result *manifest,
+	payloads map[string][]byte) (errs []error) {
+
+	relative := func(id string) string {
+		return filepath.ToSlash(strings.TrimPrefix(id, workingDir+string(filepath.Separator)))
+	}
+
+	dict := make(map[string]api.DynatraceEntity)
+	var rendered []config.Config
+
+	for _, p := range projects {
+		for _, c := range p.GetConfigs() {
+			referenceId := strings.TrimPrefix(c.GetFullQualifiedId(), workingDir+"/")
+
+			if c.IsSkipDeployment(env) {
+				util.Log.Info("\tSkipping %s, as it is not deployed to %s", relative(c.GetFullQualifiedId()), env.GetId())
+				continue
+			}
+
+			name, err := c.GetObjectNameForEnvironment(env, dict)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", relative(c.GetFullQualifiedId()), err))
+				continue
+			}
+
+			payload, err := c.GetConfigForEnvironment(env, dict)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", relative(c.GetFullQualifiedId()), err))
+				continue
+			}
+
+			entry := manifestEntry{
+				Project:      relative(c.GetProject()),
+				Api:          c.GetType(),
+				Id:           c.GetId(),
+				Name:         name,
+				References:   make([]string, 0),
+				SkipIfExists: c.IsSkipIfExists(env),
+			}
+			entry.File = path.Join("configs", entry.referenceId()+".json")
+
+			if c.GetApi().IsSettingsApi() {
+				entry.Parameters, err = settingsParameters(c, env, dict)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", relative(c.GetFullQualifiedId()), err))
+					continue
+				}
+			}
+
+			if tokenName := c.GetTokenName(env); tokenName != "" {
+				if entry.Parameters == nil {
+					entry.Parameters = make(map[string]string)
+				}
+				entry.Parameters[config.TokenNameParameter] = tokenName
+			}
+
+			for _, other := range rendered {
+				if c.HasDependencyOn(other) {
+					entry.References = append(entry.References, relative(other.GetFullQualifiedId()))
+				}
+			}
+
+			dict[referenceId] = api.DynatraceEntity{
+				Id:      config.BundleIdPlaceholder(entry.referenceId()),
+				Name:    name,
+				Payload: payload,
+			}
+
+			rendered = append(rendered, c)
+			result.Configs = append(result.Configs, entry)
+			payloads[entry.File] = payload
+		}
+	}
+
+	return errs
+}
+
+// settingsParameters renders the schemaId and scope of a settings config
+func settingsParameters(c config.Config, env environment.Environment, dict map[string]api.DynatraceEntity) (map[string]string, error) {
+	parameters := make(map[string]string)
+	for _, parameter := range []string{config.SchemaIdParameter, config.ScopeParameter} {
+		value, err := c.GetParameterForEnvironment(parameter, env, dict)
+		if err != nil {
+			return nil, err
+		}
+		parameters[parameter] = value
+	}
+	return parameters, nil
+}
+
+func write(fs afero.Fs, bundleFile string, result manifest, payloads map[string][]byte) error {
+	content, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	if err := addFile(archive, manifestFile, content); err != nil {
+		return err
+	}
+	for _, entry := range result.Configs {
+		if err := addFile(archive, entry.File, payloads[entry.File]); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(bundleFile); dir != "." {
+		if err := fs.MkdirAll(dir, 0777); err != nil {
+			return err
+		}
+	}
+	return afero.WriteFile(fs, bundleFile, buffer.Bytes(), 0644)
+}
+
+func addFile(archive *zip.Writer, name string, content []byte) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	return err
+}
+
+// Load reads a bundle written by Create. Its configs are grouped into projects in the order they were bundled.
+func Load(fs afero.Fs, bundleFile string) (Bundle, error) {
+	content, err := afero.ReadFile(fs, bundleFile)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("could not read bundle %s: %w", bundleFile, err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return Bundle{}, fmt.Errorf("could not read bundle %s: %w", bundleFile, err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var result manifest
+	manifestContent, err := readFile(files, manifestFile)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("invalid bundle %s: %w", bundleFile, err)
+	}
+	if err := json.Unmarshal(manifestContent, &result); err != nil {
+		return Bundle{}, fmt.Errorf("invalid bundle %s: could not parse %s: %w", bundleFile, manifestFile, err)
+	}
+	if result.Version != manifestVersion {
+		return Bundle{}, fmt.Errorf("unsupported version %d of bundle %s: this version of monaco supports version %d", result.Version, bundleFile, manifestVersion)
+	}
+
+	apis := api.NewApis()
+	var projects []*bundleProject
+
+	for _, entry := range result.Configs {
+		theApi, found := apis[entry.Api]
+		if !found {
+			return Bundle{}, fmt.Errorf("invalid bundle %s: unknown api %s of config %s", bundleFile, entry.Api, entry.referenceId())
+		}
+
+		payload, err := readFile(files, entry.File)
+		if err != nil {
+			return Bundle{}, fmt.Errorf("invalid bundle %s: %w", bundleFile, err)
+		}
+
+		c := config.NewBundledConfig(entry.Id, entry.Project, theApi, entry.Name, entry.Parameters, payload, entry.References,
+			entry.SkipIfExists, filepath.Join(bundleFile, filepath.FromSlash(entry.File)))
+
+		if len(projects) == 0 || projects[len(projects)-1].id != entry.Project {
+			projects = append(projects, &bundleProject{id: entry.Project})
+		}
+		projects[len(projects)-1].configs = append(projects[len(projects)-1].configs, c)
+	}
+
+	bundle := Bundle{Environment: result.Environment, Projects: make([]project.Project, len(projects))}
+	for i, p := range projects {
+		bundle.Projects[i] = p
+	}
+	return bundle, nil
+}
+
+func readFile(files map[string]*zip.File, name string) ([]byte, error) {
+	file, found := files[name]
+	if !found {
+		return nil, fmt.Errorf("%s is missing", name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
+
+// bundleProject is a project of a bundle. Its configs are already in order of their dependencies.
+type bundleProject struct {
+	id      string
+	configs []config.Config
+}
+
+func (p *bundleProject) HasDependencyOn(other project.Project) bool {
+	for _, c := range p.configs {
+		for _, otherConfig := range other.GetConfigs() {
+			if c.HasDependencyOn(otherConfig) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *bundleProject) GetConfigs() []config.Config {
+	return p.configs
+}
+
+func (p *bundleProject) GetConfig(id string) (config.Config, error) {
+	for _, c := range p.configs {
+		if c.GetId() == id {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("config %s not found in project %s", id, p.id)
+}
+
+func (p *bundleProject) GetId() string {
+	return p.id
+}
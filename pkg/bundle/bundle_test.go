@@ -0,0 +1,229 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+const testEnvironments = `
+dev:
+  - name: "Dev"
+  - env-url: "https://dev.live.dynatrace.com"
+  - env-token-name: "MONACO_BUNDLE_TEST_TOKEN"
+
+prod:
+  - name: "Prod"
+  - env-url: "https://prod.live.dynatrace.com"
+  - env-token-name: "MONACO_BUNDLE_TEST_TOKEN"
+`
+
+func createTestProjects(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+
+	files := map[string]string{
+		"environments.yaml":                             testEnvironments,
+		"projects/zaphod/alerting-profile/profile.yaml": "config:\n  - profile: \"profile.json\"\n\nprofile:\n  - name: \"Profile\"\n\nprofile.dev:\n  - name: \"Dev Profile\"\n",
+		"projects/zaphod/alerting-profile/profile.json": `{"name": "{{.name}}", "rules": []}`,
+		"projects/zaphod/calculated-metrics-log/metric.yaml": "config:\n  - metric: \"metric.json\"\n  - skipped: \"metric.json\"\n\n" +
+			"metric:\n  - name: \"Metric\"\n  - profile: \"zaphod/alerting-profile/profile.id\"\n  - profileName: \"zaphod/alerting-profile/profile.name\"\n\n" +
+			"skipped:\n  - name: \"Skipped\"\n  - profile: \"zaphod/alerting-profile/profile.id\"\n  - profileName: \"zaphod/alerting-profile/profile.name\"\n\n" +
+			"skipped.dev:\n  - skipDeployment: \"true\"\n",
+		"projects/zaphod/calculated-metrics-log/metric.json": `{"name": "{{.name}}", "profile": "{{.profile}}", "description": "{{.profileName}}"}`,
+	}
+
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+
+	return fs
+}
+
+func TestCreateWritesRenderedPayloadsAndManifest(t *testing.T) {
+	fs := createTestProjects(t)
+
+	err := Create("projects", fs, "environments.yaml", "dev", "", "out/bundle.zip")
+	assert.NilError(t, err)
+
+	bundle, err := Load(fs, "out/bundle.zip")
+	assert.NilError(t, err)
+
+	assert.Equal(t, bundle.Environment, "dev")
+	assert.Equal(t, len(bundle.Projects), 1)
+	assert.Equal(t, bundle.Projects[0].GetId(), "zaphod")
+
+	configs := bundle.Projects[0].GetConfigs()
+	assert.Equal(t, len(configs), 2)
+	assert.Equal(t, configs[0].GetFullQualifiedId(), "zaphod/alerting-profile/profile")
+	assert.Equal(t, configs[1].GetFullQualifiedId(), "zaphod/calculated-metrics-log/metric")
+	assert.Assert(t, configs[1].HasDependencyOn(configs[0]))
+	assert.Assert(t, !configs[0].HasDependencyOn(configs[1]))
+
+	env := environment.NewEnvironment("prod", "Prod", "", "https://prod.live.dynatrace.com", "MONACO_BUNDLE_TEST_TOKEN")
+	dict := map[string]api.DynatraceEntity{
+		"zaphod/alerting-profile/profile": {Id: "1234", Name: "Dev Profile"},
+	}
+
+	name, err := configs[0].GetObjectNameForEnvironment(env, dict)
+	assert.NilError(t, err)
+	assert.Equal(t, name, "Dev Profile")
+
+	payload, err := configs[1].GetConfigForEnvironment(env, dict)
+	assert.NilError(t, err)
+	assert.Equal(t, string(payload), `{"name": "Metric", "profile": "1234", "description": "Dev Profile"}`)
+}
+
+func TestCreateStoresReferencedIdsAsPlaceholders(t *testing.T) {
+	fs := createTestProjects(t)
+
+	err := Create("projects", fs, "environments.yaml", "dev", "", "bundle.zip")
+	assert.NilError(t, err)
+
+	content, err := afero.ReadFile(fs, "bundle.zip")
+	assert.NilError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	assert.NilError(t, err)
+
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	payload, err := readFile(files, "configs/zaphod/calculated-metrics-log/metric.json")
+	assert.NilError(t, err)
+	assert.Equal(t, string(payload), `{"name": "Metric", "profile": "monaco-bundle-id[zaphod/alerting-profile/profile]", "description": "Dev Profile"}`)
+}
+
+func TestCreateInlinesAssetsOfConfigs(t *testing.T) {
+	fs := createTestProjects(t)
+	assert.NilError(t, afero.WriteFile(fs, "projects/zaphod/dashboard/dashboard.yaml", []byte("config:\n  - dashboard: \"dashboard.json\"\n\ndashboard:\n  - name: \"Dashboard\"\n"), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "projects/zaphod/dashboard/dashboard.json", []byte(`{"name": "{{.name}}", "image": "{{ asset "images/logo.png" }}"}`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "projects/zaphod/dashboard/images/logo.png", []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}, 0644))
+
+	err := Create("projects", fs, "environments.yaml", "dev", "", "bundle.zip")
+	assert.NilError(t, err)
+
+	// only the rendered payload is bundled, so the asset doesn't need to be deployed with the bundle
+	assert.NilError(t, fs.RemoveAll("projects"))
+
+	bundle, err := Load(fs, "bundle.zip")
+	assert.NilError(t, err)
+
+	for _, c := range bundle.Projects[0].GetConfigs() {
+		if c.GetFullQualifiedId() != "zaphod/dashboard/dashboard" {
+			continue
+		}
+
+		payload, err := c.GetConfigForEnvironment(environment.NewEnvironment("dev", "Dev", "", "https://dev.live.dynatrace.com", "MONACO_BUNDLE_TEST_TOKEN"), nil)
+		assert.NilError(t, err)
+		assert.Equal(t, string(payload), `{"name": "Dashboard", "image": "iVBORwD/"}`)
+		return
+	}
+	t.Fatal("bundle does not contain the dashboard")
+}
+
+func TestCreateFailsForMissingAssets(t *testing.T) {
+	fs := createTestProjects(t)
+	assert.NilError(t, afero.WriteFile(fs, "projects/zaphod/dashboard/dashboard.yaml", []byte("config:\n  - dashboard: \"dashboard.json\"\n\ndashboard:\n  - name: \"Dashboard\"\n"), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "projects/zaphod/dashboard/dashboard.json", []byte(`{"image": "{{ asset "images/logo.png" }}"}`), 0644))
+
+	err := Create("projects", fs, "environments.yaml", "dev", "", "bundle.zip")
+	assert.ErrorContains(t, err, "Errors while rendering the bundle")
+}
+
+func TestCreateRendersForTheSelectedEnvironment(t *testing.T) {
+	fs := createTestProjects(t)
+
+	err := Create("projects", fs, "environments.yaml", "prod", "", "bundle.zip")
+	assert.NilError(t, err)
+
+	bundle, err := Load(fs, "bundle.zip")
+	assert.NilError(t, err)
+
+	configs := bundle.Projects[0].GetConfigs()
+	assert.Equal(t, len(configs), 3)
+
+	name, err := configs[0].GetObjectNameForEnvironment(nil, map[string]api.DynatraceEntity{})
+	assert.NilError(t, err)
+	assert.Equal(t, name, "Profile")
+}
+
+func TestCreateFailsForSeveralEnvironments(t *testing.T) {
+	fs := createTestProjects(t)
+
+	err := Create("projects", fs, "environments.yaml", "", "", "bundle.zip")
+	assert.ErrorContains(t, err, "a bundle is rendered for a single environment, but 2 environments were selected")
+
+	exists, _ := afero.Exists(fs, "bundle.zip")
+	assert.Assert(t, !exists)
+}
+
+func TestResolvingReferenceToConfigNotDeployedFails(t *testing.T) {
+	fs := createTestProjects(t)
+
+	err := Create("projects", fs, "environments.yaml", "dev", "", "bundle.zip")
+	assert.NilError(t, err)
+
+	bundle, err := Load(fs, "bundle.zip")
+	assert.NilError(t, err)
+
+	_, err = bundle.Projects[0].GetConfigs()[1].GetConfigForEnvironment(nil, map[string]api.DynatraceEntity{})
+	assert.Error(t, err, "id of zaphod/alerting-profile/profile is not available, as it has not been deployed")
+}
+
+func TestLoadFailsForUnsupportedVersion(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := write(fs, "bundle.zip", manifest{Version: 42, Environment: "dev"}, map[string][]byte{})
+	assert.NilError(t, err)
+
+	_, err = Load(fs, "bundle.zip")
+	assert.Error(t, err, "unsupported version 42 of bundle bundle.zip: this version of monaco supports version 1")
+}
+
+func TestLoadFailsForUnknownApi(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	entry := manifestEntry{Project: "zaphod", Api: "unknown", Id: "profile", Name: "Profile", File: "configs/zaphod/unknown/profile.json"}
+	err := write(fs, "bundle.zip", manifest{Version: manifestVersion, Environment: "dev", Configs: []manifestEntry{entry}}, map[string][]byte{})
+	assert.NilError(t, err)
+
+	_, err = Load(fs, "bundle.zip")
+	assert.Error(t, err, "invalid bundle bundle.zip: unknown api unknown of config zaphod/unknown/profile")
+}
+
+func TestLoadFailsForMissingManifest(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	var buffer bytes.Buffer
+	assert.NilError(t, zip.NewWriter(&buffer).Close())
+	assert.NilError(t, afero.WriteFile(fs, "bundle.zip", buffer.Bytes(), 0644))
+
+	_, err := Load(fs, "bundle.zip")
+	assert.Error(t, err, "invalid bundle bundle.zip: manifest.json is missing")
+}
@@ -0,0 +1,190 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+)
+
+// bundleIdPlaceholderPattern matches the placeholders created by BundleIdPlaceholder
+var bundleIdPlaceholderPattern = regexp.MustCompile(`monaco-bundle-id\[([^\]]+)\]`)
+
+// BundleIdPlaceholder returns the placeholder for the id of the referenced config, which is written to bundled
+// payloads instead of the id. The id is only known once the referenced config is deployed.
+func BundleIdPlaceholder(referenceId string) string {
+	return "monaco-bundle-id[" + referenceId + "]"
+}
+
+// bundledConfig is a config read from a bundle. Its payload and object name have been rendered when the bundle was
+// created, except for the ids of referenced configs, which are resolved on deployment.
+type bundledConfig struct {
+	id                  string
+	project             string
+	api                 api.Api
+	objectName          string
+	parameters          map[string]string
+	payload             []byte
+	references          []string
+	skipIfExists        bool
+	fileName            string
+	requiredByConfigIds []string
+}
+
+// NewBundledConfig creates a config from a bundle. Project, id and references use / as separator, regardless of the
+// operating system the bundle was created on. Parameters contain the rendered parameters required to deploy the
+// config in addition to its name, e.g. the schemaId and scope of settings.
+func NewBundledConfig(id string, project string, api api.Api, objectName string, parameters map[string]string, payload []byte,
+	references []string, skipIfExists bool, fileName string) Config {
+
+	return &bundledConfig{
+		id:           id,
+		project:      project,
+		api:          api,
+		objectName:   objectName,
+		parameters:   parameters,
+		payload:      payload,
+		references:   references,
+		skipIfExists: skipIfExists,
+		fileName:     fileName,
+	}
+}
+
+// resolveBundleIdPlaceholders replaces the id placeholders in the value with the ids of the deployed configs
+func resolveBundleIdPlaceholders(value string, dict map[string]api.DynatraceEntity) (string, error) {
+	var err error
+
+	resolved := bundleIdPlaceholderPattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+		referenceId := bundleIdPlaceholderPattern.FindStringSubmatch(placeholder)[1]
+
+		entity, found := dict[referenceId]
+		if !found {
+			err = fmt.Errorf("id of %s is not available, as it has not been deployed", referenceId)
+			return placeholder
+		}
+		return entity.Id
+	})
+
+	return resolved, err
+}
+
+func (c *bundledConfig) GetConfigForEnvironment(_ environment.Environment, dict map[string]api.DynatraceEntity) ([]byte, error) {
+	payload, err := resolveBundleIdPlaceholders(string(c.payload), dict)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(payload), nil
+}
+
+// IsSkipDeployment is always false, as configs skipped for the environment are not bundled
+func (c *bundledConfig) IsSkipDeployment(_ environment.Environment) bool {
+	return false
+}
+
+func (c *bundledConfig) IsSkipIfExists(_ environment.Environment) bool {
+	return c.skipIfExists
+}
+
+// GetTokenName returns the name of the token rendered into the parameters of the config, if any
+func (c *bundledConfig) GetTokenName(_ environment.Environment) string {
+	return c.parameters[TokenNameParameter]
+}
+
+func (c *bundledConfig) GetApi() api.Api {
+	return c.api
+}
+
+func (c *bundledConfig) GetObjectNameForEnvironment(_ environment.Environment, dict map[string]api.DynatraceEntity) (string, error) {
+	return resolveBundleIdPlaceholders(c.objectName, dict)
+}
+
+func (c *bundledConfig) GetParameterForEnvironment(parameter string, _ environment.Environment, dict map[string]api.DynatraceEntity) (string, error) {
+	value, found := c.parameters[parameter]
+	if !found {
+		return "", fmt.Errorf("could not find %s property in bundled config %s", parameter, c.GetFullQualifiedId())
+	}
+	return resolveBundleIdPlaceholders(value, dict)
+}
+
+// HasDependencyOn checks whether the given config was referenced by this config when the bundle was created
+func (c *bundledConfig) HasDependencyOn(config Config) bool {
+	for _, reference := range c.references {
+		if reference == config.GetFullQualifiedId() {
+			config.addToRequiredByConfigIdList(c.GetFullQualifiedId())
+			return true
+		}
+	}
+	return false
+}
+
+// GetFilePath returns the path of the payload inside the bundle
+func (c *bundledConfig) GetFilePath() string {
+	return c.fileName
+}
+
+// GetReferencedEnvironmentTags returns no tags, as configs skipped for the environment are not bundled
+func (c *bundledConfig) GetReferencedEnvironmentTags() []string {
+	return nil
+}
+
+// GetReferencedEnvVars returns no environment variables, as they have been resolved when the bundle was created
+func (c *bundledConfig) GetReferencedEnvVars() []string {
+	return nil
+}
+
+func (c *bundledConfig) GetFullQualifiedId() string {
+	return strings.Join([]string{c.project, c.api.GetId(), c.id}, "/")
+}
+
+func (c *bundledConfig) GetType() string {
+	return c.api.GetId()
+}
+
+// GetMeIdsOfEnvironment returns no ME identifiers, as the properties are not part of the bundle
+func (c *bundledConfig) GetMeIdsOfEnvironment(_ environment.Environment) map[string]map[string]string {
+	return map[string]map[string]string{}
+}
+
+func (c *bundledConfig) GetId() string {
+	return c.id
+}
+
+func (c *bundledConfig) GetProject() string {
+	return c.project
+}
+
+// GetProperties returns no properties, as they have been resolved when the bundle was created
+func (c *bundledConfig) GetProperties() map[string]map[string]string {
+	return map[string]map[string]string{}
+}
+
+func (c *bundledConfig) GetRequiredByConfigIdList() []string {
+	return c.requiredByConfigIds
+}
+
+func (c *bundledConfig) addToRequiredByConfigIdList(config string) {
+	for _, existing := range c.requiredByConfigIds {
+		if existing == config {
+			return
+		}
+	}
+	c.requiredByConfigIds = append(c.requiredByConfigIds, config)
+}
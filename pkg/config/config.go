@@ -17,10 +17,12 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
@@ -34,10 +36,21 @@ import (
 type Config interface {
 	GetConfigForEnvironment(environment environment.Environment, dict map[string]api.DynatraceEntity) ([]byte, error)
 	IsSkipDeployment(environment environment.Environment) bool
+	IsSkipIfExists(environment environment.Environment) bool
+
+	// GetTokenName returns the name of the token of the environment the config is deployed with. It is empty for
+	// the default token.
+	GetTokenName(environment environment.Environment) string
 	GetApi() api.Api
 	GetObjectNameForEnvironment(environment environment.Environment, dict map[string]api.DynatraceEntity) (string, error)
+	GetParameterForEnvironment(parameter string, environment environment.Environment, dict map[string]api.DynatraceEntity) (string, error)
 	HasDependencyOn(config Config) bool
 	GetFilePath() string
+	GetReferencedEnvVars() []string
+
+	// GetReferencedEnvironmentTags returns the environment tags the config uses to select the environments it is
+	// deployed to
+	GetReferencedEnvironmentTags() []string
 	GetFullQualifiedId() string
 	GetType() string
 	GetMeIdsOfEnvironment(environment environment.Environment) map[string]map[string]string
@@ -50,8 +63,37 @@ type Config interface {
 
 var dependencySuffixes = []string{".id", ".name"}
 
+// propertyAccessor separates the referenced config from the path of a property in its rendered json,
+// e.g. `/project/dashboard/overview.property.dashboardMetadata.owner`
+const propertyAccessor = ".property."
+
+// responseAccessor separates the referenced config from the path of a field in the response of the API the config
+// was deployed to, e.g. `/project/request-attributes/attribute.response.metadata.clusterVersion`
+const responseAccessor = ".response."
+
 const skipConfigDeploymentParameter = "skipDeployment"
 
+// onlyEnvironmentsParameter and skipEnvironmentsParameter select the environments a config is deployed to by their
+// tags: a config is only deployed to environments with any of the tags of onlyEnvironments, and never to environments
+// with any of the tags of skipEnvironments
+const (
+	onlyEnvironmentsParameter = "onlyEnvironments"
+	skipEnvironmentsParameter = "skipEnvironments"
+)
+
+// skipIfExistsParameter marks create-only configs, which are never updated once they exist
+const skipIfExistsParameter = "skipIfExists"
+
+// TokenNameParameter selects a named token of the environment to deploy the config with, instead of its default token
+const TokenNameParameter = "tokenName"
+
+// SchemaIdParameter and ScopeParameter define the schema and scope of the Settings 2.0 object a config of the
+// settings api is deployed to
+const (
+	SchemaIdParameter = "schemaId"
+	ScopeParameter    = "scope"
+)
+
 type configImpl struct {
 	id                  string
 	project             string
@@ -61,6 +103,9 @@ type configImpl struct {
 	objectName          string
 	fileName            string
 	requiredByConfigIds []string
+
+	// log logs the rendering of the config, it is the default logger if nil
+	log *util.Logger
 }
 
 // configFactory is used to create new Configs - this is needed for testing purposes
@@ -68,27 +113,37 @@ type ConfigFactory interface {
 	NewConfig(fs afero.Fs, id string, project string, fileName string, properties map[string]map[string]string, api api.Api) (Config, error)
 }
 
-type configFactoryImpl struct{}
+type configFactoryImpl struct {
+	log *util.Logger
+}
 
-func NewConfigFactory() ConfigFactory {
-	return &configFactoryImpl{}
+// NewConfigFactory returns the factory of configs, which log their rendering to the given logger
+func NewConfigFactory(log *util.Logger) ConfigFactory {
+	return &configFactoryImpl{log: log}
 }
 
 func NewConfig(fs afero.Fs, id string, project string, fileName string, properties map[string]map[string]string, api api.Api) (Config, error) {
+	return loadConfig(fs, id, project, fileName, properties, api, nil)
+}
+
+// loadConfig loads the config from its template file. The rendering of the config is logged to log.
+func loadConfig(fs afero.Fs, id string, project string, fileName string, properties map[string]map[string]string, api api.Api, log *util.Logger) (Config, error) {
 
 	template, err := util.NewTemplate(fs, fileName)
 	if err != nil {
 		return nil, fmt.Errorf("loading config %s failed with %s", project+string(os.PathSeparator)+id, err)
 	}
 
-	return newConfig(id, project, template, filterProperties(id, properties), api, fileName), nil
+	config := newConfig(id, project, template, filterProperties(id, properties), api, fileName)
+	config.log = log
+	return config, nil
 }
 
 func NewConfigForDelete(id string, fileName string, properties map[string]map[string]string, api api.Api) Config {
 	return newConfig(id, "", nil, filterProperties(id, properties), api, fileName)
 }
 
-func newConfig(id string, project string, template util.Template, properties map[string]map[string]string, api api.Api, fileName string) Config {
+func newConfig(id string, project string, template util.Template, properties map[string]map[string]string, api api.Api, fileName string) *configImpl {
 	return &configImpl{
 		id:         id,
 		project:    project,
@@ -113,30 +168,80 @@ func filterProperties(id string, properties map[string]map[string]string) map[st
 	return result
 }
 
+// IsSkipDeployment returns true if the config is not deployed to the environment, either as skipDeployment is set,
+// or as the tags of the environment don't match onlyEnvironments or match skipEnvironments
 func (c *configImpl) IsSkipDeployment(environment environment.Environment) bool {
-	environmentKey := c.id + "." + environment.GetId()
+	if c.isParameterTrue(skipConfigDeploymentParameter, environment) {
+		return true
+	}
 
-	if properties, ok := c.properties[environmentKey]; ok {
-		if value, ok := properties[skipConfigDeploymentParameter]; ok {
-			return strings.EqualFold(value, "true")
-		}
+	if only, found := c.parameterValue(onlyEnvironmentsParameter, environment); found && !hasAnyTag(environment, only) {
+		return true
 	}
 
-	environmentGroupKey := c.id + "." + environment.GetGroup()
+	if skip, found := c.parameterValue(skipEnvironmentsParameter, environment); found && hasAnyTag(environment, skip) {
+		return true
+	}
+
+	return false
+}
+
+// hasAnyTag checks if the environment has any of the comma-separated tags
+func hasAnyTag(environment environment.Environment, tags string) bool {
+	for _, tag := range splitTags(tags) {
+		for _, environmentTag := range environment.GetTags() {
+			if tag == environmentTag {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	if properties, ok := c.properties[environmentGroupKey]; ok {
-		if value, ok := properties[skipConfigDeploymentParameter]; ok {
-			return strings.EqualFold(value, "true")
+// splitTags splits a comma-separated list of environment tags, ignoring empty tags
+func splitTags(tags string) []string {
+	var result []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
 		}
 	}
+	return result
+}
+
+// IsSkipIfExists returns true if the config is only created, and an existing object is never updated
+func (c *configImpl) IsSkipIfExists(environment environment.Environment) bool {
+	return c.isParameterTrue(skipIfExistsParameter, environment)
+}
+
+// GetTokenName returns the value of the tokenName parameter for the environment
+func (c *configImpl) GetTokenName(environment environment.Environment) string {
+	value, _ := c.parameterValue(TokenNameParameter, environment)
+	return strings.TrimSpace(value)
+}
+
+// isParameterTrue checks the value of the parameter for the environment, overriding the value of its group,
+// which overrides the default value
+func (c *configImpl) isParameterTrue(parameter string, environment environment.Environment) bool {
+	value, _ := c.parameterValue(parameter, environment)
+	return strings.EqualFold(value, "true")
+}
 
-	if properties, ok := c.properties[c.id]; ok {
-		if value, ok := properties[skipConfigDeploymentParameter]; ok {
-			return strings.EqualFold(value, "true")
+// parameterValue returns the unresolved value of the parameter for the environment, overriding the value of its
+// group, which overrides the default value. found is false if the parameter is not defined at all.
+func (c *configImpl) parameterValue(parameter string, environment environment.Environment) (value string, found bool) {
+	environmentKey := c.id + "." + environment.GetId()
+	environmentGroupKey := c.id + "." + environment.GetGroup()
+
+	for _, key := range []string{environmentKey, environmentGroupKey, c.id} {
+		if properties, ok := c.properties[key]; ok {
+			if value, ok := properties[parameter]; ok {
+				return value, true
+			}
 		}
 	}
 
-	return false
+	return "", false
 }
 
 func (c *configImpl) GetConfigForEnvironment(environment environment.Environment, dict map[string]api.DynatraceEntity) ([]byte, error) {
@@ -201,24 +306,30 @@ func (c *configImpl) GetConfigForEnvironment(environment environment.Environment
 }
 
 func (c *configImpl) GetObjectNameForEnvironment(environment environment.Environment, dict map[string]api.DynatraceEntity) (string, error) {
+	return c.GetParameterForEnvironment("name", environment, dict)
+}
+
+// GetParameterForEnvironment returns the value of the parameter for the environment, overriding the value of its
+// group, which overrides the default value. References to other configs are resolved.
+func (c *configImpl) GetParameterForEnvironment(parameter string, environment environment.Environment, dict map[string]api.DynatraceEntity) (string, error) {
 	environmentKey := c.id + "." + environment.GetId()
 	environmentGroupKey := c.id + "." + environment.GetGroup()
-	name := c.properties[environmentKey]["name"]
+	value := c.properties[environmentKey][parameter]
 	// assign group value if exists
-	if name == "" {
-		name = c.properties[environmentGroupKey]["name"]
+	if value == "" {
+		value = c.properties[environmentGroupKey][parameter]
 	}
 	// assign default value
-	if name == "" {
-		name = c.properties[c.id]["name"]
+	if value == "" {
+		value = c.properties[c.id][parameter]
 	}
-	if name == "" {
-		return "", fmt.Errorf("could not find name property in config %s, please make sure `name` is defined", c.GetFullQualifiedId())
+	if value == "" {
+		return "", fmt.Errorf("could not find %s property in config %s, please make sure `%s` is defined", parameter, c.GetFullQualifiedId(), parameter)
 	}
-	if isDependency(name) {
-		return c.parseDependency(name, dict)
+	if isDependency(value) {
+		return c.parseDependency(value, dict)
 	}
-	return name, nil
+	return value, nil
 }
 
 func copyProperties(original map[string]map[string]string) map[string]map[string]string {
@@ -257,7 +368,15 @@ func (c *configImpl) parseDependency(dependency string, dict map[string]api.Dyna
 		dependency = dependency[1:]
 	}
 
-	id, access, err := splitDependency(dependency)
+	if index := strings.Index(dependency, propertyAccessor); index > 0 {
+		return parsePropertyDependency(dependency[:index], dependency[index+len(propertyAccessor):], dict)
+	}
+
+	if index := strings.Index(dependency, responseAccessor); index > 0 {
+		return parseResponseDependency(dependency[:index], dependency[index+len(responseAccessor):], dict)
+	}
+
+	id, access, err := splitDependency(dependency, c.log)
 	if err != nil {
 		return "", err
 	}
@@ -276,16 +395,132 @@ func (c *configImpl) parseDependency(dependency string, dict map[string]api.Dyna
 	}
 }
 
+// parsePropertyDependency returns the value of the property at the given path in the rendered json of the
+// referenced config. Path segments are separated by dots, array elements are accessed by their index.
+func parsePropertyDependency(id string, path string, dict map[string]api.DynatraceEntity) (string, error) {
+	dtObject, ok := dict[id]
+	if !ok {
+		return "", errors.New("Id '" + id + "' was not available. Please make sure the reference exists.")
+	}
+
+	if len(dtObject.Payload) == 0 {
+		return "", fmt.Errorf("properties of %s are not available, only its id and name can be referenced", id)
+	}
+
+	value, found, err := jsonField(dtObject.Payload, path)
+	if err != nil {
+		return "", fmt.Errorf("properties of %s could not be read: %w", id, err)
+	}
+	if !found {
+		return "", fmt.Errorf("property %s does not exist in %s", path, id)
+	}
+	return value, nil
+}
+
+// parseResponseDependency returns the value of the field at the given path in the response of the API the referenced
+// config was deployed to. Configs which are only planned to be deployed, e.g. in a dry run, have no response, so a
+// placeholder is returned.
+func parseResponseDependency(id string, path string, dict map[string]api.DynatraceEntity) (string, error) {
+	dtObject, ok := dict[id]
+	if !ok {
+		return "", errors.New("Id '" + id + "' was not available. Please make sure the reference exists.")
+	}
+
+	if dtObject.Planned {
+		return fmt.Sprintf("<%s of %s after deployment>", path, id), nil
+	}
+
+	if len(dtObject.Response) == 0 {
+		return "", fmt.Errorf("field %s of the response of %s is not available, as %s has not been deployed in this run or its API returned no response", path, id, id)
+	}
+
+	value, found, err := jsonField(dtObject.Response, path)
+	if err != nil {
+		return "", fmt.Errorf("response of %s could not be read: %w", id, err)
+	}
+	if !found {
+		return "", fmt.Errorf("field %s does not exist in the response of %s", path, id)
+	}
+	return value, nil
+}
+
+// jsonField returns the value at the given path in the json content. Path segments are separated by dots, array
+// elements are accessed by their index. Found is false, if the path does not exist.
+func jsonField(content []byte, path string) (field string, found bool, err error) {
+	var value interface{}
+	if err := json.Unmarshal(content, &value); err != nil {
+		return "", false, err
+	}
+
+	var ok bool
+	for _, segment := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value, ok = v[segment]
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			ok = err == nil && index >= 0 && index < len(v)
+			if ok {
+				value = v[index]
+			}
+		default:
+			ok = false
+		}
+
+		if !ok {
+			return "", false, nil
+		}
+	}
+
+	if s, isString := value.(string); isString {
+		return s, true, nil
+	}
+
+	// other values are inserted as json, e.g. numbers or objects
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return "", false, fmt.Errorf("field %s could not be serialized: %w", path, err)
+	}
+	return string(serialized), true, nil
+}
+
+// ResponseReferences returns the references to the configs whose API response is used by the properties of the
+// config, e.g. `management-zone/zone` for `management-zone/zone.response.id`. References start without a separator.
+func ResponseReferences(c Config) []string {
+	var references []string
+	for _, properties := range c.GetProperties() {
+		for _, value := range properties {
+			if index := strings.Index(value, responseAccessor); index > 0 {
+				references = append(references, strings.TrimPrefix(value[:index], string(os.PathSeparator)))
+			}
+		}
+	}
+	return references
+}
+
 func isDependency(property string) bool {
+	_, ok := referencedConfig(property)
+	return ok
+}
+
+// referencedConfig returns the reference to the config a property value depends on, e.g. `management-zone/zone`
+// for `management-zone/zone.id`. It returns false if the value is no reference.
+func referencedConfig(value string) (string, bool) {
+	for _, accessor := range []string{propertyAccessor, responseAccessor} {
+		if index := strings.Index(value, accessor); index > 0 {
+			return value[:index], true
+		}
+	}
+
 	for _, suffix := range dependencySuffixes {
-		if strings.HasSuffix(property, suffix) {
-			return true
+		if strings.HasSuffix(value, suffix) {
+			return strings.TrimSuffix(value, suffix), true
 		}
 	}
-	return false
+	return "", false
 }
 
-func splitDependency(property string) (id string, access string, err error) {
+func splitDependency(property string, log *util.Logger) (id string, access string, err error) {
 	split := strings.Split(property, ".")
 	if len(split) < 2 {
 		return "", "", fmt.Errorf("property %s cannot be split", property)
@@ -293,7 +528,7 @@ func splitDependency(property string) (id string, access string, err error) {
 	firstPart, secondPart := split[0], split[1]
 
 	if len(split) > 2 {
-		util.Log.Debug("\t\t\tproperty %s contains more than the single expected `.` separator, using last separator for split", property)
+		log.Debug("\t\t\tproperty %s contains more than the single expected `.` separator, using last separator for split", property)
 		secondPart = split[len(split)-1]
 		firstPart = strings.TrimSuffix(property, "."+secondPart)
 	}
@@ -309,6 +544,12 @@ func (c *configImpl) GetRequiredByConfigIdList() []string {
 }
 
 func (c *configImpl) addToRequiredByConfigIdList(config string) {
+	// HasDependencyOn may be called multiple times for the same configs
+	for _, existing := range c.requiredByConfigIds {
+		if existing == config {
+			return
+		}
+	}
 	c.requiredByConfigIds = append(c.requiredByConfigIds, config)
 }
 
@@ -333,12 +574,9 @@ func (c *configImpl) GetProperties() map[string]map[string]string {
 func (c *configImpl) HasDependencyOn(config Config) bool {
 	for _, v := range c.properties {
 		for _, value := range v {
-			valueIndex := strings.LastIndex(value, ".")
-
-			// Check dependencies only for values ending with suffixes
-			// User can freely define values using dots, but .name$ and .id$ are reserved
-			if valueIndex != -1 && isDependency(value[valueIndex:]) {
-				valueString := value[:valueIndex]
+			// Check dependencies only for values ending with suffixes or accessing properties
+			// User can freely define values using dots, but .name$, .id$, .property. and .response. are reserved
+			if valueString, ok := referencedConfig(value); ok {
 
 				if strings.HasPrefix(valueString, string(os.PathSeparator)) {
 					// remove prefix "/"
@@ -382,6 +620,22 @@ func (c *configImpl) GetFilePath() string {
 	return c.fileName
 }
 
+// GetReferencedEnvVars returns the environment variables required to render the template of the config
+func (c *configImpl) GetReferencedEnvVars() []string {
+	return c.template.ReferencedEnvVars()
+}
+
+// GetReferencedEnvironmentTags returns the tags of onlyEnvironments and skipEnvironments of all environments and groups
+func (c *configImpl) GetReferencedEnvironmentTags() []string {
+	var tags []string
+	for _, properties := range c.properties {
+		for _, parameter := range []string{onlyEnvironmentsParameter, skipEnvironmentsParameter} {
+			tags = append(tags, splitTags(properties[parameter])...)
+		}
+	}
+	return tags
+}
+
 // GetFullQualifiedId returns the full qualified id of the config based on project, api and config id
 func (c *configImpl) GetFullQualifiedId() string {
 	return strings.Join([]string{c.GetProject(), c.GetApi().GetId(), c.GetId()}, string(os.PathSeparator))
@@ -389,7 +643,7 @@ func (c *configImpl) GetFullQualifiedId() string {
 
 // NewConfig creates a new Config
 func (c *configFactoryImpl) NewConfig(fs afero.Fs, id string, project string, fileName string, properties map[string]map[string]string, api api.Api) (Config, error) {
-	config, err := NewConfig(fs, id, project, fileName, properties, api)
+	config, err := loadConfig(fs, id, project, fileName, properties, api, c.log)
 	if err != nil {
 		return nil, err
 	}
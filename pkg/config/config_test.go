@@ -24,11 +24,13 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
 	"gotest.tools/assert"
 )
 
@@ -266,6 +268,98 @@ func TestGetConfigWithMergingGroupAndEnvironmentOverrides(t *testing.T) {
 	assert.Equal(t, "Follow the brown dog", productionResult["msg"])
 }
 
+const testYamlWithPerEnvironmentValues = `
+test:
+  - color:
+      .default: "white"
+      development: "black"
+      production: "brown"
+  - animalType:
+      development: "squid"
+      hardening: "rabbit"
+      production: "dog"
+`
+
+func TestGetConfigWithPerEnvironmentValues(t *testing.T) {
+	err, m := util.UnmarshalYaml(testYamlWithPerEnvironmentValues, "test.yaml")
+	assert.NilError(t, err)
+
+	templ := getTestTemplate(t)
+	config := newConfig("test", "testproject", templ, m, testManagementZoneApi, "")
+
+	devResult, err := getConfigForEnvironmentAsMap(config, testDevEnvironment, make(map[string]api.DynatraceEntity))
+	assert.NilError(t, err)
+	assert.Equal(t, "Follow the black squid", devResult["msg"])
+
+	hardeningResult, err := getConfigForEnvironmentAsMap(config, testHardeningEnvironment, make(map[string]api.DynatraceEntity))
+	assert.NilError(t, err)
+	assert.Equal(t, "Follow the white rabbit", hardeningResult["msg"])
+
+	// production is the group of the environment
+	productionResult, err := getConfigForEnvironmentAsMap(config, testProductionEnvironment, make(map[string]api.DynatraceEntity))
+	assert.NilError(t, err)
+	assert.Equal(t, "Follow the brown dog", productionResult["msg"])
+}
+
+func TestGetConfigWithTemplateFunctions(t *testing.T) {
+	templ, err := util.NewTemplateFromString("test", `{"name": "{{ .name | trim | upper }}", "threshold": {{ add .threshold 5 }}, "owner": "{{ default "nobody" .owner }}"}`)
+	assert.NilError(t, err)
+
+	properties := map[string]map[string]string{
+		"test": {"name": " availability ", "threshold": "90", "owner": ""},
+	}
+	config := newConfig("test", "testproject", templ, properties, testManagementZoneApi, "")
+
+	result, err := getConfigForEnvironmentAsMap(config, testDevEnvironment, make(map[string]api.DynatraceEntity))
+	assert.NilError(t, err)
+	assert.Equal(t, "AVAILABILITY", result["name"])
+	assert.Equal(t, float64(95), result["threshold"])
+	assert.Equal(t, "nobody", result["owner"])
+}
+
+func TestGetConfigWithJsonnetTemplate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "testproject/dashboard/dashboard.jsonnet", []byte(`{
+  name: std.extVar("name"),
+  owner: std.extVar("Env").ANIMAL,
+  dashboardFilter: { managementZone: { id: std.extVar("zoneId") } },
+}`), 0644))
+
+	util.SetEnv(t, "ANIMAL", "rabbit")
+	defer util.UnsetEnv(t, "ANIMAL")
+
+	properties := map[string]map[string]string{
+		"dashboard": {"name": "overview", "zoneId": "testproject/management-zone/zone.id"},
+	}
+	config, err := NewConfig(fs, "dashboard", "testproject", "testproject/dashboard/dashboard.jsonnet", properties, api.NewStandardApi("dashboard", "/api/config/v1/dashboards"))
+	assert.NilError(t, err)
+	assert.Assert(t, config.HasDependencyOn(newConfig("zone", "testproject", nil, nil, testManagementZoneApi, "")))
+
+	dict := map[string]api.DynatraceEntity{"testproject/management-zone/zone": {Id: "1234", Name: "zone"}}
+	result, err := getConfigForEnvironmentAsMap(config, testDevEnvironment, dict)
+	assert.NilError(t, err)
+	assert.Equal(t, "overview", result["name"])
+	assert.Equal(t, "rabbit", result["owner"])
+	assert.DeepEqual(t, map[string]interface{}{"managementZone": map[string]interface{}{"id": "1234"}}, result["dashboardFilter"])
+}
+
+func TestGetConfigWithPerEnvironmentValuesFailsIfValueIsMissing(t *testing.T) {
+	yaml := `
+test:
+  - color: "white"
+  - animalType:
+      development: "squid"
+`
+	err, m := util.UnmarshalYaml(yaml, "test.yaml")
+	assert.NilError(t, err)
+
+	templ := getTestTemplate(t)
+	config := newConfig("test", "testproject", templ, m, testManagementZoneApi, "")
+
+	_, err = config.GetConfigForEnvironment(testHardeningEnvironment, make(map[string]api.DynatraceEntity))
+	assert.ErrorContains(t, err, "map has no entry for key \"animalType\"")
+}
+
 func TestSkipConfigDeployment(t *testing.T) {
 
 	m := getTestPropertiesWithGroupAndEnvironment()
@@ -293,6 +387,29 @@ func TestSkipConfigDeployment(t *testing.T) {
 	assert.Equal(t, false, skipDeployment)
 }
 
+func TestSkipIfExists(t *testing.T) {
+	m := map[string]map[string]string{
+		"test":                  {"name": "Test", skipIfExistsParameter: "true"},
+		"test.prod-environment": {skipIfExistsParameter: "false"},
+	}
+	config := newConfig("test", "testproject", getTestTemplate(t), m, testManagementZoneApi, "")
+
+	assert.Equal(t, true, config.IsSkipIfExists(testDevEnvironment))
+	assert.Equal(t, false, config.IsSkipIfExists(testProductionEnvironment))
+	assert.Equal(t, false, config.IsSkipDeployment(testDevEnvironment))
+}
+
+func TestGetTokenName(t *testing.T) {
+	m := map[string]map[string]string{
+		"test":                  {"name": "Test"},
+		"test.prod-environment": {TokenNameParameter: " settings "},
+	}
+	config := newConfig("test", "testproject", getTestTemplate(t), m, testManagementZoneApi, "")
+
+	assert.Equal(t, "", config.GetTokenName(testDevEnvironment))
+	assert.Equal(t, "settings", config.GetTokenName(testProductionEnvironment))
+}
+
 // Test getting object name for environment
 // considering environment and group overrides
 func TestGetObjectNameForEnvironment(t *testing.T) {
@@ -328,6 +445,99 @@ func TestGetObjectNameForEnvironment(t *testing.T) {
 	assert.Error(t, err, expected)
 }
 
+func newTaggedTestEnvironment(t *testing.T, id string, tags string) environment.Environment {
+	environments, errs := environment.NewEnvironments(map[string]map[string]string{
+		"production." + id: {"name": id, "env-url": "https://" + id + ".live.dynatrace.com", "env-token-name": "TOKEN", "tags": tags},
+	})
+	assert.Equal(t, len(errs), 0)
+	return environments[id]
+}
+
+func TestIsSkipDeploymentWithEnvironmentTags(t *testing.T) {
+	euProduction := newTaggedTestEnvironment(t, "prod-eu", "production, eu")
+	usProduction := newTaggedTestEnvironment(t, "prod-us", "production, us")
+	untagged := newTaggedTestEnvironment(t, "sandbox", "")
+
+	tests := []struct {
+		name       string
+		properties map[string]map[string]string
+		skipped    []environment.Environment
+		deployed   []environment.Environment
+	}{
+		{
+			"only environments with any tag",
+			map[string]map[string]string{"test": {"onlyEnvironments": "eu, us"}},
+			[]environment.Environment{untagged},
+			[]environment.Environment{euProduction, usProduction},
+		},
+		{
+			"skip environments with any tag",
+			map[string]map[string]string{"test": {"skipEnvironments": "us"}},
+			[]environment.Environment{usProduction},
+			[]environment.Environment{euProduction, untagged},
+		},
+		{
+			"skip takes precedence over only",
+			map[string]map[string]string{"test": {"onlyEnvironments": "production", "skipEnvironments": "us"}},
+			[]environment.Environment{usProduction, untagged},
+			[]environment.Environment{euProduction},
+		},
+		{
+			"environment overrides group",
+			map[string]map[string]string{"test.production": {"onlyEnvironments": "us"}, "test.prod-eu": {"onlyEnvironments": "eu"}},
+			[]environment.Environment{untagged},
+			[]environment.Environment{euProduction, usProduction},
+		},
+		{
+			"skipDeployment overrides tags",
+			map[string]map[string]string{"test": {"onlyEnvironments": "production"}, "test.prod-eu": {"skipDeployment": "true"}},
+			[]environment.Environment{euProduction, untagged},
+			[]environment.Environment{usProduction},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := newConfig("test", "testproject", getTestTemplate(t), test.properties, testManagementZoneApi, "")
+
+			for _, environment := range test.skipped {
+				assert.Check(t, config.IsSkipDeployment(environment), environment.GetId())
+			}
+			for _, environment := range test.deployed {
+				assert.Check(t, !config.IsSkipDeployment(environment), environment.GetId())
+			}
+		})
+	}
+}
+
+func TestGetReferencedEnvironmentTags(t *testing.T) {
+	properties := map[string]map[string]string{
+		"test":            {"onlyEnvironments": "production, eu"},
+		"test.production": {"skipEnvironments": "us"},
+	}
+	config := newConfig("test", "testproject", getTestTemplate(t), properties, testManagementZoneApi, "")
+
+	tags := config.GetReferencedEnvironmentTags()
+	sort.Strings(tags)
+	assert.DeepEqual(t, tags, []string{"eu", "production", "us"})
+}
+
+func TestGetParameterForEnvironment(t *testing.T) {
+
+	m := getTestPropertiesWithGroupAndEnvironment()
+	m["test.production"][ScopeParameter] = "environment"
+	templ := getTestTemplate(t)
+	config := newConfig("test", "testproject", templ, m, testManagementZoneApi, "")
+
+	scope, err := config.GetParameterForEnvironment(ScopeParameter, testProductionEnvironment, make(map[string]api.DynatraceEntity))
+	assert.NilError(t, err)
+	assert.Equal(t, "environment", scope)
+
+	_, err = config.GetParameterForEnvironment(SchemaIdParameter, testProductionEnvironment, make(map[string]api.DynatraceEntity))
+	expected := util.ReplacePathSeparators("could not find schemaId property in config testproject/management-zone/test, please make sure `schemaId` is defined")
+	assert.Error(t, err, expected)
+}
+
 func getTestTemplate(t *testing.T) util.Template {
 	template, e := util.NewTemplateFromString("test", testTemplate)
 	assert.NilError(t, e)
@@ -523,6 +733,140 @@ func TestParseDependencyWithRelativePath(t *testing.T) {
 	assert.Equal(t, "zone", managementZoneId)
 }
 
+func TestParseDependencyWithProperty(t *testing.T) {
+	config := createConfigForTest("test", "testproject", getTestTemplate(t), make(map[string]map[string]string), testManagementZoneApi, "")
+
+	dict := map[string]api.DynatraceEntity{
+		"infrastructure/management-zone/zone": {
+			Id:      "zone",
+			Name:    "Test Management Zone",
+			Payload: []byte(`{"displayName": "Zone", "rules": [{"type": "SERVICE", "enabled": true}]}`),
+		},
+	}
+
+	value, err := config.parseDependency("/infrastructure/management-zone/zone.property.displayName", dict)
+	assert.NilError(t, err)
+	assert.Equal(t, value, "Zone")
+
+	value, err = config.parseDependency("infrastructure/management-zone/zone.property.rules.0.type", dict)
+	assert.NilError(t, err)
+	assert.Equal(t, value, "SERVICE")
+
+	value, err = config.parseDependency("infrastructure/management-zone/zone.property.rules.0.enabled", dict)
+	assert.NilError(t, err)
+	assert.Equal(t, value, "true")
+}
+
+func TestParseDependencyWithMissingProperty(t *testing.T) {
+	config := createConfigForTest("test", "testproject", getTestTemplate(t), make(map[string]map[string]string), testManagementZoneApi, "")
+
+	dict := map[string]api.DynatraceEntity{
+		"infrastructure/management-zone/zone": {
+			Id:      "zone",
+			Name:    "Test Management Zone",
+			Payload: []byte(`{"displayName": "Zone", "rules": []}`),
+		},
+		"infrastructure/management-zone/uploaded": {
+			Id:   "uploaded",
+			Name: "Uploaded Management Zone",
+		},
+	}
+
+	_, err := config.parseDependency("infrastructure/management-zone/zone.property.description", dict)
+	assert.Error(t, err, "property description does not exist in infrastructure/management-zone/zone")
+
+	_, err = config.parseDependency("infrastructure/management-zone/zone.property.rules.0.type", dict)
+	assert.Error(t, err, "property rules.0.type does not exist in infrastructure/management-zone/zone")
+
+	_, err = config.parseDependency("infrastructure/management-zone/uploaded.property.displayName", dict)
+	assert.Error(t, err, "properties of infrastructure/management-zone/uploaded are not available, only its id and name can be referenced")
+}
+
+func TestParseDependencyWithResponseField(t *testing.T) {
+	config := createConfigForTest("test", "testproject", getTestTemplate(t), make(map[string]map[string]string), testManagementZoneApi, "")
+
+	dict := map[string]api.DynatraceEntity{
+		"infrastructure/management-zone/zone": {
+			Id:       "zone",
+			Name:     "Test Management Zone",
+			Payload:  []byte(`{"displayName": "Zone"}`),
+			Response: []byte(`{"id": "zone", "metadata": {"key": "assigned-key", "version": 3}}`),
+		},
+		"infrastructure/management-zone/planned": {
+			Id:      "random-1",
+			Name:    "Planned Management Zone",
+			Planned: true,
+		},
+	}
+
+	value, err := config.parseDependency("/infrastructure/management-zone/zone.response.metadata.key", dict)
+	assert.NilError(t, err)
+	assert.Equal(t, value, "assigned-key")
+
+	value, err = config.parseDependency("infrastructure/management-zone/zone.response.metadata.version", dict)
+	assert.NilError(t, err)
+	assert.Equal(t, value, "3")
+
+	value, err = config.parseDependency("infrastructure/management-zone/planned.response.metadata.key", dict)
+	assert.NilError(t, err)
+	assert.Equal(t, value, "<metadata.key of infrastructure/management-zone/planned after deployment>")
+}
+
+func TestParseDependencyWithMissingResponseField(t *testing.T) {
+	config := createConfigForTest("test", "testproject", getTestTemplate(t), make(map[string]map[string]string), testManagementZoneApi, "")
+
+	dict := map[string]api.DynatraceEntity{
+		"infrastructure/management-zone/zone": {
+			Id:       "zone",
+			Name:     "Test Management Zone",
+			Response: []byte(`{"id": "zone"}`),
+		},
+		"infrastructure/management-zone/updated": {
+			Id:   "updated",
+			Name: "Updated Management Zone",
+		},
+	}
+
+	_, err := config.parseDependency("infrastructure/management-zone/zone.response.metadata.key", dict)
+	assert.Error(t, err, "field metadata.key does not exist in the response of infrastructure/management-zone/zone")
+
+	_, err = config.parseDependency("infrastructure/management-zone/updated.response.id", dict)
+	assert.Error(t, err, "field id of the response of infrastructure/management-zone/updated is not available, as infrastructure/management-zone/updated has not been deployed in this run or its API returned no response")
+}
+
+func TestResponseReferencesReturnsConfigsWhoseResponseIsReferenced(t *testing.T) {
+	prop := map[string]map[string]string{
+		"test": {
+			"name":     "A name",
+			"zoneKey":  util.ReplacePathSeparators("/testproject/management-zone/other.response.metadata.key"),
+			"zoneName": util.ReplacePathSeparators("/testproject/management-zone/zone.property.displayName"),
+		},
+	}
+	temp, e := util.NewTemplateFromString("test", "{{.name}}{{.zoneKey}}{{.zoneName}}")
+	assert.NilError(t, e)
+
+	config := newConfig("test", "testproject", temp, prop, testManagementZoneApi, "test.json")
+	otherConfig := newConfig("other", "testproject", temp, make(map[string]map[string]string), testManagementZoneApi, "other.json")
+
+	assert.DeepEqual(t, ResponseReferences(config), []string{util.ReplacePathSeparators("testproject/management-zone/other")})
+	assert.Equal(t, true, config.HasDependencyOn(otherConfig))
+}
+
+func TestHasDependencyOnConfigWithReferencedProperty(t *testing.T) {
+	prop := make(map[string]map[string]string)
+	prop["test"] = make(map[string]string)
+	prop["test"]["name"] = "A name"
+	prop["test"]["zoneName"] = util.ReplacePathSeparators("/testproject/management-zone/other.property.displayName")
+	temp, e := util.NewTemplateFromString("test", "{{.name}}{{.zoneName}}")
+	assert.NilError(t, e)
+
+	config := newConfig("test", "testproject", temp, prop, testManagementZoneApi, "test.json")
+
+	otherConfig := newConfig("other", "testproject", temp, make(map[string]map[string]string), testManagementZoneApi, "other.json")
+
+	assert.Equal(t, true, config.HasDependencyOn(otherConfig))
+}
+
 func TestGetConfigStringWithEnvVar(t *testing.T) {
 
 	templ := getTestTemplateWithEnvVars(t)
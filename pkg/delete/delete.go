@@ -36,8 +36,8 @@ type deleteYaml struct {
 }
 
 // LoadConfigsToDelete loads the delete.yaml file (if available) and converts its entries into configs which need
-// to be deleted
-func LoadConfigsToDelete(fs afero.Fs, apis map[string]api.Api, path string) (configs []config.Config, err error) {
+// to be deleted. Missing and invalid delete files are logged to log.
+func LoadConfigsToDelete(fs afero.Fs, apis map[string]api.Api, path string, log *util.Logger) (configs []config.Config, err error) {
 
 	result := make([]config.Config, 0)
 
@@ -45,19 +45,19 @@ func LoadConfigsToDelete(fs afero.Fs, apis map[string]api.Api, path string) (con
 	data, err := afero.ReadFile(fs, deleteFilePath)
 	if err != nil {
 		// Don't raise an error. The delete.yaml might not be there, that's a valid case
-		util.Log.Info("There is no delete file %s found in %s. Skipping delete config.", deleteFileName, deleteFilePath)
+		log.Info("There is no delete file %s found in %s. Skipping delete config.", deleteFileName, deleteFilePath)
 		return result, nil
 	}
 
-	list, err := unmarshalDeleteYaml(string(data), deleteFileName)
-	if util.CheckError(err, deleteFileName+" file content was invalid") {
+	list, err := unmarshalDeleteYaml(string(data), deleteFileName, log)
+	if log.CheckError(err, deleteFileName+" file content was invalid") {
 		return configs, err
 	}
 
 	for _, element := range list {
 
 		configType, name, err := splitConfigToDelete(element)
-		if util.CheckError(err, "deletion failed") {
+		if log.CheckError(err, "deletion failed") {
 			return configs, err
 		}
 
@@ -102,17 +102,17 @@ func splitConfigToDelete(config string) (configType string, name string, err err
 //  - "list-entry-1"
 //  - "list-entry-2"
 //
-func unmarshalDeleteYaml(text string, fileName string) (typed []string, err error) {
+func unmarshalDeleteYaml(text string, fileName string, log *util.Logger) (typed []string, err error) {
 
 	d := deleteYaml{}
 
 	err = yaml.Unmarshal([]byte(text), &d)
-	if util.CheckError(err, "Failed to unmarshal yaml\n"+text+"for file name"+fileName+"\nerror:") {
+	if log.CheckError(err, "Failed to unmarshal yaml\n"+text+"for file name"+fileName+"\nerror:") {
 		return typed, err
 	}
 
 	typed, err = convertList(d)
-	if util.CheckError(err, "Failed to unmarshal yaml\n"+text+"for file name"+fileName+"\nerror:") {
+	if log.CheckError(err, "Failed to unmarshal yaml\n"+text+"for file name"+fileName+"\nerror:") {
 		return typed, err
 	}
 
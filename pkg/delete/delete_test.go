@@ -20,6 +20,7 @@
 package delete
 
 import (
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 	"gotest.tools/assert"
 	"testing"
 )
@@ -34,7 +35,7 @@ delete:
 
 func TestUnmarshalDeleteYaml(t *testing.T) {
 
-	result, e := unmarshalDeleteYaml(testYamlList, "test-yaml")
+	result, e := unmarshalDeleteYaml(testYamlList, "test-yaml", util.DefaultLogger())
 	assert.NilError(t, e)
 
 	assert.Check(t, len(result) == 4)
@@ -0,0 +1,111 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+// createAutomationTestProject creates a project with a scheduling rule and a workflow triggered by the rule
+func createAutomationTestProject(t *testing.T) project.Project {
+	apis := api.NewApis()
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "weekdays.json", []byte(`{"title": "{{.name}}", "ruleType": "recurrence"}`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "report.json", []byte(`{"title": "{{.name}}", "tasks": {}, "trigger": {"schedule": {"rule": "{{.rule}}"}}}`), 0644))
+
+	rule, err := config.NewConfig(fs, "weekdays", "proj", "weekdays.json", map[string]map[string]string{"weekdays": {"name": "Weekdays"}}, apis["scheduling-rule"])
+	assert.NilError(t, err)
+	workflow, err := config.NewConfig(fs, "report", "proj", "report.json", map[string]map[string]string{"report": {"name": "Daily report", "rule": "proj/scheduling-rule/weekdays.id"}}, apis["workflow"])
+	assert.NilError(t, err)
+
+	return &testProject{id: "proj", configs: []config.Config{rule, workflow}}
+}
+
+func TestExecuteSerialDeploysAutomationObjectsById(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	server := rest.NewAutomationServer()
+	client, closeServer := rest.NewAutomationTestClient(server)
+	defer closeServer()
+
+	ruleId := automationObjectId("proj", "scheduling-rule", "weekdays")
+	workflowId := automationObjectId("proj", "workflow", "report")
+
+	for _, expected := range []resultAction{resultCreated, resultUpdated} {
+		report := newDeploymentReport()
+		errors := executeSerial(context.Background(), client, environment, []project.Project{createAutomationTestProject(t)}, false, "", false, newDeploymentSummary(), report, newDeploymentState())
+		assert.Equal(t, len(errors), 0)
+
+		assert.Equal(t, len(report.results), 2)
+		assert.Equal(t, report.results[0].Action, expected)
+		assert.Equal(t, report.results[0].EntityId, ruleId)
+		assert.Equal(t, report.results[1].Action, expected)
+		assert.Equal(t, report.results[1].EntityId, workflowId)
+	}
+
+	rules := server.Objects("/platform/automation/v1/scheduling-rules")
+	assert.Equal(t, len(rules), 1)
+	assert.Equal(t, rules[0]["id"], ruleId)
+	assert.Equal(t, rules[0]["title"], "Weekdays")
+
+	workflows := server.Objects("/platform/automation/v1/workflows")
+	assert.Equal(t, len(workflows), 1)
+	assert.Equal(t, workflows[0]["id"], workflowId)
+	assert.Equal(t, workflows[0]["title"], "Daily report")
+	assert.DeepEqual(t, workflows[0]["trigger"], map[string]interface{}{"schedule": map[string]interface{}{"rule": ruleId}})
+}
+
+func TestDryRunPlansAutomationObjectsById(t *testing.T) {
+	server := rest.NewAutomationServer()
+	server.Add("/platform/automation/v1/scheduling-rules", `{"id": "`+automationObjectId("proj", "scheduling-rule", "weekdays")+`", "title": "Weekdays"}`)
+	client, closeServer := rest.NewAutomationTestClient(server)
+	defer closeServer()
+
+	configs := createAutomationTestProject(t).GetConfigs()
+
+	action, _, err := plannedAction(client, configs[0], "Weekdays", api.SettingsObject{}, automationObjectId("proj", "scheduling-rule", "weekdays"))
+	assert.NilError(t, err)
+	assert.Equal(t, action, actionUpdate)
+
+	action, _, err = plannedAction(client, configs[1], "Daily report", api.SettingsObject{}, automationObjectId("proj", "workflow", "report"))
+	assert.NilError(t, err)
+	assert.Equal(t, action, actionCreate)
+}
+
+func TestAutomationObjectIdIsStableUuidNamespacedByProject(t *testing.T) {
+	id := automationObjectId("proj", "workflow", "report")
+	assert.Equal(t, id, automationObjectId("proj", "workflow", "report"))
+	assert.Assert(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id), id)
+
+	assert.Assert(t, id != automationObjectId("other-proj", "workflow", "report"))
+	assert.Assert(t, id != automationObjectId("proj", "scheduling-rule", "report"))
+	assert.Equal(t, automationObjectId(filepath.Join("proj", "sub"), "workflow", "report"), automationObjectId("proj/sub", "workflow", "report"))
+}
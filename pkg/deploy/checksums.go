@@ -0,0 +1,184 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/spf13/afero"
+)
+
+// checksumState contains the checksums of the configs deployed by previous deployments, by environment and reference
+// id of the config. Configs whose checksum didn't change are not deployed again. It is safe for concurrent use.
+type checksumState struct {
+	mutex   sync.Mutex
+	entries map[string]map[string]checksumEntry
+
+	// ignoreState deploys all configs, regardless of their checksums
+	ignoreState bool
+
+	// refresh only skips unchanged configs whose object still exists in the environment
+	refresh bool
+}
+
+// checksumEntry is a config deployed by a previous deployment
+type checksumEntry struct {
+	Checksum string `json:"checksum"`
+	Id       string `json:"id"`
+}
+
+// checksumStateFile is the content of the state file
+type checksumStateFile struct {
+	Environments map[string]map[string]checksumEntry `json:"environments"`
+}
+
+func newChecksumState() *checksumState {
+	return &checksumState{entries: make(map[string]map[string]checksumEntry)}
+}
+
+// loadChecksumState reads the state from the given file. If the file doesn't exist yet, an empty state is returned.
+func loadChecksumState(fs afero.Fs, file string) (*checksumState, error) {
+	exists, err := afero.Exists(fs, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read state file %s: %w", file, err)
+	}
+	if !exists {
+		return newChecksumState(), nil
+	}
+
+	content, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read state file %s: %w", file, err)
+	}
+
+	var stateFile checksumStateFile
+	err = json.Unmarshal(content, &stateFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse state file %s: %w", file, err)
+	}
+
+	state := newChecksumState()
+	if stateFile.Environments != nil {
+		state.entries = stateFile.Environments
+	}
+	return state, nil
+}
+
+// unchanged returns whether the config was deployed with the same checksum before, and ignoreState is not set
+func (s *checksumState) unchanged(environment string, referenceId string, checksum string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.ignoreState {
+		return false
+	}
+
+	entry, found := s.entries[environment][referenceId]
+	return found && entry.Checksum == checksum
+}
+
+func (s *checksumState) put(environment string, referenceId string, checksum string, id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.entries[environment] == nil {
+		s.entries[environment] = make(map[string]checksumEntry)
+	}
+	s.entries[environment][referenceId] = checksumEntry{Checksum: checksum, Id: id}
+}
+
+// remove forgets the config, e.g. because its object doesn't exist anymore
+func (s *checksumState) remove(environment string, referenceId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries[environment], referenceId)
+}
+
+// write writes the state as json to the given file
+func (s *checksumState) write(fs afero.Fs, file string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	content, err := json.MarshalIndent(checksumStateFile{Environments: s.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = afero.WriteFile(fs, file, content, 0644)
+	if err != nil {
+		return fmt.Errorf("could not write state file %s: %w", file, err)
+	}
+	return nil
+}
+
+// configChecksum returns the checksum of everything deploying a config sends to the API: the api, the object name,
+// the rendered payload including the resolved references, and the schema and scope of settings
+func configChecksum(theApi api.Api, objectName string, payload []byte, settings api.SettingsObject) string {
+	hash := sha256.New()
+	for _, part := range []string{theApi.GetId(), objectName, settings.SchemaId, settings.Scope, settings.ExternalId} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(payload)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// containsPayload returns whether the live object read from the API contains all fields of the deployed payload with
+// the same values. The API adds fields like the id to the object, which are ignored.
+func containsPayload(live []byte, payload []byte) bool {
+	var liveValue, payloadValue interface{}
+	if json.Unmarshal(live, &liveValue) != nil || json.Unmarshal(payload, &payloadValue) != nil {
+		return false
+	}
+	return containsJsonValue(liveValue, payloadValue)
+}
+
+func containsJsonValue(live interface{}, expected interface{}) bool {
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		liveMap, ok := live.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range expected {
+			liveValue, found := liveMap[key]
+			if !found || !containsJsonValue(liveValue, value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		liveList, ok := live.([]interface{})
+		if !ok || len(liveList) != len(expected) {
+			return false
+		}
+		for i := range expected {
+			if !containsJsonValue(liveList[i], expected[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return live == expected
+	}
+}
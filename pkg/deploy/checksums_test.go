@@ -0,0 +1,198 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+// executeWithChecksums deploys the test project with the given profile reference to the dev environment, storing the
+// checksums in the given state
+func executeWithChecksums(t *testing.T, client rest.DynatraceClient, profileReference string, checksums *checksumState) *deploymentReport {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": profileReference}),
+			createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/profile.id"}),
+		},
+	}}
+
+	state := newDeploymentState()
+	state.checksums = checksums
+	report := newDeploymentReport()
+
+	errors := executeSerial(context.Background(), client, environment, projects, false, "", false, newDeploymentSummary(), report, state)
+	assert.Equal(t, len(errors), 0)
+	return report
+}
+
+// expectInitialDeployment expects the test project to be created by the first deployment
+func expectInitialDeployment(client *rest.MockDynatraceClient) {
+	client.EXPECT().UpsertByName(testProfileApi, "profile", []byte(`{"name": "profile", "reference": "none"}`)).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+}
+
+func TestChecksumStateIsWrittenAndLoaded(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	state := newChecksumState()
+	state.put("dev", "proj/alerting-profile/profile", "checksum", "profile-id")
+	assert.NilError(t, state.write(fs, "state.json"))
+
+	loaded, err := loadChecksumState(fs, "state.json")
+	assert.NilError(t, err)
+	assert.Assert(t, loaded.unchanged("dev", "proj/alerting-profile/profile", "checksum"))
+	assert.Assert(t, !loaded.unchanged("dev", "proj/alerting-profile/profile", "other checksum"))
+	assert.Assert(t, !loaded.unchanged("prod", "proj/alerting-profile/profile", "checksum"))
+
+	loaded.ignoreState = true
+	assert.Assert(t, !loaded.unchanged("dev", "proj/alerting-profile/profile", "checksum"))
+}
+
+func TestLoadChecksumStateReturnsEmptyStateIfFileDoesNotExist(t *testing.T) {
+	state, err := loadChecksumState(afero.NewMemMapFs(), "state.json")
+	assert.NilError(t, err)
+	assert.Equal(t, len(state.entries), 0)
+}
+
+func TestConfigChecksumChangesWithPayloadAndSettings(t *testing.T) {
+	checksum := configChecksum(testProfileApi, "profile", []byte(`{}`), api.SettingsObject{})
+	assert.Equal(t, checksum, configChecksum(testProfileApi, "profile", []byte(`{}`), api.SettingsObject{}))
+
+	assert.Assert(t, checksum != configChecksum(testProfileApi, "profile", []byte(`{"a": 1}`), api.SettingsObject{}))
+	assert.Assert(t, checksum != configChecksum(testProfileApi, "other", []byte(`{}`), api.SettingsObject{}))
+	assert.Assert(t, checksum != configChecksum(testMetricApi, "profile", []byte(`{}`), api.SettingsObject{}))
+	assert.Assert(t, checksum != configChecksum(testProfileApi, "profile", []byte(`{}`), api.SettingsObject{Scope: "HOST-1234"}))
+}
+
+func TestExecuteSerialSkipsUnchangedConfigs(t *testing.T) {
+	client := rest.CreateDynatraceClientMockFactory(t)
+	checksums := newChecksumState()
+
+	expectInitialDeployment(client)
+	executeWithChecksums(t, client, "none", checksums)
+
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "profile-id", nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(true, "metric-id", nil)
+
+	report := executeWithChecksums(t, client, "none", checksums)
+	assert.Equal(t, report.results[0].Action, resultSkipped)
+	assert.Equal(t, report.results[0].EntityId, "profile-id")
+	assert.Equal(t, report.results[1].Action, resultSkipped)
+}
+
+func TestExecuteSerialDeploysChangedConfigs(t *testing.T) {
+	client := rest.CreateDynatraceClientMockFactory(t)
+	checksums := newChecksumState()
+
+	expectInitialDeployment(client)
+	executeWithChecksums(t, client, "none", checksums)
+
+	client.EXPECT().UpsertByName(testProfileApi, "profile", []byte(`{"name": "profile", "reference": "changed"}`)).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(true, "metric-id", nil)
+
+	report := executeWithChecksums(t, client, "changed", checksums)
+	assert.Equal(t, report.results[0].Action, resultUpdated)
+	assert.Equal(t, report.results[1].Action, resultSkipped)
+
+	// the checksum of the changed payload is stored
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "profile-id", nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(true, "metric-id", nil)
+
+	report = executeWithChecksums(t, client, "changed", checksums)
+	assert.Equal(t, report.results[0].Action, resultSkipped)
+}
+
+func TestExecuteSerialDeploysUnchangedConfigsWhoseObjectWasDeleted(t *testing.T) {
+	client := rest.CreateDynatraceClientMockFactory(t)
+	checksums := newChecksumState()
+
+	expectInitialDeployment(client)
+	executeWithChecksums(t, client, "none", checksums)
+
+	// the metric references the new id of the profile, so it changed as well
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(false, "", nil)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).Return(api.DynatraceEntity{Id: "new-profile-id", Name: "profile", Created: true}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "new-profile-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	report := executeWithChecksums(t, client, "none", checksums)
+	assert.Equal(t, report.results[0].Action, resultCreated)
+	assert.Equal(t, report.results[1].Action, resultUpdated)
+}
+
+func TestExecuteSerialIgnoringStateDeploysUnchangedConfigs(t *testing.T) {
+	client := rest.CreateDynatraceClientMockFactory(t)
+	checksums := newChecksumState()
+
+	expectInitialDeployment(client)
+	executeWithChecksums(t, client, "none", checksums)
+
+	checksums.ignoreState = true
+	expectInitialDeployment(client)
+
+	report := executeWithChecksums(t, client, "none", checksums)
+	assert.Equal(t, report.results[0].Action, resultUpdated)
+	assert.Equal(t, report.results[1].Action, resultUpdated)
+}
+
+func TestExecuteSerialRefreshDeploysConfigsChangedInEnvironment(t *testing.T) {
+	client := rest.CreateDynatraceClientMockFactory(t)
+	checksums := newChecksumState()
+
+	expectInitialDeployment(client)
+	executeWithChecksums(t, client, "none", checksums)
+
+	checksums.refresh = true
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "profile-id", nil)
+	client.EXPECT().ReadById(testProfileApi, "profile-id").Return([]byte(`{"id": "profile-id", "name": "profile", "reference": "edited"}`), nil)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", []byte(`{"name": "profile", "reference": "none"}`)).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(true, "metric-id", nil)
+	client.EXPECT().ReadById(testMetricApi, "metric-id").Return([]byte(`{"id": "metric-id", "name": "metric", "reference": "profile-id"}`), nil)
+
+	report := executeWithChecksums(t, client, "none", checksums)
+	assert.Equal(t, report.results[0].Action, resultUpdated)
+	assert.Equal(t, report.results[1].Action, resultSkipped)
+}
+
+func TestContainsPayloadIgnoresFieldsAddedByApi(t *testing.T) {
+	assert.Assert(t, containsPayload([]byte(`{"id": "1", "name": "a", "rules": [{"value": 1, "enabled": true}]}`), []byte(`{"name": "a", "rules": [{"value": 1}]}`)))
+	assert.Assert(t, !containsPayload([]byte(`{"id": "1", "name": "b"}`), []byte(`{"name": "a"}`)))
+	assert.Assert(t, !containsPayload([]byte(`{"rules": [{"value": 1}, {"value": 2}]}`), []byte(`{"rules": [{"value": 1}]}`)))
+	assert.Assert(t, !containsPayload([]byte(`not found`), []byte(`{"name": "a"}`)))
+}
+
+func TestDeployRejectsIgnoringStateWithoutStateFile(t *testing.T) {
+	opts := NewOptions()
+	opts.IgnoreState = true
+
+	_, err := Deploy(context.Background(), afero.NewMemMapFs(), ".", "environments.yaml", opts)
+	assert.Error(t, err, "ignoring the state or refreshing the deployment of unchanged configs requires a state file")
+}
@@ -0,0 +1,114 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+)
+
+// DeploymentTarget is an environment affected by a deployment
+type DeploymentTarget struct {
+	Environment string
+
+	// Production is true, if the environment is flagged as production in the environments file
+	Production bool
+
+	// Configs is the number of configs deployed to the environment, without the configs skipped for it
+	Configs int
+}
+
+// Confirmation is asked before deploying to production environments. It receives every environment affected by the
+// deployment, sorted by id, and aborts the deployment by returning an error.
+type Confirmation func(targets []DeploymentTarget) error
+
+// NewConsoleConfirmation returns a confirmation which lists the affected environments on out and requires the name of
+// every production environment to be typed on in
+func NewConsoleConfirmation(in io.Reader, out io.Writer) Confirmation {
+	reader := bufio.NewReader(in)
+
+	return func(targets []DeploymentTarget) error {
+		fmt.Fprintln(out, "The deployment affects the following environments:")
+		for _, target := range targets {
+			suffix := ""
+			if target.Production {
+				suffix = " (production)"
+			}
+			fmt.Fprintf(out, "\t%s: %d configs%s\n", target.Environment, target.Configs, suffix)
+		}
+
+		for _, target := range targets {
+			if !target.Production {
+				continue
+			}
+
+			fmt.Fprintf(out, "Type the name of the production environment %s to deploy to it: ", target.Environment)
+			answer, err := reader.ReadString('\n')
+			if strings.TrimSpace(answer) != target.Environment {
+				fmt.Fprintln(out)
+				return fmt.Errorf("deployment to production environment %s was not confirmed", target.Environment)
+			}
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("could not read confirmation of production environment %s: %w", target.Environment, err)
+			}
+		}
+		return nil
+	}
+}
+
+// deploymentTargets returns the environments affected by deploying the projects, sorted by id
+func deploymentTargets(projects []project.Project, environments map[string]environment.Environment) []DeploymentTarget {
+	targets := make([]DeploymentTarget, 0, len(environments))
+
+	for _, environment := range environments {
+		target := DeploymentTarget{Environment: environment.GetId(), Production: environment.IsProduction()}
+		for _, project := range projects {
+			for _, config := range project.GetConfigs() {
+				if !config.IsSkipDeployment(environment) {
+					target.Configs++
+				}
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Environment < targets[j].Environment
+	})
+	return targets
+}
+
+// confirmDeployment asks for confirmation, if any of the targets is a production environment. Without confirmation,
+// all deployments proceed.
+func confirmDeployment(confirm Confirmation, targets []DeploymentTarget) error {
+	if confirm == nil {
+		return nil
+	}
+
+	for _, target := range targets {
+		if target.Production {
+			return confirm(targets)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+var confirmTestTargets = []DeploymentTarget{
+	{Environment: "dev", Configs: 3},
+	{Environment: "prod", Production: true, Configs: 2},
+	{Environment: "prod-eu", Production: true, Configs: 2},
+}
+
+func TestConsoleConfirmationRequiresNamesOfProductionEnvironments(t *testing.T) {
+	var out bytes.Buffer
+	confirm := NewConsoleConfirmation(strings.NewReader("prod\n prod-eu \n"), &out)
+
+	assert.NilError(t, confirm(confirmTestTargets))
+	assert.Equal(t, out.String(), "The deployment affects the following environments:\n"+
+		"\tdev: 3 configs\n"+
+		"\tprod: 2 configs (production)\n"+
+		"\tprod-eu: 2 configs (production)\n"+
+		"Type the name of the production environment prod to deploy to it: "+
+		"Type the name of the production environment prod-eu to deploy to it: ")
+}
+
+func TestConsoleConfirmationFailsOnOtherNames(t *testing.T) {
+	for _, input := range []string{"prod\nprod\n", "prod\n", "", "y\n"} {
+		confirm := NewConsoleConfirmation(strings.NewReader(input), &bytes.Buffer{})
+		assert.ErrorContains(t, confirm(confirmTestTargets), "was not confirmed", input)
+	}
+}
+
+func TestConsoleConfirmationAcceptsNameWithoutNewLine(t *testing.T) {
+	confirm := NewConsoleConfirmation(strings.NewReader("prod"), &bytes.Buffer{})
+	assert.NilError(t, confirm(confirmTestTargets[:2]))
+}
+
+func TestConfirmDeploymentOnlyAsksForProductionEnvironments(t *testing.T) {
+	asked := 0
+	confirm := func([]DeploymentTarget) error {
+		asked++
+		return errors.New("not confirmed")
+	}
+
+	assert.NilError(t, confirmDeployment(confirm, confirmTestTargets[:1]))
+	assert.Equal(t, asked, 0)
+
+	assert.ErrorContains(t, confirmDeployment(confirm, confirmTestTargets), "not confirmed")
+	assert.Equal(t, asked, 1)
+
+	assert.NilError(t, confirmDeployment(nil, confirmTestTargets))
+}
+
+func TestDeploymentTargetsCountConfigsNotSkipped(t *testing.T) {
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile"}),
+			createTestConfigWithProperties(t, "skipped", testProfileApi, map[string]string{"name": "skipped", "skipDeployment": "true"}),
+		},
+	}}
+	environments := map[string]environment.Environment{
+		"prod": environment.NewEnvironment("prod", "Prod", "", "https://url/to/prod/environment", "PROD"),
+		"dev":  environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"),
+	}
+
+	assert.DeepEqual(t, deploymentTargets(projects, environments), []DeploymentTarget{
+		{Environment: "dev", Configs: 1},
+		{Environment: "prod", Configs: 1},
+	})
+}
+
+func TestDeployAbortsProductionDeploymentsNotConfirmed(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"environments.yaml":                  "prod:\n  - name: \"Prod\"\n  - env-url: \"https://url/to/prod/environment\"\n  - env-token-name: \"CONFIRM_TEST_TOKEN\"\n  - production: \"true\"\n",
+		"proj/alerting-profile/profile.yaml": "config:\n  - profile: \"profile.json\"\n\nprofile:\n  - name: \"profile\"\n",
+		"proj/alerting-profile/profile.json": `{"displayName": "{{.name}}"}`,
+	}
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+
+	var targets []DeploymentTarget
+	opts := NewOptions()
+	opts.SkipPreflight = true
+	opts.LogWriter = &bytes.Buffer{}
+	opts.Confirm = func(affected []DeploymentTarget) error {
+		targets = affected
+		return errors.New("deployment to production environment prod was not confirmed")
+	}
+
+	result, err := Deploy(context.Background(), fs, ".", "environments.yaml", opts)
+	assert.ErrorContains(t, err, "deployment to production environment prod was not confirmed")
+	assert.DeepEqual(t, targets, []DeploymentTarget{{Environment: "prod", Production: true, Configs: 1}})
+	assert.Equal(t, len(result.Configs), 0)
+}
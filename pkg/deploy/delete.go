@@ -0,0 +1,318 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/delete"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// deleteConfigs deletes the configs specified in the delete.yaml file, if available, from all environments. During a
+// dry run, the configs which would be deleted are only logged. The APIs of the configs and the configs of the projects
+// are used to delete dependents before the configs they reference.
+func deleteConfigs(apis map[string]api.Api, environments map[string]environment.Environment, projects []project.Project, path string, dryRun bool, fs afero.Fs,
+	log *util.Logger) (errors []error) {
+	configs, err := delete.LoadConfigsToDelete(fs, apis, path, log)
+	if err != nil {
+		return []error{fmt.Errorf("deletion failed: %w", err)}
+	}
+
+	if len(configs) == 0 {
+		return nil
+	}
+
+	var deployed []config.Config
+	for _, p := range projects {
+		deployed = append(deployed, p.GetConfigs()...)
+	}
+
+	names := make([]string, 0, len(environments))
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		environment := environments[name]
+
+		client, err := createDeleteClient(environment, dryRun, log)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("could not delete configs of environment %s: %w", name, err))
+			continue
+		}
+
+		errors = append(errors, deleteFromEnvironment(client, environment, configs, deployed, dryRun, log)...)
+	}
+
+	return errors
+}
+
+func createDeleteClient(environment environment.Environment, dryRun bool, log *util.Logger) (rest.DynatraceClient, error) {
+	if dryRun {
+		return createDryRunClient(environment, log)
+	}
+
+	apiToken, err := environment.GetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return newDynatraceClient(environment, apiToken, log)
+}
+
+// failedDeletion is a config whose deletion failed in the last pass
+type failedDeletion struct {
+	config config.Config
+	err    error
+}
+
+// deleteFromEnvironment deletes the configs which exist in the environment and logs the configs which are already
+// absent. If no client is available during a dry run, it can't be checked whether the configs exist.
+//
+// Configs are deleted in the reverse order of their deployment, so configs referencing another config to delete are
+// deleted first. The order is given by the APIs of the configs, and by the references of the configs of the projects.
+// As the API may know references which are neither, failed deletions are retried in a second pass, and in further
+// passes as long as the previous retry deleted at least one config.
+func deleteFromEnvironment(client rest.DynatraceClient, environment environment.Environment, configs []config.Config, deployed []config.Config, dryRun bool,
+	log *util.Logger) (errors []error) {
+	if dryRun {
+		log.Info("Checking %d configs to delete for environment %s...", len(configs), environment.GetId())
+	} else {
+		log.Info("Deleting %d configs for environment %s...", len(configs), environment.GetId())
+	}
+
+	deletions := newDeletionOrder(configs, deployed, environment, log)
+
+	var failed []failedDeletion
+	for _, config := range deletions.sorted {
+		theApi := config.GetApi()
+		name := config.GetId()
+
+		if client == nil {
+			log.Info("\twould delete %s %s, if it exists", theApi.GetId(), name)
+			continue
+		}
+
+		exists, _, err := client.ExistsByName(theApi, name)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("could not delete %s %s from environment %s: %w", theApi.GetId(), name, environment.GetId(), err))
+			continue
+		}
+
+		if !exists {
+			log.Info("\t%s %s does not exist, nothing to delete", theApi.GetId(), name)
+			continue
+		}
+
+		if dryRun {
+			log.Info("\twould delete %s %s", theApi.GetId(), name)
+			continue
+		}
+
+		err = client.DeleteByName(theApi, name)
+		if err != nil {
+			failed = append(failed, failedDeletion{config: config, err: err})
+			continue
+		}
+		deletions.deleted[config] = true
+		log.Info("\tdeleted %s %s", theApi.GetId(), name)
+	}
+
+	for pass := 2; len(failed) > 0; pass++ {
+		log.Info("Retrying %d failed deletions for environment %s (pass %d)...", len(failed), environment.GetId(), pass)
+
+		var remaining []failedDeletion
+		for _, f := range failed {
+			err := client.DeleteByName(f.config.GetApi(), f.config.GetId())
+			if err != nil {
+				remaining = append(remaining, failedDeletion{config: f.config, err: err})
+				continue
+			}
+			deletions.deleted[f.config] = true
+			log.Info("\tdeleted %s %s", f.config.GetApi().GetId(), f.config.GetId())
+		}
+
+		progress := len(remaining) < len(failed)
+		failed = remaining
+		if !progress {
+			break
+		}
+	}
+
+	for _, f := range failed {
+		errors = append(errors, deletions.failureError(f, environment))
+	}
+
+	return errors
+}
+
+// apiDeletionRanks orders the APIs whose configs are referenced by configs of other APIs, as the configs to delete are
+// usually no longer part of the projects and their references are thus unknown. Configs of APIs with a lower rank are
+// deleted first. APIs which are not listed have rank 0, as their configs are not referenced by other configs.
+var apiDeletionRanks = map[string]int{
+	// referenced by notifications, reports and synthetic monitors
+	"alerting-profile":   1,
+	"dashboard":          1,
+	"credential-vault":   1,
+	"synthetic-location": 1,
+
+	// referenced by SLOs, metric events and dashboards
+	"calculated-metrics-service":            2,
+	"calculated-metrics-log":                2,
+	"calculated-metrics-synthetic":          2,
+	"calculated-metrics-application-web":    2,
+	"calculated-metrics-application-mobile": 2,
+
+	// referenced by calculated metrics, request naming rules, detection rules and synthetic monitors
+	"request-attributes": 3,
+	"application":        3,
+	"application-web":    3,
+	"application-mobile": 3,
+
+	// referenced by alerting profiles, dashboards, calculated metrics, maintenance windows and SLOs
+	"management-zone": 4,
+}
+
+// deletionOrder sorts the configs to delete by the ranks of their APIs and by the references of the matching configs
+// of the projects. A config to delete matches a config of the projects with the same API and object name in the
+// environment.
+type deletionOrder struct {
+	sorted   []config.Config
+	matching map[config.Config]config.Config
+	deployed []config.Config
+	deleted  map[config.Config]bool
+	log      *util.Logger
+}
+
+func newDeletionOrder(configs []config.Config, deployed []config.Config, environment environment.Environment, log *util.Logger) *deletionOrder {
+	byName := make(map[string]config.Config)
+	for _, c := range deployed {
+		// names referencing other configs can't be resolved without deploying, so these configs aren't matched
+		name, err := c.GetObjectNameForEnvironment(environment, map[string]api.DynatraceEntity{})
+		if err != nil {
+			continue
+		}
+
+		key := c.GetApi().GetId() + "/" + name
+		if _, found := byName[key]; !found {
+			byName[key] = c
+		}
+	}
+
+	order := &deletionOrder{
+		matching: make(map[config.Config]config.Config),
+		deployed: deployed,
+		deleted:  make(map[config.Config]bool),
+		log:      log,
+	}
+	for _, c := range configs {
+		if match, found := byName[c.GetApi().GetId()+"/"+c.GetId()]; found {
+			order.matching[c] = match
+		}
+	}
+
+	order.sorted = order.sort(configs)
+	return order
+}
+
+// sort returns the configs ordered by the ranks of their APIs, and in the order of the delete.yaml within a rank.
+// Configs are then moved behind all configs referencing them. In case of a circular reference, the remaining configs
+// keep this order.
+func (o *deletionOrder) sort(configs []config.Config) []config.Config {
+	remaining := append([]config.Config{}, configs...)
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return apiDeletionRanks[remaining[i].GetApi().GetId()] < apiDeletionRanks[remaining[j].GetApi().GetId()]
+	})
+
+	sorted := make([]config.Config, 0, len(configs))
+
+	for len(remaining) > 0 {
+		next := -1
+		for i, c := range remaining {
+			if !o.isReferencedByAny(c, remaining) {
+				next = i
+				break
+			}
+		}
+
+		if next < 0 {
+			o.log.Warn("Configs to delete reference each other, the remaining %d configs are deleted in the order of the delete.yaml", len(remaining))
+			return append(sorted, remaining...)
+		}
+
+		sorted = append(sorted, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return sorted
+}
+
+// isReferencedByAny returns whether the matching config of one of the others references the matching config of c
+func (o *deletionOrder) isReferencedByAny(c config.Config, others []config.Config) bool {
+	match, found := o.matching[c]
+	if !found {
+		return false
+	}
+
+	for _, other := range others {
+		if otherMatch, found := o.matching[other]; found && other != c && otherMatch.HasDependencyOn(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// failureError returns the error of a config which could not be deleted. If configs of the projects which haven't been
+// deleted still reference it, they are listed as the likely reason.
+func (o *deletionOrder) failureError(f failedDeletion, environment environment.Environment) error {
+	theApi := f.config.GetApi()
+	name := f.config.GetId()
+
+	var referencing []string
+	if match, found := o.matching[f.config]; found {
+		for _, c := range o.deployed {
+			if c != match && c.HasDependencyOn(match) && !o.isDeleted(c) {
+				referencing = append(referencing, c.GetFullQualifiedId())
+			}
+		}
+	}
+
+	if len(referencing) > 0 {
+		return fmt.Errorf("could not delete %s %s from environment %s, as it is still referenced by %s: %w",
+			theApi.GetId(), name, environment.GetId(), strings.Join(referencing, ", "), f.err)
+	}
+	return fmt.Errorf("could not delete %s %s from environment %s: %w", theApi.GetId(), name, environment.GetId(), f.err)
+}
+
+// isDeleted returns whether the config of the projects has been deleted as one of the configs to delete
+func (o *deletionOrder) isDeleted(deployed config.Config) bool {
+	for c, match := range o.matching {
+		if match == deployed && o.deleted[c] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+func createTestConfigsToDelete() []config.Config {
+	return []config.Config{
+		config.NewConfigForDelete("profile", "delete.yaml", map[string]map[string]string{"profile": {"name": "profile"}}, testProfileApi),
+		config.NewConfigForDelete("metric", "delete.yaml", map[string]map[string]string{"metric": {"name": "metric"}}, testMetricApi),
+	}
+}
+
+func TestDeleteFromEnvironmentDeletesExistingConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "profile-id", nil)
+	client.EXPECT().DeleteByName(testProfileApi, "profile").Return(nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(false, "", nil)
+
+	errs := deleteFromEnvironment(client, environment, createTestConfigsToDelete(), nil, false, util.DefaultLogger())
+	assert.Equal(t, len(errs), 0)
+}
+
+func TestDeleteFromEnvironmentCollectsErrors(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "profile-id", nil)
+	client.EXPECT().DeleteByName(testProfileApi, "profile").Return(errors.New("delete failed")).Times(2)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(true, "metric-id", nil)
+	client.EXPECT().DeleteByName(testMetricApi, "metric").Return(nil)
+
+	errs := deleteFromEnvironment(client, environment, createTestConfigsToDelete(), nil, false, util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "could not delete alerting-profile profile from environment dev: delete failed")
+}
+
+func TestDeleteFromEnvironmentDoesNotDeleteOnDryRun(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "profile-id", nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(false, "", nil)
+
+	errs := deleteFromEnvironment(client, environment, createTestConfigsToDelete(), nil, true, util.DefaultLogger())
+	assert.Equal(t, len(errs), 0)
+}
+
+func TestDeleteFromEnvironmentWithoutClientOnDryRun(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	errs := deleteFromEnvironment(nil, environment, createTestConfigsToDelete(), nil, true, util.DefaultLogger())
+	assert.Equal(t, len(errs), 0)
+}
+
+// createDeployedTestConfigs creates the configs of a project, in which two metrics reference the profile
+func createDeployedTestConfigs(t *testing.T) []config.Config {
+	return []config.Config{
+		createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": "none"}),
+		createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/profile.id"}),
+		createTestConfigWithProperties(t, "other-metric", testMetricApi, map[string]string{"name": "other-metric", "reference": "proj/alerting-profile/profile.id"}),
+	}
+}
+
+func TestDeleteFromEnvironmentDeletesDependentsFirst(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+	configs := append(createTestConfigsToDelete(),
+		config.NewConfigForDelete("other-metric", "delete.yaml", map[string]map[string]string{"other-metric": {"name": "other-metric"}}, testMetricApi))
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(gomock.Any(), gomock.Any()).Return(true, "id", nil).Times(3)
+	gomock.InOrder(
+		client.EXPECT().DeleteByName(testMetricApi, "metric").Return(nil),
+		client.EXPECT().DeleteByName(testMetricApi, "other-metric").Return(nil),
+		client.EXPECT().DeleteByName(testProfileApi, "profile").Return(nil),
+	)
+
+	errs := deleteFromEnvironment(client, environment, configs, createDeployedTestConfigs(t), false, util.DefaultLogger())
+	assert.Equal(t, len(errs), 0)
+}
+
+func TestDeleteFromEnvironmentRetriesFailedDeletionsAfterDependentsAreGone(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	// the reference of the metric to the profile isn't known, as the configs aren't part of the projects
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(gomock.Any(), gomock.Any()).Return(true, "id", nil).Times(2)
+	gomock.InOrder(
+		client.EXPECT().DeleteByName(testProfileApi, "profile").Return(errors.New("profile is still in use")),
+		client.EXPECT().DeleteByName(testMetricApi, "metric").Return(nil),
+		client.EXPECT().DeleteByName(testProfileApi, "profile").Return(nil),
+	)
+
+	errs := deleteFromEnvironment(client, environment, createTestConfigsToDelete(), nil, false, util.DefaultLogger())
+	assert.Equal(t, len(errs), 0)
+}
+
+func TestDeleteFromEnvironmentReportsRemainingReferences(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(gomock.Any(), gomock.Any()).Return(true, "id", nil).Times(2)
+	client.EXPECT().DeleteByName(testMetricApi, "metric").Return(nil)
+	client.EXPECT().DeleteByName(testProfileApi, "profile").Return(errors.New("profile is still in use")).Times(2)
+
+	errs := deleteFromEnvironment(client, environment, createTestConfigsToDelete(), createDeployedTestConfigs(t), false, util.DefaultLogger())
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "could not delete alerting-profile profile from environment dev, as it is still referenced by proj/calculated-metrics-log/other-metric: profile is still in use")
+}
+
+func TestDeletionOrderKeepsOrderOfCircularReferences(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+	deployed := []config.Config{
+		createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": "proj/calculated-metrics-log/metric.id"}),
+		createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/profile.id"}),
+	}
+
+	configs := createTestConfigsToDelete()
+	order := newDeletionOrder(configs, deployed, environment, util.DefaultLogger())
+	assert.Equal(t, len(order.sorted), 2)
+	assert.Equal(t, order.sorted[0], configs[0])
+	assert.Equal(t, order.sorted[1], configs[1])
+}
+
+func TestDeletionOrderDeletesConfigsOfReferencingApisFirst(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+	configs := createTestConfigsToDelete()
+
+	order := newDeletionOrder([]config.Config{configs[1], configs[0]}, nil, environment, util.DefaultLogger())
+	assert.Equal(t, len(order.sorted), 2)
+	assert.Equal(t, order.sorted[0], configs[0])
+	assert.Equal(t, order.sorted[1], configs[1])
+}
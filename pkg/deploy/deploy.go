@@ -15,181 +15,955 @@
 package deploy
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/bundle"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/delete"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/diff"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/metrics"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/schema"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/tracing"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/jcelliott/lumber"
 	"github.com/spf13/afero"
 )
 
-func Deploy(workingDir string, fs afero.Fs, environmentsFile string,
-	specificEnvironment string, proj string, dryRun bool, continueOnError bool) error {
-	environments, errors := environment.LoadEnvironmentList(specificEnvironment, environmentsFile, fs)
+// deploy deploys the projects to the environments. Once the context is cancelled, no further configs are deployed:
+// configs in progress are finished, and the deployment summary, report and id cache reflect the configs processed.
+// The processed configs and errors are added to the result, even if the deployment fails.
+func deploy(ctx context.Context, workingDir string, fs afero.Fs, environmentsFile string, opts Options, log *util.Logger, result *Result) error {
+	ctx, span := tracing.Start(ctx, "monaco deploy")
+	defer span.End()
+	span.SetAttribute("monaco.dry_run", opts.DryRun)
+
+	if opts.Parallel < 1 {
+		return fmt.Errorf("invalid number of parallel deployments %d: needs to be at least 1", opts.Parallel)
+	}
+
+	if opts.Diff && !opts.DryRun {
+		return fmt.Errorf("showing the changed fields of configs requires a dry run")
+	}
+
+	// without continuing on error, a deployment already aborts on the first error, like with a maximum of 0 errors
+	if opts.MaxErrors > 0 && !opts.ContinueOnError {
+		return fmt.Errorf("the maximum number of errors can only be set if the deployment continues on error")
+	}
+
+	if opts.ResetIdCache && opts.IdCacheFile == "" {
+		return fmt.Errorf("resetting the id cache requires an id cache file")
+	}
+
+	if (opts.IgnoreState || opts.Refresh) && opts.StateFile == "" {
+		return fmt.Errorf("ignoring the state or refreshing the deployment of unchanged configs requires a state file")
+	}
+
+	if opts.Resume && opts.NoResume {
+		return fmt.Errorf("a deployment can't be resumed while its run state is discarded")
+	}
+
+	if opts.BundleFile != "" && (opts.Project != "" || opts.ProjectsFile != "" || opts.StrictProjects) {
+		return fmt.Errorf("projects can't be selected when deploying a bundle, as it contains the projects it was created for")
+	}
+
+	proj := opts.Project
+	environments, errors := environment.LoadEnvironmentList(opts.SpecificEnvironment, environmentsFile, fs, log)
 
 	workingDir = filepath.Clean(workingDir)
 
 	var deploymentErrors = make(map[string][]error)
 
+	report := newDeploymentReport()
+	defer result.fill(report, deploymentErrors, opts.DryRun)
+
 	for i, err := range errors {
 		configIssue := fmt.Sprintf("environmentfile-issue-%d", i)
 		deploymentErrors[configIssue] = append(deploymentErrors[configIssue], err)
 	}
 
+	if opts.ProjectsFile != "" {
+		listedProjects, err := project.ReadProjectsFile(fs, opts.ProjectsFile)
+		if err != nil {
+			return err
+		}
+		proj = joinProjects(proj, listedProjects)
+	}
+
+	if opts.StrictProjects && proj == "" {
+		return fmt.Errorf("strict project selection requires the projects to deploy to be specified")
+	}
+
 	apis := api.NewApis()
 
-	projects, err := project.LoadProjectsToDeploy(fs, proj, apis, workingDir)
-	if err != nil {
-		util.FailOnError(err, "Loading of projects failed")
+	loadProjects := project.LoadProjectsToDeploy
+	if opts.StrictProjects {
+		loadProjects = project.LoadProjectsToDeployStrict
 	}
 
-	util.Log.Info("Executing projects in this order: ")
+	var projects []project.Project
+	var err error
+
+	if opts.BundleFile != "" {
+		projects, err = loadBundle(fs, opts.BundleFile, environments, log)
+		if err != nil {
+			return err
+		}
+		// ids of bundled configs are not prefixed with the working directory
+		workingDir = ""
+	} else {
+		projects, err = loadProjects(fs, proj, apis, workingDir, log)
+		if err != nil {
+			log.Error("Loading of projects failed: %s", err)
+			return fmt.Errorf("Loading of projects failed: %w", err)
+		}
+	}
+
+	log.Info("Executing projects in this order: ")
 
 	for i, project := range projects {
-		util.Log.Info("\t%d: %s (%d configs)", i+1, project.GetId(), len(project.GetConfigs()))
+		log.Info("\t%d: %s (%d configs)", i+1, project.GetId(), len(project.GetConfigs()))
+	}
+
+	if err := checkEnvironmentTags(projects, environment.LoadEnvironmentTags(environmentsFile, fs)); err != nil {
+		log.Error("%s", err)
+		return fmt.Errorf("Environment tags used in configs are not defined! Check log!")
+	}
+
+	if err := checkTokenNames(projects, environments); err != nil {
+		log.Error("%s", err)
+		return fmt.Errorf("Tokens used in configs are not defined! Check log!")
+	}
+
+	if err := checkDuplicateNames(projects, environments); err != nil {
+		if !opts.AllowDuplicateNames {
+			log.Error("%s", err)
+			return fmt.Errorf("Configs of the same project share their names! Check log!")
+		}
+		log.Warn("%s", err)
+	}
+
+	if !opts.SkipEnvCheck {
+		if err := checkEnvVars(projects, environments); err != nil {
+			log.Error("%s", err)
+			return fmt.Errorf("Environment variables referenced in configs are missing! Check log!")
+		}
+	}
+
+	// a dry run doesn't access the environments
+	if !opts.SkipPreflight && !opts.DryRun {
+		if err := preflightCheck(projects, environments, checkEnvironmentAccess); err != nil {
+			log.Error("%s", err)
+			return fmt.Errorf("Environments failed the pre-flight check! Check log!")
+		}
+	}
+
+	// a dry run doesn't change the environments, so it is not confirmed
+	if !opts.DryRun {
+		if err := confirmDeployment(opts.Confirm, deploymentTargets(projects, environments)); err != nil {
+			log.Error("%s", err)
+			return err
+		}
+	}
+
+	summary := newDeploymentSummary()
+
+	// metrics are only collected if they are written, to not slow down normal runs
+	var registry *metrics.Registry
+	if opts.MetricsFile != "" {
+		registry = metrics.Enable()
+		defer metrics.Disable()
+	}
+
+	// the id cache is only used if a file is given
+	var ids *idCache
+	if opts.ResetIdCache {
+		ids = newIdCache()
+	} else if opts.IdCacheFile != "" {
+		ids, err = loadIdCache(fs, opts.IdCacheFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	// configs are only skipped if unchanged, if the checksums of previous deployments are stored in a state file
+	var checksums *checksumState
+	if opts.StateFile != "" {
+		checksums, err = loadChecksumState(fs, opts.StateFile)
+		if err != nil {
+			return err
+		}
+		checksums.ignoreState = opts.IgnoreState
+		checksums.refresh = opts.Refresh
+	}
+
+	// the configs deployed by a run are only recorded if a file is given or the run is resumed, and a dry run deploys
+	// nothing to record
+	runStateFile := runStateFileOf(opts.RunStateFile, opts.Resume, opts.NoResume)
+	var run *runState
+	if runStateFile != "" && !opts.DryRun {
+		run, err = loadRunToResume(fs, runStateFile, opts.Resume, opts.NoResume, opts.ResumeMaxAge, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	// schemas are only validated if requested, as the bundled schemas might reject payloads the api accepts
+	var schemas *schema.Validator
+	if opts.ValidateSchemas || opts.SchemaDir != "" {
+		schemas, err = schema.NewValidator(fs, opts.SchemaDir, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a dry run validates all configs, to report all errors at once
+	var limit *errorLimit
+	if !opts.DryRun {
+		limit = newErrorLimit(opts.MaxErrors)
 	}
 
 	for _, environment := range environments {
-		errors := execute(environment, projects, dryRun, workingDir, continueOnError)
+		if ctx.Err() != nil {
+			log.Warn("Skipping environment %s, as the deployment was interrupted", environment.GetId())
+			continue
+		}
+
+		if limit.reached() {
+			log.Warn("Skipping environment %s, as the maximum number of %d error(s) was reached", environment.GetId(), opts.MaxErrors)
+			continue
+		}
+
+		errors := execute(ctx, environment, projects, opts.DryRun, opts.Diff, workingDir, opts.ContinueOnError, summary, report, opts.Parallel, ids, checksums, run, schemas, limit, opts.AllowDuplicateNames, log)
 		if errors != nil && len(errors) > 0 {
 			deploymentErrors[environment.GetId()] = errors
 		}
 	}
 
-	util.Log.Info("Deployment summary:")
-	for environment, errors := range deploymentErrors {
-		if dryRun {
-			util.Log.Error("Validation of %s failed. Found %d error(s)\n", environment, len(errors))
-			util.PrintErrors(errors)
-		} else if continueOnError {
-			util.Log.Error("Deployment to %s finished with %d error(s):\n", environment, len(errors))
-			util.PrintErrors(errors)
+	// bundles don't contain configs to delete
+	if opts.DryRun && opts.BundleFile == "" {
+		err := addPlannedDeletions(summary, apis, environments, workingDir, fs, log)
+		if err != nil {
+			deploymentErrors["delete-file-issue"] = append(deploymentErrors["delete-file-issue"], err)
+		}
+	}
+	if opts.DryRun {
+		summary.print(log)
+	}
+
+	log.Info("Deployment summary:")
+	for _, environment := range sortedErrorKeys(deploymentErrors) {
+		errors := deploymentErrors[environment]
+		if opts.DryRun {
+			log.Error("Validation of %s failed. Found %d error(s)\n", environment, len(errors))
+		} else if opts.ContinueOnError {
+			log.Error("Deployment to %s finished with %d error(s):\n", environment, len(errors))
+		} else {
+			log.Error("Deployment to %s failed with error!\n", environment)
+		}
+		printDeploymentErrors(log, errors)
+		rest.PrintMissingScopes(log, environment, errors)
+	}
+
+	report.timings().print(log)
+
+	// the report is written even if the deployment failed, to capture the configs processed so far
+	if opts.ReportFile != "" {
+		err := report.write(fs, opts.ReportFile, opts.DryRun, len(deploymentErrors) == 0)
+		if err != nil {
+			return err
+		}
+		log.Info("Report written to %s", opts.ReportFile)
+	}
+
+	if opts.MetricsFile != "" {
+		err := writeMetrics(fs, opts.MetricsFile, registry, report, len(deploymentErrors) == 0)
+		if err != nil {
+			return err
+		}
+		log.Info("Metrics written to %s", opts.MetricsFile)
+	}
+
+	// the id cache is written even if the deployment failed, to reuse the ids of configs created so far
+	if ids != nil && !opts.DryRun {
+		err := ids.write(fs, opts.IdCacheFile)
+		if err != nil {
+			return err
+		}
+		log.Debug("Id cache written to %s", opts.IdCacheFile)
+	}
+
+	// the state is written even if the deployment failed, to skip the configs deployed so far if they are unchanged
+	if checksums != nil && !opts.DryRun {
+		err := checksums.write(fs, opts.StateFile)
+		if err != nil {
+			return err
+		}
+		log.Debug("State written to %s", opts.StateFile)
+	}
+
+	// the run state is only kept if the deployment failed, to resume it by a later deployment
+	if run != nil {
+		if len(deploymentErrors) == 0 && ctx.Err() == nil {
+			err = removeRunState(fs, runStateFile)
 		} else {
-			util.Log.Error("Deployment to %s failed with error!\n", environment)
-			util.PrintErrors(errors)
+			err = run.write(fs, runStateFile, time.Now())
+			if err == nil {
+				log.Info("Run state written to %s, deploy with --resume to skip the configs deployed so far", runStateFile)
+			}
+		}
+		if err != nil {
+			return err
 		}
 	}
 
+	if ctx.Err() != nil {
+		if opts.DryRun {
+			return fmt.Errorf("Validation was interrupted: %w", ctx.Err())
+		}
+		return fmt.Errorf("Deployment was interrupted: %w", ctx.Err())
+	}
+
 	// do not execute delete if there are problems with deployment
 	if len(deploymentErrors) > 0 {
-		if dryRun {
+		span.SetError(fmt.Errorf("deployment failed in %d environment(s)", len(deploymentErrors)))
+		if opts.DryRun {
 			return fmt.Errorf("Errors during validation! Check log!")
 		} else {
 			return fmt.Errorf("Errors during deployment! Check log!")
 		}
 	}
 
-	if dryRun {
-		util.Log.Info("Validation finished without errors")
+	if opts.DryRun {
+		log.Info("Validation finished without errors")
 	} else {
-		util.Log.Info("Deployment finished without errors")
+		log.Info("Deployment finished without errors")
 	}
 
-	deleteConfigs(apis, environments, workingDir, dryRun, fs)
+	if opts.BundleFile != "" {
+		return nil
+	}
+
+	deletionErrors := deleteConfigs(apis, environments, projects, workingDir, opts.DryRun, fs, log)
+	if len(deletionErrors) > 0 {
+		deploymentErrors[deletionErrorsKey] = deletionErrors
+		log.Error("Deletion of configs failed with %d error(s):", len(deletionErrors))
+		log.PrintErrors(deletionErrors)
+		return fmt.Errorf("Errors during deletion! Check log!")
+	}
 
 	return nil
 }
 
-func execute(environment environment.Environment, projects []project.Project, dryRun bool, path string, continueOnError bool) (errors []error) {
-	util.Log.Info("Processing environment " + environment.GetId() + "...")
+// runStateFileOf returns the run state file of a deployment, which is the default file if the deployment is resumed or
+// its run state is discarded without naming the file, or empty if no run state is recorded
+func runStateFileOf(runStateFile string, resume bool, noResume bool) string {
+	if runStateFile == "" && (resume || noResume) {
+		return DefaultRunStateFile
+	}
+	return runStateFile
+}
+
+// loadRunToResume returns the run state recording the deployment. If the deployment is resumed, it contains the
+// configs deployed by the previous run, unless its state expired. If the run state is discarded, its file is removed.
+func loadRunToResume(fs afero.Fs, file string, resume bool, noResume bool, maxAge time.Duration, log *util.Logger) (*runState, error) {
+	if noResume {
+		log.Info("Discarding the run state %s of previous deployments", file)
+		return newRunState(), removeRunState(fs, file)
+	}
+
+	if !resume {
+		return newRunState(), nil
+	}
+
+	run, found, err := loadRunState(fs, file, maxAge, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		log.Warn("No run state of a recent deployment found in %s, deploying all configs", file)
+	} else {
+		log.Info("Resuming the deployment recorded in %s, skipping %d unchanged config(s) deployed before", file, run.count())
+	}
+	return run, nil
+}
+
+// joinProjects adds the listed projects to the comma separated projects
+func joinProjects(projects string, listedProjects []string) string {
+	if strings.TrimSpace(projects) != "" {
+		listedProjects = append([]string{projects}, listedProjects...)
+	}
+	return strings.Join(listedProjects, ",")
+}
+
+// loadBundle loads the projects of the bundle. Deploying a bundle to an environment it wasn't rendered for is allowed,
+// e.g. to deploy the same configs to several environments, but might not yield the intended configs.
+func loadBundle(fs afero.Fs, bundleFile string, environments map[string]environment.Environment, log *util.Logger) ([]project.Project, error) {
+	b, err := bundle.Load(fs, bundleFile)
+	if err != nil {
+		return nil, err
+	}
+
+	for id := range environments {
+		if id != b.Environment {
+			log.Warn("Bundle %s was rendered for environment %s, but is deployed to %s", bundleFile, b.Environment, id)
+		}
+	}
+
+	return b.Projects, nil
+}
+
+func execute(ctx context.Context, environment environment.Environment, projects []project.Project, dryRun bool, showChanges bool, path string, continueOnError bool,
+	summary *deploymentSummary, report *deploymentReport, parallel int, ids *idCache, checksums *checksumState, run *runState,
+	schemas *schema.Validator, limit *errorLimit, allowDuplicateNames bool, log *util.Logger) (errors []error) {
+	environmentLog := log.WithFields(util.LogFields{"environment": environment.GetId()})
+	environmentLog.Info("Processing environment " + environment.GetId() + "...")
+
+	ctx, span := tracing.Start(ctx, "deploy environment")
+	defer func() {
+		span.SetAttribute("monaco.errors", len(errors))
+		if len(errors) > 0 {
+			span.SetError(fmt.Errorf("%d config(s) failed", len(errors)))
+		}
+		span.End()
+	}()
+	span.SetAttribute("monaco.environment", environment.GetId())
 
 	var client rest.DynatraceClient
-	if !dryRun {
+	if dryRun {
+		var err error
+		client, err = createDryRunClient(environment, log)
+		if err != nil {
+			return append(errors, err)
+		}
+	} else {
 		apiToken, err := environment.GetToken()
 		if err != nil {
 			return append(errors, err)
 		}
 
-		client, err = rest.NewDynatraceClient(environment.GetEnvironmentUrl(), apiToken)
+		client, err = newDynatraceClient(environment, apiToken, log)
 		if err != nil {
 			return append(errors, err)
 		}
+
+		if ids != nil {
+			client = &idCachingClient{DynatraceClient: client, cache: ids, environment: environment.GetId(), log: log}
+		}
+	}
+
+	state := newDeploymentState()
+	state.progress = newDeploymentProgress(environment.GetId(), countConfigs(projects), time.Now())
+	state.schemas = schemas
+	state.errors = limit
+	state.allowDuplicateNames = allowDuplicateNames
+	state.showChanges = dryRun && showChanges
+	state.log = log
+	state.addResponseReferences(projects)
+	if !dryRun {
+		state.checksums = checksums
+		state.run = run
+	}
+
+	reporter := startProgressReporter(state.progress, log)
+	defer reporter.stop()
+
+	if parallel > 1 {
+		errors = executeParallel(ctx, client, environment, projects, dryRun, path, continueOnError, summary, report, state, parallel)
+	} else {
+		errors = executeSerial(ctx, client, environment, projects, dryRun, path, continueOnError, summary, report, state)
 	}
 
-	dict := make(map[string]api.DynatraceEntity)
-	var nameDict = make(map[string]string)
-	var name, configID string
+	if ctx.Err() != nil {
+		completed, total, _, _ := state.progress.status()
+		environmentLog.Warn("Deployment to %s was interrupted after %d of %d configs", environment.GetId(), completed, total)
+	} else if limit.reached() {
+		completed, total, _, _ := state.progress.status()
+		environmentLog.Error("Deployment to %s was aborted after %d of %d configs, as the maximum number of errors was reached", environment.GetId(), completed, total)
+	}
+	return errors
+}
 
+func countConfigs(projects []project.Project) int {
+	count := 0
 	for _, project := range projects {
+		count += len(project.GetConfigs())
+	}
+	return count
+}
 
-		util.Log.Info("\tProcessing project " + project.GetId() + "...")
-		util.Log.Debug("\t\tDeploying configs in this order: ")
-		for i, config := range project.GetConfigs() {
-			util.Log.Debug("\t\t\t%d: %s", i+1, config.GetFilePath())
-		}
+// executeSerial deploys the configs of all projects to the environment one after the other. Configs depending on
+// a failed config are skipped. Once the context is cancelled, the remaining configs are not deployed.
+func executeSerial(ctx context.Context, client rest.DynatraceClient, environment environment.Environment, projects []project.Project, dryRun bool,
+	path string, continueOnError bool, summary *deploymentSummary, report *deploymentReport, state *deploymentState) (errors []error) {
 
-		for _, config := range project.GetConfigs() {
+	// failed contains all configs which failed or were skipped due to a failed dependency
+	failed := make([]config.Config, 0)
 
-			var entity api.DynatraceEntity
-			var err error
+	for _, project := range projects {
 
-			if config.IsSkipDeployment(environment) {
-				util.Log.Info("\t\t\tskipping deployment of %s: %s", config.GetId(), config.GetFilePath())
-				continue
-			}
+		logProjectStart(state.log, environment, project)
 
-			name, err = config.GetObjectNameForEnvironment(environment, dict)
-			if err != nil {
-				return append(errors, err)
-			}
-			name = config.GetApi().GetId() + "/" + name
-			configID = config.GetFullQualifiedId()
-			if nameDict[name] != "" {
-				return append(errors, fmt.Errorf("duplicate UID '%s' found in %s and %s", name, configID, nameDict[name]))
+		for _, config := range project.GetConfigs() {
+
+			if ctx.Err() != nil {
+				return errors
 			}
-			nameDict[name] = configID
 
-			if dryRun {
-				entity, err = validateConfig(project, config, dict, environment)
-			} else {
-				entity, err = uploadConfig(client, config, dict, environment)
+			if dependency, found := findFailedDependency(config, failed); found {
+				err := failedDependencyError(dependency)
+				configLogger(state.log, environment, project, config).Warn("\t\t\t%s", err)
+				report.add(newConfigResult(environment, project, config, resultSkipped, "", 0, 0, err))
+				state.progress.complete(time.Now())
+				errors = append(errors, newConfigDeploymentError(environment, project, config, err))
+				failed = append(failed, config)
+				continue
 			}
 
+			err, fatal := deployConfig(ctx, client, environment, project, config, dryRun, path, summary, report, state)
+
 			if err != nil {
-				// by default stop deployment on error
-				if continueOnError || dryRun {
-					errors = append(errors, err)
+				deploymentErr := newConfigDeploymentError(environment, project, config, err)
+
+				// by default stop deployment on error. A dry run validates all configs, to report all errors at once.
+				if dryRun || (!fatal && continueOnError) {
+					errors = append(errors, deploymentErr)
+					failed = append(failed, config)
 					// Log error here in addition to deployment summary
 					// Useful to debug using verbose
-					util.Log.Error("\t\t\tFailed %s", err)
+					configLogger(state.log, environment, project, config).Error("\t\t\tFailed %s", err)
+
+					if !dryRun && state.errors.add() {
+						return errors
+					}
 				} else {
-					return append(errors, err)
+					return append(errors, deploymentErr)
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+// findFailedDependency returns the first of the failed configs the config depends on
+func findFailedDependency(config config.Config, failed []config.Config) (config.Config, bool) {
+	for _, f := range failed {
+		if config.HasDependencyOn(f) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func failedDependencyError(dependency config.Config) error {
+	return fmt.Errorf("skipped deployment, as it depends on failed config %s", dependency.GetFullQualifiedId())
+}
+
+// configDeploymentError is the error of a single config, including the project and environment it was deployed to
+type configDeploymentError struct {
+	environment string
+	project     string
+	config      string
+	err         error
+}
+
+func newConfigDeploymentError(environment environment.Environment, project project.Project, config config.Config, err error) error {
+	return &configDeploymentError{
+		environment: environment.GetId(),
+		project:     project.GetId(),
+		config:      config.GetFullQualifiedId(),
+		err:         err,
+	}
+}
+
+func (e *configDeploymentError) Error() string {
+	return fmt.Sprintf("%s (project: %s, environment: %s): %s", e.config, e.project, e.environment, e.err)
+}
+
+func (e *configDeploymentError) Unwrap() error {
+	return e.err
+}
+
+// printDeploymentErrors prints all errors. Errors of configs are prefixed with the config, its project and environment.
+func printDeploymentErrors(log *util.Logger, errors []error) {
+	for _, err := range errors {
+		if configErr, ok := err.(*configDeploymentError); ok {
+			log.Error("\t%s (project: %s, environment: %s):", configErr.config, configErr.project, configErr.environment)
+			log.PrintError(configErr.err)
+		} else {
+			log.PrintError(err)
+		}
+	}
+}
+
+func sortedErrorKeys(deploymentErrors map[string][]error) []string {
+	keys := make([]string, 0, len(deploymentErrors))
+	for key := range deploymentErrors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func logProjectStart(log *util.Logger, environment environment.Environment, project project.Project) {
+	projectLog := log.WithFields(util.LogFields{"environment": environment.GetId(), "project": project.GetId()})
+	projectLog.Info("\tProcessing project " + project.GetId() + "...")
+	projectLog.Debug("\t\tDeploying configs in this order: ")
+	for i, config := range project.GetConfigs() {
+		projectLog.Debug("\t\t\t%d: %s", i+1, config.GetFilePath())
+	}
+}
+
+func configLogger(log *util.Logger, environment environment.Environment, project project.Project, config config.Config) lumber.Logger {
+	return log.WithFields(util.LogFields{"environment": environment.GetId(), "project": project.GetId(), "config": config.GetFullQualifiedId()})
+}
+
+// deployConfig deploys a single config, or validates it during a dry run, and adds the result to the report. Fatal
+// errors (e.g. duplicate names) stop the deployment to the environment, even if continueOnError is set.
+func deployConfig(ctx context.Context, client rest.DynatraceClient, environment environment.Environment, project project.Project, config config.Config,
+	dryRun bool, path string, summary *deploymentSummary, report *deploymentReport, state *deploymentState) (err error, fatal bool) {
+
+	start := time.Now()
+
+	ctx, span := tracing.Start(ctx, "deploy config")
+	defer span.End()
+	span.SetAttribute("monaco.config", config.GetFullQualifiedId())
+	span.SetAttribute("monaco.api", config.GetApi().GetId())
+	span.SetAttribute("monaco.project", project.GetId())
+	span.SetAttribute("monaco.environment", environment.GetId())
+
+	client, err = clientWithTokenOf(client, environment, config, dryRun, state.log)
+
+	// measure the time spent in requests separately, a dry run might not have a client
+	timed := &timingClient{client: client}
+	if client != nil {
+		timed.client = rest.WithTraceContext(client, ctx)
+		client = timed
+	}
+
+	var entity api.DynatraceEntity
+	var action resultAction
+	var changes []diff.Change
+	if err == nil {
+		entity, action, changes, err, fatal = applyConfig(client, environment, project, config, dryRun, path, summary, state)
+	}
+	if err != nil {
+		action = resultFailed
+	}
+	span.SetAttribute("monaco.action", string(action))
+	span.SetError(err)
+
+	result := newConfigResult(environment, project, config, action, entity.Id, time.Since(start), timed.network, err)
+	result.Changes = changes
+	report.add(result)
+	state.progress.complete(time.Now())
+
+	return err, fatal
+}
+
+// clientWithTokenOf returns the client sending the requests of the config using the named token it selects, or the
+// client itself, if the config is deployed using the default token. If the token is not available during a dry run, the
+// config is validated without accessing the environment.
+func clientWithTokenOf(client rest.DynatraceClient, environment environment.Environment, config config.Config, dryRun bool,
+	log *util.Logger) (rest.DynatraceClient, error) {
+
+	tokenName := config.GetTokenName(environment)
+	if client == nil || tokenName == "" || config.IsSkipDeployment(environment) {
+		return client, nil
+	}
+
+	token, err := environment.GetNamedToken(tokenName)
+	if err != nil && dryRun {
+		log.Warn("\t\t\tToken %s of environment %s is not available (%s): validating %s without checking whether it already exists", tokenName, environment.GetId(), err, config.GetId())
+		return nil, nil
+	}
+	if err != nil {
+		return client, fmt.Errorf("%w, responsible config: %s", err, config.GetFilePath())
+	}
+	return rest.WithToken(client, token), nil
+}
+
+func applyConfig(client rest.DynatraceClient, environment environment.Environment, project project.Project, config config.Config,
+	dryRun bool, path string, summary *deploymentSummary, state *deploymentState) (entity api.DynatraceEntity, action resultAction,
+	changes []diff.Change, err error, fatal bool) {
+
+	configLog := configLogger(state.log, environment, project, config)
+
+	if config.IsSkipDeployment(environment) {
+		configLog.Info("\t\t\tskipping deployment of %s: %s", config.GetId(), config.GetFilePath())
+		summary.add(environment.GetId(), config.GetApi().GetId(), actionSkip)
+		return entity, resultSkipped, nil, nil, false
+	}
+
+	// work on a copy, as configs deployed in parallel add their entities
+	dict := state.entities()
+
+	objectName, err := config.GetObjectNameForEnvironment(environment, dict)
+	if err != nil {
+		return entity, resultFailed, nil, err, true
+	}
+
+	referenceId := strings.TrimPrefix(config.GetFullQualifiedId(), path+"/")
+
+	// settings and automation objects are identified by the ids derived from their config, so names may be reused
+	var settings api.SettingsObject
+	var automationId string
+	if config.GetApi().IsSettingsApi() {
+		settings, err = newSettingsObject(config, environment, dict, objectName, strings.TrimPrefix(config.GetProject(), path+"/"))
+		if err != nil {
+			return entity, resultFailed, nil, err, false
+		}
+	} else if config.GetApi().IsAutomationApi() {
+		automationId = automationObjectId(strings.TrimPrefix(config.GetProject(), path+"/"), config.GetApi().GetId(), config.GetId())
+	} else {
+		err = state.registerName(config.GetApi().GetId()+"/"+objectName, config.GetFullQualifiedId())
+		if err != nil {
+			return entity, resultFailed, nil, err, true
+		}
+	}
+
+	if dryRun {
+		action = resultValidated
+		entity, err = validateConfig(project, config, dict, environment, state.schemas, state.log)
+		if err == nil {
+			var planned deploymentAction
+			var liveId string
+			planned, liveId, err = plannedAction(client, config, objectName, settings, automationId)
+			if err == nil && planned == actionUpdate && config.IsSkipIfExists(environment) {
+				planned = actionSkip
+			}
+			if err == nil && planned == actionUpdate && state.showChanges {
+				// configs referencing this one are compared with the id of its live object, not a random one
+				if liveId != "" {
+					entity.Id = liveId
 				}
+				changes, err = plannedChanges(client, config, liveId, entity.Payload, configLog)
+				if err == nil {
+					logPlannedChanges(configLog, objectName, changes)
+				}
+			} else if err == nil {
+				configLog.Debug("\t\t\twould %s %s", planned, objectName)
+			}
+			if err == nil {
+				summary.add(environment.GetId(), config.GetApi().GetId(), planned)
+			}
+		}
+	} else {
+		var checksum string
+		var resumed bool
+		if state.run != nil {
+			entity, checksum, resumed, err = findResumedConfig(config, dict, environment, objectName, settings, referenceId, state.run)
+			if err != nil {
+				return entity, resultFailed, nil, err, false
+			}
+		}
+
+		var unchanged bool
+		if state.checksums != nil && !resumed {
+			entity, checksum, unchanged, err = findUnchangedConfig(client, config, dict, environment, objectName, settings, automationId, referenceId, state.checksums)
+			if err != nil {
+				return entity, resultFailed, nil, err, false
 			}
+		}
 
-			referenceId := strings.TrimPrefix(config.GetFullQualifiedId(), path+"/")
+		var exists bool
+		if !unchanged && !resumed {
+			entity, exists, err = findExistingCreateOnlyConfig(client, config, environment, objectName, settings, automationId)
+			if err != nil {
+				return entity, resultFailed, nil, err, false
+			}
+		}
 
-			if entity.Name != "" {
-				dict[referenceId] = entity
+		if resumed {
+			configLog.Info("\t\t\tskipping deployment of %s: %s was deployed by the resumed run", config.GetId(), objectName)
+			summary.add(environment.GetId(), config.GetApi().GetId(), actionSkip)
+			action = resultSkipped
+		} else if unchanged {
+			configLog.Info("\t\t\tskipping deployment of %s: %s is unchanged since the last deployment", config.GetId(), objectName)
+			summary.add(environment.GetId(), config.GetApi().GetId(), actionSkip)
+			action = resultSkipped
+		} else if exists {
+			configLog.Info("\t\t\tskipping deployment of %s: %s already exists and skipIfExists is set", config.GetId(), objectName)
+			summary.add(environment.GetId(), config.GetApi().GetId(), actionSkip)
+			action = resultSkipped
+		} else {
+			entity, err = uploadConfig(client, config, dict, environment, state.schemas, settings, automationId, state.log)
+			if entity.Created {
+				action = resultCreated
+			} else {
+				action = resultUpdated
 			}
 		}
+
+		if err == nil && state.checksums != nil {
+			state.checksums.put(environment.GetId(), referenceId, checksum, entity.Id)
+		}
+		if err == nil && state.run != nil {
+			state.run.put(environment.GetId(), referenceId, checksum, entity.Id)
+		}
 	}
 
-	return errors
+	if err == nil && !dryRun && len(entity.Response) == 0 && state.isResponseReferenced(referenceId) {
+		entity.Response, err = readResponse(client, config, entity)
+	}
+
+	if entity.Name != "" {
+		state.addEntity(referenceId, entity)
+	}
+
+	return entity, action, changes, err, false
 }
 
-func validateConfig(project project.Project, config config.Config, dict map[string]api.DynatraceEntity, environment environment.Environment) (entity api.DynatraceEntity, err error) {
-	util.Log.Debug("\t\tValidating config " + config.GetFilePath())
+// readResponse reads the deployed object of a config, for APIs which return no response on deployment, e.g. when
+// the object is updated, or if the existing object was kept. Settings objects are not read, as their responses
+// only contain the object id.
+func readResponse(client rest.DynatraceClient, config config.Config, entity api.DynatraceEntity) ([]byte, error) {
+	if config.GetApi().IsSettingsApi() {
+		return nil, nil
+	}
 
-	_, err = config.GetConfigForEnvironment(environment, dict)
+	response, err := client.ReadById(config.GetApi(), entity.Id)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s, whose response is referenced by other configs: %w, responsible config: %s", entity.Name, err, config.GetFilePath())
+	}
+	return response, nil
+}
+
+// findResumedConfig returns whether the config was deployed with the same checksum by the resumed run, and the entity
+// deployed then. The object is not looked up, as the resumed run deployed it only recently.
+func findResumedConfig(config config.Config, dict map[string]api.DynatraceEntity, environment environment.Environment,
+	objectName string, settings api.SettingsObject, referenceId string, run *runState) (entity api.DynatraceEntity, checksum string, resumed bool, err error) {
+
+	payload, err := config.GetConfigForEnvironment(environment, dict)
+	if err != nil {
+		return entity, "", false, err
+	}
+
+	checksum = configChecksum(config.GetApi(), objectName, payload, settings)
+	deployed, resumed := run.deployed(environment.GetId(), referenceId, checksum)
+	if !resumed {
+		return entity, checksum, false, nil
+	}
+	return api.DynatraceEntity{Id: deployed.Id, Name: objectName, Payload: payload}, checksum, true, nil
+}
+
+// findUnchangedConfig returns whether the config was deployed with the same checksum by a previous deployment and
+// its object still exists, and the entity deployed then. If the state is refreshed, the config is only unchanged if
+// the live object still contains the deployed payload, so changes made in the environment are reverted.
+func findUnchangedConfig(client rest.DynatraceClient, config config.Config, dict map[string]api.DynatraceEntity, environment environment.Environment,
+	objectName string, settings api.SettingsObject, automationId string, referenceId string, checksums *checksumState) (entity api.DynatraceEntity, checksum string, unchanged bool, err error) {
+
+	payload, err := config.GetConfigForEnvironment(environment, dict)
+	if err != nil {
+		return entity, "", false, err
+	}
+
+	checksum = configChecksum(config.GetApi(), objectName, payload, settings)
+	if !checksums.unchanged(environment.GetId(), referenceId, checksum) {
+		return entity, checksum, false, nil
+	}
+
+	// Single configuration APIs always exist
+	id := config.GetApi().GetId()
+	if !config.GetApi().IsSingleConfigurationApi() {
+		var exists bool
+		if config.GetApi().IsSettingsApi() {
+			exists, id, err = client.ExistsSettings(config.GetApi(), settings)
+		} else if config.GetApi().IsAutomationApi() {
+			id = automationId
+			exists, err = client.ExistsAutomation(config.GetApi(), automationId)
+		} else {
+			exists, id, err = client.ExistsByName(config.GetApi(), objectName)
+		}
+		if err != nil {
+			return entity, "", false, fmt.Errorf("could not check whether %s exists: %w, responsible config: %s", objectName, err, config.GetFilePath())
+		}
+		if !exists {
+			checksums.remove(environment.GetId(), referenceId)
+			return entity, checksum, false, nil
+		}
+	}
+
+	// settings objects are not read, as their value can't be read by their id
+	if checksums.refresh && !config.GetApi().IsSettingsApi() {
+		live, err := client.ReadById(config.GetApi(), id)
+		if err != nil {
+			return entity, "", false, fmt.Errorf("could not read %s: %w, responsible config: %s", objectName, err, config.GetFilePath())
+		}
+		if !containsPayload(live, payload) {
+			checksums.remove(environment.GetId(), referenceId)
+			return entity, checksum, false, nil
+		}
+	}
+
+	return api.DynatraceEntity{Id: id, Name: objectName, Payload: payload}, checksum, true, nil
+}
+
+// findExistingCreateOnlyConfig returns the existing object of a config with skipIfExists set, which must not be
+// updated. For all other configs, exists is false. Settings are looked up by the external id of the settings object,
+// automation objects by the id derived from their config.
+func findExistingCreateOnlyConfig(client rest.DynatraceClient, config config.Config, environment environment.Environment,
+	objectName string, settings api.SettingsObject, automationId string) (entity api.DynatraceEntity, exists bool, err error) {
+
+	if !config.IsSkipIfExists(environment) {
+		return entity, false, nil
+	}
+
+	// Single configuration APIs always exist
+	if config.GetApi().IsSingleConfigurationApi() {
+		return api.DynatraceEntity{Id: config.GetApi().GetId(), Name: objectName}, true, nil
+	}
+
+	var id string
+	if config.GetApi().IsSettingsApi() {
+		exists, id, err = client.ExistsSettings(config.GetApi(), settings)
+	} else if config.GetApi().IsAutomationApi() {
+		id = automationId
+		exists, err = client.ExistsAutomation(config.GetApi(), automationId)
+	} else {
+		exists, id, err = client.ExistsByName(config.GetApi(), objectName)
+	}
+	if err != nil {
+		return entity, false, fmt.Errorf("could not check whether %s exists: %w, responsible config: %s", objectName, err, config.GetFilePath())
+	}
+	return api.DynatraceEntity{Id: id, Name: objectName}, exists, nil
+}
+
+func validateConfig(project project.Project, config config.Config, dict map[string]api.DynatraceEntity, environment environment.Environment,
+	schemas *schema.Validator, log *util.Logger) (entity api.DynatraceEntity, err error) {
+	log.Debug("\t\tValidating config " + config.GetFilePath())
+
+	payload, err := config.GetConfigForEnvironment(environment, dict)
 
 	if err != nil {
 		return entity, err
 	}
 
+	if err = schemas.Validate(config.GetType(), payload); err != nil {
+		return entity, fmt.Errorf("%w\n\tresponsible config: %s", err, config.GetFilePath())
+	}
+
+	if err = api.CheckConstraints(config.GetApi(), payload); err != nil {
+		return entity, fmt.Errorf("%w\n\tresponsible config: %s", err, config.GetFilePath())
+	}
+
 	randomId := "random-" + strconv.Itoa(rand.Int())
 
 	// If configuration deployment skipped but has dependency, throw an error
 	if config.IsSkipDeployment(environment) {
-		util.Log.Info("\t\t\tskipping deployment of %s: %s", config.GetId(), config.GetFilePath())
+		log.Info("\t\t\tskipping deployment of %s: %s", config.GetId(), config.GetFilePath())
 		erronousDependencies := make([]string, 0)
 
 		for _, requiredId := range config.GetRequiredByConfigIdList() {
@@ -197,7 +971,7 @@ func validateConfig(project project.Project, config config.Config, dict map[stri
 			requiredConfig, err := project.GetConfig(requiredId)
 
 			if err != nil {
-				util.Log.Warn("Encountered known bug (cross project skipDeployment check is not working at the moment): %s", err)
+				log.Warn("Encountered known bug (cross project skipDeployment check is not working at the moment): %s", err)
 				// return api.DynatraceEntity{
 				// 	Id:          randomId,
 				// 	Name:        randomId,
@@ -227,60 +1001,70 @@ func validateConfig(project project.Project, config config.Config, dict map[stri
 		Id:          randomId,
 		Name:        randomId,
 		Description: randomId,
+		Payload:     payload,
+		Planned:     true,
 	}, err
 }
 
-func uploadConfig(client rest.DynatraceClient, config config.Config, dict map[string]api.DynatraceEntity, environment environment.Environment) (entity api.DynatraceEntity, err error) {
+func uploadConfig(client rest.DynatraceClient, config config.Config, dict map[string]api.DynatraceEntity, environment environment.Environment,
+	schemas *schema.Validator, settings api.SettingsObject, automationId string, log *util.Logger) (entity api.DynatraceEntity, err error) {
 	name, err := config.GetObjectNameForEnvironment(environment, dict)
 	if err != nil {
 		return entity, err
 	}
 
-	util.Log.Debug("\t\tApplying config `%s` using %s", name, config.GetFilePath())
+	log.Debug("\t\tApplying config `%s` using %s", name, config.GetFilePath())
 
 	uploadMap, err := config.GetConfigForEnvironment(environment, dict)
 	if err != nil {
 		return entity, err
 	}
 
-	entity, err = client.UpsertByName(config.GetApi(), name, uploadMap)
+	if err = schemas.Validate(config.GetType(), uploadMap); err != nil {
+		return entity, fmt.Errorf("%w\n\tresponsible config: %s", err, config.GetFilePath())
+	}
+
+	if config.GetApi().IsSettingsApi() {
+		settings.Value = uploadMap
+		entity, err = client.UpsertSettings(config.GetApi(), settings)
+	} else if config.GetApi().IsAutomationApi() {
+		entity, err = client.UpsertAutomation(config.GetApi(), automationId, name, uploadMap)
+	} else {
+		entity, err = client.UpsertByName(config.GetApi(), name, uploadMap)
+	}
 
 	if err != nil {
-		err = fmt.Errorf("%s, responsible config: %s", err.Error(), config.GetFilePath())
+		err = fmt.Errorf("%w, responsible config: %s, environment: %s", err, config.GetFilePath(), environment.GetId())
+	} else {
+		entity.Payload = uploadMap
 	}
 	return entity, err
 }
 
-// deleteConfigs deletes specified configs, if a delete.yaml file was found
-func deleteConfigs(apis map[string]api.Api, environments map[string]environment.Environment, path string, dryRun bool, fs afero.Fs) error {
-	configs, err := delete.LoadConfigsToDelete(fs, apis, path)
-	util.FailOnError(err, "deletion failed")
-
-	if len(configs) > 0 && !dryRun {
-
-		for name, environment := range environments {
-			util.Log.Info("Deleting %d configs for environment %s...", len(configs), name)
-
-			apiToken, err := environment.GetToken()
-			if err != nil {
-				return err
-			}
-
-			client, err := rest.NewDynatraceClient(environment.GetEnvironmentUrl(), apiToken)
-			if err != nil {
-				return err
-			}
-
-			for _, config := range configs {
-				util.Log.Debug("\tDeleting config " + config.GetId() + " (" + config.GetApi().GetId() + ")")
+// addPlannedDeletions adds the configs specified in the delete.yaml file to the summary of all environments
+func addPlannedDeletions(summary *deploymentSummary, apis map[string]api.Api, environments map[string]environment.Environment, path string, fs afero.Fs,
+	log *util.Logger) error {
+	configs, err := delete.LoadConfigsToDelete(fs, apis, path, log)
+	if err != nil {
+		return err
+	}
 
-				err = client.DeleteByName(config.GetApi(), config.GetId())
-				if err != nil {
-					return err
-				}
-			}
+	for name := range environments {
+		for _, config := range configs {
+			summary.add(name, config.GetApi().GetId(), actionDelete)
 		}
 	}
 
 	return nil
 }
+
+// newDynatraceClient creates a client for the environment. Cluster APIs are sent to the Dynatrace Managed cluster of
+// the environment, if it is defined.
+func newDynatraceClient(environment environment.Environment, apiToken string, log *util.Logger) (rest.DynatraceClient, error) {
+	clusterToken, err := environment.GetManagedClusterToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return rest.NewManagedDynatraceClientWithLogger(environment.GetEnvironmentUrl(), apiToken, environment.GetManagedClusterUrl(), clusterToken, log)
+}
@@ -20,36 +20,45 @@
 package deploy
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/bundle"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/tracing"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
 
 	"gotest.tools/assert"
 )
 
 func TestFailsOnMissingFileName(t *testing.T) {
-	_, err := environment.LoadEnvironmentList("dev", "", util.CreateTestFileSystem())
+	_, err := environment.LoadEnvironmentList("dev", "", util.CreateTestFileSystem(), util.DefaultLogger())
 	assert.Assert(t, len(err) == 1, "Expected error return")
 }
 
 func TestLoadsEnvironmentListCorrectly(t *testing.T) {
-	environments, err := environment.LoadEnvironmentList("", "../../cmd/monaco/test-resources/test-environments.yaml", util.CreateTestFileSystem())
+	environments, err := environment.LoadEnvironmentList("", "../../cmd/monaco/test-resources/test-environments.yaml", util.CreateTestFileSystem(), util.DefaultLogger())
 	assert.Assert(t, len(err) == 0, "Expected no error")
 	assert.Assert(t, len(environments) == 3, "Expected to load test environments 1-3!")
 }
 
 func TestLoadSpecificEnvironmentCorrectly(t *testing.T) {
-	environments, err := environment.LoadEnvironmentList("test2", "../../cmd/monaco/test-resources/test-environments.yaml", util.CreateTestFileSystem())
+	environments, err := environment.LoadEnvironmentList("test2", "../../cmd/monaco/test-resources/test-environments.yaml", util.CreateTestFileSystem(), util.DefaultLogger())
 	assert.Assert(t, len(err) == 0, "Expected no error")
 	assert.Assert(t, len(environments) == 1, "Expected to load test environment 2 only!")
 	assert.Assert(t, environments["test2"] != nil, "test2 environment not found in returned list!")
 }
 
 func TestMissingSpecificEnvironmentResultsInError(t *testing.T) {
-	environments, err := environment.LoadEnvironmentList("test42", "../../cmd/monaco/test-resources/test-environments.yaml", util.CreateTestFileSystem())
+	environments, err := environment.LoadEnvironmentList("test42", "../../cmd/monaco/test-resources/test-environments.yaml", util.CreateTestFileSystem(), util.DefaultLogger())
 	assert.Assert(t, len(err) == 1, "Expected error from referencing unknown environment")
 	assert.Assert(t, len(environments) == 0, "Expected to get empty environment map even on error")
 }
@@ -65,14 +74,43 @@ func TestExecuteFailOnDuplicateNamesWithinSameConfig(t *testing.T) {
 	fs := util.CreateTestFileSystem()
 	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
 	//always use files relative to the local folder or absolute paths, don't use ../ to navigate to upper levels to allow to run the test locally
-	projects, err := project.LoadProjectsToDeploy(fs, "project1", apis, "./test-resources/duplicate-name-test")
+	projects, err := project.LoadProjectsToDeploy(fs, "project1", apis, "./test-resources/duplicate-name-test", util.DefaultLogger())
 	assert.NilError(t, err)
 
-	errors := execute(environment, projects, true, "", false)
+	errors := execute(context.Background(), environment, projects, true, false, "", false, newDeploymentSummary(), newDeploymentReport(), 1, nil, nil, nil, nil, nil, false, util.DefaultLogger())
 	assert.Equal(t, errors != nil, true)
 	assert.ErrorContains(t, errors[0], "duplicate UID 'calculated-metrics-log/metric' found in")
 }
 
+func TestExecuteTracesEnvironmentAndConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "MONACO_TEST_MISSING_TOKEN")
+	util.UnsetEnv(t, "MONACO_TEST_MISSING_TOKEN")
+
+	spans := tracing.RecordSpans(t)
+
+	ctx, root := tracing.Start(context.Background(), "monaco deploy")
+	errors := execute(ctx, environment, []project.Project{createTestProject(t)}, true, false, "", false, newDeploymentSummary(), newDeploymentReport(), 1, nil, nil, nil, nil, nil, false, util.DefaultLogger())
+	assert.Equal(t, len(errors), 0)
+	root.End()
+
+	recorded := spans()
+	assert.Equal(t, len(recorded), 5)
+
+	environmentSpan := tracing.FindSpan(t, recorded, "deploy environment")
+	assert.Equal(t, environmentSpan.ParentSpanId, tracing.FindSpan(t, recorded, "monaco deploy").SpanId)
+	assert.Equal(t, environmentSpan.Attributes["monaco.environment"], "dev")
+
+	configs := 0
+	for _, span := range recorded {
+		if span.Name == "deploy config" {
+			configs++
+			assert.Equal(t, span.ParentSpanId, environmentSpan.SpanId)
+			assert.Equal(t, span.Attributes["monaco.project"], "proj")
+		}
+	}
+	assert.Equal(t, configs, 3)
+}
+
 func TestExecutePassOnDifferentApis(t *testing.T) {
 	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
 
@@ -80,10 +118,10 @@ func TestExecutePassOnDifferentApis(t *testing.T) {
 
 	path := util.ReplacePathSeparators("./test-resources/duplicate-name-test")
 	fs := util.CreateTestFileSystem()
-	projects, err := project.LoadProjectsToDeploy(fs, "project2", apis, path)
+	projects, err := project.LoadProjectsToDeploy(fs, "project2", apis, path, util.DefaultLogger())
 	assert.NilError(t, err)
 
-	errors := execute(environment, projects, true, "", false)
+	errors := execute(context.Background(), environment, projects, true, false, "", false, newDeploymentSummary(), newDeploymentReport(), 1, nil, nil, nil, nil, nil, false, util.DefaultLogger())
 	for _, err := range errors {
 		assert.NilError(t, err)
 	}
@@ -96,10 +134,10 @@ func TestExecuteFailOnDuplicateNamesInDifferentProjects(t *testing.T) {
 
 	path := util.ReplacePathSeparators("./test-resources/duplicate-name-test")
 	fs := util.CreateTestFileSystem()
-	projects, err := project.LoadProjectsToDeploy(fs, "project1, project2", apis, path)
+	projects, err := project.LoadProjectsToDeploy(fs, "project1, project2", apis, path, util.DefaultLogger())
 	assert.NilError(t, err)
 
-	errors := execute(environment, projects, true, "", false)
+	errors := execute(context.Background(), environment, projects, true, false, "", false, newDeploymentSummary(), newDeploymentReport(), 1, nil, nil, nil, nil, nil, false, util.DefaultLogger())
 	assert.ErrorContains(t, errors[0], "duplicate UID 'calculated-metrics-log/metric' found in")
 }
 
@@ -111,14 +149,14 @@ func TestExecutePassOnDuplicateNamesInDifferentEnvironments(t *testing.T) {
 
 	path := util.ReplacePathSeparators("./test-resources/duplicate-name-test")
 	fs := util.CreateTestFileSystem()
-	projects, err := project.LoadProjectsToDeploy(fs, "project5", apis, path)
+	projects, err := project.LoadProjectsToDeploy(fs, "project5", apis, path, util.DefaultLogger())
 	assert.NilError(t, err)
 
-	errors := execute(environmentDev, projects, true, "", false)
+	errors := execute(context.Background(), environmentDev, projects, true, false, "", false, newDeploymentSummary(), newDeploymentReport(), 1, nil, nil, nil, nil, nil, false, util.DefaultLogger())
 	for _, err := range errors {
 		assert.NilError(t, err)
 	}
-	errors = execute(environmentProd, projects, true, "", false)
+	errors = execute(context.Background(), environmentProd, projects, true, false, "", false, newDeploymentSummary(), newDeploymentReport(), 1, nil, nil, nil, nil, nil, false, util.DefaultLogger())
 	for _, err := range errors {
 		assert.NilError(t, err)
 	}
@@ -126,8 +164,295 @@ func TestExecutePassOnDuplicateNamesInDifferentEnvironments(t *testing.T) {
 
 // TODO (CDF-6511) Currently here UnmarshallYaml logs fatal, only ever returns nil errors!
 // func TestInvalidEnvironmentFileResultsInError(t *testing.T) {
-// 	_, err := environment.LoadEnvironmentList("", "test-resources/invalid-environmentsfile.yaml")
+// 	_, err := environment.LoadEnvironmentList("", "test-resources/invalid-environmentsfile.yaml", util.DefaultLogger())
 // 	assert.Assert(t, err != nil, "Expected error return")
 // }
 
 // TODO (CDF-6511) add tests when execute failures of single environments don't crash program anymore
+
+func TestExecuteSerialSkipsDependentsOfFailedConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := &recordingClient{failing: "profile"}
+	projects := []project.Project{createTestProject(t)}
+
+	errors := executeSerial(context.Background(), client, environment, projects, false, "", true, newDeploymentSummary(), newDeploymentReport(), newDeploymentState())
+
+	assert.Equal(t, len(errors), 2)
+	assert.ErrorContains(t, errors[0], "proj/alerting-profile/profile (project: proj, environment: dev): upload failed")
+	assert.ErrorContains(t, errors[1], "proj/calculated-metrics-log/metric (project: proj, environment: dev): skipped deployment, as it depends on failed config proj/alerting-profile/profile")
+	assert.DeepEqual(t, client.upserted, []string{"other"})
+}
+
+func TestExecuteSerialStopsOnErrorWithoutContinueOnError(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := &recordingClient{failing: "profile"}
+	projects := []project.Project{createTestProject(t)}
+
+	errors := executeSerial(context.Background(), client, environment, projects, false, "", false, newDeploymentSummary(), newDeploymentReport(), newDeploymentState())
+
+	assert.Equal(t, len(errors), 1)
+	assert.Equal(t, len(client.upserted), 0)
+}
+
+func createSkipIfExistsTestProject(t *testing.T) project.Project {
+	return &testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": "none", "skipIfExists": "true"}),
+			createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/profile.id"}),
+		},
+	}
+}
+
+func TestExecuteSerialSkipsExistingCreateOnlyConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "existing-profile-id", nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "existing-profile-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	summary := newDeploymentSummary()
+	report := newDeploymentReport()
+
+	errors := executeSerial(context.Background(), client, environment, []project.Project{createSkipIfExistsTestProject(t)}, false, "", false, summary, report, newDeploymentState())
+	assert.Equal(t, len(errors), 0)
+
+	assert.Equal(t, summary.count("dev", "alerting-profile", actionSkip), 1)
+	assert.Equal(t, report.results[0].Action, resultSkipped)
+	assert.Equal(t, report.results[0].EntityId, "existing-profile-id")
+	assert.Equal(t, report.results[1].Action, resultUpdated)
+}
+
+func TestExecuteSerialCreatesMissingCreateOnlyConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(false, "", nil)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile", Created: true}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	report := newDeploymentReport()
+
+	errors := executeSerial(context.Background(), client, environment, []project.Project{createSkipIfExistsTestProject(t)}, false, "", false, newDeploymentSummary(), report, newDeploymentState())
+	assert.Equal(t, len(errors), 0)
+
+	assert.Equal(t, report.results[0].Action, resultCreated)
+}
+
+func TestDryRunPlansToSkipExistingCreateOnlyConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "existing-profile-id", nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(true, "metric-id", nil)
+
+	summary := newDeploymentSummary()
+
+	errors := executeSerial(context.Background(), client, environment, []project.Project{createSkipIfExistsTestProject(t)}, true, "", false, summary, newDeploymentReport(), newDeploymentState())
+	assert.Equal(t, len(errors), 0)
+
+	assert.Equal(t, summary.count("dev", "alerting-profile", actionSkip), 1)
+	assert.Equal(t, summary.count("dev", "calculated-metrics-log", actionUpdate), 1)
+}
+
+func TestExecuteSerialStopsOnceCancelled(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).DoAndReturn(func(api.Api, string, []byte) (api.DynatraceEntity, error) {
+		cancel()
+		return api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil
+	})
+
+	report := newDeploymentReport()
+
+	errors := executeSerial(ctx, client, environment, []project.Project{createTestProject(t)}, false, "", false, newDeploymentSummary(), report, newDeploymentState())
+	assert.Equal(t, len(errors), 0)
+	assert.Equal(t, len(report.results), 1)
+}
+
+func TestExecuteSerialResolvesReferencesOfBundledConfigs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"environments.yaml":                          "dev:\n  - name: \"Dev\"\n  - env-url: \"https://url/to/dev/environment\"\n  - env-token-name: \"DEV\"\n",
+		"proj/alerting-profile/profile.yaml":         "config:\n  - profile: \"profile.json\"\n\nprofile:\n  - name: \"profile\"\n",
+		"proj/alerting-profile/profile.json":         `{"name": "{{.name}}"}`,
+		"proj/calculated-metrics-log/metric.yaml":    "config:\n  - metric: \"metric.json\"\n\nmetric:\n  - name: \"metric\"\n  - reference: \"proj/alerting-profile/profile.id\"\n",
+		"proj/calculated-metrics-log/metric.json":    `{"name": "{{.name}}", "reference": "{{.reference}}"}`,
+		"proj/calculated-metrics-log/unrelated.json": `{}`,
+		"proj/calculated-metrics-log/unrelated.yaml": "config:\n  - unrelated: \"unrelated.json\"\n\nunrelated:\n  - name: \"unrelated\"\n  - skipDeployment: \"true\"\n",
+	}
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+
+	assert.NilError(t, bundle.Create(".", fs, "environments.yaml", "", "", "bundle.zip"))
+
+	b, err := bundle.Load(fs, "bundle.zip")
+	assert.NilError(t, err)
+
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(gomock.Any(), "profile", []byte(`{"name": "profile"}`)).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	client.EXPECT().UpsertByName(gomock.Any(), "metric", []byte(`{"name": "metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	errors := executeSerial(context.Background(), client, environment, b.Projects, false, "", false, newDeploymentSummary(), newDeploymentReport(), newDeploymentState())
+	assert.Equal(t, len(errors), 0)
+}
+
+func TestDeployRejectsProjectSelectionForBundles(t *testing.T) {
+	opts := NewOptions()
+	opts.Project = "proj"
+	opts.BundleFile = "bundle.zip"
+
+	_, err := Deploy(context.Background(), afero.NewMemMapFs(), ".", "environments.yaml", opts)
+	assert.Error(t, err, "projects can't be selected when deploying a bundle, as it contains the projects it was created for")
+}
+
+// createResponseTestProject creates a project with a profile and a metric referencing a field of the API response of
+// the profile
+func createResponseTestProject(t *testing.T) []project.Project {
+	return []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": "none"}),
+			createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/profile.response.metadata.key"}),
+		},
+	}}
+}
+
+func TestExecuteSerialResolvesFieldsOfCreateResponses(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).Return(api.DynatraceEntity{
+		Id: "profile-id", Name: "profile", Created: true, Response: []byte(`{"id": "profile-id", "metadata": {"key": "assigned-key"}}`),
+	}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "assigned-key"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	projects := createResponseTestProject(t)
+	state := newDeploymentState()
+	state.addResponseReferences(projects)
+
+	errors := executeSerial(context.Background(), client, environment, projects, false, "", false, newDeploymentSummary(), newDeploymentReport(), state)
+	assert.Equal(t, len(errors), 0)
+}
+
+func TestExecuteSerialReadsReferencedObjectsUpdatedWithoutResponse(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	client.EXPECT().ReadById(testProfileApi, "profile-id").Return([]byte(`{"id": "profile-id", "metadata": {"key": "assigned-key"}}`), nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "assigned-key"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	projects := createResponseTestProject(t)
+	state := newDeploymentState()
+	state.addResponseReferences(projects)
+
+	errors := executeSerial(context.Background(), client, environment, projects, false, "", false, newDeploymentSummary(), newDeploymentReport(), state)
+	assert.Equal(t, len(errors), 0)
+}
+
+func TestExecuteSerialFailsOnFieldsMissingInResponse(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).Return(api.DynatraceEntity{
+		Id: "profile-id", Name: "profile", Created: true, Response: []byte(`{"id": "profile-id", "metadata": {}}`),
+	}, nil)
+
+	projects := createResponseTestProject(t)
+	state := newDeploymentState()
+	state.addResponseReferences(projects)
+
+	errors := executeSerial(context.Background(), client, environment, projects, false, "", true, newDeploymentSummary(), newDeploymentReport(), state)
+	assert.Equal(t, len(errors), 1)
+	assert.ErrorContains(t, errors[0], "field metadata.key does not exist in the response of proj/alerting-profile/profile")
+}
+
+func TestDryRunResolvesFieldsOfResponsesToPlaceholders(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(gomock.Any(), gomock.Any()).Return(false, "", nil).Times(2)
+
+	projects := createResponseTestProject(t)
+	state := newDeploymentState()
+	state.addResponseReferences(projects)
+
+	errors := executeSerial(context.Background(), client, environment, projects, true, "", false, newDeploymentSummary(), newDeploymentReport(), state)
+	assert.Equal(t, len(errors), 0)
+	assert.Equal(t, string(state.entities()["proj/calculated-metrics-log/metric"].Payload), `{"name": "metric", "reference": "<metadata.key of proj/alerting-profile/profile after deployment>"}`)
+}
+
+// tokenTestClient records the token it was created with by WithToken
+type tokenTestClient struct {
+	rest.DynatraceClient
+	token string
+}
+
+func (c *tokenTestClient) WithToken(token string) rest.DynatraceClient {
+	return &tokenTestClient{DynatraceClient: c.DynatraceClient, token: token}
+}
+
+func TestClientWithTokenOfConfigUsesNamedToken(t *testing.T) {
+	environments, errs := environment.NewEnvironments(map[string]map[string]string{
+		"dev": {"name": "Dev", "env-url": "https://url/to/dev/environment", "env-token-name": "DEV", "env-token-name.settings": "MONACO_TEST_SETTINGS_TOKEN"},
+	})
+	assert.Equal(t, len(errs), 0)
+	dev := environments["dev"]
+
+	util.SetEnv(t, "MONACO_TEST_SETTINGS_TOKEN", "settings-token")
+	defer util.UnsetEnv(t, "MONACO_TEST_SETTINGS_TOKEN")
+
+	client := &tokenTestClient{token: "default-token"}
+	log := util.DefaultLogger()
+
+	withToken, err := clientWithTokenOf(client, dev, createTestConfigWithProperties(t, "named", testProfileApi, map[string]string{"name": "named", "tokenName": "settings"}), false, log)
+	assert.NilError(t, err)
+	assert.Equal(t, withToken.(*tokenTestClient).token, "settings-token")
+
+	withToken, err = clientWithTokenOf(client, dev, createTestConfigWithProperties(t, "default", testProfileApi, map[string]string{"name": "default"}), false, log)
+	assert.NilError(t, err)
+	assert.Equal(t, withToken, rest.DynatraceClient(client))
+}
+
+func TestClientWithTokenOfConfigFailsOnUnavailableToken(t *testing.T) {
+	environments, errs := environment.NewEnvironments(map[string]map[string]string{
+		"dev": {"name": "Dev", "env-url": "https://url/to/dev/environment", "env-token-name": "DEV", "env-token-name.settings": "MONACO_TEST_MISSING_TOKEN"},
+	})
+	assert.Equal(t, len(errs), 0)
+	util.UnsetEnv(t, "MONACO_TEST_MISSING_TOKEN")
+
+	named := createTestConfigWithProperties(t, "named", testProfileApi, map[string]string{"name": "named", "tokenName": "settings"})
+
+	_, err := clientWithTokenOf(&tokenTestClient{}, environments["dev"], named, false, util.DefaultLogger())
+	assert.ErrorContains(t, err, "MONACO_TEST_MISSING_TOKEN")
+
+	withToken, err := clientWithTokenOf(&tokenTestClient{}, environments["dev"], named, true, util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Assert(t, withToken == nil)
+}
+
+func TestExecuteSerialReturnsApiErrorsOfFailedRequests(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).Return(api.DynatraceEntity{}, &rest.ApiError{StatusCode: http.StatusBadRequest, Method: http.MethodPost})
+	client.EXPECT().UpsertByName(testProfileApi, "other", gomock.Any()).Return(api.DynatraceEntity{Id: "other-id", Name: "other"}, nil)
+
+	errs := executeSerial(context.Background(), client, environment, []project.Project{createTestProject(t)}, false, "", true, newDeploymentSummary(), newDeploymentReport(), newDeploymentState())
+	assert.Assert(t, len(errs) > 0)
+
+	var apiErr *rest.ApiError
+	assert.Assert(t, errors.As(errs[0], &apiErr))
+	assert.Equal(t, apiErr.StatusCode, http.StatusBadRequest)
+	assert.Equal(t, apiErr.Method, http.MethodPost)
+}
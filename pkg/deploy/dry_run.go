@@ -0,0 +1,262 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/diff"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/jcelliott/lumber"
+)
+
+// deploymentAction describes what a deployment would do with a config
+type deploymentAction string
+
+const (
+	actionCreate deploymentAction = "create"
+	actionUpdate deploymentAction = "update"
+	// actionDeploy is used if it is unknown whether a config would be created or updated, as the environment
+	// can't be accessed
+	actionDeploy deploymentAction = "deploy"
+	actionSkip   deploymentAction = "skip"
+	actionDelete deploymentAction = "delete"
+)
+
+var deploymentActions = []deploymentAction{actionCreate, actionUpdate, actionDeploy, actionSkip, actionDelete}
+
+// deploymentSummary counts the configs per environment, config type (api) and action during a dry run.
+// It is safe for concurrent use.
+type deploymentSummary struct {
+	mutex  sync.Mutex
+	counts map[string]map[string]map[deploymentAction]int
+}
+
+func newDeploymentSummary() *deploymentSummary {
+	return &deploymentSummary{
+		counts: make(map[string]map[string]map[deploymentAction]int),
+	}
+}
+
+func (s *deploymentSummary) add(environment string, configType string, action deploymentAction) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.counts[environment] == nil {
+		s.counts[environment] = make(map[string]map[deploymentAction]int)
+	}
+	if s.counts[environment][configType] == nil {
+		s.counts[environment][configType] = make(map[deploymentAction]int)
+	}
+	s.counts[environment][configType][action]++
+}
+
+func (s *deploymentSummary) count(environment string, configType string, action deploymentAction) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.counts[environment][configType][action]
+}
+
+// lines returns a human-readable summary, sorted by environment and config type
+func (s *deploymentSummary) lines() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	lines := make([]string, 0)
+
+	for _, environment := range sortedEnvironments(s.counts) {
+		lines = append(lines, fmt.Sprintf("Environment %s:", environment))
+
+		totals := make(map[deploymentAction]int)
+		for _, configType := range sortedConfigTypes(s.counts[environment]) {
+			counts := s.counts[environment][configType]
+			for action, count := range counts {
+				totals[action] += count
+			}
+			lines = append(lines, fmt.Sprintf("\t%s: %s", configType, formatActionCounts(counts)))
+		}
+		lines = append(lines, fmt.Sprintf("\ttotal: %s", formatActionCounts(totals)))
+	}
+
+	return lines
+}
+
+func (s *deploymentSummary) print(log *util.Logger) {
+	log.Info("Dry run summary:")
+	for _, line := range s.lines() {
+		log.Info("\t" + line)
+	}
+}
+
+func formatActionCounts(counts map[deploymentAction]int) string {
+	parts := make([]string, 0, len(deploymentActions))
+	for _, action := range deploymentActions {
+		if counts[action] > 0 {
+			parts = append(parts, fmt.Sprintf("%d to %s", counts[action], action))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedEnvironments(counts map[string]map[string]map[deploymentAction]int) []string {
+	environments := make([]string, 0, len(counts))
+	for environment := range counts {
+		environments = append(environments, environment)
+	}
+	sort.Strings(environments)
+	return environments
+}
+
+func sortedConfigTypes(counts map[string]map[deploymentAction]int) []string {
+	configTypes := make([]string, 0, len(counts))
+	for configType := range counts {
+		configTypes = append(configTypes, configType)
+	}
+	sort.Strings(configTypes)
+	return configTypes
+}
+
+// createDryRunClient creates a client for the environment, which only sends read-only (GET) requests. If the
+// token of the environment is not available, nil is returned and configs are validated without accessing the
+// environment.
+func createDryRunClient(environment environment.Environment, log *util.Logger) (rest.DynatraceClient, error) {
+	apiToken, err := environment.GetToken()
+	if err != nil {
+		log.Warn("\tToken of environment %s is not available (%s): validating configs without checking whether they already exist", environment.GetId(), err)
+		return nil, nil
+	}
+
+	client, err := newDynatraceClient(environment, apiToken, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readOnlyClient{client}, nil
+}
+
+// readOnlyClient is a DynatraceClient which refuses all mutating requests
+type readOnlyClient struct {
+	rest.DynatraceClient
+}
+
+// WithToken returns a read-only client sending the requests of the wrapped client using the given token
+func (r *readOnlyClient) WithToken(token string) rest.DynatraceClient {
+	return &readOnlyClient{rest.WithToken(r.DynatraceClient, token)}
+}
+
+// WithTraceContext returns a read-only client tracing the requests of the wrapped client
+func (r *readOnlyClient) WithTraceContext(ctx context.Context) rest.DynatraceClient {
+	return &readOnlyClient{rest.WithTraceContext(r.DynatraceClient, ctx)}
+}
+
+func (r *readOnlyClient) UpsertByName(a api.Api, name string, _ []byte) (api.DynatraceEntity, error) {
+	return api.DynatraceEntity{}, fmt.Errorf("refusing to upsert %s %s during dry run", a.GetId(), name)
+}
+
+func (r *readOnlyClient) UpsertById(a api.Api, _ string, name string, _ []byte) (api.DynatraceEntity, error) {
+	return api.DynatraceEntity{}, fmt.Errorf("refusing to upsert %s %s during dry run", a.GetId(), name)
+}
+
+func (r *readOnlyClient) UpsertSettings(a api.Api, object api.SettingsObject) (api.DynatraceEntity, error) {
+	return api.DynatraceEntity{}, fmt.Errorf("refusing to upsert %s %s during dry run", a.GetId(), object.Name)
+}
+
+func (r *readOnlyClient) UpsertAutomation(a api.Api, _ string, name string, _ []byte) (api.DynatraceEntity, error) {
+	return api.DynatraceEntity{}, fmt.Errorf("refusing to upsert %s %s during dry run", a.GetId(), name)
+}
+
+func (r *readOnlyClient) DeleteByName(a api.Api, name string) error {
+	return fmt.Errorf("refusing to delete %s %s during dry run", a.GetId(), name)
+}
+
+// plannedAction determines whether the config would be created or updated, and returns the id of the object it would
+// update. Settings are looked up by the external id of the settings object, automation objects by the id derived from
+// their config. If no client is available, actionDeploy is returned.
+func plannedAction(client rest.DynatraceClient, config config.Config, objectName string, settings api.SettingsObject, automationId string) (deploymentAction, string, error) {
+	if client == nil {
+		return actionDeploy, "", nil
+	}
+
+	// Single configuration APIs always exist and are updated
+	if config.GetApi().IsSingleConfigurationApi() {
+		return actionUpdate, "", nil
+	}
+
+	var exists bool
+	var id string
+	var err error
+	if config.GetApi().IsSettingsApi() {
+		exists, id, err = client.ExistsSettings(config.GetApi(), settings)
+	} else if config.GetApi().IsAutomationApi() {
+		exists, err = client.ExistsAutomation(config.GetApi(), automationId)
+		id = automationId
+	} else {
+		exists, id, err = client.ExistsByName(config.GetApi(), objectName)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("could not check whether %s exists: %w, responsible config: %s", objectName, err, config.GetFilePath())
+	}
+
+	if exists {
+		return actionUpdate, id, nil
+	}
+	return actionCreate, "", nil
+}
+
+// plannedChanges compares the rendered payload of a config a dry run would update with the live object of the given
+// id, ignoring the server-managed fields like the diff command. Like the diff command, it only supports configs
+// identified by their name and single configuration APIs, so no changes are returned for other configs.
+func plannedChanges(client rest.DynatraceClient, config config.Config, id string, payload []byte, log lumber.Logger) ([]diff.Change, error) {
+	theApi := config.GetApi()
+	if theApi.IsSettingsApi() || theApi.IsAutomationApi() || theApi.GetId() == "extension" {
+		log.Debug("\t\t\tnot comparing %s with its live object: comparing %s configs is not supported", config.GetId(), theApi.GetId())
+		return nil, nil
+	}
+
+	live, err := client.ReadById(theApi, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the live object of %s: %w, responsible config: %s", config.GetId(), err, config.GetFilePath())
+	}
+
+	changes, err := diff.CompareJson(payload, live, diff.DefaultIgnoredFields)
+	if err != nil {
+		return nil, fmt.Errorf("could not compare %s with its live object: %w, responsible config: %s", config.GetId(), err, config.GetFilePath())
+	}
+	return changes, nil
+}
+
+// logPlannedChanges logs the changed fields of a config a dry run would update, one line per field
+func logPlannedChanges(log lumber.Logger, objectName string, changes []diff.Change) {
+	if len(changes) == 0 {
+		log.Info("\t\t\twould update %s: no changes", objectName)
+		return
+	}
+
+	log.Info("\t\t\twould update %s: %d change(s)", objectName, len(changes))
+	for _, change := range changes {
+		log.Info("\t\t\t\t%s", change)
+	}
+}
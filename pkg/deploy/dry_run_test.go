@@ -0,0 +1,213 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/diff"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestDeploymentSummaryLines(t *testing.T) {
+	summary := newDeploymentSummary()
+	summary.add("prod", "dashboard", actionCreate)
+	summary.add("dev", "dashboard", actionCreate)
+	summary.add("dev", "dashboard", actionUpdate)
+	summary.add("dev", "alerting-profile", actionCreate)
+	summary.add("dev", "alerting-profile", actionDelete)
+
+	assert.DeepEqual(t, summary.lines(), []string{
+		"Environment dev:",
+		"\talerting-profile: 1 to create, 1 to delete",
+		"\tdashboard: 1 to create, 1 to update",
+		"\ttotal: 2 to create, 1 to update, 1 to delete",
+		"Environment prod:",
+		"\tdashboard: 1 to create",
+		"\ttotal: 1 to create",
+	})
+}
+
+func TestExecuteDryRunWithoutTokenCountsConfigsToDeploy(t *testing.T) {
+	os.Unsetenv("DEV")
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	fs := util.CreateTestFileSystem()
+	projects, err := project.LoadProjectsToDeploy(fs, "project2", testGetExecuteApis(), util.ReplacePathSeparators("./test-resources/duplicate-name-test"), util.DefaultLogger())
+	assert.NilError(t, err)
+
+	summary := newDeploymentSummary()
+	errors := execute(context.Background(), environment, projects, true, false, "", false, summary, newDeploymentReport(), 1, nil, nil, nil, nil, nil, false, util.DefaultLogger())
+
+	assert.Equal(t, len(errors), 0)
+	assert.Equal(t, summary.count("dev", "alerting-profile", actionDeploy), 1)
+	assert.Equal(t, summary.count("dev", "calculated-metrics-log", actionDeploy), 1)
+}
+
+func createTestConfig(t *testing.T, theApi api.Api) config.Config {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "my-config.json", []byte(`{"name": "{{.name}}"}`), 0644))
+
+	c, err := config.NewConfig(fs, "my-config", "project", "my-config.json", map[string]map[string]string{}, theApi)
+	assert.NilError(t, err)
+	return c
+}
+
+func TestPlannedAction(t *testing.T) {
+	standardApi := api.NewStandardApi("alerting-profile", "/api/config/v1/alertingProfiles")
+	standardConfig := createTestConfig(t, standardApi)
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(standardApi, "existing").Return(true, "42", nil)
+	client.EXPECT().ExistsByName(standardApi, "new").Return(false, "", nil)
+	client.EXPECT().ExistsByName(standardApi, "broken").Return(false, "", errors.New("timed out"))
+
+	action, id, err := plannedAction(client, standardConfig, "existing", api.SettingsObject{}, "")
+	assert.NilError(t, err)
+	assert.Equal(t, action, actionUpdate)
+	assert.Equal(t, id, "42")
+
+	action, _, err = plannedAction(client, standardConfig, "new", api.SettingsObject{}, "")
+	assert.NilError(t, err)
+	assert.Equal(t, action, actionCreate)
+
+	_, _, err = plannedAction(client, standardConfig, "broken", api.SettingsObject{}, "")
+	assert.ErrorContains(t, err, "could not check whether broken exists: timed out")
+
+	action, _, err = plannedAction(nil, standardConfig, "new", api.SettingsObject{}, "")
+	assert.NilError(t, err)
+	assert.Equal(t, action, actionDeploy)
+
+	singleConfig := createTestConfig(t, api.NewSingleConfigurationApi("frequent-issue-detection", "/api/config/v1/frequentIssueDetection"))
+	action, _, err = plannedAction(client, singleConfig, "frequent-issue-detection", api.SettingsObject{}, "")
+	assert.NilError(t, err)
+	assert.Equal(t, action, actionUpdate)
+}
+
+// createChangedTestProject creates a project with a profile, whose liveProfile differs in a single nested field and
+// the server-managed fields, and a metric referencing the profile
+func createChangedTestProject(t *testing.T) project.Project {
+	template := `{"name": "{{.name}}", "rules": [{"severity": "AVAILABILITY", "delayInMinutes": 10}]}`
+	return &testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithTemplate(t, "profile", template, map[string]string{"name": "profile"}),
+			createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/profile.id"}),
+		},
+	}
+}
+
+const liveProfile = `{"id": "profile-id", "metadata": {"clusterVersion": "1.250"}, "name": "profile", "rules": [{"severity": "AVAILABILITY", "delayInMinutes": 5}]}`
+
+func TestDryRunShowsChangedFieldsOfUpdatedConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "profile-id", nil)
+	client.EXPECT().ReadById(testProfileApi, "profile-id").Return([]byte(liveProfile), nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(true, "metric-id", nil)
+	client.EXPECT().ReadById(testMetricApi, "metric-id").Return([]byte(`{"name": "metric", "reference": "profile-id"}`), nil)
+
+	state := newDeploymentState()
+	state.showChanges = true
+	summary := newDeploymentSummary()
+	report := newDeploymentReport()
+
+	errs := executeSerial(context.Background(), client, environment, []project.Project{createChangedTestProject(t)}, true, "", false, summary, report, state)
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, summary.count("dev", "alerting-profile", actionUpdate), 1)
+
+	expected := []diff.Change{{Kind: diff.FieldChanged, Path: "rules[0].delayInMinutes", Local: float64(10), Live: float64(5)}}
+	assert.DeepEqual(t, report.results[0].Changes, expected)
+
+	// the reference of the metric is resolved using the id of the live profile
+	assert.DeepEqual(t, report.results[1].Changes, []diff.Change{})
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, report.write(fs, "report.json", true, true))
+	assert.DeepEqual(t, readReport(t, fs, "report.json").Configs[0].Changes, expected)
+}
+
+func TestDryRunComparesConfigsOnlyIfChangesAreShown(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	// the mock fails the test if the live object is read
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "profile").Return(true, "profile-id", nil)
+	client.EXPECT().ExistsByName(testMetricApi, "metric").Return(false, "", nil)
+
+	report := newDeploymentReport()
+	errs := executeSerial(context.Background(), client, environment, []project.Project{createChangedTestProject(t)}, true, "", false, newDeploymentSummary(), report, newDeploymentState())
+	assert.Equal(t, len(errs), 0)
+	for _, result := range report.results {
+		assert.Assert(t, result.Changes == nil)
+	}
+}
+
+func TestPlannedChanges(t *testing.T) {
+	profile := createChangedTestProject(t).GetConfigs()[0]
+	payload := []byte(`{"name": "profile", "rules": [{"severity": "AVAILABILITY", "delayInMinutes": 10}]}`)
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ReadById(testProfileApi, "profile-id").Return([]byte(liveProfile), nil)
+	client.EXPECT().ReadById(testProfileApi, "other-id").Return(nil, errors.New("timed out"))
+
+	changes, err := plannedChanges(client, profile, "profile-id", payload, util.Log)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, changes, []diff.Change{{Kind: diff.FieldChanged, Path: "rules[0].delayInMinutes", Local: float64(10), Live: float64(5)}})
+
+	_, err = plannedChanges(client, profile, "other-id", payload, util.Log)
+	assert.ErrorContains(t, err, "could not read the live object of profile: timed out")
+
+	// settings objects are not compared, so they are not read
+	tags := createTestConfigWithProperties(t, "tags", testSettingsApi, map[string]string{"name": "tags"})
+	changes, err = plannedChanges(client, tags, "object-id", payload, util.Log)
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 0)
+}
+
+func TestDeployRejectsDiffWithoutDryRun(t *testing.T) {
+	opts := NewOptions()
+	opts.Diff = true
+
+	_, err := Deploy(context.Background(), afero.NewMemMapFs(), ".", "environments.yaml", opts)
+	assert.Error(t, err, "showing the changed fields of configs requires a dry run")
+}
+
+func TestReadOnlyClientRefusesMutatingRequests(t *testing.T) {
+	theApi := api.NewStandardApi("alerting-profile", "/api/config/v1/alertingProfiles")
+	client := &readOnlyClient{rest.CreateDynatraceClientMockFactory(t)}
+
+	_, err := client.UpsertByName(theApi, "profile", []byte("{}"))
+	assert.ErrorContains(t, err, "refusing to upsert alerting-profile profile during dry run")
+
+	err = client.DeleteByName(theApi, "profile")
+	assert.ErrorContains(t, err, "refusing to delete alerting-profile profile during dry run")
+}
@@ -0,0 +1,86 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+)
+
+// checkDuplicateNames verifies that no two configs of the same api in a project get the same name in an environment
+// they are deployed to, as both would be deployed to the same object. All duplicates are reported at once, together
+// with the environments they occur in and the files of the configs. Settings are identified by their external id, so
+// they may share names. Names referencing other configs are only known during the deployment and are not checked.
+func checkDuplicateNames(projects []project.Project, environments map[string]environment.Environment) error {
+	// the environments of each duplicate, by project, api, name and configs sharing it
+	duplicates := make(map[string][]string)
+
+	for _, project := range projects {
+		for _, environment := range environments {
+			configsByName := make(map[string][]string)
+			var names []string
+
+			for _, config := range project.GetConfigs() {
+				if config.GetApi().IsSettingsApi() || config.GetApi().IsAutomationApi() || config.IsSkipDeployment(environment) {
+					continue
+				}
+
+				name, err := config.GetObjectNameForEnvironment(environment, nil)
+				if err != nil {
+					continue
+				}
+
+				key := fmt.Sprintf("%s: %s '%s'", project.GetId(), config.GetApi().GetId(), name)
+				if _, found := configsByName[key]; !found {
+					names = append(names, key)
+				}
+				configsByName[key] = append(configsByName[key], fmt.Sprintf("%s (%s)", config.GetId(), config.GetFilePath()))
+			}
+
+			for _, key := range names {
+				if configs := configsByName[key]; len(configs) > 1 {
+					sort.Strings(configs)
+					duplicate := fmt.Sprintf("%s is used by %s", key, strings.Join(configs, ", "))
+					duplicates[duplicate] = append(duplicates[duplicate], environment.GetId())
+				}
+			}
+		}
+	}
+
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(duplicates))
+	for key := range duplicates {
+		keys = append(keys, key)
+		sort.Strings(duplicates[key])
+	}
+	sort.Strings(keys)
+
+	var message strings.Builder
+	message.WriteString("configs of the same project and api share their names:")
+	for _, key := range keys {
+		message.WriteString(fmt.Sprintf("\n\t%s (in environments %s)", key, strings.Join(duplicates[key], ", ")))
+	}
+	return errors.New(message.String())
+}
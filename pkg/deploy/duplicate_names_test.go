@@ -0,0 +1,146 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func createDuplicateNamesTestEnvironments() map[string]environment.Environment {
+	return map[string]environment.Environment{
+		"dev":  environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"),
+		"prod": environment.NewEnvironment("prod", "Prod", "", "https://url/to/prod/environment", "PROD"),
+	}
+}
+
+func TestCheckDuplicateNamesReportsConfigsOfProjectSharingName(t *testing.T) {
+	projects, err := project.LoadProjectsToDeploy(util.CreateTestFileSystem(), "project1", testGetExecuteApis(), util.ReplacePathSeparators("./test-resources/duplicate-name-test"), util.DefaultLogger())
+	assert.NilError(t, err)
+
+	err = checkDuplicateNames(projects, createDuplicateNamesTestEnvironments())
+	assert.ErrorContains(t, err, "configs of the same project and api share their names:\n\t")
+	assert.ErrorContains(t, err, "project1: calculated-metrics-log 'metric' is used by log-errors (")
+	assert.ErrorContains(t, err, "jira-log-errors.json), lucene-index-issues (")
+	assert.ErrorContains(t, err, "jira-lucene-index-issues.json) (in environments dev, prod)")
+	assert.Equal(t, strings.Count(err.Error(), "\n\t"), 1)
+}
+
+func TestCheckDuplicateNamesAcceptsSharedNamesOfDifferentApisAndEnvironments(t *testing.T) {
+	projects, err := project.LoadProjectsToDeploy(util.CreateTestFileSystem(), "project2,project5", testGetExecuteApis(), util.ReplacePathSeparators("./test-resources/duplicate-name-test"), util.DefaultLogger())
+	assert.NilError(t, err)
+
+	assert.NilError(t, checkDuplicateNames(projects, createDuplicateNamesTestEnvironments()))
+}
+
+func TestCheckDuplicateNamesOnlyChecksEnvironmentsConfigsAreDeployedTo(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "profile.json", []byte(`{"name": "{{.name}}"}`), 0644))
+
+	profile, err := config.NewConfig(fs, "profile", "proj", "profile.json", map[string]map[string]string{
+		"profile":      {"name": "shared"},
+		"profile.prod": {"name": "production"},
+	}, testProfileApi)
+	assert.NilError(t, err)
+
+	devOnly, err := config.NewConfig(fs, "dev-only", "proj", "profile.json", map[string]map[string]string{
+		"dev-only":      {"name": "shared"},
+		"dev-only.prod": {"skipDeployment": "true"},
+	}, testProfileApi)
+	assert.NilError(t, err)
+
+	projects := []project.Project{&testProject{id: "proj", configs: []config.Config{profile, devOnly}}}
+
+	err = checkDuplicateNames(projects, createDuplicateNamesTestEnvironments())
+	assert.Error(t, err, "configs of the same project and api share their names:\n\tproj: alerting-profile 'shared' is used by dev-only (profile.json), profile (profile.json) (in environments dev)")
+}
+
+func TestCheckDuplicateNamesAcceptsSettingsSharingName(t *testing.T) {
+	projects := []project.Project{&testProject{id: "proj", configs: []config.Config{
+		createTestConfigWithProperties(t, "tags", testSettingsApi, map[string]string{"name": "tags", "schemaId": "builtin:tags.auto-tagging", "scope": "environment"}),
+		createTestConfigWithProperties(t, "host-tags", testSettingsApi, map[string]string{"name": "tags", "schemaId": "builtin:tags.auto-tagging", "scope": "HOST-1234"}),
+	}}}
+
+	assert.NilError(t, checkDuplicateNames(projects, createDuplicateNamesTestEnvironments()))
+}
+
+func TestCheckDuplicateNamesKeepsPercentSignsOfNames(t *testing.T) {
+	projects := []project.Project{&testProject{id: "proj", configs: []config.Config{
+		createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "100%s availability"}),
+		createTestConfigWithProperties(t, "copy", testProfileApi, map[string]string{"name": "100%s availability"}),
+	}}}
+
+	err := checkDuplicateNames(projects, map[string]environment.Environment{
+		"dev": environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"),
+	})
+	assert.Error(t, err, "configs of the same project and api share their names:\n\tproj: alerting-profile '100%s availability' is used by copy (copy.json), profile (profile.json) (in environments dev)")
+}
+
+func createDuplicateNamesTestFs(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"environments.yaml":                  "dev:\n  - name: \"Dev\"\n  - env-url: \"https://url/to/dev/environment\"\n  - env-token-name: \"DUPLICATE_NAMES_TEST_TOKEN\"\n",
+		"proj/alerting-profile/profile.yaml": "config:\n  - profile: \"profile.json\"\n  - copy: \"copy.json\"\n\nprofile:\n  - name: \"profile\"\n\ncopy:\n  - name: \"profile\"\n",
+		"proj/alerting-profile/profile.json": `{"displayName": "{{.name}}"}`,
+		"proj/alerting-profile/copy.json":    `{"displayName": "{{.name}}"}`,
+	}
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+	return fs
+}
+
+func TestDeployFailsOnDuplicateNamesBeforeDeploying(t *testing.T) {
+	opts := NewOptions()
+	opts.DryRun = true
+
+	var log bytes.Buffer
+	opts.LogWriter = &log
+
+	result, err := Deploy(context.Background(), createDuplicateNamesTestFs(t), ".", "environments.yaml", opts)
+	assert.ErrorContains(t, err, "Configs of the same project share their names")
+	assert.Equal(t, len(result.Configs), 0)
+	assert.Assert(t, strings.Contains(log.String(), "proj: alerting-profile 'profile' is used by copy ("), log.String())
+}
+
+func TestDeployWarnsOnDuplicateNamesIfAllowed(t *testing.T) {
+	opts := NewOptions()
+	opts.DryRun = true
+	opts.AllowDuplicateNames = true
+
+	var log bytes.Buffer
+	opts.LogWriter = &log
+
+	result, err := Deploy(context.Background(), createDuplicateNamesTestFs(t), ".", "environments.yaml", opts)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Configs), 2)
+	assert.Assert(t, strings.Contains(log.String(), "WARN"), log.String())
+	assert.Assert(t, strings.Contains(log.String(), "configs of the same project and api share their names"), log.String())
+	assert.Assert(t, strings.Contains(log.String(), "as both are named 'alerting-profile/profile'"), log.String())
+}
@@ -0,0 +1,160 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+)
+
+// checkEnvVars verifies that all environment variables referenced by the templates of the configs to deploy are set.
+// All missing variables are reported at once, together with the files referencing them.
+func checkEnvVars(projects []project.Project, environments map[string]environment.Environment) error {
+	missing := make(map[string][]string)
+
+	for _, project := range projects {
+		for _, config := range project.GetConfigs() {
+			if !isDeployedToAny(config, environments) {
+				continue
+			}
+
+			for _, name := range config.GetReferencedEnvVars() {
+				if _, found := os.LookupEnv(name); !found {
+					missing[name] = append(missing[name], config.GetFilePath())
+				}
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var message strings.Builder
+	message.WriteString("missing environment variables referenced in templates:")
+	for _, name := range names {
+		message.WriteString(fmt.Sprintf("\n\t%s (referenced in %s)", name, strings.Join(missing[name], ", ")))
+	}
+	return errors.New(message.String())
+}
+
+// checkEnvironmentTags verifies that the environment tags used by configs to select the environments they are deployed
+// to are defined by any environment, so misspelled tags don't silently skip configs everywhere
+func checkEnvironmentTags(projects []project.Project, tags map[string]bool) error {
+	unknown := make(map[string][]string)
+
+	for _, project := range projects {
+		for _, config := range project.GetConfigs() {
+			for _, tag := range config.GetReferencedEnvironmentTags() {
+				if !tags[tag] && !containsString(unknown[tag], config.GetFilePath()) {
+					unknown[tag] = append(unknown[tag], config.GetFilePath())
+				}
+			}
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var message strings.Builder
+	message.WriteString("environment tags used in configs are not defined by any environment:")
+	for _, name := range names {
+		message.WriteString(fmt.Sprintf("\n\t%s (used in %s)", name, strings.Join(unknown[name], ", ")))
+	}
+	return errors.New(message.String())
+}
+
+// checkTokenNames verifies that the named tokens selected by configs are defined by the environments the configs are
+// deployed to. All undefined tokens are reported at once, together with the files using them.
+func checkTokenNames(projects []project.Project, environments map[string]environment.Environment) error {
+	undefined := make(map[string][]string)
+
+	for _, project := range projects {
+		for _, config := range project.GetConfigs() {
+			for _, environment := range environments {
+				if config.IsSkipDeployment(environment) {
+					continue
+				}
+
+				name := config.GetTokenName(environment)
+				if name == "" || environment.HasToken(name) {
+					continue
+				}
+
+				key := fmt.Sprintf("%s: %s", environment.GetId(), name)
+				if !containsString(undefined[key], config.GetFilePath()) {
+					undefined[key] = append(undefined[key], config.GetFilePath())
+				}
+			}
+		}
+	}
+
+	if len(undefined) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(undefined))
+	for key := range undefined {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var message strings.Builder
+	message.WriteString("token names used in configs are not defined by their environments:")
+	for _, key := range keys {
+		message.WriteString(fmt.Sprintf("\n\t%s (used in %s)", key, strings.Join(undefined[key], ", ")))
+	}
+	return errors.New(message.String())
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isDeployedToAny(config config.Config, environments map[string]environment.Environment) bool {
+	for _, environment := range environments {
+		if !config.IsSkipDeployment(environment) {
+			return true
+		}
+	}
+	return false
+}
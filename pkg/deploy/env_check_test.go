@@ -0,0 +1,177 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func createTestConfigWithTemplate(t *testing.T, id string, template string, properties map[string]string) config.Config {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, id+".json", []byte(template), 0644))
+
+	c, err := config.NewConfig(fs, id, "proj", id+".json", map[string]map[string]string{id: properties}, testProfileApi)
+	assert.NilError(t, err)
+	return c
+}
+
+func TestCheckEnvVarsReportsAllMissingVariables(t *testing.T) {
+	environments := map[string]environment.Environment{
+		"dev": environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"),
+	}
+
+	util.UnsetEnv(t, "MONACO_TEST_MISSING_A")
+	util.UnsetEnv(t, "MONACO_TEST_MISSING_B")
+	util.SetEnv(t, "MONACO_TEST_SET", "value")
+	defer util.UnsetEnv(t, "MONACO_TEST_SET")
+
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithTemplate(t, "first", `{"name": "{{ .name }}", "a": "{{ .Env.MONACO_TEST_MISSING_A }}", "b": "{{ env "MONACO_TEST_MISSING_B" }}"}`, map[string]string{"name": "first"}),
+			createTestConfigWithTemplate(t, "second", `{"name": "{{ .name }}", "a": "{{ .Env.MONACO_TEST_MISSING_A }}", "set": "{{ .Env.MONACO_TEST_SET }}"}`, map[string]string{"name": "second"}),
+			createTestConfigWithTemplate(t, "optional", `{"name": "{{ .name }}", "c": "{{ env "MONACO_TEST_MISSING_C" "default" }}"}`, map[string]string{"name": "optional"}),
+		},
+	}}
+
+	err := checkEnvVars(projects, environments)
+
+	assert.Error(t, err, "missing environment variables referenced in templates:"+
+		"\n\tMONACO_TEST_MISSING_A (referenced in first.json, second.json)"+
+		"\n\tMONACO_TEST_MISSING_B (referenced in first.json)")
+}
+
+func TestCheckEnvVarsKeepsPercentSignsOfFileNames(t *testing.T) {
+	environments := map[string]environment.Environment{
+		"dev": environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"),
+	}
+
+	util.UnsetEnv(t, "MONACO_TEST_MISSING_A")
+
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithTemplate(t, "100%s-availability", `{"a": "{{ .Env.MONACO_TEST_MISSING_A }}"}`, map[string]string{"name": "availability"}),
+		},
+	}}
+
+	err := checkEnvVars(projects, environments)
+
+	assert.Error(t, err, "missing environment variables referenced in templates:"+
+		"\n\tMONACO_TEST_MISSING_A (referenced in 100%s-availability.json)")
+}
+
+func TestCheckEnvVarsIgnoresSkippedConfigs(t *testing.T) {
+	environments := map[string]environment.Environment{
+		"dev": environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"),
+	}
+
+	util.UnsetEnv(t, "MONACO_TEST_MISSING_A")
+
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithTemplate(t, "skipped", `{"name": "{{ .name }}", "a": "{{ .Env.MONACO_TEST_MISSING_A }}"}`, map[string]string{"name": "skipped", "skipDeployment": "true"}),
+		},
+	}}
+
+	assert.NilError(t, checkEnvVars(projects, environments))
+}
+
+func TestCheckEnvironmentTagsReportsUnknownTags(t *testing.T) {
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithTemplate(t, "first", `{}`, map[string]string{"name": "first", "onlyEnvironments": "production, prodution"}),
+			createTestConfigWithTemplate(t, "second", `{}`, map[string]string{"name": "second", "skipEnvironments": "prodution, eu"}),
+		},
+	}}
+
+	err := checkEnvironmentTags(projects, map[string]bool{"production": true})
+
+	assert.Error(t, err, "environment tags used in configs are not defined by any environment:"+
+		"\n\teu (used in second.json)"+
+		"\n\tprodution (used in first.json, second.json)")
+
+	assert.NilError(t, checkEnvironmentTags(projects, map[string]bool{"production": true, "prodution": true, "eu": true}))
+}
+
+func TestCheckEnvironmentTagsKeepsPercentSignsOfFileNames(t *testing.T) {
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithTemplate(t, "100%s-availability", `{}`, map[string]string{"name": "first", "onlyEnvironments": "prodution"}),
+		},
+	}}
+
+	err := checkEnvironmentTags(projects, map[string]bool{"production": true})
+
+	assert.Error(t, err, "environment tags used in configs are not defined by any environment:"+
+		"\n\tprodution (used in 100%s-availability.json)")
+}
+
+func TestCheckTokenNamesReportsTokensUndefinedByEnvironments(t *testing.T) {
+	environments, errs := environment.NewEnvironments(map[string]map[string]string{
+		"dev":  {"name": "Dev", "env-url": "https://url/to/dev/environment", "env-token-name": "DEV", "env-token-name.settings": "DEV_SETTINGS"},
+		"prod": {"name": "Prod", "env-url": "https://url/to/prod/environment", "env-token-name": "PROD", "tags": "production"},
+	})
+	assert.Equal(t, len(errs), 0)
+
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithTemplate(t, "first", `{}`, map[string]string{"name": "first", "tokenName": "settings"}),
+			createTestConfigWithTemplate(t, "second", `{}`, map[string]string{"name": "second", "tokenName": "admin", "skipEnvironments": "production"}),
+			createTestConfigWithTemplate(t, "third", `{}`, map[string]string{"name": "third"}),
+		},
+	}}
+
+	err := checkTokenNames(projects, environments)
+
+	assert.Error(t, err, "token names used in configs are not defined by their environments:"+
+		"\n\tdev: admin (used in second.json)"+
+		"\n\tprod: settings (used in first.json)")
+}
+
+func TestCheckTokenNamesKeepsPercentSignsOfFileNames(t *testing.T) {
+	environments, errs := environment.NewEnvironments(map[string]map[string]string{
+		"dev": {"name": "Dev", "env-url": "https://url/to/dev/environment", "env-token-name": "DEV"},
+	})
+	assert.Equal(t, len(errs), 0)
+
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithTemplate(t, "100%s-availability", `{}`, map[string]string{"name": "first", "tokenName": "admin"}),
+		},
+	}}
+
+	err := checkTokenNames(projects, environments)
+
+	assert.Error(t, err, "token names used in configs are not defined by their environments:"+
+		"\n\tdev: admin (used in 100%s-availability.json)")
+}
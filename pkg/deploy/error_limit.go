@@ -0,0 +1,61 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import "sync"
+
+// errorLimit counts the configs failed during a run with continue on error, which is aborted once the maximum number
+// of errors is reached. It is shared by all environments and the workers deploying in parallel.
+type errorLimit struct {
+	mutex  sync.Mutex
+	max    int
+	failed int
+}
+
+// newErrorLimit returns the limit for the maximum number of errors. A negative maximum means unlimited, so nil is
+// returned, which never reaches its limit.
+func newErrorLimit(max int) *errorLimit {
+	if max < 0 {
+		return nil
+	}
+	return &errorLimit{max: max}
+}
+
+// add counts a failed config and returns true if the limit is reached
+func (l *errorLimit) add() bool {
+	if l == nil {
+		return false
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.failed++
+	return l.failed >= l.max
+}
+
+// reached returns true if the run has to be aborted, as the maximum number of configs failed
+func (l *errorLimit) reached() bool {
+	if l == nil {
+		return false
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.failed > 0 && l.failed >= l.max
+}
@@ -0,0 +1,157 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestErrorLimit(t *testing.T) {
+	unlimited := newErrorLimit(-1)
+	for i := 0; i < 10; i++ {
+		assert.Assert(t, !unlimited.add())
+	}
+	assert.Assert(t, !unlimited.reached())
+
+	first := newErrorLimit(0)
+	assert.Assert(t, !first.reached())
+	assert.Assert(t, first.add())
+	assert.Assert(t, first.reached())
+
+	limit := newErrorLimit(2)
+	assert.Assert(t, !limit.add())
+	assert.Assert(t, !limit.reached())
+	assert.Assert(t, limit.add())
+	assert.Assert(t, limit.reached())
+}
+
+func TestErrorLimitCountsConcurrentErrors(t *testing.T) {
+	limit := newErrorLimit(50)
+
+	var reached int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limit.add() {
+				atomic.AddInt32(&reached, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, limit.failed, 100)
+	assert.Equal(t, reached, int32(51))
+}
+
+// failingClient fails to upsert or look up any config, and counts the upserts. It is safe for concurrent use.
+type failingClient struct {
+	rest.DynatraceClient
+	upserts int32
+}
+
+func (c *failingClient) UpsertByName(api.Api, string, []byte) (api.DynatraceEntity, error) {
+	atomic.AddInt32(&c.upserts, 1)
+	return api.DynatraceEntity{}, errors.New("upload failed")
+}
+
+func (c *failingClient) ExistsByName(api.Api, string) (bool, string, error) {
+	return false, "", errors.New("lookup failed")
+}
+
+// createIndependentTestProject creates a project with the given number of profiles, which don't reference each other
+func createIndependentTestProject(t *testing.T, count int) project.Project {
+	configs := make([]config.Config, 0, count)
+	for _, id := range []string{"a", "b", "c", "d", "e"}[:count] {
+		configs = append(configs, createTestConfigWithProperties(t, id, testProfileApi, map[string]string{"name": id, "reference": "none"}))
+	}
+	return &testProject{id: "proj", configs: configs}
+}
+
+func TestExecuteSerialStopsOnceMaxErrorsReached(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := &failingClient{}
+	state := newDeploymentState()
+	state.errors = newErrorLimit(2)
+
+	errs := executeSerial(context.Background(), client, environment, []project.Project{createIndependentTestProject(t, 5)}, false, "", true, newDeploymentSummary(), newDeploymentReport(), state)
+
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, client.upserts, int32(2))
+}
+
+func TestExecuteParallelStopsOnceMaxErrorsReached(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := &failingClient{}
+	state := newDeploymentState()
+	state.errors = newErrorLimit(2)
+
+	// a single worker dispatches the configs in order, so no further config is in progress once the limit is reached
+	errs := executeParallel(context.Background(), client, environment, []project.Project{createIndependentTestProject(t, 5)}, false, "", true, newDeploymentSummary(), newDeploymentReport(), state, 1)
+
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, client.upserts, int32(2))
+}
+
+func TestExecuteSerialIgnoresMaxErrorsInDryRun(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := &failingClient{}
+	state := newDeploymentState()
+	state.errors = newErrorLimit(0)
+
+	errs := executeSerial(context.Background(), client, environment, []project.Project{createIndependentTestProject(t, 3)}, true, "", false, newDeploymentSummary(), newDeploymentReport(), state)
+
+	assert.Equal(t, len(errs), 3)
+}
+
+func TestDeployRejectsMaxErrorsWithoutContinueOnError(t *testing.T) {
+	opts := NewOptions()
+	opts.MaxErrors = 1
+
+	_, err := Deploy(context.Background(), afero.NewMemMapFs(), ".", "environments.yaml", opts)
+	assert.ErrorContains(t, err, "maximum number of errors can only be set if the deployment continues on error")
+}
+
+func TestDeployAcceptsNoErrorsWithoutContinueOnError(t *testing.T) {
+	opts := NewOptions()
+	opts.MaxErrors = 0
+
+	// the deployment fails later, as the environments file doesn't exist
+	_, err := Deploy(context.Background(), afero.NewMemMapFs(), ".", "environments.yaml", opts)
+	assert.Assert(t, err != nil)
+	assert.Assert(t, !strings.Contains(err.Error(), "maximum number of errors"), err.Error())
+}
@@ -0,0 +1,157 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// idCache contains the ids of the entities created or updated by previous deployments, by environment, api and
+// name of the config. It is safe for concurrent use.
+type idCache struct {
+	mutex sync.Mutex
+	ids   map[string]map[string]map[string]string
+}
+
+// idCacheFile is the content of the id cache file
+type idCacheFile struct {
+	Environments map[string]map[string]map[string]string `json:"environments"`
+}
+
+func newIdCache() *idCache {
+	return &idCache{ids: make(map[string]map[string]map[string]string)}
+}
+
+// loadIdCache reads the id cache from the given file. If the file doesn't exist yet, an empty cache is returned.
+func loadIdCache(fs afero.Fs, file string) (*idCache, error) {
+	exists, err := afero.Exists(fs, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read id cache %s: %w", file, err)
+	}
+	if !exists {
+		return newIdCache(), nil
+	}
+
+	content, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read id cache %s: %w", file, err)
+	}
+
+	var cacheFile idCacheFile
+	err = json.Unmarshal(content, &cacheFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse id cache %s: %w", file, err)
+	}
+
+	cache := newIdCache()
+	if cacheFile.Environments != nil {
+		cache.ids = cacheFile.Environments
+	}
+	return cache, nil
+}
+
+func (c *idCache) get(environment string, apiId string, name string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id, found := c.ids[environment][apiId][name]
+	return id, found
+}
+
+func (c *idCache) put(environment string, apiId string, name string, id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.ids[environment] == nil {
+		c.ids[environment] = make(map[string]map[string]string)
+	}
+	if c.ids[environment][apiId] == nil {
+		c.ids[environment][apiId] = make(map[string]string)
+	}
+	c.ids[environment][apiId][name] = id
+}
+
+// write writes the cache as json to the given file
+func (c *idCache) write(fs afero.Fs, file string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	content, err := json.MarshalIndent(idCacheFile{Environments: c.ids}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = afero.WriteFile(fs, file, content, 0644)
+	if err != nil {
+		return fmt.Errorf("could not write id cache %s: %w", file, err)
+	}
+	return nil
+}
+
+// idCachingClient is a DynatraceClient which updates configs by the ids of the id cache, if available, and records
+// the ids of all upserted configs in the cache
+type idCachingClient struct {
+	rest.DynatraceClient
+
+	cache       *idCache
+	environment string
+	log         *util.Logger
+}
+
+// WithToken returns a caching client sending the requests of the wrapped client using the given token
+func (c *idCachingClient) WithToken(token string) rest.DynatraceClient {
+	return &idCachingClient{DynatraceClient: rest.WithToken(c.DynatraceClient, token), cache: c.cache, environment: c.environment, log: c.log}
+}
+
+// WithTraceContext returns a caching client tracing the requests of the wrapped client
+func (c *idCachingClient) WithTraceContext(ctx context.Context) rest.DynatraceClient {
+	return &idCachingClient{DynatraceClient: rest.WithTraceContext(c.DynatraceClient, ctx), cache: c.cache, environment: c.environment, log: c.log}
+}
+
+func (c *idCachingClient) UpsertByName(a api.Api, name string, payload []byte) (api.DynatraceEntity, error) {
+	// single configuration APIs are not identified by an id
+	if a.IsSingleConfigurationApi() {
+		return c.DynatraceClient.UpsertByName(a, name, payload)
+	}
+
+	var entity api.DynatraceEntity
+	var err error
+
+	if id, found := c.cache.get(c.environment, a.GetId(), name); found {
+		c.log.Debug("\t\t\tUsing cached id %s of %s", id, name)
+		entity, err = c.DynatraceClient.UpsertById(a, id, name, payload)
+	} else {
+		entity, err = c.DynatraceClient.UpsertByName(a, name, payload)
+	}
+
+	if err != nil {
+		return entity, err
+	}
+
+	if entity.Id != "" {
+		c.cache.put(c.environment, a.GetId(), name, entity.Id)
+	}
+	return entity, nil
+}
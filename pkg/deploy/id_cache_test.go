@@ -0,0 +1,105 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestLoadIdCacheReturnsEmptyCacheIfFileDoesNotExist(t *testing.T) {
+	cache, err := loadIdCache(afero.NewMemMapFs(), "ids.json")
+	assert.NilError(t, err)
+
+	_, found := cache.get("dev", "alerting-profile", "profile")
+	assert.Equal(t, found, false)
+}
+
+func TestIdCacheIsWrittenAndLoaded(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	cache := newIdCache()
+	cache.put("dev", "alerting-profile", "profile", "profile-id")
+	cache.put("prod", "alerting-profile", "profile", "other-id")
+	assert.NilError(t, cache.write(fs, "ids.json"))
+
+	loaded, err := loadIdCache(fs, "ids.json")
+	assert.NilError(t, err)
+
+	id, found := loaded.get("dev", "alerting-profile", "profile")
+	assert.Equal(t, found, true)
+	assert.Equal(t, id, "profile-id")
+
+	id, found = loaded.get("prod", "alerting-profile", "profile")
+	assert.Equal(t, found, true)
+	assert.Equal(t, id, "other-id")
+}
+
+func TestLoadIdCacheFailsOnInvalidFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "ids.json", []byte("not json"), 0644))
+
+	_, err := loadIdCache(fs, "ids.json")
+	assert.ErrorContains(t, err, "could not parse id cache ids.json")
+}
+
+func TestIdCachingClientUpdatesCachedIds(t *testing.T) {
+	cache := newIdCache()
+	cache.put("dev", "alerting-profile", "profile", "profile-id")
+
+	mock := rest.CreateDynatraceClientMockFactory(t)
+	mock.EXPECT().UpsertById(testProfileApi, "profile-id", "profile", gomock.Any()).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	mock.EXPECT().UpsertByName(testProfileApi, "other", gomock.Any()).Return(api.DynatraceEntity{Id: "other-id", Name: "other", Created: true}, nil)
+
+	client := &idCachingClient{DynatraceClient: mock, cache: cache, environment: "dev"}
+
+	entity, err := client.UpsertByName(testProfileApi, "profile", []byte("{}"))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "profile-id")
+
+	entity, err = client.UpsertByName(testProfileApi, "other", []byte("{}"))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "other-id")
+
+	id, found := cache.get("dev", "alerting-profile", "other")
+	assert.Equal(t, found, true)
+	assert.Equal(t, id, "other-id")
+}
+
+func TestIdCachingClientDoesNotCacheSingleConfigurationApis(t *testing.T) {
+	singleConfigurationApi := api.NewSingleConfigurationApi("frequent-issue-detection", "/api/config/v1/frequentIssueDetection")
+
+	mock := rest.CreateDynatraceClientMockFactory(t)
+	mock.EXPECT().UpsertByName(singleConfigurationApi, "detection", gomock.Any()).Return(api.DynatraceEntity{Id: "frequent-issue-detection", Name: "detection"}, nil)
+
+	cache := newIdCache()
+	client := &idCachingClient{DynatraceClient: mock, cache: cache, environment: "dev"}
+
+	_, err := client.UpsertByName(singleConfigurationApi, "detection", []byte("{}"))
+	assert.NilError(t, err)
+
+	_, found := cache.get("dev", "frequent-issue-detection", "detection")
+	assert.Equal(t, found, false)
+}
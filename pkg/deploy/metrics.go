@@ -0,0 +1,55 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/metrics"
+	"github.com/spf13/afero"
+)
+
+// addConfigMetrics adds the metrics of the processed configs and the duration of the run, which are derived from the
+// report at the end of the run and therefore cost nothing while deploying
+func (r *deploymentReport) addConfigMetrics(registry *metrics.Registry, success bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, result := range r.results {
+		registry.Add("monaco_configs_total", "Configs processed by the run, by environment and action.",
+			metrics.Labels{"environment": result.Environment, "action": string(result.Action)}, 1)
+
+		if result.Action == resultFailed {
+			registry.Add("monaco_config_failures_total", "Configs which failed to deploy, by environment and API.",
+				metrics.Labels{"environment": result.Environment, "api": result.Type}, 1)
+		}
+	}
+
+	registry.Set("monaco_run_duration_seconds", "Duration of the run.", nil, time.Since(r.startedAt).Seconds())
+
+	successValue := 0.0
+	if success {
+		successValue = 1
+	}
+	registry.Set("monaco_run_success", "Whether the run finished without errors.", nil, successValue)
+}
+
+// writeMetrics writes the metrics collected during the run together with the metrics of the configs to the file
+func writeMetrics(fs afero.Fs, file string, registry *metrics.Registry, report *deploymentReport, success bool) error {
+	report.addConfigMetrics(registry, success)
+	return registry.WriteFile(fs, file)
+}
@@ -0,0 +1,55 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/metrics"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestWriteMetricsCountsConfigsByActionAndFailuresByApi(t *testing.T) {
+	report := newDeploymentReport()
+	report.add(configResult{Project: "proj", Type: "dashboard", Config: "a", Environment: "dev", Action: resultCreated})
+	report.add(configResult{Project: "proj", Type: "dashboard", Config: "b", Environment: "dev", Action: resultCreated})
+	report.add(configResult{Project: "proj", Type: "alerting-profile", Config: "c", Environment: "dev", Action: resultFailed})
+	report.add(configResult{Project: "proj", Type: "dashboard", Config: "a", Environment: "prod", Action: resultUpdated})
+
+	fs := afero.NewMemMapFs()
+	err := writeMetrics(fs, "metrics.prom", metrics.NewRegistry(), report, false)
+	assert.NilError(t, err)
+
+	content, err := afero.ReadFile(fs, "metrics.prom")
+	assert.NilError(t, err)
+
+	for _, line := range []string{
+		`monaco_configs_total{action="created",environment="dev"} 2`,
+		`monaco_configs_total{action="failed",environment="dev"} 1`,
+		`monaco_configs_total{action="updated",environment="prod"} 1`,
+		`monaco_config_failures_total{api="alerting-profile",environment="dev"} 1`,
+		`monaco_run_success 0`,
+		`# TYPE monaco_run_duration_seconds gauge`,
+	} {
+		assert.Check(t, strings.Contains(string(content), line+"\n"), "missing %q in\n%s", line, content)
+	}
+}
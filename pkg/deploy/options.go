@@ -0,0 +1,247 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/diff"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// deletionErrorsKey is the key of the errors of deleting the configs of the delete.yaml in the errors of a result
+const deletionErrorsKey = "delete"
+
+const (
+	// DefaultRunStateFile is the run state file of a deployment which is resumed or discarded without naming its file
+	DefaultRunStateFile = ".monaco-run-state.json"
+
+	// DefaultResumeMaxAge is the age after which the deploy command doesn't resume a run state anymore
+	DefaultResumeMaxAge = 24 * time.Hour
+)
+
+// Options configure a deployment started by Deploy. Each option corresponds to the flag of the deploy command with
+// the same name. Use NewOptions to start with the defaults of the command, as the zero value of some options differs.
+type Options struct {
+	// SpecificEnvironment is a comma separated list of the environments and environment groups to deploy to. All
+	// environments of the environments file are deployed to if it is empty.
+	SpecificEnvironment string
+
+	// Project is a comma separated list of the projects to deploy, together with the projects they depend on. All
+	// projects of the working directory are deployed if it is empty.
+	Project string
+
+	// ProjectsFile is a file listing further projects to deploy, one per line
+	ProjectsFile string
+
+	// StrictProjects only deploys the selected projects, and fails if they depend on other projects
+	StrictProjects bool
+
+	// DryRun validates the configs without deploying them
+	DryRun bool
+
+	// Diff shows the fields a dry run would change in the configs it would update, by comparing their rendered
+	// payloads with the live objects. It requires DryRun.
+	Diff bool
+
+	// ContinueOnError deploys all configs not depending on a failed config, instead of stopping at the first error
+	ContinueOnError bool
+
+	// MaxErrors aborts a deployment continuing on error once the given number of configs failed. 0 aborts on the
+	// first error, also without ContinueOnError, a negative number never aborts. It defaults to -1.
+	MaxErrors int
+
+	// Parallel is the number of configs deployed concurrently, which needs to be at least 1. It defaults to 1.
+	Parallel int
+
+	// ReportFile is the file the json report of all processed configs is written to, if it is not empty
+	ReportFile string
+
+	// MetricsFile is the file metrics of the run are written to in the Prometheus text format, if it is not empty
+	MetricsFile string
+
+	// SkipEnvCheck skips the check for missing environment variables referenced in configs
+	SkipEnvCheck bool
+
+	// SkipPreflight skips the check whether the environments are reachable and accept their tokens
+	SkipPreflight bool
+
+	// IdCacheFile is the file caching the ids of deployed configs, if it is not empty
+	IdCacheFile string
+
+	// ResetIdCache ignores the content of the id cache file and rewrites it
+	ResetIdCache bool
+
+	// ValidateSchemas validates the payloads of the configs against the bundled json schemas of the APIs
+	ValidateSchemas bool
+
+	// SchemaDir is a directory of json schemas of the APIs, which replace the bundled schemas
+	SchemaDir string
+
+	// BundleFile is a bundle to deploy instead of the projects of the working directory
+	BundleFile string
+
+	// StateFile is the file storing the checksums of the deployed configs, if it is not empty. Configs unchanged since
+	// the previous deployment are skipped.
+	StateFile string
+
+	// IgnoreState deploys all configs, including the ones unchanged according to the state file
+	IgnoreState bool
+
+	// Refresh checks that the objects of unchanged configs still exist, and deploys them again if they don't
+	Refresh bool
+
+	// AllowDuplicateNames reports configs of the same api sharing their name as warnings instead of failing. The
+	// config deployed last updates the object of the configs deployed before.
+	AllowDuplicateNames bool
+
+	// RunStateFile is the file recording the configs deployed by a failed or interrupted deployment, if it is not
+	// empty. It is removed once a deployment succeeds. If it is empty, only deployments setting Resume or NoResume
+	// record their run state, in DefaultRunStateFile.
+	RunStateFile string
+
+	// Resume skips the configs recorded in the run state file, if they are unchanged since they were deployed
+	Resume bool
+
+	// NoResume removes the run state file before deploying, so no later deployment resumes the recorded run
+	NoResume bool
+
+	// ResumeMaxAge is the age after which a run state is not resumed anymore. 0 never expires the run state.
+	ResumeMaxAge time.Duration
+
+	// Confirm is asked before deploying to environments flagged as production, if it is not nil. The deploy command
+	// asks on the console, unless --yes is set or the input is no terminal.
+	Confirm Confirmation
+
+	// Logger logs the deployment, including its requests and responses, if it is not nil. Deployments running
+	// concurrently in one process can be logged separately by giving each its own logger created by util.NewLogger.
+	Logger *util.Logger
+
+	// LogWriter receives the log of the deployment instead of the shared logger of monaco, if it is not nil and no
+	// Logger is set. The log contains messages of level info and above, or debug if Verbose is set.
+	LogWriter io.Writer
+
+	// Verbose adds debug messages to the log written to LogWriter
+	Verbose bool
+}
+
+// NewOptions returns the options of a deployment with the defaults of the deploy command
+func NewOptions() Options {
+	return Options{
+		MaxErrors:    -1,
+		Parallel:     1,
+		ResumeMaxAge: DefaultResumeMaxAge,
+	}
+}
+
+// Result is the outcome of a deployment
+type Result struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	DryRun     bool
+
+	// Configs contains the result of every config processed in every environment, in the order of their processing
+	Configs []ConfigResult
+
+	// Errors contains the errors of the deployment by environment. Errors of the environments file are stored as
+	// environmentfile-issue-<n>, errors of deleting the configs of the delete.yaml as delete.
+	Errors map[string][]error
+}
+
+// ConfigResult is the result of processing a config for an environment
+type ConfigResult struct {
+	Project     string
+	Type        string
+	Config      string
+	Environment string
+
+	// Action is one of created, updated, skipped, failed, or validated during a dry run
+	Action string
+
+	// EntityId is the id of the Dynatrace entity of the config, if it was created or updated
+	EntityId string
+
+	Duration time.Duration
+
+	// Error is the message of the error of a failed config
+	Error string
+
+	// Changes are the fields a dry run with Diff would change in the config, sorted by path
+	Changes []diff.Change
+}
+
+// Success returns whether the deployment finished without errors
+func (r Result) Success() bool {
+	return len(r.Errors) == 0
+}
+
+// Deploy deploys the projects of the working directory to the environments of the environments file. The files are
+// read from and written to the given filesystem. Once the context is cancelled, no further configs are deployed:
+// configs in progress are finished, and the returned result reflects the configs processed so far.
+//
+// The result is returned even if the deployment fails, together with the error. The deploy command of the CLI is a
+// thin wrapper of Deploy.
+func Deploy(ctx context.Context, fs afero.Fs, workingDir string, environmentsFile string, opts Options) (Result, error) {
+	log := opts.Logger
+	if log == nil && opts.LogWriter != nil {
+		var err error
+		log, err = util.NewLogger(fs, util.LoggerOptions{Writer: opts.LogWriter, Verbose: opts.Verbose})
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	if log == nil {
+		log = util.DefaultLogger()
+	}
+
+	var result Result
+	err := deploy(ctx, workingDir, fs, environmentsFile, opts, log, &result)
+	return result, err
+}
+
+// fill sets the result from the report and errors of a deployment
+func (r *Result) fill(report *deploymentReport, errors map[string][]error, dryRun bool) {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	r.StartedAt = report.startedAt
+	r.FinishedAt = time.Now()
+	r.DryRun = dryRun
+
+	r.Configs = make([]ConfigResult, 0, len(report.results))
+	for _, c := range report.results {
+		r.Configs = append(r.Configs, ConfigResult{
+			Project:     c.Project,
+			Type:        c.Type,
+			Config:      c.Config,
+			Environment: c.Environment,
+			Action:      string(c.Action),
+			EntityId:    c.EntityId,
+			Duration:    time.Duration(c.DurationMs) * time.Millisecond,
+			Error:       c.Error,
+			Changes:     c.Changes,
+		})
+	}
+
+	r.Errors = make(map[string][]error, len(errors))
+	for key, errs := range errors {
+		r.Errors[key] = errs
+	}
+}
@@ -0,0 +1,123 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func createOptionsTestFs(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"environments.yaml":                   "dev:\n  - name: \"Dev\"\n  - env-url: \"https://url/to/dev/environment\"\n  - env-token-name: \"OPTIONS_TEST_TOKEN\"\n",
+		"proj/alerting-profile/profile.yaml":  "config:\n  - profile: \"profile.json\"\n\nprofile:\n  - name: \"profile\"\n",
+		"proj/alerting-profile/profile.json":  `{"displayName": "{{.name}}"}`,
+		"proj/alerting-profile/invalid.yaml":  "config:\n  - invalid: \"invalid.json\"\n\ninvalid:\n  - name: \"invalid\"\n",
+		"proj/alerting-profile/invalid.json":  `{"displayName": "{{.missing}}"}`,
+		"other/alerting-profile/profile.yaml": "config:\n  - profile: \"profile.json\"\n\nprofile:\n  - name: \"other\"\n",
+		"other/alerting-profile/profile.json": `{"displayName": "{{.name}}"}`,
+	}
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+	return fs
+}
+
+func TestDeployReturnsResultOfDryRun(t *testing.T) {
+	opts := NewOptions()
+	opts.Project = "other"
+	opts.DryRun = true
+
+	var log bytes.Buffer
+	opts.LogWriter = &log
+
+	result, err := Deploy(context.Background(), createOptionsTestFs(t), ".", "environments.yaml", opts)
+	assert.NilError(t, err)
+
+	assert.Assert(t, result.Success())
+	assert.Assert(t, result.DryRun)
+	assert.Equal(t, len(result.Configs), 1)
+	assert.Equal(t, result.Configs[0].Environment, "dev")
+	assert.Equal(t, result.Configs[0].Action, "validated")
+	assert.Assert(t, strings.Contains(log.String(), "Validation finished without errors"), log.String())
+}
+
+func TestDeployReturnsErrorsInResult(t *testing.T) {
+	opts := NewOptions()
+	opts.Project = "proj"
+	opts.DryRun = true
+	opts.LogWriter = &bytes.Buffer{}
+
+	result, err := Deploy(context.Background(), createOptionsTestFs(t), ".", "environments.yaml", opts)
+	assert.ErrorContains(t, err, "Errors during validation")
+
+	assert.Assert(t, !result.Success())
+	assert.Equal(t, len(result.Errors["dev"]), 1)
+	assert.Equal(t, len(result.Configs), 2)
+}
+
+func TestDeployRestoresLoggerAfterRedirect(t *testing.T) {
+	previous := util.Log
+
+	opts := NewOptions()
+	opts.Project = "other"
+	opts.DryRun = true
+	opts.LogWriter = &bytes.Buffer{}
+
+	_, err := Deploy(context.Background(), createOptionsTestFs(t), ".", "environments.yaml", opts)
+	assert.NilError(t, err)
+	assert.Equal(t, util.Log, previous)
+}
+
+func TestConcurrentDeploymentsLogToTheirOwnLogger(t *testing.T) {
+	projects := []string{"proj", "other"}
+	logs := make([]bytes.Buffer, len(projects))
+
+	var wg sync.WaitGroup
+	for i, project := range projects {
+		logger, err := util.NewLogger(afero.NewMemMapFs(), util.LoggerOptions{Writer: &logs[i]})
+		assert.NilError(t, err)
+
+		opts := NewOptions()
+		opts.Project = project
+		opts.DryRun = true
+		opts.ContinueOnError = true
+		opts.Logger = logger
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = Deploy(context.Background(), createOptionsTestFs(t), ".", "environments.yaml", opts)
+		}()
+	}
+	wg.Wait()
+
+	assert.Assert(t, strings.Contains(logs[0].String(), "Validation of dev failed"), logs[0].String())
+	assert.Assert(t, !strings.Contains(logs[1].String(), "Validation of dev failed"), logs[1].String())
+	assert.Assert(t, strings.Contains(logs[1].String(), "Validation finished without errors"), logs[1].String())
+}
@@ -0,0 +1,307 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/schema"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+// deploymentState contains the entities and names of all configs deployed to an environment so far. It is safe
+// for concurrent use.
+type deploymentState struct {
+	mutex sync.Mutex
+
+	// dict contains the deployed entities by reference id and is used to resolve references between configs
+	dict map[string]api.DynatraceEntity
+
+	// nameDict contains the full qualified config id by api and object name to detect duplicate names
+	nameDict map[string]string
+
+	// progress counts the processed configs. It is nil, if the progress is not reported.
+	progress *deploymentProgress
+
+	// schemas validates the rendered payloads. It is nil, if schema validation is disabled.
+	schemas *schema.Validator
+
+	// errors limits the number of failed configs with continue on error. It is nil, if the number is unlimited.
+	errors *errorLimit
+
+	// log logs the deployment to the environment
+	log *util.Logger
+
+	// responses contains the reference ids of the configs whose API response is referenced by other configs
+	responses map[string]struct{}
+
+	// checksums skips configs unchanged since previous deployments. It is nil, if all configs are deployed.
+	checksums *checksumState
+
+	// run records the deployed configs and skips the ones deployed by a resumed run. It is nil, if no run state is
+	// recorded.
+	run *runState
+
+	// allowDuplicateNames logs configs sharing the name of a config registered before as warning instead of failing
+	allowDuplicateNames bool
+
+	// showChanges compares the configs a dry run would update with their live objects, to show the changed fields
+	showChanges bool
+}
+
+func newDeploymentState() *deploymentState {
+	return &deploymentState{
+		dict:      make(map[string]api.DynatraceEntity),
+		nameDict:  make(map[string]string),
+		log:       util.DefaultLogger(),
+		responses: make(map[string]struct{}),
+	}
+}
+
+// addResponseReferences registers the configs whose API response is referenced by the configs of the projects, so
+// their response is read if the API doesn't return one on deployment
+func (s *deploymentState) addResponseReferences(projects []project.Project) {
+	for _, project := range projects {
+		for _, c := range project.GetConfigs() {
+			for _, reference := range config.ResponseReferences(c) {
+				s.responses[reference] = struct{}{}
+			}
+		}
+	}
+}
+
+// isResponseReferenced returns whether other configs reference the API response of the config with the reference id
+func (s *deploymentState) isResponseReferenced(referenceId string) bool {
+	_, found := s.responses[referenceId]
+	return found
+}
+
+// entities returns a copy of all deployed entities
+func (s *deploymentState) entities() map[string]api.DynatraceEntity {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entities := make(map[string]api.DynatraceEntity, len(s.dict))
+	for k, v := range s.dict {
+		entities[k] = v
+	}
+	return entities
+}
+
+func (s *deploymentState) addEntity(referenceId string, entity api.DynatraceEntity) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.dict[referenceId] = entity
+}
+
+// registerName returns an error if another config with the same name has already been registered, unless duplicate
+// names are allowed
+func (s *deploymentState) registerName(name string, configId string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.nameDict[name] != "" {
+		if !s.allowDuplicateNames {
+			return fmt.Errorf("duplicate UID '%s' found in %s and %s", name, configId, s.nameDict[name])
+		}
+		s.log.Warn("Config %s updates the object of config %s, as both are named '%s'", configId, s.nameDict[name], name)
+	}
+	s.nameDict[name] = configId
+	return nil
+}
+
+// configDeployment is a config to deploy, including the configs it depends on
+type configDeployment struct {
+	project project.Project
+	config  config.Config
+
+	// dependencies contains the indices of all deployments which need to be applied before this one
+	dependencies []int
+}
+
+type deploymentResult struct {
+	index int
+	err   error
+	fatal bool
+}
+
+// createConfigDeployments flattens the (sorted) configs of all projects and determines their dependencies.
+// As projects and configs are sorted according to their dependencies, a config can only depend on earlier configs.
+func createConfigDeployments(projects []project.Project) []configDeployment {
+	deployments := make([]configDeployment, 0)
+
+	for _, project := range projects {
+		for _, config := range project.GetConfigs() {
+			dependencies := make([]int, 0)
+			for i, earlier := range deployments {
+				if config.HasDependencyOn(earlier.config) {
+					dependencies = append(dependencies, i)
+				}
+			}
+
+			deployments = append(deployments, configDeployment{
+				project:      project,
+				config:       config,
+				dependencies: dependencies,
+			})
+		}
+	}
+
+	return deployments
+}
+
+// executeParallel deploys the configs of all projects to the environment using a pool of workers. A config is only
+// dispatched after all configs it depends on have been applied, and skipped if any of them failed. Errors are
+// collected and returned in the order of the configs. Once the context is cancelled, no further configs are dispatched
+// and the configs in progress are finished.
+func executeParallel(ctx context.Context, client rest.DynatraceClient, environment environment.Environment, projects []project.Project, dryRun bool,
+	path string, continueOnError bool, summary *deploymentSummary, report *deploymentReport, state *deploymentState, workers int) []error {
+
+	for _, project := range projects {
+		logProjectStart(state.log, environment, project)
+	}
+
+	deployments := createConfigDeployments(projects)
+	state.log.Debug("\tDeploying %d configs using %d workers", len(deployments), workers)
+
+	pendingDependencies := make([]int, len(deployments))
+	dependents := make([][]int, len(deployments))
+	ready := make([]int, 0)
+
+	// failedDependency contains the index of a failed config the deployment depends on, or -1
+	failedDependency := make([]int, len(deployments))
+
+	for i, deployment := range deployments {
+		failedDependency[i] = -1
+		pendingDependencies[i] = len(deployment.dependencies)
+		for _, dependency := range deployment.dependencies {
+			dependents[dependency] = append(dependents[dependency], i)
+		}
+		if pendingDependencies[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	jobs := make(chan int)
+	results := make(chan deploymentResult)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				deployment := deployments[i]
+				err, fatal := deployConfig(ctx, client, environment, deployment.project, deployment.config, dryRun, path, summary, report, state)
+				if err != nil {
+					err = newConfigDeploymentError(environment, deployment.project, deployment.config, err)
+				}
+				results <- deploymentResult{index: i, err: err, fatal: fatal}
+			}
+		}()
+	}
+
+	failed := make([]deploymentResult, 0)
+	stopped := false
+	inFlight := 0
+
+	for {
+		for !stopped && ctx.Err() == nil && len(ready) > 0 && inFlight < workers {
+			jobs <- ready[0]
+			ready = ready[1:]
+			inFlight++
+		}
+
+		if inFlight == 0 {
+			break
+		}
+
+		result := <-results
+		inFlight--
+
+		if result.err != nil {
+			failed = append(failed, result)
+
+			deployment := deployments[result.index]
+			configLogger(state.log, environment, deployment.project, deployment.config).Error("\t\t\tFailed %s", errors.Unwrap(result.err))
+
+			// by default stop deployment on error, configs already in progress are finished. A dry run validates
+			// all configs, to report all errors at once.
+			if !dryRun && (result.fatal || !continueOnError || state.errors.add()) {
+				stopped = true
+			}
+		}
+
+		if stopped {
+			continue
+		}
+
+		// release the dependents of completed configs. Dependents of failed configs are skipped, which in turn
+		// completes them and releases their dependents.
+		completed := []deploymentResult{result}
+		for len(completed) > 0 {
+			current := completed[0]
+			completed = completed[1:]
+
+			for _, dependent := range dependents[current.index] {
+				if current.err != nil && failedDependency[dependent] == -1 {
+					failedDependency[dependent] = current.index
+					if failedDependency[current.index] != -1 {
+						failedDependency[dependent] = failedDependency[current.index]
+					}
+				}
+
+				pendingDependencies[dependent]--
+				if pendingDependencies[dependent] > 0 {
+					continue
+				}
+
+				if failedDependency[dependent] == -1 {
+					ready = append(ready, dependent)
+					continue
+				}
+
+				deployment := deployments[dependent]
+				err := failedDependencyError(deployments[failedDependency[dependent]].config)
+				configLogger(state.log, environment, deployment.project, deployment.config).Warn("\t\t\t%s", err)
+				report.add(newConfigResult(environment, deployment.project, deployment.config, resultSkipped, "", 0, 0, err))
+				state.progress.complete(time.Now())
+
+				skipped := deploymentResult{index: dependent, err: newConfigDeploymentError(environment, deployment.project, deployment.config, err)}
+				failed = append(failed, skipped)
+				completed = append(completed, skipped)
+			}
+		}
+	}
+	close(jobs)
+
+	sort.Slice(failed, func(i, j int) bool { return failed[i].index < failed[j].index })
+
+	errs := make([]error, 0, len(failed))
+	for _, result := range failed {
+		errs = append(errs, result.err)
+	}
+	return errs
+}
@@ -0,0 +1,230 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+type testProject struct {
+	id      string
+	configs []config.Config
+}
+
+func (p *testProject) HasDependencyOn(project.Project) bool { return false }
+func (p *testProject) GetConfigs() []config.Config          { return p.configs }
+func (p *testProject) GetId() string                        { return p.id }
+func (p *testProject) GetConfig(id string) (config.Config, error) {
+	for _, c := range p.configs {
+		if c.GetFullQualifiedId() == id {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("config %s not found", id)
+}
+
+var (
+	testProfileApi = api.NewStandardApi("alerting-profile", "/api/config/v1/alertingProfiles")
+	testMetricApi  = api.NewStandardApi("calculated-metrics-log", "/api/config/v1/calculatedMetrics/log")
+)
+
+func createTestConfigWithProperties(t *testing.T, id string, theApi api.Api, properties map[string]string) config.Config {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, id+".json", []byte(`{"name": "{{.name}}", "reference": "{{.reference}}"}`), 0644))
+
+	c, err := config.NewConfig(fs, id, "proj", id+".json", map[string]map[string]string{id: properties}, theApi)
+	assert.NilError(t, err)
+	return c
+}
+
+// createTestProject creates a project with two profiles and a metric referencing the first profile
+func createTestProject(t *testing.T) project.Project {
+	return &testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": "none"}),
+			createTestConfigWithProperties(t, "other", testProfileApi, map[string]string{"name": "other", "reference": "none"}),
+			createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/profile.id"}),
+		},
+	}
+}
+
+func TestCreateConfigDeploymentsDeterminesDependencies(t *testing.T) {
+	deployments := createConfigDeployments([]project.Project{createTestProject(t)})
+
+	assert.Equal(t, len(deployments), 3)
+	assert.DeepEqual(t, deployments[0].dependencies, []int{})
+	assert.DeepEqual(t, deployments[1].dependencies, []int{})
+	assert.DeepEqual(t, deployments[2].dependencies, []int{0})
+}
+
+// recordingClient records the order of upserted configs. It is safe for concurrent use.
+type recordingClient struct {
+	rest.DynatraceClient
+
+	mutex    sync.Mutex
+	upserted []string
+	failing  string
+}
+
+func (c *recordingClient) UpsertByName(_ api.Api, name string, payload []byte) (api.DynatraceEntity, error) {
+	if name == c.failing {
+		return api.DynatraceEntity{}, errors.New("upload failed")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.upserted = append(c.upserted, name)
+	return api.DynatraceEntity{Id: name + "-id", Name: name}, nil
+}
+
+func (c *recordingClient) indexOf(name string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, n := range c.upserted {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestExecuteParallelDeploysDependenciesFirst(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	for i := 0; i < 20; i++ {
+		client := &recordingClient{}
+		projects := []project.Project{createTestProject(t)}
+
+		errs := executeParallel(context.Background(), client, environment, projects, false, "", false, newDeploymentSummary(), newDeploymentReport(), newDeploymentState(), 3)
+
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(client.upserted), 3)
+		assert.Assert(t, client.indexOf("profile") < client.indexOf("metric"), "metric deployed before the profile it references: %v", client.upserted)
+	}
+}
+
+func TestExecuteParallelResolvesReferencesOfDependencies(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	client.EXPECT().UpsertByName(testProfileApi, "other", gomock.Any()).Return(api.DynatraceEntity{Id: "other-id", Name: "other"}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	errs := executeParallel(context.Background(), client, environment, []project.Project{createTestProject(t)}, false, "", false, newDeploymentSummary(), newDeploymentReport(), newDeploymentState(), 2)
+	assert.Equal(t, len(errs), 0)
+}
+
+func TestExecuteParallelStopsDispatchingOnceCancelled(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the config in progress is finished, but no further configs are deployed
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).DoAndReturn(func(api.Api, string, []byte) (api.DynatraceEntity, error) {
+		cancel()
+		return api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil
+	})
+
+	report := newDeploymentReport()
+
+	errs := executeParallel(ctx, client, environment, []project.Project{createTestProject(t)}, false, "", false, newDeploymentSummary(), report, newDeploymentState(), 1)
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(report.results), 1)
+}
+
+func TestExecuteParallelCollectsErrorsOnContinueOnError(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := &recordingClient{failing: "other"}
+	projects := []project.Project{createTestProject(t)}
+
+	errs := executeParallel(context.Background(), client, environment, projects, false, "", true, newDeploymentSummary(), newDeploymentReport(), newDeploymentState(), 3)
+
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "upload failed, responsible config: other.json, environment: dev")
+	assert.Equal(t, len(client.upserted), 2)
+}
+
+func TestExecuteParallelStopsOnError(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := &recordingClient{failing: "profile"}
+	projects := []project.Project{createTestProject(t)}
+
+	// a single worker dispatches the configs in order, so the deployment stops before any other config is deployed
+	errs := executeParallel(context.Background(), client, environment, projects, false, "", false, newDeploymentSummary(), newDeploymentReport(), newDeploymentState(), 1)
+
+	assert.Equal(t, len(errs), 1)
+	assert.Equal(t, len(client.upserted), 0)
+}
+
+func TestExecuteParallelFailsOnDuplicateNames(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": "none"}),
+			createTestConfigWithProperties(t, "duplicate", testProfileApi, map[string]string{"name": "profile", "reference": "none"}),
+		},
+	}}
+
+	errs := executeParallel(context.Background(), nil, environment, projects, true, "", true, newDeploymentSummary(), newDeploymentReport(), newDeploymentState(), 2)
+
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "duplicate UID 'alerting-profile/profile' found in")
+}
+
+func TestExecuteParallelSkipsDependentsOfFailedConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	testProject := createTestProject(t).(*testProject)
+	dependent := createTestConfigWithProperties(t, "dependent", testMetricApi, map[string]string{"name": "dependent", "reference": "proj/calculated-metrics-log/metric.id"})
+	testProject.configs = append(testProject.configs, dependent)
+
+	client := &recordingClient{failing: "profile"}
+
+	errs := executeParallel(context.Background(), client, environment, []project.Project{testProject}, false, "", true, newDeploymentSummary(), newDeploymentReport(), newDeploymentState(), 3)
+
+	assert.Equal(t, len(errs), 3)
+	assert.ErrorContains(t, errs[0], "proj/alerting-profile/profile (project: proj, environment: dev): upload failed")
+	assert.ErrorContains(t, errs[1], "proj/calculated-metrics-log/metric (project: proj, environment: dev): skipped deployment, as it depends on failed config proj/alerting-profile/profile")
+	assert.ErrorContains(t, errs[2], "proj/calculated-metrics-log/dependent (project: proj, environment: dev): skipped deployment, as it depends on failed config proj/alerting-profile/profile")
+	assert.DeepEqual(t, client.upserted, []string{"other"})
+}
@@ -0,0 +1,102 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+)
+
+// accessCheck verifies that the api of the environment can be accessed
+type accessCheck func(environment environment.Environment, theApi api.Api) error
+
+func checkEnvironmentAccess(environment environment.Environment, theApi api.Api) error {
+	token, err := environment.GetToken()
+	if err != nil {
+		return err
+	}
+	return rest.CheckAccess(environment.GetEnvironmentUrl(), token, theApi)
+}
+
+// preflightCheck verifies that every environment is reachable and accepts its token, before anything is deployed.
+// Each environment is checked with a single request to an api of the configs deployed to it. All environments failing
+// the check are reported at once.
+func preflightCheck(projects []project.Project, environments map[string]environment.Environment, check accessCheck) error {
+	var (
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+		failures = make(map[string]error)
+	)
+
+	for _, env := range environments {
+		theApi := firstDeployedApi(projects, env)
+		if theApi == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(env environment.Environment, theApi api.Api) {
+			defer wg.Done()
+
+			if err := check(env, theApi); err != nil {
+				mutex.Lock()
+				defer mutex.Unlock()
+				failures[env.GetId()] = err
+			}
+		}(env, theApi)
+	}
+	wg.Wait()
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(failures))
+	for id := range failures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("pre-flight check failed for %d environment(s):", len(failures)))
+	for _, id := range ids {
+		message.WriteString(fmt.Sprintf("\n\t%s (%s): %s", id, environments[id].GetEnvironmentUrl(), failures[id]))
+	}
+	return errors.New(message.String())
+}
+
+// firstDeployedApi returns the api of the first config deployed to the environment. Platform and cluster apis are
+// skipped, as they are not accessed with the token of the environment. If no config is deployed to the environment, nil
+// is returned.
+func firstDeployedApi(projects []project.Project, environment environment.Environment) api.Api {
+	for _, project := range projects {
+		for _, config := range project.GetConfigs() {
+			if !config.IsSkipDeployment(environment) && !config.GetApi().IsPlatformApi() && !config.GetApi().IsClusterApi() {
+				return config.GetApi()
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"gotest.tools/assert"
+)
+
+func TestPreflightCheckReportsAllFailingEnvironments(t *testing.T) {
+	environments := map[string]environment.Environment{
+		"dev":  environment.NewEnvironment("dev", "Dev", "", "https://dev.live.dynatrace.com", "DEV"),
+		"prod": environment.NewEnvironment("prod", "Prod", "", "https://prod.live.dynatrace.com", "PROD"),
+		"test": environment.NewEnvironment("test", "Test", "", "https://test.live.dynatrace.com", "TEST"),
+	}
+
+	check := func(environment environment.Environment, theApi api.Api) error {
+		if environment.GetId() == "test" {
+			return nil
+		}
+		return errors.New("HTTP 401")
+	}
+
+	err := preflightCheck([]project.Project{createTestProject(t)}, environments, check)
+	assert.Error(t, err, "pre-flight check failed for 2 environment(s):"+
+		"\n\tdev (https://dev.live.dynatrace.com): HTTP 401"+
+		"\n\tprod (https://prod.live.dynatrace.com): HTTP 401")
+}
+
+func TestPreflightCheckKeepsPercentSignsOfErrors(t *testing.T) {
+	environments := map[string]environment.Environment{
+		"dev": environment.NewEnvironment("dev", "Dev", "", "https://dev.live.dynatrace.com/e/my%20environment", "DEV"),
+	}
+
+	check := func(environment environment.Environment, theApi api.Api) error {
+		return errors.New("HTTP 404")
+	}
+
+	err := preflightCheck([]project.Project{createTestProject(t)}, environments, check)
+	assert.Error(t, err, "pre-flight check failed for 1 environment(s):"+
+		"\n\tdev (https://dev.live.dynatrace.com/e/my%20environment): HTTP 404")
+}
+
+func TestPreflightCheckUsesApiOfFirstDeployedConfig(t *testing.T) {
+	environments := map[string]environment.Environment{
+		"dev": environment.NewEnvironment("dev", "Dev", "", "https://dev.live.dynatrace.com", "DEV"),
+	}
+
+	var mutex sync.Mutex
+	checked := make([]string, 0)
+	check := func(environment environment.Environment, theApi api.Api) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		checked = append(checked, environment.GetId()+": "+theApi.GetId())
+		return nil
+	}
+
+	err := preflightCheck([]project.Project{createTestProject(t)}, environments, check)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, checked, []string{"dev: alerting-profile"})
+}
+
+func TestPreflightCheckSkipsEnvironmentsWithoutConfigs(t *testing.T) {
+	environments := map[string]environment.Environment{
+		"dev": environment.NewEnvironment("dev", "Dev", "", "https://dev.live.dynatrace.com", "DEV"),
+	}
+
+	check := func(environment environment.Environment, theApi api.Api) error {
+		t.Errorf("environment %s without configs was checked", environment.GetId())
+		return nil
+	}
+
+	err := preflightCheck([]project.Project{&testProject{id: "empty"}}, environments, check)
+	assert.NilError(t, err)
+}
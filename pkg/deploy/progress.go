@@ -0,0 +1,182 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+// progressSmoothing is the weight of the latest duration in the moving average used to estimate the remaining time
+const progressSmoothing = 0.2
+
+const (
+	// progressBarInterval is the interval in which the progress bar is redrawn on a terminal
+	progressBarInterval = time.Second
+	// progressLogInterval is the interval in which the progress is logged, if the console is not a terminal
+	progressLogInterval = 30 * time.Second
+	progressBarWidth    = 30
+)
+
+// deploymentProgress counts the configs processed for an environment and estimates the remaining duration, using
+// the exponential moving average of the durations between processed configs. It is safe for concurrent use.
+type deploymentProgress struct {
+	mutex sync.Mutex
+
+	environment string
+	total       int
+	completed   int
+
+	lastCompletion time.Time
+	average        time.Duration
+}
+
+func newDeploymentProgress(environment string, total int, start time.Time) *deploymentProgress {
+	return &deploymentProgress{
+		environment:    environment,
+		total:          total,
+		lastCompletion: start,
+	}
+}
+
+// complete marks a config as processed. It can be called on a nil progress, which doesn't track anything.
+func (p *deploymentProgress) complete(now time.Time) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	duration := now.Sub(p.lastCompletion)
+	if p.completed == 0 {
+		p.average = duration
+	} else {
+		p.average = time.Duration(progressSmoothing*float64(duration) + (1-progressSmoothing)*float64(p.average))
+	}
+
+	p.completed++
+	p.lastCompletion = now
+}
+
+// status returns the number of processed configs and the estimated remaining duration. The estimate is only
+// available after the first config has been processed.
+func (p *deploymentProgress) status() (completed int, total int, remaining time.Duration, estimated bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.completed == 0 {
+		return 0, p.total, 0, false
+	}
+
+	remaining = time.Duration(p.total-p.completed) * p.average
+	return p.completed, p.total, remaining, true
+}
+
+func (p *deploymentProgress) String() string {
+	completed, total, remaining, estimated := p.status()
+
+	percent := 100
+	if total > 0 {
+		percent = completed * 100 / total
+	}
+
+	line := fmt.Sprintf("%s: %d/%d configs (%d%%)", p.environment, completed, total, percent)
+	if estimated && completed < total {
+		line += fmt.Sprintf(", about %s remaining", remaining.Round(time.Second))
+	}
+	return line
+}
+
+// bar renders the progress as a bar of the given width, followed by the progress line
+func (p *deploymentProgress) bar(width int) string {
+	completed, total, _, _ := p.status()
+
+	filled := width
+	if total > 0 {
+		filled = completed * width / total
+	}
+
+	return fmt.Sprintf("[%s%s] %s", strings.Repeat("=", filled), strings.Repeat(" ", width-filled), p)
+}
+
+// progressReporter periodically reports the progress of a deployment. On a terminal the progress is drawn as bar
+// below the log messages, otherwise it is logged. The progress is only written to the console and the session log,
+// never to the request and response logs.
+type progressReporter struct {
+	progress *deploymentProgress
+
+	// drawBar is set if the progress bar is drawn as status line of the console, instead of logging the progress
+	drawBar bool
+
+	log *util.Logger
+
+	stopped chan struct{}
+	done    chan struct{}
+}
+
+// startProgressReporter starts reporting the progress until stop is called
+func startProgressReporter(progress *deploymentProgress, log *util.Logger) *progressReporter {
+	reporter := &progressReporter{
+		progress: progress,
+		log:      log,
+		stopped:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	interval := progressLogInterval
+	if log.IsInteractiveConsole() && !util.IsQuietConsole() {
+		reporter.drawBar = true
+		interval = progressBarInterval
+	}
+
+	go reporter.run(interval)
+	return reporter
+}
+
+func (r *progressReporter) run(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopped:
+			if r.drawBar {
+				util.ClearConsoleStatus()
+			}
+			return
+		case <-ticker.C:
+			if r.drawBar {
+				util.SetConsoleStatus(r.progress.bar(progressBarWidth))
+			} else {
+				r.log.Info("Progress of %s", r.progress)
+			}
+		}
+	}
+}
+
+// stop stops reporting and waits until the progress bar is cleared
+func (r *progressReporter) stop() {
+	close(r.stopped)
+	<-r.done
+}
@@ -0,0 +1,88 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestDeploymentProgressWithoutProcessedConfigs(t *testing.T) {
+	progress := newDeploymentProgress("dev", 4, time.Now())
+
+	completed, total, _, estimated := progress.status()
+	assert.Equal(t, completed, 0)
+	assert.Equal(t, total, 4)
+	assert.Equal(t, estimated, false)
+	assert.Equal(t, progress.String(), "dev: 0/4 configs (0%)")
+}
+
+func TestDeploymentProgressEstimatesRemainingDuration(t *testing.T) {
+	start := time.Now()
+	progress := newDeploymentProgress("dev", 4, start)
+
+	progress.complete(start.Add(10 * time.Second))
+
+	_, _, remaining, estimated := progress.status()
+	assert.Equal(t, estimated, true)
+	assert.Equal(t, remaining, 30*time.Second)
+	assert.Equal(t, progress.String(), "dev: 1/4 configs (25%), about 30s remaining")
+
+	// the moving average gives the latest duration a weight of 20%: 0.2 * 20s + 0.8 * 10s = 12s
+	progress.complete(start.Add(30 * time.Second))
+
+	_, _, remaining, _ = progress.status()
+	assert.Equal(t, remaining, 24*time.Second)
+}
+
+func TestDeploymentProgressWhenAllConfigsAreProcessed(t *testing.T) {
+	start := time.Now()
+	progress := newDeploymentProgress("dev", 2, start)
+
+	progress.complete(start.Add(time.Second))
+	progress.complete(start.Add(2 * time.Second))
+
+	assert.Equal(t, progress.String(), "dev: 2/2 configs (100%)")
+}
+
+func TestDeploymentProgressBar(t *testing.T) {
+	start := time.Now()
+	progress := newDeploymentProgress("dev", 4, start)
+
+	assert.Equal(t, progress.bar(8), "[        ] dev: 0/4 configs (0%)")
+
+	progress.complete(start.Add(time.Second))
+	progress.complete(start.Add(2 * time.Second))
+
+	assert.Equal(t, progress.bar(8), "[====    ] dev: 2/4 configs (50%), about 2s remaining")
+}
+
+func TestDeploymentProgressWithoutConfigs(t *testing.T) {
+	progress := newDeploymentProgress("dev", 0, time.Now())
+
+	assert.Equal(t, progress.bar(4), "[====] dev: 0/0 configs (100%)")
+}
+
+func TestCompleteOnNilProgress(t *testing.T) {
+	var progress *deploymentProgress
+	progress.complete(time.Now())
+}
@@ -0,0 +1,147 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/diff"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/spf13/afero"
+)
+
+// resultAction is the outcome of processing a single config
+type resultAction string
+
+const (
+	resultCreated resultAction = "created"
+	resultUpdated resultAction = "updated"
+	// resultValidated is used during a dry run, as nothing is created or updated
+	resultValidated resultAction = "validated"
+	resultSkipped   resultAction = "skipped"
+	resultFailed    resultAction = "failed"
+)
+
+// configResult is the result of processing a single config for an environment
+type configResult struct {
+	Project     string       `json:"project"`
+	Type        string       `json:"type"`
+	Config      string       `json:"config"`
+	Environment string       `json:"environment"`
+	Action      resultAction `json:"action"`
+	EntityId    string       `json:"entityId,omitempty"`
+	DurationMs  int64        `json:"durationMs"`
+	NetworkMs   int64        `json:"networkMs"`
+	RenderMs    int64        `json:"renderMs"`
+	Error       string       `json:"error,omitempty"`
+
+	// Changes are the fields a dry run would change, if the changes of updated configs are shown
+	Changes []diff.Change `json:"changes,omitempty"`
+}
+
+// newConfigResult creates the result of a config. The network duration is the part of the duration spent in requests
+// to the environment, the remaining duration is attributed to rendering.
+func newConfigResult(environment environment.Environment, project project.Project, config config.Config, action resultAction,
+	entityId string, duration time.Duration, network time.Duration, err error) configResult {
+
+	result := configResult{
+		Project:     project.GetId(),
+		Type:        config.GetType(),
+		Config:      config.GetFullQualifiedId(),
+		Environment: environment.GetId(),
+		Action:      action,
+		EntityId:    entityId,
+		DurationMs:  duration.Milliseconds(),
+		NetworkMs:   network.Milliseconds(),
+		RenderMs:    duration.Milliseconds() - network.Milliseconds(),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// deploymentReport collects the results of all processed configs, to write them to a machine-readable report.
+// It is safe for concurrent use.
+type deploymentReport struct {
+	mutex     sync.Mutex
+	startedAt time.Time
+	results   []configResult
+}
+
+// reportFile is the content of the report file
+type reportFile struct {
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt"`
+	DryRun     bool           `json:"dryRun"`
+	Success    bool           `json:"success"`
+	Configs    []configResult `json:"configs"`
+	Timing     timingSummary  `json:"timing"`
+}
+
+func newDeploymentReport() *deploymentReport {
+	return &deploymentReport{
+		startedAt: time.Now(),
+		results:   make([]configResult, 0),
+	}
+}
+
+func (r *deploymentReport) add(result configResult) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.results = append(r.results, result)
+}
+
+// timings returns the breakdown of the durations of all processed configs
+func (r *deploymentReport) timings() timingSummary {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return summarizeTimings(r.results, slowestConfigsCount)
+}
+
+// write writes the report as json to the given file
+func (r *deploymentReport) write(fs afero.Fs, file string, dryRun bool, success bool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	content, err := json.MarshalIndent(reportFile{
+		StartedAt:  r.startedAt,
+		FinishedAt: time.Now(),
+		DryRun:     dryRun,
+		Success:    success,
+		Configs:    r.results,
+		Timing:     summarizeTimings(r.results, slowestConfigsCount),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = afero.WriteFile(fs, file, content, 0644)
+	if err != nil {
+		return fmt.Errorf("could not write report %s: %w", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func readReport(t *testing.T, fs afero.Fs, file string) reportFile {
+	content, err := afero.ReadFile(fs, file)
+	assert.NilError(t, err)
+
+	var report reportFile
+	assert.NilError(t, json.Unmarshal(content, &report))
+	return report
+}
+
+func TestReportContainsResultOfEachConfig(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "profile", gomock.Any()).Return(api.DynatraceEntity{}, errors.New("upload failed"))
+	client.EXPECT().UpsertByName(testProfileApi, "other", gomock.Any()).Return(api.DynatraceEntity{Id: "other-id", Name: "other", Created: true}, nil)
+
+	report := newDeploymentReport()
+	errs := executeSerial(context.Background(), client, environment, []project.Project{createTestProject(t)}, false, "", true, newDeploymentSummary(), report, newDeploymentState())
+	assert.Equal(t, len(errs), 2)
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, report.write(fs, "report.json", false, false))
+
+	written := readReport(t, fs, "report.json")
+	assert.Equal(t, written.Success, false)
+	assert.Equal(t, written.DryRun, false)
+	assert.Equal(t, len(written.Configs), 3)
+
+	assert.Equal(t, written.Configs[0].Config, "proj/alerting-profile/profile")
+	assert.Equal(t, written.Configs[0].Project, "proj")
+	assert.Equal(t, written.Configs[0].Type, "alerting-profile")
+	assert.Equal(t, written.Configs[0].Environment, "dev")
+	assert.Equal(t, written.Configs[0].Action, resultFailed)
+	assert.Equal(t, written.Configs[0].Error, "upload failed, responsible config: profile.json, environment: dev")
+
+	assert.Equal(t, written.Configs[1].Config, "proj/alerting-profile/other")
+	assert.Equal(t, written.Configs[1].Action, resultCreated)
+	assert.Equal(t, written.Configs[1].EntityId, "other-id")
+	assert.Equal(t, written.Configs[1].Error, "")
+
+	assert.Equal(t, written.Configs[2].Config, "proj/calculated-metrics-log/metric")
+	assert.Equal(t, written.Configs[2].Action, resultSkipped)
+	assert.Equal(t, written.Configs[2].Error, "skipped deployment, as it depends on failed config proj/alerting-profile/profile")
+}
+
+func TestReportContainsUpdatedAndValidatedConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	report := newDeploymentReport()
+	errs := executeParallel(context.Background(), &recordingClient{}, environment, []project.Project{createTestProject(t)}, false, "", false, newDeploymentSummary(), report, newDeploymentState(), 2)
+	assert.Equal(t, len(errs), 0)
+
+	for _, result := range report.results {
+		assert.Equal(t, result.Action, resultUpdated)
+		assert.Assert(t, result.EntityId != "")
+	}
+
+	report = newDeploymentReport()
+	errs = executeSerial(context.Background(), nil, environment, []project.Project{createTestProject(t)}, true, "", false, newDeploymentSummary(), report, newDeploymentState())
+	assert.Equal(t, len(errs), 0)
+
+	assert.Equal(t, len(report.results), 3)
+	for _, result := range report.results {
+		assert.Equal(t, result.Action, resultValidated)
+	}
+}
@@ -0,0 +1,156 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// runState records the configs deployed by a run, by environment and reference id of the config, so a failed or
+// interrupted run can be resumed by a later run. Configs recorded by the resumed run are skipped if their checksum
+// didn't change. It is safe for concurrent use.
+type runState struct {
+	mutex   sync.Mutex
+	entries map[string]map[string]checksumEntry
+
+	// resumed contains the configs deployed by the resumed run. It is empty, if no run is resumed.
+	resumed map[string]map[string]checksumEntry
+}
+
+// runStateFile is the content of the run state file
+type runStateFile struct {
+	UpdatedAt    time.Time                           `json:"updatedAt"`
+	Environments map[string]map[string]checksumEntry `json:"environments"`
+}
+
+func newRunState() *runState {
+	return &runState{
+		entries: make(map[string]map[string]checksumEntry),
+		resumed: make(map[string]map[string]checksumEntry),
+	}
+}
+
+// loadRunState reads the run state to resume from the given file. If the file doesn't exist, or was written longer
+// than maxAge before now, an empty state is returned and found is false. A maxAge of 0 never expires the state.
+func loadRunState(fs afero.Fs, file string, maxAge time.Duration, now time.Time) (state *runState, found bool, err error) {
+	exists, err := afero.Exists(fs, file)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not read run state %s: %w", file, err)
+	}
+	if !exists {
+		return newRunState(), false, nil
+	}
+
+	content, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not read run state %s: %w", file, err)
+	}
+
+	var stateFile runStateFile
+	err = json.Unmarshal(content, &stateFile)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not parse run state %s: %w", file, err)
+	}
+
+	state = newRunState()
+	if maxAge > 0 && now.Sub(stateFile.UpdatedAt) > maxAge {
+		return state, false, nil
+	}
+	if stateFile.Environments != nil {
+		state.resumed = stateFile.Environments
+	}
+	return state, true, nil
+}
+
+// removeRunState removes the run state file, if it exists
+func removeRunState(fs afero.Fs, file string) error {
+	exists, err := afero.Exists(fs, file)
+	if err == nil && exists {
+		err = fs.Remove(file)
+	}
+	if err != nil {
+		return fmt.Errorf("could not remove run state %s: %w", file, err)
+	}
+	return nil
+}
+
+// deployed returns the entity recorded by the resumed run for the config, if it deployed the config with the same
+// checksum
+func (s *runState) deployed(environment string, referenceId string, checksum string) (checksumEntry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, found := s.resumed[environment][referenceId]
+	return entry, found && entry.Checksum == checksum
+}
+
+// count returns the number of configs deployed by the resumed run
+func (s *runState) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	for _, entries := range s.resumed {
+		count += len(entries)
+	}
+	return count
+}
+
+func (s *runState) put(environment string, referenceId string, checksum string, id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.entries[environment] == nil {
+		s.entries[environment] = make(map[string]checksumEntry)
+	}
+	s.entries[environment][referenceId] = checksumEntry{Checksum: checksum, Id: id}
+}
+
+// write writes the configs deployed by the run as json to the given file. Configs of the resumed run which were not
+// processed again, e.g. because the run stopped earlier, are kept, so they are still skipped by the next run.
+func (s *runState) write(fs afero.Fs, file string, now time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	environments := make(map[string]map[string]checksumEntry)
+	for _, entries := range []map[string]map[string]checksumEntry{s.resumed, s.entries} {
+		for environment, configs := range entries {
+			if environments[environment] == nil {
+				environments[environment] = make(map[string]checksumEntry)
+			}
+			for referenceId, entry := range configs {
+				environments[environment][referenceId] = entry
+			}
+		}
+	}
+
+	content, err := json.MarshalIndent(runStateFile{UpdatedAt: now.UTC(), Environments: environments}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = afero.WriteFile(fs, file, content, 0644)
+	if err != nil {
+		return fmt.Errorf("could not write run state %s: %w", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+// executeWithRunState deploys a profile and two metrics referencing it to the dev environment, recording the deployed
+// configs in the given run state
+func executeWithRunState(t *testing.T, client rest.DynatraceClient, profileReference string, run *runState) (*deploymentReport, []error) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": profileReference}),
+			createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/profile.id"}),
+			createTestConfigWithProperties(t, "other-metric", testMetricApi, map[string]string{"name": "other-metric", "reference": "proj/alerting-profile/profile.id"}),
+		},
+	}}
+
+	state := newDeploymentState()
+	state.run = run
+	report := newDeploymentReport()
+
+	errors := executeSerial(context.Background(), client, environment, projects, false, "", false, newDeploymentSummary(), report, state)
+	return report, errors
+}
+
+// failPartway fails the deployment of the test project at the first metric and writes the run state to the file
+func failPartway(t *testing.T, fs afero.Fs, client *rest.MockDynatraceClient, now time.Time) {
+	client.EXPECT().UpsertByName(testProfileApi, "profile", []byte(`{"name": "profile", "reference": "none"}`)).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{}, errors.New("internal server error"))
+
+	run := newRunState()
+	_, errs := executeWithRunState(t, client, "none", run)
+	assert.Equal(t, len(errs), 1)
+	assert.NilError(t, run.write(fs, "run-state.json", now))
+}
+
+func TestResumedRunOnlyDeploysRemainingConfigs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	client := rest.CreateDynatraceClientMockFactory(t)
+	now := time.Now()
+
+	failPartway(t, fs, client, now)
+
+	run, found, err := loadRunState(fs, "run-state.json", time.Hour, now.Add(time.Minute))
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+	assert.Equal(t, run.count(), 1)
+
+	// the profile isn't deployed again, but its id is still resolved
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "other-metric", []byte(`{"name": "other-metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{Id: "other-metric-id", Name: "other-metric"}, nil)
+
+	report, errs := executeWithRunState(t, client, "none", run)
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, report.results[0].Action, resultSkipped)
+	assert.Equal(t, report.results[0].EntityId, "profile-id")
+	assert.Equal(t, report.results[1].Action, resultUpdated)
+	assert.Equal(t, report.results[2].Action, resultUpdated)
+}
+
+func TestResumedRunDeploysConfigsChangedSinceTheFailedRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	client := rest.CreateDynatraceClientMockFactory(t)
+	now := time.Now()
+
+	failPartway(t, fs, client, now)
+
+	run, _, err := loadRunState(fs, "run-state.json", time.Hour, now)
+	assert.NilError(t, err)
+
+	client.EXPECT().UpsertByName(testProfileApi, "profile", []byte(`{"name": "profile", "reference": "changed"}`)).Return(api.DynatraceEntity{Id: "profile-id", Name: "profile"}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "other-metric", []byte(`{"name": "other-metric", "reference": "profile-id"}`)).Return(api.DynatraceEntity{Id: "other-metric-id", Name: "other-metric"}, nil)
+
+	report, errs := executeWithRunState(t, client, "changed", run)
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, report.results[0].Action, resultUpdated)
+}
+
+func TestLoadRunStateIgnoresExpiredState(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Now()
+
+	run := newRunState()
+	run.put("dev", "proj/alerting-profile/profile", "checksum", "profile-id")
+	assert.NilError(t, run.write(fs, "run-state.json", now))
+
+	loaded, found, err := loadRunState(fs, "run-state.json", time.Hour, now.Add(2*time.Hour))
+	assert.NilError(t, err)
+	assert.Assert(t, !found)
+	assert.Equal(t, loaded.count(), 0)
+
+	// a max age of 0 never expires the state
+	loaded, found, err = loadRunState(fs, "run-state.json", 0, now.AddDate(1, 0, 0))
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+	_, deployed := loaded.deployed("dev", "proj/alerting-profile/profile", "checksum")
+	assert.Assert(t, deployed)
+}
+
+func TestRunStateKeepsConfigsOfResumedRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Now()
+
+	first := newRunState()
+	first.put("dev", "proj/alerting-profile/profile", "checksum", "profile-id")
+	assert.NilError(t, first.write(fs, "run-state.json", now))
+
+	second, _, err := loadRunState(fs, "run-state.json", time.Hour, now)
+	assert.NilError(t, err)
+	second.put("dev", "proj/custom-metric/metric", "checksum", "metric-id")
+	assert.NilError(t, second.write(fs, "run-state.json", now))
+
+	third, _, err := loadRunState(fs, "run-state.json", time.Hour, now)
+	assert.NilError(t, err)
+	assert.Equal(t, third.count(), 2)
+}
+
+func TestLoadRunToResumeRemovesDiscardedState(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, newRunState().write(fs, "run-state.json", time.Now()))
+
+	run, err := loadRunToResume(fs, "run-state.json", false, true, time.Hour, util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Equal(t, run.count(), 0)
+
+	exists, err := afero.Exists(fs, "run-state.json")
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+}
+
+func TestDeployRejectsResumeAndNoResume(t *testing.T) {
+	opts := NewOptions()
+	opts.Resume = true
+	opts.NoResume = true
+
+	_, err := Deploy(context.Background(), afero.NewMemMapFs(), ".", "environments.yaml", opts)
+	assert.Error(t, err, "a deployment can't be resumed while its run state is discarded")
+}
+
+func TestRunStateIsOnlyRecordedIfGivenOrResumed(t *testing.T) {
+	assert.Equal(t, NewOptions().RunStateFile, "")
+	assert.Equal(t, runStateFileOf("", false, false), "")
+	assert.Equal(t, runStateFileOf("", true, false), DefaultRunStateFile)
+	assert.Equal(t, runStateFileOf("", false, true), DefaultRunStateFile)
+	assert.Equal(t, runStateFileOf("run-state.json", false, false), "run-state.json")
+	assert.Equal(t, runStateFileOf("run-state.json", true, false), "run-state.json")
+}
@@ -0,0 +1,77 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+)
+
+// settingsExternalIdPrefix marks the settings objects deployed by monaco
+const settingsExternalIdPrefix = "monaco:"
+
+// settingsExternalId returns the external id of the settings object deployed from the config with the given id in the
+// given project and api. It is a hash of the three ids, which are separated so a config can't share its external id
+// with a config of another project, and is thus found again by every later deployment. The ids don't depend on the
+// operating system, so deployments from other machines find the same object.
+func settingsExternalId(projectId string, apiId string, configId string) string {
+	input := strings.Join([]string{filepath.ToSlash(projectId), apiId, configId}, "\x00")
+	hash := sha256.Sum256([]byte(input))
+	return settingsExternalIdPrefix + hex.EncodeToString(hash[:])
+}
+
+// automationObjectId returns the id of the automation object deployed from the config with the given id in the given
+// project and api. Like the external id of settings objects, it is a hash of the three ids, formatted as the UUID
+// the automation APIs expect, so every later deployment updates the same object.
+func automationObjectId(projectId string, apiId string, configId string) string {
+	input := strings.Join([]string{filepath.ToSlash(projectId), apiId, configId}, "\x00")
+	hash := sha256.Sum256([]byte(input))
+
+	// the version and variant bits mark the id as a name-based UUID
+	hash[6] = (hash[6] & 0x0f) | 0x50
+	hash[8] = (hash[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+}
+
+// newSettingsObject returns the settings object a config of the settings api is deployed to, without its value
+func newSettingsObject(c config.Config, environment environment.Environment, dict map[string]api.DynatraceEntity,
+	name string, projectId string) (api.SettingsObject, error) {
+
+	schemaId, err := c.GetParameterForEnvironment(config.SchemaIdParameter, environment, dict)
+	if err != nil {
+		return api.SettingsObject{}, err
+	}
+
+	scope, err := c.GetParameterForEnvironment(config.ScopeParameter, environment, dict)
+	if err != nil {
+		return api.SettingsObject{}, err
+	}
+
+	return api.SettingsObject{
+		Name:       name,
+		SchemaId:   schemaId,
+		Scope:      scope,
+		ExternalId: settingsExternalId(projectId, c.GetApi().GetId(), c.GetId()),
+	}, nil
+}
@@ -0,0 +1,211 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/bundle"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+var testSettingsApi = api.NewSettingsApi("settings", "/api/v2/settings/objects")
+
+// createSettingsTestProject creates a project with a settings object in the scope of a management zone, and a metric
+// referencing the settings object
+func createSettingsTestProject(t *testing.T, tagsProperties map[string]string) project.Project {
+	return &testProject{
+		id: "proj",
+		configs: []config.Config{
+			createTestConfigWithProperties(t, "zone", testProfileApi, map[string]string{"name": "zone", "reference": "none"}),
+			createTestConfigWithProperties(t, "tags", testSettingsApi, tagsProperties),
+			createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/settings/tags.id"}),
+		},
+	}
+}
+
+func TestExecuteSerialUpsertsSettingsByExternalId(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "zone", []byte(`{"name": "zone", "reference": "none"}`)).Return(api.DynatraceEntity{Id: "zone-id", Name: "zone"}, nil)
+	client.EXPECT().UpsertSettings(testSettingsApi, api.SettingsObject{
+		Name:       "tags",
+		SchemaId:   "builtin:tags.auto-tagging",
+		Scope:      "zone-id",
+		ExternalId: settingsExternalId("proj", "settings", "tags"),
+		Value:      []byte(`{"name": "tags", "reference": "none"}`),
+	}).Return(api.DynatraceEntity{Id: "tags-object-id", Name: "tags", Created: true}, nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "tags-object-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	report := newDeploymentReport()
+	settingsProject := createSettingsTestProject(t, map[string]string{
+		"name":      "tags",
+		"reference": "none",
+		"schemaId":  "builtin:tags.auto-tagging",
+		"scope":     "proj/alerting-profile/zone.id",
+	})
+
+	errors := executeSerial(context.Background(), client, environment, []project.Project{settingsProject}, false, "", false, newDeploymentSummary(), report, newDeploymentState())
+	assert.Equal(t, len(errors), 0)
+	assert.Equal(t, report.results[1].Action, resultCreated)
+	assert.Equal(t, report.results[1].EntityId, "tags-object-id")
+}
+
+func TestExecuteSerialSkipsExistingCreateOnlySettings(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "zone", gomock.Any()).Return(api.DynatraceEntity{Id: "zone-id", Name: "zone"}, nil)
+	client.EXPECT().ExistsSettings(testSettingsApi, api.SettingsObject{
+		Name:       "tags",
+		SchemaId:   "builtin:tags.auto-tagging",
+		Scope:      "environment",
+		ExternalId: settingsExternalId("proj", "settings", "tags"),
+	}).Return(true, "tags-object-id", nil)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "tags-object-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil)
+
+	report := newDeploymentReport()
+	settingsProject := createSettingsTestProject(t, map[string]string{
+		"name":         "tags",
+		"reference":    "none",
+		"schemaId":     "builtin:tags.auto-tagging",
+		"scope":        "environment",
+		"skipIfExists": "true",
+	})
+
+	errors := executeSerial(context.Background(), client, environment, []project.Project{settingsProject}, false, "", false, newDeploymentSummary(), report, newDeploymentState())
+	assert.Equal(t, len(errors), 0)
+	assert.Equal(t, report.results[1].Action, resultSkipped)
+}
+
+func TestExecuteSerialFailsOnSettingsWithoutScope(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "zone", gomock.Any()).Return(api.DynatraceEntity{Id: "zone-id", Name: "zone"}, nil)
+
+	settingsProject := createSettingsTestProject(t, map[string]string{
+		"name":      "tags",
+		"reference": "none",
+		"schemaId":  "builtin:tags.auto-tagging",
+	})
+
+	errors := executeSerial(context.Background(), client, environment, []project.Project{settingsProject}, false, "", true, newDeploymentSummary(), newDeploymentReport(), newDeploymentState())
+	assert.Equal(t, len(errors), 2)
+	assert.ErrorContains(t, errors[0], "could not find scope property in config proj/settings/tags, please make sure `scope` is defined")
+}
+
+func TestExecuteSerialDeploysBundledSettings(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"environments.yaml":       "dev:\n  - name: \"Dev\"\n  - env-url: \"https://url/to/dev/environment\"\n  - env-token-name: \"DEV\"\n",
+		"proj/settings/tags.yaml": "config:\n  - tags: \"tags.json\"\n\ntags:\n  - name: \"tags\"\n  - schemaId: \"builtin:tags.auto-tagging\"\n  - scope: \"environment\"\n",
+		"proj/settings/tags.json": `{"name": "{{.name}}"}`,
+	}
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+
+	assert.NilError(t, bundle.Create(".", fs, "environments.yaml", "", "", "bundle.zip"))
+
+	b, err := bundle.Load(fs, "bundle.zip")
+	assert.NilError(t, err)
+
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertSettings(gomock.Any(), api.SettingsObject{
+		Name:       "tags",
+		SchemaId:   "builtin:tags.auto-tagging",
+		Scope:      "environment",
+		ExternalId: settingsExternalId("proj", "settings", "tags"),
+		Value:      []byte(`{"name": "tags"}`),
+	}).Return(api.DynatraceEntity{Id: "tags-object-id", Name: "tags"}, nil)
+
+	errors := executeSerial(context.Background(), client, environment, b.Projects, false, "", false, newDeploymentSummary(), newDeploymentReport(), newDeploymentState())
+	assert.Equal(t, len(errors), 0)
+}
+
+func TestDryRunPlansSettingsByExternalId(t *testing.T) {
+	client := rest.CreateDynatraceClientMockFactory(t)
+	object := api.SettingsObject{Name: "tags", SchemaId: "builtin:tags.auto-tagging", Scope: "environment", ExternalId: settingsExternalId("proj", "settings", "tags")}
+	client.EXPECT().ExistsSettings(testSettingsApi, object).Return(false, "", nil)
+
+	settingsConfig := createTestConfigWithProperties(t, "tags", testSettingsApi, map[string]string{"name": "tags"})
+
+	action, _, err := plannedAction(client, settingsConfig, "tags", object, "")
+	assert.NilError(t, err)
+	assert.Equal(t, action, actionCreate)
+}
+
+func TestSettingsExternalIdIsNamespacedByProject(t *testing.T) {
+	externalId := settingsExternalId("proj", "settings", "tags")
+	assert.Equal(t, externalId, settingsExternalId("proj", "settings", "tags"))
+	assert.Assert(t, strings.HasPrefix(externalId, "monaco:"))
+
+	assert.Assert(t, externalId != settingsExternalId("other-proj", "settings", "tags"))
+	assert.Assert(t, settingsExternalId("proj/sub", "settings", "tags") != settingsExternalId("proj", "sub/settings", "tags"))
+	assert.Equal(t, settingsExternalId(filepath.Join("proj", "sub"), "settings", "tags"), settingsExternalId("proj/sub", "settings", "tags"))
+}
+
+func TestExecuteSerialUpdatesSettingsOnSecondDeployment(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+	object := api.SettingsObject{
+		Name:       "tags",
+		SchemaId:   "builtin:tags.auto-tagging",
+		Scope:      "environment",
+		ExternalId: settingsExternalId("proj", "settings", "tags"),
+		Value:      []byte(`{"name": "tags", "reference": "none"}`),
+	}
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().UpsertByName(testProfileApi, "zone", gomock.Any()).Return(api.DynatraceEntity{Id: "zone-id", Name: "zone"}, nil).Times(2)
+	gomock.InOrder(
+		client.EXPECT().UpsertSettings(testSettingsApi, object).Return(api.DynatraceEntity{Id: "tags-object-id", Name: "tags", Created: true}, nil),
+		client.EXPECT().UpsertSettings(testSettingsApi, object).Return(api.DynatraceEntity{Id: "tags-object-id", Name: "tags"}, nil),
+	)
+	client.EXPECT().UpsertByName(testMetricApi, "metric", []byte(`{"name": "metric", "reference": "tags-object-id"}`)).Return(api.DynatraceEntity{Id: "metric-id", Name: "metric"}, nil).Times(2)
+
+	for _, expected := range []resultAction{resultCreated, resultUpdated} {
+		report := newDeploymentReport()
+		settingsProject := createSettingsTestProject(t, map[string]string{
+			"name":      "tags",
+			"reference": "none",
+			"schemaId":  "builtin:tags.auto-tagging",
+			"scope":     "environment",
+		})
+
+		errors := executeSerial(context.Background(), client, environment, []project.Project{settingsProject}, false, "", false, newDeploymentSummary(), report, newDeploymentState())
+		assert.Equal(t, len(errors), 0)
+		assert.Equal(t, report.results[1].Action, expected)
+		assert.Equal(t, report.results[1].EntityId, "tags-object-id")
+	}
+}
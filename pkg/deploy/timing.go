@@ -0,0 +1,216 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+// slowestConfigsCount is the number of slowest configs listed in the timing summary
+const slowestConfigsCount = 10
+
+// timingClient is a DynatraceClient measuring the time spent in requests to the environment. It is created for a
+// single config and is not safe for concurrent use.
+type timingClient struct {
+	client  rest.DynatraceClient
+	network time.Duration
+}
+
+func (c *timingClient) measure(start time.Time) {
+	c.network += time.Since(start)
+}
+
+func (c *timingClient) List(a api.Api) ([]api.Value, error) {
+	defer c.measure(time.Now())
+	return c.client.List(a)
+}
+
+func (c *timingClient) ReadByName(a api.Api, name string) ([]byte, error) {
+	defer c.measure(time.Now())
+	return c.client.ReadByName(a, name)
+}
+
+func (c *timingClient) ReadById(a api.Api, id string) ([]byte, error) {
+	defer c.measure(time.Now())
+	return c.client.ReadById(a, id)
+}
+
+func (c *timingClient) UpsertByName(a api.Api, name string, payload []byte) (api.DynatraceEntity, error) {
+	defer c.measure(time.Now())
+	return c.client.UpsertByName(a, name, payload)
+}
+
+func (c *timingClient) UpsertById(a api.Api, id string, name string, payload []byte) (api.DynatraceEntity, error) {
+	defer c.measure(time.Now())
+	return c.client.UpsertById(a, id, name, payload)
+}
+
+func (c *timingClient) DeleteByName(a api.Api, name string) error {
+	defer c.measure(time.Now())
+	return c.client.DeleteByName(a, name)
+}
+
+func (c *timingClient) ExistsByName(a api.Api, name string) (bool, string, error) {
+	defer c.measure(time.Now())
+	return c.client.ExistsByName(a, name)
+}
+
+func (c *timingClient) ListSchemas(a api.Api) ([]string, error) {
+	defer c.measure(time.Now())
+	return c.client.ListSchemas(a)
+}
+
+func (c *timingClient) ListSettings(a api.Api, schemaId string) ([]api.DownloadedSettingsObject, error) {
+	defer c.measure(time.Now())
+	return c.client.ListSettings(a, schemaId)
+}
+
+func (c *timingClient) ExistsSettings(a api.Api, object api.SettingsObject) (bool, string, error) {
+	defer c.measure(time.Now())
+	return c.client.ExistsSettings(a, object)
+}
+
+func (c *timingClient) UpsertSettings(a api.Api, object api.SettingsObject) (api.DynatraceEntity, error) {
+	defer c.measure(time.Now())
+	return c.client.UpsertSettings(a, object)
+}
+
+func (c *timingClient) ListAutomations(a api.Api) ([]api.DownloadedAutomationObject, error) {
+	defer c.measure(time.Now())
+	return c.client.ListAutomations(a)
+}
+
+func (c *timingClient) ExistsAutomation(a api.Api, id string) (bool, error) {
+	defer c.measure(time.Now())
+	return c.client.ExistsAutomation(a, id)
+}
+
+func (c *timingClient) UpsertAutomation(a api.Api, id string, name string, payload []byte) (api.DynatraceEntity, error) {
+	defer c.measure(time.Now())
+	return c.client.UpsertAutomation(a, id, name, payload)
+}
+
+// apiTiming contains the accumulated durations of all configs of an api
+type apiTiming struct {
+	Type       string `json:"type"`
+	Configs    int    `json:"configs"`
+	DurationMs int64  `json:"durationMs"`
+	NetworkMs  int64  `json:"networkMs"`
+	RenderMs   int64  `json:"renderMs"`
+}
+
+// configTiming contains the durations of a single config
+type configTiming struct {
+	Config      string `json:"config"`
+	Environment string `json:"environment"`
+	DurationMs  int64  `json:"durationMs"`
+	NetworkMs   int64  `json:"networkMs"`
+	RenderMs    int64  `json:"renderMs"`
+}
+
+// timingSummary is the breakdown of the durations of all processed configs. Network durations contain the time
+// spent in requests to the environment, render durations the remaining time, mostly spent rendering templates.
+type timingSummary struct {
+	DurationMs int64          `json:"durationMs"`
+	NetworkMs  int64          `json:"networkMs"`
+	RenderMs   int64          `json:"renderMs"`
+	Apis       []apiTiming    `json:"apis"`
+	Slowest    []configTiming `json:"slowest"`
+}
+
+// summarizeTimings accumulates the durations of all configs which were not skipped. Apis are sorted by their
+// duration, the slowest configs are limited to the given count.
+func summarizeTimings(results []configResult, slowest int) timingSummary {
+	summary := timingSummary{
+		Apis:    make([]apiTiming, 0),
+		Slowest: make([]configTiming, 0),
+	}
+
+	apis := make(map[string]*apiTiming)
+	configs := make([]configTiming, 0, len(results))
+
+	for _, result := range results {
+		if result.Action == resultSkipped {
+			continue
+		}
+
+		summary.DurationMs += result.DurationMs
+		summary.NetworkMs += result.NetworkMs
+		summary.RenderMs += result.RenderMs
+
+		if apis[result.Type] == nil {
+			apis[result.Type] = &apiTiming{Type: result.Type}
+		}
+		apis[result.Type].Configs++
+		apis[result.Type].DurationMs += result.DurationMs
+		apis[result.Type].NetworkMs += result.NetworkMs
+		apis[result.Type].RenderMs += result.RenderMs
+
+		configs = append(configs, configTiming{
+			Config:      result.Config,
+			Environment: result.Environment,
+			DurationMs:  result.DurationMs,
+			NetworkMs:   result.NetworkMs,
+			RenderMs:    result.RenderMs,
+		})
+	}
+
+	for _, timing := range apis {
+		summary.Apis = append(summary.Apis, *timing)
+	}
+	sort.SliceStable(summary.Apis, func(i, j int) bool {
+		if summary.Apis[i].DurationMs != summary.Apis[j].DurationMs {
+			return summary.Apis[i].DurationMs > summary.Apis[j].DurationMs
+		}
+		return summary.Apis[i].Type < summary.Apis[j].Type
+	})
+
+	sort.SliceStable(configs, func(i, j int) bool { return configs[i].DurationMs > configs[j].DurationMs })
+	if len(configs) > slowest {
+		configs = configs[:slowest]
+	}
+	summary.Slowest = append(summary.Slowest, configs...)
+
+	return summary
+}
+
+func (s timingSummary) print(log *util.Logger) {
+	if len(s.Apis) == 0 {
+		return
+	}
+
+	log.Info("Timing summary: %s in total (network: %s, rendering: %s)", ms(s.DurationMs), ms(s.NetworkMs), ms(s.RenderMs))
+
+	log.Info("\tBy type:")
+	for _, timing := range s.Apis {
+		log.Info("\t\t%s: %s for %d config(s) (network: %s, rendering: %s)", timing.Type, ms(timing.DurationMs), timing.Configs, ms(timing.NetworkMs), ms(timing.RenderMs))
+	}
+
+	log.Info("\tSlowest configs:")
+	for _, timing := range s.Slowest {
+		log.Info("\t\t%s (environment: %s): %s (network: %s, rendering: %s)", timing.Config, timing.Environment, ms(timing.DurationMs), ms(timing.NetworkMs), ms(timing.RenderMs))
+	}
+}
+
+func ms(milliseconds int64) time.Duration {
+	return time.Duration(milliseconds) * time.Millisecond
+}
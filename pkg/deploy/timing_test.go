@@ -0,0 +1,89 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"gotest.tools/assert"
+)
+
+// slowClient delays each upsert, to simulate the network time of a request
+type slowClient struct {
+	recordingClient
+	delay time.Duration
+}
+
+func (c *slowClient) UpsertByName(a api.Api, name string, payload []byte) (api.DynatraceEntity, error) {
+	time.Sleep(c.delay)
+	return c.recordingClient.UpsertByName(a, name, payload)
+}
+
+func TestDeployConfigMeasuresNetworkTime(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	report := newDeploymentReport()
+	errs := executeSerial(context.Background(), &slowClient{delay: 20 * time.Millisecond}, environment, []project.Project{createTestProject(t)}, false, "", false, newDeploymentSummary(), report, newDeploymentState())
+	assert.Equal(t, len(errs), 0)
+
+	assert.Equal(t, len(report.results), 3)
+	for _, result := range report.results {
+		assert.Assert(t, result.NetworkMs >= 20, "network time of %s not measured: %d ms", result.Config, result.NetworkMs)
+		assert.Equal(t, result.DurationMs, result.NetworkMs+result.RenderMs)
+	}
+}
+
+func TestSummarizeTimings(t *testing.T) {
+	results := []configResult{
+		{Config: "proj/alerting-profile/a", Type: "alerting-profile", Environment: "dev", Action: resultCreated, DurationMs: 100, NetworkMs: 90, RenderMs: 10},
+		{Config: "proj/alerting-profile/b", Type: "alerting-profile", Environment: "dev", Action: resultUpdated, DurationMs: 300, NetworkMs: 250, RenderMs: 50},
+		{Config: "proj/dashboard/c", Type: "dashboard", Environment: "dev", Action: resultFailed, DurationMs: 200, NetworkMs: 20, RenderMs: 180},
+		{Config: "proj/dashboard/d", Type: "dashboard", Environment: "dev", Action: resultSkipped},
+	}
+
+	summary := summarizeTimings(results, 2)
+
+	assert.Equal(t, summary.DurationMs, int64(600))
+	assert.Equal(t, summary.NetworkMs, int64(360))
+	assert.Equal(t, summary.RenderMs, int64(240))
+
+	assert.DeepEqual(t, summary.Apis, []apiTiming{
+		{Type: "alerting-profile", Configs: 2, DurationMs: 400, NetworkMs: 340, RenderMs: 60},
+		{Type: "dashboard", Configs: 1, DurationMs: 200, NetworkMs: 20, RenderMs: 180},
+	})
+
+	assert.DeepEqual(t, summary.Slowest, []configTiming{
+		{Config: "proj/alerting-profile/b", Environment: "dev", DurationMs: 300, NetworkMs: 250, RenderMs: 50},
+		{Config: "proj/dashboard/c", Environment: "dev", DurationMs: 200, NetworkMs: 20, RenderMs: 180},
+	})
+}
+
+func TestSummarizeTimingsWithoutResults(t *testing.T) {
+	summary := summarizeTimings(nil, 10)
+
+	assert.Equal(t, summary.DurationMs, int64(0))
+	assert.Equal(t, len(summary.Apis), 0)
+	assert.Equal(t, len(summary.Slowest), 0)
+}
@@ -0,0 +1,120 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/delete"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/schema"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// Validate checks the configs of the projects for all environments, without contacting any environment. The templates
+// are rendered with the parameters of each environment, references to other configs are resolved using placeholder
+// ids, and the rendered payloads are checked to be valid json and to satisfy the constraints of their api. All errors
+// found are reported.
+//
+// Template files in api folders which are not referenced by any config, and configs referencing template files which
+// do not exist, are reported as warnings, or as errors if strict is set. Configs of the same api in a project sharing
+// their name are always reported as errors.
+//
+// If validateSchemas is set, or a schemaDir is given, the rendered payloads are also validated against the schema of
+// their api.
+//
+// All messages are written to the logger, which is the default logger if nil.
+func Validate(workingDir string, fs afero.Fs, environmentsFile string, specificEnvironment string, proj string, strict bool,
+	validateSchemas bool, schemaDir string, log *util.Logger) error {
+	environments, errors := environment.LoadEnvironmentList(specificEnvironment, environmentsFile, fs, log)
+
+	workingDir = filepath.Clean(workingDir)
+
+	var validationErrors = make(map[string][]error)
+
+	for i, err := range errors {
+		configIssue := fmt.Sprintf("environmentfile-issue-%d", i)
+		validationErrors[configIssue] = append(validationErrors[configIssue], err)
+	}
+
+	apis := api.NewApis()
+
+	templateIssues, err := project.FindTemplateIssues(fs, workingDir, apis, log)
+	if err != nil {
+		validationErrors["template-issue"] = append(validationErrors["template-issue"], err)
+	}
+	for _, issue := range templateIssues {
+		if strict {
+			validationErrors["template-issue"] = append(validationErrors["template-issue"], fmt.Errorf("%s", issue))
+		} else {
+			log.Warn("%s", issue)
+		}
+	}
+
+	projects, err := project.LoadProjectsToDeploy(fs, proj, apis, workingDir, log)
+	if err != nil {
+		log.Error("Loading of projects failed: %s", err)
+		return fmt.Errorf("Errors during validation! Check log!")
+	}
+
+	if err := checkDuplicateNames(projects, environments); err != nil {
+		validationErrors["duplicate-name-issue"] = append(validationErrors["duplicate-name-issue"], err)
+	}
+
+	var schemas *schema.Validator
+	if validateSchemas || schemaDir != "" {
+		schemas, err = schema.NewValidator(fs, schemaDir, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, environment := range environments {
+		log.Info("Validating configs for environment %s...", environment.GetId())
+
+		state := newDeploymentState()
+		state.schemas = schemas
+		state.log = log
+
+		// without a client, configs are only rendered and validated locally
+		errors := executeSerial(context.Background(), nil, environment, projects, true, workingDir, true, newDeploymentSummary(), newDeploymentReport(), state)
+		if len(errors) > 0 {
+			validationErrors[environment.GetId()] = errors
+		}
+	}
+
+	if _, err := delete.LoadConfigsToDelete(fs, apis, workingDir, log); err != nil {
+		validationErrors["delete-file-issue"] = append(validationErrors["delete-file-issue"], err)
+	}
+
+	if len(validationErrors) > 0 {
+		for _, environment := range sortedErrorKeys(validationErrors) {
+			errors := validationErrors[environment]
+			log.Error("Validation of %s failed. Found %d error(s)\n", environment, len(errors))
+			printDeploymentErrors(log, errors)
+		}
+		return fmt.Errorf("Errors during validation! Check log!")
+	}
+
+	log.Info("Validation finished without errors")
+	return nil
+}
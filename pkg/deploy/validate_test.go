@@ -0,0 +1,192 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+const validateTestEnvironments = `
+dev:
+  - name: "Dev"
+  - env-url: "https://dev.live.dynatrace.com"
+  - env-token-name: "MONACO_VALIDATE_TEST_TOKEN"
+`
+
+func writeValidateTestProject(t *testing.T, fs afero.Fs, profileJson string) {
+	files := map[string]string{
+		"environments.yaml": validateTestEnvironments,
+		"project/alerting-profile/profile.yaml": `
+config:
+  - profile: "profile.json"
+
+profile:
+  - name: "Profile"
+`,
+		"project/alerting-profile/profile.json": profileJson,
+		"project/calculated-metrics-log/metric.yaml": `
+config:
+  - metric: "metric.json"
+
+metric:
+  - name: "Metric"
+  - profile: "project/alerting-profile/profile.id"
+`,
+		"project/calculated-metrics-log/metric.json": `{"name": "{{.name}}", "profile": "{{.profile}}"}`,
+	}
+
+	for file, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, file, []byte(content), 0644))
+	}
+}
+
+func TestValidateSucceedsForValidConfigs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"displayName": "{{.name}}", "rules": []}`)
+
+	err := Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.NilError(t, err)
+}
+
+func TestValidateFailsForInvalidJson(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"displayName": "{{.name}}", "rules": [}`)
+
+	err := Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.Error(t, err, "Errors during validation! Check log!")
+}
+
+func TestValidateChecksAssetsOfConfigs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"displayName": "{{.name}}", "image": "{{ asset "logo.png" }}", "rules": []}`)
+
+	err := Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.Error(t, err, "Errors during validation! Check log!")
+
+	assert.NilError(t, afero.WriteFile(fs, "project/alerting-profile/logo.png", []byte{0x89, 'P', 'N', 'G'}, 0644))
+
+	err = Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.NilError(t, err)
+}
+
+func TestDryRunReportsAllErrors(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "invalid.json", []byte(`{"name": "{{ .name }}",}`), 0644))
+	invalidJson, err := config.NewConfig(fs, "invalid", "proj", "invalid.json", map[string]map[string]string{"invalid": {"name": "invalid"}}, testProfileApi)
+	assert.NilError(t, err)
+
+	projects := []project.Project{&testProject{
+		id: "proj",
+		configs: []config.Config{
+			// a missing name stops a deployment, but not a dry run
+			createTestConfigWithProperties(t, "unnamed", testProfileApi, map[string]string{"reference": "none"}),
+			invalidJson,
+			createTestConfigWithProperties(t, "profile", testProfileApi, map[string]string{"name": "profile", "reference": "none"}),
+			createTestConfigWithProperties(t, "metric", testMetricApi, map[string]string{"name": "metric", "reference": "proj/alerting-profile/invalid.id"}),
+		},
+	}}
+
+	errs := executeSerial(context.Background(), nil, environment, projects, true, "", false, newDeploymentSummary(), newDeploymentReport(), newDeploymentState())
+
+	assert.Equal(t, len(errs), 3)
+	assert.ErrorContains(t, errs[0], "could not find name property in config proj/alerting-profile/unnamed")
+	assert.ErrorContains(t, errs[1], "file invalid.json is not a valid json")
+	assert.ErrorContains(t, errs[2], "skipped deployment, as it depends on failed config proj/alerting-profile/invalid")
+}
+
+func TestValidateWarnsOnOrphanedTemplates(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"displayName": "{{.name}}", "rules": []}`)
+	assert.NilError(t, afero.WriteFile(fs, "project/alerting-profile/profile-old.json", []byte("{}"), 0644))
+
+	err := Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.NilError(t, err)
+}
+
+func TestValidateFailsOnOrphanedTemplatesIfStrict(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"displayName": "{{.name}}", "rules": []}`)
+	assert.NilError(t, afero.WriteFile(fs, "project/alerting-profile/profile-old.json", []byte("{}"), 0644))
+
+	err := Validate(".", fs, "environments.yaml", "", "", true, false, "", util.DefaultLogger())
+	assert.Error(t, err, "Errors during validation! Check log!")
+}
+
+func TestValidateResolvesReferencedProperties(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"displayName": "{{.name}}", "rules": []}`)
+	assert.NilError(t, afero.WriteFile(fs, "project/calculated-metrics-log/metric.yaml", []byte(`
+config:
+  - metric: "metric.json"
+
+metric:
+  - name: "Metric"
+  - profile: "project/alerting-profile/profile.property.displayName"
+`), 0644))
+
+	err := Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.NilError(t, err)
+}
+
+func TestValidateFailsForMissingReferencedProperty(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"displayName": "{{.name}}", "rules": []}`)
+	assert.NilError(t, afero.WriteFile(fs, "project/calculated-metrics-log/metric.yaml", []byte(`
+config:
+  - metric: "metric.json"
+
+metric:
+  - name: "Metric"
+  - profile: "project/alerting-profile/profile.property.name"
+`), 0644))
+
+	err := Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.Error(t, err, "Errors during validation! Check log!")
+}
+
+func TestValidateChecksConstraintsOfApis(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"name": "{{.name}}", "rules": [{"severityLevel": "WARNING"}]}`)
+
+	err := Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.Error(t, err, "Errors during validation! Check log!")
+}
+
+func TestValidateChecksSchemasIfEnabled(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeValidateTestProject(t, fs, `{"displayName": "{{.name}}", "rules": [{"severityLevel": "ERROR", "delayInMinutes": -1}]}`)
+
+	err := Validate(".", fs, "environments.yaml", "", "", false, false, "", util.DefaultLogger())
+	assert.NilError(t, err)
+
+	// the bundled alerting-profile schema requires a delay of at least zero minutes
+	err = Validate(".", fs, "environments.yaml", "", "", false, true, "", util.DefaultLogger())
+	assert.Error(t, err, "Errors during validation! Check log!")
+}
@@ -0,0 +1,223 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package diff
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// configDiff contains the differences between a local config and its live counterpart in an environment
+type configDiff struct {
+	config string
+
+	// missing is true if the config doesn't exist in the environment and would be created
+	missing bool
+	changes []Change
+}
+
+func (d configDiff) hasDifferences() bool {
+	return d.missing || len(d.changes) > 0
+}
+
+// Diff compares the configs of the projects with the configs in the environments and prints the differences.
+// If failOnDiff is set, an error is returned if any difference is found.
+func Diff(workingDir string, fs afero.Fs, environmentsFile string, specificEnvironment string, proj string,
+	ignoredFields []string, failOnDiff bool) error {
+
+	environments, errs := environment.LoadEnvironmentList(specificEnvironment, environmentsFile, fs, util.DefaultLogger())
+	if len(errs) > 0 {
+		util.PrintErrors(errs)
+		return fmt.Errorf("Errors while loading environments! Check log!")
+	}
+
+	workingDir = filepath.Clean(workingDir)
+
+	projects, err := project.LoadProjectsToDeploy(fs, proj, api.NewApis(), workingDir, util.DefaultLogger())
+	if err != nil {
+		return err
+	}
+
+	ignored := newIgnoreList(ignoredFields)
+	foundDifferences := false
+	failed := false
+
+	for _, environment := range sortedEnvironments(environments) {
+		apiToken, err := environment.GetToken()
+		if err != nil {
+			util.Log.Error("Could not compare environment %s: %s", environment.GetId(), err)
+			failed = true
+			continue
+		}
+
+		clusterToken, err := environment.GetManagedClusterToken()
+		if err != nil {
+			util.Log.Error("Could not compare environment %s: %s", environment.GetId(), err)
+			failed = true
+			continue
+		}
+
+		client, err := rest.NewManagedDynatraceClient(environment.GetEnvironmentUrl(), apiToken, environment.GetManagedClusterUrl(), clusterToken)
+		if err != nil {
+			util.Log.Error("Could not compare environment %s: %s", environment.GetId(), err)
+			failed = true
+			continue
+		}
+
+		diffs, errs := diffEnvironment(client, environment, projects, workingDir, ignored)
+		printDiffs(environment, diffs)
+
+		if len(errs) > 0 {
+			util.Log.Error("Comparison of %s failed with %d error(s):", environment.GetId(), len(errs))
+			util.PrintErrors(errs)
+			failed = true
+		}
+
+		for _, diff := range diffs {
+			foundDifferences = foundDifferences || diff.hasDifferences()
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("Errors during comparison! Check log!")
+	}
+
+	if foundDifferences && failOnDiff {
+		return fmt.Errorf("Differences between local and live configs found")
+	}
+
+	return nil
+}
+
+// diffEnvironment compares all configs of the projects, in order of their dependencies, with the configs in the
+// environment. References to other configs are resolved using the ids of the live configs.
+func diffEnvironment(client rest.DynatraceClient, environment environment.Environment, projects []project.Project,
+	path string, ignored ignoreList) (diffs []configDiff, errs []error) {
+
+	dict := make(map[string]api.DynatraceEntity)
+
+	for _, project := range projects {
+		for _, config := range project.GetConfigs() {
+			if config.IsSkipDeployment(environment) {
+				continue
+			}
+
+			theApi := config.GetApi()
+
+			if theApi.GetId() == "extension" {
+				util.Log.Debug("\tSkipping %s: comparing extensions is not supported", config.GetFullQualifiedId())
+				continue
+			}
+
+			objectName, err := config.GetObjectNameForEnvironment(environment, dict)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", config.GetFullQualifiedId(), err))
+				continue
+			}
+
+			local, err := config.GetConfigForEnvironment(environment, dict)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", config.GetFullQualifiedId(), err))
+				continue
+			}
+
+			referenceId := strings.TrimPrefix(config.GetFullQualifiedId(), path+"/")
+			diff := configDiff{config: config.GetFullQualifiedId()}
+
+			live, id, err := readLiveConfig(client, theApi, objectName)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", config.GetFullQualifiedId(), err))
+				continue
+			}
+
+			if live == nil {
+				diff.missing = true
+				// configs referencing this one would use the id assigned on creation
+				dict[referenceId] = api.DynatraceEntity{Id: fmt.Sprintf("<id of %s after creation>", objectName), Name: objectName, Payload: local, Planned: true}
+			} else {
+				diff.changes, err = compareJson(local, live, ignored)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", config.GetFullQualifiedId(), err))
+					continue
+				}
+				// the live config is what the API returns for the deployed config
+				dict[referenceId] = api.DynatraceEntity{Id: id, Name: objectName, Payload: local, Response: live}
+			}
+
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs, errs
+}
+
+// readLiveConfig returns the json of the config with the given name. If the config doesn't exist, nil is returned.
+func readLiveConfig(client rest.DynatraceClient, theApi api.Api, objectName string) (json []byte, id string, err error) {
+	if theApi.IsSingleConfigurationApi() {
+		json, err = client.ReadById(theApi, "")
+		return json, theApi.GetId(), err
+	}
+
+	exists, id, err := client.ExistsByName(theApi, objectName)
+	if err != nil || !exists {
+		return nil, "", err
+	}
+
+	json, err = client.ReadById(theApi, id)
+	return json, id, err
+}
+
+func printDiffs(environment environment.Environment, diffs []configDiff) {
+	util.Log.Info("Environment %s:", environment.GetId())
+
+	for _, diff := range diffs {
+		switch {
+		case diff.missing:
+			util.Log.Info("\t%s: does not exist, would be created", diff.config)
+		case len(diff.changes) == 0:
+			util.Log.Info("\t%s: no differences", diff.config)
+		default:
+			util.Log.Info("\t%s: %d difference(s)", diff.config, len(diff.changes))
+			for _, change := range diff.changes {
+				util.Log.Info("\t\t%s", change)
+			}
+		}
+	}
+}
+
+func sortedEnvironments(environments map[string]environment.Environment) []environment.Environment {
+	ids := make([]string, 0, len(environments))
+	for id := range environments {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	sorted := make([]environment.Environment, 0, len(ids))
+	for _, id := range ids {
+		sorted = append(sorted, environments[id])
+	}
+	return sorted
+}
@@ -0,0 +1,110 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+var (
+	testProfileApi = api.NewStandardApi("alerting-profile", "/api/config/v1/alertingProfiles")
+	testMetricApi  = api.NewStandardApi("calculated-metrics-log", "/api/config/v1/calculatedMetrics/log")
+)
+
+func writeTestProject(t *testing.T, fs afero.Fs) {
+	files := map[string]string{
+		"project/alerting-profile/profile.yaml": `
+config:
+  - profile: "profile.json"
+
+profile:
+  - name: "Profile"
+`,
+		"project/alerting-profile/profile.json": `{"name": "{{.name}}", "rules": []}`,
+		"project/calculated-metrics-log/metric.yaml": `
+config:
+  - metric: "metric.json"
+
+metric:
+  - name: "Metric"
+  - profile: "project/alerting-profile/profile.id"
+`,
+		"project/calculated-metrics-log/metric.json": `{"name": "{{.name}}", "profile": "{{.profile}}"}`,
+	}
+
+	for file, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, file, []byte(content), 0644))
+	}
+}
+
+func loadTestProjects(t *testing.T) []project.Project {
+	fs := afero.NewMemMapFs()
+	writeTestProject(t, fs)
+
+	apis := map[string]api.Api{testProfileApi.GetId(): testProfileApi, testMetricApi.GetId(): testMetricApi}
+	projects, err := project.LoadProjectsToDeploy(fs, "project", apis, ".", util.DefaultLogger())
+	assert.NilError(t, err)
+	return projects
+}
+
+func TestDiffEnvironmentResolvesReferencesUsingLiveIds(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "Profile").Return(true, "profile-id", nil)
+	client.EXPECT().ReadById(testProfileApi, "profile-id").Return([]byte(`{"id": "profile-id", "name": "Profile", "rules": []}`), nil)
+	client.EXPECT().ExistsByName(testMetricApi, "Metric").Return(true, "metric-id", nil)
+	client.EXPECT().ReadById(testMetricApi, "metric-id").Return([]byte(`{"name": "Metric", "profile": "other-id"}`), nil)
+
+	diffs, errs := diffEnvironment(client, environment, loadTestProjects(t), ".", newIgnoreList(DefaultIgnoredFields))
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(diffs), 2)
+
+	assert.Equal(t, diffs[0].config, "project/alerting-profile/profile")
+	assert.Equal(t, diffs[0].hasDifferences(), false)
+
+	assert.Equal(t, diffs[1].config, "project/calculated-metrics-log/metric")
+	assert.DeepEqual(t, changeStrings(diffs[1].changes), []string{`~ profile: "other-id" -> "profile-id"`})
+}
+
+func TestDiffEnvironmentReportsMissingConfigs(t *testing.T) {
+	environment := environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV")
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ExistsByName(testProfileApi, "Profile").Return(false, "", nil)
+	client.EXPECT().ExistsByName(testMetricApi, "Metric").Return(true, "metric-id", nil)
+	client.EXPECT().ReadById(testMetricApi, "metric-id").Return([]byte(`{"name": "Metric", "profile": "other-id"}`), nil)
+
+	diffs, errs := diffEnvironment(client, environment, loadTestProjects(t), ".", newIgnoreList(DefaultIgnoredFields))
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(diffs), 2)
+
+	assert.Equal(t, diffs[0].missing, true)
+	assert.Equal(t, diffs[0].hasDifferences(), true)
+	assert.DeepEqual(t, changeStrings(diffs[1].changes), []string{`~ profile: "other-id" -> "<id of Profile after creation>"`})
+}
@@ -0,0 +1,190 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultIgnoredFields are the server-managed fields, which are ignored if no other fields are configured
+var DefaultIgnoredFields = []string{"id", "metadata"}
+
+// ChangeKind describes how a field differs between the local and the live config
+type ChangeKind string
+
+const (
+	// FieldAdded is a field which only exists in the local config
+	FieldAdded ChangeKind = "+"
+	// FieldRemoved is a field which only exists in the live config
+	FieldRemoved ChangeKind = "-"
+	// FieldChanged is a field with different values in the local and live config
+	FieldChanged ChangeKind = "~"
+)
+
+// Change is a single difference between the local and the live config. The path of the field separates the keys of
+// nested objects by dots and adds the indices of array elements, e.g. "rules[0].enabled".
+type Change struct {
+	Kind  ChangeKind  `json:"kind"`
+	Path  string      `json:"path"`
+	Local interface{} `json:"local,omitempty"`
+	Live  interface{} `json:"live,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case FieldAdded:
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Path, formatValue(c.Local))
+	case FieldRemoved:
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Path, formatValue(c.Live))
+	default:
+		return fmt.Sprintf("%s %s: %s -> %s", c.Kind, c.Path, formatValue(c.Live), formatValue(c.Local))
+	}
+}
+
+func formatValue(value interface{}) string {
+	var formatted bytes.Buffer
+
+	encoder := json.NewEncoder(&formatted)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return strings.TrimSpace(formatted.String())
+}
+
+var arrayIndexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// ignoreList contains the fields which are excluded from the diff. A field is ignored if an entry matches its
+// name (e.g. "id") or its path without array indices (e.g. "tiles.bounds").
+type ignoreList map[string]bool
+
+func newIgnoreList(fields []string) ignoreList {
+	ignored := make(ignoreList)
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			ignored[field] = true
+		}
+	}
+	return ignored
+}
+
+func (l ignoreList) ignores(name string, path string) bool {
+	return l[name] || l[arrayIndexPattern.ReplaceAllString(path, "")]
+}
+
+// CompareJson returns the differences between the local and live json, sorted by path. Fields are ignored like in
+// the diff command, either by name or by their path without array indices.
+func CompareJson(local []byte, live []byte, ignoredFields []string) ([]Change, error) {
+	return compareJson(local, live, newIgnoreList(ignoredFields))
+}
+
+// compareJson returns the differences between the local and live json, sorted by path
+func compareJson(local []byte, live []byte, ignored ignoreList) ([]Change, error) {
+	var localValue, liveValue interface{}
+
+	if err := json.Unmarshal(local, &localValue); err != nil {
+		return nil, fmt.Errorf("could not parse local config: %w", err)
+	}
+	if err := json.Unmarshal(live, &liveValue); err != nil {
+		return nil, fmt.Errorf("could not parse live config: %w", err)
+	}
+
+	return compareValues("", localValue, liveValue, ignored), nil
+}
+
+func compareValues(path string, local interface{}, live interface{}, ignored ignoreList) []Change {
+	localObject, localIsObject := local.(map[string]interface{})
+	liveObject, liveIsObject := live.(map[string]interface{})
+	if localIsObject && liveIsObject {
+		return compareObjects(path, localObject, liveObject, ignored)
+	}
+
+	localArray, localIsArray := local.([]interface{})
+	liveArray, liveIsArray := live.([]interface{})
+	if localIsArray && liveIsArray {
+		return compareArrays(path, localArray, liveArray, ignored)
+	}
+
+	if reflect.DeepEqual(local, live) {
+		return nil
+	}
+	return []Change{{Kind: FieldChanged, Path: path, Local: local, Live: live}}
+}
+
+func compareObjects(path string, local map[string]interface{}, live map[string]interface{}, ignored ignoreList) []Change {
+	keys := make([]string, 0, len(local)+len(live))
+	for key := range local {
+		keys = append(keys, key)
+	}
+	for key := range live {
+		if _, found := local[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := make([]Change, 0)
+	for _, key := range keys {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+
+		if ignored.ignores(key, fieldPath) {
+			continue
+		}
+
+		localValue, inLocal := local[key]
+		liveValue, inLive := live[key]
+
+		switch {
+		case !inLive:
+			changes = append(changes, Change{Kind: FieldAdded, Path: fieldPath, Local: localValue})
+		case !inLocal:
+			changes = append(changes, Change{Kind: FieldRemoved, Path: fieldPath, Live: liveValue})
+		default:
+			changes = append(changes, compareValues(fieldPath, localValue, liveValue, ignored)...)
+		}
+	}
+	return changes
+}
+
+func compareArrays(path string, local []interface{}, live []interface{}, ignored ignoreList) []Change {
+	changes := make([]Change, 0)
+
+	for i := 0; i < len(local) || i < len(live); i++ {
+		elementPath := fmt.Sprintf("%s[%d]", path, i)
+
+		switch {
+		case i >= len(live):
+			changes = append(changes, Change{Kind: FieldAdded, Path: elementPath, Local: local[i]})
+		case i >= len(local):
+			changes = append(changes, Change{Kind: FieldRemoved, Path: elementPath, Live: live[i]})
+		default:
+			changes = append(changes, compareValues(elementPath, local[i], live[i], ignored)...)
+		}
+	}
+	return changes
+}
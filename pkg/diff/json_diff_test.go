@@ -0,0 +1,91 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func changeStrings(changes []Change) []string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return lines
+}
+
+func TestCompareJsonWithoutDifferences(t *testing.T) {
+	changes, err := compareJson([]byte(`{"name": "a", "tags": ["x", "y"]}`), []byte(`{"tags": ["x", "y"], "name": "a"}`), newIgnoreList(nil))
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 0)
+}
+
+func TestCompareJsonReportsAddedRemovedAndChangedFields(t *testing.T) {
+	local := `{"name": "new", "enabled": true, "rules": [{"key": "a"}, {"key": "b"}], "nested": {"value": 1}}`
+	live := `{"name": "old", "description": "server default", "rules": [{"key": "a"}], "nested": {"value": 2}}`
+
+	changes, err := compareJson([]byte(local), []byte(live), newIgnoreList(nil))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, changeStrings(changes), []string{
+		`- description: "server default"`,
+		`+ enabled: true`,
+		`~ name: "old" -> "new"`,
+		`~ nested.value: 2 -> 1`,
+		`+ rules[1]: {"key":"b"}`,
+	})
+}
+
+func TestCompareJsonIgnoresFieldsByNameAndPath(t *testing.T) {
+	local := `{"name": "a", "tiles": [{"name": "t", "bounds": {"top": 1}}]}`
+	live := `{"id": "42", "metadata": {"version": "1.2"}, "name": "a", "tiles": [{"name": "t", "bounds": {"top": 2}}]}`
+
+	changes, err := compareJson([]byte(local), []byte(live), newIgnoreList(DefaultIgnoredFields))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, changeStrings(changes), []string{`~ tiles[0].bounds.top: 2 -> 1`})
+
+	changes, err = compareJson([]byte(local), []byte(live), newIgnoreList([]string{"id", "metadata", "tiles.bounds"}))
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 0)
+}
+
+func TestCompareJsonFailsOnInvalidJson(t *testing.T) {
+	_, err := compareJson([]byte(`{`), []byte(`{}`), newIgnoreList(nil))
+	assert.ErrorContains(t, err, "could not parse local config")
+
+	_, err = compareJson([]byte(`{}`), []byte(`<html>`), newIgnoreList(nil))
+	assert.ErrorContains(t, err, "could not parse live config")
+}
+
+func TestCompareJsonWithIgnoredFieldsReturnsChangesAsJson(t *testing.T) {
+	local := `{"name": "a", "rules": [{"key": "a", "threshold": {"value": 10}}]}`
+	live := `{"id": "42", "metadata": {"version": "1.2"}, "name": "a", "rules": [{"key": "a", "threshold": {"value": 5}}]}`
+
+	changes, err := CompareJson([]byte(local), []byte(live), DefaultIgnoredFields)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, changes, []Change{{Kind: FieldChanged, Path: "rules[0].threshold.value", Local: float64(10), Live: float64(5)}})
+
+	content, err := json.Marshal(changes)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `[{"kind":"~","path":"rules[0].threshold.value","local":10,"live":5}]`)
+}
@@ -0,0 +1,117 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/jsoncreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/yamlcreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// createConfigsFromAutomationAPI downloads the objects of an automation API, e.g. workflows, into the folder of the
+// api. Objects are named by their title, which is made unique, as titles of different objects may be equal. Their ids
+// are not kept, as a deployment derives the id of an object from its config.
+func createConfigsFromAutomationAPI(
+	fs afero.Fs,
+	theApi api.Api,
+	fullpath string,
+	client rest.DynatraceClient,
+	ycreator yamlcreator.YamlCreator,
+	pool *workerPool,
+	failures *downloadFailures,
+	filter *nameFilter,
+	downloaded *downloadedConfigs,
+	log *util.Logger,
+) (err error) {
+	var objects []api.DownloadedAutomationObject
+	pool.do(func() {
+		objects, err = client.ListAutomations(theApi)
+	})
+	if err != nil {
+		log.Error("error getting objects from api %v %v", theApi.GetId(), err)
+		return err
+	}
+
+	// the order of the list is not stable, so the objects are sorted to always write the yaml file in the same order
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].Title != objects[j].Title {
+			return objects[i].Title < objects[j].Title
+		}
+		return objects[i].Id < objects[j].Id
+	})
+
+	var subPath string
+	names := make(map[string]struct{})
+	count := 0
+
+	for _, object := range objects {
+		name := object.Title
+		if name == "" {
+			name = object.Id
+		}
+		if !filter.matches(name) {
+			continue
+		}
+
+		if subPath == "" {
+			subPath, err = createConfigsFolder(fs, theApi, fullpath)
+			if err != nil {
+				log.Error("error creating folder for api %v %v", theApi.GetId(), err)
+				return err
+			}
+		}
+
+		name, cleanName := uniqueSettingsName(name, names)
+
+		content, parameters, err := jsoncreator.ProcessAutomationObject(theApi.GetId(), object.Payload)
+		if err != nil {
+			failures.add(theApi.GetId(), name, fmt.Errorf("invalid object %s: %w", object.Id, err))
+			continue
+		}
+
+		err = afero.WriteFile(fs, filepath.Join(subPath, cleanName+".json"), content, 0664)
+		if err != nil {
+			failures.add(theApi.GetId(), name, err)
+			continue
+		}
+
+		ycreator.AddConfig(cleanName, name, parameters)
+		downloaded.add(theApi.GetId(), object.Id, cleanName, parameters)
+		count++
+	}
+
+	if count == 0 {
+		log.Info("No elements for API %s", theApi.GetId())
+		return nil
+	}
+
+	err = ycreator.CreateYamlFile(fs, subPath, theApi.GetId())
+	if err != nil {
+		log.Error("error creating config api yaml file: %v", err)
+		return err
+	}
+
+	downloaded.addYaml(theApi.GetId(), subPath, ycreator)
+	return nil
+}
@@ -0,0 +1,146 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/yamlcreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestCreateConfigsFromAutomationAPIDownloadsWorkflowsReferencingRules(t *testing.T) {
+	server := rest.NewAutomationServer()
+	server.Add("/platform/automation/v1/scheduling-rules", `{"id": "rule-id", "title": "Weekdays", "ruleType": "recurrence", "modificationInfo": {"lastModifiedTime": "2022-06-10T12:00:00Z"}}`)
+	server.Add("/platform/automation/v1/workflows", `{"id": "workflow-b", "title": "Daily report", "owner": "user", "ownerType": "USER", "actor": "user", "tasks": {"report": {"action": "dynatrace.automations:run-javascript"}}, "trigger": {"schedule": {"rule": "rule-id"}}}`)
+	server.Add("/platform/automation/v1/workflows", `{"id": "workflow-a", "title": "Daily report", "tasks": {}}`)
+	client, closeServer := rest.NewAutomationTestClient(server)
+	defer closeServer()
+
+	apis := api.NewApis()
+	fs := afero.NewMemMapFs()
+	failures := &downloadFailures{}
+	downloaded := newDownloadedConfigs()
+
+	for _, id := range []string{"scheduling-rule", "workflow"} {
+		err := createConfigsFromAutomationAPI(fs, apis[id], "project", client, yamlcreator.NewYamlConfig(util.DefaultLogger()), newWorkerPool(context.Background(), 1),
+			failures, nil, downloaded, util.DefaultLogger())
+		assert.NilError(t, err)
+	}
+	downloaded.writeResolvedYamls(fs, "project", failures, util.DefaultLogger())
+	assert.Equal(t, len(failures.sorted()), 0)
+
+	content, err := afero.ReadFile(fs, filepath.Join("project", "workflow", "workflow.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `config:
+- Dailyreport: Dailyreport.json
+- Dailyreport-2: Dailyreport-2.json
+Dailyreport:
+- name: Daily report
+Dailyreport-2:
+- name: Daily report (2)
+  schedulingRuleId: /project/scheduling-rule/Weekdays.id
+`)
+
+	content, err = afero.ReadFile(fs, filepath.Join("project", "workflow", "Dailyreport-2.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `{
+ "tasks": {
+  "report": {
+   "action": "dynatrace.automations:run-javascript"
+  }
+ },
+ "title": "{{.name}}",
+ "trigger": {
+  "schedule": {
+   "rule": "{{.schedulingRuleId}}"
+  }
+ }
+}
+`)
+
+	content, err = afero.ReadFile(fs, filepath.Join("project", "scheduling-rule", "Weekdays.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "{\n \"ruleType\": \"recurrence\",\n \"title\": \"{{.name}}\"\n}\n")
+}
+
+func TestDownloadedWorkflowWithExpressionsIsDeployedUnchanged(t *testing.T) {
+	tasks := `{"notify": {"action": "dynatrace.slack:message", "input": {"message": "{{ result(\"report\").count }} problems since {{ event()[\"timestamp\"] }}"}}}`
+
+	server := rest.NewAutomationServer()
+	server.Add("/platform/automation/v1/workflows", `{"id": "workflow-id", "title": "Daily report", "tasks": `+tasks+`}`)
+	client, closeServer := rest.NewAutomationTestClient(server)
+	defer closeServer()
+
+	workflows := api.NewApis()["workflow"]
+	fs := afero.NewMemMapFs()
+	failures := &downloadFailures{}
+	downloaded := newDownloadedConfigs()
+
+	err := createConfigsFromAutomationAPI(fs, workflows, "project", client, yamlcreator.NewYamlConfig(util.DefaultLogger()), newWorkerPool(context.Background(), 1),
+		failures, nil, downloaded, util.DefaultLogger())
+	assert.NilError(t, err)
+	downloaded.writeResolvedYamls(fs, "project", failures, util.DefaultLogger())
+	assert.Equal(t, len(failures.sorted()), 0)
+
+	content, err := afero.ReadFile(fs, filepath.Join("project", "workflow", "Dailyreport.json"))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(content), "{{`{{`}} result(\\\"report\\\").count {{`}}`}}"), string(content))
+
+	workflow, err := config.NewConfig(fs, "Dailyreport", "project", filepath.Join("project", "workflow", "Dailyreport.json"),
+		map[string]map[string]string{"Dailyreport": {"name": "Daily report"}}, workflows)
+	assert.NilError(t, err)
+	payload, err := workflow.GetConfigForEnvironment(environment.NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"), nil)
+	assert.NilError(t, err)
+
+	_, err = client.UpsertAutomation(workflows, "workflow-id", "Daily report", payload)
+	assert.NilError(t, err)
+
+	var expected map[string]interface{}
+	assert.NilError(t, json.Unmarshal([]byte(tasks), &expected))
+	deployed := server.Objects("/platform/automation/v1/workflows")
+	assert.Equal(t, len(deployed), 1)
+	assert.DeepEqual(t, deployed[0]["tasks"], expected)
+	assert.Equal(t, deployed[0]["title"], "Daily report")
+}
+
+func TestCreateConfigsFromAutomationAPIWithoutObjectsCreatesNoFolder(t *testing.T) {
+	client, closeServer := rest.NewAutomationTestClient(rest.NewAutomationServer())
+	defer closeServer()
+
+	fs := afero.NewMemMapFs()
+	err := createConfigsFromAutomationAPI(fs, api.NewApis()["workflow"], "project", client, yamlcreator.NewYamlConfig(util.DefaultLogger()), newWorkerPool(context.Background(), 1),
+		&downloadFailures{}, nil, newDownloadedConfigs(), util.DefaultLogger())
+	assert.NilError(t, err)
+
+	exists, err := afero.DirExists(fs, filepath.Join("project", "workflow"))
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+}
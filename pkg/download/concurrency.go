@@ -0,0 +1,174 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// workerPool limits the number of download tasks executed concurrently
+type workerPool struct {
+	ctx     context.Context
+	workers chan struct{}
+}
+
+func newWorkerPool(ctx context.Context, size int) *workerPool {
+	return &workerPool{ctx: ctx, workers: make(chan struct{}, size)}
+}
+
+// do executes the task as soon as a worker is available and waits for it to finish. Once the context of the pool is
+// cancelled, tasks are skipped.
+func (p *workerPool) do(task func()) {
+	p.workers <- struct{}{}
+	defer func() { <-p.workers }()
+
+	if p.ctx.Err() != nil {
+		return
+	}
+	task()
+}
+
+// synchronizedFs serializes writes to the same file: a file opened for writing is locked until it is closed.
+// This prevents concurrent downloads of configs with the same name from corrupting each other's files.
+type synchronizedFs struct {
+	afero.Fs
+
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newSynchronizedFs(fs afero.Fs) *synchronizedFs {
+	return &synchronizedFs{
+		Fs:    fs,
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+func (s *synchronizedFs) lockFor(name string) *sync.Mutex {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	name = filepath.Clean(name)
+	if s.locks[name] == nil {
+		s.locks[name] = &sync.Mutex{}
+	}
+	return s.locks[name]
+}
+
+func (s *synchronizedFs) Create(name string) (afero.File, error) {
+	return s.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (s *synchronizedFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) == 0 {
+		return s.Fs.OpenFile(name, flag, perm)
+	}
+
+	lock := s.lockFor(name)
+	lock.Lock()
+
+	file, err := s.Fs.OpenFile(name, flag, perm)
+	if err != nil {
+		lock.Unlock()
+		return nil, err
+	}
+
+	return &lockedFile{File: file, unlock: lock.Unlock}, nil
+}
+
+// lockedFile releases the lock of the file when it is closed
+type lockedFile struct {
+	afero.File
+
+	once   sync.Once
+	unlock func()
+}
+
+func (f *lockedFile) Close() error {
+	err := f.File.Close()
+	f.once.Do(f.unlock)
+	return err
+}
+
+// downloadFailure is a failed download of an api or a single config
+type downloadFailure struct {
+	api    string
+	object string
+	err    error
+}
+
+// downloadFailures collects the failures of an environment, to report them together at the end of the download.
+// It is safe for concurrent use.
+type downloadFailures struct {
+	mutex    sync.Mutex
+	failures []downloadFailure
+}
+
+func (f *downloadFailures) add(api string, object string, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.failures = append(f.failures, downloadFailure{api: api, object: object, err: err})
+}
+
+// sorted returns all failures, sorted by api and object
+func (f *downloadFailures) sorted() []downloadFailure {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	sorted := make([]downloadFailure, len(f.failures))
+	copy(sorted, f.failures)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].api != sorted[j].api {
+			return sorted[i].api < sorted[j].api
+		}
+		return sorted[i].object < sorted[j].object
+	})
+	return sorted
+}
+
+func (f *downloadFailures) print(log *util.Logger, environment string) {
+	failures := f.sorted()
+	if len(failures) == 0 {
+		return
+	}
+
+	log.Error("Download of environment %s finished with %d error(s):", environment, len(failures))
+	errs := make([]error, 0, len(failures))
+	for _, failure := range failures {
+		log.Error("\t%s", failure)
+		errs = append(errs, failure.err)
+	}
+
+	rest.PrintMissingScopes(log, environment, errs)
+}
+
+func (f downloadFailure) String() string {
+	if f.object == "" {
+		return fmt.Sprintf("%s: %s", f.api, f.err)
+	}
+	return fmt.Sprintf("%s %s: %s", f.api, f.object, f.err)
+}
@@ -0,0 +1,120 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestWorkerPoolLimitsConcurrentTasks(t *testing.T) {
+	pool := newWorkerPool(context.Background(), 2)
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pool.do(func() {
+				current := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.Assert(t, maxRunning <= 2, "%d tasks ran concurrently", maxRunning)
+}
+
+func TestWorkerPoolSkipsTasksOnceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := newWorkerPool(ctx, 1)
+
+	executed := 0
+	pool.do(func() {
+		executed++
+		cancel()
+	})
+	pool.do(func() {
+		executed++
+	})
+
+	assert.Equal(t, executed, 1)
+}
+
+func TestSynchronizedFsSerializesWritesToTheSameFile(t *testing.T) {
+	fs := newSynchronizedFs(afero.NewMemMapFs())
+
+	var wg sync.WaitGroup
+	for _, content := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+		content := content
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			file, err := fs.Create("config.json")
+			assert.NilError(t, err)
+			defer file.Close()
+
+			for _, c := range content {
+				_, err := file.WriteString(string(c))
+				assert.NilError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	content, err := afero.ReadFile(fs, "config.json")
+	assert.NilError(t, err)
+	assert.Equal(t, len(content), 4)
+	assert.Equal(t, strings.Count(string(content), string(content[0])), 4, "writes were interleaved: %s", content)
+}
+
+func TestDownloadFailuresAreSortedByApiAndObject(t *testing.T) {
+	failures := &downloadFailures{}
+	failures.add("dashboard", "b", errors.New("failed"))
+	failures.add("alerting-profile", "", errors.New("failed"))
+	failures.add("dashboard", "a", errors.New("failed"))
+
+	sorted := failures.sorted()
+
+	assert.Equal(t, len(sorted), 3)
+	assert.Equal(t, sorted[0].String(), "alerting-profile: failed")
+	assert.Equal(t, sorted[1].String(), "dashboard a: failed")
+	assert.Equal(t, sorted[2].String(), "dashboard b: failed")
+}
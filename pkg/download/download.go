@@ -15,9 +15,14 @@
 package download
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/jsoncreator"
@@ -28,46 +33,102 @@ import (
 	"github.com/spf13/afero"
 )
 
-var cont = 0
+var cont int64 = 0
 
-//GetConfigsFilterByEnvironment filters the enviroments list based on specificEnvironment flag value
-func GetConfigsFilterByEnvironment(workingDir string, fs afero.Fs, environmentsFile string,
-	specificEnvironment string, downloadSpecificAPI string) error {
-	environments, errors := environment.LoadEnvironmentList(specificEnvironment, environmentsFile, fs)
+// GetConfigsFilterByEnvironment filters the enviroments list based on specificEnvironment flag value. Once the context
+// is cancelled, no further configs are downloaded. If since is set, only objects modified afterwards are downloaded of
+// the APIs exposing modification times, see newSinceFilter.
+func GetConfigsFilterByEnvironment(ctx context.Context, workingDir string, fs afero.Fs, environmentsFile string,
+	specificEnvironment string, downloadSpecificAPI string, excludeAPI string, parallel int, namePattern string, tags []string,
+	since string, log *util.Logger) error {
+	if parallel < 1 {
+		return fmt.Errorf("invalid number of parallel downloads %d: needs to be at least 1", parallel)
+	}
+
+	filter, err := newNameFilter(namePattern)
+	if err != nil {
+		return err
+	}
+
+	tagFilter, err := newTagFilter(tags)
+	if err != nil {
+		return err
+	}
+
+	sinceFilter, err := newSinceFilter(since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	environments, errors := environment.LoadEnvironmentList(specificEnvironment, environmentsFile, fs, log)
 	if len(errors) > 0 {
 		for _, err := range errors {
-			util.Log.Error("Error while getting enviroments ", err)
+			log.Error("Error while getting enviroments ", err)
 		}
 		return fmt.Errorf("There were some errors while getting environment files")
 	}
-	return getConfigs(fs, workingDir, environments, downloadSpecificAPI)
+	return getConfigs(ctx, fs, workingDir, environments, downloadSpecificAPI, excludeAPI, parallel, filter, tagFilter, sinceFilter, log)
 
 }
 
-//getConfigs Entry point that retrieves the specified configurations from a Dynatrace tenant
-func getConfigs(fs afero.Fs, workingDir string, environments map[string]environment.Environment, downloadSpecificAPI string) error {
-	list, err := getAPIList(downloadSpecificAPI)
+// getConfigs Entry point that retrieves the specified configurations from a Dynatrace tenant
+func getConfigs(ctx context.Context, fs afero.Fs, workingDir string, environments map[string]environment.Environment, downloadSpecificAPI string,
+	excludeAPI string, parallel int, filter *nameFilter, tagFilter *tagFilter, sinceFilter *sinceFilter, log *util.Logger) error {
+	list, err := getAPIList(downloadSpecificAPI, excludeAPI, log)
 	if err != nil {
 		return err
 	}
-	isError := false
+	var environmentErr error
 	for _, environment := range environments {
+		if ctx.Err() != nil {
+			log.Warn("Skipping environment %s, as the download was interrupted", environment.GetId())
+			continue
+		}
+
 		//download configs for each environment
-		err := downloadConfigFromEnvironment(fs, environment, workingDir, list)
+		err := downloadConfigFromEnvironment(ctx, fs, environment, workingDir, list, parallel, filter, tagFilter, sinceFilter, log)
 		if err != nil {
-			util.Log.Error("error while downloading configs for environment %v %v", environment.GetId())
-			isError = true
+			log.Error("error while downloading configs for environment %v %v", environment.GetId(), err)
+			if environmentErr == nil {
+				environmentErr = err
+			}
 		}
 	}
-	if isError {
-		return fmt.Errorf("There were some errors while downloading the environment configs, please check the logs")
+	if ctx.Err() != nil {
+		return fmt.Errorf("Download was interrupted: %w", ctx.Err())
+	}
+	// the error of the first failed environment is wrapped, so callers can tell e.g. a rejected token using errors.As
+	if environmentErr != nil {
+		return fmt.Errorf("There were some errors while downloading the environment configs, please check the logs: %w", environmentErr)
 	}
 	return nil
 
 }
 
-//returns the list of API filter if the download specific flag is used, otherwise returns all the API's
-func getAPIList(downloadSpecificAPI string) (filterAPIList map[string]api.Api, err error) {
+// returns the list of API filter if the download specific flag is used, otherwise returns all the API's.
+// APIs of the exclude list are removed from the result, even if they are part of the download specific list.
+func getAPIList(downloadSpecificAPI string, excludeAPI string, log *util.Logger) (filterAPIList map[string]api.Api, err error) {
+	filterAPIList, err = getIncludedAPIList(downloadSpecificAPI, log)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range strings.Split(excludeAPI, ",") {
+		cleanAPI := strings.TrimSpace(id)
+		if cleanAPI == "" {
+			continue
+		}
+		if !api.IsApi(cleanAPI) {
+			log.Warn("Value %s is not a valid API name and can not be excluded", cleanAPI)
+			continue
+		}
+		delete(filterAPIList, cleanAPI)
+	}
+
+	return filterAPIList, nil
+}
+
+func getIncludedAPIList(downloadSpecificAPI string, log *util.Logger) (filterAPIList map[string]api.Api, err error) {
 	availableApis := api.NewApis()
 	noFilterAPIListProvided := strings.TrimSpace(downloadSpecificAPI) == ""
 
@@ -81,7 +142,7 @@ func getAPIList(downloadSpecificAPI string) (filterAPIList map[string]api.Api, e
 		cleanAPI := strings.TrimSpace(id)
 		isAPI := api.IsApi(cleanAPI)
 		if !isAPI {
-			util.Log.Error("Value %s is not a valid API name", cleanAPI)
+			log.Error("Value %s is not a valid API name", cleanAPI)
 			isErr = true
 		} else {
 			filterAPI := availableApis[cleanAPI]
@@ -95,47 +156,126 @@ func getAPIList(downloadSpecificAPI string) (filterAPIList map[string]api.Api, e
 	return filterAPIList, nil
 }
 
-//creates the project and downloads the configs
-func downloadConfigFromEnvironment(fs afero.Fs, environment environment.Environment, basepath string, listApis map[string]api.Api) (err error) {
+// creates the project and downloads the configs. Up to parallel configs are downloaded concurrently.
+// Only configs with a name matching the filter and tags matching the tag filter are downloaded. APIs whose configs don't
+// carry tags are skipped if a tag filter is set. Once the context is cancelled, no further APIs and configs are
+// downloaded, downloads in progress are finished.
+func downloadConfigFromEnvironment(ctx context.Context, fs afero.Fs, environment environment.Environment, basepath string, listApis map[string]api.Api,
+	parallel int, filter *nameFilter, tagFilter *tagFilter, sinceFilter *sinceFilter, log *util.Logger) (err error) {
+
 	projectName := environment.GetId()
 	path := filepath.Join(basepath, projectName)
 
-	util.Log.Info("Creating base project name %s", projectName)
+	log.Info("Creating base project name %s", projectName)
 	err = fs.MkdirAll(path, 0777)
 	if err != nil {
-		util.Log.Error("error creating folder for enviroment %v %v", projectName, err)
+		log.Error("error creating folder for enviroment %v %v", projectName, err)
 		return err
 	}
 	token, err := environment.GetToken()
 	if err != nil {
-		util.Log.Error("error retrieving token for enviroment %v %v", projectName, err)
+		log.Error("error retrieving token for enviroment %v %v", projectName, err)
 		return err
 	}
-	client, err := rest.NewDynatraceClient(environment.GetEnvironmentUrl(), token)
+	clusterToken, err := environment.GetManagedClusterToken()
 	if err != nil {
-		util.Log.Error("error creating dynatrace client for enviroment %v %v", projectName, err)
+		log.Error("error retrieving cluster token for enviroment %v %v", projectName, err)
 		return err
 	}
-	for _, api := range listApis {
-		util.Log.Info(" --- GETTING CONFIGS for %s", api.GetId())
-		jcreator := jsoncreator.NewJSONCreator()
-		ycreator := yamlcreator.NewYamlConfig()
+	client, err := rest.NewManagedDynatraceClientWithLogger(environment.GetEnvironmentUrl(), token, environment.GetManagedClusterUrl(), clusterToken, log)
+	if err != nil {
+		log.Error("error creating dynatrace client for enviroment %v %v", projectName, err)
+		return err
+	}
+
+	fs = newSynchronizedFs(fs)
+	pool := newWorkerPool(ctx, parallel)
+	failures := &downloadFailures{}
+	downloaded := newDownloadedConfigs()
+
+	var wg sync.WaitGroup
+	var completed int32
+
+	downloadApi := func(api api.Api) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if tagFilter != nil && !jsoncreator.SupportsTags(api.GetId()) {
+			log.Warn("Skipping API %s, as its configs don't carry tags to filter by", api.GetId())
+			log.Info(" --- SKIPPED %s (%d/%d APIs)", api.GetId(), atomic.AddInt32(&completed, 1), len(listApis))
+			return
+		}
+
+		if sinceFilter != nil && !api.IsSettingsApi() {
+			log.Warn("Downloading all configs of API %s, as it doesn't expose when configs were modified", api.GetId())
+		}
+
+		log.Info(" --- GETTING CONFIGS for %s", api.GetId())
+		jcreator := jsoncreator.NewJSONCreator(log)
+		if tagFilter != nil {
+			jcreator = jsoncreator.NewTagFilteringJSONCreator(tagFilter.matches, log)
+		}
+		ycreator := yamlcreator.NewYamlConfig(log)
+
+		var errorAPI error
 
 		// Retrieves object from single configuration API
 		isSingleConfigurationApi := api.IsSingleConfigurationApi()
 		if isSingleConfigurationApi {
-			errorAPI := createConfigsFromSingleConfigurationAPI(fs, api, token, path, client, jcreator, ycreator)
-			if errorAPI != nil {
-				util.Log.Error("error getting configs from API %v %v", api.GetId())
-			}
+			errorAPI = createConfigsFromSingleConfigurationAPI(fs, api, token, path, client, jcreator, ycreator, pool, downloaded, log)
+		} else if api.IsSettingsApi() {
+			errorAPI = createConfigsFromSettingsAPI(fs, api, path, client, ycreator, pool, failures, filter, sinceFilter, downloaded, log)
+		} else if api.IsAutomationApi() {
+			errorAPI = createConfigsFromAutomationAPI(fs, api, path, client, ycreator, pool, failures, filter, downloaded, log)
 		} else {
-			errorAPI := createConfigsFromAPI(fs, api, token, path, client, jcreator, ycreator)
-			if errorAPI != nil {
-				util.Log.Error("error getting configs from API %v %v", api.GetId())
-			}
+			errorAPI = createConfigsFromAPI(fs, api, token, path, client, jcreator, ycreator, pool, failures, filter, downloaded, log)
+		}
+
+		if errorAPI != nil {
+			log.Error("error getting configs from API %v for environment %v: %v", api.GetId(), projectName, errorAPI)
+			failures.add(api.GetId(), "", errorAPI)
+		}
+
+		log.Info(" --- FINISHED %s (%d/%d APIs)", api.GetId(), atomic.AddInt32(&completed, 1), len(listApis))
+	}
+
+	for _, theApi := range listApis {
+		if theApi.IsClusterApi() && environment.GetManagedClusterUrl() == "" {
+			log.Debug("Skipping cluster API %s, as no managed-cluster-url is defined for environment %s", theApi.GetId(), projectName)
+			atomic.AddInt32(&completed, 1)
+			continue
+		}
+
+		if theApi.IsPlatformApi() && !rest.HasOAuthCredentials() {
+			log.Debug("Skipping platform API %s, as no OAuth client credentials are configured", theApi.GetId())
+			atomic.AddInt32(&completed, 1)
+			continue
 		}
+
+		if parallel == 1 {
+			downloadApi(theApi)
+			continue
+		}
+
+		theApi := theApi
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			downloadApi(theApi)
+		}()
 	}
-	util.Log.Info("END downloading info %s", projectName)
+
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		log.Warn("Download of %s was interrupted after %d of %d APIs", projectName, atomic.LoadInt32(&completed), len(listApis))
+	}
+
+	downloaded.writeResolvedYamls(fs, projectName, failures, log)
+
+	failures.print(log, projectName)
+	log.Info("END downloading info %s", projectName)
 	return nil
 }
 
@@ -156,31 +296,41 @@ func createConfigsFromSingleConfigurationAPI(
 	client rest.DynatraceClient,
 	jcreator jsoncreator.JSONCreator,
 	ycreator yamlcreator.YamlCreator,
+	pool *workerPool,
+	downloaded *downloadedConfigs,
+	log *util.Logger,
 ) (err error) {
 	subPath, err := createConfigsFolder(fs, api, fullpath)
 	if err != nil {
-		util.Log.Error("error creating folder for api %v %v", api.GetId(), err)
+		log.Error("error creating folder for api %v %v", api.GetId(), err)
 		return err
 	}
 
 	idVal := api.NewIdValue()
 
-	name, cleanName, filter, err := jcreator.CreateJSONConfig(fs, client, api, idVal, subPath)
+	var name, cleanName string
+	var parameters map[string]string
+	var filter bool
+	pool.do(func() {
+		name, cleanName, parameters, filter, err = jcreator.CreateJSONConfig(fs, client, api, idVal, subPath)
+	})
 	if err != nil {
-		util.Log.Error("error creating config api json file: %v", err)
+		log.Error("error creating config api json file: %v", err)
 		return err
 	}
 	if filter {
 		return nil
 	}
 
-	ycreator.AddConfig(cleanName, name)
+	ycreator.AddConfig(cleanName, name, parameters)
 	err = ycreator.CreateYamlFile(fs, subPath, api.GetId())
 	if err != nil {
-		util.Log.Error("error creating config api yaml file: %v", err)
+		log.Error("error creating config api yaml file: %v", err)
 		return err
 	}
 
+	downloaded.add(api.GetId(), idVal.Id, cleanName, parameters)
+	downloaded.addYaml(api.GetId(), subPath, ycreator)
 	return nil
 }
 
@@ -192,41 +342,97 @@ func createConfigsFromAPI(
 	client rest.DynatraceClient,
 	jcreator jsoncreator.JSONCreator,
 	ycreator yamlcreator.YamlCreator,
+	pool *workerPool,
+	failures *downloadFailures,
+	filter *nameFilter,
+	downloaded *downloadedConfigs,
+	log *util.Logger,
 ) (err error) {
 	//retrieves all objects for the specific api
-	values, err := client.List(api)
+	values, err := listValues(client, api, pool)
 	if err != nil {
-		util.Log.Error("error getting client list from api %v %v", api.GetId(), err)
+		log.Error("error getting client list from api %v %v", api.GetId(), err)
 		return err
 	}
 	if len(values) == 0 {
-		util.Log.Info("No elements for API %s", api.GetId())
+		log.Info("No elements for API %s", api.GetId())
 		return nil
 	}
+
+	values = filter.apply(values)
+	if len(values) == 0 {
+		log.Info("No elements matching the name filter for API %s", api.GetId())
+		return nil
+	}
+
+	// the order of the list is not stable, so the configs are sorted to always write the yaml file in the same order
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].Name != values[j].Name {
+			return values[i].Name < values[j].Name
+		}
+		return values[i].Id < values[j].Id
+	})
 	subPath, err := createConfigsFolder(fs, api, fullpath)
 	if err != nil {
-		util.Log.Error("error creating folder for api %v %v", api.GetId(), err)
+		log.Error("error creating folder for api %v %v", api.GetId(), err)
 		return err
 	}
-	for _, val := range values {
-		util.Log.Debug("getting detail %s", val)
-		cont++
-		util.Log.Debug("REQUEST counter %v", cont)
-		name, cleanName, filter, err := jcreator.CreateJSONConfig(fs, client, api, val, subPath)
-		if err != nil {
-			util.Log.Error("error creating config api json file: %v", err)
+
+	type jsonConfig struct {
+		name       string
+		cleanName  string
+		parameters map[string]string
+		filter     bool
+		err        error
+	}
+
+	// configs are downloaded concurrently, but added to the yaml file in the order of the list
+	configs := make([]jsonConfig, len(values))
+
+	var wg sync.WaitGroup
+	for i, val := range values {
+		i, val := i, val
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			pool.do(func() {
+				log.Debug("getting detail %s", val)
+				log.Debug("REQUEST counter %v", atomic.AddInt64(&cont, 1))
+				name, cleanName, parameters, filter, err := jcreator.CreateJSONConfig(fs, client, api, val, subPath)
+				configs[i] = jsonConfig{name: name, cleanName: cleanName, parameters: parameters, filter: filter, err: err}
+			})
+		}()
+	}
+	wg.Wait()
+
+	for i, config := range configs {
+		if config.err != nil {
+			log.Error("error creating config api json file: %v", config.err)
+			failures.add(api.GetId(), values[i].Name, config.err)
 			continue
 		}
-		if filter {
+		if config.filter {
 			continue
 		}
-		ycreator.AddConfig(cleanName, name)
+		ycreator.AddConfig(config.cleanName, config.name, config.parameters)
+		downloaded.add(api.GetId(), values[i].Id, config.cleanName, config.parameters)
 	}
 
 	err = ycreator.CreateYamlFile(fs, subPath, api.GetId())
 	if err != nil {
-		util.Log.Error("error creating config api yaml file: %v", err)
+		log.Error("error creating config api yaml file: %v", err)
 		return err
 	}
+
+	downloaded.addYaml(api.GetId(), subPath, ycreator)
 	return nil
 }
+
+func listValues(client rest.DynatraceClient, theApi api.Api, pool *workerPool) (values []api.Value, err error) {
+	pool.do(func() {
+		values, err = client.List(theApi)
+	})
+	return values, err
+}
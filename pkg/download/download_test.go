@@ -18,7 +18,12 @@
 package download
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
@@ -28,6 +33,7 @@ import (
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
 	"gotest.tools/assert"
 )
 
@@ -37,7 +43,7 @@ func TestGetConfigs(t *testing.T) {
 	envs := make(map[string]environment.Environment)
 	fileManager := util.CreateTestFileSystem()
 	envs["e1"] = env
-	err := getConfigs(fileManager, "", envs, "")
+	err := getConfigs(context.Background(), fileManager, "", envs, "", "", 1, nil, nil, nil, util.DefaultLogger())
 	assert.NilError(t, err)
 }
 
@@ -60,43 +66,237 @@ func TestCreateConfigsFromAPI(t *testing.T) {
 
 	jcreator.EXPECT().
 		CreateJSONConfig(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-		Return("demo.json", "demo", false, nil)
+		Return("demo.json", "demo", nil, false, nil)
 
 	ycreator.EXPECT().
 		CreateYamlFile(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil)
-	ycreator.EXPECT().AddConfig(gomock.Any(), gomock.Any())
+	ycreator.EXPECT().AddConfig(gomock.Any(), gomock.Any(), gomock.Any())
 
-	err := createConfigsFromAPI(fs, apiMock, "123", "/", client, jcreator, ycreator)
+	err := createConfigsFromAPI(fs, apiMock, "123", "/", client, jcreator, ycreator, newWorkerPool(context.Background(), 1), &downloadFailures{}, nil, newDownloadedConfigs(), util.DefaultLogger())
 	assert.NilError(t, err, "No errors")
 }
 
+func TestCreateConfigsFromAPIAddsConfigsInListOrderAndCollectsFailures(t *testing.T) {
+	apiMock := api.CreateAPIMockFactory(t)
+	client := rest.CreateDynatraceClientMockFactory(t)
+	jcreator := jsoncreator.CreateJSONCreatorMock(t)
+	ycreator := yamlcreator.CreateYamlCreatorMock(t)
+	fs := util.CreateTestFileSystem()
+	list := []api.Value{{Id: "a", Name: "first"}, {Id: "b", Name: "second"}, {Id: "c", Name: "third"}}
+
+	client.EXPECT().
+		List(gomock.Any()).Return(list, nil)
+
+	apiMock.EXPECT().
+		GetId().Return("synthetic-monitor").AnyTimes()
+
+	jcreator.EXPECT().
+		CreateJSONConfig(gomock.Any(), gomock.Any(), gomock.Any(), list[0], gomock.Any()).
+		Return("first.json", "first", nil, false, nil)
+	jcreator.EXPECT().
+		CreateJSONConfig(gomock.Any(), gomock.Any(), gomock.Any(), list[1], gomock.Any()).
+		Return("", "", nil, false, errors.New("download failed"))
+	jcreator.EXPECT().
+		CreateJSONConfig(gomock.Any(), gomock.Any(), gomock.Any(), list[2], gomock.Any()).
+		Return("third.json", "third", nil, false, nil)
+
+	gomock.InOrder(
+		ycreator.EXPECT().AddConfig("first", "first.json", nil),
+		ycreator.EXPECT().AddConfig("third", "third.json", nil),
+	)
+	ycreator.EXPECT().
+		CreateYamlFile(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	failures := &downloadFailures{}
+	err := createConfigsFromAPI(fs, apiMock, "123", "/", client, jcreator, ycreator, newWorkerPool(context.Background(), 4), failures, nil, newDownloadedConfigs(), util.DefaultLogger())
+	assert.NilError(t, err)
+
+	sorted := failures.sorted()
+	assert.Equal(t, len(sorted), 1)
+	assert.Equal(t, sorted[0].String(), "synthetic-monitor second: download failed")
+}
+
+func TestCreateConfigsFromAPIOnlyDownloadsConfigsMatchingTheNameFilter(t *testing.T) {
+	apiMock := api.CreateAPIMockFactory(t)
+	client := rest.CreateDynatraceClientMockFactory(t)
+	jcreator := jsoncreator.CreateJSONCreatorMock(t)
+	ycreator := yamlcreator.CreateYamlCreatorMock(t)
+	fs := util.CreateTestFileSystem()
+	list := []api.Value{{Id: "a", Name: "PROD-first"}, {Id: "b", Name: "DEV-second"}}
+
+	client.EXPECT().
+		List(gomock.Any()).Return(list, nil)
+
+	apiMock.EXPECT().
+		GetId().Return("synthetic-monitor").AnyTimes()
+
+	jcreator.EXPECT().
+		CreateJSONConfig(gomock.Any(), gomock.Any(), gomock.Any(), list[0], gomock.Any()).
+		Return("first.json", "first", nil, false, nil)
+
+	ycreator.EXPECT().AddConfig("first", "first.json", nil)
+	ycreator.EXPECT().
+		CreateYamlFile(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	filter, err := newNameFilter("PROD-*")
+	assert.NilError(t, err)
+
+	err = createConfigsFromAPI(fs, apiMock, "123", "/", client, jcreator, ycreator, newWorkerPool(context.Background(), 1), &downloadFailures{}, filter, newDownloadedConfigs(), util.DefaultLogger())
+	assert.NilError(t, err)
+}
+
 func TestDownloadConfigFromEnvironment(t *testing.T) {
 	os.Setenv("token", "test")
 	env := environment.NewEnvironment("environment1", "test", "", "https://test.live.dynatrace.com", "token")
 
 	fileManager := util.CreateTestFileSystem()
-	err := downloadConfigFromEnvironment(fileManager, env, "", nil)
+	err := downloadConfigFromEnvironment(context.Background(), fileManager, env, "", nil, 1, nil, nil, nil, util.DefaultLogger())
 	assert.NilError(t, err)
 }
 
 func TestGetAPIList(t *testing.T) {
 	//multiple options
-	list, err := getAPIList("synthetic-location,   extension, alerting-profile")
+	list, err := getAPIList("synthetic-location,   extension, alerting-profile", "", util.DefaultLogger())
 	assert.NilError(t, err)
 	assert.Check(t, list["synthetic-location"].GetId() == "synthetic-location")
 	assert.Check(t, list["dashboard"] == nil)
-	list, err = getAPIList("synthetic-location,extension,dashboard")
+	list, err = getAPIList("synthetic-location,extension,dashboard", "", util.DefaultLogger())
 	assert.NilError(t, err)
 	//single option
-	list, err = getAPIList("synthetic-location")
+	list, err = getAPIList("synthetic-location", "", util.DefaultLogger())
 	assert.NilError(t, err)
 	//no option
-	list, err = getAPIList("")
+	list, err = getAPIList("", "", util.DefaultLogger())
 	assert.NilError(t, err)
-	list, err = getAPIList(" ")
+	list, err = getAPIList(" ", "", util.DefaultLogger())
 	assert.NilError(t, err)
 	//not a real API
-	list, err = getAPIList("synthetic-location-test,   extension-test, alerting-profile")
+	list, err = getAPIList("synthetic-location-test,   extension-test, alerting-profile", "", util.DefaultLogger())
 	assert.ErrorContains(t, err, "There were some errors in the API list provided")
 }
+
+func TestGetAPIListExcludesApis(t *testing.T) {
+	list, err := getAPIList("", "synthetic-monitor, dashboard", util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Check(t, list["synthetic-monitor"] == nil)
+	assert.Check(t, list["dashboard"] == nil)
+	assert.Check(t, list["alerting-profile"] != nil)
+	assert.Equal(t, len(list), len(api.NewApis())-2)
+}
+
+func TestGetAPIListExcludeTakesPrecedence(t *testing.T) {
+	list, err := getAPIList("synthetic-monitor,dashboard", "dashboard", util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 1)
+	assert.Check(t, list["synthetic-monitor"] != nil)
+}
+
+func TestGetAPIListIgnoresUnknownExcludedApis(t *testing.T) {
+	list, err := getAPIList("dashboard", "dashboards, ", util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 1)
+	assert.Check(t, list["dashboard"] != nil)
+}
+
+func TestDownloadingUnchangedConfigsTwiceProducesIdenticalFiles(t *testing.T) {
+	monitors := api.NewApis()["synthetic-monitor"]
+
+	download := func(list []api.Value, payloads map[string]string) afero.Fs {
+		client := rest.CreateDynatraceClientMockFactory(t)
+		client.EXPECT().List(gomock.Any()).Return(list, nil)
+		client.EXPECT().ReadById(gomock.Any(), gomock.Any()).DoAndReturn(func(_ api.Api, id string) ([]byte, error) {
+			return []byte(payloads[id]), nil
+		}).AnyTimes()
+
+		fs := afero.NewMemMapFs()
+		err := createConfigsFromAPI(fs, monitors, "123", "project", client, jsoncreator.NewJSONCreator(util.DefaultLogger()), yamlcreator.NewYamlConfig(util.DefaultLogger()),
+			newWorkerPool(context.Background(), 4), &downloadFailures{}, nil, newDownloadedConfigs(), util.DefaultLogger())
+		assert.NilError(t, err)
+		return fs
+	}
+
+	first := download(
+		[]api.Value{{Id: "a", Name: "monitor-a"}, {Id: "b", Name: "monitor-b"}},
+		map[string]string{
+			"a": `{"id": "a", "name": "monitor-a", "frequencyMin": 5, "tags": [{"key": "team"}, {"key": "app", "value": "shop"}]}`,
+			"b": `{"id": "b", "name": "monitor-b", "enabled": true, "tags": ["b", "a"]}`,
+		})
+	second := download(
+		[]api.Value{{Id: "b", Name: "monitor-b"}, {Id: "a", Name: "monitor-a"}},
+		map[string]string{
+			"a": `{"tags": [{"value": "shop", "key": "app"}, {"key": "team"}], "frequencyMin": 5, "name": "monitor-a", "id": "a"}`,
+			"b": `{"tags": ["a", "b"], "enabled": true, "name": "monitor-b", "id": "b"}`,
+		})
+
+	for _, file := range []string{"synthetic-monitor.yaml", "monitor-a.json", "monitor-b.json"} {
+		path := filepath.Join("project", "synthetic-monitor", file)
+
+		firstContent, err := afero.ReadFile(first, path)
+		assert.NilError(t, err)
+		secondContent, err := afero.ReadFile(second, path)
+		assert.NilError(t, err)
+
+		assert.Equal(t, string(secondContent), string(firstContent), path)
+	}
+
+	content, err := afero.ReadFile(first, filepath.Join("project", "synthetic-monitor", "synthetic-monitor.yaml"))
+	assert.NilError(t, err)
+	assert.Check(t, strings.Index(string(content), "monitor-a") < strings.Index(string(content), "monitor-b"), string(content))
+}
+
+func TestCreateConfigsFromAPIDownloadsOnlyConfigsWithMatchingNameAndTags(t *testing.T) {
+	dashboards := api.NewApis()["dashboard"]
+	payloads := map[string]string{
+		"a": `{"id": "a", "dashboardMetadata": {"name": "PROD-a", "tags": ["team:a"]}}`,
+		"b": `{"id": "b", "dashboardMetadata": {"name": "PROD-b", "tags": ["team:b"]}}`,
+		"c": `{"id": "c", "dashboardMetadata": {"name": "DEV-c", "tags": ["team:a"]}}`,
+	}
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().List(dashboards).Return([]api.Value{{Id: "a", Name: "PROD-a"}, {Id: "b", Name: "PROD-b"}, {Id: "c", Name: "DEV-c"}}, nil)
+	client.EXPECT().ReadById(dashboards, gomock.Any()).DoAndReturn(func(_ api.Api, id string) ([]byte, error) {
+		return []byte(payloads[id]), nil
+	}).Times(2)
+
+	names, err := newNameFilter("PROD-*")
+	assert.NilError(t, err)
+	tags, err := newTagFilter([]string{"team:a"})
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	log, err := util.NewLogger(afero.NewMemMapFs(), util.LoggerOptions{Writer: &out, Verbose: true})
+	assert.NilError(t, err)
+
+	fs := afero.NewMemMapFs()
+	err = createConfigsFromAPI(fs, dashboards, "123", "project", client, jsoncreator.NewTagFilteringJSONCreator(tags.matches, log), yamlcreator.NewYamlConfig(log),
+		newWorkerPool(context.Background(), 1), &downloadFailures{}, names, newDownloadedConfigs(), log)
+	assert.NilError(t, err)
+
+	files, err := afero.ReadDir(fs, filepath.Join("project", "dashboard"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 2)
+	assert.Equal(t, files[0].Name(), "PROD-a.json")
+	assert.Equal(t, files[1].Name(), "dashboard.yaml")
+	assert.Check(t, strings.Contains(out.String(), "Config PROD-b has been filtered out by its tags"), out.String())
+}
+
+func TestDownloadConfigFromEnvironmentSkipsApisWithoutTagsIfFilteredByTag(t *testing.T) {
+	util.SetEnv(t, "token", "test")
+	defer util.UnsetEnv(t, "token")
+	env := environment.NewEnvironment("environment1", "test", "", "https://test.live.dynatrace.com", "token")
+
+	tags, err := newTagFilter([]string{"team:a"})
+	assert.NilError(t, err)
+
+	fs := afero.NewMemMapFs()
+	apis := api.NewApis()
+	err = downloadConfigFromEnvironment(context.Background(), fs, env, "", map[string]api.Api{"alerting-profile": apis["alerting-profile"], "settings": apis["settings"]}, 1, nil, tags, nil, util.DefaultLogger())
+	assert.NilError(t, err)
+
+	exists, err := afero.DirExists(fs, filepath.Join("environment1", "alerting-profile"))
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+}
@@ -0,0 +1,83 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsoncreator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// templateActionEscaper replaces the delimiters of template actions by actions rendering them literally. Raw strings
+// are used, as the quotes of interpreted strings would be escaped in the marshalled json.
+var templateActionEscaper = strings.NewReplacer("{{", "{{`{{`}}", "}}", "{{`}}`}}")
+
+// ProcessAutomationObject prepares the payload of an object downloaded from an automation API, e.g. a workflow, to be
+// deployed again. The id is removed, as deployments derive it from the config, the title is replaced by the name of
+// the config and the cleanup of the api is applied. Template actions in the payload, e.g. the {{ result("task") }}
+// expressions of workflow tasks, are escaped, so they are deployed as they were downloaded. It returns the json file
+// and the parameters of the config.
+func ProcessAutomationObject(apiId string, payload []byte) ([]byte, map[string]string, error) {
+	var dat map[string]interface{}
+
+	// numbers are kept as they are, as large ids would lose precision as float
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&dat); err != nil {
+		return nil, nil, err
+	}
+
+	delete(dat, "id")
+	parameters, numbers := cleanupFields(apiId, dat)
+
+	placeholders := make(map[string]bool, len(parameters))
+	for parameter := range parameters {
+		placeholders[placeholder(parameter)] = true
+	}
+	escapeTemplateActions(dat, placeholders)
+
+	if dat["title"] != nil {
+		dat["title"] = "{{.name}}"
+	}
+
+	content, err := marshalCanonical(dat)
+	if err != nil {
+		return nil, nil, err
+	}
+	return unquotePlaceholders(content, numbers), parameters, nil
+}
+
+// escapeTemplateActions escapes the template actions in all strings of the value, except the placeholders of the
+// parameters, and returns the value
+func escapeTemplateActions(value interface{}, placeholders map[string]bool) interface{} {
+	switch v := value.(type) {
+	case string:
+		if placeholders[v] {
+			return v
+		}
+		return templateActionEscaper.Replace(v)
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = escapeTemplateActions(child, placeholders)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = escapeTemplateActions(child, placeholders)
+		}
+	}
+	return value
+}
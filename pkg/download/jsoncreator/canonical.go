@@ -0,0 +1,82 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsoncreator
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// unorderedListKeys are the properties holding lists whose order has no meaning, but which the Dynatrace API doesn't
+// return in a stable order
+var unorderedListKeys = map[string]struct{}{
+	"tags": {},
+}
+
+// marshalCanonical serializes the config, so downloading an unchanged config again results in the same file: object
+// keys are sorted, the indentation is always the same, lists without meaningful order are sorted and the file ends
+// with a newline.
+func marshalCanonical(dat map[string]interface{}) ([]byte, error) {
+	sortUnorderedLists(dat)
+
+	content, err := json.MarshalIndent(dat, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// sortUnorderedLists sorts the lists of unorderedListKeys at any depth of the value by their serialized items
+func sortUnorderedLists(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, property := range v {
+			if list, isList := property.([]interface{}); isList {
+				if _, unordered := unorderedListKeys[key]; unordered {
+					sortBySerializedItems(list)
+				}
+			}
+			sortUnorderedLists(property)
+		}
+	case []interface{}:
+		for _, item := range v {
+			sortUnorderedLists(item)
+		}
+	}
+}
+
+func sortBySerializedItems(list []interface{}) {
+	serialized := make(map[int]string, len(list))
+	for i, item := range list {
+		content, _ := json.Marshal(item)
+		serialized[i] = string(content)
+	}
+
+	indices := make([]int, len(list))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return serialized[indices[i]] < serialized[indices[j]]
+	})
+
+	sorted := make([]interface{}, len(list))
+	for i, index := range indices {
+		sorted[i] = list[index]
+	}
+	copy(list, sorted)
+}
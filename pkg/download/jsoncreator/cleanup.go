@@ -0,0 +1,148 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsoncreator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// fieldCleanup defines which fields of the downloaded configs of an api are changed, so the configs can be deployed
+// again. Paths separate the keys of nested objects with dots, e.g. `dashboardMetadata.owner`.
+type fieldCleanup struct {
+	// removed are the paths of fields managed by the server, e.g. owners or timestamps
+	removed []string
+
+	// parameterized maps the paths of environment specific fields, e.g. ids of management zones, to the name of the
+	// parameter replacing them. The downloaded value is moved to the parameter in the yaml file, where it can be
+	// replaced by a reference or a value per environment.
+	parameterized map[string]string
+
+	// referenced maps parameters holding the id of another config to the id of its api. They are replaced by a
+	// reference to the downloaded config having that id.
+	referenced map[string]string
+}
+
+// fieldCleanups are the cleanups of the apis, by api id. Apis without entry are downloaded as they are.
+var fieldCleanups = map[string]fieldCleanup{
+	"dashboard": {
+		removed: []string{"dashboardMetadata.owner"},
+		parameterized: map[string]string{
+			"dashboardMetadata.dashboardFilter.managementZone.id": "managementZoneId",
+		},
+		referenced: map[string]string{
+			"managementZoneId": "management-zone",
+		},
+	},
+	"alerting-profile": {
+		parameterized: map[string]string{
+			"managementZoneId": "managementZoneId",
+		},
+		referenced: map[string]string{
+			"managementZoneId": "management-zone",
+		},
+	},
+	"workflow": {
+		removed: []string{"owner", "ownerType", "actor", "modificationInfo", "lastExecution"},
+		parameterized: map[string]string{
+			"trigger.schedule.rule": "schedulingRuleId",
+		},
+		referenced: map[string]string{
+			"schedulingRuleId": "scheduling-rule",
+		},
+	},
+	"scheduling-rule": {
+		removed: []string{"modificationInfo"},
+	},
+}
+
+// ReferencedApi returns the id of the api of the config whose id is held by the parameter of a downloaded config of
+// the api, if the parameter holds the id of another config
+func ReferencedApi(apiId string, parameter string) (string, bool) {
+	referencedApi, found := fieldCleanups[apiId].referenced[parameter]
+	return referencedApi, found
+}
+
+// cleanupFields applies the cleanup of the api to the downloaded config and returns the parameters holding the
+// values of the parameterized fields. Fields which are missing or null are skipped. Numbers must stay numbers in the
+// config, so the parameters of numeric fields are returned as well, to unquote their placeholders after marshalling.
+func cleanupFields(apiId string, dat map[string]interface{}) (parameters map[string]string, numbers []string) {
+	cleanup, found := fieldCleanups[apiId]
+	if !found {
+		return nil, nil
+	}
+
+	for _, path := range cleanup.removed {
+		parent, key := lookupParent(dat, path)
+		if parent != nil {
+			delete(parent, key)
+		}
+	}
+
+	parameters = make(map[string]string)
+	for path, parameter := range cleanup.parameterized {
+		parent, key := lookupParent(dat, path)
+		if parent == nil || parent[key] == nil {
+			continue
+		}
+
+		switch parent[key].(type) {
+		case json.Number, float64:
+			numbers = append(numbers, parameter)
+		}
+
+		parameters[parameter] = fmt.Sprint(parent[key])
+		parent[key] = placeholder(parameter)
+	}
+
+	return parameters, numbers
+}
+
+func placeholder(parameter string) string {
+	return "{{." + parameter + "}}"
+}
+
+// unquotePlaceholders removes the quotes around the placeholders of the parameters in the marshalled config, so the
+// values of the parameters are rendered as numbers, e.g. "managementZoneId": {{.managementZoneId}}
+func unquotePlaceholders(content []byte, parameters []string) []byte {
+	for _, parameter := range parameters {
+		content = bytes.ReplaceAll(content, []byte(`"`+placeholder(parameter)+`"`), []byte(placeholder(parameter)))
+	}
+	return content
+}
+
+// lookupParent returns the object containing the field of the path and the key of the field in it. The object is nil
+// if any object on the path is missing.
+func lookupParent(dat map[string]interface{}, path string) (map[string]interface{}, string) {
+	keys := strings.Split(path, ".")
+
+	parent := dat
+	for _, key := range keys[:len(keys)-1] {
+		child, isObject := parent[key].(map[string]interface{})
+		if !isObject {
+			return nil, ""
+		}
+		parent = child
+	}
+
+	if _, found := parent[keys[len(keys)-1]]; !found {
+		return nil, ""
+	}
+	return parent, keys[len(keys)-1]
+}
@@ -15,6 +15,7 @@
 package jsoncreator
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -31,80 +32,101 @@ import (
 //JSONCreator interface allows to mock the methods for unit testing
 type JSONCreator interface {
 	CreateJSONConfig(fs afero.Fs, client rest.DynatraceClient, api api.Api, value api.Value,
-		path string) (name string, cleanName string, filter bool, err error)
+		path string) (name string, cleanName string, parameters map[string]string, filter bool, err error)
 }
 
 //JSONCreatorImp object
-type JsonCreatorImp struct{}
+type JsonCreatorImp struct {
+	// matchesTags selects the configs to create by their tags, all configs are created if it is nil
+	matchesTags func(tags []string) bool
 
-//NewJSONCreator creates a new instance of the jsonCreator
-func NewJSONCreator() *JsonCreatorImp {
-	result := JsonCreatorImp{}
+	// log logs the configs which couldn't be created
+	log *util.Logger
+}
+
+//NewJSONCreator creates a new instance of the jsonCreator, which logs to the given logger
+func NewJSONCreator(log *util.Logger) *JsonCreatorImp {
+	result := JsonCreatorImp{log: log}
 	return &result
 }
 
-//CreateJSONConfig creates a json file using the specified path and API data
+//NewTagFilteringJSONCreator creates a jsonCreator which filters out configs whose tags don't match. The tags of a
+//config are returned by TagsOf.
+func NewTagFilteringJSONCreator(matchesTags func(tags []string) bool, log *util.Logger) *JsonCreatorImp {
+	return &JsonCreatorImp{matchesTags: matchesTags, log: log}
+}
+
+//CreateJSONConfig creates a json file using the specified path and API data. The returned parameters hold the values
+//of environment specific fields, which are replaced by parameters in the json file.
 func (d *JsonCreatorImp) CreateJSONConfig(fs afero.Fs, client rest.DynatraceClient, api api.Api, value api.Value,
-	path string) (name string, cleanName string, filter bool, err error) {
-	data, filter, err := getDetailFromAPI(client, api, value.Id)
+	path string) (name string, cleanName string, parameters map[string]string, filter bool, err error) {
+	data, filter, err := getDetailFromAPI(client, api, value.Id, d.log)
 	if err != nil {
-		util.Log.Error("error getting detail %s from API", api.GetId())
-		return "", "", false, err
+		d.log.Error("error getting detail %s from API", api.GetId())
+		return "", "", nil, false, err
 	}
 	if filter {
-		return "", "", true, nil
+		return "", "", nil, true, nil
+	}
+	if d.matchesTags != nil && !d.matchesTags(TagsOf(api.GetId(), data)) {
+		d.log.Debug("Config %s has been filtered out by its tags", value.Name)
+		return "", "", nil, true, nil
 	}
-	jsonfile, name, cleanName, err := processJSONFile(data, value.Id, value.Name, api)
+	jsonfile, name, cleanName, parameters, err := processJSONFile(data, value.Id, value.Name, api, d.log)
 	if err != nil {
-		util.Log.Error("error processing jsonfile %s", api.GetId())
-		return "", "", false, err
+		d.log.Error("error processing jsonfile %s", api.GetId())
+		return "", "", nil, false, err
 	}
 	fullPath := filepath.Join(path, cleanName+".json")
 	err = afero.WriteFile(fs, fullPath, jsonfile, 0664)
 	if err != nil {
-		util.Log.Error("error writing detail %s", api.GetId())
-		return "", "", false, err
+		d.log.Error("error writing detail %s", api.GetId())
+		return "", "", nil, false, err
 	}
-	return name, cleanName, false, nil
+	return name, cleanName, parameters, false, nil
 }
 
-func getDetailFromAPI(client rest.DynatraceClient, api api.Api, name string) (dat map[string]interface{}, filter bool, err error) {
+func getDetailFromAPI(client rest.DynatraceClient, api api.Api, name string, log *util.Logger) (dat map[string]interface{}, filter bool, err error) {
 
 	name = url.QueryEscape(name)
 	resp, err := client.ReadById(api, name)
 	if err != nil {
-		util.Log.Error("error getting detail for API %s", api.GetId(), name)
+		log.Error("error getting detail for API %s", api.GetId(), name)
 		return nil, false, err
 	}
-	err = json.Unmarshal(resp, &dat)
+	// numbers are kept as they are, as large ids would lose precision as float
+	decoder := json.NewDecoder(bytes.NewReader(resp))
+	decoder.UseNumber()
+	err = decoder.Decode(&dat)
 	if err != nil {
-		util.Log.Error("error transforming %s from json to object", name)
+		log.Error("error transforming %s from json to object", name)
 		return nil, false, err
 	}
 	filter = isDefaultEntity(api.GetId(), dat)
 	if filter {
-		util.Log.Debug("Non-user-created default Object has been filtered out", name)
+		log.Debug("Non-user-created default Object has been filtered out", name)
 		return nil, true, err
 	}
 	return dat, false, nil
 }
 
 //processJSONFile removes and replaces properties for each json config to make them compatible with monaco standard
-func processJSONFile(dat map[string]interface{}, id string, name string, api api.Api) ([]byte, string, string, error) {
+func processJSONFile(dat map[string]interface{}, id string, name string, api api.Api, log *util.Logger) ([]byte, string, string, map[string]string, error) {
 
 	name, err := getNameForConfig(name, dat, api)
 	if err != nil {
-		return nil, "", "", err
+		return nil, "", "", nil, err
 	}
 	dat = replaceKeyProperties(dat)
+	parameters, numbers := cleanupFields(api.GetId(), dat)
 	cleanName := util.SanitizeName(name) //for using as the json filename
-	jsonfile, err := json.MarshalIndent(dat, "", " ")
+	jsonfile, err := marshalCanonical(dat)
 
 	if err != nil {
-		util.Log.Error("error creating json file  %s", id)
-		return nil, "", "", err
+		log.Error("error creating json file  %s", id)
+		return nil, "", "", nil, err
 	}
-	return jsonfile, name, cleanName, nil
+	return unquotePlaceholders(jsonfile, numbers), name, cleanName, parameters, nil
 }
 
 //replaceKeyProperties replaces name or displayname for each config
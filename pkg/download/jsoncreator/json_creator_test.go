@@ -24,6 +24,7 @@ import (
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
 	"gotest.tools/assert"
 )
 
@@ -41,9 +42,9 @@ func TestCreateJsonConfig(t *testing.T) {
 
 	apiMock.EXPECT().GetId().Return("alerting-profile").AnyTimes()
 
-	jcreator := NewJSONCreator()
+	jcreator := NewJSONCreator(util.DefaultLogger())
 
-	name, cleanName, filter, err := jcreator.CreateJSONConfig(fs, client, apiMock, val, "/")
+	name, cleanName, _, filter, err := jcreator.CreateJSONConfig(fs, client, apiMock, val, "/")
 	assert.NilError(t, err)
 	assert.Equal(t, filter, false)
 	assert.Equal(t, name, "Sockshop Error Profile")
@@ -118,7 +119,7 @@ func TestProcessJSONFile(t *testing.T) {
 	sample["id"] = "testId"
 	apiMock := api.CreateAPIMockFactory(t)
 	apiMock.EXPECT().GetId().Return("alerting-profile").AnyTimes()
-	file, name, cleanName, err := processJSONFile(sample, "testId", "test1", apiMock)
+	file, name, cleanName, _, err := processJSONFile(sample, "testId", "test1", apiMock, util.DefaultLogger())
 	assert.NilError(t, err)
 	jsonfile := make(map[string]interface{})
 	err = json.Unmarshal(file, &jsonfile)
@@ -129,3 +130,122 @@ func TestProcessJSONFile(t *testing.T) {
 	assert.Check(t, cleanName == "test1")
 	assert.Check(t, jsonfile["id"] == nil)
 }
+
+func TestMarshalCanonicalSortsKeysAndUnorderedLists(t *testing.T) {
+	var dat map[string]interface{}
+	err := json.Unmarshal([]byte(`{"name": "x", "tiles": [{"b": 2, "tags": ["z", "a"]}, {"a": 1}], "tags": ["b", "a"]}`), &dat)
+	assert.NilError(t, err)
+
+	content, err := marshalCanonical(dat)
+	assert.NilError(t, err)
+
+	assert.Equal(t, string(content), `{
+ "name": "x",
+ "tags": [
+  "a",
+  "b"
+ ],
+ "tiles": [
+  {
+   "b": 2,
+   "tags": [
+    "a",
+    "z"
+   ]
+  },
+  {
+   "a": 1
+  }
+ ]
+}
+`)
+}
+
+func TestCreateJsonConfigParameterizesManagementZoneOfAlertingProfile(t *testing.T) {
+	jsonsample := []byte(`{"id": "profile-id", "displayName": "Error Profile", "managementZoneId": 4373787840716453989, "rules": []}`)
+
+	apiMock := api.CreateAPIMockFactory(t)
+	client := rest.CreateDynatraceClientMockFactory(t)
+	fs := util.CreateTestFileSystem()
+	val := api.Value{Id: "profile-id", Name: "Error Profile"}
+	client.EXPECT().ReadById(apiMock, val.Id).Return(jsonsample, nil)
+	apiMock.EXPECT().GetId().Return("alerting-profile").AnyTimes()
+
+	_, cleanName, parameters, _, err := NewJSONCreator(util.DefaultLogger()).CreateJSONConfig(fs, client, apiMock, val, "/")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parameters, map[string]string{"managementZoneId": "4373787840716453989"})
+
+	content, err := afero.ReadFile(fs, "/"+cleanName+".json")
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `{
+ "displayName": "{{.name}}",
+ "managementZoneId": {{.managementZoneId}},
+ "rules": []
+}
+`)
+}
+
+func TestCleanupFieldsOfDashboard(t *testing.T) {
+	var dat map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"dashboardMetadata": {
+			"name": "my dashboard",
+			"owner": "someone@example.com",
+			"dashboardFilter": {"managementZone": {"id": "-1234567890", "name": "my zone"}}
+		},
+		"tiles": []
+	}`), &dat)
+	assert.NilError(t, err)
+
+	parameters, numbers := cleanupFields("dashboard", dat)
+
+	assert.DeepEqual(t, parameters, map[string]string{"managementZoneId": "-1234567890"})
+	assert.Equal(t, len(numbers), 0)
+	assert.DeepEqual(t, dat, map[string]interface{}{
+		"dashboardMetadata": map[string]interface{}{
+			"name":            "my dashboard",
+			"dashboardFilter": map[string]interface{}{"managementZone": map[string]interface{}{"id": "{{.managementZoneId}}", "name": "my zone"}},
+		},
+		"tiles": []interface{}{},
+	})
+}
+
+func TestCleanupFieldsSkipsMissingAndNullFields(t *testing.T) {
+	var dat map[string]interface{}
+	err := json.Unmarshal([]byte(`{"dashboardMetadata": {"name": "my dashboard", "dashboardFilter": {"managementZone": null}}}`), &dat)
+	assert.NilError(t, err)
+
+	parameters, _ := cleanupFields("dashboard", dat)
+	assert.Equal(t, len(parameters), 0)
+
+	err = json.Unmarshal([]byte(`{"displayName": "profile", "managementZoneId": null}`), &dat)
+	assert.NilError(t, err)
+
+	parameters, _ = cleanupFields("alerting-profile", dat)
+	assert.Equal(t, len(parameters), 0)
+	assert.Check(t, dat["managementZoneId"] == nil)
+}
+
+func TestCleanupFieldsIgnoresApisWithoutCleanup(t *testing.T) {
+	dat := map[string]interface{}{"name": "monitor", "managementZoneId": "123"}
+
+	parameters, _ := cleanupFields("synthetic-monitor", dat)
+
+	assert.Check(t, parameters == nil)
+	assert.Equal(t, dat["managementZoneId"], "123")
+}
+
+func TestTagsOfReadsTagsOfApisSupportingTags(t *testing.T) {
+	var dashboard, monitor map[string]interface{}
+	assert.NilError(t, json.Unmarshal([]byte(`{"dashboardMetadata": {"tags": ["team:a", "prod"]}}`), &dashboard))
+	assert.NilError(t, json.Unmarshal([]byte(`{"tags": [{"key": "team", "value": "a", "context": "CONTEXTLESS"}, {"key": "prod"}]}`), &monitor))
+
+	assert.Assert(t, SupportsTags("dashboard"))
+	assert.DeepEqual(t, TagsOf("dashboard", dashboard), []string{"team:a", "prod"})
+
+	assert.Assert(t, SupportsTags("synthetic-monitor"))
+	assert.DeepEqual(t, TagsOf("synthetic-monitor", monitor), []string{"team:a", "prod"})
+
+	assert.Assert(t, !SupportsTags("alerting-profile"))
+	assert.Equal(t, len(TagsOf("alerting-profile", monitor)), 0)
+}
@@ -0,0 +1,78 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsoncreator
+
+import "fmt"
+
+// SupportsTags returns whether the configs of the api carry tags, which are returned by TagsOf
+func SupportsTags(apiId string) bool {
+	switch apiId {
+	case "dashboard", "synthetic-monitor":
+		return true
+	default:
+		return false
+	}
+}
+
+// TagsOf returns the tags of a config downloaded from the api. Tags with a value are returned as key:value.
+func TagsOf(apiId string, dat map[string]interface{}) []string {
+	switch apiId {
+	case "dashboard":
+		metadata, ok := dat["dashboardMetadata"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		return stringTags(metadata["tags"])
+	case "synthetic-monitor":
+		return keyValueTags(dat["tags"])
+	default:
+		return nil
+	}
+}
+
+// stringTags returns the tags of a list of strings, e.g. ["team:a", "prod"]
+func stringTags(value interface{}) []string {
+	list, _ := value.([]interface{})
+
+	tags := make([]string, 0, len(list))
+	for _, tag := range list {
+		if s, ok := tag.(string); ok {
+			tags = append(tags, s)
+		}
+	}
+	return tags
+}
+
+// keyValueTags returns the tags of a list of objects, e.g. [{"key": "team", "value": "a"}, {"key": "prod"}]
+func keyValueTags(value interface{}) []string {
+	list, _ := value.([]interface{})
+
+	tags := make([]string, 0, len(list))
+	for _, tag := range list {
+		object, ok := tag.(map[string]interface{})
+		if !ok || object["key"] == nil {
+			continue
+		}
+
+		if object["value"] != nil && object["value"] != "" {
+			tags = append(tags, fmt.Sprintf("%v:%v", object["key"], object["value"]))
+		} else {
+			tags = append(tags, fmt.Sprintf("%v", object["key"]))
+		}
+	}
+	return tags
+}
@@ -0,0 +1,83 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+)
+
+// regexFilterPrefix marks a name filter as regular expression instead of a glob pattern
+const regexFilterPrefix = "regex:"
+
+// nameFilter selects the objects to download by their display name, as returned by the list endpoint of an api.
+// A nil nameFilter matches every name.
+type nameFilter struct {
+	pattern *regexp.Regexp
+}
+
+// newNameFilter creates a filter from a glob pattern (supporting '*' and '?') or, if prefixed with 'regex:', from a
+// regular expression. Glob patterns need to match the whole name, regular expressions any part of it.
+// An empty pattern returns a nil filter.
+func newNameFilter(pattern string) (*nameFilter, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, nil
+	}
+
+	var expression string
+	if strings.HasPrefix(pattern, regexFilterPrefix) {
+		expression = strings.TrimPrefix(pattern, regexFilterPrefix)
+	} else {
+		expression = globToRegex(pattern)
+	}
+
+	compiled, err := regexp.Compile(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name filter %s: %w", pattern, err)
+	}
+
+	return &nameFilter{pattern: compiled}, nil
+}
+
+func globToRegex(glob string) string {
+	expression := regexp.QuoteMeta(glob)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+	return "^" + expression + "$"
+}
+
+func (f *nameFilter) matches(name string) bool {
+	return f == nil || f.pattern.MatchString(name)
+}
+
+// apply returns the values with a matching name
+func (f *nameFilter) apply(values []api.Value) []api.Value {
+	if f == nil {
+		return values
+	}
+
+	filtered := make([]api.Value, 0, len(values))
+	for _, value := range values {
+		if f.matches(value.Name) {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,74 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"gotest.tools/assert"
+)
+
+func TestNewNameFilterReturnsNilForEmptyPattern(t *testing.T) {
+	filter, err := newNameFilter(" ")
+	assert.NilError(t, err)
+	assert.Assert(t, filter == nil)
+	assert.Assert(t, filter.matches("anything"))
+}
+
+func TestNameFilterMatchesGlobPatterns(t *testing.T) {
+	filter, err := newNameFilter("PROD-*")
+	assert.NilError(t, err)
+
+	assert.Assert(t, filter.matches("PROD-"))
+	assert.Assert(t, filter.matches("PROD-app/dashboard (v1.2)"))
+	assert.Assert(t, !filter.matches("prod-app"))
+	assert.Assert(t, !filter.matches("DEV-PROD-app"))
+
+	filter, err = newNameFilter("app-?.[1]")
+	assert.NilError(t, err)
+
+	assert.Assert(t, filter.matches("app-a.[1]"))
+	assert.Assert(t, !filter.matches("app-ab.[1]"))
+	assert.Assert(t, !filter.matches("app-a.1"))
+}
+
+func TestNameFilterMatchesRegularExpressions(t *testing.T) {
+	filter, err := newNameFilter("regex:^(PROD|STAGE)-")
+	assert.NilError(t, err)
+
+	assert.Assert(t, filter.matches("PROD-app"))
+	assert.Assert(t, filter.matches("STAGE-app"))
+	assert.Assert(t, !filter.matches("DEV-app"))
+}
+
+func TestNewNameFilterFailsOnInvalidRegularExpression(t *testing.T) {
+	_, err := newNameFilter("regex:PROD-(")
+	assert.ErrorContains(t, err, "invalid name filter regex:PROD-(")
+}
+
+func TestNameFilterAppliesToValueNames(t *testing.T) {
+	filter, err := newNameFilter("PROD-*")
+	assert.NilError(t, err)
+
+	values := []api.Value{{Id: "1", Name: "PROD-a"}, {Id: "PROD-b", Name: "DEV-b"}, {Id: "3", Name: "PROD-c"}}
+
+	assert.DeepEqual(t, filter.apply(values), []api.Value{{Id: "1", Name: "PROD-a"}, {Id: "3", Name: "PROD-c"}})
+}
@@ -0,0 +1,57 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"fmt"
+
+	"github.com/spf13/afero"
+)
+
+// PrepareOutputDir creates the directory a download is written to instead of the working directory. The directory
+// needs to be empty, unless overwrite is set, so downloading can't replace configs edited locally. Existing files of
+// the directory are kept if overwrite is set, only files of downloaded configs are replaced.
+func PrepareOutputDir(fs afero.Fs, outputDir string, overwrite bool) error {
+	exists, err := afero.Exists(fs, outputDir)
+	if err != nil {
+		return fmt.Errorf("could not access output directory %s: %w", outputDir, err)
+	}
+
+	if exists {
+		isDir, err := afero.IsDir(fs, outputDir)
+		if err != nil {
+			return fmt.Errorf("could not access output directory %s: %w", outputDir, err)
+		}
+		if !isDir {
+			return fmt.Errorf("output directory %s is not a directory", outputDir)
+		}
+
+		isEmpty, err := afero.IsEmpty(fs, outputDir)
+		if err != nil {
+			return fmt.Errorf("could not access output directory %s: %w", outputDir, err)
+		}
+		if !isEmpty && !overwrite {
+			return fmt.Errorf("output directory %s is not empty, use --overwrite to download into it anyway", outputDir)
+		}
+		return nil
+	}
+
+	if err := fs.MkdirAll(outputDir, 0777); err != nil {
+		return fmt.Errorf("could not create output directory %s: %w", outputDir, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestPrepareOutputDirCreatesMissingDirectory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	assert.NilError(t, PrepareOutputDir(fs, filepath.Join("downloads", "dev"), false))
+
+	isDir, err := afero.IsDir(fs, filepath.Join("downloads", "dev"))
+	assert.NilError(t, err)
+	assert.Assert(t, isDir)
+}
+
+func TestPrepareOutputDirAcceptsEmptyDirectory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, fs.MkdirAll("downloads", 0777))
+
+	assert.NilError(t, PrepareOutputDir(fs, "downloads", false))
+}
+
+func TestPrepareOutputDirRejectsNonEmptyDirectory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, filepath.Join("downloads", "project", "dashboard", "dashboard.json"), []byte("{}"), 0644))
+
+	err := PrepareOutputDir(fs, "downloads", false)
+	assert.ErrorContains(t, err, "output directory downloads is not empty, use --overwrite to download into it anyway")
+
+	content, err := afero.ReadFile(fs, filepath.Join("downloads", "project", "dashboard", "dashboard.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "{}")
+}
+
+func TestPrepareOutputDirAcceptsNonEmptyDirectoryIfOverwriting(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, filepath.Join("downloads", "notes.txt"), []byte("notes"), 0644))
+
+	assert.NilError(t, PrepareOutputDir(fs, "downloads", true))
+
+	exists, err := afero.Exists(fs, filepath.Join("downloads", "notes.txt"))
+	assert.NilError(t, err)
+	assert.Assert(t, exists)
+}
+
+func TestPrepareOutputDirRejectsFiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "downloads", []byte("not a directory"), 0644))
+
+	assert.ErrorContains(t, PrepareOutputDir(fs, "downloads", true), "output directory downloads is not a directory")
+}
+
+func TestDownloadConfigFromEnvironmentWritesProjectIntoOutputDir(t *testing.T) {
+	os.Setenv("token", "test")
+	defer os.Unsetenv("token")
+	env := environment.NewEnvironment("environment1", "test", "", "https://test.live.dynatrace.com", "token")
+
+	fs := afero.NewMemMapFs()
+	outputDir := filepath.Join("downloads", "fresh")
+	assert.NilError(t, PrepareOutputDir(fs, outputDir, false))
+
+	err := downloadConfigFromEnvironment(context.Background(), fs, env, outputDir, nil, 1, nil, nil, nil, util.DefaultLogger())
+	assert.NilError(t, err)
+
+	isDir, err := afero.IsDir(fs, filepath.Join(outputDir, "environment1"))
+	assert.NilError(t, err)
+	assert.Assert(t, isDir)
+
+	exists, err := afero.Exists(fs, "environment1")
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+}
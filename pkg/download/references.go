@@ -0,0 +1,134 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/jsoncreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/yamlcreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// downloadedConfig is a config written by the download, whose parameters may hold ids of other configs
+type downloadedConfig struct {
+	api        string
+	name       string
+	parameters map[string]string
+}
+
+// downloadedYaml is the yaml file of an api, which is written again once references have been resolved
+type downloadedYaml struct {
+	api      string
+	path     string
+	ycreator yamlcreator.YamlCreator
+}
+
+// downloadedConfigs collects the configs downloaded from an environment by all apis, to replace the ids of
+// referenced configs with references once all apis have been downloaded. It is safe for concurrent use.
+type downloadedConfigs struct {
+	mutex   sync.Mutex
+	ids     map[string]map[string]string
+	configs []downloadedConfig
+	yamls   []downloadedYaml
+}
+
+func newDownloadedConfigs() *downloadedConfigs {
+	return &downloadedConfigs{ids: make(map[string]map[string]string)}
+}
+
+// add records the config with the given id, written to the yaml file as name
+func (d *downloadedConfigs) add(api string, id string, name string, parameters map[string]string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.ids[api] == nil {
+		d.ids[api] = make(map[string]string)
+	}
+	d.ids[api][id] = name
+	d.configs = append(d.configs, downloadedConfig{api: api, name: name, parameters: parameters})
+}
+
+// addYaml records the yaml file written for the api
+func (d *downloadedConfigs) addYaml(api string, path string, ycreator yamlcreator.YamlCreator) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.yamls = append(d.yamls, downloadedYaml{api: api, path: path, ycreator: ycreator})
+}
+
+// resolveReferences replaces parameters holding the id of a downloaded config with a reference to that config in the
+// project, e.g. `/project/management-zone/zone.id`. Ids of configs which have not been downloaded are kept, as the
+// config may not be available in other environments, and a warning is logged. Returns the apis whose parameters
+// changed.
+func (d *downloadedConfigs) resolveReferences(project string, log *util.Logger) map[string]struct{} {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	changed := make(map[string]struct{})
+
+	for _, config := range d.configs {
+		// resolve parameters in a stable order, to log warnings in the same order on every download
+		parameters := make([]string, 0, len(config.parameters))
+		for parameter := range config.parameters {
+			parameters = append(parameters, parameter)
+		}
+		sort.Strings(parameters)
+
+		for _, parameter := range parameters {
+			referencedApi, isReference := jsoncreator.ReferencedApi(config.api, parameter)
+			if !isReference {
+				continue
+			}
+
+			id := config.parameters[parameter]
+			name, found := d.ids[referencedApi][id]
+			if !found {
+				log.Warn("Keeping id %s of %s in %s %s, as no %s with this id was downloaded", id, parameter,
+					config.api, config.name, referencedApi)
+				continue
+			}
+
+			config.parameters[parameter] = "/" + strings.Join([]string{project, referencedApi, name}, "/") + ".id"
+			changed[config.api] = struct{}{}
+		}
+	}
+
+	return changed
+}
+
+// writeResolvedYamls resolves references and writes the yaml files of the apis whose parameters changed again.
+// Failures to write a yaml file are added to failures.
+func (d *downloadedConfigs) writeResolvedYamls(fs afero.Fs, project string, failures *downloadFailures, log *util.Logger) {
+	changed := d.resolveReferences(project, log)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, yaml := range d.yamls {
+		if _, found := changed[yaml.api]; !found {
+			continue
+		}
+		if err := yaml.ycreator.CreateYamlFile(fs, yaml.path, yaml.api); err != nil {
+			log.Error("error creating config api yaml file: %v", err)
+			failures.add(yaml.api, "", err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/jsoncreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/yamlcreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestResolveReferencesReplacesIdsOfDownloadedConfigs(t *testing.T) {
+	downloaded := newDownloadedConfigs()
+	downloaded.add("management-zone", "1234", "zone", nil)
+
+	resolved := map[string]string{"managementZoneId": "1234"}
+	unresolved := map[string]string{"managementZoneId": "5678"}
+	downloaded.add("alerting-profile", "a", "profile", resolved)
+	downloaded.add("alerting-profile", "b", "other-profile", unresolved)
+
+	changed := downloaded.resolveReferences("project", util.DefaultLogger())
+
+	assert.DeepEqual(t, resolved, map[string]string{"managementZoneId": "/project/management-zone/zone.id"})
+	assert.DeepEqual(t, unresolved, map[string]string{"managementZoneId": "5678"})
+	assert.DeepEqual(t, changed, map[string]struct{}{"alerting-profile": {}})
+}
+
+func TestResolveReferencesOnlyReplacesReferenceParameters(t *testing.T) {
+	downloaded := newDownloadedConfigs()
+	downloaded.add("management-zone", "1234", "zone", nil)
+
+	parameters := map[string]string{"threshold": "1234"}
+	downloaded.add("alerting-profile", "a", "profile", parameters)
+
+	changed := downloaded.resolveReferences("project", util.DefaultLogger())
+
+	assert.DeepEqual(t, parameters, map[string]string{"threshold": "1234"})
+	assert.Equal(t, len(changed), 0)
+}
+
+func TestDownloadedProjectReferencesDownloadedManagementZone(t *testing.T) {
+	apis := api.NewApis()
+	payloads := map[string]string{
+		"zone-id":    `{"id": "zone-id", "name": "zone"}`,
+		"profile-id": `{"id": "profile-id", "name": "profile", "managementZoneId": "zone-id"}`,
+	}
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().List(apis["management-zone"]).Return([]api.Value{{Id: "zone-id", Name: "zone"}}, nil)
+	client.EXPECT().List(apis["alerting-profile"]).Return([]api.Value{{Id: "profile-id", Name: "profile"}}, nil)
+	client.EXPECT().ReadById(gomock.Any(), gomock.Any()).DoAndReturn(func(_ api.Api, id string) ([]byte, error) {
+		return []byte(payloads[id]), nil
+	}).AnyTimes()
+
+	fs := afero.NewMemMapFs()
+	pool := newWorkerPool(context.Background(), 1)
+	failures := &downloadFailures{}
+	downloaded := newDownloadedConfigs()
+
+	// the profile is downloaded first, the reference is resolved once all apis have been downloaded
+	for _, id := range []string{"alerting-profile", "management-zone"} {
+		err := createConfigsFromAPI(fs, apis[id], "123", "project", client, jsoncreator.NewJSONCreator(util.DefaultLogger()), yamlcreator.NewYamlConfig(util.DefaultLogger()),
+			pool, failures, nil, downloaded, util.DefaultLogger())
+		assert.NilError(t, err)
+	}
+
+	downloaded.writeResolvedYamls(fs, "project", failures, util.DefaultLogger())
+	assert.Equal(t, len(failures.sorted()), 0)
+
+	content, err := afero.ReadFile(fs, filepath.Join("project", "alerting-profile", "alerting-profile.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `config:
+- profile: profile.json
+profile:
+- name: profile
+  managementZoneId: /project/management-zone/zone.id
+`)
+}
@@ -0,0 +1,193 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/yamlcreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// createConfigsFromSettingsAPI downloads the settings objects of all schemas into the folder of the settings api. The
+// json file of a config contains the value of the object, its yaml parameters the schemaId and scope. Objects are named
+// by their summary, which is made unique, as summaries of different objects may be equal. Objects not modified since
+// the point in time of the since filter are skipped.
+func createConfigsFromSettingsAPI(
+	fs afero.Fs,
+	theApi api.Api,
+	fullpath string,
+	client rest.DynatraceClient,
+	ycreator yamlcreator.YamlCreator,
+	pool *workerPool,
+	failures *downloadFailures,
+	filter *nameFilter,
+	since *sinceFilter,
+	downloaded *downloadedConfigs,
+	log *util.Logger,
+) (err error) {
+	var schemaIds []string
+	pool.do(func() {
+		schemaIds, err = client.ListSchemas(theApi)
+	})
+	if err != nil {
+		log.Error("error getting settings schemas from api %v %v", theApi.GetId(), err)
+		return err
+	}
+
+	// the order of the list is not stable, so the schemas are sorted to always write the yaml file in the same order
+	sort.Strings(schemaIds)
+
+	objects := make([][]api.DownloadedSettingsObject, len(schemaIds))
+	errs := make([]error, len(schemaIds))
+
+	var wg sync.WaitGroup
+	for i, schemaId := range schemaIds {
+		i, schemaId := i, schemaId
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			pool.do(func() {
+				objects[i], errs[i] = client.ListSettings(theApi, schemaId)
+			})
+		}()
+	}
+	wg.Wait()
+
+	var subPath string
+	names := make(map[string]struct{})
+	count := 0
+
+	for i, schemaId := range schemaIds {
+		if errs[i] != nil {
+			log.Error("error getting settings of schema %s: %v", schemaId, errs[i])
+			failures.add(theApi.GetId(), schemaId, errs[i])
+			continue
+		}
+
+		for _, object := range objects[i] {
+			name := object.Summary
+			if name == "" {
+				name = object.SchemaId
+			}
+			if !filter.matches(name) {
+				continue
+			}
+			if modified, known := settingsModificationTime(object); !since.matches(modified, known) {
+				log.Debug("Settings object %s has been filtered out, as it was last modified at %s", name, modified.Format(time.RFC3339))
+				continue
+			}
+
+			if subPath == "" {
+				subPath, err = createConfigsFolder(fs, theApi, fullpath)
+				if err != nil {
+					log.Error("error creating folder for api %v %v", theApi.GetId(), err)
+					return err
+				}
+			}
+
+			name, cleanName := uniqueSettingsName(name, names)
+
+			content, err := formatSettingsValue(object.Value)
+			if err != nil {
+				failures.add(theApi.GetId(), name, err)
+				continue
+			}
+
+			err = afero.WriteFile(fs, filepath.Join(subPath, cleanName+".json"), content, 0664)
+			if err != nil {
+				failures.add(theApi.GetId(), name, err)
+				continue
+			}
+
+			parameters := map[string]string{
+				config.SchemaIdParameter: object.SchemaId,
+				config.ScopeParameter:    object.Scope,
+			}
+			ycreator.AddConfig(cleanName, name, parameters)
+			downloaded.add(theApi.GetId(), object.ObjectId, cleanName, parameters)
+			count++
+		}
+	}
+
+	if count == 0 {
+		log.Info("No elements for API %s", theApi.GetId())
+		return nil
+	}
+
+	err = ycreator.CreateYamlFile(fs, subPath, theApi.GetId())
+	if err != nil {
+		log.Error("error creating config api yaml file: %v", err)
+		return err
+	}
+
+	downloaded.addYaml(theApi.GetId(), subPath, ycreator)
+	return nil
+}
+
+// settingsModificationTime returns when the settings object was last modified. Known is false, if the environment didn't
+// return the time.
+func settingsModificationTime(object api.DownloadedSettingsObject) (modified time.Time, known bool) {
+	if object.ModificationInfo == nil || object.ModificationInfo.LastModifiedTime == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, object.ModificationInfo.LastModifiedTime*int64(time.Millisecond)), true
+}
+
+// uniqueSettingsName returns the name and file name of a settings object, adding a number if the file name is
+// already used by another object
+func uniqueSettingsName(name string, used map[string]struct{}) (string, string) {
+	cleanName := util.SanitizeName(name)
+	if cleanName == "" {
+		cleanName = "setting"
+	}
+
+	uniqueName, uniqueCleanName := name, cleanName
+	for i := 2; ; i++ {
+		if _, found := used[uniqueCleanName]; !found {
+			break
+		}
+		uniqueName = name + " (" + strconv.Itoa(i) + ")"
+		uniqueCleanName = cleanName + "-" + strconv.Itoa(i)
+	}
+
+	used[uniqueCleanName] = struct{}{}
+	return uniqueName, uniqueCleanName
+}
+
+// formatSettingsValue indents the value of a settings object like the json files of other configs
+func formatSettingsValue(value json.RawMessage) ([]byte, error) {
+	var formatted bytes.Buffer
+	if err := json.Indent(&formatted, value, "", " "); err != nil {
+		return nil, fmt.Errorf("invalid value of settings object: %w", err)
+	}
+	formatted.WriteString("\n")
+	return formatted.Bytes(), nil
+}
@@ -0,0 +1,136 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/download/yamlcreator"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/rest"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestCreateConfigsFromSettingsAPIDownloadsObjectsOfAllSchemas(t *testing.T) {
+	settings := api.NewApis()["settings"]
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ListSchemas(settings).Return([]string{"builtin:tags.auto-tagging", "builtin:alerting.profile"}, nil)
+	client.EXPECT().ListSettings(settings, "builtin:alerting.profile").Return([]api.DownloadedSettingsObject{
+		{ObjectId: "a", SchemaId: "builtin:alerting.profile", Scope: "environment", Summary: "Default", Value: json.RawMessage(`{"name":"Default","severityRules":[]}`)},
+	}, nil)
+	client.EXPECT().ListSettings(settings, "builtin:tags.auto-tagging").Return([]api.DownloadedSettingsObject{
+		{ObjectId: "b", SchemaId: "builtin:tags.auto-tagging", Scope: "environment", Summary: "Default", Value: json.RawMessage(`{"name":"Default"}`)},
+		{ObjectId: "c", SchemaId: "builtin:tags.auto-tagging", Scope: "HOST-1234", Value: json.RawMessage(`{}`)},
+	}, nil)
+
+	fs := afero.NewMemMapFs()
+	failures := &downloadFailures{}
+
+	err := createConfigsFromSettingsAPI(fs, settings, "project", client, yamlcreator.NewYamlConfig(util.DefaultLogger()), newWorkerPool(context.Background(), 1),
+		failures, nil, nil, newDownloadedConfigs(), util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Equal(t, len(failures.sorted()), 0)
+
+	content, err := afero.ReadFile(fs, filepath.Join("project", "settings", "settings.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `config:
+- Default: Default.json
+- Default-2: Default-2.json
+- builtintagsauto-tagging: builtintagsauto-tagging.json
+Default:
+- name: Default
+  schemaId: builtin:alerting.profile
+  scope: environment
+Default-2:
+- name: Default (2)
+  schemaId: builtin:tags.auto-tagging
+  scope: environment
+builtintagsauto-tagging:
+- name: builtin:tags.auto-tagging
+  schemaId: builtin:tags.auto-tagging
+  scope: HOST-1234
+`)
+
+	content, err = afero.ReadFile(fs, filepath.Join("project", "settings", "Default.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "{\n \"name\": \"Default\",\n \"severityRules\": []\n}\n")
+}
+
+func TestCreateConfigsFromSettingsAPIAddsFailuresOfSchemas(t *testing.T) {
+	settings := api.NewApis()["settings"]
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ListSchemas(settings).Return([]string{"builtin:alerting.profile"}, nil)
+	client.EXPECT().ListSettings(settings, "builtin:alerting.profile").Return(nil, errors.New("access denied"))
+
+	fs := afero.NewMemMapFs()
+	failures := &downloadFailures{}
+
+	err := createConfigsFromSettingsAPI(fs, settings, "project", client, yamlcreator.NewYamlConfig(util.DefaultLogger()), newWorkerPool(context.Background(), 1),
+		failures, nil, nil, newDownloadedConfigs(), util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Equal(t, len(failures.sorted()), 1)
+
+	exists, err := afero.DirExists(fs, filepath.Join("project", "settings"))
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+}
+
+func TestCreateConfigsFromSettingsAPISkipsObjectsNotModifiedSince(t *testing.T) {
+	settings := api.NewApis()["settings"]
+	now := time.Date(2022, 6, 10, 12, 0, 0, 0, time.UTC)
+	modifiedAt := func(at time.Time) *api.SettingsModificationInfo {
+		return &api.SettingsModificationInfo{LastModifiedTime: at.UnixNano() / int64(time.Millisecond)}
+	}
+
+	client := rest.CreateDynatraceClientMockFactory(t)
+	client.EXPECT().ListSchemas(settings).Return([]string{"builtin:alerting.profile"}, nil)
+	client.EXPECT().ListSettings(settings, "builtin:alerting.profile").Return([]api.DownloadedSettingsObject{
+		{ObjectId: "a", SchemaId: "builtin:alerting.profile", Scope: "environment", Summary: "recent", Value: json.RawMessage(`{}`), ModificationInfo: modifiedAt(now.Add(-time.Hour))},
+		{ObjectId: "b", SchemaId: "builtin:alerting.profile", Scope: "environment", Summary: "old", Value: json.RawMessage(`{}`), ModificationInfo: modifiedAt(now.AddDate(0, -1, 0))},
+		{ObjectId: "c", SchemaId: "builtin:alerting.profile", Scope: "environment", Summary: "boundary", Value: json.RawMessage(`{}`), ModificationInfo: modifiedAt(now.Add(-24 * time.Hour))},
+		{ObjectId: "d", SchemaId: "builtin:alerting.profile", Scope: "environment", Summary: "unknown", Value: json.RawMessage(`{}`)},
+		{ObjectId: "e", SchemaId: "builtin:alerting.profile", Scope: "environment", Summary: "unset", Value: json.RawMessage(`{}`), ModificationInfo: &api.SettingsModificationInfo{}},
+	}, nil)
+
+	since, err := newSinceFilter("24h", now)
+	assert.NilError(t, err)
+
+	fs := afero.NewMemMapFs()
+	downloaded := newDownloadedConfigs()
+
+	err = createConfigsFromSettingsAPI(fs, settings, "project", client, yamlcreator.NewYamlConfig(util.DefaultLogger()), newWorkerPool(context.Background(), 1),
+		&downloadFailures{}, nil, since, downloaded, util.DefaultLogger())
+	assert.NilError(t, err)
+
+	for name, expected := range map[string]bool{"recent": true, "old": false, "boundary": true, "unknown": true, "unset": true} {
+		exists, err := afero.Exists(fs, filepath.Join("project", "settings", name+".json"))
+		assert.NilError(t, err)
+		assert.Equal(t, exists, expected, name)
+	}
+}
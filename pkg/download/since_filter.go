@@ -0,0 +1,72 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sinceDateFormat is the format of a date passed to --since, which is read as midnight UTC
+const sinceDateFormat = "2006-01-02"
+
+// sinceFilter selects the objects to download by the time they were last modified. Objects modified at or after the
+// point in time of the filter match. Objects whose modification time is unknown always match, as it can't be told
+// whether they changed. A nil sinceFilter matches every object.
+type sinceFilter struct {
+	since time.Time
+}
+
+// newSinceFilter creates a filter from a duration before now (e.g. 90m, 24h or 7d), an RFC 3339 timestamp (e.g.
+// 2022-06-01T12:00:00Z) or a date (e.g. 2022-06-01). An empty value returns a nil filter.
+func newSinceFilter(value string, now time.Time) (*sinceFilter, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		if count, err := strconv.Atoi(days); err == nil && count >= 0 {
+			return &sinceFilter{since: now.AddDate(0, 0, -count)}, nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return &sinceFilter{since: now.Add(-duration)}, nil
+	}
+
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return &sinceFilter{since: timestamp}, nil
+	}
+
+	if date, err := time.Parse(sinceDateFormat, value); err == nil {
+		return &sinceFilter{since: date}, nil
+	}
+
+	return nil, fmt.Errorf("invalid since filter %s: must be a duration (e.g. 24h or 7d), an RFC 3339 timestamp (e.g. 2022-06-01T12:00:00Z) or a date (e.g. 2022-06-01)", value)
+}
+
+// matches returns whether an object last modified at the given time is downloaded. Known is false, if the modification
+// time of the object is unknown.
+func (f *sinceFilter) matches(modified time.Time, known bool) bool {
+	if f == nil || !known {
+		return true
+	}
+	return !modified.Before(f.since)
+}
@@ -0,0 +1,70 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+var sinceTestNow = time.Date(2022, 6, 10, 12, 0, 0, 0, time.UTC)
+
+func TestNewSinceFilterReturnsNilWithoutValue(t *testing.T) {
+	filter, err := newSinceFilter(" ", sinceTestNow)
+	assert.NilError(t, err)
+	assert.Assert(t, filter == nil)
+	assert.Assert(t, filter.matches(time.Time{}, true))
+}
+
+func TestNewSinceFilterReadsDurationsTimestampsAndDates(t *testing.T) {
+	tests := map[string]time.Time{
+		"90m":                       time.Date(2022, 6, 10, 10, 30, 0, 0, time.UTC),
+		"24h":                       time.Date(2022, 6, 9, 12, 0, 0, 0, time.UTC),
+		"7d":                        time.Date(2022, 6, 3, 12, 0, 0, 0, time.UTC),
+		"2022-06-01T08:15:00Z":      time.Date(2022, 6, 1, 8, 15, 0, 0, time.UTC),
+		"2022-06-01T10:15:00+02:00": time.Date(2022, 6, 1, 8, 15, 0, 0, time.UTC),
+		"2022-06-01":                time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	for value, expected := range tests {
+		filter, err := newSinceFilter(value, sinceTestNow)
+		assert.NilError(t, err, value)
+		assert.Assert(t, filter.since.Equal(expected), "%s: got %s, expected %s", value, filter.since, expected)
+	}
+}
+
+func TestNewSinceFilterRejectsInvalidValues(t *testing.T) {
+	for _, value := range []string{"yesterday", "-24h", "-1d", "d", "2022-13-01"} {
+		_, err := newSinceFilter(value, sinceTestNow)
+		assert.ErrorContains(t, err, "invalid since filter "+value)
+	}
+}
+
+func TestSinceFilterMatchesObjectsModifiedSinceOrWithUnknownTime(t *testing.T) {
+	filter, err := newSinceFilter("24h", sinceTestNow)
+	assert.NilError(t, err)
+
+	assert.Assert(t, filter.matches(sinceTestNow, true))
+	assert.Assert(t, filter.matches(sinceTestNow.Add(-24*time.Hour), true))
+	assert.Assert(t, !filter.matches(sinceTestNow.Add(-25*time.Hour), true))
+	assert.Assert(t, filter.matches(time.Time{}, false))
+}
@@ -0,0 +1,64 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tagFilter selects the configs to download by their tags. A config matches if it carries at least one of the tags of
+// the filter. A tag of the filter without value, e.g. 'team', matches the tag with any value, e.g. 'team:a', while a tag
+// with value, e.g. 'team:a', only matches this value. A nil tagFilter matches every config.
+type tagFilter struct {
+	tags []string
+}
+
+// newTagFilter creates a filter from the given tags. No tags return a nil filter.
+func newTagFilter(tags []string) (*tagFilter, error) {
+	var cleaned []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if strings.HasPrefix(tag, ":") {
+			return nil, fmt.Errorf("invalid tag filter %s: the tag has no key", tag)
+		}
+		cleaned = append(cleaned, tag)
+	}
+
+	if len(cleaned) == 0 {
+		return nil, nil
+	}
+	return &tagFilter{tags: cleaned}, nil
+}
+
+func (f *tagFilter) matches(tags []string) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, expected := range f.tags {
+		for _, tag := range tags {
+			if tag == expected || (!strings.Contains(expected, ":") && strings.HasPrefix(tag, expected+":")) {
+				return true
+			}
+		}
+	}
+	return false
+}
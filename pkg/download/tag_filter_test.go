@@ -0,0 +1,50 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package download
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestNewTagFilterReturnsNilWithoutTags(t *testing.T) {
+	filter, err := newTagFilter([]string{" "})
+	assert.NilError(t, err)
+	assert.Assert(t, filter == nil)
+	assert.Assert(t, filter.matches(nil))
+}
+
+func TestTagFilterMatchesConfigsCarryingOneOfTheTags(t *testing.T) {
+	filter, err := newTagFilter([]string{"team:a", "shared"})
+	assert.NilError(t, err)
+
+	assert.Assert(t, filter.matches([]string{"team:a"}))
+	assert.Assert(t, filter.matches([]string{"prod", "shared"}))
+	assert.Assert(t, filter.matches([]string{"shared:yes"}))
+	assert.Assert(t, !filter.matches([]string{"team:b", "team"}))
+	assert.Assert(t, !filter.matches([]string{"team:a:b", "sharedx"}))
+	assert.Assert(t, !filter.matches(nil))
+}
+
+func TestNewTagFilterRejectsTagsWithoutKey(t *testing.T) {
+	_, err := newTagFilter([]string{":a"})
+	assert.ErrorContains(t, err, "invalid tag filter :a")
+}
@@ -27,31 +27,36 @@ import (
 //YamlCreator implements method to create the yaml configuration file
 type YamlCreator interface {
 	CreateYamlFile(fs afero.Fs, path string, name string) error
-	AddConfig(name string, rawName string)
+	AddConfig(name string, rawName string, parameters map[string]string)
 }
 
 //YamlConfig defines the structure for the config file for each API
 type YamlConfig struct {
 	Config []map[string]string
 	Detail map[string][]DetailConfig `yaml:",inline"`
+
+	// log logs the yaml files which couldn't be created
+	log *util.Logger
 }
 
 //DetailConfig sets the default properties to be set replace in each json file
 type DetailConfig struct {
 	Name string `yaml:"name"`
+	// Parameters hold the values of environment specific fields of the json file
+	Parameters map[string]string `yaml:",inline"`
 }
 
-//NewYamlConfig return a new yaml struct with Config and Detail as fields
-func NewYamlConfig() *YamlConfig {
-	yamlConfig := YamlConfig{}
+//NewYamlConfig return a new yaml struct with Config and Detail as fields, which logs to the given logger
+func NewYamlConfig(log *util.Logger) *YamlConfig {
+	yamlConfig := YamlConfig{log: log}
 	yamlConfig.Detail = make(map[string][]DetailConfig)
 	return &yamlConfig
 }
 
 //AddConfig allows to add new configs to the yaml file
-func (yc *YamlConfig) AddConfig(name string, rawName string) {
+func (yc *YamlConfig) AddConfig(name string, rawName string, parameters map[string]string) {
 
-	config := DetailConfig{Name: rawName}
+	config := DetailConfig{Name: rawName, Parameters: parameters}
 	mp := make(map[string]string)
 	mp[name] = name + ".json"
 	yc.Config = append(yc.Config, mp)
@@ -63,13 +68,13 @@ func (yc *YamlConfig) CreateYamlFile(fs afero.Fs, path string, name string) erro
 
 	data, err := yaml.Marshal(yc)
 	if err != nil {
-		util.Log.Error("error parsing yaml file: %v", err)
+		yc.log.Error("error parsing yaml file: %v", err)
 		return err
 	}
 	fullPath := filepath.Join(path, name+".yaml")
 	err = afero.WriteFile(fs, fullPath, data, 0664)
 	if err != nil {
-		util.Log.Error("error creating yaml file %s", name)
+		yc.log.Error("error creating yaml file %s", name)
 		return err
 	}
 	return nil
@@ -21,27 +21,50 @@ import (
 	"testing"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
 	"gotest.tools/assert"
 )
 
 func TestNewYamlConfig(t *testing.T) {
-	config := NewYamlConfig()
+	config := NewYamlConfig(util.DefaultLogger())
 	assert.Check(t, config.Detail != nil, "map not initialized")
 }
 
 func TestAddConfig(t *testing.T) {
 	//test special name in config file
-	config := NewYamlConfig()
-	config.AddConfig("test", "test 1234")
+	config := NewYamlConfig(util.DefaultLogger())
+	config.AddConfig("test", "test 1234", nil)
 	assert.Check(t, len(config.Detail["test"]) == 1)
 	assert.Check(t, config.Detail["test"][0].Name == "test 1234")
 }
 
 func TestCreateYamlFile(t *testing.T) {
 	// ctrl := gomock.NewController(t)
-	config := NewYamlConfig()
-	config.AddConfig("test", "test 1234")
+	config := NewYamlConfig(util.DefaultLogger())
+	config.AddConfig("test", "test 1234", nil)
 	fileCreator := util.CreateTestFileSystem()
 	err := config.CreateYamlFile(fileCreator, "", "test")
 	assert.NilError(t, err)
 }
+
+func TestCreateYamlFileWithParameters(t *testing.T) {
+	config := NewYamlConfig(util.DefaultLogger())
+	config.AddConfig("profile", "my profile", map[string]string{"managementZoneId": "1234"})
+	fs := afero.NewMemMapFs()
+
+	err := config.CreateYamlFile(fs, "", "alerting-profile")
+	assert.NilError(t, err)
+
+	content, err := afero.ReadFile(fs, "alerting-profile.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), `config:
+- profile: profile.json
+profile:
+- name: my profile
+  managementZoneId: "1234"
+`)
+
+	err, parsed := util.UnmarshalYaml(string(content), "alerting-profile.yaml")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parsed["profile"], map[string]string{"name": "my profile", "managementZoneId": "1234"})
+}
@@ -17,18 +17,45 @@
 package environment
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/secret"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
 )
 
 type Environment interface {
 	GetId() string
 	GetEnvironmentUrl() string
 	GetToken() (string, error)
+
+	// GetNamedToken returns the token of the given name, which is defined in addition to the default token. The
+	// default token is returned for an empty name.
+	GetNamedToken(name string) (string, error)
+
+	// HasToken returns whether a token of the given name is defined. The default token has an empty name.
+	HasToken(name string) bool
+
 	GetGroup() string
+
+	// GetTags returns the tags of the environment, which configs use to be deployed only to some environments
+	GetTags() []string
+
+	// IsProduction returns whether the environment is flagged as production, so deployments to it are confirmed
+	IsProduction() bool
+
+	// GetManagedClusterUrl returns the url of the Dynatrace Managed cluster the environment belongs to, which is
+	// used for cluster APIs. It is empty if no cluster is defined.
+	GetManagedClusterUrl() string
+
+	// GetManagedClusterToken returns the cluster API token of the Dynatrace Managed cluster. It is empty if no
+	// cluster is defined.
+	GetManagedClusterToken() (string, error)
 }
 
 type environmentImpl struct {
@@ -37,15 +64,57 @@ type environmentImpl struct {
 	group          string
 	environmentUrl string
 	envTokenName   string
+
+	// tokenSecret references the token in a secret backend, e.g. vault://secret/data/dynatrace#token. It is read
+	// if the environment variable envTokenName is not set.
+	tokenSecret string
+
+	// tokenFile is the file containing the token, which is read if neither envTokenName nor tokenSecret are available
+	tokenFile string
+	fs        afero.Fs
+
+	// managedClusterUrl is the url of the Dynatrace Managed cluster, whose cluster API token is read from the
+	// environment variable managedClusterTokenName
+	managedClusterUrl       string
+	managedClusterTokenName string
+
+	// tags are the comma-separated values of the property tags
+	tags []string
+
+	// production is the value of the property production
+	production bool
+
+	// namedTokens are the tokens defined in addition to the default token, by their name. Configs select them to
+	// be deployed using a token with other scopes.
+	namedTokens map[string]tokenSources
 }
 
+// tokenSources are the sources of a named token, which are read like the ones of the default token
+type tokenSources struct {
+	envTokenName string
+	tokenSecret  string
+	tokenFile    string
+}
+
+// Named tokens are defined by the token properties with the name of the token as suffix, e.g. env-token-name.settings
+const (
+	envTokenNamePrefix   = "env-token-name."
+	envTokenSecretPrefix = "env-token-secret."
+	envTokenFilePrefix   = "env-token-file."
+)
+
 func NewEnvironments(maps map[string]map[string]string) (map[string]Environment, []error) {
+	return newEnvironments(maps, afero.NewOsFs())
+}
+
+// newEnvironments creates the environments of the given maps. Token files are read from the given file system.
+func newEnvironments(maps map[string]map[string]string, fs afero.Fs) (map[string]Environment, []error) {
 
 	environments := make(map[string]Environment)
 	errors := make([]error, 0)
 
 	for id, details := range maps {
-		environment, err := newEnvironment(id, details)
+		environment, err := newEnvironment(id, details, fs)
 		if err != nil {
 			errors = append(errors, err)
 		} else {
@@ -61,7 +130,7 @@ func NewEnvironments(maps map[string]map[string]string) (map[string]Environment,
 	return environments, errors
 }
 
-func newEnvironment(id string, properties map[string]string) (Environment, error) {
+func newEnvironment(id string, properties map[string]string, fs afero.Fs) (Environment, error) {
 
 	// only one group per environment is allowed
 	// ignore environments with leading or trailing `.`
@@ -84,17 +153,153 @@ func newEnvironment(id string, properties map[string]string) (Environment, error
 
 	environmentName, nameErr := util.CheckProperty(properties, "name")
 	environmentUrl, urlErr := util.CheckProperty(properties, "env-url")
+
+	// the token is taken from an environment variable, a secret backend or a file, at least one of them is required
 	envTokenName, tokenErr := util.CheckProperty(properties, "env-token-name")
+	tokenSecret := strings.TrimSpace(properties["env-token-secret"])
+	tokenFile := strings.TrimSpace(properties["env-token-file"])
+	if tokenSecret != "" || tokenFile != "" {
+		tokenErr = nil
+	} else if tokenErr != nil {
+		tokenErr = fmt.Errorf("Property env-token-name, env-token-secret or env-token-file was not available")
+	}
+
+	if tokenSecret != "" && !secret.NewResolver(fs).IsReference(tokenSecret) {
+		tokenErr = fmt.Errorf("Property env-token-secret must reference a secret, e.g. vault://secret/data/dynatrace#token")
+	}
 
 	if nameErr != nil || urlErr != nil || tokenErr != nil {
 		return nil, fmt.Errorf("failed to parse config for environment %s (issues: %s %s %s)", id, nameErr, urlErr, tokenErr)
 	}
 
-	return NewEnvironment(id, environmentName, environmentGroup, environmentUrl, envTokenName), nil
+	environmentUrl, err := normalizeEnvironmentUrl(environmentUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid env-url of environment %s: %w", id, err)
+	}
+
+	// cluster APIs of Dynatrace Managed are only available, if the cluster is defined
+	managedClusterUrl := strings.TrimSpace(properties["managed-cluster-url"])
+	managedClusterTokenName := strings.TrimSpace(properties["managed-cluster-token-name"])
+	if managedClusterUrl != "" {
+		if managedClusterTokenName == "" {
+			return nil, fmt.Errorf("failed to parse config for environment %s: property managed-cluster-token-name is required if managed-cluster-url is defined", id)
+		}
+
+		managedClusterUrl, err = normalizeEnvironmentUrl(managedClusterUrl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid managed-cluster-url of environment %s: %w", id, err)
+		}
+	} else if managedClusterTokenName != "" {
+		return nil, fmt.Errorf("failed to parse config for environment %s: property managed-cluster-token-name requires managed-cluster-url", id)
+	}
+
+	production := false
+	if value := strings.TrimSpace(properties["production"]); value != "" {
+		production, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config for environment %s: property production must be true or false, but was %s", id, value)
+		}
+	}
+
+	namedTokens, err := parseNamedTokens(properties, fs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config for environment %s: %w", id, err)
+	}
+
+	environment := NewEnvironment(id, environmentName, environmentGroup, environmentUrl, envTokenName).(*environmentImpl)
+	environment.tokenSecret = tokenSecret
+	environment.tokenFile = tokenFile
+	environment.fs = fs
+	environment.managedClusterUrl = managedClusterUrl
+	environment.managedClusterTokenName = managedClusterTokenName
+	environment.tags = splitEnvironmentNames(properties["tags"])
+	environment.production = production
+	environment.namedTokens = namedTokens
+
+	return environment, nil
+}
+
+// parseNamedTokens returns the sources of the tokens defined by properties with a token name as suffix
+func parseNamedTokens(properties map[string]string, fs afero.Fs) (map[string]tokenSources, error) {
+	tokens := make(map[string]tokenSources)
+
+	for key, value := range properties {
+		var name string
+		var sources tokenSources
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(key, envTokenNamePrefix):
+			name = strings.TrimPrefix(key, envTokenNamePrefix)
+			sources = tokens[name]
+			sources.envTokenName = value
+		case strings.HasPrefix(key, envTokenSecretPrefix):
+			name = strings.TrimPrefix(key, envTokenSecretPrefix)
+			if !secret.NewResolver(fs).IsReference(value) {
+				return nil, fmt.Errorf("property %s must reference a secret, e.g. vault://secret/data/dynatrace#token", key)
+			}
+			sources = tokens[name]
+			sources.tokenSecret = value
+		case strings.HasPrefix(key, envTokenFilePrefix):
+			name = strings.TrimPrefix(key, envTokenFilePrefix)
+			sources = tokens[name]
+			sources.tokenFile = value
+		default:
+			continue
+		}
+
+		if strings.TrimSpace(name) == "" || value == "" {
+			return nil, fmt.Errorf("property %s must define a value for a token name", key)
+		}
+		tokens[name] = sources
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return tokens, nil
+}
+
+// repeatedSlashes matches empty segments in the path of environment urls
+var repeatedSlashes = regexp.MustCompile(`/{2,}`)
+
+// normalizeEnvironmentUrl validates the environment url and returns it without trailing or repeated slashes, so api
+// paths can be appended to it. Paths, e.g. /e/<environment-id> of Managed environments, are kept.
+func normalizeEnvironmentUrl(environmentUrl string) (string, error) {
+	environmentUrl = strings.TrimSpace(environmentUrl)
+
+	if !strings.Contains(environmentUrl, "://") {
+		return "", fmt.Errorf("%s has no scheme, use https://%s", environmentUrl, environmentUrl)
+	}
+
+	parsed, err := url.Parse(environmentUrl)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a valid url: %w", environmentUrl, err)
+	}
+
+	if parsed.Scheme != "https" {
+		return "", fmt.Errorf("%s must use https", environmentUrl)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("%s has no host", environmentUrl)
+	}
+	// templated urls whose variables are empty, e.g. https://{{ .Env.TENANT }}.live.dynatrace.com, still parse
+	if hostname := parsed.Hostname(); hostname == "" || strings.HasPrefix(hostname, ".") || strings.Contains(hostname, "..") {
+		return "", fmt.Errorf("%s has an empty label in its host", environmentUrl)
+	}
+	if strings.HasSuffix(parsed.Host, ":") {
+		return "", fmt.Errorf("%s has an empty port", environmentUrl)
+	}
+	if parsed.User != nil || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("%s must not contain credentials, query parameters or a fragment", environmentUrl)
+	}
+
+	path := strings.TrimRight(repeatedSlashes.ReplaceAllString(parsed.EscapedPath(), "/"), "/")
+	return parsed.Scheme + "://" + parsed.Host + path, nil
 }
 
 func NewEnvironment(id string, name string, group string, environmentUrl string, envTokenName string) Environment {
-	environmentUrl = strings.TrimSuffix(environmentUrl, "/")
+	environmentUrl = strings.TrimRight(environmentUrl, "/")
 
 	return &environmentImpl{
 		id:             id,
@@ -102,6 +307,7 @@ func NewEnvironment(id string, name string, group string, environmentUrl string,
 		group:          group,
 		environmentUrl: environmentUrl,
 		envTokenName:   envTokenName,
+		fs:             afero.NewOsFs(),
 	}
 }
 
@@ -113,14 +319,103 @@ func (s *environmentImpl) GetEnvironmentUrl() string {
 	return s.environmentUrl
 }
 
+// GetToken returns the token of the environment. The environment variable env-token-name takes precedence over the
+// secret env-token-secret, which takes precedence over the file env-token-file. A source is skipped if its token
+// doesn't exist and another source is defined.
 func (s *environmentImpl) GetToken() (string, error) {
-	value := os.Getenv(s.envTokenName)
-	if value == "" {
-		return value, fmt.Errorf("environment variable " + s.envTokenName + " not found")
+	return s.resolveToken(s.tokenReferences(), s.envTokenName, "token")
+}
+
+// GetNamedToken returns the token of the given name. Its sources are read in the same order as the ones of the default
+// token.
+func (s *environmentImpl) GetNamedToken(name string) (string, error) {
+	if name == "" {
+		return s.GetToken()
+	}
+
+	sources, found := s.namedTokens[name]
+	if !found {
+		return "", fmt.Errorf("token %s is not defined for environment %s", name, s.id)
+	}
+	return s.resolveToken(sources.references(), sources.envTokenName, "token "+name)
+}
+
+func (s *environmentImpl) HasToken(name string) bool {
+	if name == "" {
+		return true
 	}
-	return value, nil
+	_, found := s.namedTokens[name]
+	return found
+}
+
+// resolveToken returns the token of the first available reference. description names the token in errors.
+func (s *environmentImpl) resolveToken(references []string, envTokenName string, description string) (string, error) {
+	resolver := secret.NewResolver(s.fs)
+
+	for i, reference := range references {
+		token, err := resolver.Resolve(reference)
+		if err == nil {
+			return token, nil
+		}
+
+		if !errors.Is(err, secret.ErrNotFound) || i == len(references)-1 {
+			// missing environment variables are reported as before secret references were supported
+			if reference == "env://"+envTokenName {
+				return "", err
+			}
+			return "", fmt.Errorf("could not read %s of environment %s: %w", description, s.id, err)
+		}
+	}
+
+	return "", fmt.Errorf("no %s defined for environment %s", description, s.id)
+}
+
+// tokenReferences returns the references of all defined sources of the default token, in order of their precedence
+func (s *environmentImpl) tokenReferences() []string {
+	return tokenSources{envTokenName: s.envTokenName, tokenSecret: s.tokenSecret, tokenFile: s.tokenFile}.references()
+}
+
+// references returns the references of all defined token sources, in order of their precedence
+func (t tokenSources) references() []string {
+	references := make([]string, 0, 3)
+
+	if t.envTokenName != "" {
+		references = append(references, "env://"+t.envTokenName)
+	}
+	if t.tokenSecret != "" {
+		references = append(references, t.tokenSecret)
+	}
+	if t.tokenFile != "" {
+		references = append(references, "file://"+t.tokenFile)
+	}
+
+	return references
 }
 
 func (s *environmentImpl) GetGroup() string {
 	return s.group
 }
+
+func (s *environmentImpl) GetTags() []string {
+	return s.tags
+}
+
+func (s *environmentImpl) IsProduction() bool {
+	return s.production
+}
+
+func (s *environmentImpl) GetManagedClusterUrl() string {
+	return s.managedClusterUrl
+}
+
+func (s *environmentImpl) GetManagedClusterToken() (string, error) {
+	if s.managedClusterUrl == "" {
+		return "", nil
+	}
+
+	token, err := secret.NewResolver(s.fs).Resolve("env://" + s.managedClusterTokenName)
+	if err != nil {
+		return "", fmt.Errorf("could not read cluster token of environment %s: %w", s.id, err)
+	}
+	return token, nil
+}
@@ -0,0 +1,102 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package environment
+
+import (
+	"fmt"
+	"sort"
+)
+
+// groupsKey is the reserved key of the environments file which defines named groups of environments, e.g.:
+//
+//	groups:
+//	    - all-prod: "prod-eu, prod-us"
+const groupsKey = "groups"
+
+// checkReservedGroupsKey fails if an environment uses the id reserved for the groups section, either as top-level key
+// with the properties of an environment or as id of an environment in a group, e.g. `production.groups`. Otherwise,
+// the properties of the environment would be read as groups, or the environment could not be told apart from them.
+func checkReservedGroupsKey(definitions map[string]string, environments map[string]Environment) error {
+	_, hasUrl := definitions["env-url"]
+	_, hasToken := definitions["env-token-name"]
+
+	if hasUrl || hasToken || environments[groupsKey] != nil {
+		return fmt.Errorf("environment id %s is reserved for the definition of environment groups, please rename the environment", groupsKey)
+	}
+	return nil
+}
+
+// newEnvironmentGroups returns the ids of the environments of each group. Groups are defined in the groups section
+// of the environments file, and implicitly by environments assigned to a group with `group.environment`.
+func newEnvironmentGroups(definitions map[string]string, environments map[string]Environment) (map[string][]string, []error) {
+
+	groups := make(map[string][]string)
+	errors := make([]error, 0)
+
+	for _, id := range sortedNames(environments) {
+		if group := environments[id].GetGroup(); group != "" {
+			groups[group] = append(groups[group], id)
+		}
+	}
+
+	for group, members := range definitions {
+		if environments[group] != nil {
+			errors = append(errors, fmt.Errorf("group name must differ from environment name %s", group))
+			continue
+		}
+
+		ids := splitEnvironmentNames(members)
+		if len(ids) == 0 {
+			errors = append(errors, fmt.Errorf("environment group %s does not contain any environments", group))
+			continue
+		}
+
+		for _, id := range ids {
+			if environments[id] == nil {
+				errors = append(errors, fmt.Errorf("environment %s referenced by group %s is not defined", id, group))
+				continue
+			}
+			if !contains(groups[group], id) {
+				groups[group] = append(groups[group], id)
+			}
+		}
+	}
+
+	for group := range groups {
+		sort.Strings(groups[group])
+	}
+
+	return groups, errors
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedGroupNames(groups map[string][]string) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -17,50 +17,187 @@
 package environment
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 	"github.com/spf13/afero"
 )
 
-func LoadEnvironmentList(specificEnvironment string, environmentsFile string, fs afero.Fs) (environments map[string]Environment, errorList []error) {
+// LoadEnvironmentList loads the environments of the given file. If specificEnvironment is set, only the environments
+// with the given comma-separated names are returned. Names of environment groups are expanded to their environments,
+// which is logged to the logger.
+func LoadEnvironmentList(specificEnvironment string, environmentsFile string, fs afero.Fs, log *util.Logger) (environments map[string]Environment, errorList []error) {
 
 	if environmentsFile == "" {
 		errorList = append(errorList, errors.New("no environmentfile provided"))
 		return environments, errorList
 	}
 
-	environmentsFromFile, errorList := readEnvironments(environmentsFile, fs)
+	environmentsFromFile, groups, errorList := readEnvironments(environmentsFile, fs)
 
 	if environmentsFromFile == nil || len(environmentsFromFile) == 0 {
 		errorList = append(errorList, fmt.Errorf("no environments loaded from file %s", environmentsFile))
 		return environments, errorList
 	}
 
-	if specificEnvironment != "" {
-		if environmentsFromFile[specificEnvironment] == nil {
-			errorList = append(errorList, fmt.Errorf("environment %s not found in file %s", specificEnvironment, environmentsFile))
-			return environments, errorList
+	specificEnvironments := splitEnvironmentNames(specificEnvironment)
+	if len(specificEnvironments) == 0 {
+		return environmentsFromFile, errorList
+	}
+
+	environments = make(map[string]Environment)
+	var unknownEnvironments []string
+
+	for _, name := range specificEnvironments {
+		if environmentsFromFile[name] != nil {
+			environments[name] = environmentsFromFile[name]
+			continue
 		}
 
-		environments = make(map[string]Environment)
-		environments[specificEnvironment] = environmentsFromFile[specificEnvironment]
-	} else {
-		environments = environmentsFromFile
+		if members, found := groups[name]; found {
+			log.Info("Environment group %s resolved to environments: %s", name, strings.Join(members, ", "))
+			for _, member := range members {
+				environments[member] = environmentsFromFile[member]
+			}
+			continue
+		}
+
+		unknownEnvironments = append(unknownEnvironments, name)
+	}
+
+	if len(unknownEnvironments) > 0 {
+		available := fmt.Sprintf("available environments: %s", strings.Join(sortedNames(environmentsFromFile), ", "))
+		if len(groups) > 0 {
+			available += fmt.Sprintf(", available groups: %s", strings.Join(sortedGroupNames(groups), ", "))
+		}
+
+		errorList = append(errorList, fmt.Errorf("environment %s not found in file %s, %s",
+			strings.Join(unknownEnvironments, ", "), environmentsFile, available))
+		return nil, errorList
 	}
 
 	return environments, errorList
 }
 
-// readEnvironments reads the yaml file for the environments and returns the parsed environments
-func readEnvironments(file string, fs afero.Fs) (map[string]Environment, []error) {
+// LoadEnvironmentTags returns the tags of all environments in the environments file, regardless of the environments
+// selected for a command, so configs can be checked to only use defined tags
+func LoadEnvironmentTags(environmentsFile string, fs afero.Fs) map[string]bool {
+	tags := make(map[string]bool)
+	if environmentsFile == "" {
+		return tags
+	}
+
+	environments, _, _ := readEnvironments(environmentsFile, fs)
+	for _, environment := range environments {
+		for _, tag := range environment.GetTags() {
+			tags[tag] = true
+		}
+	}
+
+	return tags
+}
+
+// splitEnvironmentNames splits a comma-separated list of environment names, ignoring empty names and duplicates. It
+// also splits the tags of environments.
+func splitEnvironmentNames(names string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+
+	return result
+}
+
+func sortedNames(environments map[string]Environment) []string {
+	names := make([]string, 0, len(environments))
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readEnvironments reads the yaml or json file for the environments and returns the parsed environments and groups
+func readEnvironments(file string, fs afero.Fs) (map[string]Environment, map[string][]string, []error) {
 
 	dat, err := afero.ReadFile(fs, file)
-	util.FailOnError(err, "Error while reading file")
+	if err != nil {
+		return nil, nil, []error{fmt.Errorf("error while reading environments file %s: %w", file, err)}
+	}
+
+	var environmentMaps map[string]map[string]string
+	if isJson(file, dat) {
+		environmentMaps, err = unmarshalJson(string(dat), file)
+		if err != nil {
+			return nil, nil, []error{err}
+		}
+	} else {
+		err, environmentMaps = util.UnmarshalYaml(string(dat), file)
+		if err != nil {
+			return nil, nil, []error{err}
+		}
+	}
+
+	groupDefinitions := environmentMaps[groupsKey]
+	delete(environmentMaps, groupsKey)
+
+	environments, errorList := newEnvironments(environmentMaps, fs)
+
+	if err := checkReservedGroupsKey(groupDefinitions, environments); err != nil {
+		return nil, nil, append(errorList, err)
+	}
+
+	groups, groupErrors := newEnvironmentGroups(groupDefinitions, environments)
+	errorList = append(errorList, groupErrors...)
+
+	return environments, groups, errorList
+}
+
+// isJson checks whether the environments file is a json file, either by its extension or by its content starting
+// with an object, unlike the lists of properties of yaml environments files
+func isJson(file string, content []byte) bool {
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		return true
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(content)), "{")
+}
+
+// unmarshalJson parses an environments file in json format into the same structure as a yaml file. Environments and
+// the groups section are objects of string properties, e.g.:
+//
+//	{
+//	    "groups": {"all-prod": "prod-eu, prod-us"},
+//	    "prod-eu": {"name": "Prod EU", "env-url": "https://prod-eu.dynatrace.com", "env-token-name": "PROD_EU"}
+//	}
+//
+// Like yaml files, the content is rendered as template first, so environment variables can be referenced.
+func unmarshalJson(text string, file string) (map[string]map[string]string, error) {
+	template, err := util.NewTemplateFromString(file, text)
+	if err != nil {
+		return nil, err
+	}
 
-	err, environmentMaps := util.UnmarshalYaml(string(dat), file)
-	util.FailOnError(err, "Error while converting file")
+	text, err = template.ExecuteTemplate(make(map[string]string))
+	if err != nil {
+		return nil, err
+	}
+
+	var environmentMaps map[string]map[string]string
+	if err := json.Unmarshal([]byte(text), &environmentMaps); err != nil {
+		return nil, fmt.Errorf("could not parse environments file %s: every environment must be an object of string properties: %w", file, err)
+	}
 
-	return NewEnvironments(environmentMaps)
+	return environmentMaps, nil
 }
@@ -23,6 +23,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/spf13/afero"
 	"gotest.tools/assert"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
@@ -165,11 +167,11 @@ func testTokenNotAvailableOnGetterCall(t *testing.T, environmentYamlContent stri
 
 func TestUrlAvailableWithTemplating(t *testing.T) {
 
-	util.SetEnv(t, "URL", "1234")
+	util.SetEnv(t, "URL", "https://1234.live.dynatrace.com")
 	e, devEnvironment := setupEnvironment(t, testYamlEnvironmentWithNewPropertyFormat, "development")
 
 	assert.NilError(t, e)
-	assert.Equal(t, "1234", devEnvironment.GetEnvironmentUrl())
+	assert.Equal(t, "https://1234.live.dynatrace.com", devEnvironment.GetEnvironmentUrl())
 
 	util.UnsetEnv(t, "URL")
 }
@@ -189,6 +191,108 @@ func TestTrailingSlashTrimmedFromEnvironmentURL(t *testing.T) {
 	}
 }
 
+func TestEnvironmentUrlIsNormalized(t *testing.T) {
+	tests := map[string]string{
+		"https://abc123.live.dynatrace.com":             "https://abc123.live.dynatrace.com",
+		"https://abc123.live.dynatrace.com/":            "https://abc123.live.dynatrace.com",
+		"https://abc123.live.dynatrace.com///":          "https://abc123.live.dynatrace.com",
+		"  https://abc123.live.dynatrace.com/ ":         "https://abc123.live.dynatrace.com",
+		"https://managed.example.com/e/abc123/":         "https://managed.example.com/e/abc123",
+		"https://managed.example.com//e//abc123":        "https://managed.example.com/e/abc123",
+		"https://managed.example.com:9999/e/abc123":     "https://managed.example.com:9999/e/abc123",
+		"https://managed.example.com/e/with%20space///": "https://managed.example.com/e/with%20space",
+	}
+
+	for input, expected := range tests {
+		environment, err := newEnvironment("dev", map[string]string{"name": "Dev", "env-url": input, "env-token-name": "DEV"}, afero.NewMemMapFs())
+		assert.NilError(t, err, input)
+		assert.Equal(t, environment.GetEnvironmentUrl(), expected, input)
+	}
+}
+
+func TestInvalidEnvironmentUrlsAreRejected(t *testing.T) {
+	tests := map[string]string{
+		"abc123.live.dynatrace.com/e/abc123":    "has no scheme",
+		"http://abc123.live.dynatrace.com":      "must use https",
+		"ftp://abc123.live.dynatrace.com":       "must use https",
+		"https:///e/abc123":                     "has no host",
+		"https://abc123.live.dynatrace.com?a=b": "must not contain",
+		"https://user:pw@abc123.dynatrace.com":  "must not contain",
+		"https://abc123.live.dynatrace.com/#x":  "must not contain",
+		"https://abc 123.live.dynatrace.com":    "is not a valid url",
+		"https://.live.dynatrace.com":           "has an empty label in its host",
+		"https://abc123..dynatrace.com":         "has an empty label in its host",
+		"https://abc123.live.dynatrace.com:":    "has an empty port",
+	}
+
+	for input, expected := range tests {
+		_, err := newEnvironment("dev", map[string]string{"name": "Dev", "env-url": input, "env-token-name": "DEV"}, afero.NewMemMapFs())
+		assert.ErrorContains(t, err, "invalid env-url of environment dev")
+		assert.ErrorContains(t, err, expected)
+	}
+}
+
+func TestManagedCluster(t *testing.T) {
+	environment, err := newEnvironment("dev", map[string]string{
+		"name":                       "Dev",
+		"env-url":                    "https://managed.example.com/e/abc",
+		"env-token-name":             "DEV",
+		"managed-cluster-url":        "https://managed.example.com/",
+		"managed-cluster-token-name": "CLUSTER_TOKEN",
+	}, afero.NewMemMapFs())
+	assert.NilError(t, err)
+	assert.Equal(t, environment.GetManagedClusterUrl(), "https://managed.example.com")
+
+	util.SetEnv(t, "CLUSTER_TOKEN", "cluster-token")
+	defer util.UnsetEnv(t, "CLUSTER_TOKEN")
+
+	token, err := environment.GetManagedClusterToken()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "cluster-token")
+}
+
+func TestManagedClusterTokenIsEmptyWithoutCluster(t *testing.T) {
+	token, err := testDevEnvironment.GetManagedClusterToken()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "")
+	assert.Equal(t, testDevEnvironment.GetManagedClusterUrl(), "")
+}
+
+func TestManagedClusterTokenFailsIfTokenIsMissing(t *testing.T) {
+	environment, err := newEnvironment("dev", map[string]string{
+		"name":                       "Dev",
+		"env-url":                    "https://managed.example.com/e/abc",
+		"env-token-name":             "DEV",
+		"managed-cluster-url":        "https://managed.example.com",
+		"managed-cluster-token-name": "UNDEFINED_CLUSTER_TOKEN",
+	}, afero.NewMemMapFs())
+	assert.NilError(t, err)
+
+	_, err = environment.GetManagedClusterToken()
+	assert.ErrorContains(t, err, "could not read cluster token of environment dev")
+}
+
+func TestInvalidManagedClusterIsRejected(t *testing.T) {
+	tests := []struct {
+		properties map[string]string
+		expected   string
+	}{
+		{map[string]string{"managed-cluster-url": "https://managed.example.com"}, "managed-cluster-token-name is required"},
+		{map[string]string{"managed-cluster-token-name": "CLUSTER_TOKEN"}, "managed-cluster-token-name requires managed-cluster-url"},
+		{map[string]string{"managed-cluster-url": "managed.example.com", "managed-cluster-token-name": "CLUSTER_TOKEN"}, "invalid managed-cluster-url of environment dev"},
+	}
+
+	for _, test := range tests {
+		properties := map[string]string{"name": "Dev", "env-url": "https://managed.example.com/e/abc", "env-token-name": "DEV"}
+		for k, v := range test.properties {
+			properties[k] = v
+		}
+
+		_, err := newEnvironment("dev", properties, afero.NewMemMapFs())
+		assert.ErrorContains(t, err, test.expected)
+	}
+}
+
 func setupEnvironment(t *testing.T, environmentYamlContent string, environmentOfInterest string) (error, Environment) {
 
 	e, result := util.UnmarshalYaml(environmentYamlContent, "test-yaml")
@@ -202,3 +306,469 @@ func setupEnvironment(t *testing.T, environmentYamlContent string, environmentOf
 
 	return e, devEnvironment
 }
+
+func writeTestEnvironmentsFile(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments.yaml", []byte(testYamlEnvironment), 0644))
+	return fs
+}
+
+func TestLoadEnvironmentListReturnsAllEnvironments(t *testing.T) {
+	environments, errs := LoadEnvironmentList("", "environments.yaml", writeTestEnvironmentsFile(t), util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(environments), 3)
+}
+
+func TestEnvironmentTags(t *testing.T) {
+	environment, err := newEnvironment("prod", map[string]string{
+		"name":           "Prod",
+		"env-url":        "https://prod.live.dynatrace.com",
+		"env-token-name": "PROD",
+		"tags":           "production, eu,,production",
+	}, afero.NewMemMapFs())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, environment.GetTags(), []string{"production", "eu"})
+
+	environment, err = newEnvironment("dev", map[string]string{"name": "Dev", "env-url": "https://dev.live.dynatrace.com", "env-token-name": "DEV"}, afero.NewMemMapFs())
+	assert.NilError(t, err)
+	assert.Equal(t, len(environment.GetTags()), 0)
+}
+
+func TestEnvironmentIsFlaggedAsProduction(t *testing.T) {
+	properties := map[string]string{"name": "Prod", "env-url": "https://prod.live.dynatrace.com", "env-token-name": "PROD"}
+
+	environment, err := newEnvironment("prod", properties, afero.NewMemMapFs())
+	assert.NilError(t, err)
+	assert.Equal(t, environment.IsProduction(), false)
+
+	properties["production"] = " true "
+	environment, err = newEnvironment("prod", properties, afero.NewMemMapFs())
+	assert.NilError(t, err)
+	assert.Equal(t, environment.IsProduction(), true)
+
+	properties["production"] = "yes"
+	_, err = newEnvironment("prod", properties, afero.NewMemMapFs())
+	assert.ErrorContains(t, err, "property production must be true or false, but was yes")
+}
+
+func TestLoadEnvironmentTagsReturnsTagsOfAllEnvironments(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	content := `
+dev:
+  - name: "Dev"
+  - env-url: "https://dev.live.dynatrace.com"
+  - env-token-name: "DEV"
+  - tags: "non-production"
+prod:
+  - name: "Prod"
+  - env-url: "https://prod.live.dynatrace.com"
+  - env-token-name: "PROD"
+  - tags: "production, eu"
+`
+	assert.NilError(t, afero.WriteFile(fs, "environments.yaml", []byte(content), 0644))
+
+	assert.DeepEqual(t, LoadEnvironmentTags("environments.yaml", fs), map[string]bool{"non-production": true, "production": true, "eu": true})
+}
+
+func TestLoadEnvironmentListReturnsSpecificEnvironments(t *testing.T) {
+	environments, errs := LoadEnvironmentList("development, prod-environment,development", "environments.yaml", writeTestEnvironmentsFile(t), util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(environments), 2)
+	assert.Assert(t, environments["development"] != nil)
+	assert.Assert(t, environments["prod-environment"] != nil)
+}
+
+func TestLoadEnvironmentListFailsOnUnknownEnvironments(t *testing.T) {
+	environments, errs := LoadEnvironmentList("development,staging,qa", "environments.yaml", writeTestEnvironmentsFile(t), util.DefaultLogger())
+
+	assert.Equal(t, len(environments), 0)
+	assert.Equal(t, len(errs), 1)
+	assert.Error(t, errs[0], "environment staging, qa not found in file environments.yaml, available environments: development, hardening, prod-environment, available groups: production")
+}
+
+const testYamlEnvironmentWithGroupDefinitions = `
+groups:
+    - all-prod: "prod-eu, prod-us"
+    - testing: "dev, staging"
+dev:
+    - name: "Dev"
+    - env-url: "https://url/to/dev/environment"
+    - env-token-name: "DEV"
+pre-prod.staging:
+    - name: "Staging"
+    - env-url: "https://url/to/staging/environment"
+    - env-token-name: "STAGING"
+prod-eu:
+    - name: "Prod EU"
+    - env-url: "https://url/to/prod-eu/environment"
+    - env-token-name: "PROD_EU"
+prod-us:
+    - name: "Prod US"
+    - env-url: "https://url/to/prod-us/environment"
+    - env-token-name: "PROD_US"
+`
+
+func TestLoadEnvironmentListExpandsGroups(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments.yaml", []byte(testYamlEnvironmentWithGroupDefinitions), 0644))
+
+	environments, errs := LoadEnvironmentList("all-prod", "environments.yaml", fs, util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(environments), 2)
+	assert.Assert(t, environments["prod-eu"] != nil)
+	assert.Assert(t, environments["prod-us"] != nil)
+
+	environments, errs = LoadEnvironmentList("testing,prod-eu", "environments.yaml", fs, util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(environments), 3)
+}
+
+func TestLoadEnvironmentListExpandsImplicitGroups(t *testing.T) {
+	environments, errs := LoadEnvironmentList("production", "environments.yaml", writeTestEnvironmentsFile(t), util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(environments), 1)
+	assert.Assert(t, environments["prod-environment"] != nil)
+}
+
+func TestLoadEnvironmentListFailsOnEnvironmentNamedGroups(t *testing.T) {
+	files := map[string]string{
+		"top-level.yaml": "groups:\n    - name: \"Groups\"\n    - env-url: \"https://url/to/groups/environment\"\n    - env-token-name: \"GROUPS\"\n",
+		"in-group.yaml":  "production.groups:\n    - name: \"Groups\"\n    - env-url: \"https://url/to/groups/environment\"\n    - env-token-name: \"GROUPS\"\n",
+	}
+
+	for file, content := range files {
+		fs := afero.NewMemMapFs()
+		assert.NilError(t, afero.WriteFile(fs, file, []byte(content), 0644))
+
+		environments, errs := LoadEnvironmentList("", file, fs, util.DefaultLogger())
+
+		assert.Equal(t, len(environments), 0, file)
+		assert.Assert(t, len(errs) > 0, file)
+		assert.Error(t, errs[0], "environment id groups is reserved for the definition of environment groups, please rename the environment", file)
+	}
+}
+
+func TestNewEnvironmentGroups(t *testing.T) {
+	environments := map[string]Environment{
+		"dev":     NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"),
+		"staging": NewEnvironment("staging", "Staging", "pre-prod", "https://url/to/staging/environment", "STAGING"),
+		"prod":    NewEnvironment("prod", "Prod", "", "https://url/to/prod/environment", "PROD"),
+	}
+
+	groups, errs := newEnvironmentGroups(map[string]string{"pre-prod": "dev, staging", "all": "prod,dev,staging"}, environments)
+
+	assert.Equal(t, len(errs), 0)
+	assert.DeepEqual(t, groups, map[string][]string{
+		"pre-prod": {"dev", "staging"},
+		"all":      {"dev", "prod", "staging"},
+	})
+}
+
+func TestNewEnvironmentGroupsFailsOnInvalidGroups(t *testing.T) {
+	environments := map[string]Environment{
+		"dev": NewEnvironment("dev", "Dev", "", "https://url/to/dev/environment", "DEV"),
+	}
+
+	_, errs := newEnvironmentGroups(map[string]string{"unknown": "dev, prod"}, environments)
+	assert.Equal(t, len(errs), 1)
+	assert.Error(t, errs[0], "environment prod referenced by group unknown is not defined")
+
+	_, errs = newEnvironmentGroups(map[string]string{"dev": "dev"}, environments)
+	assert.Equal(t, len(errs), 1)
+	assert.Error(t, errs[0], "group name must differ from environment name dev")
+
+	_, errs = newEnvironmentGroups(map[string]string{"empty": ""}, environments)
+	assert.Equal(t, len(errs), 1)
+	assert.Error(t, errs[0], "environment group empty does not contain any environments")
+}
+
+const testYamlEnvironmentWithTokenFile = `
+development:
+    - name: "Dev"
+    - env-url: "https://url/to/dev/environment"
+    - env-token-file: "/secrets/dev-token"
+hardening:
+    - name: "Hardening"
+    - env-url: "https://url/to/hardening/environment"
+    - env-token-name: "HARDENING"
+    - env-token-file: "/secrets/hardening-token"
+`
+
+func setupTokenFileEnvironments(t *testing.T, fs afero.Fs) map[string]Environment {
+	e, result := util.UnmarshalYaml(testYamlEnvironmentWithTokenFile, "test-yaml")
+	assert.NilError(t, e)
+
+	environments, errorList := newEnvironments(result, fs)
+	assert.Equal(t, len(errorList), 0)
+	return environments
+}
+
+func TestTokenReadFromTokenFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "/secrets/dev-token", []byte("dev-token-value\n"), 0600))
+
+	token, err := setupTokenFileEnvironments(t, fs)["development"].GetToken()
+
+	assert.NilError(t, err)
+	assert.Equal(t, token, "dev-token-value")
+}
+
+func TestTokenFromEnvironmentVariableTakesPrecedenceOverTokenFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "/secrets/hardening-token", []byte("file-token"), 0600))
+	environment := setupTokenFileEnvironments(t, fs)["hardening"]
+
+	util.SetEnv(t, "HARDENING", "env-token")
+	token, err := environment.GetToken()
+	util.UnsetEnv(t, "HARDENING")
+
+	assert.NilError(t, err)
+	assert.Equal(t, token, "env-token")
+
+	token, err = environment.GetToken()
+
+	assert.NilError(t, err)
+	assert.Equal(t, token, "file-token")
+}
+
+func TestTokenFileMissingOrEmpty(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	environments := setupTokenFileEnvironments(t, fs)
+
+	_, err := environments["development"].GetToken()
+	assert.ErrorContains(t, err, "could not read token of environment development: could not read file /secrets/dev-token")
+
+	assert.NilError(t, afero.WriteFile(fs, "/secrets/dev-token", []byte(" \n"), 0600))
+
+	_, err = environments["development"].GetToken()
+	assert.Error(t, err, "could not read token of environment development: file /secrets/dev-token is empty")
+}
+
+func TestEnvironmentWithoutTokenIsInvalid(t *testing.T) {
+	_, err := newEnvironment("development", map[string]string{"name": "Dev", "env-url": "https://url/to/dev/environment"}, afero.NewMemMapFs())
+
+	assert.ErrorContains(t, err, "Property env-token-name, env-token-secret or env-token-file was not available")
+}
+
+func TestTokenSecretMustBeAReference(t *testing.T) {
+	_, err := newEnvironment("development", map[string]string{
+		"name":             "Dev",
+		"env-url":          "https://url/to/dev/environment",
+		"env-token-secret": "/secrets/dev-token",
+	}, afero.NewMemMapFs())
+
+	assert.ErrorContains(t, err, "Property env-token-secret must reference a secret")
+}
+
+func TestNamedTokensAreReadFromTheirSources(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "/secrets/settings-token", []byte("settings-file-token\n"), 0600))
+
+	environment, err := newEnvironment("development", map[string]string{
+		"name":                    "Dev",
+		"env-url":                 "https://url/to/dev/environment",
+		"env-token-name":          "DEV",
+		"env-token-name.settings": "DEV_SETTINGS",
+		"env-token-file.settings": "/secrets/settings-token",
+		"env-token-name.admin":    "DEV_ADMIN",
+	}, fs)
+	assert.NilError(t, err)
+
+	util.SetEnv(t, "DEV", "default-token")
+	defer util.UnsetEnv(t, "DEV")
+	util.SetEnv(t, "DEV_ADMIN", "admin-token")
+	defer util.UnsetEnv(t, "DEV_ADMIN")
+	util.UnsetEnv(t, "DEV_SETTINGS")
+
+	token, err := environment.GetNamedToken("")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "default-token")
+
+	token, err = environment.GetNamedToken("admin")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "admin-token")
+
+	token, err = environment.GetNamedToken("settings")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "settings-file-token")
+
+	assert.Assert(t, environment.HasToken(""))
+	assert.Assert(t, environment.HasToken("settings"))
+	assert.Assert(t, !environment.HasToken("write"))
+
+	_, err = environment.GetNamedToken("write")
+	assert.Error(t, err, "token write is not defined for environment development")
+}
+
+func TestNamedTokensMustDefineNameAndSource(t *testing.T) {
+	properties := func(key string, value string) map[string]string {
+		return map[string]string{"name": "Dev", "env-url": "https://url/to/dev/environment", "env-token-name": "DEV", key: value}
+	}
+
+	_, err := newEnvironment("development", properties("env-token-name.", "DEV_ADMIN"), afero.NewMemMapFs())
+	assert.Error(t, err, "failed to parse config for environment development: property env-token-name. must define a value for a token name")
+
+	_, err = newEnvironment("development", properties("env-token-file.admin", " "), afero.NewMemMapFs())
+	assert.Error(t, err, "failed to parse config for environment development: property env-token-file.admin must define a value for a token name")
+
+	_, err = newEnvironment("development", properties("env-token-secret.admin", "/secrets/admin-token"), afero.NewMemMapFs())
+	assert.ErrorContains(t, err, "property env-token-secret.admin must reference a secret")
+}
+
+func TestTokenSecretTakesPrecedenceOverTokenFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "/secrets/dev-token", []byte("file-token"), 0600))
+
+	environment, err := newEnvironment("development", map[string]string{
+		"name":             "Dev",
+		"env-url":          "https://url/to/dev/environment",
+		"env-token-secret": "vault://secret/data/dynatrace#token",
+		"env-token-file":   "/secrets/dev-token",
+	}, fs)
+	assert.NilError(t, err)
+
+	util.UnsetEnv(t, "VAULT_ADDR")
+
+	_, err = environment.GetToken()
+	assert.ErrorContains(t, err, "could not read token of environment development: could not read vault://secret/data/dynatrace#token: environment variable VAULT_ADDR is not set")
+}
+
+const testYamlEnvironmentWithAllProperties = `
+groups:
+    - all-prod: "prod-eu, prod-us"
+dev:
+    - name: "Dev"
+    - env-url: "https://url/to/dev/environment"
+    - env-token-name: "DEV"
+production.prod-eu:
+    - name: "Prod EU"
+    - env-url: "https://url/to/prod-eu/environment"
+    - env-token-secret: "vault://secret/data/dynatrace#prod-eu"
+production.prod-us:
+    - name: "Prod US"
+    - env-url: "https://url/to/prod-us/environment"
+    - env-token-file: "/var/run/secrets/prod-us-token"
+`
+
+const testJsonEnvironmentWithAllProperties = `{
+	"groups": {"all-prod": "prod-eu, prod-us"},
+	"dev": {
+		"name": "Dev",
+		"env-url": "https://url/to/dev/environment",
+		"env-token-name": "DEV"
+	},
+	"production.prod-eu": {
+		"name": "Prod EU",
+		"env-url": "https://url/to/prod-eu/environment",
+		"env-token-secret": "vault://secret/data/dynatrace#prod-eu"
+	},
+	"production.prod-us": {
+		"name": "Prod US",
+		"env-url": "https://url/to/prod-us/environment",
+		"env-token-file": "/var/run/secrets/prod-us-token"
+	}
+}`
+
+func TestJsonAndYamlEnvironmentsFilesAreParsedIdentically(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments.yaml", []byte(testYamlEnvironmentWithAllProperties), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "environments.json", []byte(testJsonEnvironmentWithAllProperties), 0644))
+
+	yamlEnvironments, yamlGroups, errs := readEnvironments("environments.yaml", fs)
+	assert.Equal(t, len(errs), 0)
+	jsonEnvironments, jsonGroups, errs := readEnvironments("environments.json", fs)
+	assert.Equal(t, len(errs), 0)
+
+	assert.Equal(t, len(jsonEnvironments), 3)
+	assert.DeepEqual(t, jsonEnvironments, yamlEnvironments, cmp.AllowUnexported(environmentImpl{}), cmpopts.IgnoreInterfaces(struct{ afero.Fs }{}))
+	assert.DeepEqual(t, jsonGroups, yamlGroups)
+	assert.DeepEqual(t, jsonGroups, map[string][]string{
+		"all-prod":   {"prod-eu", "prod-us"},
+		"production": {"prod-eu", "prod-us"},
+	})
+}
+
+func TestJsonEnvironmentsFileIsDetectedByContent(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments", []byte(testJsonEnvironmentWithAllProperties), 0644))
+
+	environments, errs := LoadEnvironmentList("all-prod", "environments", fs, util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(environments), 2)
+	assert.Equal(t, environments["prod-eu"].GetGroup(), "production")
+}
+
+func TestJsonEnvironmentsFileWithInvalidPropertiesFails(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments.json", []byte(`{"dev": [{"name": "Dev"}]}`), 0644))
+
+	_, errs := LoadEnvironmentList("", "environments.json", fs, util.DefaultLogger())
+
+	assert.Assert(t, len(errs) > 0)
+	assert.ErrorContains(t, errs[0], "could not parse environments file environments.json: every environment must be an object of string properties")
+}
+
+func TestTemplatedUrlsAndTokenNamesAreResolvedFromEnvironmentVariables(t *testing.T) {
+	util.SetEnv(t, "TENANT", "abc123")
+	defer util.UnsetEnv(t, "TENANT")
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments.yaml", []byte(`
+dev:
+    - name: "Dev"
+    - env-url: "https://{{ .Env.TENANT }}.live.dynatrace.com/"
+    - env-token-name: "{{ .Env.TENANT | upper }}_TOKEN"
+    - managed-cluster-url: "https://{{ env "CLUSTER_HOST" "cluster.example.com" }}"
+    - managed-cluster-token-name: "{{ .Env.TENANT | upper }}_CLUSTER_TOKEN"
+`), 0644))
+
+	environments, errs := LoadEnvironmentList("", "environments.yaml", fs, util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, environments["dev"].GetEnvironmentUrl(), "https://abc123.live.dynatrace.com")
+	assert.Equal(t, environments["dev"].GetManagedClusterUrl(), "https://cluster.example.com")
+
+	util.SetEnv(t, "ABC123_TOKEN", "token")
+	defer util.UnsetEnv(t, "ABC123_TOKEN")
+
+	token, err := environments["dev"].GetToken()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "token")
+}
+
+func TestTemplatedUrlsResolvingToInvalidUrlsAreRejected(t *testing.T) {
+	util.SetEnv(t, "TENANT", "")
+	defer util.UnsetEnv(t, "TENANT")
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments.yaml", []byte(`
+dev:
+    - name: "Dev"
+    - env-url: "https://{{ .Env.TENANT }}.live.dynatrace.com"
+    - env-token-name: "DEV"
+`), 0644))
+
+	_, errs := LoadEnvironmentList("", "environments.yaml", fs, util.DefaultLogger())
+
+	assert.Assert(t, len(errs) > 0)
+	assert.ErrorContains(t, errs[0], "invalid env-url of environment dev: https://.live.dynatrace.com has an empty label in its host")
+}
+
+func TestJsonEnvironmentsFileIsRenderedAsTemplate(t *testing.T) {
+	util.SetEnv(t, "DEV_URL", "https://url/from/env")
+	defer util.UnsetEnv(t, "DEV_URL")
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments.json", []byte(`{"dev": {"name": "Dev", "env-url": "{{ .Env.DEV_URL }}", "env-token-name": "DEV"}}`), 0644))
+
+	environments, errs := LoadEnvironmentList("", "environments.json", fs, util.DefaultLogger())
+
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, environments["dev"].GetEnvironmentUrl(), "https://url/from/env")
+}
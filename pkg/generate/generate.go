@@ -0,0 +1,289 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// DefaultApis are the apis whose skeletons are generated, if no api is chosen
+var DefaultApis = []string{"management-zone", "alerting-profile"}
+
+// environmentsFileName is the name of the environments file generated next to the projects
+const environmentsFileName = "environments.yaml"
+
+// exampleConfigId is the id of the example config generated for every api
+const exampleConfigId = "example"
+
+// skeleton is the example config generated for an api
+type skeleton struct {
+	template   string
+	parameters [][2]string
+}
+
+// skeletons are the example configs of the apis with a known payload. Other apis get a skeleton with a name only,
+// which has to be completed by the payload of the api.
+var skeletons = map[string]skeleton{
+	"alerting-profile": {
+		template: `{
+  "displayName": "{{ .name }}",
+  "rules": [
+    {
+      "severityLevel": "AVAILABILITY",
+      "tagFilter": {
+        "includeMode": "INCLUDE_ANY",
+        "tagFilters": [
+          {
+            "context": "CONTEXTLESS",
+            "key": "{{ .tag }}"
+          }
+        ]
+      },
+      "delayInMinutes": 0
+    }
+  ]
+}
+`,
+		parameters: [][2]string{{"name", "Example profile"}, {"tag", "example"}},
+	},
+	"management-zone": {
+		template: `{
+  "name": "{{ .name }}",
+  "rules": [
+    {
+      "type": "SERVICE",
+      "enabled": true,
+      "propagationTypes": [],
+      "conditions": [
+        {
+          "key": {
+            "attribute": "SERVICE_TAGS"
+          },
+          "comparisonInfo": {
+            "type": "TAG",
+            "operator": "TAG_KEY_EQUALS",
+            "value": {
+              "context": "CONTEXTLESS",
+              "key": "{{ .tag }}"
+            },
+            "negate": false
+          }
+        }
+      ]
+    }
+  ]
+}
+`,
+		parameters: [][2]string{{"name", "Example zone"}, {"tag", "example"}},
+	},
+	"auto-tag": {
+		template: `{
+  "name": "{{ .name }}",
+  "rules": [
+    {
+      "type": "SERVICE",
+      "enabled": true,
+      "valueFormat": null,
+      "propagationTypes": [],
+      "conditions": [
+        {
+          "key": {
+            "attribute": "SERVICE_NAME"
+          },
+          "comparisonInfo": {
+            "type": "STRING",
+            "operator": "CONTAINS",
+            "value": "{{ .serviceName }}",
+            "negate": false,
+            "caseSensitive": false
+          }
+        }
+      ]
+    }
+  ]
+}
+`,
+		parameters: [][2]string{{"name", "example"}, {"serviceName", "example"}},
+	},
+	"dashboard": {
+		template: `{
+  "dashboardMetadata": {
+    "name": "{{ .name }}",
+    "shared": true
+  },
+  "tiles": []
+}
+`,
+		parameters: [][2]string{{"name", "Example dashboard"}},
+	},
+	"settings": {
+		template: `{
+  "name": "{{ .name }}",
+  "rules": []
+}
+`,
+		parameters: [][2]string{{"name", "example"}, {config.SchemaIdParameter, "builtin:tags.auto-tagging"}, {config.ScopeParameter, "environment"}},
+	},
+}
+
+// genericSkeleton is the skeleton of apis without a known payload
+var genericSkeleton = skeleton{
+	template:   "{\n  \"name\": \"{{ .name }}\"\n}\n",
+	parameters: [][2]string{{"name", "example"}},
+}
+
+// environmentsFile is the environments file generated next to the projects. It must not contain template actions, as
+// environments files are rendered as templates.
+const environmentsFile = `# The environments configs are deployed to. Every environment defines the url of its Dynatrace environment and the
+# name of the environment variable containing its API token.
+#
+# Environments can be assigned to a group by prefixing their id with the group, e.g. production.prod-eu.
+# Configs are overridden per environment or group, by defining parameters of <config>.<environment> or <config>.<group>.
+development:
+    - name: "Development"
+    # the url of the environment, for Dynatrace Managed including the path of the environment, e.g. /e/<environment-id>
+    - env-url: "https://xxxxxxxx.live.dynatrace.com"
+    # the name of the environment variable containing the API token, which is never stored in this file
+    - env-token-name: "DEVELOPMENT_TOKEN"
+
+production.prod:
+    - name: "Production"
+    - env-url: "https://yyyyyyyy.live.dynatrace.com"
+    - env-token-name: "PRODUCTION_TOKEN"
+`
+
+// Project scaffolds the project with the given name in the working directory. It contains an example config of every
+// given api, or of the DefaultApis, if none is given. An environments file is created in the working directory, unless
+// it already exists. Existing projects are never overwritten.
+func Project(workingDir string, fs afero.Fs, name string, apiIds []string) error {
+	name = filepath.Clean(strings.TrimSpace(name))
+	if name == "." || filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid project name %q: the name must be a path relative to the working directory", name)
+	}
+
+	if len(apiIds) == 0 {
+		apiIds = DefaultApis
+	}
+
+	apis, err := selectApis(apiIds)
+	if err != nil {
+		return err
+	}
+
+	projectDir := filepath.Join(workingDir, name)
+	if exists, err := afero.Exists(fs, projectDir); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("project %s already exists in %s", name, projectDir)
+	}
+
+	for _, a := range apis {
+		if err := writeSkeleton(fs, projectDir, a); err != nil {
+			return err
+		}
+	}
+
+	environmentsPath := filepath.Join(workingDir, environmentsFileName)
+	exists, err := afero.Exists(fs, environmentsPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		util.Log.Info("Keeping existing environments file %s", environmentsPath)
+	} else {
+		if err := afero.WriteFile(fs, environmentsPath, []byte(environmentsFile), 0664); err != nil {
+			return err
+		}
+		util.Log.Info("Created environments file %s", environmentsPath)
+	}
+
+	util.Log.Info("Created project %s in %s with example configs of %s", name, projectDir, strings.Join(apiIds, ", "))
+	return nil
+}
+
+// selectApis returns the apis of the given ids, failing on unknown ids
+func selectApis(apiIds []string) ([]api.Api, error) {
+	known := api.NewApis()
+
+	var unknown []string
+	apis := make([]api.Api, 0, len(apiIds))
+	for _, id := range apiIds {
+		a, found := known[strings.TrimSpace(id)]
+		if !found {
+			unknown = append(unknown, id)
+			continue
+		}
+		apis = append(apis, a)
+	}
+
+	if len(unknown) > 0 {
+		ids := make([]string, 0, len(known))
+		for id := range known {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		return nil, fmt.Errorf("unknown apis %s: supported apis are %s", strings.Join(unknown, ", "), strings.Join(ids, ", "))
+	}
+	return apis, nil
+}
+
+// writeSkeleton writes the yaml and json file of the example config of the api to its folder in the project
+func writeSkeleton(fs afero.Fs, projectDir string, a api.Api) error {
+	s, found := skeletons[a.GetId()]
+	if !found {
+		s = genericSkeleton
+	}
+
+	dir := filepath.Join(projectDir, a.GetId())
+	if err := fs.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+
+	if err := afero.WriteFile(fs, filepath.Join(dir, exampleConfigId+".json"), []byte(s.template), 0664); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, filepath.Join(dir, a.GetId()+".yaml"), []byte(skeletonYaml(a, s)), 0664)
+}
+
+// skeletonYaml returns the yaml file defining the example config of the api
+func skeletonYaml(a api.Api, s skeleton) string {
+	var yaml strings.Builder
+
+	if _, found := skeletons[a.GetId()]; !found {
+		yaml.WriteString(fmt.Sprintf("# %s.json only defines the name of the config: complete it with the payload of the %s api\n", exampleConfigId, a.GetId()))
+	}
+	yaml.WriteString("# configs of the api, each defined by the json template of its payload\n")
+	yaml.WriteString(fmt.Sprintf("config:\n  - %s: \"%s.json\"\n\n", exampleConfigId, exampleConfigId))
+
+	yaml.WriteString("# parameters of the config, available in its template, e.g. the name of the config\n")
+	yaml.WriteString(exampleConfigId + ":\n")
+	for _, parameter := range s.parameters {
+		yaml.WriteString(fmt.Sprintf("  - %s: \"%s\"\n", parameter[0], parameter[1]))
+	}
+
+	yaml.WriteString("\n# parameters overridden for the environments of the production group\n")
+	yaml.WriteString(fmt.Sprintf("%s.production:\n  - %s: \"%s (production)\"\n", exampleConfigId, s.parameters[0][0], s.parameters[0][1]))
+	return yaml.String()
+}
@@ -0,0 +1,136 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package generate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/environment"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func listFiles(t *testing.T, fs afero.Fs, root string) []string {
+	var files []string
+	err := afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, filepath.ToSlash(path))
+		}
+		return err
+	})
+	assert.NilError(t, err)
+	return files
+}
+
+func TestProjectCreatesExampleConfigsOfDefaultApis(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	assert.NilError(t, Project("projects", fs, "my-project", nil))
+
+	assert.DeepEqual(t, listFiles(t, fs, "projects"), []string{
+		"projects/environments.yaml",
+		"projects/my-project/alerting-profile/alerting-profile.yaml",
+		"projects/my-project/alerting-profile/example.json",
+		"projects/my-project/management-zone/example.json",
+		"projects/my-project/management-zone/management-zone.yaml",
+	})
+
+	content, err := afero.ReadFile(fs, filepath.Join("projects", "my-project", "management-zone", "management-zone.yaml"))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(content), "config:\n  - example: \"example.json\"\n"))
+}
+
+func TestGeneratedProjectCanBeLoadedAndRendered(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	assert.NilError(t, Project("projects", fs, "my-project", []string{"alerting-profile", "management-zone", "auto-tag", "dashboard", "settings", "notification"}))
+
+	environments, errs := environment.LoadEnvironmentList("", filepath.Join("projects", "environments.yaml"), fs, util.DefaultLogger())
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(environments), 2)
+
+	projects, err := project.LoadProjectsToDeploy(fs, "my-project", api.NewApis(), "projects", util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Equal(t, len(projects), 1)
+	assert.Equal(t, len(projects[0].GetConfigs()), 6)
+
+	for _, c := range projects[0].GetConfigs() {
+		for _, env := range environments {
+			rendered, err := c.GetConfigForEnvironment(env, nil)
+			assert.NilError(t, err, c.GetFullQualifiedId())
+			assert.Assert(t, json.Valid(rendered), c.GetFullQualifiedId())
+		}
+	}
+
+	name, err := projects[0].GetConfigs()[0].GetObjectNameForEnvironment(environments["prod"], nil)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasSuffix(name, "(production)"), name)
+}
+
+func TestProjectMarksSkeletonsOfApisWithoutKnownPayload(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	assert.NilError(t, Project(".", fs, "my-project", []string{"notification"}))
+
+	content, err := afero.ReadFile(fs, filepath.Join("my-project", "notification", "notification.yaml"))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasPrefix(string(content), "# example.json only defines the name of the config: complete it with the payload of the notification api\n"))
+}
+
+func TestProjectKeepsExistingEnvironmentsFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "environments.yaml", []byte("existing"), 0644))
+
+	assert.NilError(t, Project(".", fs, "my-project", nil))
+
+	content, err := afero.ReadFile(fs, "environments.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "existing")
+}
+
+func TestProjectFailsOnExistingProject(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, fs.MkdirAll(filepath.Join("projects", "my-project"), 0777))
+
+	err := Project("projects", fs, "my-project", nil)
+	assert.ErrorContains(t, err, "project my-project already exists")
+	assert.DeepEqual(t, listFiles(t, fs, "projects"), []string(nil))
+}
+
+func TestProjectFailsOnUnknownApisAndInvalidNames(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := Project(".", fs, "my-project", []string{"management-zone", "managment-zone"})
+	assert.ErrorContains(t, err, "unknown apis managment-zone: supported apis are")
+
+	for _, name := range []string{"", ".", "..", "../other", "/absolute"} {
+		err = Project(".", fs, name, nil)
+		assert.ErrorContains(t, err, "invalid project name", name)
+	}
+
+	assert.DeepEqual(t, listFiles(t, fs, "."), []string(nil))
+}
@@ -0,0 +1,188 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graph
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+const (
+	FormatDot     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+// node is a config of the graph
+type node struct {
+	id    string
+	label string
+}
+
+// cluster contains the configs of a project
+type cluster struct {
+	id    string
+	nodes []node
+}
+
+// edge points from a config to a config it references
+type edge struct {
+	from string
+	to   string
+}
+
+type dependencyGraph struct {
+	clusters []cluster
+	edges    []edge
+}
+
+// Write writes the graph of the configs of the projects found in the working directory and the references between
+// them to out, either in the DOT format of Graphviz or as Mermaid flowchart. The projects are loaded and sorted like
+// for a deployment, so a circular dependency fails like the deployment does. No environment is contacted.
+func Write(workingDir string, fs afero.Fs, proj string, format string, out io.Writer) error {
+	if format != FormatDot && format != FormatMermaid {
+		return fmt.Errorf("invalid format %s: supported formats are %s and %s", format, FormatDot, FormatMermaid)
+	}
+
+	workingDir = filepath.Clean(workingDir)
+
+	projects, err := project.LoadProjectsToDeploy(fs, proj, api.NewApis(), workingDir, util.DefaultLogger())
+	if err != nil {
+		return err
+	}
+
+	graph := createGraph(projects, workingDir)
+
+	var text string
+	if format == FormatMermaid {
+		text = graph.mermaid()
+	} else {
+		text = graph.dot()
+	}
+
+	_, err = io.WriteString(out, text)
+	return err
+}
+
+// createGraph creates the graph of the configs in the order they are deployed. Ids are relative to the working
+// directory. Configs are labeled with their project, type and name.
+func createGraph(projects []project.Project, workingDir string) dependencyGraph {
+	relative := func(id string) string {
+		return filepath.ToSlash(strings.TrimPrefix(id, workingDir+string(filepath.Separator)))
+	}
+
+	var allConfigs []config.Config
+	for _, p := range projects {
+		allConfigs = append(allConfigs, p.GetConfigs()...)
+	}
+
+	var graph dependencyGraph
+
+	for _, p := range projects {
+		projectCluster := cluster{id: relative(p.GetId())}
+
+		for _, c := range p.GetConfigs() {
+			projectCluster.nodes = append(projectCluster.nodes, node{
+				id:    relative(c.GetFullQualifiedId()),
+				label: fmt.Sprintf("%s/%s/%s", projectCluster.id, c.GetType(), configName(c)),
+			})
+
+			for _, other := range allConfigs {
+				if other != c && c.HasDependencyOn(other) {
+					graph.edges = append(graph.edges, edge{from: relative(c.GetFullQualifiedId()), to: relative(other.GetFullQualifiedId())})
+				}
+			}
+		}
+
+		graph.clusters = append(graph.clusters, projectCluster)
+	}
+
+	return graph
+}
+
+// configName returns the default name of the config, or its id if the name is only defined for environments
+func configName(c config.Config) string {
+	if name := c.GetProperties()[c.GetId()]["name"]; name != "" {
+		return name
+	}
+	return c.GetId()
+}
+
+func (g dependencyGraph) dot() string {
+	var text strings.Builder
+
+	text.WriteString("digraph monaco {\n")
+	text.WriteString("\trankdir=\"RL\";\n")
+	text.WriteString("\tnode [shape=box];\n")
+
+	for i, c := range g.clusters {
+		text.WriteString(fmt.Sprintf("\tsubgraph \"cluster_%d\" {\n", i))
+		text.WriteString(fmt.Sprintf("\t\tlabel=%s;\n", dotQuote(c.id)))
+		for _, n := range c.nodes {
+			text.WriteString(fmt.Sprintf("\t\t%s [label=%s];\n", dotQuote(n.id), dotQuote(n.label)))
+		}
+		text.WriteString("\t}\n")
+	}
+
+	for _, e := range g.edges {
+		text.WriteString(fmt.Sprintf("\t%s -> %s;\n", dotQuote(e.from), dotQuote(e.to)))
+	}
+
+	text.WriteString("}\n")
+	return text.String()
+}
+
+// dotQuote returns the value as quoted DOT id
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// mermaid returns the graph as Mermaid flowchart. Mermaid ids can't contain the slashes of config ids, so nodes are
+// numbered in the order of the configs.
+func (g dependencyGraph) mermaid() string {
+	var text strings.Builder
+	ids := make(map[string]string)
+
+	text.WriteString("flowchart RL\n")
+
+	for i, c := range g.clusters {
+		text.WriteString(fmt.Sprintf("\tsubgraph project%d[%s]\n", i, mermaidQuote(c.id)))
+		for _, n := range c.nodes {
+			ids[n.id] = fmt.Sprintf("config%d", len(ids))
+			text.WriteString(fmt.Sprintf("\t\t%s[%s]\n", ids[n.id], mermaidQuote(n.label)))
+		}
+		text.WriteString("\tend\n")
+	}
+
+	for _, e := range g.edges {
+		text.WriteString(fmt.Sprintf("\t%s --> %s\n", ids[e.from], ids[e.to]))
+	}
+
+	return text.String()
+}
+
+// mermaidQuote returns the value as quoted Mermaid label, in which quotes are written as entity
+func mermaidQuote(value string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(value) + `"`
+}
@@ -0,0 +1,87 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func createTestProjects(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+
+	files := map[string]string{
+		"projects/zaphod/alerting-profile/profile.yaml": "config:\n  - profile: \"profile.json\"\n\nprofile:\n  - name: \"Star Trek Service\"\n",
+		"projects/zaphod/alerting-profile/profile.json": "{}",
+		"projects/trillian/dashboard/dashboard.yaml":    "config:\n  - dashboard: \"dashboard.json\"\n\ndashboard:\n  - name: \"Star \\\"Wars\\\"\"\n  - mzone: \"/zaphod/alerting-profile/profile.id\"\n",
+		"projects/trillian/dashboard/dashboard.json":    "{}",
+	}
+
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+
+	return fs
+}
+
+func TestWriteWritesGraphAsDot(t *testing.T) {
+	fs := createTestProjects(t)
+
+	var out bytes.Buffer
+	err := Write("projects", fs, "trillian", FormatDot, &out)
+	assert.NilError(t, err)
+
+	dot := out.String()
+	assert.Assert(t, strings.HasPrefix(dot, "digraph monaco {\n"), dot)
+	assert.Assert(t, strings.Contains(dot, "\t\t\"zaphod/alerting-profile/profile\" [label=\"zaphod/alerting-profile/Star Trek Service\"];\n"), dot)
+	assert.Assert(t, strings.Contains(dot, "\t\t\"trillian/dashboard/dashboard\" [label=\"trillian/dashboard/Star \\\"Wars\\\"\"];\n"), dot)
+	assert.Assert(t, strings.Contains(dot, "\t\"trillian/dashboard/dashboard\" -> \"zaphod/alerting-profile/profile\";\n"), dot)
+	assert.Equal(t, strings.Count(dot, "->"), 1)
+}
+
+func TestWriteWritesGraphAsMermaid(t *testing.T) {
+	fs := createTestProjects(t)
+
+	var out bytes.Buffer
+	err := Write("projects", fs, "", FormatMermaid, &out)
+	assert.NilError(t, err)
+
+	assert.Equal(t, out.String(), "flowchart RL\n"+
+		"\tsubgraph project0[\"zaphod\"]\n"+
+		"\t\tconfig0[\"zaphod/alerting-profile/Star Trek Service\"]\n"+
+		"\tend\n"+
+		"\tsubgraph project1[\"trillian\"]\n"+
+		"\t\tconfig1[\"trillian/dashboard/Star #quot;Wars#quot;\"]\n"+
+		"\tend\n"+
+		"\tconfig1 --> config0\n")
+}
+
+func TestWriteFailsOnInvalidFormat(t *testing.T) {
+	fs := createTestProjects(t)
+
+	var out bytes.Buffer
+	err := Write("projects", fs, "", "svg", &out)
+	assert.Error(t, err, "invalid format svg: supported formats are dot and mermaid")
+	assert.Equal(t, out.Len(), 0)
+}
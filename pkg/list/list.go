@@ -0,0 +1,150 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/project"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+// projectListing is a discovered project, including its configs and the projects it depends on
+type projectListing struct {
+	Id        string          `json:"id"`
+	DependsOn []string        `json:"dependsOn"`
+	Configs   []configListing `json:"configs"`
+}
+
+// configListing is a discovered config, including the configs it references
+type configListing struct {
+	Id         string   `json:"id"`
+	Type       string   `json:"type"`
+	File       string   `json:"file"`
+	References []string `json:"references"`
+}
+
+type listing struct {
+	Projects []projectListing `json:"projects"`
+}
+
+// List writes the projects found in the working directory, their configs and the references between them to out,
+// either as text or as json. No environment is contacted.
+func List(workingDir string, fs afero.Fs, proj string, format string, out io.Writer) error {
+	if format != FormatText && format != FormatJson {
+		return fmt.Errorf("invalid format %s: supported formats are %s and %s", format, FormatText, FormatJson)
+	}
+
+	workingDir = filepath.Clean(workingDir)
+
+	projects, err := project.LoadProjectsToDeploy(fs, proj, api.NewApis(), workingDir, util.DefaultLogger())
+	if err != nil {
+		return err
+	}
+
+	result := createListing(projects, workingDir)
+
+	if format == FormatJson {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	return result.write(out)
+}
+
+// createListing lists the projects in order of their dependencies. Ids are relative to the working directory.
+func createListing(projects []project.Project, workingDir string) listing {
+	relative := func(id string) string {
+		return strings.TrimPrefix(id, workingDir+string(filepath.Separator))
+	}
+
+	var allConfigs []config.Config
+	for _, p := range projects {
+		allConfigs = append(allConfigs, p.GetConfigs()...)
+	}
+
+	result := listing{Projects: make([]projectListing, 0, len(projects))}
+
+	for _, p := range projects {
+		projectResult := projectListing{
+			Id:        relative(p.GetId()),
+			DependsOn: make([]string, 0),
+			Configs:   make([]configListing, 0, len(p.GetConfigs())),
+		}
+
+		for _, other := range projects {
+			if other.GetId() != p.GetId() && p.HasDependencyOn(other) {
+				projectResult.DependsOn = append(projectResult.DependsOn, relative(other.GetId()))
+			}
+		}
+
+		for _, c := range p.GetConfigs() {
+			configResult := configListing{
+				Id:         relative(c.GetFullQualifiedId()),
+				Type:       c.GetType(),
+				File:       relative(c.GetFilePath()),
+				References: make([]string, 0),
+			}
+
+			for _, other := range allConfigs {
+				if other != c && c.HasDependencyOn(other) {
+					configResult.References = append(configResult.References, relative(other.GetFullQualifiedId()))
+				}
+			}
+
+			projectResult.Configs = append(projectResult.Configs, configResult)
+		}
+
+		result.Projects = append(result.Projects, projectResult)
+	}
+
+	return result
+}
+
+func (l listing) write(out io.Writer) error {
+	var text strings.Builder
+
+	for _, p := range l.Projects {
+		text.WriteString(fmt.Sprintf("%s (%d configs)\n", p.Id, len(p.Configs)))
+		if len(p.DependsOn) > 0 {
+			text.WriteString(fmt.Sprintf("\tdepends on: %s\n", strings.Join(p.DependsOn, ", ")))
+		}
+
+		for _, c := range p.Configs {
+			text.WriteString(fmt.Sprintf("\t%s (%s)\n", c.Id, c.File))
+			for _, reference := range c.References {
+				text.WriteString(fmt.Sprintf("\t\treferences %s\n", reference))
+			}
+		}
+	}
+
+	_, err := io.WriteString(out, text.String())
+	return err
+}
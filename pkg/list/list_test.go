@@ -0,0 +1,108 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package list
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func createTestProjects(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+
+	files := map[string]string{
+		"projects/zaphod/alerting-profile/profile.yaml": "config:\n  - profile: \"profile.json\"\n\nprofile:\n  - name: \"Star Trek Service\"\n",
+		"projects/zaphod/alerting-profile/profile.json": "{}",
+		"projects/trillian/dashboard/dashboard.yaml":    "config:\n  - dashboard: \"dashboard.json\"\n\ndashboard:\n  - name: \"Star Wars\"\n  - mzone: \"/zaphod/alerting-profile/profile.id\"\n",
+		"projects/trillian/dashboard/dashboard.json":    "{}",
+	}
+
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+
+	return fs
+}
+
+func TestListWritesProjectsAsText(t *testing.T) {
+	fs := createTestProjects(t)
+
+	var out bytes.Buffer
+	err := List("projects", fs, "trillian", FormatText, &out)
+	assert.NilError(t, err)
+
+	assert.Equal(t, out.String(), "zaphod (1 configs)\n"+
+		"\tzaphod/alerting-profile/profile (zaphod/alerting-profile/profile.json)\n"+
+		"trillian (1 configs)\n"+
+		"\tdepends on: zaphod\n"+
+		"\ttrillian/dashboard/dashboard (trillian/dashboard/dashboard.json)\n"+
+		"\t\treferences zaphod/alerting-profile/profile\n")
+}
+
+func TestListWritesProjectsAsJson(t *testing.T) {
+	fs := createTestProjects(t)
+
+	var out bytes.Buffer
+	err := List("projects", fs, "trillian", FormatJson, &out)
+	assert.NilError(t, err)
+
+	var result listing
+	assert.NilError(t, json.Unmarshal(out.Bytes(), &result))
+
+	assert.DeepEqual(t, result, listing{Projects: []projectListing{
+		{
+			Id:        "zaphod",
+			DependsOn: []string{},
+			Configs: []configListing{
+				{
+					Id:         "zaphod/alerting-profile/profile",
+					Type:       "alerting-profile",
+					File:       "zaphod/alerting-profile/profile.json",
+					References: []string{},
+				},
+			},
+		},
+		{
+			Id:        "trillian",
+			DependsOn: []string{"zaphod"},
+			Configs: []configListing{
+				{
+					Id:         "trillian/dashboard/dashboard",
+					Type:       "dashboard",
+					File:       "trillian/dashboard/dashboard.json",
+					References: []string{"zaphod/alerting-profile/profile"},
+				},
+			},
+		},
+	}})
+}
+
+func TestListFailsOnInvalidFormat(t *testing.T) {
+	fs := createTestProjects(t)
+
+	var out bytes.Buffer
+	err := List("projects", fs, "", "xml", &out)
+	assert.Error(t, err, "invalid format xml: supported formats are text and json")
+	assert.Equal(t, out.Len(), 0)
+}
@@ -0,0 +1,268 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package metrics collects counters, gauges and histograms of a run, which are written to a file in the Prometheus
+// text format at the end of the run. Metrics are only collected if enabled, observations of a nil registry are
+// discarded.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/spf13/afero"
+)
+
+// metric types of the Prometheus text format
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Labels are the label names and values of a series
+type Labels map[string]string
+
+// Registry contains the metrics of a run. All methods can be called on a nil registry, which discards the
+// observations. It is safe for concurrent use.
+type Registry struct {
+	mutex    sync.Mutex
+	families map[string]*family
+}
+
+// family is a metric with all its series
+type family struct {
+	name    string
+	help    string
+	kind    string
+	buckets []float64
+	series  map[string]*series
+}
+
+// series is a metric with a set of label values. Counters and gauges use the value, histograms the bucket counts,
+// sum and count.
+type series struct {
+	labels  string
+	value   float64
+	counts  []uint64
+	sum     float64
+	samples uint64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// active holds the *Registry of the run, which is nil if metrics are disabled. It is read by every request, concurrently
+// to deployments enabling and disabling metrics, and is thus accessed atomically.
+var active atomic.Value
+
+// Enable starts collecting the metrics of a run in a new registry, which is returned. Deployments running concurrently
+// in the same process share the registry of the last call.
+func Enable() *Registry {
+	registry := NewRegistry()
+	active.Store(registry)
+	return registry
+}
+
+// Disable stops collecting metrics
+func Disable() {
+	active.Store((*Registry)(nil))
+}
+
+// Active returns the registry collecting the metrics of the run, or nil if metrics are disabled
+func Active() *Registry {
+	registry, _ := active.Load().(*Registry)
+	return registry
+}
+
+// Add adds the value to a counter
+func (r *Registry) Add(name string, help string, labels Labels, value float64) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.series(name, help, typeCounter, nil, labels).value += value
+}
+
+// Set sets the value of a gauge
+func (r *Registry) Set(name string, help string, labels Labels, value float64) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.series(name, help, typeGauge, nil, labels).value = value
+}
+
+// Observe adds the value to a histogram with the given upper bounds of its buckets, which have to be sorted
+func (r *Registry) Observe(name string, help string, buckets []float64, labels Labels, value float64) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s := r.series(name, help, typeHistogram, buckets, labels)
+	for i, bound := range buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += value
+	s.samples++
+}
+
+// series returns the series of the labels, creating the metric and series if necessary. The help, type and buckets
+// of the first observation of a metric are kept.
+func (r *Registry) series(name string, help string, kind string, buckets []float64, labels Labels) *series {
+	f, found := r.families[name]
+	if !found {
+		f = &family{name: name, help: help, kind: kind, buckets: buckets, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+
+	key := formatLabels(labels)
+	s, found := f.series[key]
+	if !found {
+		s = &series{labels: key, counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Write writes all metrics in the Prometheus text format. Metrics and series are sorted, so the output is stable.
+func (r *Registry) Write(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out bytes.Buffer
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(&out, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&out, "# TYPE %s %s\n", f.name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			f.write(&out, f.series[key])
+		}
+	}
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func (f *family) write(out *bytes.Buffer, s *series) {
+	if f.kind != typeHistogram {
+		fmt.Fprintf(out, "%s%s %s\n", f.name, s.labels, formatValue(s.value))
+		return
+	}
+
+	for i, bound := range f.buckets {
+		fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, withLabel(s.labels, "le", formatValue(bound)), s.counts[i])
+	}
+	fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, withLabel(s.labels, "le", "+Inf"), s.samples)
+	fmt.Fprintf(out, "%s_sum%s %s\n", f.name, s.labels, formatValue(s.sum))
+	fmt.Fprintf(out, "%s_count%s %d\n", f.name, s.labels, s.samples)
+}
+
+// WriteFile writes all metrics to the file in the Prometheus text format
+func (r *Registry) WriteFile(fs afero.Fs, file string) error {
+	var out bytes.Buffer
+	if err := r.Write(&out); err != nil {
+		return err
+	}
+
+	if err := afero.WriteFile(fs, file, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write metrics file %s: %w", file, err)
+	}
+	return nil
+}
+
+// formatLabels formats the labels sorted by name, e.g. {action="created",environment="dev"}
+func formatLabels(labels Labels) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabelValue(labels[name])+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel appends a label to formatted labels
+func withLabel(formatted string, name string, value string) string {
+	pair := name + `="` + escapeLabelValue(value) + `"`
+	if formatted == "" {
+		return "{" + pair + "}"
+	}
+	return strings.TrimSuffix(formatted, "}") + "," + pair + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
@@ -0,0 +1,133 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestWriteFormatsMetricsSortedInPrometheusTextFormat(t *testing.T) {
+	registry := NewRegistry()
+	registry.Add("configs_total", "Processed configs.", Labels{"environment": "prod", "action": "created"}, 1)
+	registry.Add("configs_total", "Processed configs.", Labels{"environment": "dev", "action": "created"}, 1)
+	registry.Add("configs_total", "Processed configs.", Labels{"environment": "dev", "action": "created"}, 1)
+	registry.Set("duration_seconds", "Duration of the run.", nil, 1.5)
+
+	var out bytes.Buffer
+	assert.NilError(t, registry.Write(&out))
+	assert.Equal(t, out.String(), `# HELP configs_total Processed configs.
+# TYPE configs_total counter
+configs_total{action="created",environment="dev"} 2
+configs_total{action="created",environment="prod"} 1
+# HELP duration_seconds Duration of the run.
+# TYPE duration_seconds gauge
+duration_seconds 1.5
+`)
+}
+
+func TestObserveCountsValuesInCumulativeBuckets(t *testing.T) {
+	registry := NewRegistry()
+	for _, value := range []float64{0.05, 0.3, 0.7, 12} {
+		registry.Observe("request_seconds", "Request latency.", []float64{0.1, 0.5, 1}, Labels{"method": "GET"}, value)
+	}
+
+	var out bytes.Buffer
+	assert.NilError(t, registry.Write(&out))
+	assert.Equal(t, out.String(), `# HELP request_seconds Request latency.
+# TYPE request_seconds histogram
+request_seconds_bucket{method="GET",le="0.1"} 1
+request_seconds_bucket{method="GET",le="0.5"} 2
+request_seconds_bucket{method="GET",le="1"} 3
+request_seconds_bucket{method="GET",le="+Inf"} 4
+request_seconds_sum{method="GET"} 13.05
+request_seconds_count{method="GET"} 4
+`)
+}
+
+func TestLabelValuesAreEscaped(t *testing.T) {
+	registry := NewRegistry()
+	registry.Add("configs_total", "Processed\nconfigs.", Labels{"environment": `a "quoted" \ name`}, 1)
+
+	var out bytes.Buffer
+	assert.NilError(t, registry.Write(&out))
+	assert.Equal(t, out.String(), `# HELP configs_total Processed\nconfigs.
+# TYPE configs_total counter
+configs_total{environment="a \"quoted\" \\ name"} 1
+`)
+}
+
+func TestNilRegistryDiscardsObservations(t *testing.T) {
+	Disable()
+
+	registry := Active()
+	registry.Add("configs_total", "Processed configs.", nil, 1)
+	registry.Observe("request_seconds", "Request latency.", []float64{1}, nil, 0.5)
+
+	var out bytes.Buffer
+	assert.NilError(t, registry.Write(&out))
+	assert.Equal(t, out.Len(), 0)
+}
+
+func TestWriteFileWritesMetricsOfActiveRegistry(t *testing.T) {
+	registry := Enable()
+	defer Disable()
+
+	Active().Add("configs_total", "Processed configs.", nil, 3)
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, registry.WriteFile(fs, "metrics.prom"))
+
+	content, err := afero.ReadFile(fs, "metrics.prom")
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "# HELP configs_total Processed configs.\n# TYPE configs_total counter\nconfigs_total 3\n")
+}
+
+func TestActiveIsNilUnlessEnabled(t *testing.T) {
+	assert.Assert(t, Active() == nil)
+
+	registry := Enable()
+	assert.Equal(t, Active(), registry)
+
+	Disable()
+	assert.Assert(t, Active() == nil)
+}
+
+func TestActiveCanBeReadWhileEnablingAndDisabling(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			Enable()
+			Disable()
+		}()
+		go func() {
+			defer wg.Done()
+			Active().Add("requests_total", "Sent requests.", nil, 1)
+		}()
+	}
+	wg.Wait()
+	assert.Assert(t, Active() == nil)
+}
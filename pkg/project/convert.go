@@ -0,0 +1,317 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// legacyApplicationApi is the deprecated config type of web applications, which is replaced by currentApplicationApi
+const (
+	legacyApplicationApi  = "application"
+	currentApplicationApi = "application-web"
+)
+
+// Migration is a change of a file or folder from the legacy config format to the current one
+type Migration struct {
+	// Path of the changed file or folder, relative to the current directory
+	Path string
+	// Description of the change
+	Description string
+}
+
+// ConversionIssue is a legacy construct which can't be converted automatically and has to be migrated manually
+type ConversionIssue struct {
+	// Path of the file or folder containing the construct, relative to the current directory
+	Path string
+	// Reason why the construct can't be converted
+	Reason string
+}
+
+func (i ConversionIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Path, i.Reason)
+}
+
+// Conversion lists the migrations and the issues found converting the projects below a projects root folder
+type Conversion struct {
+	Migrations []Migration
+	Issues     []ConversionIssue
+}
+
+// ConvertLegacyConfigs converts the projects below the projects root folder from the legacy config format to the
+// current one. Configs of the deprecated config type application are moved to the folder of application-web, and all
+// references to them, absolute locations of their templates and delete.yaml entries are updated. Nothing is changed
+// in a dry run, but the migrations are returned all the same. Hidden folders and paths matching a .monacoignore file
+// are skipped.
+func ConvertLegacyConfigs(fs afero.Fs, projectRootFolder string, dryRun bool) (Conversion, error) {
+	ignore := newIgnoreMatcher(fs, projectRootFolder, util.DefaultLogger())
+
+	var conversion Conversion
+	var yamlFiles []string
+	moves := make(map[string]string)
+	projectFolders := make(map[string]bool)
+
+	err := afero.Walk(fs, projectRootFolder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			if isYaml(path) && !ignore.isIgnored(path, false) {
+				yamlFiles = append(yamlFiles, path)
+			}
+			return nil
+		}
+
+		if path != projectRootFolder && (strings.HasPrefix(info.Name(), ".") || ignore.isIgnored(path, true)) {
+			return filepath.SkipDir
+		}
+		if info.Name() != legacyApplicationApi || path == projectRootFolder {
+			return nil
+		}
+
+		projectFolder := filepath.Dir(path)
+		projectId, err := filepath.Rel(projectRootFolder, projectFolder)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(projectFolder, currentApplicationApi)
+		exists, err := afero.Exists(fs, target)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case projectId == ".":
+			conversion.Issues = append(conversion.Issues, ConversionIssue{Path: path, Reason: "configs must be part of a project folder below the projects root folder"})
+		case exists:
+			conversion.Issues = append(conversion.Issues, ConversionIssue{
+				Path:   path,
+				Reason: fmt.Sprintf("can't be moved to %s, as it already exists: move the configs of the deprecated config type %s manually", target, legacyApplicationApi),
+			})
+		default:
+			moves[path] = target
+			projectFolders[filepath.ToSlash(projectFolder)] = true
+			conversion.Migrations = append(conversion.Migrations, Migration{
+				Path:        path,
+				Description: fmt.Sprintf("move to %s, as the config type %s is replaced by %s", target, legacyApplicationApi, currentApplicationApi),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	rewritten := make(map[string][]byte)
+	for _, file := range yamlFiles {
+		content, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return Conversion{}, fmt.Errorf("could not read %s: %w", file, err)
+		}
+
+		converted, count := migrateApplicationReferences(string(content), projectFolders, projectFolderOf(file))
+		if filepath.Base(file) == "delete.yaml" && projectFolders[filepath.ToSlash(filepath.Dir(file))] {
+			var deleted int
+			converted, deleted = migrateDeleteEntries(converted)
+			count += deleted
+		}
+		if count == 0 {
+			continue
+		}
+
+		rewritten[file] = []byte(converted)
+		conversion.Migrations = append(conversion.Migrations, Migration{
+			Path:        file,
+			Description: fmt.Sprintf("update %d references to configs of the config type %s", count, legacyApplicationApi),
+		})
+	}
+
+	sort.Slice(conversion.Migrations, func(i, j int) bool {
+		return conversion.Migrations[i].Path < conversion.Migrations[j].Path
+	})
+
+	if dryRun {
+		return conversion, nil
+	}
+
+	for folder, target := range moves {
+		if err := moveFolder(fs, folder, target); err != nil {
+			return Conversion{}, fmt.Errorf("could not move %s to %s: %w", folder, target, err)
+		}
+	}
+
+	for file, content := range rewritten {
+		for folder, target := range moves {
+			if strings.HasPrefix(file, folder+string(filepath.Separator)) {
+				file = target + strings.TrimPrefix(file, folder)
+			}
+		}
+
+		if err := afero.WriteFile(fs, file, content, 0664); err != nil {
+			return Conversion{}, fmt.Errorf("could not write %s: %w", file, err)
+		}
+	}
+
+	return conversion, nil
+}
+
+// Write writes the migrations and issues of the conversion to out. In a dry run, the migrations are marked as not
+// applied.
+func (c Conversion) Write(out io.Writer, dryRun bool) error {
+	if len(c.Migrations) == 0 && len(c.Issues) == 0 {
+		_, err := fmt.Fprintln(out, "No legacy configs found")
+		return err
+	}
+
+	if len(c.Migrations) > 0 {
+		header := "Converted the legacy configs:"
+		if dryRun {
+			header = "Changes converting the legacy configs (dry run, nothing was changed):"
+		}
+		if _, err := fmt.Fprintln(out, header); err != nil {
+			return err
+		}
+	}
+	for _, migration := range c.Migrations {
+		if _, err := fmt.Fprintf(out, "\t%s: %s\n", migration.Path, migration.Description); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Issues) > 0 {
+		if _, err := fmt.Fprintf(out, "%d legacy constructs can't be converted automatically:\n", len(c.Issues)); err != nil {
+			return err
+		}
+	}
+	for _, issue := range c.Issues {
+		if _, err := fmt.Fprintf(out, "\t%s\n", issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// referenceToken matches the values in yaml files which may reference configs or template locations
+var referenceToken = regexp.MustCompile(`[^\s"']+`)
+
+// relativeReference matches references to configs of the same project, which only consist of the api and config id
+var relativeReference = regexp.MustCompile(`^` + legacyApplicationApi + `/[^/]+(\.id|\.name|\.property\..+|\.response\..+)$`)
+
+// migrateApplicationReferences replaces the api of references and absolute template locations of the configs of the
+// application api of the given project folders. Like when resolving dependencies, any path the project folder ends
+// with identifies the project, and references without project refer to the project of the yaml file. It returns the
+// content and the number of replaced references.
+func migrateApplicationReferences(content string, projectFolders map[string]bool, ownProjectFolder string) (string, int) {
+	count := 0
+	separator := "/" + legacyApplicationApi + "/"
+
+	converted := referenceToken.ReplaceAllStringFunc(content, func(token string) string {
+		leading := 0
+		if strings.HasPrefix(token, "/") || strings.HasPrefix(token, `\`) {
+			leading = 1
+		}
+		normalized := strings.ReplaceAll(token[leading:], `\`, "/")
+
+		// separators are single characters, so the api is at the same position in the token
+		start := -1
+		if relativeReference.MatchString(normalized) && projectFolders[ownProjectFolder] {
+			start = leading
+		} else if index := strings.LastIndex(normalized, separator); index > 0 && len(normalized) > index+len(separator) {
+			if identifiesProjectFolder(normalized[:index], projectFolders) {
+				start = leading + index + 1
+			}
+		}
+
+		if start < 0 {
+			return token
+		}
+		count++
+		return token[:start] + currentApplicationApi + token[start+len(legacyApplicationApi):]
+	})
+
+	return converted, count
+}
+
+// identifiesProjectFolder returns true if one of the project folders ends with the path
+func identifiesProjectFolder(path string, projectFolders map[string]bool) bool {
+	for folder := range projectFolders {
+		if folder == path || strings.HasSuffix(folder, "/"+path) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectFolderOf returns the folder of the project the yaml file in an api folder or project folder belongs to
+func projectFolderOf(file string) string {
+	folder := filepath.Dir(file)
+	if api.IsApi(filepath.Base(folder)) {
+		folder = filepath.Dir(folder)
+	}
+	return filepath.ToSlash(folder)
+}
+
+// legacyDeleteEntry matches the entries of a delete.yaml deleting configs of the application api
+var legacyDeleteEntry = regexp.MustCompile(`(?m)^(\s*-\s*["']?)` + legacyApplicationApi + `/`)
+
+// migrateDeleteEntries replaces the api of the entries of a delete.yaml deleting configs of the application api
+func migrateDeleteEntries(content string) (string, int) {
+	count := len(legacyDeleteEntry.FindAllStringIndex(content, -1))
+	return legacyDeleteEntry.ReplaceAllString(content, "${1}"+currentApplicationApi+"/"), count
+}
+
+// moveFolder moves all files of the folder to the target folder, which must not exist, and removes the folder
+func moveFolder(fs afero.Fs, folder string, target string) error {
+	var files []string
+	err := afero.Walk(fs, folder, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := fs.MkdirAll(target, 0777); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		moved := target + strings.TrimPrefix(file, folder)
+		if err := fs.MkdirAll(filepath.Dir(moved), 0777); err != nil {
+			return err
+		}
+		if err := fs.Rename(file, moved); err != nil {
+			return err
+		}
+	}
+
+	return fs.RemoveAll(folder)
+}
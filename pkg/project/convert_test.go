@@ -0,0 +1,138 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+const convertTestFolder = "test-resources/convert-test"
+
+// readTestFiles returns the content of all files below the folder of the file system, by their path relative to it
+func readTestFiles(t *testing.T, fs afero.Fs, folder string) map[string]string {
+	files := make(map[string]string)
+	err := afero.Walk(fs, folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(folder, path)
+		files[filepath.ToSlash(relative)] = string(content)
+		return err
+	})
+	assert.NilError(t, err)
+	return files
+}
+
+// createLegacyProjects copies the legacy fixture into a writable file system
+func createLegacyProjects(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for path, content := range readTestFiles(t, afero.NewOsFs(), filepath.Join(convertTestFolder, "legacy")) {
+		assert.NilError(t, afero.WriteFile(fs, filepath.Join("projects", path), []byte(content), 0644))
+	}
+	return fs
+}
+
+func TestConvertLegacyConfigsMigratesApplicationConfigs(t *testing.T) {
+	fs := createLegacyProjects(t)
+
+	conversion, err := ConvertLegacyConfigs(fs, "projects", false)
+	assert.NilError(t, err)
+
+	expected := readTestFiles(t, afero.NewOsFs(), filepath.Join(convertTestFolder, "converted"))
+	assert.DeepEqual(t, readTestFiles(t, fs, "projects"), expected)
+
+	assert.DeepEqual(t, conversion.Migrations, []Migration{
+		{Path: filepath.Join("projects", "apps", "application-web", "checkout.yaml"), Description: "update 1 references to configs of the config type application"},
+		{Path: filepath.Join("projects", "apps", "dashboard", "dashboard.yaml"), Description: "update 4 references to configs of the config type application"},
+		{Path: filepath.Join("projects", "infrastructure", "application"), Description: "move to " + filepath.Join("projects", "infrastructure", "application-web") + ", as the config type application is replaced by application-web"},
+		{Path: filepath.Join("projects", "infrastructure", "delete.yaml"), Description: "update 1 references to configs of the config type application"},
+		{Path: filepath.Join("projects", "infrastructure", "management-zone", "zone.yaml"), Description: "update 1 references to configs of the config type application"},
+	})
+	assert.Equal(t, len(conversion.Issues), 1)
+	assert.Equal(t, conversion.Issues[0].Path, filepath.Join("projects", "blocked", "application"))
+	assert.Assert(t, strings.HasSuffix(conversion.Issues[0].Reason, "already exists: move the configs of the deprecated config type application manually"), conversion.Issues[0].Reason)
+}
+
+func TestConvertedProjectsCanBeLoaded(t *testing.T) {
+	fs := createLegacyProjects(t)
+	assert.NilError(t, fs.RemoveAll(filepath.Join("projects", "blocked")))
+
+	_, err := ConvertLegacyConfigs(fs, "projects", false)
+	assert.NilError(t, err)
+
+	projects, err := LoadProjectsToDeploy(fs, "apps", api.NewApis(), "projects", util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Equal(t, len(projects), 2)
+
+	assert.Equal(t, projects[0].GetId(), filepath.Join("projects", "infrastructure"))
+	assert.Assert(t, projects[1].HasDependencyOn(projects[0]))
+
+	var apis []string
+	for _, c := range projects[0].GetConfigs() {
+		apis = append(apis, c.GetApi().GetId()+"/"+c.GetId())
+	}
+	assert.DeepEqual(t, apis, []string{"application-web/shop", "management-zone/zone"})
+}
+
+func TestConvertLegacyConfigsDoesNotChangeFilesInDryRun(t *testing.T) {
+	fs := createLegacyProjects(t)
+	before := readTestFiles(t, fs, "projects")
+
+	conversion, err := ConvertLegacyConfigs(fs, "projects", true)
+	assert.NilError(t, err)
+	assert.Equal(t, len(conversion.Migrations), 5)
+	assert.DeepEqual(t, readTestFiles(t, fs, "projects"), before)
+
+	var out bytes.Buffer
+	assert.NilError(t, conversion.Write(&out, true))
+	assert.Equal(t, out.String(), "Changes converting the legacy configs (dry run, nothing was changed):\n"+
+		"\t"+filepath.Join("projects", "apps", "application-web", "checkout.yaml")+": update 1 references to configs of the config type application\n"+
+		"\t"+filepath.Join("projects", "apps", "dashboard", "dashboard.yaml")+": update 4 references to configs of the config type application\n"+
+		"\t"+filepath.Join("projects", "infrastructure", "application")+": move to "+filepath.Join("projects", "infrastructure", "application-web")+", as the config type application is replaced by application-web\n"+
+		"\t"+filepath.Join("projects", "infrastructure", "delete.yaml")+": update 1 references to configs of the config type application\n"+
+		"\t"+filepath.Join("projects", "infrastructure", "management-zone", "zone.yaml")+": update 1 references to configs of the config type application\n"+
+		"1 legacy constructs can't be converted automatically:\n"+
+		"\t"+conversion.Issues[0].String()+"\n")
+}
+
+func TestConvertLegacyConfigsWithoutLegacyConfigs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, filepath.Join("projects", "proj", "dashboard", "dashboard.yaml"), []byte("config:\n  - overview: \"overview.json\"\n"), 0644))
+
+	conversion, err := ConvertLegacyConfigs(fs, "projects", false)
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, conversion.Write(&out, false))
+	assert.Equal(t, out.String(), "No legacy configs found\n")
+}
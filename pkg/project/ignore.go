@@ -0,0 +1,203 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// ignoreFileName is the name of the files containing patterns of paths to skip when discovering projects and configs
+const ignoreFileName = ".monacoignore"
+
+// ignoreRule is a single pattern of an ignore file
+type ignoreRule struct {
+	// file is the ignore file defining the rule
+	file string
+	// pattern as defined in the file, used for logging
+	pattern string
+	// segments of the pattern, separated by /
+	segments []string
+	// anchored patterns contain a / and are matched against the path relative to the ignore file. All others are
+	// matched against the name of the file or folder, at any depth.
+	anchored bool
+	// negated patterns start with ! and include paths ignored by previous patterns again
+	negated bool
+	// dirOnly patterns end with / and only match folders
+	dirOnly bool
+}
+
+// ignoreMatcher decides whether a path below the projects root folder is ignored. The patterns of a .monacoignore file
+// apply to all paths in its folder and below. Patterns are applied in order, a later matching pattern taking precedence,
+// and patterns of files in subfolders take precedence over those in their parent folders.
+type ignoreMatcher struct {
+	fs    afero.Fs
+	root  string
+	rules map[string][]ignoreRule
+	log   *util.Logger
+}
+
+func newIgnoreMatcher(fs afero.Fs, root string, log *util.Logger) *ignoreMatcher {
+	return &ignoreMatcher{
+		fs:    fs,
+		root:  root,
+		rules: make(map[string][]ignoreRule),
+		log:   log,
+	}
+}
+
+// isIgnored returns true if the path matches a pattern of a .monacoignore file in the projects root folder or any
+// folder between it and the path. Paths outside the projects root folder are never ignored, neither is any path if
+// the matcher is nil.
+func (m *ignoreMatcher) isIgnored(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+
+	relative, err := filepath.Rel(m.root, path)
+	if err != nil || relative == "." || strings.HasPrefix(relative, "..") {
+		return false
+	}
+
+	segments := strings.Split(filepath.ToSlash(relative), "/")
+
+	var matched *ignoreRule
+	for i := range segments {
+		folder := filepath.Join(append([]string{m.root}, segments[:i]...)...)
+
+		rules := m.rulesOf(folder)
+		for j := range rules {
+			if rules[j].matches(segments[i:], isDir) {
+				matched = &rules[j]
+			}
+		}
+	}
+
+	if matched == nil || matched.negated {
+		return false
+	}
+
+	m.log.Debug("Skipping %s, as it matches %s in %s", path, matched.pattern, matched.file)
+	return true
+}
+
+// rulesOf returns the rules of the ignore file in the folder. Each file is only read once.
+func (m *ignoreMatcher) rulesOf(folder string) []ignoreRule {
+	if rules, found := m.rules[folder]; found {
+		return rules
+	}
+
+	file := filepath.Join(folder, ignoreFileName)
+
+	content, err := afero.ReadFile(m.fs, file)
+	if err != nil && !os.IsNotExist(err) {
+		m.log.Warn("Could not read %s: %s", file, err)
+	}
+
+	rules := parseIgnoreFile(file, string(content), m.log)
+	m.rules[folder] = rules
+	return rules
+}
+
+// parseIgnoreFile parses the patterns of an ignore file. Blank lines and lines starting with # are skipped. A leading
+// backslash escapes a pattern starting with ! or #.
+func parseIgnoreFile(file string, content string, log *util.Logger) []ignoreRule {
+	rules := make([]ignoreRule, 0)
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{file: file, pattern: line}
+
+		if strings.HasPrefix(line, "!") {
+			rule.negated = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimLeft(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		if _, err := filepath.Match(line, ""); err != nil {
+			log.Warn("Skipping invalid pattern %s in %s: %s", rule.pattern, file, err)
+			continue
+		}
+
+		rule.segments = strings.Split(line, "/")
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// matches checks the rule against the path segments relative to the folder of the ignore file
+func (r ignoreRule) matches(segments []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if !r.anchored {
+		matched, _ := filepath.Match(r.segments[0], segments[len(segments)-1])
+		return matched
+	}
+
+	return matchSegments(r.segments, segments)
+}
+
+// matchSegments matches the path segments against the pattern segments, where ** matches any number of segments
+func matchSegments(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if matched, _ := filepath.Match(pattern[0], segments[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,92 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestIgnoreMatcherAppliesPatternsRelativeToIgnoreFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "projects/.monacoignore", []byte("# samples provided by the vendor\nsamples/\n*.wip.json\n/zaphod/dashboard\n"), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "projects/trillian/.monacoignore", []byte("!keep.wip.json\nalerting-profile/**/old-*\n"), 0644))
+
+	ignore := newIgnoreMatcher(fs, "projects", util.DefaultLogger())
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"projects/samples", true, true},
+		{"projects/trillian/samples", true, true},
+		{"projects/trillian/samples", false, false},
+		{"projects/zaphod/alerting-profile/profile.wip.json", false, true},
+		{"projects/zaphod/alerting-profile/profile.json", false, false},
+		{"projects/zaphod/dashboard", true, true},
+		{"projects/trillian/zaphod/dashboard", true, false},
+		{"projects/trillian/dashboard/keep.wip.json", false, false},
+		{"projects/trillian/alerting-profile/old-profile.json", false, true},
+		{"projects/trillian/alerting-profile/nested/old-profile.json", false, true},
+		{"projects/zaphod/alerting-profile/old-profile.json", false, false},
+		{"projects", true, false},
+		{"other/samples", true, false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, ignore.isIgnored(test.path, test.isDir), test.ignored, test.path)
+	}
+}
+
+func TestParseIgnoreFileSkipsCommentsAndInvalidPatterns(t *testing.T) {
+	rules := parseIgnoreFile(".monacoignore", "# comment\n\n\\#hash.json\n[invalid\n!\\!bang.json\n", util.DefaultLogger())
+
+	assert.Equal(t, len(rules), 2)
+	assert.DeepEqual(t, rules[0].segments, []string{"#hash.json"})
+	assert.Equal(t, rules[0].negated, false)
+	assert.DeepEqual(t, rules[1].segments, []string{`\!bang.json`})
+	assert.Equal(t, rules[1].negated, true)
+}
+
+func TestLoadProjectsSkipsIgnoredPaths(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"projects/.monacoignore":                              "wip/\nprofile-draft.yaml\n",
+		"projects/zaphod/alerting-profile/profile.yaml":       "config:\n  - profile: \"profile.json\"\n\nprofile:\n  - name: \"Profile\"\n",
+		"projects/zaphod/alerting-profile/profile.json":       "{}",
+		"projects/zaphod/alerting-profile/profile-draft.yaml": "config:\n  - draft: \"missing.json\"\n",
+		"projects/wip/dashboard/dashboard.yaml":               "config:\n  - dashboard: \"missing.json\"\n",
+	}
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+
+	projects, err := LoadProjectsToDeploy(fs, "", api.NewApis(), "projects", util.DefaultLogger())
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(projects), 1)
+	assert.Equal(t, projects[0].GetId(), "projects/zaphod")
+	assert.Equal(t, len(projects[0].GetConfigs()), 1)
+}
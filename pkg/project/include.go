@@ -0,0 +1,107 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// includePattern matches a list entry including a shared fragment, e.g. `  - !include "/shared/defaults.yaml"`
+var includePattern = regexp.MustCompile(`(?m)^([ \t]*)-[ \t]+!include[ \t]+(.*?)[ \t]*\r?$`)
+
+// readYamlWithIncludes reads the yaml file and replaces all `- !include <file>` entries with the content of the
+// included fragment. Fragments are a list of parameters, which are inserted into the parameter list containing the
+// include, and may include further fragments.
+//
+// Includes are resolved before the yaml is templated and parsed, so a parameter defined in a fragment behaves exactly
+// like one defined in the including file. This includes references to other configs, which therefore become
+// dependencies of every config using the fragment.
+func (p *projectBuilder) readYamlWithIncludes(filename string) (string, error) {
+	return p.expandIncludes(filename, []string{})
+}
+
+func (p *projectBuilder) expandIncludes(filename string, includeChain []string) (string, error) {
+	filename = filepath.Clean(filename)
+
+	for _, included := range includeChain {
+		if included == filename {
+			return "", fmt.Errorf("circular include of %s: %s -> %s", filename, strings.Join(includeChain, " -> "), filename)
+		}
+	}
+	includeChain = append(includeChain, filename)
+
+	content, err := afero.ReadFile(p.fs, filename)
+	if err != nil {
+		return "", err
+	}
+
+	var expandErr error
+	expanded := includePattern.ReplaceAllStringFunc(string(content), func(line string) string {
+		if expandErr != nil {
+			return line
+		}
+
+		match := includePattern.FindStringSubmatch(line)
+		indentation, location := match[1], strings.Trim(match[2], `"'`)
+
+		if location == "" {
+			expandErr = fmt.Errorf("missing file name in include of %s", filename)
+			return line
+		}
+
+		fragment, err := p.expandIncludes(p.resolveIncludeLocation(location, filename), includeChain)
+		if err != nil {
+			expandErr = fmt.Errorf("could not include %s in %s: %w", location, filename, err)
+			return line
+		}
+
+		return indent(fragment, indentation)
+	})
+
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
+}
+
+// resolveIncludeLocation resolves the location of an included file like the location of a config json: absolute
+// locations (starting with /) are relative to the projects root folder, all others to the including file
+func (p *projectBuilder) resolveIncludeLocation(location string, includingFile string) string {
+	location = filepath.FromSlash(location)
+
+	if strings.HasPrefix(location, string(os.PathSeparator)) {
+		return filepath.Join(p.projectRootFolder, location[1:])
+	}
+	return filepath.Join(filepath.Dir(includingFile), location)
+}
+
+func indent(fragment string, indentation string) string {
+	lines := strings.Split(strings.TrimRight(fragment, "\r\n"), "\n")
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indentation + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,132 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+const includingYaml = `
+config:
+  - profile: "profile.json"
+
+profile:
+  - name: "profile"
+  - !include "../../shared/defaults.yaml"
+
+profile.dev:
+  - !include /shared/dev.yaml
+`
+
+const defaultsFragment = `# shared defaults
+- severity: "HIGH"
+- zone: "/infrastructure/management-zone/zone.id"
+`
+
+const devFragment = `- !include defaults.yaml
+- severity: "LOW"
+`
+
+func createIncludeTestFs(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "root/project/alerting-profile/profile.yaml", []byte(includingYaml), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "root/shared/defaults.yaml", []byte(defaultsFragment), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "root/shared/dev.yaml", []byte(devFragment), 0644))
+	return fs
+}
+
+func TestReadYamlWithIncludesInsertsFragments(t *testing.T) {
+	fs := createIncludeTestFs(t)
+	builder := testCreateProjectBuilderWithMock(nil, fs, "project", "root")
+
+	content, err := builder.readYamlWithIncludes("root/project/alerting-profile/profile.yaml")
+	assert.NilError(t, err)
+
+	err, properties := util.UnmarshalYaml(content, "profile.yaml")
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, properties["profile"], map[string]string{
+		"name":     "profile",
+		"severity": "HIGH",
+		"zone":     util.ReplacePathSeparators("/infrastructure/management-zone/zone.id"),
+	})
+	assert.DeepEqual(t, properties["profile.dev"], map[string]string{
+		"severity": "LOW",
+		"zone":     util.ReplacePathSeparators("/infrastructure/management-zone/zone.id"),
+	})
+}
+
+func TestReadYamlWithIncludesFailsOnCircularIncludes(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "root/project/alerting-profile/profile.yaml", []byte(includingYaml), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "root/shared/defaults.yaml", []byte("- !include dev.yaml"), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "root/shared/dev.yaml", []byte(devFragment), 0644))
+
+	builder := testCreateProjectBuilderWithMock(nil, fs, "project", "root")
+
+	_, err := builder.readYamlWithIncludes("root/project/alerting-profile/profile.yaml")
+	assert.ErrorContains(t, err, "circular include of root/shared/defaults.yaml: "+
+		"root/project/alerting-profile/profile.yaml -> root/shared/defaults.yaml -> root/shared/dev.yaml -> root/shared/defaults.yaml")
+}
+
+func TestReadYamlWithIncludesFailsOnMissingFragment(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "root/project/alerting-profile/profile.yaml", []byte(includingYaml), 0644))
+
+	builder := testCreateProjectBuilderWithMock(nil, fs, "project", "root")
+
+	_, err := builder.readYamlWithIncludes("root/project/alerting-profile/profile.yaml")
+	assert.ErrorContains(t, err, "could not include ../../shared/defaults.yaml in root/project/alerting-profile/profile.yaml")
+}
+
+func TestConfigsDependOnReferencesInIncludedFragments(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "root/project/alerting-profile/profile.yaml", []byte(`
+config:
+  - profile: "profile.json"
+
+profile:
+  - name: "profile"
+  - !include /shared/zone.yaml
+`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "root/project/alerting-profile/profile.json", []byte(`{"name": "{{ .name }}", "zone": "{{ .zone }}"}`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "root/project/management-zone/zone.yaml", []byte(`
+config:
+  - zone: "zone.json"
+
+zone:
+  - name: "zone"
+`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "root/project/management-zone/zone.json", []byte(`{"name": "{{ .name }}"}`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "root/shared/zone.yaml", []byte(`- zone: "/project/management-zone/zone.id"`), 0644))
+
+	project, err := NewProject(fs, "root/project", "project", createTestApis(), "root", util.DefaultLogger())
+	assert.NilError(t, err)
+
+	configs := project.GetConfigs()
+	assert.Equal(t, len(configs), 2)
+	assert.Equal(t, configs[0].GetId(), "zone")
+	assert.Equal(t, configs[1].GetId(), "profile")
+	assert.Assert(t, configs[1].HasDependencyOn(configs[0]))
+}
@@ -48,13 +48,19 @@ type projectBuilder struct {
 	apis              map[string]api.Api
 	configFactory     config.ConfigFactory
 	fs                afero.Fs
+	ignore            *ignoreMatcher
+	log               *util.Logger
 }
 
-// NewProject loads a new project from folder. Returns either project or a reading/sorting error respectively.
-func NewProject(fs afero.Fs, fullQualifiedProjectFolderName string, projectFolderName string, apis map[string]api.Api, projectRootFolder string) (Project, error) {
+// NewProject loads a new project from folder. Returns either project or a reading/sorting error respectively. Messages
+// are written to the logger, which is the default logger if nil.
+func NewProject(fs afero.Fs, fullQualifiedProjectFolderName string, projectFolderName string, apis map[string]api.Api, projectRootFolder string,
+	log *util.Logger) (Project, error) {
 
 	var configs = make([]config.Config, 0)
 
+	ignore := newIgnoreMatcher(fs, projectRootFolder, log)
+
 	// standardize projectRootFolder
 	// trim path separator from projectRoot
 	projectRootFolder = strings.Trim(projectRootFolder, string(os.PathSeparator))
@@ -64,8 +70,10 @@ func NewProject(fs afero.Fs, fullQualifiedProjectFolderName string, projectFolde
 		projectId:         fullQualifiedProjectFolderName,
 		configs:           configs,
 		apis:              apis,
-		configFactory:     config.NewConfigFactory(),
+		configFactory:     config.NewConfigFactory(log),
 		fs:                fs,
+		ignore:            ignore,
+		log:               log,
 	}
 	err := builder.readFolder(fullQualifiedProjectFolderName, true)
 	if err != nil {
@@ -79,7 +87,7 @@ func NewProject(fs afero.Fs, fullQualifiedProjectFolderName string, projectFolde
 		return nil, err
 	}
 
-	warnIfProjectNameClashesWithApiName(projectFolderName, apis, projectRootFolder)
+	warnIfProjectNameClashesWithApiName(projectFolderName, apis, projectRootFolder, log)
 
 	return &projectImpl{
 		id:      fullQualifiedProjectFolderName,
@@ -87,19 +95,19 @@ func NewProject(fs afero.Fs, fullQualifiedProjectFolderName string, projectFolde
 	}, nil
 }
 
-func warnIfProjectNameClashesWithApiName(projectFolderName string, apis map[string]api.Api, projectRootFolder string) {
+func warnIfProjectNameClashesWithApiName(projectFolderName string, apis map[string]api.Api, projectRootFolder string, log *util.Logger) {
 
 	lowerCaseProjectFolderName := strings.ToLower(projectFolderName)
 	_, ok := apis[lowerCaseProjectFolderName]
 	if ok {
-		util.Log.Warn("Project %s in folder %s clashes with API name %s. Consider using a different name for your project.", projectFolderName, projectRootFolder, lowerCaseProjectFolderName)
+		log.Warn("Project %s in folder %s clashes with API name %s. Consider using a different name for your project.", projectFolderName, projectRootFolder, lowerCaseProjectFolderName)
 	}
 }
 
 func (p *projectBuilder) readFolder(folder string, isProjectRoot bool) error {
 	files, err := afero.ReadDir(p.fs, folder)
 
-	if util.CheckError(err, "Folder "+folder+" could not be read") {
+	if p.log.CheckError(err, "Folder "+folder+" could not be read") {
 		return err
 	}
 
@@ -107,6 +115,10 @@ func (p *projectBuilder) readFolder(folder string, isProjectRoot bool) error {
 
 		fullFileName := filepath.Join(folder, file.Name())
 
+		if p.ignore.isIgnored(fullFileName, file.IsDir()) {
+			continue
+		}
+
 		if file.IsDir() {
 			err = p.readFolder(fullFileName, false)
 			if err != nil {
@@ -121,21 +133,21 @@ func (p *projectBuilder) readFolder(folder string, isProjectRoot bool) error {
 
 func (p *projectBuilder) processYaml(filename string) error {
 
-	util.Log.Debug("Processing file: " + filename)
+	p.log.Debug("Processing file: %s", filename)
 
-	bytes, err := afero.ReadFile(p.fs, filename)
+	content, err := p.readYamlWithIncludes(filename)
 
-	if util.CheckError(err, "Error while reading file "+filename) {
+	if p.log.CheckError(err, "Error while reading file "+filename) {
 		return err
 	}
 
-	err, properties := util.UnmarshalYaml(string(bytes), filename)
-	if util.CheckError(err, "Error while converting file "+filename) {
+	err, properties := util.UnmarshalYaml(content, filename)
+	if p.log.CheckError(err, "Error while converting file "+filename) {
 		return err
 	}
 
 	err, folderPath := p.removeYamlFileFromPath(filename)
-	if util.CheckError(err, "Error while stripping yaml from file path "+filename) {
+	if p.log.CheckError(err, "Error while stripping yaml from file path "+filename) {
 		return err
 	}
 
@@ -148,7 +160,7 @@ func (p *projectBuilder) processConfigSection(properties map[string]map[string]s
 
 	templates, ok := properties["config"]
 	if !ok {
-		util.Log.Error("Property 'config' was not available")
+		p.log.Error("Property 'config' was not available")
 		return errors.New("Property 'config' was not available")
 	}
 
@@ -157,17 +169,17 @@ func (p *projectBuilder) processConfigSection(properties map[string]map[string]s
 		location = p.standardizeLocation(location, folderPath)
 
 		err, api := p.getExtendedInformationFromLocation(location)
-		if util.CheckError(err, "Could not find API fom location") {
+		if p.log.CheckError(err, "Could not find API fom location") {
 			return err
 		}
 
 		//Introduce deprecation warning message when using the config type "application"
 		if api.GetId() == "application" {
-			util.Log.Warn("You are using the configuration 'application', which will be deprecated in v2.0.0. Replace with type 'application-web'.")
+			p.log.Warn("You are using the configuration 'application', which will be deprecated in v2.0.0. Replace with type 'application-web', e.g. using the convert command.")
 		}
 
 		config, err := p.configFactory.NewConfig(p.fs, configName, p.projectId, location, properties, api)
-		if util.CheckError(err, "Could not create config"+configName) {
+		if p.log.CheckError(err, "Could not create config"+configName) {
 			return err
 		}
 
@@ -249,7 +261,7 @@ func isYaml(file string) bool {
 
 func (p *projectBuilder) sortConfigsAccordingToDependencies() error {
 
-	configs, err := sortConfigurations(p.configs)
+	configs, err := sortConfigurations(p.configs, p.log)
 	if err == nil {
 		p.configs = configs
 	}
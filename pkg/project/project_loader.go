@@ -33,24 +33,35 @@ import (
 // if projects specified with -p parameter then it takes only those projects and
 // it also resolves all project dependencies
 // if no -p parameter specified, then it creates a list of all projects
-func LoadProjectsToDeploy(fs afero.Fs, specificProjectToDeploy string, apis map[string]api.Api, path string) (projectsToDeploy []Project, err error) {
+// Messages are written to the logger, which is the default logger if nil.
+func LoadProjectsToDeploy(fs afero.Fs, specificProjectToDeploy string, apis map[string]api.Api, path string, log *util.Logger) (projectsToDeploy []Project, err error) {
+	return loadProjectsToDeploy(fs, specificProjectToDeploy, apis, path, false, log)
+}
+
+// LoadProjectsToDeployStrict returns the specified projects like LoadProjectsToDeploy, but doesn't add the projects
+// they depend on. Instead, it fails if any of the specified projects depends on a project which isn't specified.
+func LoadProjectsToDeployStrict(fs afero.Fs, specificProjectToDeploy string, apis map[string]api.Api, path string, log *util.Logger) (projectsToDeploy []Project, err error) {
+	return loadProjectsToDeploy(fs, specificProjectToDeploy, apis, path, true, log)
+}
+
+func loadProjectsToDeploy(fs afero.Fs, specificProjectToDeploy string, apis map[string]api.Api, path string, strict bool, log *util.Logger) (projectsToDeploy []Project, err error) {
 
 	projectsFolder := filepath.Clean(path)
 	projectsToDeploy = make([]Project, 0)
 
-	util.Log.Debug("Reading projects...")
+	log.Debug("Reading projects...")
 
 	// creates list of all available projects
-	availableProjectFolders, err := getAllProjectFoldersRecursively(fs, projectsFolder)
+	availableProjectFolders, err := getAllProjectFoldersRecursively(fs, projectsFolder, newIgnoreMatcher(fs, projectsFolder, log))
 	if err != nil {
 		return nil, err
 	}
 
 	availableProjects := make([]Project, 0)
 	for _, fullQualifiedProjectFolderName := range availableProjectFolders {
-		util.Log.Debug("  project - %s", fullQualifiedProjectFolderName)
+		log.Debug("  project - %s", fullQualifiedProjectFolderName)
 		projectFolderName := extractFolderNameFromFullPath(fullQualifiedProjectFolderName)
-		project, err := NewProject(fs, fullQualifiedProjectFolderName, projectFolderName, apis, projectsFolder)
+		project, err := NewProject(fs, fullQualifiedProjectFolderName, projectFolderName, apis, projectsFolder, log)
 		if err != nil {
 			return nil, err
 		}
@@ -61,15 +72,23 @@ func LoadProjectsToDeploy(fs afero.Fs, specificProjectToDeploy string, apis map[
 	// otherwise only add projects specified by parameter
 	if specificProjectToDeploy == "" {
 		projectsToDeploy = availableProjects
-		return returnSortedProjects(projectsToDeploy)
+		return returnSortedProjects(projectsToDeploy, log)
 	}
 
-	projectsToDeploy, err = createProjectsListFromFolderList(fs, projectsFolder, specificProjectToDeploy, projectsFolder, apis, availableProjectFolders)
+	projectsToDeploy, err = createProjectsListFromFolderList(fs, projectsFolder, specificProjectToDeploy, projectsFolder, apis, availableProjectFolders, log)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if strict {
+		err = checkDependenciesIncluded(projectsToDeploy, availableProjects)
+		if err != nil {
+			return nil, err
+		}
+		return returnSortedProjects(projectsToDeploy, log)
+	}
+
 	// goes through the list of projectToDeploy and searches for dependencies
 	// it searches the list recursively as long as dependencies are found
 	foundDependency := true
@@ -78,6 +97,7 @@ func LoadProjectsToDeploy(fs afero.Fs, specificProjectToDeploy string, apis map[
 		for _, project := range projectsToDeploy {
 			for _, availableProject := range availableProjects {
 				if project.HasDependencyOn(availableProject) && !isProjectAlreadyAdded(availableProject, projectsToDeploy) {
+					log.Info("Including project %s, as project %s depends on it (%s)", availableProject.GetId(), project.GetId(), dependencyReason(project, availableProject))
 					projectsToDeploy = append(projectsToDeploy, availableProject)
 					foundDependency = true
 				}
@@ -85,12 +105,41 @@ func LoadProjectsToDeploy(fs afero.Fs, specificProjectToDeploy string, apis map[
 		}
 	}
 
-	return returnSortedProjects(projectsToDeploy)
+	return returnSortedProjects(projectsToDeploy, log)
 }
 
-func returnSortedProjects(projectsToDeploy []Project) ([]Project, error) {
-	util.Log.Debug("Sorting projects...")
-	projectsToDeploy, err := sortProjects(projectsToDeploy)
+// dependencyReason describes the first config of the project referencing a config of the other project
+func dependencyReason(project Project, other Project) string {
+	for _, config := range project.GetConfigs() {
+		for _, otherConfig := range other.GetConfigs() {
+			if config.HasDependencyOn(otherConfig) {
+				return fmt.Sprintf("%s references %s", config.GetFullQualifiedId(), otherConfig.GetFullQualifiedId())
+			}
+		}
+	}
+	return "dependency"
+}
+
+// checkDependenciesIncluded fails if any of the projects depends on a project which isn't part of the projects
+func checkDependenciesIncluded(projects []Project, availableProjects []Project) error {
+	var missing []string
+	for _, project := range projects {
+		for _, availableProject := range availableProjects {
+			if project.HasDependencyOn(availableProject) && !isProjectAlreadyAdded(availableProject, projects) {
+				missing = append(missing, fmt.Sprintf("%s depends on %s (%s)", project.GetId(), availableProject.GetId(), dependencyReason(project, availableProject)))
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("projects to deploy depend on projects which are not selected: %s. Add the projects, or deploy without strict project selection to include them automatically", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func returnSortedProjects(projectsToDeploy []Project, log *util.Logger) ([]Project, error) {
+	log.Debug("Sorting projects...")
+	projectsToDeploy, err := sortProjects(projectsToDeploy, log)
 	if err != nil {
 		return nil, err
 	}
@@ -100,9 +149,15 @@ func returnSortedProjects(projectsToDeploy []Project) ([]Project, error) {
 
 // takes project folder parameter and creates []Project slice
 // if project specified contains subprojects, then it adds subprojects instead
-func createProjectsListFromFolderList(fs afero.Fs, path, specificProjectToDeploy string, projectsFolder string, apis map[string]api.Api, availableProjectFolders []string) ([]Project, error) {
+func createProjectsListFromFolderList(fs afero.Fs, path, specificProjectToDeploy string, projectsFolder string, apis map[string]api.Api, availableProjectFolders []string,
+	log *util.Logger) ([]Project, error) {
 	projectsToDeploy := make([]Project, 0)
 	multiProjects := strings.Split(specificProjectToDeploy, ",")
+
+	if err := checkProjectsExist(fs, projectsFolder, multiProjects); err != nil {
+		return nil, err
+	}
+
 	for _, projectFolderName := range multiProjects {
 
 		projectFolderName = strings.TrimSpace(projectFolderName)
@@ -116,21 +171,21 @@ func createProjectsListFromFolderList(fs afero.Fs, path, specificProjectToDeploy
 				return nil, errors.WithMessagef(err, "Project %s does not exist!", specificProjectToDeploy)
 			}
 
-			newProject, err := NewProject(fs, fullQualifiedProjectFolderName, projectFolderName, apis, path)
+			newProject, err := NewProject(fs, fullQualifiedProjectFolderName, projectFolderName, apis, path, log)
 			if err != nil {
 				return nil, err
 			}
 			projectsToDeploy = append(projectsToDeploy, newProject)
 		} else {
 			// get list of folders only for this path
-			subProjectFolders, err := getAllProjectFoldersRecursively(fs, fullQualifiedProjectFolderName)
+			subProjectFolders, err := getAllProjectFoldersRecursively(fs, fullQualifiedProjectFolderName, newIgnoreMatcher(fs, projectsFolder, log))
 			if err != nil {
 				return nil, err
 			}
 			for _, fullQualifiedSubProjectFolderName := range subProjectFolders {
 
 				subProjectFolderName := extractFolderNameFromFullPath(fullQualifiedSubProjectFolderName)
-				newProject, err := NewProject(fs, fullQualifiedSubProjectFolderName, subProjectFolderName, apis, path)
+				newProject, err := NewProject(fs, fullQualifiedSubProjectFolderName, subProjectFolderName, apis, path, log)
 				if err != nil {
 					return nil, err
 				}
@@ -142,6 +197,22 @@ func createProjectsListFromFolderList(fs afero.Fs, path, specificProjectToDeploy
 	return projectsToDeploy, nil
 }
 
+// checkProjectsExist fails if any of the project folders doesn't exist, naming all missing projects at once
+func checkProjectsExist(fs afero.Fs, projectsFolder string, projectFolderNames []string) error {
+	var missing []string
+	for _, projectFolderName := range projectFolderNames {
+		projectFolderName = strings.TrimSpace(projectFolderName)
+		if _, err := fs.Stat(filepath.Join(projectsFolder, projectFolderName)); err != nil {
+			missing = append(missing, projectFolderName)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("Project(s) %s do not exist in %s!", strings.Join(missing, ", "), projectsFolder)
+	}
+	return nil
+}
+
 func extractFolderNameFromFullPath(fullQualifiedProjectFolderName string) string {
 
 	// split the full qualified sub project folder name into the individual folders:
@@ -182,17 +253,20 @@ func hasSubprojectFolder(projectFolder string, projectFolders []string) bool {
 }
 
 // walks through a path recursively and searches for all folders
-// ignores folders with configurations (containing api configs) and hidden folders
+// ignores folders with configurations (containing api configs), hidden folders and folders matching a .monacoignore file
 // fails if a folder with both sub projects and api configs are found
-func getAllProjectFoldersRecursively(fs afero.Fs, path string) ([]string, error) {
+func getAllProjectFoldersRecursively(fs afero.Fs, path string, ignore *ignoreMatcher) ([]string, error) {
 	var allProjectsFolders []string
 	err := afero.Walk(fs, path, func(path string, info os.FileInfo, err error) error {
 		if info == nil {
 			return fmt.Errorf("Project path does not exist: %s. (This needs to be a relative path from the current directory)", path)
 		}
+		if info.IsDir() && ignore.isIgnored(path, true) {
+			return filepath.SkipDir
+		}
 		if info.IsDir() && !strings.HasPrefix(path, ".") && !api.ContainsApiName(path) {
 			allProjectsFolders = append(allProjectsFolders, path)
-			err := subprojectsMixedWithApi(fs, path)
+			err := subprojectsMixedWithApi(fs, path, ignore)
 			return err
 		}
 		return nil
@@ -204,7 +278,7 @@ func getAllProjectFoldersRecursively(fs afero.Fs, path string) ([]string, error)
 	return filterProjectsWithSubproject(allProjectsFolders), nil
 }
 
-func subprojectsMixedWithApi(fs afero.Fs, path string) error {
+func subprojectsMixedWithApi(fs afero.Fs, path string, ignore *ignoreMatcher) error {
 	apiFound, subprojectFound := false, false
 	_, err := fs.Open(path)
 	if err != nil {
@@ -215,6 +289,9 @@ func subprojectsMixedWithApi(fs afero.Fs, path string) error {
 		return err
 	}
 	for _, d := range dirs {
+		if ignore.isIgnored(filepath.Join(path, d.Name()), d.IsDir()) {
+			continue
+		}
 		if api.IsApi(d.Name()) {
 			apiFound = true
 		} else if d.IsDir() {
@@ -20,7 +20,9 @@
 package project
 
 import (
+	"bytes"
 	"os"
+	"strings"
 	"testing"
 
 	"gotest.tools/assert"
@@ -47,10 +49,10 @@ func TestCreateProjectsFromFolderList(t *testing.T) {
 	specificProjectToDeploy := "zem, marvin, caveman"
 	apis := api.NewApis()
 	fs := util.CreateTestFileSystem()
-	allProjectFolders, err := getAllProjectFoldersRecursively(fs, path)
+	allProjectFolders, err := getAllProjectFoldersRecursively(fs, path, newIgnoreMatcher(fs, path, util.DefaultLogger()))
 	assert.NilError(t, err)
 
-	projects, err := createProjectsListFromFolderList(fs, path, specificProjectToDeploy, path, apis, allProjectFolders)
+	projects, err := createProjectsListFromFolderList(fs, path, specificProjectToDeploy, path, apis, allProjectFolders, util.DefaultLogger())
 
 	assert.NilError(t, err)
 
@@ -65,7 +67,7 @@ func TestCreateProjectsFromFolderList(t *testing.T) {
 func TestLoadProjectsToDeployFromFolder(t *testing.T) {
 	folder := "test-resources/transitional-dependency-test"
 	fs := util.CreateTestFileSystem()
-	projects, err := LoadProjectsToDeploy(fs, "", api.NewApis(), folder)
+	projects, err := LoadProjectsToDeploy(fs, "", api.NewApis(), folder, util.DefaultLogger())
 	assert.NilError(t, err)
 	assert.Equal(t, len(projects), 7, "Check if all projects are loaded into list.")
 }
@@ -73,14 +75,14 @@ func TestLoadProjectsToDeployFromFolder(t *testing.T) {
 func TestLoadProjectsThrowsErrorOnCircularConfigDependecy(t *testing.T) {
 	folder := "test-resources/circular-config-dependency-test"
 	fs := util.CreateTestFileSystem()
-	_, err := LoadProjectsToDeploy(fs, "", api.NewApis(), folder)
+	_, err := LoadProjectsToDeploy(fs, "", api.NewApis(), folder, util.DefaultLogger())
 	assert.ErrorContains(t, err, "circular dependency on config")
 }
 
 func TestLoadProjectsThrowsErrorOnCircularProjectDependency(t *testing.T) {
 	folder := "test-resources/circular-project-dependency-test"
 	fs := util.CreateTestFileSystem()
-	_, err := LoadProjectsToDeploy(fs, "", api.NewApis(), folder)
+	_, err := LoadProjectsToDeploy(fs, "", api.NewApis(), folder, util.DefaultLogger())
 	assert.ErrorContains(t, err, "circular dependency on project")
 }
 
@@ -91,7 +93,7 @@ func TestLoadProjectsThrowsErrorOnCircularProjectDependency(t *testing.T) {
 func TestLoadProjectsToDeployWithTransitionalDependencies(t *testing.T) {
 	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
 	fs := util.CreateTestFileSystem()
-	projects, err := LoadProjectsToDeploy(fs, "aseed", api.NewApis(), folder)
+	projects, err := LoadProjectsToDeploy(fs, "aseed", api.NewApis(), folder, util.DefaultLogger())
 
 	assert.NilError(t, err)
 
@@ -104,6 +106,19 @@ func TestLoadProjectsToDeployWithTransitionalDependencies(t *testing.T) {
 	assert.Equal(t, projects[3].GetId(), folder+ps+"aseed", "Check if `aseed` in projects list")
 }
 
+func TestLoadProjectsToDeployLogsIncludedDependenciesToLogger(t *testing.T) {
+	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
+	fs := util.CreateTestFileSystem()
+
+	var out bytes.Buffer
+	log, err := util.NewLogger(fs, util.LoggerOptions{Writer: &out})
+	assert.NilError(t, err)
+
+	_, err = LoadProjectsToDeploy(fs, "aseed", api.NewApis(), folder, log)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(out.String(), "Including project "+folder+string(os.PathSeparator)+"marvin"), out.String())
+}
+
 /*Test loading of project zem
  * Dependencies: zem -> caveman/eddie, caveman/eddie -> zaphod
  * Expected sorted projects: zaphod, caveman/eddie, zem
@@ -111,7 +126,7 @@ func TestLoadProjectsToDeployWithTransitionalDependencies(t *testing.T) {
 func TestLoadProjectsWithResolvingDependenciesInProjectsTree1(t *testing.T) {
 	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
 	fs := util.CreateTestFileSystem()
-	projects, err := LoadProjectsToDeploy(fs, "zem", api.NewApis(), folder)
+	projects, err := LoadProjectsToDeploy(fs, "zem", api.NewApis(), folder, util.DefaultLogger())
 
 	assert.NilError(t, err)
 
@@ -130,7 +145,7 @@ func TestLoadProjectsWithResolvingDependenciesInProjectsTree1(t *testing.T) {
 func TestLoadProjectsWithResolvingDependenciesInProjectsTree2(t *testing.T) {
 	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
 	fs := util.CreateTestFileSystem()
-	projects, err := LoadProjectsToDeploy(fs, "zem, marvin, caveman", api.NewApis(), folder)
+	projects, err := LoadProjectsToDeploy(fs, "zem, marvin, caveman", api.NewApis(), folder, util.DefaultLogger())
 
 	assert.NilError(t, err)
 
@@ -149,7 +164,7 @@ func TestLoadProjectsWithResolvingDependenciesInProjectsTreeProjectSubprojectWit
 	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
 	project := util.ReplacePathSeparators("caveman/anjie/garkbit")
 	fs := util.CreateTestFileSystem()
-	projects, err := LoadProjectsToDeploy(fs, project, api.NewApis(), folder)
+	projects, err := LoadProjectsToDeploy(fs, project, api.NewApis(), folder, util.DefaultLogger())
 
 	assert.NilError(t, err)
 
@@ -174,7 +189,7 @@ func TestFilterProjectsWithSubproject(t *testing.T) {
 func TestGetAllProjectFoldersRecursivelyFailsOnMixedFolder(t *testing.T) {
 	path := util.ReplacePathSeparators("test-resources/configs-and-api-mixed-test/project1")
 	fs := util.CreateTestFileSystem()
-	_, err := getAllProjectFoldersRecursively(fs, path)
+	_, err := getAllProjectFoldersRecursively(fs, path, newIgnoreMatcher(fs, path, util.DefaultLogger()))
 
 	expected := util.ReplacePathSeparators("found folder with projects and configurations in test-resources/configs-and-api-mixed-test/project1")
 	assert.Error(t, err, expected)
@@ -183,7 +198,7 @@ func TestGetAllProjectFoldersRecursivelyFailsOnMixedFolder(t *testing.T) {
 func TestGetAllProjectFoldersRecursivelyFailsOnMixedFolderInSubproject(t *testing.T) {
 	path := util.ReplacePathSeparators("test-resources/configs-and-api-mixed-test/project2")
 	fs := util.CreateTestFileSystem()
-	_, err := getAllProjectFoldersRecursively(fs, path)
+	_, err := getAllProjectFoldersRecursively(fs, path, newIgnoreMatcher(fs, path, util.DefaultLogger()))
 
 	expected := util.ReplacePathSeparators("found folder with projects and configurations in test-resources/configs-and-api-mixed-test/project2/subproject2")
 	assert.Error(t, err, expected)
@@ -192,6 +207,44 @@ func TestGetAllProjectFoldersRecursivelyFailsOnMixedFolderInSubproject(t *testin
 func TestGetAllProjectFoldersRecursivelyPassesOnSeparatedFolders(t *testing.T) {
 	path := util.ReplacePathSeparators("test-resources/configs-and-api-mixed-test/project3")
 	fs := util.CreateTestFileSystem()
-	_, err := getAllProjectFoldersRecursively(fs, path)
+	_, err := getAllProjectFoldersRecursively(fs, path, newIgnoreMatcher(fs, path, util.DefaultLogger()))
+	assert.NilError(t, err)
+}
+
+func TestLoadProjectsToDeployStrictFailsOnUnselectedDependencies(t *testing.T) {
+	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
+	fs := util.CreateTestFileSystem()
+	_, err := LoadProjectsToDeployStrict(fs, "marvin", api.NewApis(), folder, util.DefaultLogger())
+
+	ps := string(os.PathSeparator)
+	assert.ErrorContains(t, err, "projects to deploy depend on projects which are not selected: "+folder+ps+"marvin depends on "+folder+ps+"trillian")
+}
+
+func TestLoadProjectsToDeployStrictLoadsOnlySelectedProjects(t *testing.T) {
+	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
+	fs := util.CreateTestFileSystem()
+	projects, err := LoadProjectsToDeployStrict(fs, "marvin, trillian, zaphod", api.NewApis(), folder, util.DefaultLogger())
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(projects), 3)
+}
+
+func TestLoadProjectsToDeployNamesAllMissingProjects(t *testing.T) {
+	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
+	fs := util.CreateTestFileSystem()
+	_, err := LoadProjectsToDeploy(fs, "marvin, unknown, other", api.NewApis(), folder, util.DefaultLogger())
+
+	assert.Error(t, err, "Project(s) unknown, other do not exist in "+folder+"!")
+}
+
+func TestDependencyReasonNamesReferencingConfig(t *testing.T) {
+	folder := util.ReplacePathSeparators("test-resources/transitional-dependency-test")
+	fs := util.CreateTestFileSystem()
+	projects, err := LoadProjectsToDeployStrict(fs, "marvin, trillian, zaphod", api.NewApis(), folder, util.DefaultLogger())
 	assert.NilError(t, err)
+
+	// projects are sorted by their dependencies: zaphod, trillian, marvin
+	ps := string(os.PathSeparator)
+	assert.Equal(t, dependencyReason(projects[2], projects[1]),
+		folder+ps+"marvin"+ps+"management-zone"+ps+"zone references "+folder+ps+"trillian"+ps+"dashboard"+ps+"dashboard")
 }
@@ -35,7 +35,7 @@ func testCreateProjectBuilder(projectsRoot string) projectBuilder {
 	return projectBuilder{
 		projectRootFolder: projectsRoot,
 		apis:              createTestApis(),
-		configFactory:     config.NewConfigFactory(),
+		configFactory:     config.NewConfigFactory(util.DefaultLogger()),
 		configs:           make([]config.Config, 10),
 	}
 }
@@ -0,0 +1,52 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// ReadProjectsFile returns the names of the projects listed in the file. Projects are separated by newlines or commas,
+// empty lines and lines starting with # are ignored.
+func ReadProjectsFile(fs afero.Fs, file string) ([]string, error) {
+	content, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read projects file %s: %w", file, err)
+	}
+
+	projects := make([]string, 0)
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				projects = append(projects, name)
+			}
+		}
+	}
+
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("projects file %s does not list any project", file)
+	}
+	return projects, nil
+}
@@ -0,0 +1,55 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestReadProjectsFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "projects.txt", []byte(`
+# release 42
+infrastructure
+cinema, star-trek
+
+  star-wars  
+`), 0644))
+
+	projects, err := ReadProjectsFile(fs, "projects.txt")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, projects, []string{"infrastructure", "cinema", "star-trek", "star-wars"})
+}
+
+func TestReadProjectsFileFailsOnEmptyFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "projects.txt", []byte("# nothing to deploy\n"), 0644))
+
+	_, err := ReadProjectsFile(fs, "projects.txt")
+	assert.Error(t, err, "projects file projects.txt does not list any project")
+}
+
+func TestReadProjectsFileFailsOnMissingFile(t *testing.T) {
+	_, err := ReadProjectsFile(afero.NewMemMapFs(), "projects.txt")
+	assert.ErrorContains(t, err, "could not read projects file projects.txt")
+}
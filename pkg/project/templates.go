@@ -0,0 +1,142 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// TemplateIssue is either a template file which is not referenced by any config (an orphan), or a config referencing
+// a template file which does not exist
+type TemplateIssue struct {
+	// Path of the template file, relative to the current directory
+	Path string
+	// ReferencedBy is the yaml file referencing the missing template. It is empty for orphaned templates.
+	ReferencedBy string
+}
+
+func (i TemplateIssue) String() string {
+	if i.ReferencedBy == "" {
+		return fmt.Sprintf("template %s is not referenced by any config", i.Path)
+	}
+	return fmt.Sprintf("template %s referenced in %s does not exist", i.Path, i.ReferencedBy)
+}
+
+// FindTemplateIssues searches all api folders below the projects root folder for json and jsonnet templates not
+// referenced by any config yaml, and for config yaml entries referencing templates which do not exist. Only files in
+// api folders are considered, as only those are loaded as configs. Hidden folders and paths matching a .monacoignore
+// file are skipped, which is logged to the logger.
+func FindTemplateIssues(fs afero.Fs, projectRootFolder string, apis map[string]api.Api, log *util.Logger) ([]TemplateIssue, error) {
+	builder := projectBuilder{
+		projectRootFolder: strings.Trim(projectRootFolder, string(os.PathSeparator)),
+		apis:              apis,
+		fs:                fs,
+		ignore:            newIgnoreMatcher(fs, projectRootFolder, log),
+		log:               log,
+	}
+
+	templates := make(map[string]struct{})
+	references := make(map[string]string)
+
+	err := afero.Walk(fs, projectRootFolder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != projectRootFolder && (strings.HasPrefix(info.Name(), ".") || builder.ignore.isIgnored(path, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if builder.ignore.isIgnored(path, false) {
+			return nil
+		}
+
+		if err, _ := builder.getConfigTypeFromLocation(path); err != nil {
+			return nil
+		}
+
+		path = filepath.Clean(path)
+
+		switch {
+		case strings.HasSuffix(path, ".json"), strings.HasSuffix(path, ".jsonnet"):
+			templates[path] = struct{}{}
+		case isYaml(path):
+			return builder.collectTemplateReferences(path, references)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []TemplateIssue
+
+	for template := range templates {
+		if _, referenced := references[template]; !referenced {
+			issues = append(issues, TemplateIssue{Path: template})
+		}
+	}
+
+	for template, yamlFile := range references {
+		exists, err := afero.Exists(fs, template)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			issues = append(issues, TemplateIssue{Path: template, ReferencedBy: yamlFile})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Path < issues[j].Path
+	})
+
+	return issues, nil
+}
+
+// collectTemplateReferences adds the location of every template referenced in the config section of the yaml file to
+// references, mapped to the yaml file. Yaml files without config section are ignored.
+func (p *projectBuilder) collectTemplateReferences(yamlFile string, references map[string]string) error {
+	content, err := p.readYamlWithIncludes(yamlFile)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", yamlFile, err)
+	}
+
+	err, properties := util.UnmarshalYaml(content, yamlFile)
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", yamlFile, err)
+	}
+
+	folderPath := filepath.Dir(yamlFile)
+
+	for _, location := range properties["config"] {
+		location = filepath.Clean(p.standardizeLocation(location, folderPath))
+		references[location] = yamlFile
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package project
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func writeTemplateTestFiles(t *testing.T, files map[string]string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for name, content := range files {
+		assert.NilError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+	return fs
+}
+
+func TestFindTemplateIssuesReportsOrphanedAndMissingTemplates(t *testing.T) {
+	fs := writeTemplateTestFiles(t, map[string]string{
+		"projects/zaphod/alerting-profile/profile.yaml":     "config:\n  - profile: \"profile.json\"\n  - other: \"missing.json\"\n",
+		"projects/zaphod/alerting-profile/profile.json":     "{}",
+		"projects/zaphod/alerting-profile/profile-old.json": "{}",
+		"projects/zaphod/dashboard/dashboard.yaml":          "config:\n  - dashboard: \"/shared/dashboard/dashboard.json\"\n",
+		"projects/shared/dashboard/dashboard.json":          "{}",
+		"projects/shared/dashboard/.backup/dashboard.json":  "{}",
+		"projects/report.json":                              "{}",
+	})
+
+	issues, err := FindTemplateIssues(fs, "projects", api.NewApis(), util.DefaultLogger())
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, issues, []TemplateIssue{
+		{Path: "projects/zaphod/alerting-profile/missing.json", ReferencedBy: "projects/zaphod/alerting-profile/profile.yaml"},
+		{Path: "projects/zaphod/alerting-profile/profile-old.json"},
+	})
+	assert.Equal(t, issues[0].String(), "template projects/zaphod/alerting-profile/missing.json referenced in projects/zaphod/alerting-profile/profile.yaml does not exist")
+	assert.Equal(t, issues[1].String(), "template projects/zaphod/alerting-profile/profile-old.json is not referenced by any config")
+}
+
+func TestFindTemplateIssuesReturnsNothingForConsistentProjects(t *testing.T) {
+	fs := writeTemplateTestFiles(t, map[string]string{
+		"zaphod/alerting-profile/profile.yaml": "config:\n  - profile: \"profile.json\"\n",
+		"zaphod/alerting-profile/profile.json": "{}",
+	})
+
+	issues, err := FindTemplateIssues(fs, ".", api.NewApis(), util.DefaultLogger())
+	assert.NilError(t, err)
+	assert.Equal(t, len(issues), 0)
+}
@@ -18,17 +18,20 @@ package project
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/config"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 )
 
-func sortProjects(projects []Project) (sorted []Project, err error) {
+func sortProjects(projects []Project, log *util.Logger) (sorted []Project, err error) {
 	sorted = []Project{}
-	incomingDeps, inDegrees := calculateIncomingProjectDependencies(projects)
+	incomingDeps, inDegrees := calculateIncomingProjectDependencies(projects, log)
 	reverse, err, errorOn := topologySort(incomingDeps, inDegrees)
 	if err != nil {
-		return sorted, fmt.Errorf("failed to sort projects, circular dependency on project %s detected, please check dependencies in project configs", projects[errorOn].GetId())
+		cycle := findCycle(incomingDeps, errorOn)
+		path := formatCycle(cycle, func(i int) string { return projects[i].GetId() })
+		return sorted, fmt.Errorf("failed to sort projects, circular dependency on project %s detected, please check dependencies in project configs: %s", projects[cycle[0]].GetId(), path)
 	}
 
 	for i := len(reverse) - 1; i >= 0; i-- {
@@ -37,7 +40,7 @@ func sortProjects(projects []Project) (sorted []Project, err error) {
 	return sorted, nil
 }
 
-func calculateIncomingProjectDependencies(projects []Project) (adjacencyMatrix [][]bool, inDegrees []int) {
+func calculateIncomingProjectDependencies(projects []Project, log *util.Logger) (adjacencyMatrix [][]bool, inDegrees []int) {
 	adjacencyMatrix = make([][]bool, len(projects))
 	inDegrees = make([]int, len(projects))
 
@@ -48,7 +51,7 @@ func calculateIncomingProjectDependencies(projects []Project) (adjacencyMatrix [
 			if i != j {
 				p2 := projects[j]
 				if p2.HasDependencyOn(p1) {
-					util.Log.Debug("\t\t%s has dep on %s", p2.GetId(), p1.GetId())
+					log.Debug("\t\t%s has dep on %s", p2.GetId(), p1.GetId())
 					adjacencyMatrix[i][j] = true
 					inDegrees[i]++
 				}
@@ -59,23 +62,25 @@ func calculateIncomingProjectDependencies(projects []Project) (adjacencyMatrix [
 	return adjacencyMatrix, inDegrees
 }
 
-func sortConfigurations(configs []config.Config) (sorted []config.Config, err error) {
+func sortConfigurations(configs []config.Config, log *util.Logger) (sorted []config.Config, err error) {
 	sorted = []config.Config{}
-	incomingDeps, inDegrees := calculateIncomingConfigDependencies(configs)
+	incomingDeps, inDegrees := calculateIncomingConfigDependencies(configs, log)
 	reverse, err, errorOn := topologySort(incomingDeps, inDegrees)
 	if err != nil {
-		util.Log.Debug(err.Error())
-		return sorted, fmt.Errorf("failed to sort configs, circular dependency on config %s detected, please check dependencies", configs[errorOn].GetFullQualifiedId())
+		log.Debug("%s", err)
+		cycle := findCycle(incomingDeps, errorOn)
+		path := formatCycle(cycle, func(i int) string { return configs[i].GetFullQualifiedId() })
+		return sorted, fmt.Errorf("failed to sort configs, circular dependency on config %s detected, please check dependencies: %s", configs[cycle[0]].GetFullQualifiedId(), path)
 	}
 
 	for i := len(reverse) - 1; i >= 0; i-- {
 		sorted = append(sorted, configs[reverse[i]])
-		util.Log.Debug("\t\t%s", configs[reverse[i]].GetFullQualifiedId())
+		log.Debug("\t\t%s", configs[reverse[i]].GetFullQualifiedId())
 	}
 	return sorted, nil
 }
 
-func calculateIncomingConfigDependencies(configs []config.Config) (adjacencyMatrix [][]bool, inDegrees []int) {
+func calculateIncomingConfigDependencies(configs []config.Config, log *util.Logger) (adjacencyMatrix [][]bool, inDegrees []int) {
 	adjacencyMatrix = make([][]bool, len(configs))
 	inDegrees = make([]int, len(configs))
 
@@ -86,7 +91,7 @@ func calculateIncomingConfigDependencies(configs []config.Config) (adjacencyMatr
 			if i != j {
 				c2 := configs[j]
 				if c2.HasDependencyOn(c1) {
-					util.Log.Debug("\t\t%s has dep on %s", c2.GetFullQualifiedId(), c1.GetFullQualifiedId())
+					log.Debug("\t\t%s has dep on %s", c2.GetFullQualifiedId(), c1.GetFullQualifiedId())
 					adjacencyMatrix[i][j] = true
 					inDegrees[i]++
 				}
@@ -135,3 +140,50 @@ func getAllLeaves(inDegrees []int) []int {
 	}
 	return nodes
 }
+
+// findCycle returns a circular dependency of the node, which could not be sorted by topologySort. The returned path
+// starts and ends with the same node, and each node depends on its successor.
+//
+// topologySort removes the edges of all sorted nodes, so every remaining edge connects two unsorted nodes. As each
+// unsorted node still has a dependent, following the dependents from any unsorted node eventually leads to a cycle.
+func findCycle(incomingEdges [][]bool, start int) []int {
+	visitedAt := make(map[int]int)
+	var path []int
+
+	cur := start
+	for {
+		if pos, visited := visitedAt[cur]; visited {
+			path = append(path[pos:], cur)
+			break
+		}
+		visitedAt[cur] = len(path)
+		path = append(path, cur)
+
+		next := -1
+		for i := range incomingEdges[cur] {
+			if incomingEdges[cur][i] {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			// can't happen for unsorted nodes, return the path found so far
+			break
+		}
+		cur = next
+	}
+
+	// the path follows the dependents, reverse it to follow the dependencies
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func formatCycle(cycle []int, name func(int) string) string {
+	names := make([]string, len(cycle))
+	for i, node := range cycle {
+		names[i] = name(node)
+	}
+	return strings.Join(names, " -> ")
+}
@@ -58,7 +58,7 @@ func TestSortingByConfigDependencyWithRootDirectory(t *testing.T) {
 
 	configs := []config.Config{configB, configA} // reverse ordering
 
-	configs, err := sortConfigurations(configs)
+	configs, err := sortConfigurations(configs, util.DefaultLogger())
 	assert.NilError(t, err)
 
 	assert.Equal(t, configA, configs[0])
@@ -77,13 +77,45 @@ func TestFailsOnCircularConfigDependency(t *testing.T) {
 
 	configs := []config.Config{configB, configA} // reverse ordering
 
-	configs, err := sortConfigurations(configs)
-	assert.Error(t, err, "failed to sort configs, circular dependency on config "+pathB+"profile detected, please check dependencies")
+	configs, err := sortConfigurations(configs, util.DefaultLogger())
+	assert.Error(t, err, "failed to sort configs, circular dependency on config "+pathB+"profile detected, please check dependencies: "+
+		pathB+"profile -> "+pathA+"zone-a -> "+pathB+"profile")
 
 	assert.Check(t, configA.HasDependencyOn(configB))
 	assert.Check(t, configB.HasDependencyOn(configA))
 }
 
+func TestFailsOnTransitiveCircularConfigDependencyWithCyclePath(t *testing.T) {
+
+	pathA := util.ReplacePathSeparators("projects/infrastructure/management-zone/")
+	pathB := util.ReplacePathSeparators("projects/infrastructure/alerting-profile/")
+	pathC := util.ReplacePathSeparators("projects/infrastructure/notification/")
+	configA := createTestConfig("zone", pathA, pathC+"notification.id")
+	configB := createTestConfig("profile", pathB, pathA+"zone.id")
+	configC := createTestConfig("notification", pathC, pathB+"profile.id")
+	// independent of the cycle, but depending on a config in it
+	configD := createTestConfig("dashboard", pathA, pathB+"profile.id")
+
+	configs := []config.Config{configD, configA, configB, configC}
+
+	_, err := sortConfigurations(configs, util.DefaultLogger())
+	assert.Error(t, err, "failed to sort configs, circular dependency on config "+pathA+"zone detected, please check dependencies: "+
+		pathA+"zone -> "+pathC+"notification -> "+pathB+"profile -> "+pathA+"zone")
+}
+
+func TestFindCycle(t *testing.T) {
+
+	// 0 <- 1 <- 2 <- 1 (1 and 2 depend on each other, 0 is depended on by 1), incomingEdges[i][j]: j depends on i
+	incomingEdges := [][]bool{
+		{false, true, false},
+		{false, false, true},
+		{false, true, false},
+	}
+
+	assert.DeepEqual(t, findCycle(incomingEdges, 0), []int{1, 2, 1})
+	assert.DeepEqual(t, findCycle(incomingEdges, 2), []int{2, 1, 2})
+}
+
 func TestSortingByConfigDependencyWithoutRootDirectory(t *testing.T) {
 
 	pathA := util.ReplacePathSeparators("infrastructure/management-zone/")
@@ -93,7 +125,7 @@ func TestSortingByConfigDependencyWithoutRootDirectory(t *testing.T) {
 
 	configs := []config.Config{configB, configA} // reverse ordering
 
-	configs, err := sortConfigurations(configs)
+	configs, err := sortConfigurations(configs, util.DefaultLogger())
 	assert.NilError(t, err)
 	assert.Equal(t, configA, configs[0])
 	assert.Equal(t, configB, configs[1])
@@ -111,7 +143,7 @@ func TestSortingByConfigDependencyWithRelativePath(t *testing.T) {
 
 	configs := []config.Config{configB, configA} // reverse ordering
 
-	configs, err := sortConfigurations(configs)
+	configs, err := sortConfigurations(configs, util.DefaultLogger())
 	assert.NilError(t, err)
 	assert.Equal(t, configA, configs[0])
 	assert.Equal(t, configB, configs[1])
@@ -147,9 +179,9 @@ func TestFailsOnCircularProjectDependency(t *testing.T) {
 	assert.Check(t, projectB.HasDependencyOn(projectA))
 
 	// sort.Sort(byProjectDependency(projects))
-	projects, err := sortProjects(projects)
+	projects, err := sortProjects(projects, util.DefaultLogger())
 
-	assert.Error(t, err, "failed to sort projects, circular dependency on project B detected, please check dependencies in project configs")
+	assert.Error(t, err, "failed to sort projects, circular dependency on project B detected, please check dependencies in project configs: B -> A -> B")
 }
 
 func TestSortingByProjectDependency_1(t *testing.T) {
@@ -179,7 +211,7 @@ func TestSortingByProjectDependency_1(t *testing.T) {
 	assert.Check(t, projectB.HasDependencyOn(projectA))
 
 	// sort.Sort(byProjectDependency(projects))
-	projects, err := sortProjects(projects)
+	projects, err := sortProjects(projects, util.DefaultLogger())
 	assert.NilError(t, err)
 	// After sort we expect {A, B}
 	assert.Equal(t, projectA, projects[0])
@@ -222,7 +254,7 @@ func TestSortingByProjectDependency_2(t *testing.T) {
 	assert.Check(t, !projectX.HasDependencyOn(projectB))
 
 	// sort.Sort(byProjectDependency(projects))
-	projects, err := sortProjects(projects)
+	projects, err := sortProjects(projects, util.DefaultLogger())
 	assert.NilError(t, err)
 	// After sort we expect {X, A, B}
 	assert.Equal(t, projectX, projects[0])
@@ -281,7 +313,7 @@ func TestSortingByProjectDependency_3(t *testing.T) {
 	assert.Check(t, !projectY.HasDependencyOn(projectX))
 
 	// sort.Stable(byProjectDependency(projects))
-	projects, err := sortProjects(projects)
+	projects, err := sortProjects(projects, util.DefaultLogger())
 
 	for i := 0; i < len(projects); i++ {
 		println(projects[i].GetId())
@@ -328,7 +360,7 @@ func TestSortingByProjectDependency_4(t *testing.T) {
 	assert.Check(t, !projectX.HasDependencyOn(projectB))
 
 	// sort.Sort(byProjectDependency(projects))
-	projects, err := sortProjects(projects)
+	projects, err := sortProjects(projects, util.DefaultLogger())
 	assert.NilError(t, err)
 	// After sort we expect {X, A, B}
 	assert.Equal(t, projectX, projects[0])
@@ -387,7 +419,7 @@ func TestSortingByProjectDependency_5(t *testing.T) {
 	assert.Check(t, !projectY.HasDependencyOn(projectB))
 	assert.Check(t, !projectY.HasDependencyOn(projectX))
 
-	projects, err := sortProjects(projects)
+	projects, err := sortProjects(projects, util.DefaultLogger())
 
 	for i := 0; i < len(projects); i++ {
 		println(projects[i].GetId())
@@ -0,0 +1,55 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	. "github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+)
+
+// CheckAccess sends a single GET request to the list endpoint of the api, to verify that the environment is reachable
+// and accepts the token. The returned error contains a hint on how to fix the problem.
+func CheckAccess(environmentUrl string, token string, theApi Api) error {
+	return checkAccess(newHttpClient(), environmentUrl, token, theApi)
+}
+
+func checkAccess(client *http.Client, environmentUrl string, token string, theApi Api) error {
+	fullUrl := addQueryParamsForNonStandardApis(theApi, theApi.GetUrlFromEnvironmentUrl(environmentUrl))
+
+	resp, err := get(client, fullUrl, token)
+
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Errorf("could not resolve host %s, check that the environment url %s is correct", dnsErr.Name, environmentUrl)
+	case err != nil:
+		return fmt.Errorf("environment url %s is not reachable: %w", environmentUrl, err)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return newApiError(resp, "HTTP 401 on GET %s: the token was rejected, check that it is valid and not expired", fullUrl)
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("GET %s: %w", fullUrl, newMissingScopeError(theApi, resp))
+	case resp.StatusCode == http.StatusNotFound:
+		return newApiError(resp, "HTTP 404 on GET %s: check that the environment url %s points to a Dynatrace environment", fullUrl, environmentUrl)
+	case !success(resp):
+		return newApiError(resp, "HTTP %d on GET %s: %s", resp.StatusCode, fullUrl, string(resp.Body))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"gotest.tools/assert"
+)
+
+var testProfileApi = api.NewStandardApi("alerting-profile", "/api/config/v1/alertingProfiles")
+
+func respondWithStatus(t *testing.T, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, req.Method, http.MethodGet)
+		assert.Equal(t, req.URL.Path, "/api/config/v1/alertingProfiles")
+		assert.Equal(t, req.Header.Get("Authorization"), "Api-Token token")
+		rw.WriteHeader(status)
+	}))
+}
+
+func TestCheckAccessSucceeds(t *testing.T) {
+	server := respondWithStatus(t, http.StatusOK)
+	defer server.Close()
+
+	err := checkAccess(server.Client(), server.URL, "token", testProfileApi)
+	assert.NilError(t, err)
+}
+
+func TestCheckAccessHintsAtInvalidToken(t *testing.T) {
+	server := respondWithStatus(t, http.StatusUnauthorized)
+	defer server.Close()
+
+	err := checkAccess(server.Client(), server.URL, "token", testProfileApi)
+	assert.ErrorContains(t, err, "HTTP 401 on GET "+server.URL+"/api/config/v1/alertingProfiles: the token was rejected")
+}
+
+func TestCheckAccessHintsAtMissingScope(t *testing.T) {
+	server := respondWithStatus(t, http.StatusForbidden)
+	defer server.Close()
+
+	err := checkAccess(server.Client(), server.URL, "token", testProfileApi)
+	assert.ErrorContains(t, err, "GET "+server.URL+"/api/config/v1/alertingProfiles: access to alerting-profile denied (HTTP 403), "+
+		"the token is likely missing one of the scopes ReadConfig (Read configuration), WriteConfig (Write configuration)")
+}
+
+func TestCheckAccessHintsAtWrongUrl(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(context.Context, string, string) (net.Conn, error) {
+			return nil, &net.DNSError{Err: "no such host", Name: "unknown.live.dynatrace.com", IsNotFound: true}
+		},
+	}}
+
+	err := checkAccess(client, "https://unknown.live.dynatrace.com", "token", testProfileApi)
+	assert.Error(t, err, "could not resolve host unknown.live.dynatrace.com, check that the environment url https://unknown.live.dynatrace.com is correct")
+}
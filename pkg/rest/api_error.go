@@ -0,0 +1,99 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrClientError is the cause of api errors of responses with a 4xx status code, e.g. an invalid payload or a
+	// rejected token
+	ErrClientError = errors.New("request rejected by the Dynatrace API")
+
+	// ErrServerError is the cause of api errors of responses with a 5xx status code, which might succeed when retried
+	ErrServerError = errors.New("request failed in the Dynatrace API")
+)
+
+// ApiError is returned if the Dynatrace API responds to a request with an unexpected status code. Callers can tell
+// e.g. an object which doesn't exist (HTTP 404) from a rejected token (HTTP 401) using errors.As.
+type ApiError struct {
+	StatusCode int
+	Body       []byte
+	Url        string
+	Method     string
+
+	// message describes the failed request, e.g. naming the config it was sent for
+	message string
+}
+
+// newApiError creates the error for the response, using the formatted message as description
+func newApiError(resp Response, format string, args ...interface{}) *ApiError {
+	return &ApiError{
+		StatusCode: resp.StatusCode,
+		Body:       resp.Body,
+		Url:        resp.url,
+		Method:     resp.method,
+		message:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *ApiError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the sentinel of the status class of the response, so errors.Is(err, ErrServerError) can tell failures
+// of the environment from rejected requests. Responses of other status codes, e.g. unexpected redirects, have no cause.
+func (e *ApiError) Unwrap() error {
+	switch {
+	case e.StatusCode >= 400 && e.StatusCode < 500:
+		return ErrClientError
+	case e.StatusCode >= 500 && e.StatusCode < 600:
+		return ErrServerError
+	default:
+		return nil
+	}
+}
+
+// NetworkError is returned if a request didn't receive a response from the Dynatrace API, e.g. because the host
+// couldn't be resolved, the connection was refused or the request timed out. It wraps the error of the transport.
+type NetworkError struct {
+	Url    string
+	Method string
+	Err    error
+
+	// timeout is the timeout of the http client, which is named by the message of timed out requests
+	timeout time.Duration
+}
+
+func (e *NetworkError) Error() string {
+	if e.Timeout() {
+		return fmt.Sprintf("%s %s timed out after %s. If the environment is reachable but slow to respond, increase the timeout using --http-timeout or MONACO_HTTP_TIMEOUT", e.Method, e.Url, e.timeout)
+	}
+	return fmt.Sprintf("%s %s failed: %s", e.Method, e.Url, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout returns whether the request timed out
+func (e *NetworkError) Timeout() bool {
+	return isTimeout(e.Err)
+}
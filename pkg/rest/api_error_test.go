@@ -0,0 +1,161 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"gotest.tools/assert"
+)
+
+// respondWith returns a handler responding to all requests with the given status code and body
+func respondWith(statusCode int, body string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(statusCode)
+		_, _ = rw.Write([]byte(body))
+	})
+}
+
+func TestFailedRequestsReturnApiErrorWithStatusCode(t *testing.T) {
+	dashboards := api.NewStandardApi("dashboard", "/api/config/v1/dashboards")
+
+	for _, statusCode := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError} {
+		client, closeServer := newSettingsTestClient(respondWith(statusCode, `{"error": "failed"}`))
+
+		_, err := client.ReadById(dashboards, "id")
+
+		var apiErr *ApiError
+		assert.Assert(t, errors.As(err, &apiErr), statusCode)
+		assert.Equal(t, apiErr.StatusCode, statusCode)
+		assert.Equal(t, string(apiErr.Body), `{"error": "failed"}`)
+		assert.Equal(t, apiErr.Method, http.MethodGet)
+		assert.Equal(t, apiErr.Url, client.environmentUrl+"/api/config/v1/dashboards/id")
+
+		var networkErr *NetworkError
+		assert.Assert(t, !errors.As(err, &networkErr), statusCode)
+
+		closeServer()
+	}
+}
+
+func TestApiErrorWrapsSentinelOfStatusClass(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		cause      error
+	}{
+		{http.StatusBadRequest, ErrClientError},
+		{http.StatusNotFound, ErrClientError},
+		{http.StatusInternalServerError, ErrServerError},
+		{http.StatusServiceUnavailable, ErrServerError},
+		{http.StatusFound, nil},
+	}
+
+	for _, test := range tests {
+		err := error(&ApiError{StatusCode: test.statusCode, message: "failed"})
+
+		assert.Equal(t, errors.Unwrap(err), test.cause, test.statusCode)
+		assert.Equal(t, errors.Is(err, ErrClientError), test.cause == ErrClientError, test.statusCode)
+		assert.Equal(t, errors.Is(err, ErrServerError), test.cause == ErrServerError, test.statusCode)
+	}
+}
+
+func TestFailedRequestReturnsApiErrorWrappingStatusClass(t *testing.T) {
+	client, closeServer := newSettingsTestClient(respondWith(http.StatusNotFound, `not found`))
+	defer closeServer()
+
+	_, err := client.ReadById(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "id")
+
+	assert.Assert(t, errors.Is(err, ErrClientError))
+	assert.Assert(t, !errors.Is(err, ErrServerError))
+}
+
+func TestApiErrorKeepsMessageOfFailedRequest(t *testing.T) {
+	client, closeServer := newSettingsTestClient(respondWith(http.StatusBadRequest, `invalid`))
+	defer closeServer()
+
+	err := client.DeleteByName(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "id")
+
+	var apiErr *ApiError
+	assert.Assert(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.StatusCode, http.StatusBadRequest)
+	assert.ErrorContains(t, err, "(HTTP 400)")
+	assert.ErrorContains(t, err, "invalid")
+}
+
+func TestDeniedAccessReturnsMissingScopeErrorWrappingApiError(t *testing.T) {
+	client, closeServer := newSettingsTestClient(respondWith(http.StatusForbidden, `denied`))
+	defer closeServer()
+
+	_, err := client.ReadById(api.NewStandardApi("slo", "/api/v2/slo"), "id")
+
+	var scopeErr *MissingScopeError
+	assert.Assert(t, errors.As(err, &scopeErr))
+	assert.Equal(t, scopeErr.Api, "slo")
+
+	var apiErr *ApiError
+	assert.Assert(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.StatusCode, http.StatusForbidden)
+	assert.Equal(t, string(apiErr.Body), `denied`)
+	assert.Equal(t, apiErr.Error(), scopeErr.Error())
+}
+
+func TestUnreachableEnvironmentReturnsNetworkError(t *testing.T) {
+	server := httptest.NewServer(respondWith(http.StatusOK, ""))
+	url := server.URL
+	server.Close()
+
+	_, err := get(server.Client(), url+"/api/config/v1/dashboards", "token")
+
+	var networkErr *NetworkError
+	assert.Assert(t, errors.As(err, &networkErr))
+	assert.Equal(t, networkErr.Method, http.MethodGet)
+	assert.Equal(t, networkErr.Url, url+"/api/config/v1/dashboards")
+	assert.Assert(t, !networkErr.Timeout())
+
+	var opErr *net.OpError
+	assert.Assert(t, errors.As(err, &opErr))
+
+	var apiErr *ApiError
+	assert.Assert(t, !errors.As(err, &apiErr))
+}
+
+func TestTimedOutRequestReturnsNetworkError(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+
+	_, err := get(client, server.URL, "token")
+
+	var networkErr *NetworkError
+	assert.Assert(t, errors.As(err, &networkErr))
+	assert.Assert(t, networkErr.Timeout())
+}
@@ -0,0 +1,183 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	. "github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+)
+
+// automationPageSize is the number of objects requested per page when listing the objects of an automation API
+const automationPageSize = 100
+
+type automationObjectsResponse struct {
+	Count   int               `json:"count"`
+	Results []json.RawMessage `json:"results"`
+}
+
+type automationObjectHeader struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// automationApiError is returned by the generic methods of the client, which identify configs by their name
+func automationApiError(api Api) error {
+	return fmt.Errorf("API %s is an automation API: its objects are identified by the id derived from their config instead of a name", api.GetId())
+}
+
+func (d *dynatraceClientImpl) ListAutomations(api Api) (objects []DownloadedAutomationObject, err error) {
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return nil, err
+	}
+
+	fullUrl := api.GetUrlFromEnvironmentUrl(baseUrl)
+
+	for {
+		query := url.Values{"offset": {strconv.Itoa(len(objects))}, "limit": {strconv.Itoa(automationPageSize)}}
+		resp, err := get(client, fullUrl+"?"+query.Encode(), token)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusForbidden {
+			return nil, newMissingScopeError(api, resp)
+		}
+
+		if !success(resp) {
+			return nil, newApiError(resp, "Failed to get objects of api %s (HTTP %d)!\n    Response was: %s", api.GetId(), resp.StatusCode, string(resp.Body))
+		}
+
+		var page automationObjectsResponse
+		if err := json.Unmarshal(resp.Body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse objects of api %s: %w", api.GetId(), err)
+		}
+
+		for _, result := range page.Results {
+			var header automationObjectHeader
+			if err := json.Unmarshal(result, &header); err != nil {
+				return nil, fmt.Errorf("failed to parse object of api %s: %w", api.GetId(), err)
+			}
+			objects = append(objects, DownloadedAutomationObject{Id: header.Id, Title: header.Title, Payload: result})
+		}
+
+		// an empty page ends the list, even if the count claims more objects
+		if len(page.Results) == 0 || len(objects) >= page.Count {
+			return objects, nil
+		}
+	}
+}
+
+func (d *dynatraceClientImpl) ExistsAutomation(api Api, id string) (exists bool, err error) {
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := get(client, api.GetUrlFromEnvironmentUrl(baseUrl)+"/"+url.PathEscape(id), token)
+	if err != nil {
+		return false, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		return false, newMissingScopeError(api, resp)
+	}
+
+	if !success(resp) {
+		return false, newApiError(resp, "Failed to get object %s of api %s (HTTP %d)!\n    Response was: %s", id, api.GetId(), resp.StatusCode, string(resp.Body))
+	}
+	return true, nil
+}
+
+func (d *dynatraceClientImpl) UpsertAutomation(api Api, id string, name string, payload []byte) (entity DynatraceEntity, err error) {
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return DynatraceEntity{}, err
+	}
+
+	fullUrl := api.GetUrlFromEnvironmentUrl(baseUrl)
+
+	resp, err := put(client, fullUrl+"/"+url.PathEscape(id), payload, token)
+	if err != nil {
+		return DynatraceEntity{}, err
+	}
+
+	if resp.StatusCode != http.StatusNotFound {
+		if err := checkAutomationResponse(api, resp, "update", name); err != nil {
+			return DynatraceEntity{}, err
+		}
+		return DynatraceEntity{Id: id, Name: name, Response: resp.Body}, nil
+	}
+
+	// the object doesn't exist yet and is created with its id, so later deployments update it
+	withId, err := automationPayloadWithId(payload, id)
+	if err != nil {
+		return DynatraceEntity{}, fmt.Errorf("invalid payload of %s: %w", name, err)
+	}
+
+	resp, err = post(client, fullUrl, withId, token)
+	if err != nil {
+		return DynatraceEntity{}, err
+	}
+	if err := checkAutomationResponse(api, resp, "create", name); err != nil {
+		return DynatraceEntity{}, err
+	}
+	return DynatraceEntity{Id: id, Name: name, Created: true, Response: resp.Body}, nil
+}
+
+func checkAutomationResponse(api Api, resp Response, action string, name string) error {
+	if resp.StatusCode == http.StatusForbidden {
+		return newMissingScopeError(api, resp)
+	}
+
+	if !success(resp) {
+		return newApiError(resp, "Failed to %s %s of api %s (HTTP %d)!\n    Response was: %s", action, name, api.GetId(), resp.StatusCode, string(resp.Body))
+	}
+	return nil
+}
+
+// automationPayloadWithId returns the payload with its id set to the given id, replacing an id already contained
+func automationPayloadWithId(payload []byte, id string) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &object); err != nil {
+		return nil, err
+	}
+
+	encodedId, err := json.Marshal(id)
+	if err != nil {
+		return nil, err
+	}
+	object["id"] = encodedId
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(object); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buffer.Bytes(), "\n"), nil
+}
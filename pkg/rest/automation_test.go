@@ -0,0 +1,143 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"gotest.tools/assert"
+)
+
+func TestUpsertAutomationCreatesObjectWithIdAndUpdatesIt(t *testing.T) {
+	server := NewAutomationServer()
+	client, closeServer := NewAutomationTestClient(server)
+	defer closeServer()
+
+	workflows := api.NewApis()["workflow"]
+
+	entity, err := client.UpsertAutomation(workflows, "3f1b0e5c-0000-5000-8000-000000000001", "My workflow", []byte(`{"title": "My workflow", "tasks": {}}`))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "3f1b0e5c-0000-5000-8000-000000000001")
+	assert.Equal(t, entity.Name, "My workflow")
+	assert.Assert(t, entity.Created)
+
+	entity, err = client.UpsertAutomation(workflows, "3f1b0e5c-0000-5000-8000-000000000001", "My workflow", []byte(`{"title": "Renamed workflow", "tasks": {}}`))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "3f1b0e5c-0000-5000-8000-000000000001")
+	assert.Assert(t, !entity.Created)
+
+	objects := server.Objects("/platform/automation/v1/workflows")
+	assert.Equal(t, len(objects), 1)
+	assert.Equal(t, objects[0]["id"], "3f1b0e5c-0000-5000-8000-000000000001")
+	assert.Equal(t, objects[0]["title"], "Renamed workflow")
+
+	assert.DeepEqual(t, server.Requests(), []string{
+		"PUT /platform/automation/v1/workflows/3f1b0e5c-0000-5000-8000-000000000001",
+		"POST /platform/automation/v1/workflows",
+		"PUT /platform/automation/v1/workflows/3f1b0e5c-0000-5000-8000-000000000001",
+	})
+}
+
+func TestExistsAutomationChecksObjectById(t *testing.T) {
+	server := NewAutomationServer()
+	server.Add("/platform/automation/v1/scheduling-rules", `{"id": "rule-id", "title": "Weekdays", "ruleType": "recurrence"}`)
+	client, closeServer := NewAutomationTestClient(server)
+	defer closeServer()
+
+	rules := api.NewApis()["scheduling-rule"]
+
+	exists, err := client.ExistsAutomation(rules, "rule-id")
+	assert.NilError(t, err)
+	assert.Assert(t, exists)
+
+	exists, err = client.ExistsAutomation(rules, "other-id")
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+}
+
+func TestListAutomationsReadsAllPages(t *testing.T) {
+	server := NewAutomationServer()
+	server.Add("/platform/automation/v1/scheduling-rules", `{"id": "c", "title": "Weekends", "ruleType": "recurrence"}`)
+	server.Add("/platform/automation/v1/scheduling-rules", `{"id": "a", "title": "Weekdays", "ruleType": "recurrence"}`)
+	server.Add("/platform/automation/v1/scheduling-rules", `{"id": "b", "title": "Holidays", "ruleType": "fixed_offset"}`)
+	server.Add("/platform/automation/v1/workflows", `{"id": "w", "title": "Workflow"}`)
+	client, closeServer := NewAutomationTestClient(server)
+	defer closeServer()
+
+	objects, err := client.ListAutomations(api.NewApis()["scheduling-rule"])
+	assert.NilError(t, err)
+	assert.Equal(t, len(objects), 3)
+	assert.Equal(t, objects[0].Id, "a")
+	assert.Equal(t, objects[0].Title, "Weekdays")
+	assert.Equal(t, objects[2].Id, "c")
+	assert.Equal(t, string(objects[2].Payload), `{"id":"c","ruleType":"recurrence","title":"Weekends"}`)
+
+	assert.DeepEqual(t, server.Requests(), []string{
+		"GET /platform/automation/v1/scheduling-rules",
+		"GET /platform/automation/v1/scheduling-rules",
+	})
+}
+
+func TestAutomationRequestsDeniedAccessReturnMissingScopes(t *testing.T) {
+	client, closeServer := newSettingsTestClient(respondWith(http.StatusForbidden, `denied`))
+	defer closeServer()
+	client.platformClient = client.client
+
+	_, err := client.UpsertAutomation(api.NewApis()["workflow"], "id", "My workflow", []byte(`{"title": "My workflow"}`))
+
+	var scopeErr *MissingScopeError
+	assert.Assert(t, errors.As(err, &scopeErr))
+	assert.ErrorContains(t, err, "automation:workflows:read (Read workflows), automation:workflows:write (Write workflows)")
+}
+
+func TestAutomationApisAreNotAccessedByName(t *testing.T) {
+	client, err := NewDynatraceClient("https://my-environment.live.dynatrace.com", "abc")
+	assert.NilError(t, err)
+
+	workflows := api.NewApis()["workflow"]
+
+	_, err = client.List(workflows)
+	assert.ErrorContains(t, err, "API workflow is an automation API")
+
+	_, err = client.UpsertByName(workflows, "My workflow", []byte(`{}`))
+	assert.ErrorContains(t, err, "API workflow is an automation API")
+
+	err = client.DeleteByName(workflows, "My workflow")
+	assert.ErrorContains(t, err, "API workflow is an automation API")
+}
+
+func TestAutomationApisRequireOAuthCredentials(t *testing.T) {
+	client := &dynatraceClientImpl{environmentUrl: "https://my-environment.live.dynatrace.com", token: "my-api-token", client: &http.Client{}}
+
+	_, err := client.ListAutomations(api.NewApis()["workflow"])
+	assert.ErrorContains(t, err, "API workflow requires OAuth authentication")
+}
+
+func TestAutomationPayloadWithIdReplacesId(t *testing.T) {
+	payload, err := automationPayloadWithId([]byte(`{"title": "<My workflow>", "id": "old"}`), "new")
+	assert.NilError(t, err)
+	assert.Equal(t, string(payload), `{"id":"new","title":"<My workflow>"}`)
+
+	_, err = automationPayloadWithId([]byte(`[]`), "new")
+	assert.ErrorContains(t, err, "cannot unmarshal")
+}
@@ -0,0 +1,223 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// automationServerPageSize is the maximum number of objects the AutomationServer lists per page
+const automationServerPageSize = 2
+
+// automationServerTokenPath is the OAuth token endpoint of the AutomationServer
+const automationServerTokenPath = "/sso/oauth2/token"
+
+// AutomationServer is a minimal automation API of the Dynatrace platform, holding the objects of all automation apis
+// in memory by their api path. It issues OAuth tokens and rejects requests not authorized using one. Objects are
+// listed with a page size of two, to cover the pagination.
+type AutomationServer struct {
+	mutex    sync.Mutex
+	objects  map[string][]map[string]interface{}
+	requests []string
+}
+
+// NewAutomationServer creates an AutomationServer without objects
+func NewAutomationServer() *AutomationServer {
+	return &AutomationServer{objects: make(map[string][]map[string]interface{})}
+}
+
+// Add adds the object given as json to the objects of the automation api with the given path, e.g.
+// /platform/automation/v1/workflows
+func (s *AutomationServer) Add(apiPath string, object string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(object), &parsed); err != nil {
+		panic(err)
+	}
+	s.objects[apiPath] = append(s.objects[apiPath], parsed)
+}
+
+// Objects returns the objects of the automation api with the given path, in the order they were created
+func (s *AutomationServer) Objects(apiPath string) []map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]map[string]interface{}{}, s.objects[apiPath]...)
+}
+
+// Requests returns the method and path of all requests to the automation apis
+func (s *AutomationServer) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+func (s *AutomationServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == automationServerTokenPath {
+		_, _ = rw.Write([]byte(`{"access_token": "platform-token", "token_type": "Bearer", "expires_in": 300}`))
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, req.Method+" "+req.URL.Path)
+
+	if req.Header.Get("Authorization") != "Bearer platform-token" {
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	apiPath, id := s.splitPath(req.URL.Path)
+	if apiPath == "" {
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	index := s.indexOf(apiPath, id)
+
+	switch {
+	case req.Method == http.MethodGet && id == "":
+		s.list(rw, req, apiPath)
+
+	case req.Method == http.MethodGet && index >= 0:
+		writeAutomationObject(rw, http.StatusOK, s.objects[apiPath][index])
+
+	case req.Method == http.MethodPost && id == "":
+		object, ok := readAutomationObject(req)
+		if !ok {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if object["id"] == nil {
+			object["id"] = "generated-" + strconv.Itoa(len(s.objects[apiPath])+1)
+		}
+		if s.indexOf(apiPath, object["id"].(string)) >= 0 {
+			rw.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.objects[apiPath] = append(s.objects[apiPath], object)
+		writeAutomationObject(rw, http.StatusCreated, object)
+
+	case req.Method == http.MethodPut && index >= 0:
+		object, ok := readAutomationObject(req)
+		if !ok {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		object["id"] = id
+		s.objects[apiPath][index] = object
+		writeAutomationObject(rw, http.StatusOK, object)
+
+	default:
+		rw.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// splitPath returns the api path and the id of the object a request is sent to. The api path is empty, if the
+// request is not sent to an automation api.
+func (s *AutomationServer) splitPath(path string) (apiPath string, id string) {
+	for _, candidate := range []string{"/platform/automation/v1/workflows", "/platform/automation/v1/scheduling-rules"} {
+		if path == candidate {
+			return candidate, ""
+		}
+		if strings.HasPrefix(path, candidate+"/") {
+			return candidate, strings.TrimPrefix(path, candidate+"/")
+		}
+	}
+	return "", ""
+}
+
+func (s *AutomationServer) indexOf(apiPath string, id string) int {
+	for i, object := range s.objects[apiPath] {
+		if id != "" && object["id"] == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *AutomationServer) list(rw http.ResponseWriter, req *http.Request, apiPath string) {
+	offset, err := strconv.Atoi(req.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(req.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > automationServerPageSize {
+		limit = automationServerPageSize
+	}
+
+	// objects are listed by id, to return a stable order independent of the order of creation
+	objects := append([]map[string]interface{}{}, s.objects[apiPath]...)
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i]["id"].(string) < objects[j]["id"].(string)
+	})
+
+	results := make([]map[string]interface{}, 0, limit)
+	for i := offset; i < len(objects) && i < offset+limit; i++ {
+		results = append(results, objects[i])
+	}
+
+	content, _ := json.Marshal(map[string]interface{}{"count": len(objects), "results": results})
+	_, _ = rw.Write(content)
+}
+
+func readAutomationObject(req *http.Request) (map[string]interface{}, bool) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, false
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(body, &object); err != nil || object["title"] == nil {
+		return nil, false
+	}
+	return object, true
+}
+
+func writeAutomationObject(rw http.ResponseWriter, statusCode int, object map[string]interface{}) {
+	content, _ := json.Marshal(object)
+	rw.WriteHeader(statusCode)
+	_, _ = rw.Write(content)
+}
+
+// NewAutomationTestClient returns a client sending its requests to the automation server, authenticating platform
+// APIs using OAuth tokens issued by the server, and a function closing the server
+func NewAutomationTestClient(server *AutomationServer) (DynatraceClient, func()) {
+	testServer := httptest.NewTLSServer(server)
+
+	credentials := oauthCredentials{clientId: "client", clientSecret: "secret", tokenUrl: testServer.URL + automationServerTokenPath}
+
+	return &dynatraceClientImpl{
+		environmentUrl: testServer.URL,
+		token:          "token",
+		client:         testServer.Client(),
+		platformClient: newPlatformHttpClient(credentials, testServer.Client()),
+	}, testServer.Close
+}
@@ -18,9 +18,11 @@ package rest
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 
@@ -63,6 +65,12 @@ type DynatraceClient interface {
 	//    PUT <environment-url>/api/config/v1/alertingProfiles/<id> ... instead of POST, if the config is already available
 	UpsertByName(a Api, name string, payload []byte) (entity DynatraceEntity, err error)
 
+	// UpsertById updates the Dynatrace config with the given id. If it doesn't exist anymore, it is upserted using its
+	// name instead. It calls the underlying GET and PUT endpoints for the API. E.g. for alerting profiles this would be:
+	//    GET <environment-url>/api/config/v1/alertingProfiles/<id> ... to check if the config is still available
+	//    PUT <environment-url>/api/config/v1/alertingProfiles/<id> ... afterwards, if the config is still available
+	UpsertById(a Api, id string, name string, payload []byte) (entity DynatraceEntity, err error)
+
 	// Delete removed a given config for a given API using its name.
 	// It calls the underlying GET and DELETE endpoints for the API. E.g. for alerting profiles this would be:
 	//    GET <environment-url>/api/config/v1/alertingProfiles ... to get the id of the existing config
@@ -73,16 +81,111 @@ type DynatraceClient interface {
 	// It cally the underlying GET endpoint for the API. E.g. for alerting profiles this would be:
 	//    GET <environment-url>/api/config/v1/alertingProfiles
 	ExistsByName(a Api, name string) (exists bool, id string, err error)
+
+	// ListSchemas lists the ids of the Settings 2.0 schemas available for the given settings API.
+	// It calls the underlying GET endpoint:
+	//    GET <environment-url>/api/v2/settings/schemas
+	ListSchemas(a Api) (schemaIds []string, err error)
+
+	// ListSettings lists the Settings 2.0 objects of the given schema in all scopes, including their values.
+	// It calls the underlying GET endpoint of the settings API:
+	//    GET <environment-url>/api/v2/settings/objects?schemaIds=<schemaId> ... on all pages of the result
+	ListSettings(a Api, schemaId string) (objects []DownloadedSettingsObject, err error)
+
+	// ExistsSettings checks if a Settings 2.0 object with the external id of the given object exists in its schema
+	// and scope. It calls the underlying GET endpoint of the settings API:
+	//    GET <environment-url>/api/v2/settings/objects?schemaIds=<schemaId>&scopes=<scope>&externalIds=<externalId>
+	ExistsSettings(a Api, object SettingsObject) (exists bool, objectId string, err error)
+
+	// UpsertSettings creates the Settings 2.0 object if no object with its external id exists in its schema and scope,
+	// and updates the existing object otherwise. It calls the underlying GET, POST and PUT endpoints:
+	//    GET <environment-url>/api/v2/settings/objects?schemaIds=<schemaId>&scopes=<scope>&externalIds=<externalId> ... to find the existing object
+	//    POST <environment-url>/api/v2/settings/objects ... afterwards, if the object is not yet available
+	//    PUT <environment-url>/api/v2/settings/objects/<objectId> ... instead of POST, if the object is already available
+	UpsertSettings(a Api, object SettingsObject) (entity DynatraceEntity, err error)
+
+	// ListAutomations lists the objects of the given automation API, including their payload.
+	// It calls the underlying GET endpoint of the API. E.g. for workflows this would be:
+	//    GET <environment-url>/platform/automation/v1/workflows?offset=<offset> ... on all pages of the result
+	ListAutomations(a Api) (objects []DownloadedAutomationObject, err error)
+
+	// ExistsAutomation checks if the object with the given id exists in the given automation API.
+	// It calls the underlying GET endpoint of the API. E.g. for workflows this would be:
+	//    GET <environment-url>/platform/automation/v1/workflows/<id>
+	ExistsAutomation(a Api, id string) (exists bool, err error)
+
+	// UpsertAutomation updates the object with the given id of the given automation API, and creates it using this id
+	// if it doesn't exist yet. It calls the underlying PUT and POST endpoints. E.g. for workflows this would be:
+	//    PUT <environment-url>/platform/automation/v1/workflows/<id> ... to update the existing object
+	//    POST <environment-url>/platform/automation/v1/workflows ... afterwards, if the object is not yet available
+	UpsertAutomation(a Api, id string, name string, payload []byte) (entity DynatraceEntity, err error)
+}
+
+// DefaultHttpTimeout is the default timeout of a single HTTP request, including sending the payload and reading
+// the response
+const DefaultHttpTimeout = 60 * time.Second
+
+var httpTimeout = DefaultHttpTimeout
+
+// SetHttpTimeout sets the timeout of HTTP requests sent by clients created afterwards. A value of 0 disables the
+// timeout.
+func SetHttpTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("invalid http timeout '%s': must be a positive duration, or 0 to disable the timeout", timeout)
+	}
+
+	httpTimeout = timeout
+	return nil
+}
+
+// NewUnloggedHttpClient returns an http client using the proxy, CA certificates and timeout configured for Dynatrace
+// clients, which doesn't log its requests. It is used for requests to secret backends, which carry credentials.
+func NewUnloggedHttpClient() *http.Client {
+	return unloggedHttpClient(newHttpClient())
+}
+
+func newHttpClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc()
+	transport.TLSClientConfig = tlsConfig()
+
+	// requests are logged to the default logger, unless another logger is set using WithLogger
+	return &http.Client{
+		Transport: &loggingTransport{base: transport},
+		Timeout:   httpTimeout,
+	}
 }
 
 type dynatraceClientImpl struct {
 	environmentUrl string
 	token          string
 	client         *http.Client
+
+	// platformClient is used for platform APIs and authenticates using OAuth. It is nil, if no OAuth client
+	// credentials are configured.
+	platformClient *http.Client
+
+	// clusterUrl and clusterToken are used for cluster APIs of Dynatrace Managed. They are empty, if no cluster is
+	// configured.
+	clusterUrl   string
+	clusterToken string
 }
 
 // NewDynatraceClient creates a new DynatraceClient
 func NewDynatraceClient(environmentUrl, token string) (DynatraceClient, error) {
+	return NewManagedDynatraceClient(environmentUrl, token, "", "")
+}
+
+// NewManagedDynatraceClient creates a new DynatraceClient for an environment of a Dynatrace Managed cluster. Cluster
+// APIs are sent to the cluster url using the cluster token. If the cluster url is empty, cluster APIs are not available.
+func NewManagedDynatraceClient(environmentUrl, token, clusterUrl, clusterToken string) (DynatraceClient, error) {
+	return NewManagedDynatraceClientWithLogger(environmentUrl, token, clusterUrl, clusterToken, nil)
+}
+
+// NewManagedDynatraceClientWithLogger creates a new DynatraceClient like NewManagedDynatraceClient, which writes its
+// messages, requests and responses to the logger, like a client returned by WithLogger. Warnings about the token are
+// written to the logger as well. A nil logger is the default logger.
+func NewManagedDynatraceClientWithLogger(environmentUrl, token, clusterUrl, clusterToken string, log *util.Logger) (DynatraceClient, error) {
 
 	if environmentUrl == "" {
 		return nil, errors.New("no environment url")
@@ -101,31 +204,153 @@ func NewDynatraceClient(environmentUrl, token string) (DynatraceClient, error) {
 		return nil, errors.New("environment url " + environmentUrl + " was not valid")
 	}
 
+	if clusterUrl != "" {
+		if clusterToken == "" {
+			return nil, errors.New("no cluster token")
+		}
+
+		parsedClusterUrl, err := url.ParseRequestURI(clusterUrl)
+		if err != nil || parsedClusterUrl.Scheme != "https" {
+			return nil, errors.New("cluster url " + clusterUrl + " was not valid")
+		}
+	}
+
 	if !isNewDynatraceTokenFormat(token) {
-		util.Log.Warn("You used an old token format. Please consider switching to the new 1.205+ token format.")
-		util.Log.Warn("More information: https://www.dynatrace.com/support/help/dynatrace-api/basics/dynatrace-api-authentication/#-dynatrace-version-1205--token-format")
+		log.Warn("You used an old token format. Please consider switching to the new 1.205+ token format.")
+		log.Warn("More information: https://www.dynatrace.com/support/help/dynatrace-api/basics/dynatrace-api-authentication/#-dynatrace-version-1205--token-format")
+	}
+
+	var platformClient *http.Client
+	credentials, found, err := readOAuthCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		platformClient = newPlatformHttpClient(credentials, loggingHttpClient(newHttpClient(), log))
 	}
 
 	return &dynatraceClientImpl{
 		environmentUrl: environmentUrl,
 		token:          token,
-		client:         &http.Client{},
+		client:         loggingHttpClient(newHttpClient(), log),
+		platformClient: platformClient,
+		clusterUrl:     strings.TrimRight(clusterUrl, "/"),
+		clusterToken:   clusterToken,
 	}, nil
 }
 
+// WithHttpClient returns a copy of the client sending its requests using the given http client instead of the default
+// one, e.g. to send them to a fake transport in tests. The requests are logged like the ones of the default client, and
+// platform APIs authenticate using OAuth on top of the given client.
+// It must be applied before WithTraceContext, which wraps the transport of the http client. Clients not created by
+// NewDynatraceClient or NewManagedDynatraceClient are returned unchanged.
+func WithHttpClient(client DynatraceClient, httpClient *http.Client) DynatraceClient {
+	impl, ok := client.(*dynatraceClientImpl)
+	if !ok {
+		return client
+	}
+
+	injected := *impl
+	injected.client = loggingHttpClient(httpClient, loggerOf(impl.client))
+	if impl.platformClient != nil {
+		if bearer, ok := impl.platformClient.Transport.(*bearerTokenTransport); ok {
+			injected.platformClient = newPlatformHttpClient(bearer.tokenSource.credentials, httpClient)
+		}
+	}
+	return &injected
+}
+
+// TokenClient is implemented by clients whose requests can be sent using another API token. Clients wrapping another
+// client implement it by wrapping the client using the other token.
+type TokenClient interface {
+
+	// WithToken returns a client sending its requests using the given API token
+	WithToken(token string) DynatraceClient
+}
+
+// WithToken returns a client sending its requests to the environment using the given API token instead of the one it
+// was created with. Cluster APIs keep using the cluster token. The client is returned unchanged, if it can't be used
+// with another token.
+func WithToken(client DynatraceClient, token string) DynatraceClient {
+	if tokenClient, ok := client.(TokenClient); ok {
+		return tokenClient.WithToken(token)
+	}
+	return client
+}
+
+// WithToken returns a copy of the client sending requests to the environment using the given API token
+func (d *dynatraceClientImpl) WithToken(token string) DynatraceClient {
+	withToken := *d
+	withToken.token = token
+	return &withToken
+}
+
+// httpClientFor returns the http client to use for the given API. Classic config APIs are accessed using the API
+// token, while platform APIs require OAuth client credentials.
+func (d *dynatraceClientImpl) httpClientFor(api Api) (*http.Client, error) {
+	if !api.IsPlatformApi() {
+		return d.client, nil
+	}
+
+	if d.platformClient == nil {
+		return nil, fmt.Errorf("API %s requires OAuth authentication: please set MONACO_CLIENT_ID and MONACO_CLIENT_SECRET", api.GetId())
+	}
+
+	return d.platformClient, nil
+}
+
+// endpointFor returns the http client, the base url and the token to use for the given API. Cluster APIs of Dynatrace
+// Managed are sent to the cluster, all other APIs to the environment.
+func (d *dynatraceClientImpl) endpointFor(api Api) (client *http.Client, baseUrl string, token string, err error) {
+	client, err = d.httpClientFor(api)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if !api.IsClusterApi() {
+		return client, d.environmentUrl, d.token, nil
+	}
+
+	if d.clusterUrl == "" {
+		return nil, "", "", fmt.Errorf("API %s is a cluster API of Dynatrace Managed: please define managed-cluster-url and managed-cluster-token-name of the environment", api.GetId())
+	}
+	return client, d.clusterUrl, d.clusterToken, nil
+}
+
 func isNewDynatraceTokenFormat(token string) bool {
 	return strings.HasPrefix(token, "dt0c01.") && strings.Count(token, ".") == 2
 }
 
 func (d *dynatraceClientImpl) List(api Api) (values []Value, err error) {
 
-	fullUrl := api.GetUrlFromEnvironmentUrl(d.environmentUrl)
-	values, err = getExistingValuesFromEndpoint(d.client, api, fullUrl, d.token)
+	if api.IsSettingsApi() {
+		return nil, settingsApiError(api)
+	}
+
+	if api.IsAutomationApi() {
+		return nil, automationApiError(api)
+	}
+
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return nil, err
+	}
+
+	fullUrl := api.GetUrlFromEnvironmentUrl(baseUrl)
+	values, err = getExistingValuesFromEndpoint(client, api, fullUrl, token)
 	return values, err
 }
 
 func (d *dynatraceClientImpl) ReadByName(api Api, name string) (json []byte, err error) {
 
+	if api.IsSettingsApi() {
+		return nil, settingsApiError(api)
+	}
+
+	if api.IsAutomationApi() {
+		return nil, automationApiError(api)
+	}
+
 	exists, id, err := d.ExistsByName(api, name)
 	if err != nil {
 		return nil, err
@@ -139,40 +364,111 @@ func (d *dynatraceClientImpl) ReadByName(api Api, name string) (json []byte, err
 }
 
 func (d *dynatraceClientImpl) ReadById(api Api, id string) (json []byte, err error) {
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return nil, err
+	}
+
 	var url string
 	isSingleConfigurationApi := api.IsSingleConfigurationApi()
 
 	if isSingleConfigurationApi {
-		url = api.GetUrlFromEnvironmentUrl(d.environmentUrl)
+		url = api.GetUrlFromEnvironmentUrl(baseUrl)
 	} else {
-		url = api.GetUrlFromEnvironmentUrl(d.environmentUrl) + "/" + id
+		url = api.GetUrlFromEnvironmentUrl(baseUrl) + "/" + id
 	}
 
-	response, err := get(d.client, url, d.token)
+	response, err := get(client, url, token)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if response.StatusCode == http.StatusForbidden {
+		return nil, newMissingScopeError(api, response)
+	}
+
+	if !success(response) {
+		return nil, newApiError(response, "Failed to read DT object %s of api %s (HTTP %d)!\n    Response was: %s", id, api.GetId(), response.StatusCode, string(response.Body))
+	}
+
 	return response.Body, nil
 }
 
 func (d *dynatraceClientImpl) DeleteByName(api Api, name string) error {
 
-	return deleteDynatraceObject(d.client, api, name, api.GetUrlFromEnvironmentUrl(d.environmentUrl), d.token)
+	if api.IsSettingsApi() {
+		return settingsApiError(api)
+	}
+
+	if api.IsAutomationApi() {
+		return automationApiError(api)
+	}
+
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return err
+	}
+
+	return deleteDynatraceObject(client, api, name, api.GetUrlFromEnvironmentUrl(baseUrl), token)
 }
 
 func (d *dynatraceClientImpl) ExistsByName(api Api, name string) (exists bool, id string, err error) {
 
-	existingObjectId, err := getObjectIdIfAlreadyExists(d.client, api, api.GetUrlFromEnvironmentUrl(d.environmentUrl), name, d.token)
+	if api.IsSettingsApi() {
+		return false, "", settingsApiError(api)
+	}
+
+	if api.IsAutomationApi() {
+		return false, "", automationApiError(api)
+	}
+
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return false, "", err
+	}
+
+	existingObjectId, err := getObjectIdIfAlreadyExists(client, api, api.GetUrlFromEnvironmentUrl(baseUrl), name, token)
 	return existingObjectId != "", existingObjectId, err
 }
 
 func (d *dynatraceClientImpl) UpsertByName(api Api, name string, payload []byte) (entity DynatraceEntity, err error) {
 
+	if api.IsSettingsApi() {
+		return DynatraceEntity{}, settingsApiError(api)
+	}
+
+	if api.IsAutomationApi() {
+		return DynatraceEntity{}, automationApiError(api)
+	}
+
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return DynatraceEntity{}, err
+	}
+
 	if api.GetId() == "extension" {
-		fullUrl := api.GetUrlFromEnvironmentUrl(d.environmentUrl)
-		return uploadExtension(d.client, fullUrl, name, payload, d.token)
+		fullUrl := api.GetUrlFromEnvironmentUrl(baseUrl)
+		return uploadExtension(client, api, fullUrl, name, payload, token)
+	}
+	return upsertDynatraceObject(client, baseUrl, name, api, payload, token)
+}
+
+func (d *dynatraceClientImpl) UpsertById(api Api, id string, name string, payload []byte) (entity DynatraceEntity, err error) {
+
+	if api.IsAutomationApi() {
+		return DynatraceEntity{}, automationApiError(api)
 	}
-	return upsertDynatraceObject(d.client, d.environmentUrl, name, api, payload, d.token)
+
+	// single configuration APIs, extensions and settings are not identified by an id
+	if api.IsSingleConfigurationApi() || api.GetId() == "extension" || api.IsSettingsApi() {
+		return d.UpsertByName(api, name, payload)
+	}
+
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return DynatraceEntity{}, err
+	}
+
+	return upsertDynatraceObjectById(client, baseUrl, id, name, api, payload, token)
 }
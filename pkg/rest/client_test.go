@@ -20,8 +20,14 @@
 package rest
 
 import (
-	"gotest.tools/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"gotest.tools/assert"
 )
 
 func TestNewClientNoUrl(t *testing.T) {
@@ -59,3 +65,114 @@ func TestNewClient(t *testing.T) {
 	assert.NilError(t, err, "not valid")
 	assert.Check(t, client != nil)
 }
+
+func TestNewClientUsesHttpTimeout(t *testing.T) {
+	defer func(original time.Duration) { httpTimeout = original }(httpTimeout)
+
+	assert.NilError(t, SetHttpTimeout(5*time.Second))
+
+	client, err := NewDynatraceClient("https://my-environment.live.dynatrace.com/", "abc")
+	assert.NilError(t, err)
+	assert.Equal(t, client.(*dynatraceClientImpl).client.Timeout, 5*time.Second)
+}
+
+func TestSetHttpTimeoutRejectsNegativeValues(t *testing.T) {
+	defer func(original time.Duration) { httpTimeout = original }(httpTimeout)
+
+	assert.ErrorContains(t, SetHttpTimeout(-1*time.Second), "invalid http timeout")
+	assert.Equal(t, httpTimeout, DefaultHttpTimeout)
+}
+
+func TestNewManagedClientRequiresClusterToken(t *testing.T) {
+	client, err := NewManagedDynatraceClient("https://managed.example.com/e/abc", "abc", "https://managed.example.com", "")
+	assert.ErrorContains(t, err, "no cluster token")
+	assert.Check(t, client == nil)
+}
+
+func TestNewManagedClientNoValidClusterUrl(t *testing.T) {
+	client, err := NewManagedDynatraceClient("https://managed.example.com/e/abc", "abc", "http://managed.example.com", "cluster-token")
+	assert.ErrorContains(t, err, "cluster url http://managed.example.com was not valid")
+	assert.Check(t, client == nil)
+}
+
+func TestClusterApisAreSentToTheCluster(t *testing.T) {
+	type request struct {
+		Method        string
+		Path          string
+		Authorization string
+		Body          string
+	}
+
+	var environmentRequests, clusterRequests []request
+	record := func(requests *[]request) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			*requests = append(*requests, request{req.Method, req.URL.Path, req.Header.Get("Authorization"), string(body)})
+			_, _ = rw.Write([]byte(`{"smtpServerUrl": "smtp.example.com"}`))
+		}
+	}
+
+	environment := httptest.NewTLSServer(record(&environmentRequests))
+	defer environment.Close()
+	cluster := httptest.NewTLSServer(record(&clusterRequests))
+	defer cluster.Close()
+
+	client := &dynatraceClientImpl{
+		environmentUrl: environment.URL + "/e/abc",
+		token:          "environment-token",
+		client:         environment.Client(),
+		clusterUrl:     cluster.URL,
+		clusterToken:   "cluster-token",
+	}
+
+	smtp := api.NewApis()["cluster-smtp"]
+
+	payload, err := client.ReadById(smtp, "cluster-smtp")
+	assert.NilError(t, err)
+	assert.Equal(t, string(payload), `{"smtpServerUrl": "smtp.example.com"}`)
+
+	_, err = client.UpsertByName(smtp, "cluster-smtp", []byte(`{"smtpServerUrl": "mail.example.com"}`))
+	assert.NilError(t, err)
+
+	_, err = client.ReadById(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "id")
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, clusterRequests, []request{
+		{http.MethodGet, "/api/v1.0/onpremise/smtp", "Api-Token cluster-token", ""},
+		{http.MethodPut, "/api/v1.0/onpremise/smtp", "Api-Token cluster-token", `{"smtpServerUrl": "mail.example.com"}`},
+	})
+	assert.DeepEqual(t, environmentRequests, []request{
+		{http.MethodGet, "/e/abc/api/config/v1/dashboards/id", "Api-Token environment-token", ""},
+	})
+}
+
+func TestClusterApisRequireClusterUrl(t *testing.T) {
+	client, err := NewDynatraceClient("https://my-environment.live.dynatrace.com", "abc")
+	assert.NilError(t, err)
+
+	_, err = client.ReadById(api.NewApis()["cluster-smtp"], "cluster-smtp")
+	assert.ErrorContains(t, err, "API cluster-smtp is a cluster API of Dynatrace Managed")
+}
+
+func TestWithTokenSendsRequestsUsingOtherToken(t *testing.T) {
+	var authorizations []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		authorizations = append(authorizations, req.Header.Get("Authorization"))
+		_, _ = rw.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := &dynatraceClientImpl{
+		environmentUrl: server.URL,
+		token:          "default-token",
+		client:         server.Client(),
+	}
+	dashboards := api.NewStandardApi("dashboard", "/api/config/v1/dashboards")
+
+	_, err := WithToken(client, "settings-token").ReadById(dashboards, "id")
+	assert.NilError(t, err)
+	_, err = client.ReadById(dashboards, "id")
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, authorizations, []string{"Api-Token settings-token", "Api-Token default-token"})
+}
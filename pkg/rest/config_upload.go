@@ -54,6 +54,9 @@ func upsertDynatraceObject(
 	body := payload
 	configType := theApi.GetId()
 
+	// Single configuration APIs always exist
+	isNew := existingObjectId == "" && !isSingleConfigurationApi
+
 	// The calculated-metrics-log API doesn't have a POST endpoint, to create a new log metric we need to use PUT which
 	// requires a metric key for which we can just take the objectName
 	if configType == "calculated-metrics-log" && existingObjectId == "" {
@@ -64,11 +67,55 @@ func upsertDynatraceObject(
 
 	// Single configuration APIs don't have a POST, but a PUT endpoint
 	// and therefore always require an update
+	var entity api.DynatraceEntity
+	var err error
+
 	if isUpdate || isSingleConfigurationApi {
-		return updateDynatraceObject(client, fullUrl, objectName, existingObjectId, theApi, body, apiToken)
+		entity, err = updateDynatraceObject(client, fullUrl, objectName, existingObjectId, theApi, body, apiToken)
 	} else {
-		return createDynatraceObject(client, fullUrl, objectName, theApi, body, apiToken)
+		entity, err = createDynatraceObject(client, fullUrl, objectName, theApi, body, apiToken)
 	}
+
+	if err != nil {
+		return api.DynatraceEntity{}, err
+	}
+
+	entity.Created = isNew
+	return entity, nil
+}
+
+// upsertDynatraceObjectById updates the object with the given id. If the object doesn't exist anymore, it is upserted
+// using its name.
+func upsertDynatraceObjectById(
+	client *http.Client,
+	environmentUrl string,
+	existingObjectId string,
+	objectName string,
+	theApi api.Api,
+	payload []byte,
+	apiToken string,
+) (api.DynatraceEntity, error) {
+	fullUrl := theApi.GetUrlFromEnvironmentUrl(environmentUrl)
+
+	resp, err := get(client, joinUrl(fullUrl, existingObjectId), apiToken)
+	if err != nil {
+		return api.DynatraceEntity{}, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		loggerOf(client).Debug("\t\t\tObject %s (%s) does not exist anymore, looking it up by name", objectName, existingObjectId)
+		return upsertDynatraceObject(client, environmentUrl, objectName, theApi, payload, apiToken)
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		return api.DynatraceEntity{}, newMissingScopeError(theApi, resp)
+	}
+
+	if !success(resp) {
+		return api.DynatraceEntity{}, newApiError(resp, "Failed to get existing DT object %s (HTTP %d)!\n    Response was: %s", objectName, resp.StatusCode, string(resp.Body))
+	}
+
+	return updateDynatraceObject(client, fullUrl, objectName, existingObjectId, theApi, payload, apiToken)
 }
 
 func createDynatraceObject(client *http.Client, fullUrl string, objectName string, theApi api.Api, payload []byte, apiToken string) (api.DynatraceEntity, error) {
@@ -86,20 +133,30 @@ func createDynatraceObject(client *http.Client, fullUrl string, objectName strin
 		return api.DynatraceEntity{}, err
 	}
 
+	if resp.StatusCode == http.StatusForbidden {
+		return api.DynatraceEntity{}, newMissingScopeError(theApi, resp)
+	}
+
 	if !success(resp) {
-		return api.DynatraceEntity{}, fmt.Errorf("Failed to create DT object %s (HTTP %d)!\n    Response was: %s", objectName, resp.StatusCode, string(resp.Body))
+		return api.DynatraceEntity{}, newApiError(resp, "Failed to create DT object %s (HTTP %d)!\n    Response was: %s", objectName, resp.StatusCode, string(resp.Body))
+	}
+
+	entity, err := unmarshalResponse(loggerOf(client), resp, fullUrl, configType, objectName)
+	if err != nil {
+		return api.DynatraceEntity{}, err
 	}
 
-	return unmarshalResponse(resp, fullUrl, configType, objectName)
+	entity.Response = resp.Body
+	return entity, nil
 }
 
-func unmarshalResponse(resp Response, fullUrl string, configType string, objectName string) (api.DynatraceEntity, error) {
+func unmarshalResponse(log *util.Logger, resp Response, fullUrl string, configType string, objectName string) (api.DynatraceEntity, error) {
 	var dtEntity api.DynatraceEntity
 
 	if configType == "synthetic-monitor" || configType == "synthetic-location" {
 		var entity api.SyntheticEntity
 		err := json.Unmarshal(resp.Body, &entity)
-		if util.CheckError(err, "Cannot unmarshal Synthetic API response") {
+		if log.CheckError(err, "Cannot unmarshal Synthetic API response") {
 			return api.DynatraceEntity{}, err
 		}
 		dtEntity = translateSyntheticEntityResponse(entity, objectName)
@@ -129,11 +186,11 @@ func unmarshalResponse(resp Response, fullUrl string, configType string, objectN
 
 	} else {
 		err := json.Unmarshal(resp.Body, &dtEntity)
-		if util.CheckError(err, "Cannot unmarshal API response") {
+		if log.CheckError(err, "Cannot unmarshal API response") {
 			return api.DynatraceEntity{}, err
 		}
 	}
-	util.Log.Debug("\t\t\tCreated new object for %s (%s)", dtEntity.Name, dtEntity.Id)
+	log.Debug("\t\t\tCreated new object for %s (%s)", dtEntity.Name, dtEntity.Id)
 
 	return dtEntity, nil
 }
@@ -159,15 +216,20 @@ func updateDynatraceObject(client *http.Client, fullUrl string, objectName strin
 		return api.DynatraceEntity{}, err
 	}
 
+	if resp.StatusCode == http.StatusForbidden {
+		return api.DynatraceEntity{}, newMissingScopeError(theApi, resp)
+	}
+
 	if !success(resp) {
-		return api.DynatraceEntity{}, fmt.Errorf("Failed to update DT object %s (HTTP %d)!\n    Response was: %s", objectName, resp.StatusCode, string(resp.Body))
+		return api.DynatraceEntity{}, newApiError(resp, "Failed to update DT object %s (HTTP %d)!\n    Response was: %s", objectName, resp.StatusCode, string(resp.Body))
 	}
 
-	util.Log.Debug("\t\t\tUpdated existing object for %s (%s)", objectName, existingObjectId)
+	loggerOf(client).Debug("\t\t\tUpdated existing object for %s (%s)", objectName, existingObjectId)
 	return api.DynatraceEntity{
 		Id:          existingObjectId,
 		Name:        objectName,
 		Description: "Updated existing object",
+		Response:    resp.Body,
 	}, nil
 }
 
@@ -187,7 +249,11 @@ func callWithRetryOnKnowTimingIssue(client *http.Client, restCall sendingRequest
 
 	resp, err := restCall(client, path, body, apiToken)
 
-	if err == nil && success(resp) {
+	if err != nil {
+		return Response{}, err
+	}
+
+	if success(resp) {
 		return resp, nil
 	}
 
@@ -218,7 +284,7 @@ func callWithRetryOnKnowTimingIssue(client *http.Client, restCall sendingRequest
 
 func retry(client *http.Client, restCall sendingRequest, objectName string, path string, body []byte, apiToken string, maxRetries int, timeout time.Duration) (Response, error) {
 	for i := 0; i < maxRetries; i++ {
-		util.Log.Warn("\t\t\tDependency of config %s was not available. Waiting for %s before retry...", objectName, timeout)
+		loggerOf(client).Warn("\t\t\tDependency of config %s was not available. Waiting for %s before retry...", objectName, timeout)
 		time.Sleep(timeout)
 		resp, err := restCall(client, path, body, apiToken)
 		if err == nil && success(resp) {
@@ -282,8 +348,22 @@ func deleteDynatraceObject(client *http.Client, api api.Api, name string, url st
 		return err
 	}
 
-	if len(existingId) > 0 {
-		deleteConfig(client, url, token, existingId)
+	if len(existingId) == 0 {
+		return nil
+	}
+
+	resp, err := deleteConfig(client, url, token, existingId)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		return newMissingScopeError(api, resp)
+	}
+
+	// the config might have been deleted in the meantime
+	if !success(resp) && resp.StatusCode != http.StatusNotFound {
+		return newApiError(resp, "Failed to delete DT object %s (HTTP %d)!\n    Response was: %s", name, resp.StatusCode, string(resp.Body))
 	}
 	return nil
 }
@@ -309,7 +389,7 @@ func getObjectIdIfAlreadyExists(client *http.Client, api api.Api, url string, ob
 	}
 
 	if configsFound > 1 {
-		util.Log.Error("\t\t\tFound %d configs with same name: %s. Please delete duplicates.", configsFound, objectName)
+		loggerOf(client).Error("\t\t\tFound %d configs with same name: %s. Please delete duplicates.", configsFound, objectName)
 	}
 	return configName, nil
 }
@@ -330,22 +410,30 @@ func isMobileApp(api api.Api) bool {
 	return api.GetId() == "application-mobile"
 }
 
+// getExistingValuesFromEndpoint lists all values of the api, following the pages of paginated responses until the last
+// page, see nextPageUrl
 func getExistingValuesFromEndpoint(client *http.Client, theApi api.Api, url string, apiToken string) (values []api.Value, err error) {
 
 	url = addQueryParamsForNonStandardApis(theApi, url)
 
 	var existingValues []api.Value
-	resp, err := get(client, url, apiToken)
+	visited := make(map[string]bool)
 
-	if err != nil {
-		return nil, err
-	}
+	for {
+		visited[url] = true
 
-	if !success(resp) {
-		return nil, fmt.Errorf("Failed to get existing configs for api %s (HTTP %d)!\n    Response was: %s", theApi.GetId(), resp.StatusCode, string(resp.Body))
-	}
+		resp, err := get(client, url, apiToken)
+		if err != nil {
+			return nil, err
+		}
 
-	for {
+		if resp.StatusCode == http.StatusForbidden {
+			return nil, newMissingScopeError(theApi, resp)
+		}
+
+		if !success(resp) {
+			return nil, newApiError(resp, "Failed to get existing configs for api %s (HTTP %d)!\n    Response was: %s", theApi.GetId(), resp.StatusCode, string(resp.Body))
+		}
 
 		err, values, objmap := unmarshalJson(theApi, err, resp)
 		if err != nil {
@@ -354,15 +442,17 @@ func getExistingValuesFromEndpoint(client *http.Client, theApi api.Api, url stri
 		existingValues = append(existingValues, values...)
 
 		// Does the API support paging?
-		if isPaginated, nextPage := isPaginatedResponse(objmap); isPaginated {
-			resp, err = get(client, url+"?nextPageKey="+nextPage, apiToken)
-
-			if err != nil {
-				return nil, err
-			}
-		} else {
+		nextUrl, err := nextPageUrl(url, objmap, resp.Headers)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get next page of existing configs for api %s: %w", theApi.GetId(), err)
+		}
+		if nextUrl == "" {
 			break
 		}
+		if visited[nextUrl] {
+			return nil, fmt.Errorf("Failed to get existing configs for api %s: page %s was already read, the pagination doesn't advance", theApi.GetId(), nextUrl)
+		}
+		url = nextUrl
 	}
 
 	return existingValues, nil
@@ -386,7 +476,7 @@ func unmarshalJson(theApi api.Api, err error, resp Response) (error, []api.Value
 
 		var jsonResp []api.Value
 		err := json.Unmarshal(resp.Body, &jsonResp)
-		if util.CheckError(err, "Cannot unmarshal API response for existing aws-credentials") {
+		if resp.log.CheckError(err, "Cannot unmarshal API response for existing aws-credentials") {
 			return err, values, objmap
 		}
 		values = jsonResp
@@ -401,7 +491,7 @@ func unmarshalJson(theApi api.Api, err error, resp Response) (error, []api.Value
 
 			var jsonResp api.SyntheticLocationResponse
 			err = json.Unmarshal(resp.Body, &jsonResp)
-			if util.CheckError(err, "Cannot unmarshal API response for existing synthetic location") {
+			if resp.log.CheckError(err, "Cannot unmarshal API response for existing synthetic location") {
 				return err, nil, nil
 			}
 			values = translateSyntheticValues(jsonResp.Locations)
@@ -410,7 +500,7 @@ func unmarshalJson(theApi api.Api, err error, resp Response) (error, []api.Value
 
 			var jsonResp api.SyntheticMonitorsResponse
 			err = json.Unmarshal(resp.Body, &jsonResp)
-			if util.CheckError(err, "Cannot unmarshal API response for existing synthetic location") {
+			if resp.log.CheckError(err, "Cannot unmarshal API response for existing synthetic location") {
 				return err, nil, nil
 			}
 			values = translateSyntheticValues(jsonResp.Monitors)
@@ -418,7 +508,7 @@ func unmarshalJson(theApi api.Api, err error, resp Response) (error, []api.Value
 		} else if !theApi.IsStandardApi() || isReportsApi(theApi) {
 
 			if available, array := isResultArrayAvailable(objmap, theApi); available {
-				jsonResp, err := translateGenericValues(array, theApi.GetId())
+				jsonResp, err := translateGenericValues(array, theApi.GetId(), theApi.GetIdPropertyName(), resp.log)
 				if err != nil {
 					return err, nil, nil
 				}
@@ -429,7 +519,7 @@ func unmarshalJson(theApi api.Api, err error, resp Response) (error, []api.Value
 
 			var jsonResponse api.ValuesResponse
 			err = json.Unmarshal(resp.Body, &jsonResponse)
-			if util.CheckError(err, "Cannot unmarshal API response for existing objects") {
+			if resp.log.CheckError(err, "Cannot unmarshal API response for existing objects") {
 				return err, nil, nil
 			}
 			values = jsonResponse.Values
@@ -446,14 +536,9 @@ func isResultArrayAvailable(jsonResponse map[string]interface{}, theApi api.Api)
 	return false, make([]interface{}, 0)
 }
 
-func isPaginatedResponse(jsonResponse map[string]interface{}) (paginated bool, pageKey string) {
-	if jsonResponse["nextPageKey"] != nil {
-		return true, jsonResponse["nextPageKey"].(string)
-	}
-	return false, ""
-}
-
-func translateGenericValues(inputValues []interface{}, configType string) ([]api.Value, error) {
+// translateGenericValues reads the ids and names of the listed objects. The id is read from the property idProperty.
+// Invalid objects are logged to log.
+func translateGenericValues(inputValues []interface{}, configType string, idProperty string, log *util.Logger) ([]api.Value, error) {
 
 	numValues := len(inputValues)
 	values := make([]api.Value, numValues, numValues)
@@ -461,15 +546,15 @@ func translateGenericValues(inputValues []interface{}, configType string) ([]api
 	for i := 0; i < numValues; i++ {
 		input := inputValues[i].(map[string]interface{})
 
-		if input["id"] == nil {
-			return values, fmt.Errorf("config of type %s was invalid: No id", configType)
+		if input[idProperty] == nil {
+			return values, fmt.Errorf("config of type %s was invalid: No %s", configType, idProperty)
 		}
 
 		// Substitute missing name attribute
 		if input["name"] == nil {
 			jsonStr, err := json.Marshal(input)
 			if err != nil {
-				util.Log.Warn("Config of type %s was invalid. Ignoring it!", configType)
+				log.Warn("Config of type %s was invalid. Ignoring it!", configType)
 				continue
 			}
 
@@ -482,19 +567,19 @@ func translateGenericValues(inputValues []interface{}, configType string) ([]api
 				substitutedName = input["dashboardId"].(string)
 			} else {
 				// Substitute name with id since it is unique identifier for entity
-				util.Log.Warn("Config of type %s was invalid. Auto-corrected to use ID as name!\nInvalid config: %s", configType, string(jsonStr))
-				substitutedName = input["id"].(string)
+				log.Warn("Config of type %s was invalid. Auto-corrected to use ID as name!\nInvalid config: %s", configType, string(jsonStr))
+				substitutedName = input[idProperty].(string)
 			}
 
 			values[i] = api.Value{
-				Id:   input["id"].(string),
+				Id:   input[idProperty].(string),
 				Name: substitutedName, // use the id as name
 			}
 			continue
 		}
 
 		values[i] = api.Value{
-			Id:   input["id"].(string),
+			Id:   input[idProperty].(string),
 			Name: input["name"].(string),
 		}
 	}
@@ -20,9 +20,12 @@
 package rest
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 	"gotest.tools/assert"
 )
 
@@ -40,7 +43,7 @@ func TestTranslateGenericValuesOnStandardResponse(t *testing.T) {
 	response := make([]interface{}, 1)
 	response[0] = entry
 
-	values, err := translateGenericValues(response, "extensions")
+	values, err := translateGenericValues(response, "extensions", "id", util.DefaultLogger())
 
 	assert.NilError(t, err)
 	assert.Check(t, len(values) == 1)
@@ -49,6 +52,28 @@ func TestTranslateGenericValuesOnStandardResponse(t *testing.T) {
 	assert.Equal(t, values[0].Name, "bar")
 }
 
+func TestTranslateGenericValuesReadsIdOfIdProperty(t *testing.T) {
+
+	entry := make(map[string]interface{})
+	entry["objectId"] = "foo"
+	entry["name"] = "bar"
+
+	response := make([]interface{}, 1)
+	response[0] = entry
+
+	values, err := translateGenericValues(response, "my-settings", "objectId", util.DefaultLogger())
+
+	assert.NilError(t, err)
+	assert.Check(t, len(values) == 1)
+
+	assert.Equal(t, values[0].Id, "foo")
+	assert.Equal(t, values[0].Name, "bar")
+
+	_, err = translateGenericValues(response, "my-settings", "id", util.DefaultLogger())
+
+	assert.ErrorContains(t, err, "config of type my-settings was invalid: No id")
+}
+
 func TestTranslateGenericValuesOnIdMissing(t *testing.T) {
 
 	entry := make(map[string]interface{})
@@ -57,7 +82,7 @@ func TestTranslateGenericValuesOnIdMissing(t *testing.T) {
 	response := make([]interface{}, 1)
 	response[0] = entry
 
-	_, err := translateGenericValues(response, "extensions")
+	_, err := translateGenericValues(response, "extensions", "id", util.DefaultLogger())
 
 	assert.ErrorContains(t, err, "config of type extensions was invalid: No id")
 }
@@ -70,7 +95,7 @@ func TestTranslateGenericValuesOnNameMissing(t *testing.T) {
 	response := make([]interface{}, 1)
 	response[0] = entry
 
-	values, err := translateGenericValues(response, "extensions")
+	values, err := translateGenericValues(response, "extensions", "id", util.DefaultLogger())
 
 	assert.NilError(t, err)
 	assert.Check(t, len(values) == 1)
@@ -88,7 +113,7 @@ func TestTranslateGenericValuesForReportsEndpoint(t *testing.T) {
 	response := make([]interface{}, 1)
 	response[0] = entry
 
-	values, err := translateGenericValues(response, "reports")
+	values, err := translateGenericValues(response, "reports", "id", util.DefaultLogger())
 
 	assert.NilError(t, err)
 	assert.Check(t, len(values) == 1)
@@ -160,3 +185,132 @@ func TestIsApiDashboard(t *testing.T) {
 	isFalse := isApiDashboard(testReportsApi)
 	assert.Equal(t, false, isFalse)
 }
+
+func TestUpsertReportsWhetherObjectWasCreated(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			_, _ = rw.Write([]byte(`{"values": [{"id": "42", "name": "existing"}]}`))
+		case http.MethodPost:
+			rw.WriteHeader(http.StatusCreated)
+			_, _ = rw.Write([]byte(`{"id": "43", "name": "new"}`))
+		case http.MethodPut:
+			rw.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := &dynatraceClientImpl{environmentUrl: server.URL, token: testToken, client: server.Client()}
+
+	entity, err := client.UpsertByName(testDashboardApi, "new", []byte(`{"name": "new"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "43")
+	assert.Equal(t, entity.Created, true)
+
+	entity, err = client.UpsertByName(testDashboardApi, "existing", []byte(`{"name": "existing"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "42")
+	assert.Equal(t, entity.Created, false)
+}
+
+func TestUpsertKeepsResponseOfApi(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			_, _ = rw.Write([]byte(`{"values": [{"id": "42", "name": "existing"}]}`))
+		case http.MethodPost:
+			rw.WriteHeader(http.StatusCreated)
+			_, _ = rw.Write([]byte(`{"id": "43", "name": "new", "key": "assigned-key"}`))
+		case http.MethodPut:
+			_, _ = rw.Write([]byte(`{"id": "42", "key": "existing-key"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := &dynatraceClientImpl{environmentUrl: server.URL, token: testToken, client: server.Client()}
+
+	entity, err := client.UpsertByName(testDashboardApi, "new", []byte(`{"name": "new"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, string(entity.Response), `{"id": "43", "name": "new", "key": "assigned-key"}`)
+
+	entity, err = client.UpsertByName(testDashboardApi, "existing", []byte(`{"name": "existing"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, string(entity.Response), `{"id": "42", "key": "existing-key"}`)
+}
+
+func TestDeleteByNameReportsFailedDeletion(t *testing.T) {
+	deleteStatus := http.StatusBadRequest
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			_, _ = rw.Write([]byte(`{"values": [{"id": "42", "name": "existing"}]}`))
+		case http.MethodDelete:
+			rw.WriteHeader(deleteStatus)
+		}
+	}))
+	defer server.Close()
+
+	client := &dynatraceClientImpl{environmentUrl: server.URL, token: testToken, client: server.Client()}
+
+	err := client.DeleteByName(testDashboardApi, "existing")
+	assert.ErrorContains(t, err, "Failed to delete DT object existing (HTTP 400)")
+
+	// objects which were deleted in the meantime are ignored
+	deleteStatus = http.StatusNotFound
+	err = client.DeleteByName(testDashboardApi, "existing")
+	assert.NilError(t, err)
+
+	// objects which don't exist are ignored
+	err = client.DeleteByName(testDashboardApi, "absent")
+	assert.NilError(t, err)
+}
+
+func TestUpsertByIdUpdatesExistingObject(t *testing.T) {
+	var updatedPath string
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			_, _ = rw.Write([]byte(`{"id": "42", "name": "renamed"}`))
+		case http.MethodPut:
+			updatedPath = req.URL.Path
+			rw.WriteHeader(http.StatusNoContent)
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	client := &dynatraceClientImpl{environmentUrl: server.URL, token: testToken, client: server.Client()}
+
+	entity, err := client.UpsertById(testDashboardApi, "42", "dashboard", []byte(`{"name": "dashboard"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "42")
+	assert.Equal(t, entity.Created, false)
+	assert.Equal(t, updatedPath, "/api/config/v1/dashboards/42")
+}
+
+func TestUpsertByIdFallsBackToNameIfObjectDoesNotExist(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/api/config/v1/dashboards/42":
+			rw.WriteHeader(http.StatusNotFound)
+		case req.Method == http.MethodGet:
+			_, _ = rw.Write([]byte(`{"dashboards": []}`))
+		case req.Method == http.MethodPost:
+			rw.WriteHeader(http.StatusCreated)
+			_, _ = rw.Write([]byte(`{"id": "43", "name": "dashboard"}`))
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	client := &dynatraceClientImpl{environmentUrl: server.URL, token: testToken, client: server.Client()}
+
+	entity, err := client.UpsertById(testDashboardApi, "42", "dashboard", []byte(`{"name": "dashboard"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "43")
+	assert.Equal(t, entity.Created, true)
+}
@@ -0,0 +1,97 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRequestIdHeader is the header carrying the id of each request, unless another header is set using
+// SetRequestIdHeader
+const DefaultRequestIdHeader = "X-Monaco-Request-ID"
+
+// RunIdHeader is the header carrying the id of the run, which is shared by all requests of a run
+const RunIdHeader = "X-Monaco-Run-ID"
+
+// requestIdHeader carries the id of a request, which is also written to the request and response logs
+var requestIdHeader = DefaultRequestIdHeader
+
+// runId identifies the requests of this run. It is unique for each run, unless it is set using SetRunId.
+var runId = uuid.NewString()
+
+// SetRequestIdHeader sets the header carrying the id of each request sent afterwards, so the requests can be
+// correlated with the access logs of the environment. An empty name resets it to DefaultRequestIdHeader.
+func SetRequestIdHeader(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		requestIdHeader = DefaultRequestIdHeader
+		return nil
+	}
+
+	if !isHeaderName(name) {
+		return fmt.Errorf("invalid request id header '%s': must be a valid header name", name)
+	}
+	requestIdHeader = name
+	return nil
+}
+
+// SetRunId sets the id sent in the RunIdHeader of all requests sent afterwards, e.g. the id of the pipeline running
+// monaco. An empty id generates a new unique id.
+func SetRunId(id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		runId = uuid.NewString()
+		return nil
+	}
+
+	for _, c := range id {
+		if c < ' ' || c == 0x7f {
+			return fmt.Errorf("invalid run id '%s': must not contain control characters", id)
+		}
+	}
+	runId = id
+	return nil
+}
+
+// RunId returns the id sent in the RunIdHeader of all requests
+func RunId() string {
+	return runId
+}
+
+// withCorrelationHeaders returns a copy of the request carrying its id and the id of the run. The request must not be
+// modified by a round tripper, so the headers are set on a copy.
+func withCorrelationHeaders(request *http.Request, requestId string) *http.Request {
+	correlated := request.Clone(request.Context())
+	correlated.Header.Set(requestIdHeader, requestId)
+	correlated.Header.Set(RunIdHeader, runId)
+	return correlated
+}
+
+// isHeaderName returns whether the name consists of the characters allowed in header names by RFC 7230
+func isHeaderName(name string) bool {
+	for _, c := range name {
+		isAlphanumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphanumeric && !strings.ContainsRune("!#$%&'*+-.^_`|~", c) {
+			return false
+		}
+	}
+	return true
+}
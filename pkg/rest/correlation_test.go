@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func resetCorrelation(header string, id string) {
+	requestIdHeader = header
+	runId = id
+}
+
+func TestRequestsCarryIdOfRequestLog(t *testing.T) {
+	defer resetCorrelation(requestIdHeader, runId)
+
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received = append(received, req.Header.Clone())
+	}))
+	defer server.Close()
+
+	assert.NilError(t, SetRunId("pipeline-42"))
+
+	fs := afero.NewMemMapFs()
+	log, err := util.NewLogger(fs, util.LoggerOptions{RequestLogFile: "requests.log"})
+	assert.NilError(t, err)
+
+	client := loggingHttpClient(server.Client(), log)
+	for i := 0; i < 2; i++ {
+		_, err = get(client, server.URL+"/api/config/v1/dashboards", "token")
+		assert.NilError(t, err)
+	}
+	log.Close()
+
+	requests, err := afero.ReadFile(fs, "requests.log")
+	assert.NilError(t, err)
+	logged := regexp.MustCompile(`Request-ID: (.+)\n`).FindAllStringSubmatch(string(requests), -1)
+
+	assert.Equal(t, len(received), 2)
+	assert.Equal(t, len(logged), 2)
+	for i, header := range received {
+		assert.Equal(t, header.Get(DefaultRequestIdHeader), logged[i][1])
+		assert.Equal(t, header.Get(RunIdHeader), "pipeline-42")
+	}
+	assert.Assert(t, logged[0][1] != logged[1][1], "each request needs its own id")
+}
+
+func TestRequestIdHeaderIsConfigurable(t *testing.T) {
+	defer resetCorrelation(requestIdHeader, runId)
+
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received = req.Header.Clone()
+	}))
+	defer server.Close()
+
+	assert.NilError(t, SetRequestIdHeader("X-Correlation-ID"))
+
+	_, err := get(loggingHttpClient(server.Client(), util.DefaultLogger()), server.URL, "token")
+	assert.NilError(t, err)
+
+	assert.Check(t, received.Get("X-Correlation-ID") != "")
+	assert.Equal(t, received.Get(DefaultRequestIdHeader), "")
+	assert.Equal(t, received.Get(RunIdHeader), RunId())
+}
+
+func TestSetRequestIdHeaderRejectsInvalidNames(t *testing.T) {
+	defer resetCorrelation(requestIdHeader, runId)
+
+	assert.ErrorContains(t, SetRequestIdHeader("X-Request ID"), "invalid request id header 'X-Request ID'")
+	assert.ErrorContains(t, SetRequestIdHeader("X-Request-ID:"), "must be a valid header name")
+	assert.Equal(t, requestIdHeader, DefaultRequestIdHeader)
+
+	assert.NilError(t, SetRequestIdHeader("X-Correlation-ID"))
+	assert.Equal(t, requestIdHeader, "X-Correlation-ID")
+
+	assert.NilError(t, SetRequestIdHeader(""))
+	assert.Equal(t, requestIdHeader, DefaultRequestIdHeader)
+}
+
+func TestSetRunIdGeneratesIdIfEmpty(t *testing.T) {
+	defer resetCorrelation(requestIdHeader, runId)
+
+	assert.ErrorContains(t, SetRunId("run\r\nX-Injected: true"), "must not contain control characters")
+
+	assert.NilError(t, SetRunId(" pipeline-42 "))
+	assert.Equal(t, RunId(), "pipeline-42")
+
+	assert.NilError(t, SetRunId(""))
+	assert.Check(t, RunId() != "pipeline-42")
+	assert.Check(t, regexp.MustCompile(`^[0-9a-f-]{36}$`).MatchString(RunId()), RunId())
+}
@@ -26,7 +26,6 @@ import (
 	"time"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
-	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 )
 
 type extensionStatus int
@@ -38,9 +37,9 @@ const (
 	extensionNeedsUpdate
 )
 
-func uploadExtension(client *http.Client, apiPath string, extensionName string, payload []byte, apiToken string) (api.DynatraceEntity, error) {
+func uploadExtension(client *http.Client, theApi api.Api, apiPath string, extensionName string, payload []byte, apiToken string) (api.DynatraceEntity, error) {
 
-	status, err := validateIfExtensionShouldBeUploaded(client, apiPath, extensionName, payload, apiToken)
+	status, err := validateIfExtensionShouldBeUploaded(client, theApi, apiPath, extensionName, payload, apiToken)
 	if err != nil {
 		return api.DynatraceEntity{}, err
 	}
@@ -65,9 +64,9 @@ func uploadExtension(client *http.Client, apiPath string, extensionName string,
 	}
 
 	if resp.StatusCode != http.StatusCreated {
-		util.Log.Error("\t\t\tUpload of %s failed with status %d!\n\t\t\t\t\tError-message: %s\n", extensionName, resp.StatusCode, string(resp.Body))
+		loggerOf(client).Error("\t\t\tUpload of %s failed with status %d!\n\t\t\t\t\tError-message: %s\n", extensionName, resp.StatusCode, string(resp.Body))
 	} else {
-		util.Log.Debug("\t\t\tExtension upload successful for %s", extensionName)
+		loggerOf(client).Debug("\t\t\tExtension upload successful for %s", extensionName)
 
 		// As other configs depend on metrics created by extensions, and metric creation seems to happen with delay...
 		time.Sleep(1 * time.Second)
@@ -79,7 +78,7 @@ func uploadExtension(client *http.Client, apiPath string, extensionName string,
 
 }
 
-func validateIfExtensionShouldBeUploaded(client *http.Client, apiPath string, extensionName string, payload []byte, apiToken string) (status extensionStatus, err error) {
+func validateIfExtensionShouldBeUploaded(client *http.Client, theApi api.Api, apiPath string, extensionName string, payload []byte, apiToken string) (status extensionStatus, err error) {
 	response, err := get(client, apiPath+"/"+extensionName, apiToken)
 	if err != nil {
 		return extensionValidationError, err
@@ -87,6 +86,9 @@ func validateIfExtensionShouldBeUploaded(client *http.Client, apiPath string, ex
 	if response.StatusCode == http.StatusNotFound {
 		return extensionNeedsUpdate, nil
 	}
+	if response.StatusCode == http.StatusForbidden {
+		return extensionValidationError, newMissingScopeError(theApi, response)
+	}
 	var extProperties struct{ Version *string }
 	if err := json.Unmarshal(response.Body, &extProperties); err != nil {
 		return extensionValidationError, err
@@ -113,7 +115,7 @@ func validateIfExtensionShouldBeUploaded(client *http.Client, apiPath string, ex
 	}
 
 	if curVersion == newVersion {
-		util.Log.Info("Extension (%s) already deployed in version (%s), skipping.", extensionName, newVersion)
+		loggerOf(client).Info("Extension (%s) already deployed in version (%s), skipping.", extensionName, newVersion)
 		return extensionUpToDate, nil
 	}
 
@@ -149,8 +151,8 @@ func writeInMemoryZip(fileName string, fileContent []byte) (*bytes.Buffer, error
 	buffer := new(bytes.Buffer)
 	zipWriter := zip.NewWriter(buffer)
 	zipFile, err := zipWriter.Create(fileName)
-	if util.CheckError(err, "Failed to create .zip file") {
-		return buffer, err
+	if err != nil {
+		return buffer, fmt.Errorf("failed to create .zip file: %w", err)
 	}
 	_, err = zipFile.Write(fileContent)
 	if err != nil {
@@ -24,8 +24,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
 )
 
+var testExtensionApi = api.NewStandardApi("extension", "/api/config/v1/extensions")
+
 func TestCorrectlyIdentifiesLowerLocalVersion(t *testing.T) {
 	localPayload := `{ "version": "1" }`
 	remotePayload := `{ "version": "2" }`
@@ -35,7 +39,7 @@ func TestCorrectlyIdentifiesLowerLocalVersion(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", []byte(localPayload), "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", []byte(localPayload), "token")
 	assert.Assert(t, err != nil)
 	assert.Equal(t, status, extensionConfigOutdated)
 }
@@ -49,7 +53,7 @@ func TestCorrectlyIdentifiesEqualVersion(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", []byte(localPayload), "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", []byte(localPayload), "token")
 	assert.NilError(t, err)
 	assert.Equal(t, status, extensionUpToDate)
 }
@@ -63,7 +67,7 @@ func TestCorrectlyIdentifiesNecessaryUpdate(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", []byte(localPayload), "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", []byte(localPayload), "token")
 	assert.NilError(t, err)
 	assert.Equal(t, status, extensionNeedsUpdate)
 }
@@ -74,7 +78,7 @@ func TestCorrectlyIdentifiesMissingExtension(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", nil, "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", nil, "token")
 	assert.NilError(t, err)
 	assert.Equal(t, status, extensionNeedsUpdate)
 }
@@ -88,7 +92,7 @@ func TestThrowsErrorOnRemoteParsingProblems(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", []byte(localPayload), "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", []byte(localPayload), "token")
 	assert.Assert(t, err != nil)
 	assert.Equal(t, status, extensionValidationError)
 }
@@ -102,7 +106,7 @@ func TestThrowsErrorOnLocalParsingProblems(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", []byte(localPayload), "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", []byte(localPayload), "token")
 	assert.Assert(t, err != nil)
 	assert.Equal(t, status, extensionValidationError)
 }
@@ -116,7 +120,7 @@ func TestThrowsErrorOnRemoteMissingVersions(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", []byte(localPayload), "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", []byte(localPayload), "token")
 	assert.Assert(t, err != nil)
 	assert.Equal(t, status, extensionValidationError)
 }
@@ -130,7 +134,7 @@ func TestThrowsErrorOnLocalMissingVersions(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", []byte(localPayload), "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", []byte(localPayload), "token")
 	assert.Assert(t, err != nil)
 	assert.Equal(t, status, extensionValidationError)
 }
@@ -143,7 +147,7 @@ func TestThrowsErrorOnRemoteNilReturn(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", []byte(localPayload), "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", []byte(localPayload), "token")
 	assert.Assert(t, err != nil)
 	assert.Equal(t, status, extensionValidationError)
 }
@@ -156,7 +160,7 @@ func TestThrowsErrorOnLocalNilPayload(t *testing.T) {
 	}))
 	defer server.Close()
 
-	status, err := validateIfExtensionShouldBeUploaded(server.Client(), server.URL, "name", nil, "token")
+	status, err := validateIfExtensionShouldBeUploaded(server.Client(), testExtensionApi, server.URL, "name", nil, "token")
 	assert.Assert(t, err != nil)
 	assert.Equal(t, status, extensionValidationError)
 }
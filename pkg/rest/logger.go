@@ -0,0 +1,163 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+// WithLogger returns a copy of the client writing its messages, requests and responses to the logger instead of the
+// default logger. Clients not created by NewDynatraceClient or NewManagedDynatraceClient are returned unchanged.
+func WithLogger(client DynatraceClient, log *util.Logger) DynatraceClient {
+	impl, ok := client.(*dynatraceClientImpl)
+	if !ok {
+		return client
+	}
+
+	logged := *impl
+	logged.client = loggingHttpClient(impl.client, log)
+	logged.platformClient = loggingHttpClient(impl.platformClient, log)
+	return &logged
+}
+
+// loggingHttpClient returns a copy of the http client logging all requests it sends to the given logger. If the
+// client already logs its requests, only the logger is replaced, so requests are not logged twice.
+func loggingHttpClient(client *http.Client, log *util.Logger) *http.Client {
+	if client == nil {
+		return nil
+	}
+
+	logged := *client
+	logged.Transport = withLoggingTransport(client.Transport, log)
+	return &logged
+}
+
+func withLoggingTransport(transport http.RoundTripper, log *util.Logger) http.RoundTripper {
+	switch t := transport.(type) {
+	case *loggingTransport:
+		return &loggingTransport{base: t.base, log: log}
+	case *tracingTransport:
+		return &tracingTransport{base: withLoggingTransport(t.base, log), ctx: t.ctx}
+	case *bearerTokenTransport:
+		return &bearerTokenTransport{base: withLoggingTransport(t.base, log), tokenSource: t.tokenSource}
+	case nil:
+		return &loggingTransport{base: http.DefaultTransport, log: log}
+	default:
+		return &loggingTransport{base: t, log: log}
+	}
+}
+
+// unloggedHttpClient returns a copy of the http client not logging its requests, for requests exchanging credentials
+func unloggedHttpClient(client *http.Client) *http.Client {
+	logging, ok := client.Transport.(*loggingTransport)
+	if !ok {
+		return client
+	}
+
+	unlogged := *client
+	unlogged.Transport = logging.base
+	return &unlogged
+}
+
+// loggedHttpClient returns the http client, if it logs its requests, or a copy of it logging them to the default logger
+func loggedHttpClient(client *http.Client) *http.Client {
+	if loggingTransportOf(client) != nil {
+		return client
+	}
+	return loggingHttpClient(client, nil)
+}
+
+// loggerOf returns the logger of the http client, which is the default logger unless it was set using WithLogger
+func loggerOf(client *http.Client) *util.Logger {
+	logging := loggingTransportOf(client)
+	if logging == nil || logging.log == nil {
+		return util.DefaultLogger()
+	}
+	return logging.log
+}
+
+// loggingTransportOf returns the transport logging the requests of the http client, or nil if they are not logged
+func loggingTransportOf(client *http.Client) *loggingTransport {
+	transport := client.Transport
+	for {
+		switch t := transport.(type) {
+		case *loggingTransport:
+			return t
+		case *tracingTransport:
+			transport = t.base
+		case *bearerTokenTransport:
+			transport = t.base
+		default:
+			return nil
+		}
+	}
+}
+
+// loggingTransport writes every request sent using the wrapped transport and its response to the request and
+// response logs of the logger, or of the default logger if it is nil. Requests get a unique id to match them with
+// their response in the response log. The id is sent to the environment, together with the id of the run, so the
+// requests can be correlated with its access logs, see withCorrelationHeaders.
+type loggingTransport struct {
+	base http.RoundTripper
+	log  *util.Logger
+}
+
+func (t *loggingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	log := t.log
+	if log == nil {
+		log = util.DefaultLogger()
+	}
+
+	request, requestId := ensureRequestId(request)
+	request = withCorrelationHeaders(request, requestId)
+
+	if log.IsRequestLoggingActive() {
+		err := log.LogRequest(requestId, request)
+
+		if err != nil {
+			log.Warn("error while writing request log for id `%s`: %v", requestId, err)
+		}
+	}
+
+	start := time.Now()
+	response, err := t.base.RoundTrip(request)
+	if err != nil || !log.IsResponseLoggingActive() {
+		return response, err
+	}
+
+	// the body is read to log it, so the response gets a new reader for it
+	body, err := ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	logged := *response
+	logged.Body = ioutil.NopCloser(bytes.NewReader(body))
+	err = log.LogResponse(requestId, &logged, time.Since(start))
+
+	if err != nil {
+		log.Warn("error while writing response log for id `%s`: %v", requestId, err)
+	}
+	return response, nil
+}
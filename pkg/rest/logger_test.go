@@ -0,0 +1,183 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestWithLoggerLogsRequestsAndResponsesOfClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{"id": "some-id", "name": "dashboard"}`))
+	}))
+	defer server.Close()
+
+	fs := afero.NewMemMapFs()
+	log, err := util.NewLogger(fs, util.LoggerOptions{RequestLogFile: "requests.log", ResponseLogFile: "responses.log"})
+	assert.NilError(t, err)
+
+	client := WithLogger(&dynatraceClientImpl{environmentUrl: server.URL, token: "token", client: server.Client()}, log)
+
+	_, err = client.ReadById(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "some-id")
+	assert.NilError(t, err)
+	log.Close()
+
+	requests, err := afero.ReadFile(fs, "requests.log")
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(string(requests), "GET /api/config/v1/dashboards/some-id"), string(requests))
+
+	responses, err := afero.ReadFile(fs, "responses.log")
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(string(responses), `{"id": "some-id", "name": "dashboard"}`), string(responses))
+}
+
+func TestWithLoggerWritesMessagesOfClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var out bytes.Buffer
+	log, err := util.NewLogger(afero.NewMemMapFs(), util.LoggerOptions{Writer: &out})
+	assert.NilError(t, err)
+
+	_, err = get(loggingHttpClient(server.Client(), log), url, "token")
+	assert.Check(t, err != nil)
+	assert.Check(t, strings.Contains(out.String(), "HTTP Request failed with Error"), out.String())
+}
+
+func TestWithLoggerWritesDeniedAccessOfClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusForbidden)
+		_, _ = rw.Write([]byte("missing scope"))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	log, err := util.NewLogger(afero.NewMemMapFs(), util.LoggerOptions{Writer: &out, Verbose: true})
+	assert.NilError(t, err)
+
+	client := WithLogger(&dynatraceClientImpl{environmentUrl: server.URL, token: "token", client: server.Client()}, log)
+
+	_, err = client.ReadById(api.NewStandardApi("slo", "/api/v2/slo"), "id")
+	assert.Check(t, err != nil)
+	assert.Check(t, strings.Contains(out.String(), "Access to slo denied (HTTP 403), response was: missing scope"), out.String())
+}
+
+func TestLoggerOfHttpClient(t *testing.T) {
+	log, err := util.NewLogger(afero.NewMemMapFs(), util.LoggerOptions{})
+	assert.NilError(t, err)
+
+	assert.Equal(t, loggerOf(&http.Client{}), util.DefaultLogger())
+	assert.Equal(t, loggerOf(loggingHttpClient(&http.Client{}, log)), log)
+	assert.Equal(t, loggerOf(tracedHttpClient(loggingHttpClient(&http.Client{}, log), context.Background())), log)
+}
+
+func TestWithLoggerReturnsOtherClientsUnchanged(t *testing.T) {
+	client := CreateDynatraceClientMockFactory(t)
+	assert.Equal(t, WithLogger(client, util.DefaultLogger()), DynatraceClient(client))
+}
+
+func TestLoggingHttpClientLogsRequestsWithoutExplicitCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{"id": "some-id"}`))
+	}))
+	defer server.Close()
+
+	fs := afero.NewMemMapFs()
+	log, err := util.NewLogger(fs, util.LoggerOptions{RequestLogFile: "requests.log", ResponseLogFile: "responses.log"})
+	assert.NilError(t, err)
+
+	response, err := loggingHttpClient(server.Client(), log).Get(server.URL + "/api/config/v1/dashboards/some-id")
+	assert.NilError(t, err)
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NilError(t, err)
+	assert.NilError(t, response.Body.Close())
+	assert.Equal(t, string(body), `{"id": "some-id"}`)
+	log.Close()
+
+	requests, err := afero.ReadFile(fs, "requests.log")
+	assert.NilError(t, err)
+	responses, err := afero.ReadFile(fs, "responses.log")
+	assert.NilError(t, err)
+
+	requestId := regexp.MustCompile(`^Request-ID: (\S+)\n`).FindStringSubmatch(string(requests))
+	assert.Assert(t, requestId != nil, string(requests))
+	assert.Check(t, strings.Contains(string(requests), "GET /api/config/v1/dashboards/some-id"), string(requests))
+	assert.Check(t, strings.HasPrefix(string(responses), "Request-ID: "+requestId[1]+"\n"), string(responses))
+	assert.Check(t, strings.Contains(string(responses), `{"id": "some-id"}`), string(responses))
+}
+
+func TestLoggingHttpClientReplacesLoggerOfLoggingClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	fs := afero.NewMemMapFs()
+	log, err := util.NewLogger(fs, util.LoggerOptions{RequestLogFile: "requests.log"})
+	assert.NilError(t, err)
+
+	client := loggingHttpClient(loggingHttpClient(server.Client(), util.DefaultLogger()), log)
+	_, err = get(client, server.URL, "token")
+	assert.NilError(t, err)
+	log.Close()
+
+	requests, err := afero.ReadFile(fs, "requests.log")
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Count(string(requests), "Request-ID: "), 1, string(requests))
+}
+
+func TestTokenRequestsOfPlatformClientAreNotLogged(t *testing.T) {
+	tokenServer, _ := newTestTokenServer(t, 300)
+	defer tokenServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	fs := afero.NewMemMapFs()
+	log, err := util.NewLogger(fs, util.LoggerOptions{RequestLogFile: "requests.log", ResponseLogFile: "responses.log"})
+	assert.NilError(t, err)
+
+	client := newPlatformHttpClient(testCredentials(tokenServer.URL), loggingHttpClient(tokenServer.Client(), log))
+	_, err = get(client, server.URL+"/platform/api/v1/documents", "token")
+	assert.NilError(t, err)
+	log.Close()
+
+	requests, err := afero.ReadFile(fs, "requests.log")
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Count(string(requests), "Request-ID: "), 1, string(requests))
+	assert.Check(t, strings.Contains(string(requests), "GET /platform/api/v1/documents"), string(requests))
+
+	responses, err := afero.ReadFile(fs, "responses.log")
+	assert.NilError(t, err)
+	assert.Check(t, !strings.Contains(string(responses), "token-1"), string(responses))
+}
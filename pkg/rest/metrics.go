@@ -0,0 +1,47 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/metrics"
+)
+
+const (
+	requestDurationMetric = "monaco_request_duration_seconds"
+	requestRetriesMetric  = "monaco_request_retries_total"
+)
+
+// requestDurationBuckets are the upper bounds of the request latency histogram in seconds
+var requestDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// observeRequest adds the duration of a request answered by the environment to the latency histogram
+func observeRequest(method string, duration time.Duration) {
+	if registry := metrics.Active(); registry != nil {
+		registry.Observe(requestDurationMetric, "Duration of the requests to Dynatrace until their response has been read.",
+			requestDurationBuckets, metrics.Labels{"method": method}, duration.Seconds())
+	}
+}
+
+// countRetry counts a request sent again after a failed or rate limited attempt
+func countRetry(method string) {
+	if registry := metrics.Active(); registry != nil {
+		registry.Add(requestRetriesMetric, "Requests to Dynatrace sent again after a failed or rate limited attempt.",
+			metrics.Labels{"method": method}, 1)
+	}
+}
@@ -0,0 +1,56 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/metrics"
+	"gotest.tools/assert"
+)
+
+func TestRequestsAreObservedInActiveMetrics(t *testing.T) {
+	registry := metrics.Enable()
+	defer metrics.Disable()
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		attempts++
+		if attempts == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NilError(t, err)
+
+	_, err = newTestRetryTransport(&clientTransport{client: server.Client()}, 2).RoundTrip(request)
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, registry.Write(&out))
+	assert.Check(t, strings.Contains(out.String(), "monaco_request_duration_seconds_count{method=\"GET\"} 2\n"), out.String())
+	assert.Check(t, strings.Contains(out.String(), "monaco_request_retries_total{method=\"GET\"} 1\n"), out.String())
+}
@@ -0,0 +1,223 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+const (
+	defaultOAuthTokenUrl = "https://sso.dynatrace.com/sso/oauth2/token"
+
+	// maxTokenRefreshMargin is the maximal time before its expiry at which a token is refreshed
+	maxTokenRefreshMargin = 1 * time.Minute
+
+	// defaultTokenLifetime is assumed if the token endpoint doesn't return a (positive) lifetime of the token
+	defaultTokenLifetime = 5 * time.Minute
+)
+
+// oauthCredentials are the OAuth client credentials used to access platform APIs
+type oauthCredentials struct {
+	clientId     string
+	clientSecret string
+	tokenUrl     string
+	scope        string
+}
+
+// readOAuthCredentials reads the OAuth client credentials from the environment variables MONACO_CLIENT_ID,
+// MONACO_CLIENT_SECRET, MONACO_TOKEN_URL and MONACO_OAUTH_SCOPE. If no client id and secret are set, found is
+// false.
+func readOAuthCredentials() (credentials oauthCredentials, found bool, err error) {
+	clientId := strings.TrimSpace(os.Getenv("MONACO_CLIENT_ID"))
+	clientSecret := strings.TrimSpace(os.Getenv("MONACO_CLIENT_SECRET"))
+
+	if clientId == "" && clientSecret == "" {
+		return oauthCredentials{}, false, nil
+	}
+
+	if clientId == "" || clientSecret == "" {
+		return oauthCredentials{}, false, fmt.Errorf("incomplete OAuth client credentials: both MONACO_CLIENT_ID and MONACO_CLIENT_SECRET need to be set")
+	}
+
+	tokenUrl := strings.TrimSpace(os.Getenv("MONACO_TOKEN_URL"))
+	if tokenUrl == "" {
+		tokenUrl = defaultOAuthTokenUrl
+	}
+
+	return oauthCredentials{
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		tokenUrl:     tokenUrl,
+		scope:        strings.TrimSpace(os.Getenv("MONACO_OAUTH_SCOPE")),
+	}, true, nil
+}
+
+// HasOAuthCredentials returns whether OAuth client credentials are configured, which clients require to access
+// platform APIs
+func HasOAuthCredentials() bool {
+	_, found, err := readOAuthCredentials()
+	return found && err == nil
+}
+
+// oauthTokenSource obtains bearer tokens using the OAuth client credentials flow. Tokens are cached and
+// refreshed shortly before they expire. It is safe for concurrent use: concurrent callers share a single token
+// and wait for each other while a token is obtained.
+type oauthTokenSource struct {
+	credentials      oauthCredentials
+	client           *http.Client
+	timelineProvider util.TimelineProvider
+	log              *util.Logger
+
+	mutex     sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+func newOAuthTokenSource(credentials oauthCredentials, client *http.Client, log *util.Logger) *oauthTokenSource {
+	return &oauthTokenSource{
+		credentials:      credentials,
+		client:           client,
+		timelineProvider: util.NewTimelineProvider(),
+		log:              log,
+	}
+}
+
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// getToken returns a valid bearer token, obtaining a new one if there is no cached token or it is about to expire
+func (s *oauthTokenSource) getToken() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.timelineProvider.Now()
+	if s.token != "" && now.Before(s.refreshAt) {
+		return s.token, nil
+	}
+
+	response, err := s.requestToken()
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := time.Duration(response.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	margin := lifetime / 2
+	if margin > maxTokenRefreshMargin {
+		margin = maxTokenRefreshMargin
+	}
+
+	s.token = response.AccessToken
+	s.refreshAt = now.Add(lifetime - margin)
+
+	s.log.Debug("Obtained OAuth token from %s, valid for %s", s.credentials.tokenUrl, lifetime)
+	return s.token, nil
+}
+
+// requestToken requests a new token. The request is neither retried nor logged, as it contains the client secret.
+func (s *oauthTokenSource) requestToken() (oauthTokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", s.credentials.clientId)
+	form.Set("client_secret", s.credentials.clientSecret)
+	if s.credentials.scope != "" {
+		form.Set("scope", s.credentials.scope)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, s.credentials.tokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauthTokenResponse{}, fmt.Errorf("invalid OAuth token url %s: %w", s.credentials.tokenUrl, err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(request)
+	if err != nil {
+		return oauthTokenResponse{}, fmt.Errorf("could not obtain OAuth token from %s: %w", s.credentials.tokenUrl, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return oauthTokenResponse{}, fmt.Errorf("could not obtain OAuth token from %s: %w", s.credentials.tokenUrl, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		tokenResponse := Response{StatusCode: resp.StatusCode, Body: body, Headers: resp.Header, method: request.Method, url: s.credentials.tokenUrl}
+		return oauthTokenResponse{}, newApiError(tokenResponse, "could not obtain OAuth token from %s (HTTP %d): %s. Please check MONACO_CLIENT_ID and MONACO_CLIENT_SECRET", s.credentials.tokenUrl, resp.StatusCode, string(body))
+	}
+
+	var response oauthTokenResponse
+	if err := json.Unmarshal(body, &response); err != nil || response.AccessToken == "" {
+		return oauthTokenResponse{}, fmt.Errorf("could not obtain OAuth token from %s: the response did not contain an access token", s.credentials.tokenUrl)
+	}
+
+	if response.TokenType != "" && !strings.EqualFold(response.TokenType, "bearer") {
+		return oauthTokenResponse{}, fmt.Errorf("could not obtain OAuth token from %s: unsupported token type '%s'", s.credentials.tokenUrl, response.TokenType)
+	}
+
+	return response, nil
+}
+
+// bearerTokenTransport replaces the Authorization header of all requests with a bearer token
+type bearerTokenTransport struct {
+	base        http.RoundTripper
+	tokenSource *oauthTokenSource
+}
+
+func (t *bearerTokenTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	token, err := t.tokenSource.getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// a RoundTripper must not modify the original request
+	authorized := request.Clone(request.Context())
+	authorized.Header.Set("Authorization", "Bearer "+token)
+
+	return t.base.RoundTrip(authorized)
+}
+
+// newPlatformHttpClient creates a http client authenticating all requests using OAuth bearer tokens. Requests and
+// tokens are sent using the given http client, but token requests are not logged, as their responses contain the token.
+func newPlatformHttpClient(credentials oauthCredentials, base *http.Client) *http.Client {
+	transport := base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	client := *base
+	client.Transport = &bearerTokenTransport{
+		base:        transport,
+		tokenSource: newOAuthTokenSource(credentials, unloggedHttpClient(base), loggerOf(base)),
+	}
+	return &client
+}
@@ -0,0 +1,259 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"gotest.tools/assert"
+)
+
+// newTestTokenServer returns a token endpoint issuing the tokens "token-1", "token-2", ... and the number of
+// issued tokens
+func newTestTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	issuedTokens := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.NilError(t, req.ParseForm())
+		if req.PostForm.Get("grant_type") != "client_credentials" || req.PostForm.Get("client_id") != "my-client" || req.PostForm.Get("client_secret") != "my-secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			_, _ = rw.Write([]byte(`{"error": "invalid_client"}`))
+			return
+		}
+
+		token := atomic.AddInt32(issuedTokens, 1)
+		_, _ = rw.Write([]byte(fmt.Sprintf(`{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, token, expiresIn)))
+	}))
+	return server, issuedTokens
+}
+
+func testCredentials(tokenUrl string) oauthCredentials {
+	return oauthCredentials{clientId: "my-client", clientSecret: "my-secret", tokenUrl: tokenUrl}
+}
+
+func TestOAuthTokenSourceCachesToken(t *testing.T) {
+	server, issuedTokens := newTestTokenServer(t, 300)
+	defer server.Close()
+
+	tokenSource := newOAuthTokenSource(testCredentials(server.URL), server.Client(), util.DefaultLogger())
+
+	for i := 0; i < 3; i++ {
+		token, err := tokenSource.getToken()
+		assert.NilError(t, err)
+		assert.Equal(t, token, "token-1")
+	}
+	assert.Equal(t, *issuedTokens, int32(1))
+}
+
+func TestOAuthTokenSourceAssumesDefaultLifetimeOfTokensWithoutExpiry(t *testing.T) {
+	server, issuedTokens := newTestTokenServer(t, 0)
+	defer server.Close()
+
+	start := time.Date(2022, 5, 4, 13, 37, 0, 0, time.UTC)
+	timelineProvider := createTimelineProviderMock(t)
+	tokenSource := newOAuthTokenSource(testCredentials(server.URL), server.Client(), util.DefaultLogger())
+	tokenSource.timelineProvider = timelineProvider
+
+	timelineProvider.EXPECT().Now().Return(start)
+	timelineProvider.EXPECT().Now().Return(start.Add(time.Second))
+	timelineProvider.EXPECT().Now().Return(start.Add(defaultTokenLifetime))
+
+	for _, expected := range []string{"token-1", "token-1", "token-2"} {
+		token, err := tokenSource.getToken()
+		assert.NilError(t, err)
+		assert.Equal(t, token, expected)
+	}
+	assert.Equal(t, *issuedTokens, int32(2))
+}
+
+func TestOAuthTokenSourceRefreshesTokenBeforeExpiry(t *testing.T) {
+	server, _ := newTestTokenServer(t, 300)
+	defer server.Close()
+
+	start := time.Date(2022, 5, 4, 13, 37, 0, 0, time.UTC)
+	timelineProvider := createTimelineProviderMock(t)
+	tokenSource := newOAuthTokenSource(testCredentials(server.URL), server.Client(), util.DefaultLogger())
+	tokenSource.timelineProvider = timelineProvider
+
+	timelineProvider.EXPECT().Now().Return(start)
+	timelineProvider.EXPECT().Now().Return(start.Add(239 * time.Second))
+	timelineProvider.EXPECT().Now().Return(start.Add(240 * time.Second))
+
+	for _, expected := range []string{"token-1", "token-1", "token-2"} {
+		token, err := tokenSource.getToken()
+		assert.NilError(t, err)
+		assert.Equal(t, token, expected)
+	}
+}
+
+func TestOAuthTokenSourceIsSafeForConcurrentUse(t *testing.T) {
+	server, issuedTokens := newTestTokenServer(t, 300)
+	defer server.Close()
+
+	tokenSource := newOAuthTokenSource(testCredentials(server.URL), server.Client(), util.DefaultLogger())
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := tokenSource.getToken()
+			assert.Check(t, err == nil)
+			assert.Check(t, token == "token-1")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, *issuedTokens, int32(1))
+}
+
+func TestOAuthTokenSourceReportsTokenEndpointFailures(t *testing.T) {
+	server, _ := newTestTokenServer(t, 300)
+	defer server.Close()
+
+	credentials := testCredentials(server.URL)
+	credentials.clientSecret = "wrong"
+	tokenSource := newOAuthTokenSource(credentials, server.Client(), util.DefaultLogger())
+
+	_, err := tokenSource.getToken()
+	assert.ErrorContains(t, err, "could not obtain OAuth token from "+server.URL+" (HTTP 401)")
+	assert.ErrorContains(t, err, "MONACO_CLIENT_SECRET")
+
+	var apiErr *ApiError
+	assert.Assert(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.StatusCode, http.StatusUnauthorized)
+	assert.Equal(t, apiErr.Url, server.URL)
+	assert.Equal(t, string(apiErr.Body), `{"error": "invalid_client"}`)
+	assert.Assert(t, errors.Is(err, ErrClientError))
+}
+
+func TestPlatformApiRequestsReturnApiErrorOfRejectedToken(t *testing.T) {
+	tokenServer, _ := newTestTokenServer(t, 300)
+	defer tokenServer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request %s without token", req.URL)
+	}))
+	defer server.Close()
+
+	credentials := testCredentials(tokenServer.URL)
+	credentials.clientSecret = "wrong"
+
+	_, err := get(newPlatformHttpClient(credentials, server.Client()), server.URL+"/platform/automation/v1/workflows", "")
+
+	var networkErr *NetworkError
+	assert.Assert(t, !errors.As(err, &networkErr))
+	assert.Assert(t, errors.Is(err, ErrClientError))
+}
+
+func TestBearerTokenTransportAuthorizesCopiesOfRequests(t *testing.T) {
+	tokenServer, _ := newTestTokenServer(t, 300)
+	defer tokenServer.Close()
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		authorization = req.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	transport := &bearerTokenTransport{
+		base:        server.Client().Transport,
+		tokenSource: newOAuthTokenSource(testCredentials(tokenServer.URL), tokenServer.Client(), util.DefaultLogger()),
+	}
+
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NilError(t, err)
+	request.Header.Set("Authorization", "Api-Token my-api-token")
+
+	response, err := transport.RoundTrip(request)
+	assert.NilError(t, err)
+	assert.NilError(t, response.Body.Close())
+
+	assert.Equal(t, authorization, "Bearer token-1")
+	assert.Equal(t, request.Header.Get("Authorization"), "Api-Token my-api-token")
+}
+
+func TestPlatformApisUseBearerTokenWhileConfigApisUseApiToken(t *testing.T) {
+	tokenServer, _ := newTestTokenServer(t, 300)
+	defer tokenServer.Close()
+
+	authorizationHeaders := make(map[string]string)
+	environment := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		authorizationHeaders[req.URL.Path] = req.Header.Get("Authorization")
+		_, _ = rw.Write([]byte(`{}`))
+	}))
+	defer environment.Close()
+
+	client := &dynatraceClientImpl{
+		environmentUrl: environment.URL,
+		token:          "my-api-token",
+		client:         environment.Client(),
+		platformClient: &http.Client{
+			Transport: &bearerTokenTransport{
+				base:        environment.Client().Transport,
+				tokenSource: newOAuthTokenSource(testCredentials(tokenServer.URL), tokenServer.Client(), util.DefaultLogger()),
+			},
+		},
+	}
+
+	_, err := client.ReadById(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "id")
+	assert.NilError(t, err)
+	_, err = client.ReadById(api.NewPlatformApi("platform", "/platform/api/v1/documents", ""), "id")
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, authorizationHeaders, map[string]string{
+		"/api/config/v1/dashboards/id":  "Api-Token my-api-token",
+		"/platform/api/v1/documents/id": "Bearer token-1",
+	})
+}
+
+func TestPlatformApisRequireOAuthCredentials(t *testing.T) {
+	client := &dynatraceClientImpl{environmentUrl: "https://my-environment.live.dynatrace.com", token: "my-api-token", client: &http.Client{}}
+
+	_, err := client.ReadById(api.NewPlatformApi("platform", "/platform/api/v1/documents", ""), "id")
+	assert.ErrorContains(t, err, "API platform requires OAuth authentication")
+}
+
+func TestReadOAuthCredentials(t *testing.T) {
+	defer os.Unsetenv("MONACO_CLIENT_ID")
+	defer os.Unsetenv("MONACO_CLIENT_SECRET")
+
+	_, found, err := readOAuthCredentials()
+	assert.NilError(t, err)
+	assert.Check(t, !found)
+
+	os.Setenv("MONACO_CLIENT_ID", "my-client")
+	_, _, err = readOAuthCredentials()
+	assert.ErrorContains(t, err, "incomplete OAuth client credentials")
+
+	os.Setenv("MONACO_CLIENT_SECRET", "my-secret")
+	credentials, found, err := readOAuthCredentials()
+	assert.NilError(t, err)
+	assert.Check(t, found)
+	assert.Equal(t, credentials.tokenUrl, defaultOAuthTokenUrl)
+}
@@ -0,0 +1,92 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// nextPageUrl returns the url of the page following a page of a list request to the given url, or an empty string if
+// the page is the last one. The Dynatrace API returns the key of the next page as nextPageKey in the body, which
+// replaces all other query parameters of the request. Responses without a key may link the next page in a Link header
+// with the relation next, which is resolved relative to the url of the page.
+func nextPageUrl(pageUrl string, body map[string]interface{}, headers map[string][]string) (string, error) {
+	if key, found := body["nextPageKey"]; found && key != nil {
+		pageKey, isString := key.(string)
+		if !isString {
+			return "", fmt.Errorf("invalid nextPageKey %v: expected a string", key)
+		}
+		if pageKey != "" {
+			return withNextPageKey(pageUrl, pageKey)
+		}
+	}
+
+	link := nextLink(http.Header(headers).Values("Link"))
+	if link == "" {
+		return "", nil
+	}
+
+	base, err := url.Parse(pageUrl)
+	if err != nil {
+		return "", err
+	}
+	next, err := base.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid link to next page %s: %w", link, err)
+	}
+	return next.String(), nil
+}
+
+// withNextPageKey returns the url with the page key as only query parameter
+func withNextPageKey(pageUrl string, pageKey string) (string, error) {
+	parsed, err := url.Parse(pageUrl)
+	if err != nil {
+		return "", err
+	}
+	parsed.RawQuery = url.Values{"nextPageKey": {pageKey}}.Encode()
+	return parsed.String(), nil
+}
+
+// nextLink returns the target of the link with the relation next in the values of Link headers, e.g.
+// <https://example.com/api?page=2>; rel="next". It returns an empty string if there is no such link.
+func nextLink(values []string) string {
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				split := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(split) != 2 || !strings.EqualFold(split[0], "rel") {
+					continue
+				}
+				for _, relation := range strings.Fields(strings.Trim(split[1], `"`)) {
+					if strings.EqualFold(relation, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
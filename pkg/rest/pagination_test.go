@@ -0,0 +1,147 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"gotest.tools/assert"
+)
+
+func TestListFollowsNextPageKeys(t *testing.T) {
+	var requests []string
+	client, closeServer := newSettingsTestClient(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.URL.RequestURI())
+
+		switch req.URL.Query().Get("nextPageKey") {
+		case "":
+			_, _ = rw.Write([]byte(`{"values": [{"id": "a", "name": "first"}, {"id": "b", "name": "second"}], "nextPageKey": "page+2/3"}`))
+		case "page+2/3":
+			_, _ = rw.Write([]byte(`{"values": [{"id": "c", "name": "third"}], "nextPageKey": null}`))
+		default:
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer closeServer()
+
+	values, err := client.List(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, values, []api.Value{{Id: "a", Name: "first"}, {Id: "b", Name: "second"}, {Id: "c", Name: "third"}})
+	assert.DeepEqual(t, requests, []string{"/api/config/v1/dashboards", "/api/config/v1/dashboards?nextPageKey=page%2B2%2F3"})
+}
+
+func TestListReplacesQueryParametersByNextPageKey(t *testing.T) {
+	var requests []string
+	client, closeServer := newSettingsTestClient(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.URL.RequestURI())
+
+		if req.URL.Query().Get("nextPageKey") == "" {
+			_, _ = rw.Write([]byte(`{"values": [{"id": "a", "name": "first"}], "nextPageKey": "2"}`))
+		} else {
+			_, _ = rw.Write([]byte(`{"values": [{"id": "b", "name": "second"}], "nextPageKey": ""}`))
+		}
+	}))
+	defer closeServer()
+
+	values, err := client.List(api.NewStandardApi("anomaly-detection-metrics", "/api/config/v1/anomalyDetection/metricEvents"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(values), 2)
+	assert.DeepEqual(t, requests, []string{
+		"/api/config/v1/anomalyDetection/metricEvents?includeEntityFilterMetricEvents=true",
+		"/api/config/v1/anomalyDetection/metricEvents?nextPageKey=2",
+	})
+}
+
+func TestListFollowsLinkHeaders(t *testing.T) {
+	var requests []string
+	client, closeServer := newSettingsTestClient(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.URL.RequestURI())
+
+		if req.URL.Query().Get("page") == "" {
+			rw.Header().Add("Link", `</api/config/v1/dashboards?page=2>; rel="next", </api/config/v1/dashboards?page=2>; rel="last"`)
+			_, _ = rw.Write([]byte(`{"values": [{"id": "a", "name": "first"}]}`))
+		} else {
+			rw.Header().Add("Link", `</api/config/v1/dashboards>; rel="first"`)
+			_, _ = rw.Write([]byte(`{"values": [{"id": "b", "name": "second"}]}`))
+		}
+	}))
+	defer closeServer()
+
+	values, err := client.List(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, values, []api.Value{{Id: "a", Name: "first"}, {Id: "b", Name: "second"}})
+	assert.DeepEqual(t, requests, []string{"/api/config/v1/dashboards", "/api/config/v1/dashboards?page=2"})
+}
+
+func TestListFailsIfLaterPageFails(t *testing.T) {
+	client, closeServer := newSettingsTestClient(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("nextPageKey") == "" {
+			_, _ = rw.Write([]byte(`{"values": [{"id": "a", "name": "first"}], "nextPageKey": "2"}`))
+		} else {
+			rw.WriteHeader(http.StatusBadRequest)
+			_, _ = rw.Write([]byte(`{"error": "invalid page key"}`))
+		}
+	}))
+	defer closeServer()
+
+	_, err := client.List(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"))
+	assert.ErrorContains(t, err, "Failed to get existing configs for api dashboard (HTTP 400)")
+}
+
+func TestListFailsIfPaginationDoesNotAdvance(t *testing.T) {
+	requests := 0
+	client, closeServer := newSettingsTestClient(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		_, _ = rw.Write([]byte(fmt.Sprintf(`{"values": [{"id": "%d", "name": "value"}], "nextPageKey": "same"}`, requests)))
+	}))
+	defer closeServer()
+
+	_, err := client.List(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"))
+	assert.ErrorContains(t, err, "the pagination doesn't advance")
+	assert.Equal(t, requests, 2)
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		values   []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{`<https://example.com/api?page=2>; rel="next"`}, "https://example.com/api?page=2"},
+		{[]string{`<https://example.com/api?page=1>; rel="prev", <https://example.com/api?page=3>; rel=next`}, "https://example.com/api?page=3"},
+		{[]string{`<https://example.com/api?page=1>; rel="prev"`, `<https://example.com/api?page=3>; REL="last next"`}, "https://example.com/api?page=3"},
+		{[]string{`<https://example.com/api?page=9>; rel="last"`}, ""},
+		{[]string{`https://example.com/api?page=2; rel="next"`}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.values), func(t *testing.T) {
+			assert.Equal(t, nextLink(test.values), test.expected)
+		})
+	}
+}
+
+func TestNextPageUrlRejectsInvalidPageKeys(t *testing.T) {
+	_, err := nextPageUrl("https://example.com/api", map[string]interface{}{"nextPageKey": 2.0}, nil)
+	assert.ErrorContains(t, err, "invalid nextPageKey 2: expected a string")
+}
@@ -0,0 +1,156 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// proxyUrl is the explicitly configured proxy. If it is nil, the proxy is read from the environment variables
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+var proxyUrl *url.URL
+
+// proxyUser authenticates requests to the proxy, if it is set. It replaces credentials contained in the proxy url.
+var proxyUser *url.Userinfo
+
+// SetProxy sets the proxy used by clients created afterwards. An explicitly set proxy takes precedence over the
+// HTTPS_PROXY and HTTP_PROXY environment variables, while hosts listed in NO_PROXY are still reached directly.
+// An empty string resets the proxy to the one defined by the environment variables.
+func SetProxy(proxy string) error {
+	if proxy == "" {
+		proxyUrl = nil
+		return nil
+	}
+
+	parsed, err := url.Parse(proxy)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid proxy url '%s': the url must contain scheme and host, e.g. http://proxy.example.com:8080", proxy)
+	}
+
+	proxyUrl = parsed
+	return nil
+}
+
+// SetProxyCredentials sets the basic auth credentials of the proxy used by clients created afterwards. The transport
+// sends them in the Proxy-Authorization header, both of CONNECT requests tunneling https requests and of http requests
+// sent to the proxy. They apply to the explicitly set proxy as well as to the proxy of the environment variables, so
+// the credentials don't need to be embedded in HTTPS_PROXY. An empty user removes the credentials.
+func SetProxyCredentials(user string, password string) error {
+	if user == "" {
+		if password != "" {
+			return fmt.Errorf("invalid proxy credentials: a proxy password requires a proxy user")
+		}
+		proxyUser = nil
+		return nil
+	}
+
+	proxyUser = url.UserPassword(user, password)
+	return nil
+}
+
+// proxyFunc returns the function selecting the proxy for a request
+func proxyFunc() func(*http.Request) (*url.URL, error) {
+	selectProxy := http.ProxyFromEnvironment
+
+	if proxyUrl != nil {
+		configuredProxy := proxyUrl
+		selectProxy = func(request *http.Request) (*url.URL, error) {
+			if isExcludedFromProxy(request.URL.Host, getNoProxy()) {
+				return nil, nil
+			}
+			return configuredProxy, nil
+		}
+	}
+
+	if proxyUser == nil {
+		return selectProxy
+	}
+
+	user := proxyUser
+	return func(request *http.Request) (*url.URL, error) {
+		proxy, err := selectProxy(request)
+		if err != nil || proxy == nil {
+			return proxy, err
+		}
+
+		authenticated := *proxy
+		authenticated.User = user
+		return &authenticated, nil
+	}
+}
+
+func getNoProxy() string {
+	if value, found := os.LookupEnv("NO_PROXY"); found {
+		return value
+	}
+	return os.Getenv("no_proxy")
+}
+
+// isExcludedFromProxy checks whether host should be reached directly. Like http.ProxyFromEnvironment, requests
+// to localhost and loopback addresses are never proxied. noProxy is a comma separated list of host names,
+// domain suffixes (e.g. '.example.com'), IP addresses or CIDR ranges; '*' excludes all hosts.
+func isExcludedFromProxy(host string, noProxy string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	hostname = strings.ToLower(strings.Trim(hostname, "[]"))
+
+	if hostname == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(hostname)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+
+		if entry == "" {
+			continue
+		}
+
+		if entry == "*" {
+			return true
+		}
+
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+
+		domain := strings.TrimPrefix(entry, ".")
+		if hostname == domain || strings.HasSuffix(hostname, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -26,24 +26,27 @@ import (
 // rateLimitStrategy ensures that the concrete implementation of the rate limiting strategy can be hidden
 // behind this interface
 type rateLimitStrategy interface {
-	executeRequest(timelineProvider util.TimelineProvider, callback func() (Response, error)) (Response, error)
+	executeRequest(timelineProvider util.TimelineProvider, request *http.Request, callback func() (Response, error)) (Response, error)
 }
 
 // createRateLimitStrategy creates a rateLimitStrategy. In the future this can be extended to instantiate
 // different rate limiting strategies based on e.g. environment variables. The current implementation
 // always returns the strategy simpleSleepRateLimitStrategy, which suspends the current goroutine until
 // the time in the rate limiting header 'X-RateLimit-Reset' is up.
-func createRateLimitStrategy() rateLimitStrategy {
-	return &simpleSleepRateLimitStrategy{}
+func createRateLimitStrategy(log *util.Logger) rateLimitStrategy {
+	return &simpleSleepRateLimitStrategy{log: log}
 }
 
 // simpleSleepRateLimitStrategy, is a rate limiting strategy which suspends the current goroutine until
 // the time in the rate limiting header 'X-RateLimit-Reset' is up.
 // It has a min sleep duration of 5 seconds and a max sleep duration of one minute and performs maximal 5
-// polling iterations before giving up.
-type simpleSleepRateLimitStrategy struct{}
+// polling iterations before giving up. It stops waiting with the error of the context of the request, if the context
+// is cancelled. It logs its waits to the logger, or to the default logger if it is nil.
+type simpleSleepRateLimitStrategy struct {
+	log *util.Logger
+}
 
-func (s *simpleSleepRateLimitStrategy) executeRequest(timelineProvider util.TimelineProvider, callback func() (Response, error)) (Response, error) {
+func (s *simpleSleepRateLimitStrategy) executeRequest(timelineProvider util.TimelineProvider, request *http.Request, callback func() (Response, error)) (Response, error) {
 
 	response, err := callback()
 	if err != nil {
@@ -57,11 +60,13 @@ func (s *simpleSleepRateLimitStrategy) executeRequest(timelineProvider util.Time
 
 		limit, humanReadableTimestamp, timeInMicroseconds, err := s.extractRateLimitHeaders(response)
 		if err != nil {
-			return response, err
+			// without rate limit headers, waiting is left to the retry strategy
+			s.log.Debug("simpleSleepRateLimitStrategy: Not applicable: %s", err)
+			return response, nil
 		}
 
-		util.Log.Info("Rate limit of %d requests/min reached: Applying rate limit strategy (simpleSleepRateLimitStrategy, iteration: %d)", limit, currentIteration+1)
-		util.Log.Info("simpleSleepRateLimitStrategy: Attempting to sleep until %s", humanReadableTimestamp)
+		s.log.Info("Rate limit of %d requests/min reached: Applying rate limit strategy (simpleSleepRateLimitStrategy, iteration: %d)", limit, currentIteration+1)
+		s.log.Info("simpleSleepRateLimitStrategy: Attempting to sleep until %s", humanReadableTimestamp)
 
 		// Attention: this uses client time:
 		now := timelineProvider.Now()
@@ -71,14 +76,16 @@ func (s *simpleSleepRateLimitStrategy) executeRequest(timelineProvider util.Time
 
 		// Attention: this mixes client and server time:
 		sleepDuration := resetTime.Sub(now)
-		util.Log.Debug("simpleSleepRateLimitStrategy: Calculated sleep duration of %f seconds...", sleepDuration.Seconds())
+		s.log.Debug("simpleSleepRateLimitStrategy: Calculated sleep duration of %f seconds...", sleepDuration.Seconds())
 
 		// That's why we need plausible min/max wait time defaults:
 		sleepDuration = s.applyMinMaxDefaults(sleepDuration)
 
-		util.Log.Debug("simpleSleepRateLimitStrategy: Sleeping for %f seconds...", sleepDuration.Seconds())
-		timelineProvider.Sleep(sleepDuration)
-		util.Log.Debug("simpleSleepRateLimitStrategy: Slept for %f seconds", sleepDuration.Seconds())
+		s.log.Debug("simpleSleepRateLimitStrategy: Sleeping for %f seconds...", sleepDuration.Seconds())
+		if err := timelineProvider.Sleep(request.Context(), sleepDuration); err != nil {
+			return Response{}, err
+		}
+		s.log.Debug("simpleSleepRateLimitStrategy: Slept for %f seconds", sleepDuration.Seconds())
 
 		// Checking again:
 		currentIteration++
@@ -120,11 +127,11 @@ func (s *simpleSleepRateLimitStrategy) applyMinMaxDefaults(sleepDuration time.Du
 
 	if sleepDuration.Nanoseconds() < minWaitTimeInNanoseconds.Nanoseconds() {
 		sleepDuration = minWaitTimeInNanoseconds
-		util.Log.Debug("simpleSleepRateLimitStrategy: Reset sleep duration to %f seconds...", sleepDuration.Seconds())
+		s.log.Debug("simpleSleepRateLimitStrategy: Reset sleep duration to %f seconds...", sleepDuration.Seconds())
 	}
 	if sleepDuration.Nanoseconds() > maxWaitTimeInNanoseconds.Nanoseconds() {
 		sleepDuration = maxWaitTimeInNanoseconds
-		util.Log.Debug("simpleSleepRateLimitStrategy: Reset sleep duration to %f seconds...", sleepDuration.Seconds())
+		s.log.Debug("simpleSleepRateLimitStrategy: Reset sleep duration to %f seconds...", sleepDuration.Seconds())
 	}
 	return sleepDuration
 }
@@ -20,10 +20,12 @@
 package rest
 
 import (
+	"context"
 	"errors"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 	"github.com/golang/mock/gomock"
 	"gotest.tools/assert"
+	"net/http"
 	"strconv"
 	"testing"
 	"time"
@@ -143,9 +145,9 @@ func TestSimpleRateLimitStrategySleepsFor42Seconds(t *testing.T) {
 	}
 
 	timelineProvider.EXPECT().Now().Times(1).Return(time.Unix(0, 0)) // time travel to the 70s
-	timelineProvider.EXPECT().Sleep(42 * time.Second).Times(1)
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 42*time.Second).Times(1)
 
-	response, err := rateLimitStrategy.executeRequest(timelineProvider, callback)
+	response, err := rateLimitStrategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
 
 	assert.NilError(t, err)
 	assert.Equal(t, response.StatusCode, 200)
@@ -173,9 +175,9 @@ func TestSimpleRateLimitStrategy2Iterations(t *testing.T) {
 	}
 
 	timelineProvider.EXPECT().Now().Times(2).Return(time.Unix(0, 0)) // time travel to the 70s
-	timelineProvider.EXPECT().Sleep(42 * time.Second).Times(2)
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 42*time.Second).Times(2)
 
-	response, err := rateLimitStrategy.executeRequest(timelineProvider, callback)
+	response, err := rateLimitStrategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
 
 	assert.NilError(t, err)
 	assert.Equal(t, response.StatusCode, 200)
@@ -189,6 +191,26 @@ func TestHandleEmptyResponse(t *testing.T) {
 		return Response{}, errors.New("foo Error")
 	}
 
-	_, err := rateLimitStrategy.executeRequest(timelineProvider, callback)
+	_, err := rateLimitStrategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
 	assert.ErrorContains(t, err, "foo Error")
 }
+
+func TestSimpleRateLimitStrategyStopsSleepingOnCancelledRequest(t *testing.T) {
+
+	rateLimitStrategy := simpleSleepRateLimitStrategy{}
+	invocationCount := 0
+	callback := func() (Response, error) {
+		invocationCount++
+		return Response{
+			StatusCode: 429,
+			Headers:    createTestHeaders(time.Now().Add(time.Minute).UnixNano() / 1000),
+		}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := rateLimitStrategy.executeRequest(util.NewTimelineProvider(), createTestRequest(t, http.MethodGet).WithContext(ctx), callback)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, invocationCount, 1)
+}
@@ -18,20 +18,30 @@ package rest
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
-	"runtime"
+	"strings"
 
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
 	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/version"
-	"github.com/google/uuid"
 )
 
 type Response struct {
 	StatusCode int
 	Body       []byte
 	Headers    map[string][]string
+
+	// method and url of the request, which are named by errors of the response
+	method string
+	url    string
+
+	// log is the logger of the client which received the response
+	log *util.Logger
 }
 
 // function type of put and post requests
@@ -44,20 +54,18 @@ func get(client *http.Client, url string, apiToken string) (Response, error) {
 		return Response{}, err
 	}
 
-	return executeRequest(client, req), nil
+	return executeRequest(client, req)
 }
 
 // the name delete() would collide with the built-in function
-func deleteConfig(client *http.Client, url string, apiToken string, id string) error {
+func deleteConfig(client *http.Client, url string, apiToken string, id string) (Response, error) {
 	req, err := request(http.MethodDelete, url+"/"+id, apiToken)
 
 	if err != nil {
-		return err
+		return Response{}, err
 	}
 
-	executeRequest(client, req)
-
-	return nil
+	return executeRequest(client, req)
 }
 
 func post(client *http.Client, url string, data []byte, apiToken string) (Response, error) {
@@ -67,7 +75,7 @@ func post(client *http.Client, url string, data []byte, apiToken string) (Respon
 		return Response{}, err
 	}
 
-	return executeRequest(client, req), nil
+	return executeRequest(client, req)
 }
 
 func postMultiPartFile(client *http.Client, url string, data *bytes.Buffer, contentType string, apiToken string) (Response, error) {
@@ -79,7 +87,7 @@ func postMultiPartFile(client *http.Client, url string, data *bytes.Buffer, cont
 
 	req.Header.Set("Content-type", contentType)
 
-	return executeRequest(client, req), nil
+	return executeRequest(client, req)
 }
 
 func put(client *http.Client, url string, data []byte, apiToken string) (Response, error) {
@@ -89,7 +97,7 @@ func put(client *http.Client, url string, data []byte, apiToken string) (Respons
 		return Response{}, err
 	}
 
-	return executeRequest(client, req), nil
+	return executeRequest(client, req)
 }
 
 func request(method string, url string, apiToken string) (*http.Request, error) {
@@ -97,7 +105,7 @@ func request(method string, url string, apiToken string) (*http.Request, error)
 }
 
 func requestWithBody(method string, url string, body io.Reader, apiToken string) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(requestContext, method, url, body)
 
 	if err != nil {
 		return nil, err
@@ -105,56 +113,82 @@ func requestWithBody(method string, url string, body io.Reader, apiToken string)
 
 	req.Header.Set("Authorization", "Api-Token "+apiToken)
 	req.Header.Set("Content-type", "application/json")
-	req.Header.Set("User-Agent", "Dynatrace Monitoring as Code/"+version.MonitoringAsCode+" "+(runtime.GOOS+" "+runtime.GOARCH))
+	req.Header.Set("User-Agent", userAgent())
 	return req, nil
 }
 
-func executeRequest(client *http.Client, request *http.Request) Response {
-	var requestId string
-	if util.IsRequestLoggingActive() {
-		requestId = uuid.NewString()
-		err := util.LogRequest(requestId, request)
+// requestContext is the context of all requests, see SetContext
+var requestContext = context.Background()
 
-		if err != nil {
-			util.Log.Warn("error while writing request log for id `%s`: %v", requestId, err)
-		}
+// SetContext sets the context of requests sent afterwards, e.g. the context of the command. Once it is cancelled,
+// requests stop waiting for their next attempt, while the attempts in progress are finished.
+func SetContext(ctx context.Context) {
+	requestContext = ctx
+}
+
+// customUserAgent replaces the default User-Agent of all requests, e.g. to identify the pipeline running monaco
+var customUserAgent string
+
+// SetUserAgent sets the User-Agent of requests sent afterwards, replacing the default monaco/<version>. An empty
+// string resets it to the default.
+func SetUserAgent(value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("invalid user agent '%s': must not contain line breaks", value)
 	}
 
-	rateLimitStrategy := createRateLimitStrategy()
+	customUserAgent = strings.TrimSpace(value)
+	return nil
+}
 
-	response, err := rateLimitStrategy.executeRequest(util.NewTimelineProvider(), func() (Response, error) {
-		resp, err := client.Do(request)
-		if err != nil {
-			util.Log.Error("HTTP Request failed with Error: " + err.Error())
+func userAgent() string {
+	if customUserAgent != "" {
+		return customUserAgent
+	}
+	return "monaco/" + version.MonitoringAsCode
+}
+
+func executeRequest(client *http.Client, request *http.Request) (Response, error) {
+	log := loggerOf(client)
+
+	resp, err := newRequestTransport(client).RoundTrip(request)
+	if err != nil {
+		// a rejected OAuth token request is no network error of the request
+		var apiErr *ApiError
+		if errors.As(err, &apiErr) {
+			return Response{}, apiErr
+		}
+		// neither is a wait for the next attempt, which was cancelled
+		if errors.Is(err, context.Canceled) {
 			return Response{}, err
 		}
-		defer func() {
-			err = resp.Body.Close()
-		}()
-		body, err := ioutil.ReadAll(resp.Body)
-
-		if util.IsResponseLoggingActive() {
-			err := util.LogResponse(requestId, resp)
-
-			if err != nil {
-				if requestId != "" {
-					util.Log.Warn("error while writing response log for id `%s`: %v", requestId, err)
-				} else {
-					util.Log.Warn("error while writing response log: %v", requestId, err)
-				}
-			}
+		if !isTimeout(err) {
+			log.Error("HTTP Request failed with Error: " + err.Error())
 		}
-
-		return Response{
-			StatusCode: resp.StatusCode,
-			Body:       body,
-			Headers:    resp.Header,
-		}, err
-	})
-
+		return Response{}, newNetworkError(client, request, err)
+	}
+	defer func() {
+		err = resp.Body.Close()
+	}()
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		// TODO properly handle error
-		return Response{}
+		return Response{}, newNetworkError(client, request, err)
 	}
-	return response
+
+	return Response{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		Headers:    resp.Header,
+		method:     request.Method,
+		url:        request.URL.String(),
+		log:        log,
+	}, nil
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func newNetworkError(client *http.Client, request *http.Request, err error) error {
+	return &NetworkError{Url: request.URL.String(), Method: request.Method, Err: err, timeout: client.Timeout}
 }
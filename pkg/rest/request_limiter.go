@@ -0,0 +1,116 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+// requestLimiter caps the number of requests sent to the Dynatrace API on the client side. A single limiter is
+// shared by all requests (and goroutines), so that all of them respect one global budget.
+type requestLimiter interface {
+	// wait blocks the current goroutine until the next request may be sent, or returns the error of the context if
+	// it is cancelled before
+	wait(ctx context.Context, timelineProvider util.TimelineProvider) error
+}
+
+// sharedRequestLimiter is used for all requests. By default, the number of requests is not limited.
+var sharedRequestLimiter requestLimiter = &noopRequestLimiter{}
+
+// SetMaxRequestsPerSecond limits the number of requests sent per second across all goroutines.
+// A value of 0 disables the limit. The limit is logged to log.
+func SetMaxRequestsPerSecond(requestsPerSecond float64, log *util.Logger) error {
+	if requestsPerSecond < 0 || math.IsNaN(requestsPerSecond) || math.IsInf(requestsPerSecond, 0) {
+		return fmt.Errorf("invalid number of requests per second '%v': must be a positive number, or 0 to disable the limit", requestsPerSecond)
+	}
+
+	if requestsPerSecond == 0 {
+		sharedRequestLimiter = &noopRequestLimiter{}
+		return nil
+	}
+
+	log.Debug("Limiting requests to %v per second", requestsPerSecond)
+	sharedRequestLimiter = newTokenBucketLimiter(requestsPerSecond)
+	return nil
+}
+
+// noopRequestLimiter does not limit requests at all
+type noopRequestLimiter struct{}
+
+func (n *noopRequestLimiter) wait(context.Context, util.TimelineProvider) error {
+	return nil
+}
+
+// tokenBucketLimiter is a token bucket which is refilled at a fixed rate of tokens per second. Every request
+// takes one token. If no token is left, a request reserves the next one and waits until it becomes available.
+// The bucket holds a single token, so requests are spread evenly instead of being sent in bursts.
+type tokenBucketLimiter struct {
+	mutex sync.Mutex
+
+	// rate is the number of tokens added per second
+	rate float64
+
+	// tokens may become negative if more requests are waiting than tokens are available
+	tokens     float64
+	capacity   float64
+	lastRefill time.Time
+}
+
+func newTokenBucketLimiter(requestsPerSecond float64) *tokenBucketLimiter {
+	return &tokenBucketLimiter{
+		rate:     requestsPerSecond,
+		tokens:   1,
+		capacity: 1,
+	}
+}
+
+func (l *tokenBucketLimiter) wait(ctx context.Context, timelineProvider util.TimelineProvider) error {
+	delay := l.reserve(timelineProvider.Now())
+	if delay > 0 {
+		return timelineProvider.Sleep(ctx, delay)
+	}
+	return nil
+}
+
+// reserve takes a token and returns how long the caller has to wait until the token is available
+func (l *tokenBucketLimiter) reserve(now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.lastRefill.IsZero() {
+		l.lastRefill = now
+	}
+
+	// concurrent callers may pass a slightly older time, which must not remove tokens
+	if elapsed := now.Sub(l.lastRefill); elapsed > 0 {
+		l.tokens = math.Min(l.capacity, l.tokens+elapsed.Seconds()*l.rate)
+		l.lastRefill = now
+	}
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
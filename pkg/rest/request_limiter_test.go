@@ -0,0 +1,116 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+var limiterStart = time.Date(2022, 5, 4, 13, 37, 0, 0, time.UTC)
+
+func TestTokenBucketLimiterSpreadsRequestsEvenly(t *testing.T) {
+
+	limiter := newTokenBucketLimiter(2)
+
+	assert.Equal(t, limiter.reserve(limiterStart), time.Duration(0))
+	assert.Equal(t, limiter.reserve(limiterStart), 500*time.Millisecond)
+	assert.Equal(t, limiter.reserve(limiterStart), 1*time.Second)
+}
+
+func TestTokenBucketLimiterRefillsOverTime(t *testing.T) {
+
+	limiter := newTokenBucketLimiter(2)
+
+	assert.Equal(t, limiter.reserve(limiterStart), time.Duration(0))
+	assert.Equal(t, limiter.reserve(limiterStart.Add(500*time.Millisecond)), time.Duration(0))
+	assert.Equal(t, limiter.reserve(limiterStart.Add(750*time.Millisecond)), 250*time.Millisecond)
+}
+
+func TestTokenBucketLimiterDoesNotAccumulateTokensWhenIdle(t *testing.T) {
+
+	limiter := newTokenBucketLimiter(1)
+
+	assert.Equal(t, limiter.reserve(limiterStart), time.Duration(0))
+	assert.Equal(t, limiter.reserve(limiterStart.Add(time.Hour)), time.Duration(0))
+	assert.Equal(t, limiter.reserve(limiterStart.Add(time.Hour)), 1*time.Second)
+}
+
+func TestTokenBucketLimiterWaitSleepsForReservedDuration(t *testing.T) {
+
+	limiter := newTokenBucketLimiter(4)
+	timelineProvider := createTimelineProviderMock(t)
+
+	timelineProvider.EXPECT().Now().Times(2).Return(limiterStart)
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 250*time.Millisecond).Times(1)
+
+	assert.NilError(t, limiter.wait(context.Background(), timelineProvider))
+	assert.NilError(t, limiter.wait(context.Background(), timelineProvider))
+}
+
+func TestTokenBucketLimiterIsSharedAcrossGoroutines(t *testing.T) {
+
+	limiter := newTokenBucketLimiter(10)
+
+	delays := make([]time.Duration, 0)
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			delay := limiter.reserve(limiterStart)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			delays = append(delays, delay)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+	for i, delay := range delays {
+		expected := time.Duration(i) * 100 * time.Millisecond
+		assert.Check(t, math.Abs(float64(delay-expected)) < float64(time.Millisecond), "request %d: expected %s, got %s", i, expected, delay)
+	}
+}
+
+func TestSetMaxRequestsPerSecond(t *testing.T) {
+	defer func(original requestLimiter) { sharedRequestLimiter = original }(sharedRequestLimiter)
+
+	assert.NilError(t, SetMaxRequestsPerSecond(5, util.DefaultLogger()))
+	_, isTokenBucket := sharedRequestLimiter.(*tokenBucketLimiter)
+	assert.Check(t, isTokenBucket)
+
+	assert.NilError(t, SetMaxRequestsPerSecond(0, util.DefaultLogger()))
+	_, isNoop := sharedRequestLimiter.(*noopRequestLimiter)
+	assert.Check(t, isNoop)
+
+	assert.ErrorContains(t, SetMaxRequestsPerSecond(-1, util.DefaultLogger()), "invalid number of requests per second")
+}
@@ -0,0 +1,133 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/version"
+	"gotest.tools/assert"
+)
+
+func TestRequestTimeoutProducesActionableError(t *testing.T) {
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+
+	_, err := put(client, server.URL+"/api/config/v1/dashboards/id", []byte(`{"name": "test"}`), "token")
+
+	assert.ErrorContains(t, err, "PUT "+server.URL+"/api/config/v1/dashboards/id timed out after 50ms")
+	assert.ErrorContains(t, err, "--http-timeout")
+}
+
+func TestRequestErrorsArePropagated(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	_, err := get(server.Client(), url, "token")
+
+	assert.Check(t, err != nil)
+}
+
+func TestRequestAndResponseLogsShareRequestId(t *testing.T) {
+	logDir := t.TempDir()
+	util.SetEnv(t, "MONACO_LOG_DIR", logDir)
+	util.SetEnv(t, "MONACO_REQUEST_LOG", filepath.Join(logDir, "requests.log"))
+	util.SetEnv(t, "MONACO_RESPONSE_LOG", filepath.Join(logDir, "responses.log"))
+	defer func() {
+		util.UnsetEnv(t, "MONACO_LOG_DIR")
+		util.UnsetEnv(t, "MONACO_REQUEST_LOG")
+		util.UnsetEnv(t, "MONACO_RESPONSE_LOG")
+	}()
+
+	previous := util.Log
+	defer func() { util.Log = previous }()
+	assert.NilError(t, util.SetupLogging(false, false, true, false))
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusCreated)
+		_, _ = rw.Write([]byte(`{"id": "created-id"}`))
+	}))
+	defer server.Close()
+
+	_, err := post(server.Client(), server.URL+"/api/config/v1/dashboards", []byte(`{"name": "test"}`), "token")
+	assert.NilError(t, err)
+	util.CloseLogging()
+
+	requests, err := ioutil.ReadFile(filepath.Join(logDir, "requests.log"))
+	assert.NilError(t, err)
+	responses, err := ioutil.ReadFile(filepath.Join(logDir, "responses.log"))
+	assert.NilError(t, err)
+
+	requestId := regexp.MustCompile(`^Request-ID: (\S+)\n`).FindStringSubmatch(string(requests))
+	assert.Assert(t, requestId != nil, string(requests))
+
+	assert.Check(t, strings.HasPrefix(string(responses), "Request-ID: "+requestId[1]+"\nStatus: 201\nDuration: "), string(responses))
+	assert.Check(t, strings.Contains(string(responses), `{"id": "created-id"}`), string(responses))
+}
+
+func TestRequestsContainUserAgent(t *testing.T) {
+	var agent string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		agent = req.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	_, err := get(server.Client(), server.URL, "token")
+	assert.NilError(t, err)
+	assert.Equal(t, agent, "monaco/"+version.MonitoringAsCode)
+
+	assert.NilError(t, SetUserAgent("monaco/"+version.MonitoringAsCode+" pipeline/my-pipeline"))
+	defer SetUserAgent("")
+
+	_, err = get(server.Client(), server.URL, "token")
+	assert.NilError(t, err)
+	assert.Equal(t, agent, "monaco/"+version.MonitoringAsCode+" pipeline/my-pipeline")
+
+	assert.NilError(t, SetUserAgent("release-pipeline"))
+
+	_, err = get(server.Client(), server.URL, "token")
+	assert.NilError(t, err)
+	assert.Equal(t, agent, "release-pipeline")
+}
+
+func TestSetUserAgentRejectsLineBreaks(t *testing.T) {
+	err := SetUserAgent("pipeline\r\nX-Injected: true")
+
+	assert.ErrorContains(t, err, "must not contain line breaks")
+}
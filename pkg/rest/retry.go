@@ -0,0 +1,227 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 1 * time.Second
+	maxRetryDelay      = 1 * time.Minute
+)
+
+// retryStrategy ensures that the concrete implementation of the retry strategy for transient failures can be
+// hidden behind this interface
+type retryStrategy interface {
+	executeRequest(timelineProvider util.TimelineProvider, request *http.Request, callback func() (Response, error)) (Response, error)
+}
+
+// retryConfig contains the maximal number of attempts and the base delay configured by the environment variables.
+// They are only read once per process, so an invalid value is reported once instead of for every request.
+var retryConfig struct {
+	once        sync.Once
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// createRetryStrategy creates a retryStrategy logging its retries to the logger. The maximal number of attempts and
+// the base delay can be set using the environment variables MONACO_HTTP_MAX_ATTEMPTS and MONACO_HTTP_RETRY_BASE_DELAY
+// (e.g. "500ms", "2s"). Invalid values are reported to the logger of the first request.
+func createRetryStrategy(log *util.Logger) retryStrategy {
+	retryConfig.once.Do(func() {
+		retryConfig.maxAttempts = readMaxAttempts(log)
+		retryConfig.baseDelay = readBaseDelay(log)
+	})
+
+	return &exponentialBackoffRetryStrategy{
+		maxAttempts: retryConfig.maxAttempts,
+		baseDelay:   retryConfig.baseDelay,
+		jitter:      randomJitter,
+		log:         log,
+	}
+}
+
+// exponentialBackoffRetryStrategy retries idempotent requests which failed with a transient error (502, 503, 504)
+// as well as any request which was rejected due to rate limiting (429), as such requests have not been processed.
+// The delay between attempts doubles with each attempt and is randomized (jitter) to avoid all clients
+// retrying at the same time. If the response contains a 'Retry-After' header, its value is used instead. Once the
+// context of the request is cancelled, it stops waiting and returns the error of the context.
+type exponentialBackoffRetryStrategy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+
+	// jitter returns a random duration in the range [0, delay)
+	jitter func(delay time.Duration) time.Duration
+
+	// log receives the retries, it is the default logger if nil
+	log *util.Logger
+}
+
+func (s *exponentialBackoffRetryStrategy) executeRequest(timelineProvider util.TimelineProvider, request *http.Request, callback func() (Response, error)) (Response, error) {
+
+	response, err := callback()
+	if err != nil {
+		return Response{}, err
+	}
+
+	for attempt := 1; attempt < s.maxAttempts && shouldRetry(request.Method, response); attempt++ {
+
+		delay := s.calculateDelay(timelineProvider, attempt, response)
+
+		if response.StatusCode == http.StatusTooManyRequests {
+			s.log.Info("Rate limit reached for %s %s (attempt %d of %d): waiting %s before retrying...", request.Method, request.URL, attempt, s.maxAttempts, delay)
+		} else {
+			s.log.Warn("Request %s %s failed with HTTP %d (attempt %d of %d): retrying in %s...", request.Method, request.URL, response.StatusCode, attempt, s.maxAttempts, delay)
+		}
+		if err := timelineProvider.Sleep(request.Context(), delay); err != nil {
+			return Response{}, err
+		}
+
+		response, err = callback()
+		if err != nil {
+			return Response{}, err
+		}
+	}
+
+	return response, nil
+}
+
+// calculateDelay returns the duration to wait before the next attempt. A 'Retry-After' header takes precedence
+// over the exponential backoff.
+func (s *exponentialBackoffRetryStrategy) calculateDelay(timelineProvider util.TimelineProvider, attempt int, response Response) time.Duration {
+
+	if retryAfter, found := parseRetryAfter(s.log, timelineProvider, response); found {
+		return capDelay(retryAfter)
+	}
+
+	delay := s.baseDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = capDelay(delay)
+
+	// equal jitter: wait at least half of the delay
+	return delay/2 + s.jitter(delay/2)
+}
+
+func capDelay(delay time.Duration) time.Duration {
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// parseRetryAfter reads the 'Retry-After' header, which contains either a number of seconds or an HTTP-date
+// (e.g. "Wed, 21 Oct 2015 07:28:00 GMT") after which the request should be retried
+func parseRetryAfter(log *util.Logger, timelineProvider util.TimelineProvider, response Response) (time.Duration, bool) {
+	values := response.Headers["Retry-After"]
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(values[0])
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		// Attention: this mixes client and server time, that's why the result is capped afterwards
+		return date.Sub(timelineProvider.Now()), true
+	}
+
+	log.Debug("Ignoring invalid Retry-After header '%s'", value)
+	return 0, false
+}
+
+func shouldRetry(method string, response Response) bool {
+	if response.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return isIdempotent(method) && isTransientFailure(response)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTransientFailure(response Response) bool {
+	switch response.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func randomJitter(delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+func readMaxAttempts(log *util.Logger) int {
+	value, found := os.LookupEnv("MONACO_HTTP_MAX_ATTEMPTS")
+	if !found {
+		return defaultMaxAttempts
+	}
+
+	maxAttempts, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || maxAttempts < 1 {
+		log.Warn("Invalid value '%s' for MONACO_HTTP_MAX_ATTEMPTS, using default of %d attempts", value, defaultMaxAttempts)
+		return defaultMaxAttempts
+	}
+
+	return maxAttempts
+}
+
+func readBaseDelay(log *util.Logger) time.Duration {
+	value, found := os.LookupEnv("MONACO_HTTP_RETRY_BASE_DELAY")
+	if !found {
+		return defaultBaseDelay
+	}
+
+	baseDelay, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || baseDelay < 0 {
+		log.Warn("Invalid value '%s' for MONACO_HTTP_RETRY_BASE_DELAY, using default of %s", value, defaultBaseDelay)
+		return defaultBaseDelay
+	}
+
+	return baseDelay
+}
@@ -0,0 +1,279 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func noJitter(time.Duration) time.Duration {
+	return 0
+}
+
+func createTestRequest(t *testing.T, method string) *http.Request {
+	request, err := http.NewRequest(method, "https://my-environment.live.dynatrace.com/api/config/v1/dashboards", nil)
+	assert.NilError(t, err)
+	return request
+}
+
+func createCallbackReturning(statusCodes ...int) (callback func() (Response, error), invocations *int) {
+	invocations = new(int)
+	callback = func() (Response, error) {
+		statusCode := statusCodes[*invocations]
+		*invocations++
+		return Response{StatusCode: statusCode}, nil
+	}
+	return callback, invocations
+}
+
+func TestRetryStrategyRetriesTransientFailuresWithExponentialBackoff(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 4, baseDelay: 2 * time.Second, jitter: noJitter}
+	timelineProvider := createTimelineProviderMock(t)
+	callback, invocations := createCallbackReturning(503, 502, 504, 200)
+
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 1*time.Second).Times(1)
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 2*time.Second).Times(1)
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 4*time.Second).Times(1)
+
+	response, err := strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
+
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, 200)
+	assert.Equal(t, *invocations, 4)
+}
+
+func TestRetryStrategyLogsRetriesToLogger(t *testing.T) {
+	var out bytes.Buffer
+	log, err := util.NewLogger(afero.NewMemMapFs(), util.LoggerOptions{Writer: &out})
+	assert.NilError(t, err)
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 2, baseDelay: 2 * time.Second, jitter: noJitter, log: log}
+	timelineProvider := createTimelineProviderMock(t)
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 1*time.Second).Times(1)
+	callback, _ := createCallbackReturning(503, 200)
+
+	_, err = strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(out.String(), "failed with HTTP 503 (attempt 1 of 2)"), out.String())
+}
+
+func TestRetryStrategyStopsAfterMaxAttempts(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 2, baseDelay: 2 * time.Second, jitter: noJitter}
+	timelineProvider := createTimelineProviderMock(t)
+	callback, invocations := createCallbackReturning(503, 503, 200)
+
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 1*time.Second).Times(1)
+
+	response, err := strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodPut), callback)
+
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, 503)
+	assert.Equal(t, *invocations, 2)
+}
+
+func TestRetryStrategyDoesNotRetryNonIdempotentRequests(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 3, baseDelay: time.Second, jitter: noJitter}
+	timelineProvider := createTimelineProviderMock(t)
+	callback, invocations := createCallbackReturning(503, 200)
+
+	response, err := strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodPost), callback)
+
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, 503)
+	assert.Equal(t, *invocations, 1)
+}
+
+func TestRetryStrategyDoesNotRetryOtherFailures(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 3, baseDelay: time.Second, jitter: noJitter}
+	timelineProvider := createTimelineProviderMock(t)
+	callback, invocations := createCallbackReturning(400, 200)
+
+	response, err := strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
+
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, 400)
+	assert.Equal(t, *invocations, 1)
+}
+
+func TestRetryStrategyHonorsRetryAfterHeader(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 3, baseDelay: time.Second, jitter: noJitter}
+	timelineProvider := createTimelineProviderMock(t)
+	invocations := 0
+	callback := func() (Response, error) {
+		invocations++
+		if invocations == 1 {
+			return Response{StatusCode: 429, Headers: map[string][]string{"Retry-After": {"7"}}}, nil
+		}
+		return Response{StatusCode: 200}, nil
+	}
+
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 7*time.Second).Times(1)
+
+	response, err := strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
+
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, 200)
+}
+
+func TestRetryStrategyHonorsRetryAfterDate(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 3, baseDelay: time.Second, jitter: noJitter}
+	timelineProvider := createTimelineProviderMock(t)
+	invocations := 0
+	callback := func() (Response, error) {
+		invocations++
+		if invocations == 1 {
+			return Response{StatusCode: 429, Headers: map[string][]string{"Retry-After": {"Wed, 21 Oct 2015 07:28:30 GMT"}}}, nil
+		}
+		return Response{StatusCode: 200}, nil
+	}
+
+	timelineProvider.EXPECT().Now().Times(1).Return(time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC))
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 30*time.Second).Times(1)
+
+	response, err := strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
+
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, 200)
+}
+
+func TestRetryStrategyRetriesRateLimitedNonIdempotentRequests(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 3, baseDelay: 2 * time.Second, jitter: noJitter}
+	timelineProvider := createTimelineProviderMock(t)
+	callback, invocations := createCallbackReturning(429, 201)
+
+	timelineProvider.EXPECT().Sleep(gomock.Any(), 1*time.Second).Times(1)
+
+	response, err := strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodPost), callback)
+
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, 201)
+	assert.Equal(t, *invocations, 2)
+}
+
+func TestParseRetryAfterIgnoresInvalidValues(t *testing.T) {
+
+	for _, value := range []string{"", "-5", "soon", "Wed, 21 Oct"} {
+		_, found := parseRetryAfter(nil, createTimelineProviderMock(t), Response{Headers: map[string][]string{"Retry-After": {value}}})
+		assert.Check(t, !found, value)
+	}
+}
+
+func TestRetryStrategyReturnsCallbackError(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 3, baseDelay: time.Second, jitter: noJitter}
+	timelineProvider := createTimelineProviderMock(t)
+	callback := func() (Response, error) {
+		return Response{}, errors.New("foo Error")
+	}
+
+	_, err := strategy.executeRequest(timelineProvider, createTestRequest(t, http.MethodGet), callback)
+	assert.ErrorContains(t, err, "foo Error")
+}
+
+func TestRetryStrategyStopsWaitingOnCancelledRequest(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 3, baseDelay: time.Minute, jitter: noJitter}
+	callback, invocations := createCallbackReturning(503, 200)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := strategy.executeRequest(util.NewTimelineProvider(), createTestRequest(t, http.MethodGet).WithContext(ctx), callback)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, *invocations, 1)
+}
+
+func TestRetryDelayIsCappedAndJittered(t *testing.T) {
+
+	strategy := exponentialBackoffRetryStrategy{maxAttempts: 100, baseDelay: time.Second, jitter: randomJitter}
+
+	for attempt := 1; attempt < 100; attempt++ {
+		delay := strategy.calculateDelay(createTimelineProviderMock(t), attempt, Response{})
+		assert.Check(t, delay <= maxRetryDelay)
+		assert.Check(t, delay > 0)
+	}
+}
+
+// resetRetryConfig reads the retry configuration of the environment again for the next request
+func resetRetryConfig(t *testing.T) {
+	retryConfig.once = sync.Once{}
+	t.Cleanup(func() { retryConfig.once = sync.Once{} })
+}
+
+func TestRetryConfigIsReadOnce(t *testing.T) {
+	resetRetryConfig(t)
+	util.SetEnv(t, "MONACO_HTTP_MAX_ATTEMPTS", "5")
+
+	strategy := createRetryStrategy(nil).(*exponentialBackoffRetryStrategy)
+	assert.Equal(t, strategy.maxAttempts, 5)
+
+	util.SetEnv(t, "MONACO_HTTP_MAX_ATTEMPTS", "invalid")
+
+	strategy = createRetryStrategy(nil).(*exponentialBackoffRetryStrategy)
+	assert.Equal(t, strategy.maxAttempts, 5)
+}
+
+func TestExecuteRequestResendsBodyOnRetry(t *testing.T) {
+
+	os.Setenv("MONACO_HTTP_RETRY_BASE_DELAY", "1ms")
+	defer os.Unsetenv("MONACO_HTTP_RETRY_BASE_DELAY")
+	resetRetryConfig(t)
+
+	receivedBodies := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		receivedBodies = append(receivedBodies, string(body))
+
+		if len(receivedBodies) == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	response, err := put(server.Client(), server.URL, []byte(`{"name": "test"}`), "token")
+
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, http.StatusOK)
+	assert.DeepEqual(t, receivedBodies, []string{`{"name": "test"}`, `{"name": "test"}`})
+}
@@ -0,0 +1,105 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+
+	. "github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+)
+
+// MissingScopeError is returned if Dynatrace denies access to an api (HTTP 403), which usually means that the token
+// is missing a scope required for the api
+type MissingScopeError struct {
+	Api    string
+	Scopes []TokenScope
+
+	// response is the error of the denied request
+	response *ApiError
+}
+
+// newMissingScopeError creates the error for the denied access to the api. The response is only logged in debug
+// mode, as it rarely contains more information than the status code.
+func newMissingScopeError(theApi Api, resp Response) error {
+	resp.log.Debug("\t\t\tAccess to %s denied (HTTP %d), response was: %s", theApi.GetId(), resp.StatusCode, string(resp.Body))
+
+	scopeErr := &MissingScopeError{
+		Api:    theApi.GetId(),
+		Scopes: GetTokenScopes(theApi.GetId()),
+	}
+	scopeErr.response = newApiError(resp, "%s", scopeErr.Error())
+	return scopeErr
+}
+
+func (e *MissingScopeError) Error() string {
+	return fmt.Sprintf("access to %s denied (HTTP 403), the token is likely missing one of the scopes %s", e.Api, JoinTokenScopes(e.Scopes))
+}
+
+// Unwrap returns the ApiError of the denied request, if the error was returned for a response
+func (e *MissingScopeError) Unwrap() error {
+	if e.response == nil {
+		return nil
+	}
+	return e.response
+}
+
+// JoinTokenScopes joins the scopes to a comma separated list
+func JoinTokenScopes(scopes []TokenScope) string {
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		names = append(names, scope.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// MissingScopes returns the scopes likely missing for each api access was denied to by any of the errors. Errors not
+// caused by denied access are ignored.
+func MissingScopes(errs []error) map[string][]TokenScope {
+	missing := make(map[string][]TokenScope)
+	for _, err := range errs {
+		var scopeErr *MissingScopeError
+		if errors.As(err, &scopeErr) {
+			missing[scopeErr.Api] = scopeErr.Scopes
+		}
+	}
+	return missing
+}
+
+// PrintMissingScopes logs the scopes likely missing in the token of the environment, aggregated over all errors, so
+// that the token can be fixed at once
+func PrintMissingScopes(log *util.Logger, environment string, errs []error) {
+	missing := MissingScopes(errs)
+	if len(missing) == 0 {
+		return
+	}
+
+	apis := make([]string, 0, len(missing))
+	for theApi := range missing {
+		apis = append(apis, theApi)
+	}
+	sort.Strings(apis)
+
+	log.Error("The token of environment %s was denied access to %d api(s). It likely requires the following scopes:", environment, len(apis))
+	for _, theApi := range apis {
+		log.Error("\t%s: %s", theApi, JoinTokenScopes(missing[theApi]))
+	}
+}
@@ -0,0 +1,62 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"gotest.tools/assert"
+)
+
+func TestListingReportsMissingScopeOnDeniedAccess(t *testing.T) {
+	sloApi := api.NewStandardApi("slo", "/api/v2/slo")
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusForbidden)
+		_, _ = rw.Write([]byte(`{"error": {"code": 403, "message": "Token is missing required scope"}}`))
+	}))
+	defer server.Close()
+
+	_, err := getExistingValuesFromEndpoint(server.Client(), sloApi, server.URL+"/api/v2/slo", "token")
+
+	var scopeErr *MissingScopeError
+	assert.Assert(t, errors.As(err, &scopeErr))
+	assert.Error(t, err, "access to slo denied (HTTP 403), the token is likely missing one of the scopes slo.read (Read SLO), slo.write (Write SLOs)")
+}
+
+func TestMissingScopesAggregatesDeniedApis(t *testing.T) {
+	errs := []error{
+		fmt.Errorf("upload failed: %w", &MissingScopeError{Api: "dashboard", Scopes: api.GetTokenScopes("dashboard")}),
+		errors.New("some other error"),
+		&MissingScopeError{Api: "slo", Scopes: api.GetTokenScopes("slo")},
+		&MissingScopeError{Api: "dashboard", Scopes: api.GetTokenScopes("dashboard")},
+	}
+
+	missing := MissingScopes(errs)
+
+	assert.Equal(t, len(missing), 2)
+	assert.Equal(t, JoinTokenScopes(missing["dashboard"]), "ReadConfig (Read configuration), WriteConfig (Write configuration)")
+	assert.Equal(t, JoinTokenScopes(missing["slo"]), "slo.read (Read SLO), slo.write (Write SLOs)")
+}
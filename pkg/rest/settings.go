@@ -0,0 +1,232 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	. "github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+)
+
+// settingsSchemasPath is the endpoint listing the Settings 2.0 schemas of an environment
+const settingsSchemasPath = "/api/v2/settings/schemas"
+
+// settingsFields are the fields of settings objects requested when listing them. The value and modification info are
+// only read on download.
+const (
+	settingsFields         = "objectId,externalId,schemaId,scope,summary,value,modificationInfo"
+	settingsExistingFields = "objectId,externalId"
+)
+
+type settingsSchemasResponse struct {
+	Items []struct {
+		SchemaId string `json:"schemaId"`
+	} `json:"items"`
+}
+
+type settingsObjectsResponse struct {
+	Items       []DownloadedSettingsObject `json:"items"`
+	NextPageKey string                     `json:"nextPageKey"`
+}
+
+type settingsObjectCreate struct {
+	SchemaId   string          `json:"schemaId"`
+	Scope      string          `json:"scope"`
+	ExternalId string          `json:"externalId,omitempty"`
+	Value      json.RawMessage `json:"value"`
+}
+
+type settingsObjectUpdate struct {
+	Value json.RawMessage `json:"value"`
+}
+
+type settingsObjectResponse struct {
+	Code     int    `json:"code"`
+	ObjectId string `json:"objectId"`
+}
+
+// settingsApiError is returned by the generic methods of the client, which identify configs by their name
+func settingsApiError(api Api) error {
+	return fmt.Errorf("API %s is a settings API: its objects are identified by schemaId, scope and external id instead of a name", api.GetId())
+}
+
+func (d *dynatraceClientImpl) ListSchemas(api Api) (schemaIds []string, err error) {
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := get(client, baseUrl+settingsSchemasPath, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, newMissingScopeError(api, resp)
+	}
+
+	if !success(resp) {
+		return nil, newApiError(resp, "Failed to get settings schemas (HTTP %d)!\n    Response was: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var schemas settingsSchemasResponse
+	if err := json.Unmarshal(resp.Body, &schemas); err != nil {
+		return nil, fmt.Errorf("failed to parse settings schemas: %w", err)
+	}
+
+	for _, schema := range schemas.Items {
+		schemaIds = append(schemaIds, schema.SchemaId)
+	}
+	return schemaIds, nil
+}
+
+func (d *dynatraceClientImpl) ListSettings(api Api, schemaId string) (objects []DownloadedSettingsObject, err error) {
+	query := url.Values{"schemaIds": {schemaId}, "fields": {settingsFields}}
+	return d.listSettingsObjects(api, schemaId, query)
+}
+
+func (d *dynatraceClientImpl) ExistsSettings(api Api, object SettingsObject) (exists bool, objectId string, err error) {
+	query := url.Values{
+		"schemaIds":   {object.SchemaId},
+		"scopes":      {object.Scope},
+		"externalIds": {object.ExternalId},
+		"fields":      {settingsExistingFields},
+	}
+
+	existing, err := d.listSettingsObjects(api, object.SchemaId, query)
+	if err != nil {
+		return false, "", err
+	}
+
+	// the server only returns objects of the external id, the external id is still compared to be safe
+	for _, candidate := range existing {
+		if candidate.ExternalId == object.ExternalId {
+			return true, candidate.ObjectId, nil
+		}
+	}
+	return false, "", nil
+}
+
+func (d *dynatraceClientImpl) UpsertSettings(api Api, object SettingsObject) (entity DynatraceEntity, err error) {
+	exists, objectId, err := d.ExistsSettings(api, object)
+	if err != nil {
+		return DynatraceEntity{}, err
+	}
+
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return DynatraceEntity{}, err
+	}
+
+	fullUrl := api.GetUrlFromEnvironmentUrl(baseUrl)
+
+	if exists {
+		payload, err := json.Marshal(settingsObjectUpdate{Value: object.Value})
+		if err != nil {
+			return DynatraceEntity{}, fmt.Errorf("invalid value of settings object %s: %w", object.Name, err)
+		}
+
+		resp, err := put(client, fullUrl+"/"+url.PathEscape(objectId), payload, token)
+		if err != nil {
+			return DynatraceEntity{}, err
+		}
+		if err := checkSettingsResponse(api, resp, "update", object); err != nil {
+			return DynatraceEntity{}, err
+		}
+		return DynatraceEntity{Id: objectId, Name: object.Name, Response: resp.Body}, nil
+	}
+
+	payload, err := json.Marshal([]settingsObjectCreate{{
+		SchemaId:   object.SchemaId,
+		Scope:      object.Scope,
+		ExternalId: object.ExternalId,
+		Value:      object.Value,
+	}})
+	if err != nil {
+		return DynatraceEntity{}, fmt.Errorf("invalid value of settings object %s: %w", object.Name, err)
+	}
+
+	resp, err := post(client, fullUrl, payload, token)
+	if err != nil {
+		return DynatraceEntity{}, err
+	}
+	if err := checkSettingsResponse(api, resp, "create", object); err != nil {
+		return DynatraceEntity{}, err
+	}
+
+	// the response lists the result of every created object, the result of the single object is kept as its response,
+	// so it has the same fields as the response of an update
+	var results []json.RawMessage
+	var created settingsObjectResponse
+	if err := json.Unmarshal(resp.Body, &results); err != nil || len(results) != 1 || json.Unmarshal(results[0], &created) != nil {
+		return DynatraceEntity{}, fmt.Errorf("failed to parse response of creating settings object %s: %s", object.Name, string(resp.Body))
+	}
+	return DynatraceEntity{Id: created.ObjectId, Name: object.Name, Created: true, Response: results[0]}, nil
+}
+
+func checkSettingsResponse(api Api, resp Response, action string, object SettingsObject) error {
+	if resp.StatusCode == http.StatusForbidden {
+		return newMissingScopeError(api, resp)
+	}
+
+	if !success(resp) {
+		return newApiError(resp, "Failed to %s settings object %s of schema %s in scope %s (HTTP %d)!\n    Response was: %s",
+			action, object.Name, object.SchemaId, object.Scope, resp.StatusCode, string(resp.Body))
+	}
+	return nil
+}
+
+// listSettingsObjects lists the settings objects matching the query on all pages. Later pages are only requested
+// by their page key, as Dynatrace rejects other parameters along with it.
+func (d *dynatraceClientImpl) listSettingsObjects(api Api, schemaId string, query url.Values) (objects []DownloadedSettingsObject, err error) {
+	client, baseUrl, token, err := d.endpointFor(api)
+	if err != nil {
+		return nil, err
+	}
+
+	fullUrl := api.GetUrlFromEnvironmentUrl(baseUrl)
+	nextUrl := fullUrl + "?" + query.Encode()
+
+	for {
+		resp, err := get(client, nextUrl, token)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusForbidden {
+			return nil, newMissingScopeError(api, resp)
+		}
+
+		if !success(resp) {
+			return nil, newApiError(resp, "Failed to get settings objects of schema %s (HTTP %d)!\n    Response was: %s", schemaId, resp.StatusCode, string(resp.Body))
+		}
+
+		var page settingsObjectsResponse
+		if err := json.Unmarshal(resp.Body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse settings objects of schema %s: %w", schemaId, err)
+		}
+		objects = append(objects, page.Items...)
+
+		if page.NextPageKey == "" {
+			return objects, nil
+		}
+		nextUrl = fullUrl + "?" + url.Values{"nextPageKey": {page.NextPageKey}}.Encode()
+	}
+}
@@ -0,0 +1,263 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"gotest.tools/assert"
+)
+
+// settingsServer is a minimal Settings 2.0 API holding the objects in memory. Objects are listed with a page size of
+// one, to cover the pagination.
+type settingsServer struct {
+	mutex    sync.Mutex
+	objects  []api.DownloadedSettingsObject
+	requests []string
+}
+
+// settingsPageKey is the content of the page keys of the settingsServer
+type settingsPageKey struct {
+	SchemaId   string
+	Scope      string
+	ExternalId string
+	Position   int
+}
+
+func (s *settingsServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, req.Method+" "+req.URL.Path)
+	body, _ := ioutil.ReadAll(req.Body)
+
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/api/v2/settings/schemas":
+		_, _ = rw.Write([]byte(`{"items": [{"schemaId": "builtin:alerting.profile"}, {"schemaId": "builtin:tags.auto-tagging"}], "totalCount": 2}`))
+
+	case req.Method == http.MethodGet && req.URL.Path == "/api/v2/settings/objects":
+		query := req.URL.Query()
+		if query.Get("nextPageKey") != "" && len(query) > 1 {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		schemaId, scope, externalId, position := query.Get("schemaIds"), query.Get("scopes"), query.Get("externalIds"), 0
+		if key := query.Get("nextPageKey"); key != "" {
+			var page settingsPageKey
+			_ = json.Unmarshal([]byte(key), &page)
+			schemaId, scope, externalId, position = page.SchemaId, page.Scope, page.ExternalId, page.Position
+		}
+
+		var matching []api.DownloadedSettingsObject
+		for _, object := range s.objects {
+			if object.SchemaId == schemaId && (scope == "" || object.Scope == scope) && (externalId == "" || object.ExternalId == externalId) {
+				matching = append(matching, object)
+			}
+		}
+
+		response := settingsObjectsResponse{Items: []api.DownloadedSettingsObject{}}
+		if position < len(matching) {
+			response.Items = matching[position : position+1]
+		}
+		if position+1 < len(matching) {
+			key, _ := json.Marshal(settingsPageKey{schemaId, scope, externalId, position + 1})
+			response.NextPageKey = string(key)
+		}
+		content, _ := json.Marshal(response)
+		_, _ = rw.Write(content)
+
+	case req.Method == http.MethodPost && req.URL.Path == "/api/v2/settings/objects":
+		var created []settingsObjectCreate
+		if err := json.Unmarshal(body, &created); err != nil || len(created) != 1 {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		objectId := "object-" + strconv.Itoa(len(s.objects)+1)
+		s.objects = append(s.objects, api.DownloadedSettingsObject{
+			ObjectId:   objectId,
+			ExternalId: created[0].ExternalId,
+			SchemaId:   created[0].SchemaId,
+			Scope:      created[0].Scope,
+			Value:      created[0].Value,
+		})
+		_, _ = rw.Write([]byte(`[{"code": 200, "objectId": "` + objectId + `"}]`))
+
+	case req.Method == http.MethodPut:
+		var updated settingsObjectUpdate
+		if err := json.Unmarshal(body, &updated); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		for i, object := range s.objects {
+			if req.URL.Path == "/api/v2/settings/objects/"+object.ObjectId {
+				s.objects[i].Value = updated.Value
+				_, _ = rw.Write([]byte(`{"code": 200, "objectId": "` + object.ObjectId + `"}`))
+				return
+			}
+		}
+		rw.WriteHeader(http.StatusNotFound)
+
+	default:
+		rw.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newSettingsTestClient(handler http.Handler) (*dynatraceClientImpl, func()) {
+	server := httptest.NewTLSServer(handler)
+
+	return &dynatraceClientImpl{
+		environmentUrl: server.URL,
+		token:          "token",
+		client:         server.Client(),
+	}, server.Close
+}
+
+func TestUpsertSettingsCreatesObjectAndUpdatesItByExternalId(t *testing.T) {
+	server := &settingsServer{}
+	client, closeServer := newSettingsTestClient(server)
+	defer closeServer()
+
+	settings := api.NewApis()["settings"]
+	object := api.SettingsObject{
+		Name:       "profile",
+		SchemaId:   "builtin:alerting.profile",
+		Scope:      "environment",
+		ExternalId: "monaco:project/settings/profile",
+		Value:      []byte(`{"name": "profile"}`),
+	}
+
+	entity, err := client.UpsertSettings(settings, object)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, entity, api.DynatraceEntity{Id: "object-1", Name: "profile", Created: true, Response: []byte(`{"code": 200, "objectId": "object-1"}`)})
+
+	object.Value = []byte(`{"name": "updated profile"}`)
+	entity, err = client.UpsertSettings(settings, object)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, entity, api.DynatraceEntity{Id: "object-1", Name: "profile", Response: []byte(`{"code": 200, "objectId": "object-1"}`)})
+
+	assert.Equal(t, len(server.objects), 1)
+	assert.Equal(t, string(server.objects[0].Value), `{"name":"updated profile"}`)
+	assert.DeepEqual(t, server.requests, []string{
+		"GET /api/v2/settings/objects",
+		"POST /api/v2/settings/objects",
+		"GET /api/v2/settings/objects",
+		"PUT /api/v2/settings/objects/object-1",
+	})
+}
+
+func TestUpsertSettingsCreatesObjectIfExternalIdIsUsedInOtherScope(t *testing.T) {
+	server := &settingsServer{objects: []api.DownloadedSettingsObject{
+		{ObjectId: "existing", ExternalId: "monaco:project/settings/tags", SchemaId: "builtin:tags.auto-tagging", Scope: "HOST-1234"},
+	}}
+	client, closeServer := newSettingsTestClient(server)
+	defer closeServer()
+
+	entity, err := client.UpsertSettings(api.NewApis()["settings"], api.SettingsObject{
+		Name:       "tags",
+		SchemaId:   "builtin:tags.auto-tagging",
+		Scope:      "environment",
+		ExternalId: "monaco:project/settings/tags",
+		Value:      []byte(`{}`),
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, entity.Created)
+	assert.Equal(t, len(server.objects), 2)
+}
+
+func TestExistsSettingsFiltersObjectsByExternalId(t *testing.T) {
+	server := &settingsServer{objects: []api.DownloadedSettingsObject{
+		{ObjectId: "a", ExternalId: "monaco:project/settings/a", SchemaId: "builtin:tags.auto-tagging", Scope: "environment"},
+		{ObjectId: "b", ExternalId: "monaco:project/settings/b", SchemaId: "builtin:tags.auto-tagging", Scope: "environment"},
+		{ObjectId: "c", ExternalId: "monaco:project/settings/c", SchemaId: "builtin:tags.auto-tagging", Scope: "environment"},
+	}}
+	client, closeServer := newSettingsTestClient(server)
+	defer closeServer()
+
+	exists, objectId, err := client.ExistsSettings(api.NewApis()["settings"], api.SettingsObject{
+		SchemaId:   "builtin:tags.auto-tagging",
+		Scope:      "environment",
+		ExternalId: "monaco:project/settings/c",
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, exists)
+	assert.Equal(t, objectId, "c")
+
+	// only the object of the external id is returned, instead of every page of the schema and scope
+	assert.DeepEqual(t, server.requests, []string{"GET /api/v2/settings/objects"})
+}
+
+func TestListSettingsReadsAllPagesOfSchema(t *testing.T) {
+	server := &settingsServer{objects: []api.DownloadedSettingsObject{
+		{ObjectId: "a", SchemaId: "builtin:tags.auto-tagging", Scope: "environment", Summary: "first", Value: json.RawMessage(`{"name":"first"}`)},
+		{ObjectId: "b", SchemaId: "builtin:alerting.profile", Scope: "environment", Summary: "profile", Value: json.RawMessage(`{}`)},
+		{ObjectId: "c", SchemaId: "builtin:tags.auto-tagging", Scope: "environment", Summary: "second", Value: json.RawMessage(`{"name":"second"}`)},
+	}}
+	client, closeServer := newSettingsTestClient(server)
+	defer closeServer()
+
+	settings := api.NewApis()["settings"]
+
+	schemaIds, err := client.ListSchemas(settings)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schemaIds, []string{"builtin:alerting.profile", "builtin:tags.auto-tagging"})
+
+	objects, err := client.ListSettings(settings, "builtin:tags.auto-tagging")
+	assert.NilError(t, err)
+	assert.Equal(t, len(objects), 2)
+	assert.Equal(t, objects[0].ObjectId, "a")
+	assert.Equal(t, objects[1].ObjectId, "c")
+	assert.Equal(t, string(objects[1].Value), `{"name":"second"}`)
+}
+
+func TestSettingsRequestsDeniedAccessReturnMissingScopes(t *testing.T) {
+	client, closeServer := newSettingsTestClient(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusForbidden)
+	}))
+	defer closeServer()
+
+	_, err := client.UpsertSettings(api.NewApis()["settings"], api.SettingsObject{Name: "profile", SchemaId: "builtin:alerting.profile", Scope: "environment"})
+	assert.ErrorContains(t, err, "settings.read (Read settings), settings.write (Write settings)")
+}
+
+func TestSettingsApiIsNotAccessedByName(t *testing.T) {
+	client, err := NewDynatraceClient("https://my-environment.live.dynatrace.com", "abc")
+	assert.NilError(t, err)
+
+	settings := api.NewApis()["settings"]
+
+	_, err = client.List(settings)
+	assert.ErrorContains(t, err, "API settings is a settings API")
+
+	_, err = client.UpsertByName(settings, "profile", []byte(`{}`))
+	assert.ErrorContains(t, err, "API settings is a settings API")
+
+	err = client.DeleteByName(settings, "profile")
+	assert.ErrorContains(t, err, "API settings is a settings API")
+}
@@ -0,0 +1,107 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// rootCAs contains the certificates trusted by clients. If it is nil, the system certificate pool is used.
+var rootCAs *x509.CertPool
+
+// SetCaCertificates adds the PEM encoded certificates in caCertFile to the certificates trusted by clients created
+// afterwards. The file may contain multiple certificates, which are added to the system certificate pool.
+// An empty file name resets the trusted certificates to the system certificate pool. The added certificates are logged
+// to log.
+func SetCaCertificates(fs afero.Fs, caCertFile string, log *util.Logger) error {
+	if caCertFile == "" {
+		rootCAs = nil
+		return nil
+	}
+
+	content, err := afero.ReadFile(fs, caCertFile)
+	if err != nil {
+		return fmt.Errorf("could not read CA certificate file %s: %w", caCertFile, err)
+	}
+
+	certificates, err := parsePemCertificates(content)
+	if err != nil {
+		return fmt.Errorf("could not parse CA certificate file %s: %w", caCertFile, err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		log.Warn("Could not load the system certificate pool, only trusting certificates of %s: %s", caCertFile, err)
+		pool = x509.NewCertPool()
+	}
+
+	for _, certificate := range certificates {
+		pool.AddCert(certificate)
+	}
+
+	log.Debug("Added %d CA certificate(s) from %s", len(certificates), caCertFile)
+	rootCAs = pool
+	return nil
+}
+
+// parsePemCertificates parses all certificates in a PEM bundle. Blocks which are not certificates (e.g. keys) are
+// ignored, but at least one certificate needs to be present.
+func parsePemCertificates(content []byte) ([]*x509.Certificate, error) {
+	certificates := make([]*x509.Certificate, 0)
+
+	rest := content
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("certificate %d is invalid: %w", len(certificates)+1, err)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	return certificates, nil
+}
+
+// tlsConfig returns the TLS configuration of clients, or nil to use the default configuration
+func tlsConfig() *tls.Config {
+	if rootCAs == nil {
+		return nil
+	}
+
+	return &tls.Config{
+		RootCAs: rootCAs,
+	}
+}
@@ -0,0 +1,106 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+const testToken = "dt0c01.ABCDEFGHIJKLMNOPQRSTUVWX.ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func newTestEnvironmentWithCustomCa(t *testing.T) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			_, _ = rw.Write([]byte(`{"values": []}`))
+		case http.MethodPost:
+			rw.WriteHeader(http.StatusCreated)
+			_, _ = rw.Write([]byte(`{"id": "42", "name": "my-dashboard"}`))
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+		}
+	}))
+}
+
+func writeCertificates(t *testing.T, fs afero.Fs, file string, certificates ...*x509.Certificate) {
+	content := make([]byte, 0)
+	for _, certificate := range certificates {
+		content = append(content, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})...)
+	}
+	assert.NilError(t, afero.WriteFile(fs, file, content, 0644))
+}
+
+func TestDeploymentSucceedsWithCustomCaCertificate(t *testing.T) {
+	defer func(original *x509.CertPool) { rootCAs = original }(rootCAs)
+
+	server := newTestEnvironmentWithCustomCa(t)
+	defer server.Close()
+
+	otherServer := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer otherServer.Close()
+
+	fs := afero.NewMemMapFs()
+	writeCertificates(t, fs, "ca.pem", otherServer.Certificate(), server.Certificate())
+	assert.NilError(t, SetCaCertificates(fs, "ca.pem", util.DefaultLogger()))
+
+	client, err := NewDynatraceClient(server.URL, testToken)
+	assert.NilError(t, err)
+
+	entity, err := client.UpsertByName(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "my-dashboard", []byte(`{"name": "my-dashboard"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, entity.Id, "42")
+}
+
+func TestDeploymentFailsWithoutCustomCaCertificate(t *testing.T) {
+	defer func(original *x509.CertPool) { rootCAs = original }(rootCAs)
+
+	server := newTestEnvironmentWithCustomCa(t)
+	defer server.Close()
+
+	assert.NilError(t, SetCaCertificates(afero.NewMemMapFs(), "", util.DefaultLogger()))
+
+	client, err := NewDynatraceClient(server.URL, testToken)
+	assert.NilError(t, err)
+
+	_, err = client.UpsertByName(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "my-dashboard", []byte(`{"name": "my-dashboard"}`))
+	assert.ErrorContains(t, err, "certificate")
+}
+
+func TestSetCaCertificatesFailsForInvalidFiles(t *testing.T) {
+	defer func(original *x509.CertPool) { rootCAs = original }(rootCAs)
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "empty.pem", []byte("not a certificate"), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "broken.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}), 0644))
+
+	assert.ErrorContains(t, SetCaCertificates(fs, "missing.pem", util.DefaultLogger()), "could not read CA certificate file missing.pem")
+	assert.ErrorContains(t, SetCaCertificates(fs, "empty.pem", util.DefaultLogger()), "could not parse CA certificate file empty.pem: no PEM encoded certificate found")
+	assert.ErrorContains(t, SetCaCertificates(fs, "broken.pem", util.DefaultLogger()), "could not parse CA certificate file broken.pem: certificate 1 is invalid")
+	assert.Check(t, rootCAs == nil)
+}
@@ -0,0 +1,142 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/tracing"
+	"github.com/google/uuid"
+)
+
+// TracedClient is implemented by clients whose requests can be traced as children of the span of a context. Clients
+// wrapping another client implement it by wrapping the traced client.
+type TracedClient interface {
+
+	// WithTraceContext returns a client tracing its requests as children of the span of the context
+	WithTraceContext(ctx context.Context) DynatraceClient
+}
+
+// WithTraceContext returns a client tracing its requests as children of the span of the context. The client is
+// returned unchanged if tracing is disabled, or the client can't be traced.
+func WithTraceContext(client DynatraceClient, ctx context.Context) DynatraceClient {
+	if !tracing.Enabled() {
+		return client
+	}
+
+	if traced, ok := client.(TracedClient); ok {
+		return traced.WithTraceContext(ctx)
+	}
+	return client
+}
+
+// WithTraceContext returns a copy of the client, whose http clients trace each request attempt as client span
+func (d *dynatraceClientImpl) WithTraceContext(ctx context.Context) DynatraceClient {
+	traced := *d
+	traced.client = tracedHttpClient(d.client, ctx)
+	traced.platformClient = tracedHttpClient(d.platformClient, ctx)
+	return &traced
+}
+
+func tracedHttpClient(client *http.Client, ctx context.Context) *http.Client {
+	if client == nil {
+		return nil
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	traced := *client
+	traced.Transport = &tracingTransport{base: base, ctx: ctx}
+	return &traced
+}
+
+// tracingTransport records a client span for each request, which ends once the body of the response is closed
+type tracingTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (t *tracingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx, span := tracing.StartClient(t.ctx, "HTTP "+request.Method)
+	span.SetAttribute("http.method", request.Method)
+	span.SetAttribute("http.url", request.URL.Scheme+"://"+request.URL.Host+request.URL.Path)
+	request, id := ensureRequestId(request)
+	span.SetAttribute("monaco.request_id", id)
+
+	// the request must not be modified by a round tripper, so the trace context is set on a copy
+	traced := request.Clone(request.Context())
+	tracing.Inject(ctx, traced.Header)
+
+	start := time.Now()
+	response, err := t.base.RoundTrip(traced)
+	if err != nil {
+		span.SetAttribute("http.duration_ms", time.Since(start))
+		span.SetError(err)
+		span.End()
+		return nil, err
+	}
+
+	span.SetAttribute("http.status_code", response.StatusCode)
+	if response.StatusCode >= 400 {
+		span.SetError(fmt.Errorf("HTTP %d", response.StatusCode))
+	}
+
+	response.Body = &spanEndingBody{ReadCloser: response.Body, end: func() {
+		span.SetAttribute("http.duration_ms", time.Since(start))
+		span.End()
+	}}
+	return response, nil
+}
+
+// spanEndingBody ends the span of a request once the body of its response is closed
+type spanEndingBody struct {
+	io.ReadCloser
+	once sync.Once
+	end  func()
+}
+
+func (b *spanEndingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.end)
+	return err
+}
+
+type requestIdKey struct{}
+
+// ensureRequestId returns the request with a unique id in its context, and the id. The id is shared by the span of
+// the request and its request and response logs, so they can be correlated. A request having an id already keeps it.
+func ensureRequestId(request *http.Request) (*http.Request, string) {
+	if id := requestIdFromContext(request.Context()); id != "" {
+		return request, id
+	}
+
+	id := uuid.NewString()
+	return request.WithContext(context.WithValue(request.Context(), requestIdKey{}, id)), id
+}
+
+func requestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
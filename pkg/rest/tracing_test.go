@@ -0,0 +1,67 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/tracing"
+	"gotest.tools/assert"
+)
+
+func TestWithTraceContextRecordsClientSpansOfRequests(t *testing.T) {
+	var traceParent string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		traceParent = req.Header.Get("traceparent")
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	spans := tracing.RecordSpans(t)
+
+	ctx, parent := tracing.Start(context.Background(), "deploy config")
+	client := WithTraceContext(&dynatraceClientImpl{environmentUrl: server.URL, token: "token", client: server.Client()}, ctx)
+
+	_, _ = client.ReadById(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "some-id")
+	parent.End()
+
+	recorded := spans()
+	assert.Equal(t, len(recorded), 2)
+
+	parentSpan, requestSpan := tracing.FindSpan(t, recorded, "deploy config"), tracing.FindSpan(t, recorded, "HTTP GET")
+	assert.Equal(t, requestSpan.ParentSpanId, parentSpan.SpanId)
+	assert.Equal(t, traceParent, "00-"+requestSpan.TraceId+"-"+requestSpan.SpanId+"-01")
+	assert.Equal(t, requestSpan.StatusMessage, "HTTP 404")
+
+	assert.Equal(t, requestSpan.Attributes["http.method"], "GET")
+	assert.Equal(t, requestSpan.Attributes["http.url"], server.URL+"/api/config/v1/dashboards/some-id")
+	assert.Equal(t, requestSpan.Attributes["http.status_code"], int64(404))
+	assert.Assert(t, requestSpan.Attributes["http.duration_ms"] != nil)
+	assert.Assert(t, requestSpan.Attributes["monaco.request_id"] != "")
+}
+
+func TestWithTraceContextReturnsClientIfTracingIsDisabled(t *testing.T) {
+	client := &dynatraceClientImpl{environmentUrl: "https://example.com", token: "token", client: &http.Client{}}
+	assert.Equal(t, WithTraceContext(client, context.Background()), DynatraceClient(client))
+}
@@ -0,0 +1,145 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+)
+
+// newRequestTransport returns the middleware sending a request of the http client: each attempt of a request is
+// retried and rate limited, before it is sent using the client, whose transport logs it. Clients not logging their
+// requests, e.g. the ones injected in tests, log them to the default logger. Retries and waits are logged to the
+// logger of the client.
+func newRequestTransport(client *http.Client) http.RoundTripper {
+	log := loggerOf(client)
+	return &retryTransport{
+		base:              &clientTransport{client: loggedHttpClient(client)},
+		retryStrategy:     createRetryStrategy(log),
+		rateLimitStrategy: createRateLimitStrategy(log),
+		limiter:           sharedRequestLimiter,
+		timelineProvider:  util.NewTimelineProvider(),
+	}
+}
+
+// retryTransport sends a request again, as long as the retry and rate limit strategies decide so. The body of the
+// request is restored for each attempt, the responses of the failed attempts are closed.
+type retryTransport struct {
+	base              http.RoundTripper
+	retryStrategy     retryStrategy
+	rateLimitStrategy rateLimitStrategy
+	limiter           requestLimiter
+	timelineProvider  util.TimelineProvider
+}
+
+func (t *retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var response *http.Response
+	attempt := 0
+
+	_, err := t.retryStrategy.executeRequest(t.timelineProvider, request, func() (Response, error) {
+		return t.rateLimitStrategy.executeRequest(t.timelineProvider, request, func() (Response, error) {
+			if err := t.limiter.wait(request.Context(), t.timelineProvider); err != nil {
+				return Response{}, err
+			}
+			attempt++
+
+			if response != nil {
+				_ = response.Body.Close()
+			}
+
+			var err error
+			response, err = t.attempt(request, attempt)
+			if err != nil {
+				return Response{}, err
+			}
+			return Response{StatusCode: response.StatusCode, Headers: response.Header}, nil
+		})
+	})
+	if err != nil {
+		// a wait for the next attempt was cancelled
+		if response != nil {
+			_ = response.Body.Close()
+		}
+		return nil, err
+	}
+	return response, nil
+}
+
+// attempt sends the request once. The body of the previous attempt has already been consumed, so a copy of the
+// request with a new body is sent again.
+func (t *retryTransport) attempt(request *http.Request, attempt int) (*http.Response, error) {
+	if attempt == 1 {
+		return t.base.RoundTrip(request)
+	}
+
+	countRetry(request.Method)
+
+	if request.GetBody == nil {
+		return t.base.RoundTrip(request)
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retried := request.Clone(request.Context())
+	retried.Body = body
+	return t.base.RoundTrip(retried)
+}
+
+// clientTransport sends requests using the http client, which applies its timeout to each attempt. The duration
+// until the response was received is added to the request metrics. Attempts are not aborted if the context of the
+// request is cancelled, they are finished or time out.
+type clientTransport struct {
+	client *http.Client
+}
+
+func (t *clientTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := t.client.Do(request.WithContext(uncancelledContext{request.Context()}))
+	if err != nil {
+		return nil, err
+	}
+
+	observeRequest(request.Method, time.Since(start))
+	return response, nil
+}
+
+// uncancelledContext keeps the values of its parent, e.g. the span of the request, but neither its cancellation
+// nor its deadline
+type uncancelledContext struct {
+	parent context.Context
+}
+
+func (c uncancelledContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c uncancelledContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c uncancelledContext) Err() error {
+	return nil
+}
+
+func (c uncancelledContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
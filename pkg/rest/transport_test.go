@@ -0,0 +1,194 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/api"
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"gotest.tools/assert"
+)
+
+// roundTripperFunc is a fake transport answering requests using the function
+type roundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// newTestRetryTransport returns a retry transport sending requests to the base transport up to the given number of
+// attempts, without waiting between them
+func newTestRetryTransport(base http.RoundTripper, maxAttempts int) *retryTransport {
+	return &retryTransport{
+		base:              base,
+		retryStrategy:     &exponentialBackoffRetryStrategy{maxAttempts: maxAttempts, jitter: noJitter},
+		rateLimitStrategy: &simpleSleepRateLimitStrategy{},
+		limiter:           &noopRequestLimiter{},
+		timelineProvider:  util.NewTimelineProvider(),
+	}
+}
+
+// closeRecordingBody records whether the body of a response was closed
+type closeRecordingBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *closeRecordingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestRetryTransportResendsRequestsFailedTransiently(t *testing.T) {
+	var bodies []string
+	var responses []*closeRecordingBody
+	transport := newTestRetryTransport(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(request.Body)
+		bodies = append(bodies, string(body))
+
+		status := http.StatusServiceUnavailable
+		if len(bodies) == 3 {
+			status = http.StatusOK
+		}
+		responseBody := &closeRecordingBody{Reader: strings.NewReader("response")}
+		responses = append(responses, responseBody)
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: responseBody}, nil
+	}), 3)
+
+	request, err := http.NewRequest(http.MethodPut, "https://my-environment.live.dynatrace.com/api/config/v1/dashboards/id", strings.NewReader(`{"name": "test"}`))
+	assert.NilError(t, err)
+
+	response, err := transport.RoundTrip(request)
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, http.StatusOK)
+	assert.DeepEqual(t, bodies, []string{`{"name": "test"}`, `{"name": "test"}`, `{"name": "test"}`})
+
+	// only the response returned is left open
+	assert.Equal(t, responses[0].closed, true)
+	assert.Equal(t, responses[1].closed, true)
+	assert.Equal(t, responses[2].closed, false)
+}
+
+func TestRetryTransportDoesNotResendNonIdempotentRequests(t *testing.T) {
+	attempts := 0
+	transport := newTestRetryTransport(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}), 3)
+
+	response, err := transport.RoundTrip(createTestRequest(t, http.MethodPost))
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, http.StatusServiceUnavailable)
+	assert.Equal(t, attempts, 1)
+}
+
+func TestRetryTransportReturnsErrorsOfBase(t *testing.T) {
+	transport := newTestRetryTransport(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}), 3)
+
+	_, err := transport.RoundTrip(createTestRequest(t, http.MethodGet))
+	assert.Error(t, err, "connection refused")
+}
+
+func TestRetryTransportStopsWaitingOnCancelledRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := 0
+	responseBody := &closeRecordingBody{Reader: strings.NewReader("")}
+	transport := newTestRetryTransport(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		attempts++
+		cancel()
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}, Body: responseBody}, nil
+	}), 3)
+
+	_, err := transport.RoundTrip(createTestRequest(t, http.MethodGet).WithContext(ctx))
+	assert.Assert(t, errors.Is(err, context.Canceled), err)
+	assert.Equal(t, attempts, 1)
+	assert.Equal(t, responseBody.closed, true)
+}
+
+func TestClientTransportAppliesTimeoutOfClient(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NilError(t, err)
+	_, err = (&clientTransport{client: client}).RoundTrip(request)
+	assert.Assert(t, isTimeout(err), err)
+}
+
+func TestWithHttpClientSendsRequestsUsingInjectedClient(t *testing.T) {
+	var requests []string
+	var authorization string
+	fake := &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		requests = append(requests, request.Method+" "+request.URL.String())
+		authorization = request.Header.Get("Authorization")
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(`{"id": "id"}`))}, nil
+	})}
+
+	client, err := NewDynatraceClient("https://my-environment.live.dynatrace.com", "my-api-token")
+	assert.NilError(t, err)
+
+	response, err := WithHttpClient(client, fake).ReadById(api.NewStandardApi("dashboard", "/api/config/v1/dashboards"), "id")
+	assert.NilError(t, err)
+	assert.Equal(t, string(response), `{"id": "id"}`)
+	assert.DeepEqual(t, requests, []string{"GET https://my-environment.live.dynatrace.com/api/config/v1/dashboards/id"})
+	assert.Equal(t, authorization, "Api-Token my-api-token")
+}
+
+func TestWithHttpClientReturnsOtherClientsUnchanged(t *testing.T) {
+	client := CreateDynatraceClientMockFactory(t)
+	assert.Equal(t, WithHttpClient(client, &http.Client{}), DynatraceClient(client))
+}
+
+func TestClientTransportFinishesAttemptsOfCancelledRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	assert.NilError(t, err)
+	response, err := (&clientTransport{client: server.Client()}).RoundTrip(request)
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, http.StatusOK)
+}
@@ -47,7 +47,7 @@ func GetDynatraceVersion(client *http.Client, environmentUrl string, apiToken st
 		return Version{}, fmt.Errorf("failed to query version of Dynatrace environment: %w", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return Version{}, fmt.Errorf("failed to query version of Dynatrace environment: (HTTP %v) %v", resp.StatusCode, string(resp.Body))
+		return Version{}, newApiError(resp, "failed to query version of Dynatrace environment: (HTTP %v) %v", resp.StatusCode, string(resp.Body))
 	}
 
 	var jsonResp ApiVersionObject
@@ -0,0 +1,259 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON schema. Only the keywords needed to describe the structure of config payloads are supported:
+// type, properties, required, additionalProperties, items, enum, minLength, minItems, minimum and maximum.
+type Schema struct {
+	Type                 typeList           `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	MinLength            *int               `json:"minLength"`
+	MinItems             *int               `json:"minItems"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+}
+
+// typeList contains the allowed types. In a schema, it is either a single type or a list of types.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = list
+	return nil
+}
+
+// additional is the value of additionalProperties, which is either a boolean or a schema
+type additional struct {
+	allowed bool
+	schema  *Schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+
+	a.allowed = true
+	return json.Unmarshal(data, &a.schema)
+}
+
+// Violation is a part of a payload which doesn't match its schema
+type Violation struct {
+	// Path of the violating value, e.g. `$.tiles[2].bounds.top`
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// Parse parses a JSON schema
+func Parse(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	if err := schema.check(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+var knownTypes = map[string]struct{}{
+	"object": {}, "array": {}, "string": {}, "number": {}, "integer": {}, "boolean": {}, "null": {},
+}
+
+// check verifies that only known types are used, as an unknown type would reject every value
+func (s *Schema) check() error {
+	for _, t := range s.Type {
+		if _, known := knownTypes[t]; !known {
+			return fmt.Errorf("unknown type %s", t)
+		}
+	}
+
+	for _, property := range s.Properties {
+		if err := property.check(); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		if err := s.Items.check(); err != nil {
+			return err
+		}
+	}
+
+	if s.AdditionalProperties != nil && s.AdditionalProperties.schema != nil {
+		return s.AdditionalProperties.schema.check()
+	}
+	return nil
+}
+
+// Validate returns all violations of the schema by the value, which is the result of unmarshalling json into an
+// interface{}
+func (s *Schema) Validate(value interface{}) []Violation {
+	return s.validate(value, "$")
+}
+
+func (s *Schema) validate(value interface{}, path string) (violations []Violation) {
+	actual := typeOf(value)
+
+	if len(s.Type) > 0 && !s.allowsType(actual) {
+		return []Violation{{Path: path, Message: fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), actual)}}
+	}
+
+	if len(s.Enum) > 0 && !s.allowsValue(value) {
+		violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("expected one of %s, got %s", formatValues(s.Enum), formatValue(value))})
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		violations = append(violations, s.validateObject(v, path)...)
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("expected at least %d items, got %d", *s.MinItems, len(v))})
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				violations = append(violations, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("expected at least %d characters, got %d", *s.MinLength, len([]rune(v)))})
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("expected at least %v, got %v", *s.Minimum, v)})
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("expected at most %v, got %v", *s.Maximum, v)})
+		}
+	}
+
+	return violations
+}
+
+func (s *Schema) validateObject(object map[string]interface{}, path string) (violations []Violation) {
+	for _, required := range s.Required {
+		if _, found := object[required]; !found {
+			violations = append(violations, Violation{Path: path + "." + required, Message: "required property is missing"})
+		}
+	}
+
+	// validate properties in a stable order
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := path + "." + name
+
+		if property, defined := s.Properties[name]; defined {
+			violations = append(violations, property.validate(object[name], propertyPath)...)
+			continue
+		}
+
+		if s.AdditionalProperties == nil {
+			continue
+		}
+		if !s.AdditionalProperties.allowed {
+			violations = append(violations, Violation{Path: propertyPath, Message: "property is not allowed"})
+		} else if s.AdditionalProperties.schema != nil {
+			violations = append(violations, s.AdditionalProperties.schema.validate(object[name], propertyPath)...)
+		}
+	}
+
+	return violations
+}
+
+func (s *Schema) allowsType(actual string) bool {
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) allowsValue(value interface{}) bool {
+	for _, allowed := range s.Enum {
+		if formatValue(allowed) == formatValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON schema type of the value. Numbers without fraction are integers.
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func formatValue(value interface{}) string {
+	serialized, _ := json.Marshal(value)
+	return string(serialized)
+}
+
+func formatValues(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = formatValue(value)
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,97 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["name", "tiles"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "owner": {"type": ["string", "null"]},
+    "tiles": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "properties": {
+          "tileType": {"type": "string", "enum": ["MARKDOWN", "HEADER"]},
+          "bounds": {"type": "object", "additionalProperties": {"type": "integer", "minimum": 0}}
+        }
+      }
+    },
+    "ratio": {"type": "number", "maximum": 1}
+  }
+}`
+
+func validate(t *testing.T, payload string) []Violation {
+	schema, err := Parse([]byte(testSchema))
+	assert.NilError(t, err)
+
+	var value interface{}
+	assert.NilError(t, json.Unmarshal([]byte(payload), &value))
+
+	return schema.Validate(value)
+}
+
+func TestValidateAcceptsMatchingPayload(t *testing.T) {
+	violations := validate(t, `{"name": "Dashboard", "owner": null, "ratio": 0.5, "tiles": [{"tileType": "MARKDOWN", "bounds": {"top": 0, "left": 38}}]}`)
+	assert.Equal(t, len(violations), 0)
+}
+
+func TestValidateReportsPathAndExpectedType(t *testing.T) {
+	violations := validate(t, `{"name": 42, "ratio": 2, "other": true, "tiles": [{"tileType": "MARKDOWN"}, {"tileType": "CHART", "bounds": {"top": "0", "left": -1}}]}`)
+
+	assert.DeepEqual(t, violations, []Violation{
+		{Path: "$.name", Message: "expected string, got integer"},
+		{Path: "$.other", Message: "property is not allowed"},
+		{Path: "$.ratio", Message: "expected at most 1, got 2"},
+		{Path: "$.tiles[1].bounds.left", Message: "expected at least 0, got -1"},
+		{Path: "$.tiles[1].bounds.top", Message: "expected integer, got string"},
+		{Path: "$.tiles[1].tileType", Message: `expected one of "MARKDOWN", "HEADER", got "CHART"`},
+	})
+}
+
+func TestValidateReportsMissingAndEmptyValues(t *testing.T) {
+	violations := validate(t, `{"name": "", "tiles": []}`)
+
+	assert.DeepEqual(t, violations, []Violation{
+		{Path: "$.name", Message: "expected at least 1 characters, got 0"},
+		{Path: "$.tiles", Message: "expected at least 1 items, got 0"},
+	})
+
+	violations = validate(t, `{}`)
+	assert.DeepEqual(t, violations, []Violation{
+		{Path: "$.name", Message: "required property is missing"},
+		{Path: "$.tiles", Message: "required property is missing"},
+	})
+}
+
+func TestParseFailsOnUnknownType(t *testing.T) {
+	_, err := Parse([]byte(`{"type": "object", "properties": {"name": {"type": "text"}}}`))
+	assert.Error(t, err, "unknown type text")
+}
@@ -0,0 +1,137 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+)
+
+// bundled contains the schemas shipped with monaco, one file per api, named after the api id
+//
+//go:embed schemas/*.json
+var bundled embed.FS
+
+// Validator validates rendered config payloads against the schema of their api
+type Validator struct {
+	schemas map[string]*Schema
+	log     *util.Logger
+}
+
+// ValidationError contains all violations of the schema of the api by a payload
+type ValidationError struct {
+	Api        string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("payload does not match the schema of %s:", e.Api))
+	for _, violation := range e.Violations {
+		message.WriteString("\n\t" + violation.String())
+	}
+	return message.String()
+}
+
+// NewValidator creates a validator using the bundled schemas. If schemaDir is not empty, the schemas in it, named
+// after the api id (e.g. `dashboard.json`), are added and replace the bundled schema of the same api. The schemas used
+// are logged to log.
+func NewValidator(fs afero.Fs, schemaDir string, log *util.Logger) (*Validator, error) {
+	validator := &Validator{schemas: make(map[string]*Schema), log: log}
+
+	entries, err := bundled.ReadDir("schemas")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		data, err := bundled.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := validator.add(entry.Name(), data); err != nil {
+			return nil, err
+		}
+	}
+
+	if schemaDir == "" {
+		return validator, nil
+	}
+
+	files, err := afero.ReadDir(fs, schemaDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read schema directory %s: %w", schemaDir, err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		data, err := afero.ReadFile(fs, filepath.Join(schemaDir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := validator.add(file.Name(), data); err != nil {
+			return nil, fmt.Errorf("%w, schema directory: %s", err, schemaDir)
+		}
+		log.Debug("Using schema %s", filepath.Join(schemaDir, file.Name()))
+	}
+
+	return validator, nil
+}
+
+func (v *Validator) add(fileName string, data []byte) error {
+	schema, err := Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid schema %s: %w", fileName, err)
+	}
+
+	v.schemas[strings.TrimSuffix(fileName, filepath.Ext(fileName))] = schema
+	return nil
+}
+
+// Validate checks the payload against the schema of the api. Payloads of apis without schema are not checked, neither
+// are any payloads if the validator is nil.
+func (v *Validator) Validate(apiId string, payload []byte) error {
+	if v == nil {
+		return nil
+	}
+
+	schema, found := v.schemas[apiId]
+	if !found {
+		v.log.Debug("\t\t\tNo schema for %s, skipping schema validation", apiId)
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return err
+	}
+
+	if violations := schema.Validate(value); len(violations) > 0 {
+		return &ValidationError{Api: apiId, Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestBundledSchemasAreValid(t *testing.T) {
+	validator, err := NewValidator(afero.NewMemMapFs(), "", util.DefaultLogger())
+	assert.NilError(t, err)
+
+	for _, apiId := range []string{"alerting-profile", "auto-tag", "dashboard", "maintenance-window", "management-zone", "notification", "slo", "synthetic-monitor"} {
+		_, found := validator.schemas[apiId]
+		assert.Assert(t, found, "no bundled schema for %s", apiId)
+	}
+}
+
+func TestValidatorReportsAllViolations(t *testing.T) {
+	validator, err := NewValidator(afero.NewMemMapFs(), "", util.DefaultLogger())
+	assert.NilError(t, err)
+
+	err = validator.Validate("dashboard", []byte(`{"dashboardMetadata": {"name": "Overview", "shared": "true"}, "tiles": [{"name": "Markdown"}]}`))
+	assert.Error(t, err, "payload does not match the schema of dashboard:"+
+		"\n\t$.dashboardMetadata.shared: expected boolean, got string"+
+		"\n\t$.tiles[0].tileType: required property is missing")
+}
+
+func TestValidatorSkipsApisWithoutSchema(t *testing.T) {
+	validator, err := NewValidator(afero.NewMemMapFs(), "", util.DefaultLogger())
+	assert.NilError(t, err)
+
+	assert.NilError(t, validator.Validate("hosts-auto-update", []byte(`{"updateWindows": 42}`)))
+
+	var disabled *Validator
+	assert.NilError(t, disabled.Validate("dashboard", []byte(`{}`)))
+}
+
+func TestValidatorUsesSchemasOfSchemaDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "schemas/dashboard.json", []byte(`{"type": "object", "required": ["owner"]}`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "schemas/hosts-auto-update.json", []byte(`{"properties": {"updateWindows": {"type": "object"}}}`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "schemas/README.md", []byte(`# schemas`), 0644))
+
+	validator, err := NewValidator(fs, "schemas", util.DefaultLogger())
+	assert.NilError(t, err)
+
+	// replaces the bundled schema
+	err = validator.Validate("dashboard", []byte(`{"tiles": "none"}`))
+	assert.Error(t, err, "payload does not match the schema of dashboard:\n\t$.owner: required property is missing")
+
+	err = validator.Validate("hosts-auto-update", []byte(`{"updateWindows": 42}`))
+	assert.Error(t, err, "payload does not match the schema of hosts-auto-update:\n\t$.updateWindows: expected object, got integer")
+}
+
+func TestNewValidatorFailsOnInvalidSchema(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "schemas/dashboard.json", []byte(`{"type": 42}`), 0644))
+
+	_, err := NewValidator(fs, "schemas", util.DefaultLogger())
+	assert.Error(t, err, "invalid schema dashboard.json: type must be a string or a list of strings, schema directory: schemas")
+}
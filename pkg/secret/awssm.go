@@ -0,0 +1,187 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/smithy-go"
+)
+
+// awsSecretsManager is shared by all resolvers, so that every secret is only read once per run
+var awsSecretsManager = newAwsSecretsManagerProvider()
+
+// awsSecretsManagerProvider reads secrets of AWS Secrets Manager, using the default AWS credential chain.
+// A reference awssm://<name> resolves to the value of the secret <name>, awssm://<name>#<key> to the key <key> of
+// the json object stored in the secret. <name> is the name or ARN of the secret.
+//
+// The AWS configuration is loaded on first access, so users not reading secrets from AWS don't need any AWS setup.
+// Secrets are cached for the lifetime of the provider.
+type awsSecretsManagerProvider struct {
+	mutex   sync.Mutex
+	config  *aws.Config
+	secrets map[string]string
+}
+
+func newAwsSecretsManagerProvider() *awsSecretsManagerProvider {
+	return &awsSecretsManagerProvider{
+		secrets: make(map[string]string),
+	}
+}
+
+func (a *awsSecretsManagerProvider) Resolve(ref string) (string, error) {
+	name, key := splitKey(trimScheme(ref))
+	if name == "" {
+		return "", fmt.Errorf("invalid awssm reference %s: expected awssm://<name> or awssm://<name>#<key>", ref)
+	}
+
+	value, err := a.secretValue(name)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", ref, err)
+	}
+
+	if key == "" {
+		return value, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return "", fmt.Errorf("could not read %s: secret %s is not a json object, reference it without #%s to use its value", ref, name, key)
+	}
+
+	keyValue, found := values[key]
+	if !found {
+		return "", NewNotFoundError("could not read %s: key %s not found in secret %s, available keys: %s", ref, key, name, strings.Join(sortedKeys(values), ", "))
+	}
+
+	stringValue, ok := keyValue.(string)
+	if !ok || stringValue == "" {
+		return "", fmt.Errorf("could not read %s: key %s of secret %s is not a non-empty string", ref, key, name)
+	}
+	return stringValue, nil
+}
+
+// secretValue returns the string value of the secret, reading it from AWS Secrets Manager on first access
+func (a *awsSecretsManagerProvider) secretValue(name string) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if value, found := a.secrets[name]; found {
+		return value, nil
+	}
+
+	ctx, cancel := lookupContext()
+	defer cancel()
+
+	if a.config == nil {
+		// the credentials and region are resolved like by the AWS CLI
+		awsConfig, err := config.LoadDefaultConfig(ctx, config.WithHTTPClient(awsHttpClient(newHttpClient())))
+		if err != nil {
+			return "", fmt.Errorf("could not load AWS configuration: %w", err)
+		}
+		a.config = &awsConfig
+	}
+
+	value, err := a.getSecretValue(ctx, name, *a.config)
+	if err != nil {
+		return "", err
+	}
+
+	a.secrets[name] = value
+	return value, nil
+}
+
+func (a *awsSecretsManagerProvider) getSecretValue(ctx context.Context, name string, awsConfig aws.Config) (string, error) {
+	region := awsRegion(name, awsConfig.Region)
+	if region == "" {
+		return "", fmt.Errorf("no AWS region configured, set AWS_REGION to the region of the secret")
+	}
+
+	client := secretsmanager.NewFromConfig(awsConfig, func(options *secretsmanager.Options) {
+		options.Region = region
+		if endpoint := os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER"); endpoint != "" {
+			options.EndpointResolver = secretsmanager.EndpointResolverFromURL(endpoint)
+		}
+	})
+
+	output, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	if err != nil {
+		var apiErr smithy.APIError
+		if !errors.As(err, &apiErr) {
+			return "", fmt.Errorf("AWS Secrets Manager in region %s is not reachable: %w", region, err)
+		}
+
+		switch apiErr.ErrorCode() {
+		case "ResourceNotFoundException":
+			return "", NewNotFoundError("secret %s not found in AWS Secrets Manager in region %s", name, region)
+		case "AccessDeniedException", "AccessDenied":
+			return "", fmt.Errorf("access to secret %s denied, check that the AWS credentials allow secretsmanager:GetSecretValue on it: %s", name, apiErr.ErrorMessage())
+		case "UnrecognizedClientException", "InvalidSignatureException", "ExpiredTokenException":
+			return "", fmt.Errorf("AWS rejected the credentials (%s), check that they are valid: %s", apiErr.ErrorCode(), apiErr.ErrorMessage())
+		}
+		return "", fmt.Errorf("AWS Secrets Manager responded with %s: %s", apiErr.ErrorCode(), apiErr.ErrorMessage())
+	}
+
+	if output.SecretString == nil || *output.SecretString == "" {
+		return "", fmt.Errorf("secret %s has no string value, binary secrets are not supported", name)
+	}
+	return *output.SecretString, nil
+}
+
+// awsHttpClient returns an http client of the AWS SDK using the proxy, CA certificates and timeout of the given client.
+// The SDK only accepts its own clients if a CA bundle is configured using AWS_CA_BUNDLE, as it adds the bundle to them.
+func awsHttpClient(client *http.Client) *awshttp.BuildableClient {
+	buildable := awshttp.NewBuildableClient().WithTimeout(client.Timeout)
+
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		buildable = buildable.WithTransportOptions(func(awsTransport *http.Transport) {
+			awsTransport.Proxy = transport.Proxy
+			if transport.TLSClientConfig != nil {
+				awsTransport.TLSClientConfig = transport.TLSClientConfig.Clone()
+			}
+		})
+	}
+	return buildable
+}
+
+// awsRegion returns the region of the ARN of a secret, or the configured region if the secret is referenced by name
+func awsRegion(name string, configuredRegion string) string {
+	if parts := strings.Split(name, ":"); len(parts) >= 7 && parts[0] == "arn" {
+		return parts[3]
+	}
+	return configuredRegion
+}
+
+// splitKey splits a path#key reference into its path and key
+func splitKey(reference string) (path string, key string) {
+	index := strings.LastIndex(reference, "#")
+	if index < 0 {
+		return reference, ""
+	}
+	return reference[:index], reference[index+1:]
+}
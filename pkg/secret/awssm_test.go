@@ -0,0 +1,223 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"gotest.tools/assert"
+)
+
+func setupSecretsManager(t *testing.T, handler http.HandlerFunc) func() {
+	server := httptest.NewServer(handler)
+
+	util.SetEnv(t, "AWS_ENDPOINT_URL_SECRETS_MANAGER", server.URL)
+	util.SetEnv(t, "AWS_REGION", "eu-west-1")
+	util.SetEnv(t, "AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	util.SetEnv(t, "AWS_SECRET_ACCESS_KEY", "secret-key")
+
+	return func() {
+		server.Close()
+		util.UnsetEnv(t, "AWS_ENDPOINT_URL_SECRETS_MANAGER")
+		util.UnsetEnv(t, "AWS_REGION")
+		util.UnsetEnv(t, "AWS_ACCESS_KEY_ID")
+		util.UnsetEnv(t, "AWS_SECRET_ACCESS_KEY")
+	}
+}
+
+func respondWithSecret(t *testing.T, value string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, req.Header.Get("X-Amz-Target"), "secretsmanager.GetSecretValue")
+		assert.Assert(t, strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+
+		response, _ := json.Marshal(map[string]string{"SecretString": value})
+		_, _ = rw.Write(response)
+	}
+}
+
+func respondWithError(code int, errorType string, message string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(code)
+		_, _ = rw.Write([]byte(`{"__type": "` + errorType + `", "message": "` + message + `"}`))
+	}
+}
+
+func TestResolveSecretOfSecretsManager(t *testing.T) {
+	defer setupSecretsManager(t, respondWithSecret(t, "dt0c01.abc"))()
+
+	token, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "dt0c01.abc")
+}
+
+func TestResolveKeyOfJsonSecretOfSecretsManager(t *testing.T) {
+	defer setupSecretsManager(t, respondWithSecret(t, `{"token": "dt0c01.abc", "other": "value"}`))()
+
+	token, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace#token")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "dt0c01.abc")
+}
+
+func TestResolveSecretOfSecretsManagerOnlyOnce(t *testing.T) {
+	requests := 0
+	defer setupSecretsManager(t, func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		respondWithSecret(t, `{"token": "dt0c01.abc", "other": "value"}`)(rw, req)
+	})()
+
+	provider := newAwsSecretsManagerProvider()
+
+	_, err := provider.Resolve("awssm://prod/dynatrace#token")
+	assert.NilError(t, err)
+	_, err = provider.Resolve("awssm://prod/dynatrace#other")
+	assert.NilError(t, err)
+
+	assert.Equal(t, requests, 1)
+}
+
+func TestResolveSecretOfSecretsManagerFailsOnMissingKey(t *testing.T) {
+	defer setupSecretsManager(t, respondWithSecret(t, `{"other": "value", "another": "value"}`))()
+
+	_, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace#token")
+	assert.Error(t, err, "could not read awssm://prod/dynatrace#token: key token not found in secret prod/dynatrace, available keys: another, other")
+	assert.Assert(t, errors.Is(err, ErrNotFound))
+}
+
+func TestResolveSecretOfSecretsManagerFailsOnKeyOfPlainSecret(t *testing.T) {
+	defer setupSecretsManager(t, respondWithSecret(t, "dt0c01.abc"))()
+
+	_, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace#token")
+	assert.ErrorContains(t, err, "secret prod/dynatrace is not a json object")
+}
+
+func TestResolveSecretOfSecretsManagerFailsOnMissingSecret(t *testing.T) {
+	defer setupSecretsManager(t, respondWithError(http.StatusBadRequest, "ResourceNotFoundException", "Secrets Manager can't find the specified secret."))()
+
+	_, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace")
+	assert.Error(t, err, "could not read awssm://prod/dynatrace: secret prod/dynatrace not found in AWS Secrets Manager in region eu-west-1")
+	assert.Assert(t, errors.Is(err, ErrNotFound))
+}
+
+func TestResolveSecretOfSecretsManagerFailsOnDeniedAccess(t *testing.T) {
+	defer setupSecretsManager(t, respondWithError(http.StatusBadRequest, "AccessDeniedException", "not authorized"))()
+
+	_, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace")
+	assert.ErrorContains(t, err, "access to secret prod/dynatrace denied, check that the AWS credentials allow secretsmanager:GetSecretValue on it: not authorized")
+	assert.Assert(t, !errors.Is(err, ErrNotFound))
+}
+
+func TestResolveSecretOfSecretsManagerFailsWithoutRegion(t *testing.T) {
+	defer setupSecretsManager(t, respondWithSecret(t, "dt0c01.abc"))()
+	util.UnsetEnv(t, "AWS_REGION")
+	util.UnsetEnv(t, "AWS_DEFAULT_REGION")
+
+	_, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace")
+	assert.ErrorContains(t, err, "no AWS region configured")
+}
+
+func TestResolveSecretOfSecretsManagerFailsWithoutName(t *testing.T) {
+	_, err := newAwsSecretsManagerProvider().Resolve("awssm://#token")
+	assert.Error(t, err, "invalid awssm reference awssm://#token: expected awssm://<name> or awssm://<name>#<key>")
+}
+
+func TestAwsRegionIsTakenFromArn(t *testing.T) {
+	assert.Equal(t, awsRegion("arn:aws:secretsmanager:us-east-1:123456789012:secret:dynatrace-AbCdEf", "eu-west-1"), "us-east-1")
+	assert.Equal(t, awsRegion("dynatrace", "eu-west-1"), "eu-west-1")
+}
+
+func TestResolveSecretOfSecretsManagerUsesProfileOfSharedCredentialsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials")
+	assert.NilError(t, ioutil.WriteFile(file, []byte(`
+[default]
+aws_access_key_id = default-key
+aws_secret_access_key = default-secret
+
+[ci]
+aws_access_key_id = ci-key
+aws_secret_access_key = ci-secret
+aws_session_token = ci-token
+`), 0644))
+
+	defer setupSecretsManager(t, func(rw http.ResponseWriter, req *http.Request) {
+		assert.Assert(t, strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ci-key/"))
+		assert.Equal(t, req.Header.Get("X-Amz-Security-Token"), "ci-token")
+		_, _ = rw.Write([]byte(`{"SecretString": "dt0c01.abc"}`))
+	})()
+
+	util.UnsetEnv(t, "AWS_ACCESS_KEY_ID")
+	util.UnsetEnv(t, "AWS_SECRET_ACCESS_KEY")
+	util.SetEnv(t, "AWS_SHARED_CREDENTIALS_FILE", file)
+	util.SetEnv(t, "AWS_PROFILE", "ci")
+	defer util.UnsetEnv(t, "AWS_SHARED_CREDENTIALS_FILE")
+	defer util.UnsetEnv(t, "AWS_PROFILE")
+
+	token, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "dt0c01.abc")
+}
+
+func TestResolveSecretOfSecretsManagerUsesHttpClientOfFactory(t *testing.T) {
+	defer setupSecretsManager(t, respondWithSecret(t, "dt0c01.abc"))()
+
+	proxied := false
+	defer func(previous func() *http.Client) { newHttpClient = previous }(newHttpClient)
+	SetHttpClientFactory(func() *http.Client {
+		return &http.Client{Transport: &http.Transport{Proxy: func(*http.Request) (*url.URL, error) {
+			proxied = true
+			return nil, nil
+		}}}
+	})
+
+	token, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "dt0c01.abc")
+	assert.Assert(t, proxied)
+}
+
+func TestResolveSecretOfSecretsManagerIsCancelledWithContext(t *testing.T) {
+	released := make(chan struct{})
+	defer setupSecretsManager(t, func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-released:
+		}
+	})()
+	defer close(released)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func(previous context.Context) { baseContext = previous }(baseContext)
+	SetContext(ctx)
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := newAwsSecretsManagerProvider().Resolve("awssm://prod/dynatrace")
+	assert.Assert(t, errors.Is(err, context.Canceled), "expected a cancelled lookup, got %v", err)
+}
@@ -0,0 +1,68 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// envProvider resolves env://NAME to the value of the environment variable NAME
+type envProvider struct{}
+
+func (e *envProvider) Resolve(ref string) (string, error) {
+	name := trimScheme(ref)
+	if name == "" {
+		return "", fmt.Errorf("invalid env reference %s: expected env://<name>", ref)
+	}
+
+	value := os.Getenv(name)
+	if value == "" {
+		return "", NewNotFoundError("environment variable %s not found", name)
+	}
+	return value, nil
+}
+
+// fileProvider resolves file://path to the content of the file at path, ignoring leading and trailing whitespace
+// and newlines. Relative paths are resolved against the current working directory.
+type fileProvider struct {
+	fs afero.Fs
+}
+
+func (f *fileProvider) Resolve(ref string) (string, error) {
+	path := trimScheme(ref)
+	if path == "" {
+		return "", fmt.Errorf("invalid file reference %s: expected file://<path>", ref)
+	}
+
+	content, err := afero.ReadFile(f.fs, path)
+	if os.IsNotExist(err) {
+		return "", NewNotFoundError("could not read file %s: %s", path, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read file %s: %w", path, err)
+	}
+
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return "", fmt.Errorf("file %s is empty", path)
+	}
+	return value, nil
+}
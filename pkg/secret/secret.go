@@ -0,0 +1,162 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// SecretProvider resolves references to secrets of a secret backend. A reference is a url with the scheme the
+// provider is registered for, e.g. vault://secret/data/dynatrace#token
+type SecretProvider interface {
+
+	// Resolve returns the value of the referenced secret. If the secret doesn't exist, an error wrapping
+	// ErrNotFound is returned.
+	Resolve(ref string) (string, error)
+}
+
+// ErrNotFound is wrapped by the errors of providers, if the referenced secret doesn't exist
+var ErrNotFound = errors.New("secret not found")
+
+// notFoundError is an error matching ErrNotFound, without including its message
+type notFoundError struct {
+	message string
+}
+
+// NewNotFoundError creates an error matching ErrNotFound with the given message
+func NewNotFoundError(format string, args ...interface{}) error {
+	return &notFoundError{message: fmt.Sprintf(format, args...)}
+}
+
+func (e *notFoundError) Error() string {
+	return e.message
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// defaultHttpTimeout is the timeout of requests to secret backends, unless another http client is set using
+// SetHttpClientFactory
+const defaultHttpTimeout = 30 * time.Second
+
+// newHttpClient creates the http clients of providers connecting to secret backends
+var newHttpClient = func() *http.Client {
+	return &http.Client{Timeout: defaultHttpTimeout}
+}
+
+// SetHttpClientFactory sets the function creating the http clients of providers connecting to secret backends, e.g.
+// to send their requests using the proxy, CA certificates and timeout configured for Dynatrace clients. It applies to
+// all secrets resolved afterwards.
+func SetHttpClientFactory(factory func() *http.Client) {
+	newHttpClient = factory
+}
+
+// resolveTimeout bounds the lookup of a single secret, including the retries and credential requests of the SDK of
+// the backend
+const resolveTimeout = 2 * time.Minute
+
+// baseContext is the context the lookups of secrets are derived from
+var baseContext = context.Background()
+
+// SetContext sets the context the lookups of secrets are derived from, e.g. the context of the command, so that
+// lookups in progress are cancelled together with it. It applies to all secrets resolved afterwards.
+func SetContext(ctx context.Context) {
+	baseContext = ctx
+}
+
+// lookupContext returns the context of a lookup of a secret, which is cancelled with the context set using SetContext
+// or after resolveTimeout
+func lookupContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(baseContext, resolveTimeout)
+}
+
+// Resolver dispatches references to the provider registered for the scheme of the reference
+type Resolver struct {
+	providers map[string]SecretProvider
+}
+
+// NewResolver creates a resolver with the built-in providers:
+//
+//	env://NAME ... reads the environment variable NAME
+//	file://path ... reads the file at path from the given file system
+//	vault://path#key ... reads the key of the secret at path from HashiCorp Vault
+//	awssm://name#key ... reads the secret name, or the key of its json value, from AWS Secrets Manager
+func NewResolver(fs afero.Fs) *Resolver {
+	resolver := &Resolver{providers: make(map[string]SecretProvider)}
+	resolver.Register("env", &envProvider{})
+	resolver.Register("file", &fileProvider{fs: fs})
+	resolver.Register("vault", newVaultProvider())
+	resolver.Register("awssm", awsSecretsManager)
+	return resolver
+}
+
+// Register registers the provider for the given scheme, replacing any provider registered before
+func (r *Resolver) Register(scheme string, provider SecretProvider) {
+	r.providers[strings.ToLower(scheme)] = provider
+}
+
+// IsReference returns whether the value references a secret of a registered provider
+func (r *Resolver) IsReference(value string) bool {
+	scheme, found := splitScheme(value)
+	return found && r.providers[scheme] != nil
+}
+
+// Resolve returns the value of the referenced secret, using the provider registered for the scheme of the reference
+func (r *Resolver) Resolve(ref string) (string, error) {
+	scheme, found := splitScheme(ref)
+	if !found {
+		return "", fmt.Errorf("invalid secret reference %s: expected <backend>://<path>, supported backends: %s", ref, r.supportedSchemes())
+	}
+
+	provider := r.providers[scheme]
+	if provider == nil {
+		return "", fmt.Errorf("invalid secret reference %s: unknown backend %s, supported backends: %s", ref, scheme, r.supportedSchemes())
+	}
+
+	return provider.Resolve(ref)
+}
+
+func (r *Resolver) supportedSchemes() string {
+	schemes := make([]string, 0, len(r.providers))
+	for scheme := range r.providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return strings.Join(schemes, ", ")
+}
+
+func splitScheme(value string) (scheme string, found bool) {
+	index := strings.Index(value, "://")
+	if index <= 0 {
+		return "", false
+	}
+	return strings.ToLower(value[:index]), true
+}
+
+// trimScheme returns the reference without its scheme
+func trimScheme(ref string) string {
+	return ref[strings.Index(ref, "://")+3:]
+}
@@ -0,0 +1,118 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package secret
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+// fakeProvider resolves references to the values of its secrets, by the full reference
+type fakeProvider struct {
+	secrets  map[string]string
+	resolved []string
+}
+
+func (f *fakeProvider) Resolve(ref string) (string, error) {
+	f.resolved = append(f.resolved, ref)
+
+	value, found := f.secrets[ref]
+	if !found {
+		return "", NewNotFoundError("secret %s not found", ref)
+	}
+	return value, nil
+}
+
+func TestResolverDispatchesToProviderOfScheme(t *testing.T) {
+	fake := &fakeProvider{secrets: map[string]string{"fake://token": "fake-token"}}
+
+	resolver := NewResolver(afero.NewMemMapFs())
+	resolver.Register("fake", fake)
+
+	value, err := resolver.Resolve("fake://token")
+	assert.NilError(t, err)
+	assert.Equal(t, value, "fake-token")
+
+	// schemes are case-insensitive, the provider receives the reference as is
+	_, err = resolver.Resolve("FAKE://other")
+	assert.Assert(t, errors.Is(err, ErrNotFound))
+	assert.Error(t, err, "secret FAKE://other not found")
+
+	assert.DeepEqual(t, fake.resolved, []string{"fake://token", "FAKE://other"})
+}
+
+func TestIsReference(t *testing.T) {
+	resolver := NewResolver(afero.NewMemMapFs())
+
+	assert.Equal(t, resolver.IsReference("vault://secret/data/dynatrace#token"), true)
+	assert.Equal(t, resolver.IsReference("VAULT://secret/data/dynatrace#token"), true)
+	assert.Equal(t, resolver.IsReference("env://TOKEN"), true)
+	assert.Equal(t, resolver.IsReference("file:///secrets/token"), true)
+	assert.Equal(t, resolver.IsReference("unknown://secret"), false)
+	assert.Equal(t, resolver.IsReference("/secrets/token"), false)
+	assert.Equal(t, resolver.IsReference(""), false)
+}
+
+func TestResolveFailsOnInvalidReferences(t *testing.T) {
+	resolver := NewResolver(afero.NewMemMapFs())
+
+	_, err := resolver.Resolve("/secrets/token")
+	assert.Error(t, err, "invalid secret reference /secrets/token: expected <backend>://<path>, supported backends: awssm, env, file, vault")
+
+	_, err = resolver.Resolve("unknown://secret")
+	assert.Error(t, err, "invalid secret reference unknown://secret: unknown backend unknown, supported backends: awssm, env, file, vault")
+}
+
+func TestResolveEnvironmentVariable(t *testing.T) {
+	resolver := NewResolver(afero.NewMemMapFs())
+
+	util.SetEnv(t, "SECRET_TEST_TOKEN", "env-token")
+	value, err := resolver.Resolve("env://SECRET_TEST_TOKEN")
+	util.UnsetEnv(t, "SECRET_TEST_TOKEN")
+
+	assert.NilError(t, err)
+	assert.Equal(t, value, "env-token")
+
+	_, err = resolver.Resolve("env://SECRET_TEST_TOKEN")
+	assert.Assert(t, errors.Is(err, ErrNotFound))
+	assert.Error(t, err, "environment variable SECRET_TEST_TOKEN not found")
+}
+
+func TestResolveFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "/secrets/token", []byte("file-token\n"), 0600))
+	assert.NilError(t, afero.WriteFile(fs, "/secrets/empty", []byte("\n"), 0600))
+
+	resolver := NewResolver(fs)
+
+	value, err := resolver.Resolve("file:///secrets/token")
+	assert.NilError(t, err)
+	assert.Equal(t, value, "file-token")
+
+	_, err = resolver.Resolve("file:///secrets/empty")
+	assert.Error(t, err, "file /secrets/empty is empty")
+
+	_, err = resolver.Resolve("file:///secrets/missing")
+	assert.Assert(t, errors.Is(err, ErrNotFound))
+	assert.ErrorContains(t, err, "could not read file /secrets/missing")
+}
@@ -0,0 +1,126 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// vaultProvider reads secrets of the HashiCorp Vault at VAULT_ADDR, authenticating with VAULT_TOKEN. A reference
+// vault://<path>#<key> reads the key of the secret at the api path <path>, e.g. vault://secret/data/dynatrace#token
+// reads the key "token" of the secret "dynatrace" of the kv secrets engine mounted at "secret". Both version 1 and 2
+// of the kv secrets engine are supported. Requests are sent using the http client set by SetHttpClientFactory.
+type vaultProvider struct{}
+
+func newVaultProvider() *vaultProvider {
+	return &vaultProvider{}
+}
+
+// vaultResponse is the response of reading a secret. Secrets of the kv secrets engine version 2 are nested in a
+// second data object.
+type vaultResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+func (v *vaultProvider) Resolve(ref string) (string, error) {
+	reference, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid vault reference %s: %w", ref, err)
+	}
+
+	path := strings.Trim(reference.Host+reference.Path, "/")
+	key := reference.Fragment
+
+	if path == "" || key == "" {
+		return "", fmt.Errorf("invalid vault reference %s: expected vault://<path>#<key>", reference)
+	}
+
+	address := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if address == "" {
+		return "", fmt.Errorf("could not read %s: environment variable VAULT_ADDR is not set, set it to the address of your Vault", reference)
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("could not read %s: environment variable VAULT_TOKEN is not set, set it to a Vault token allowed to read %s", reference, path)
+	}
+
+	ctx, cancel := lookupContext()
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address+"/v1/"+path, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", reference, err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := newHttpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: Vault at %s is not reachable: %w", reference, address, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", reference, err)
+	}
+
+	var secret vaultResponse
+	_ = json.Unmarshal(body, &secret)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", NewNotFoundError("could not read %s: secret %s not found in Vault at %s", reference, path, address)
+	case resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("could not read %s: access denied by Vault at %s, check that VAULT_TOKEN is valid and allowed to read %s", reference, address, path)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", fmt.Errorf("could not read %s: Vault at %s responded with HTTP %d: %s", reference, address, resp.StatusCode, strings.Join(secret.Errors, ", "))
+	}
+
+	values := secret.Data
+	if nested, ok := values["data"].(map[string]interface{}); ok {
+		values = nested
+	}
+
+	value, found := values[key]
+	if !found {
+		return "", NewNotFoundError("could not read %s: key %s not found in secret %s, available keys: %s", reference, key, path, strings.Join(sortedKeys(values), ", "))
+	}
+
+	stringValue, ok := value.(string)
+	if !ok || stringValue == "" {
+		return "", fmt.Errorf("could not read %s: key %s of secret %s is not a non-empty string", reference, key, path)
+	}
+	return stringValue, nil
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,144 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"gotest.tools/assert"
+)
+
+func setupVault(t *testing.T, handler http.HandlerFunc) func() {
+	server := httptest.NewServer(handler)
+
+	util.SetEnv(t, "VAULT_ADDR", server.URL)
+	util.SetEnv(t, "VAULT_TOKEN", "vault-token")
+
+	return func() {
+		server.Close()
+		util.UnsetEnv(t, "VAULT_ADDR")
+		util.UnsetEnv(t, "VAULT_TOKEN")
+	}
+}
+
+func TestReadSecretOfKvVersion2(t *testing.T) {
+	defer setupVault(t, func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, req.URL.Path, "/v1/secret/data/dynatrace")
+		assert.Equal(t, req.Header.Get("X-Vault-Token"), "vault-token")
+		_, _ = rw.Write([]byte(`{"data": {"data": {"token": "dt0c01.abc"}, "metadata": {"version": 1}}}`))
+	})()
+
+	token, err := newVaultProvider().Resolve("vault://secret/data/dynatrace#token")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "dt0c01.abc")
+}
+
+func TestReadSecretOfKvVersion1(t *testing.T) {
+	defer setupVault(t, func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, req.URL.Path, "/v1/kv/dynatrace")
+		_, _ = rw.Write([]byte(`{"data": {"token": "dt0c01.abc"}}`))
+	})()
+
+	token, err := newVaultProvider().Resolve("vault://kv/dynatrace#token")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "dt0c01.abc")
+}
+
+func TestReadSecretFailsOnMissingKey(t *testing.T) {
+	defer setupVault(t, func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`{"data": {"data": {"other": "value", "another": "value"}}}`))
+	})()
+
+	_, err := newVaultProvider().Resolve("vault://secret/data/dynatrace#token")
+	assert.Error(t, err, "could not read vault://secret/data/dynatrace#token: key token not found in secret secret/data/dynatrace, available keys: another, other")
+}
+
+func TestReadSecretFailsOnMissingSecret(t *testing.T) {
+	defer setupVault(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+		_, _ = rw.Write([]byte(`{"errors": []}`))
+	})()
+
+	_, err := newVaultProvider().Resolve("vault://secret/data/dynatrace#token")
+	assert.ErrorContains(t, err, "secret secret/data/dynatrace not found in Vault at")
+}
+
+func TestReadSecretFailsOnDeniedAccess(t *testing.T) {
+	defer setupVault(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusForbidden)
+		_, _ = rw.Write([]byte(`{"errors": ["permission denied"]}`))
+	})()
+
+	_, err := newVaultProvider().Resolve("vault://secret/data/dynatrace#token")
+	assert.ErrorContains(t, err, "check that VAULT_TOKEN is valid and allowed to read secret/data/dynatrace")
+}
+
+func TestReadSecretFailsIfVaultIsNotReachable(t *testing.T) {
+	util.SetEnv(t, "VAULT_ADDR", "http://127.0.0.1:1")
+	util.SetEnv(t, "VAULT_TOKEN", "vault-token")
+	defer util.UnsetEnv(t, "VAULT_ADDR")
+	defer util.UnsetEnv(t, "VAULT_TOKEN")
+
+	_, err := newVaultProvider().Resolve("vault://secret/data/dynatrace#token")
+	assert.ErrorContains(t, err, "Vault at http://127.0.0.1:1 is not reachable")
+}
+
+func TestReadSecretFailsWithoutVaultAddress(t *testing.T) {
+	util.UnsetEnv(t, "VAULT_ADDR")
+
+	_, err := newVaultProvider().Resolve("vault://secret/data/dynatrace#token")
+	assert.ErrorContains(t, err, "environment variable VAULT_ADDR is not set")
+}
+
+func TestReadSecretFailsWithoutKey(t *testing.T) {
+	_, err := newVaultProvider().Resolve("vault://secret/data/dynatrace")
+	assert.Error(t, err, "invalid vault reference vault://secret/data/dynatrace: expected vault://<path>#<key>")
+}
+
+func TestReadSecretUsesHttpClientOfFactory(t *testing.T) {
+	defer setupVault(t, func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, req.Header.Get("X-Proxied"), "true")
+		_, _ = rw.Write([]byte(`{"data": {"data": {"token": "dt0c01.abc"}}}`))
+	})()
+
+	defer func(previous func() *http.Client) { newHttpClient = previous }(newHttpClient)
+	SetHttpClientFactory(func() *http.Client {
+		return &http.Client{Transport: headerTransport{name: "X-Proxied", value: "true"}}
+	})
+
+	token, err := newVaultProvider().Resolve("vault://secret/data/dynatrace#token")
+	assert.NilError(t, err)
+	assert.Equal(t, token, "dt0c01.abc")
+}
+
+// headerTransport adds a header to all requests, to verify which http client sent them
+type headerTransport struct {
+	name  string
+	value string
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(t.name, t.value)
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,85 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecordedSpan is a span recorded by RecordSpans
+type RecordedSpan struct {
+	TraceId       string
+	SpanId        string
+	ParentSpanId  string
+	Name          string
+	Kind          trace.SpanKind
+	Attributes    map[string]interface{}
+	StatusCode    codes.Code
+	StatusMessage string
+}
+
+// RecordSpans enables tracing, recording the ended spans in memory. The returned function disables tracing and returns
+// all spans ended until then. Integer attributes are returned as int64.
+func RecordSpans(t *testing.T) func() []RecordedSpan {
+	recorder := tracetest.NewSpanRecorder()
+	setup(recorder, resource.Empty())
+
+	return func() []RecordedSpan {
+		Shutdown()
+
+		var spans []RecordedSpan
+		for _, span := range recorder.Ended() {
+			attributes := make(map[string]interface{})
+			for _, a := range span.Attributes() {
+				attributes[string(a.Key)] = a.Value.AsInterface()
+			}
+
+			var parentId string
+			if span.Parent().IsValid() {
+				parentId = span.Parent().SpanID().String()
+			}
+
+			spans = append(spans, RecordedSpan{
+				TraceId:       span.SpanContext().TraceID().String(),
+				SpanId:        span.SpanContext().SpanID().String(),
+				ParentSpanId:  parentId,
+				Name:          span.Name(),
+				Kind:          span.SpanKind(),
+				Attributes:    attributes,
+				StatusCode:    span.Status().Code,
+				StatusMessage: span.Status().Description,
+			})
+		}
+		return spans
+	}
+}
+
+// FindSpan returns the first span with the given name
+func FindSpan(t *testing.T, spans []RecordedSpan, name string) RecordedSpan {
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no span named %s in %v", name, spans)
+	return RecordedSpan{}
+}
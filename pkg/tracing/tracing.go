@@ -0,0 +1,263 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tracing records the spans of a run using the OpenTelemetry SDK and exports them to a collector using OTLP.
+// Tracing is disabled unless Setup is called with an endpoint: all spans are nil then, and calling their methods has no
+// effect.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/dynatrace-oss/dynatrace-monitoring-as-code"
+
+// propagator reads and writes the W3C trace context of spans
+var propagator = propagation.TraceContext{}
+
+// tracer creates the spans of a run
+type tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+
+	// remoteParent is the parent of spans started without a parent, read from the TRACEPARENT environment
+	// variable, so the spans of monaco are part of the trace of the pipeline running it. It is invalid if not set.
+	remoteParent trace.SpanContext
+}
+
+// active is the tracer of the run, or nil if tracing is disabled. It is set up before the run and is thus only read
+// concurrently.
+var active *tracer
+
+// Setup enables tracing, exporting all spans to the OTLP endpoint, e.g. http://localhost:4318. The exporter honors the
+// standard OTEL_EXPORTER_OTLP_* variables: OTEL_EXPORTER_OTLP_TRACES_ENDPOINT enables tracing even without an endpoint,
+// OTEL_EXPORTER_OTLP_PROTOCOL selects http/protobuf (default) or grpc, and headers, certificates, compression and
+// timeouts are read by the exporter. The service name is read from OTEL_SERVICE_NAME. An endpoint passed explicitly,
+// instead of being read from OTEL_EXPORTER_OTLP_ENDPOINT, takes precedence over the variables.
+func Setup(endpoint string) error {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		active = nil
+		return nil
+	}
+
+	var explicit *url.URL
+	if endpoint != "" && endpoint != strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")) {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid trace endpoint '%s': must be an http or https url, e.g. http://localhost:4318", endpoint)
+		}
+		explicit = parsed
+	}
+
+	client, err := newClient(explicit)
+	if err != nil {
+		return err
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceNameKey.String("monaco")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create the resource of the traces: %w", err)
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		util.Log.Warn("Failed to export spans: %v", err)
+	}))
+	setup(sdktrace.NewBatchSpanProcessor(otlptrace.NewUnstarted(client)), res)
+	return nil
+}
+
+// newClient creates the OTLP client of the protocol set in OTEL_EXPORTER_OTLP_TRACES_PROTOCOL or
+// OTEL_EXPORTER_OTLP_PROTOCOL. An explicit endpoint overrides the endpoint read from the environment by the client.
+func newClient(endpoint *url.URL) (otlptrace.Client, error) {
+	protocol := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"))
+	if protocol == "" {
+		protocol = strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"))
+	}
+
+	switch protocol {
+	case "", "http/protobuf":
+		var options []otlptracehttp.Option
+		if endpoint != nil {
+			options = append(options, otlptracehttp.WithEndpoint(endpoint.Host), otlptracehttp.WithURLPath(strings.TrimRight(endpoint.Path, "/")+"/v1/traces"))
+			if endpoint.Scheme == "http" {
+				options = append(options, otlptracehttp.WithInsecure())
+			}
+		}
+		return otlptracehttp.NewClient(options...), nil
+	case "grpc":
+		var options []otlptracegrpc.Option
+		if endpoint != nil {
+			options = append(options, otlptracegrpc.WithEndpoint(endpoint.Host))
+			if endpoint.Scheme == "http" {
+				options = append(options, otlptracegrpc.WithInsecure())
+			}
+		}
+		return otlptracegrpc.NewClient(options...), nil
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol '%s': must be http/protobuf or grpc", protocol)
+	}
+}
+
+// setup enables tracing, handing all ended spans to the processor
+func setup(processor sdktrace.SpanProcessor, res *resource.Resource) {
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor), sdktrace.WithResource(res))
+
+	remote := propagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": strings.TrimSpace(os.Getenv("TRACEPARENT"))})
+
+	active = &tracer{
+		provider:     provider,
+		tracer:       provider.Tracer(instrumentationName),
+		remoteParent: trace.SpanContextFromContext(remote),
+	}
+}
+
+// Shutdown exports all ended spans and disables tracing. Spans ended afterwards are dropped.
+func Shutdown() {
+	if active == nil {
+		return
+	}
+
+	if err := active.provider.Shutdown(context.Background()); err != nil {
+		util.Log.Warn("Failed to export spans: %v", err)
+	}
+	active = nil
+}
+
+// Enabled returns true if spans are recorded
+func Enabled() bool {
+	return active != nil
+}
+
+// Inject writes the W3C trace context of the span of the context to the headers of a request, so the receiving
+// service continues the trace. The headers are unchanged if the context doesn't contain a span.
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Span is an operation of a trace. A nil span is not recorded, so all methods can be called if tracing is disabled.
+type Span struct {
+	span trace.Span
+}
+
+type spanKey struct{}
+
+// Start starts a span as child of the span of the context, and returns a context containing the new span. The span
+// has to be ended by calling End.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return start(ctx, name, trace.SpanKindInternal)
+}
+
+// StartClient starts a span of a request sent to another service, as child of the span of the context
+func StartClient(ctx context.Context, name string) (context.Context, *Span) {
+	return start(ctx, name, trace.SpanKindClient)
+}
+
+func start(ctx context.Context, name string, kind trace.SpanKind) (context.Context, *Span) {
+	t := active
+	if t == nil {
+		return ctx, nil
+	}
+
+	if !trace.SpanContextFromContext(ctx).IsValid() && t.remoteParent.IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, t.remoteParent)
+	}
+
+	ctx, otelSpan := t.tracer.Start(ctx, name, trace.WithSpanKind(kind))
+	span := &Span{span: otelSpan}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span of the context, or nil if the context doesn't contain a span
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SetAttribute sets an attribute of the span. Integers are recorded as int64 and durations in milliseconds. Values
+// other than strings, bools, integers and floats are formatted as strings.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(newAttribute(key, value))
+}
+
+func newAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case time.Duration:
+		return attribute.Int64(key, v.Milliseconds())
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+// SetError marks the span as failed with the message of the error. A nil error marks the span as successful.
+func (s *Span) SetError(err error) {
+	if s == nil {
+		return
+	}
+
+	if err == nil {
+		s.span.SetStatus(codes.Ok, "")
+		return
+	}
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End ends the span and hands it to the exporter. Calls after the first one have no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
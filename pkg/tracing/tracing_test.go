@@ -0,0 +1,214 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dynatrace-oss/dynatrace-monitoring-as-code/pkg/util"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/assert"
+)
+
+func TestSpansAreNotRecordedIfTracingIsDisabled(t *testing.T) {
+	util.UnsetEnv(t, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	assert.NilError(t, Setup(""))
+	assert.Assert(t, !Enabled())
+
+	ctx, span := Start(context.Background(), "disabled")
+	assert.Assert(t, span == nil)
+	assert.Assert(t, FromContext(ctx) == nil)
+
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("failed"))
+	span.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+	assert.Equal(t, header.Get("traceparent"), "")
+	Shutdown()
+}
+
+func TestSpansAreRecordedWithParentsAttributesAndStatus(t *testing.T) {
+	util.UnsetEnv(t, "TRACEPARENT")
+	spans := RecordSpans(t)
+
+	ctx, root := Start(context.Background(), "root")
+	root.SetAttribute("monaco.dry_run", true)
+
+	_, child := StartClient(ctx, "child")
+	child.SetAttribute("http.status_code", 404)
+	child.SetAttribute("http.url", "https://example.com")
+	child.SetAttribute("ratio", 0.5)
+	child.SetError(errors.New("HTTP 404"))
+	child.End()
+	child.End()
+	root.SetError(nil)
+	root.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+
+	recorded := spans()
+	assert.Equal(t, len(recorded), 2)
+
+	rootSpan, childSpan := FindSpan(t, recorded, "root"), FindSpan(t, recorded, "child")
+	assert.Equal(t, rootSpan.ParentSpanId, "")
+	assert.Equal(t, rootSpan.Kind, trace.SpanKindInternal)
+	assert.Equal(t, rootSpan.StatusCode, codes.Ok)
+	assert.DeepEqual(t, rootSpan.Attributes, map[string]interface{}{"monaco.dry_run": true})
+
+	assert.Equal(t, childSpan.TraceId, rootSpan.TraceId)
+	assert.Equal(t, childSpan.ParentSpanId, rootSpan.SpanId)
+	assert.Equal(t, childSpan.Kind, trace.SpanKindClient)
+	assert.Equal(t, childSpan.StatusCode, codes.Error)
+	assert.Equal(t, childSpan.StatusMessage, "HTTP 404")
+	assert.DeepEqual(t, childSpan.Attributes, map[string]interface{}{"http.status_code": int64(404), "http.url": "https://example.com", "ratio": 0.5})
+
+	assert.Equal(t, header.Get("traceparent"), "00-"+rootSpan.TraceId+"-"+rootSpan.SpanId+"-01")
+	assert.Assert(t, !Enabled())
+}
+
+func TestSpansContinueTraceOfTraceParent(t *testing.T) {
+	util.SetEnv(t, "TRACEPARENT", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	defer util.UnsetEnv(t, "TRACEPARENT")
+	spans := RecordSpans(t)
+
+	_, span := Start(context.Background(), "root")
+	span.End()
+
+	recorded := spans()
+	assert.Equal(t, len(recorded), 1)
+	assert.Equal(t, recorded[0].TraceId, "0af7651916cd43dd8448eb211c80319c")
+	assert.Equal(t, recorded[0].ParentSpanId, "b7ad6b7169203331")
+}
+
+func TestInvalidTraceParentIsIgnored(t *testing.T) {
+	util.SetEnv(t, "TRACEPARENT", "00-00000000000000000000000000000000-b7ad6b7169203331-01")
+	defer util.UnsetEnv(t, "TRACEPARENT")
+	spans := RecordSpans(t)
+
+	_, span := Start(context.Background(), "root")
+	span.End()
+
+	recorded := spans()
+	assert.Equal(t, len(recorded), 1)
+	assert.Equal(t, recorded[0].ParentSpanId, "")
+}
+
+// exportedRequest is an export request received by the collector of recordExports
+type exportedRequest struct {
+	path    string
+	headers http.Header
+	spans   []string
+}
+
+// recordExports starts a collector recording the OTLP/HTTP export requests it receives
+func recordExports(t *testing.T) (*httptest.Server, func() []exportedRequest) {
+	var mutex sync.Mutex
+	var requests []exportedRequest
+
+	collector := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NilError(t, err)
+
+		var request collectortrace.ExportTraceServiceRequest
+		assert.NilError(t, proto.Unmarshal(body, &request))
+
+		exported := exportedRequest{path: req.URL.Path, headers: req.Header}
+		for _, resourceSpans := range request.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				for _, span := range scopeSpans.Spans {
+					exported.spans = append(exported.spans, span.Name)
+				}
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests = append(requests, exported)
+	}))
+
+	return collector, func() []exportedRequest {
+		collector.Close()
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests
+	}
+}
+
+func TestSpansAreExportedToTheTracesPathOfTheEndpoint(t *testing.T) {
+	util.UnsetEnv(t, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	collector, requests := recordExports(t)
+
+	assert.NilError(t, Setup(collector.URL+"/otlp"))
+	_, span := Start(context.Background(), "root")
+	span.End()
+	Shutdown()
+
+	exported := requests()
+	assert.Equal(t, len(exported), 1)
+	assert.Equal(t, exported[0].path, "/otlp/v1/traces")
+	assert.DeepEqual(t, exported[0].spans, []string{"root"})
+}
+
+func TestSpansAreExportedUsingTheOtlpEnvironmentVariables(t *testing.T) {
+	collector, requests := recordExports(t)
+
+	util.UnsetEnv(t, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	util.SetEnv(t, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", collector.URL+"/custom/traces")
+	defer util.UnsetEnv(t, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	util.SetEnv(t, "OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Api-Token%20abc")
+	defer util.UnsetEnv(t, "OTEL_EXPORTER_OTLP_HEADERS")
+
+	assert.NilError(t, Setup(""))
+	assert.Assert(t, Enabled())
+	_, span := Start(context.Background(), "root")
+	span.End()
+	Shutdown()
+
+	exported := requests()
+	assert.Equal(t, len(exported), 1)
+	assert.Equal(t, exported[0].path, "/custom/traces")
+	assert.Equal(t, exported[0].headers.Get("Authorization"), "Api-Token abc")
+	assert.DeepEqual(t, exported[0].spans, []string{"root"})
+}
+
+func TestSetupFailsOnInvalidEndpointsAndProtocols(t *testing.T) {
+	defer Shutdown()
+	util.UnsetEnv(t, "OTEL_EXPORTER_OTLP_ENDPOINT")
+
+	assert.ErrorContains(t, Setup("localhost:4318"), "invalid trace endpoint 'localhost:4318'")
+	assert.ErrorContains(t, Setup("ftp://localhost:4318"), "invalid trace endpoint")
+
+	util.SetEnv(t, "OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+	defer util.UnsetEnv(t, "OTEL_EXPORTER_OTLP_PROTOCOL")
+	assert.ErrorContains(t, Setup("http://localhost:4318"), "unsupported OTLP protocol 'http/json'")
+}
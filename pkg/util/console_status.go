@@ -0,0 +1,80 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// clearLine moves the cursor to the start of the line and erases the line
+const clearLine = "\r\033[K"
+
+// console is the writer of the text console log. It keeps a status line, e.g. a progress bar, at the bottom of the
+// console: the status line is cleared before each log message and redrawn after it, so messages are never appended to
+// the status line.
+var console = &statusWriter{out: os.Stdout}
+
+// statusWriter writes to out, while keeping the status line below everything written. It is safe for concurrent use.
+type statusWriter struct {
+	mutex sync.Mutex
+	out   io.Writer
+	line  string
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.line == "" {
+		return w.out.Write(p)
+	}
+
+	if _, err := io.WriteString(w.out, clearLine); err != nil {
+		return 0, err
+	}
+	n, err := w.out.Write(p)
+	if err != nil {
+		return n, err
+	}
+	_, err = io.WriteString(w.out, w.line)
+	return n, err
+}
+
+func (w *statusWriter) setStatus(line string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if line == "" && w.line == "" {
+		return
+	}
+
+	_, _ = io.WriteString(w.out, clearLine+line)
+	w.line = line
+}
+
+// SetConsoleStatus draws the line at the bottom of the console, replacing the previous status line. It must only be
+// used if the console is interactive, see IsInteractiveConsole.
+func SetConsoleStatus(line string) {
+	console.setStatus(line)
+}
+
+// ClearConsoleStatus removes the status line from the console
+func ClearConsoleStatus() {
+	console.setStatus("")
+}
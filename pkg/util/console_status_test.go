@@ -0,0 +1,57 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestStatusWriterRedrawsStatusLineAfterMessages(t *testing.T) {
+	var out bytes.Buffer
+	writer := &statusWriter{out: &out}
+
+	_, err := writer.Write([]byte("first\n"))
+	assert.NilError(t, err)
+
+	writer.setStatus("[==  ] 50%")
+	_, err = writer.Write([]byte("second\n"))
+	assert.NilError(t, err)
+
+	writer.setStatus("")
+	_, err = writer.Write([]byte("third\n"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, out.String(), "first\n"+
+		"\r\033[K[==  ] 50%"+
+		"\r\033[Ksecond\n[==  ] 50%"+
+		"\r\033[K"+
+		"third\n")
+}
+
+func TestStatusWriterOnlyClearsDrawnStatusLine(t *testing.T) {
+	var out bytes.Buffer
+	writer := &statusWriter{out: &out}
+
+	writer.setStatus("")
+	assert.Equal(t, out.String(), "")
+}
@@ -0,0 +1,176 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// envFileKey matches the valid names of variables defined in env files
+var envFileKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvVariable is a variable defined by a line of an env file
+type EnvVariable struct {
+	Name  string
+	Value string
+}
+
+// LoadEnvFile sets the environment variables defined by the env file. Variables which are already set keep their
+// value, unless override is true. It returns the names of the variables which were set.
+func LoadEnvFile(fs afero.Fs, file string, override bool) ([]string, error) {
+	content, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read env file %s: %w", file, err)
+	}
+
+	variables, err := ParseEnvFile(string(content), file)
+	if err != nil {
+		return nil, err
+	}
+
+	var set []string
+	for _, variable := range variables {
+		if _, found := os.LookupEnv(variable.Name); found && !override && !containsName(set, variable.Name) {
+			Log.Debug("Environment variable %s of env file %s is already set and keeps its value", variable.Name, file)
+			continue
+		}
+
+		if err := os.Setenv(variable.Name, variable.Value); err != nil {
+			return nil, fmt.Errorf("could not set environment variable %s of env file %s: %w", variable.Name, file, err)
+		}
+		if !containsName(set, variable.Name) {
+			set = append(set, variable.Name)
+		}
+	}
+	return set, nil
+}
+
+// ParseEnvFile returns the variables defined by the KEY=VALUE lines of an env file, in order of their definition.
+// Lines may start with export, values may be quoted in single or double quotes, and lines or unquoted values may end
+// with # comments. Escape sequences are only replaced in double quoted values. All malformed lines are reported at once.
+func ParseEnvFile(content string, fileName string) ([]EnvVariable, error) {
+	var variables []EnvVariable
+	var malformed []string
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		variable, err := parseEnvLine(line)
+		if err != nil {
+			malformed = append(malformed, fmt.Sprintf("\n\tline %d: %s", i+1, err))
+			continue
+		}
+		variables = append(variables, variable)
+	}
+
+	if len(malformed) > 0 {
+		return nil, fmt.Errorf("malformed lines in env file %s:%s", fileName, strings.Join(malformed, ""))
+	}
+	return variables, nil
+}
+
+func parseEnvLine(line string) (EnvVariable, error) {
+	if strings.HasPrefix(line, "export ") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+	}
+
+	index := strings.Index(line, "=")
+	if index < 0 {
+		return EnvVariable{}, fmt.Errorf("expected KEY=VALUE, but found %q", line)
+	}
+
+	name := strings.TrimSpace(line[:index])
+	if !envFileKey.MatchString(name) {
+		return EnvVariable{}, fmt.Errorf("invalid variable name %q", name)
+	}
+
+	value, err := parseEnvValue(strings.TrimSpace(line[index+1:]))
+	if err != nil {
+		return EnvVariable{}, fmt.Errorf("invalid value of %s: %w", name, err)
+	}
+	return EnvVariable{Name: name, Value: value}, nil
+}
+
+// parseEnvValue returns the value of an env file line, without quotes and comments
+func parseEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	quote := raw[0]
+	if quote != '"' && quote != '\'' {
+		// comments of unquoted values must be separated by whitespace, so values may contain #
+		for i := 1; i < len(raw); i++ {
+			if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+				return strings.TrimSpace(raw[:i]), nil
+			}
+		}
+		return raw, nil
+	}
+
+	var value strings.Builder
+	for i := 1; i < len(raw); i++ {
+		c := raw[i]
+
+		if c == quote {
+			rest := strings.TrimSpace(raw[i+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected characters %q after the closing quote", rest)
+			}
+			return value.String(), nil
+		}
+
+		if c == '\\' && quote == '"' && i+1 < len(raw) {
+			i++
+			switch raw[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case 'r':
+				value.WriteByte('\r')
+			case '"', '\\', '$':
+				value.WriteByte(raw[i])
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(raw[i])
+			}
+			continue
+		}
+
+		value.WriteByte(c)
+	}
+
+	return "", fmt.Errorf("missing closing quote %c", quote)
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
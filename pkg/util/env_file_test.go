@@ -0,0 +1,119 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestParseEnvFileSupportsQuotesAndComments(t *testing.T) {
+	content := `# variables of the development environment
+DEV_URL=https://dev.example.com
+export DEV_TOKEN_NAME = DEV_TOKEN
+EMPTY=
+UNQUOTED=value with spaces # a comment
+HASH=color#red
+DOUBLE="quoted # not a comment" # a comment
+ESCAPED="line\nnext \"quoted\" \\ \$HOME \d"
+SINGLE='literal \n $HOME'   
+`
+
+	variables, err := ParseEnvFile(content, ".env")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, variables, []EnvVariable{
+		{Name: "DEV_URL", Value: "https://dev.example.com"},
+		{Name: "DEV_TOKEN_NAME", Value: "DEV_TOKEN"},
+		{Name: "EMPTY", Value: ""},
+		{Name: "UNQUOTED", Value: "value with spaces"},
+		{Name: "HASH", Value: "color#red"},
+		{Name: "DOUBLE", Value: "quoted # not a comment"},
+		{Name: "ESCAPED", Value: "line\nnext \"quoted\" \\ $HOME \\d"},
+		{Name: "SINGLE", Value: `literal \n $HOME`},
+	})
+}
+
+func TestParseEnvFileReportsAllMalformedLines(t *testing.T) {
+	content := "VALID=value\nmissing separator\n1INVALID=value\nOPEN=\"unterminated\nTRAILING='value' more\n=value\n"
+
+	_, err := ParseEnvFile(content, ".env")
+	assert.Error(t, err, "malformed lines in env file .env:"+
+		"\n\tline 2: expected KEY=VALUE, but found \"missing separator\""+
+		"\n\tline 3: invalid variable name \"1INVALID\""+
+		"\n\tline 4: invalid value of OPEN: missing closing quote \""+
+		"\n\tline 5: invalid value of TRAILING: unexpected characters \"more\" after the closing quote"+
+		"\n\tline 6: invalid variable name \"\"")
+}
+
+func TestLoadEnvFileKeepsVariablesWhichAreAlreadySet(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, ".env", []byte("MONACO_TEST_ENV_FILE_SET=from file\nMONACO_TEST_ENV_FILE_NEW=first\nMONACO_TEST_ENV_FILE_NEW=second\n"), 0644))
+
+	SetEnv(t, "MONACO_TEST_ENV_FILE_SET", "from os")
+	defer UnsetEnv(t, "MONACO_TEST_ENV_FILE_SET")
+	UnsetEnv(t, "MONACO_TEST_ENV_FILE_NEW")
+	defer UnsetEnv(t, "MONACO_TEST_ENV_FILE_NEW")
+
+	set, err := LoadEnvFile(fs, ".env", false)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, set, []string{"MONACO_TEST_ENV_FILE_NEW"})
+	assert.Equal(t, os.Getenv("MONACO_TEST_ENV_FILE_SET"), "from os")
+	assert.Equal(t, os.Getenv("MONACO_TEST_ENV_FILE_NEW"), "second")
+
+	set, err = LoadEnvFile(fs, ".env", true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, set, []string{"MONACO_TEST_ENV_FILE_SET", "MONACO_TEST_ENV_FILE_NEW"})
+	assert.Equal(t, os.Getenv("MONACO_TEST_ENV_FILE_SET"), "from file")
+}
+
+func TestLoadEnvFileIsUsedByTemplates(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, ".env", []byte(`MONACO_TEST_ENV_FILE_ANIMAL="white rabbit"`), 0644))
+	UnsetEnv(t, "MONACO_TEST_ENV_FILE_ANIMAL")
+	defer UnsetEnv(t, "MONACO_TEST_ENV_FILE_ANIMAL")
+
+	_, err := LoadEnvFile(fs, ".env", false)
+	assert.NilError(t, err)
+
+	template, err := NewTemplateFromString("test", `Follow the {{ .Env.MONACO_TEST_ENV_FILE_ANIMAL }}`)
+	assert.NilError(t, err)
+
+	rendered, err := template.ExecuteTemplate(map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, rendered, "Follow the white rabbit")
+}
+
+func TestLoadEnvFileFailsOnMissingOrMalformedFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	_, err := LoadEnvFile(fs, "missing.env", false)
+	assert.ErrorContains(t, err, "could not read env file missing.env")
+
+	assert.NilError(t, afero.WriteFile(fs, ".env", []byte("MONACO_TEST_ENV_FILE_VALID=value\nmalformed\n"), 0644))
+	UnsetEnv(t, "MONACO_TEST_ENV_FILE_VALID")
+
+	_, err = LoadEnvFile(fs, ".env", false)
+	assert.ErrorContains(t, err, "line 2: expected KEY=VALUE")
+	_, found := os.LookupEnv("MONACO_TEST_ENV_FILE_VALID")
+	assert.Assert(t, !found)
+}
@@ -23,17 +23,11 @@ import (
 
 // PrintError should pretty-print the error using a more user-friendly format
 func PrintError(err error) {
-	if ppError, ok := err.(JsonValidationError); ok {
-		ppError.PrettyPrintError()
-	} else {
-		Log.Error("\t%s", err)
-	}
+	defaultLogger.PrintError(err)
 }
 
 func PrintErrors(errors []error) {
-	for _, err := range errors {
-		PrintError(err)
-	}
+	defaultLogger.PrintErrors(errors)
 }
 
 func FailOnError(err error, msg string) {
@@ -68,6 +68,10 @@ const errorTemplate = `File did not contain valid json:
 `
 
 func (e *JsonValidationError) PrettyPrintError() {
+	e.prettyPrint(defaultLogger)
+}
+
+func (e *JsonValidationError) prettyPrint(log *Logger) {
 
 	if e.ContainsLineInformation() {
 
@@ -77,7 +81,7 @@ func (e *JsonValidationError) PrettyPrintError() {
 		lineContent := strings.Replace(e.LineContent, "\t", " ", -1)
 		previousLineContent := strings.Replace(e.PreviousLineContent, "\t", " ", -1)
 
-		Log.Error("\t"+errorTemplate, e.FileName, e.LineNumber, e.CharacterNumberInLine,
+		log.Error("\t"+errorTemplate, e.FileName, e.LineNumber, e.CharacterNumberInLine,
 			whiteSpace, previousLineContent,
 			e.LineNumber, lineContent,
 			whiteSpace, whiteSpaceOffset,
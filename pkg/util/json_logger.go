@@ -0,0 +1,235 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcelliott/lumber"
+)
+
+// LogFields contains structured context (e.g. project, config, environment) which is attached to log entries
+// written in json format
+type LogFields map[string]string
+
+// fieldLogger is implemented by loggers, which are able to attach structured context to their log entries
+type fieldLogger interface {
+	withFields(fields LogFields) lumber.Logger
+}
+
+// LogWithFields returns a logger which attaches the given fields to every log entry. If the shared Log does not
+// support structured context (e.g. when using the default text format), the shared Log is returned as is, as the
+// log messages themselves are expected to contain all relevant information.
+func LogWithFields(fields LogFields) lumber.Logger {
+	return withFields(Log, fields)
+}
+
+func withFields(logger lumber.Logger, fields LogFields) lumber.Logger {
+	if l, ok := logger.(fieldLogger); ok {
+		return l.withFields(fields)
+	}
+	return logger
+}
+
+// jsonLogSink is a single output of the jsonLogger with its own log level
+type jsonLogSink struct {
+	out    io.Writer
+	closer io.Closer
+	level  int
+
+	// fixedLevel keeps the level of the sink if the level of the logger is changed, e.g. for log files
+	fixedLevel bool
+}
+
+// jsonLogger is a lumber.Logger writing one json object per log entry to all of its sinks
+type jsonLogger struct {
+	// lumber.Logger contains unexported methods which can't be implemented outside of lumber.
+	// Embedding a logger satisfies the interface; all exported methods are overwritten below.
+	lumber.Logger
+
+	sinks  []*jsonLogSink
+	fields LogFields
+
+	// writeMutex is shared by all loggers derived using withFields, so concurrent log entries are not interleaved
+	writeMutex *sync.Mutex
+}
+
+type jsonLogEntry map[string]string
+
+func newJsonLogger() *jsonLogger {
+	return &jsonLogger{
+		Logger:     lumber.NewBasicLogger(nopWriteCloser{io.Discard}, lumber.FATAL),
+		sinks:      make([]*jsonLogSink, 0),
+		fields:     LogFields{},
+		writeMutex: &sync.Mutex{},
+	}
+}
+
+// addSink adds an output, which is not closed on Close (e.g. the console)
+func (l *jsonLogger) addSink(out io.Writer, level int) {
+	l.sinks = append(l.sinks, &jsonLogSink{out: out, level: level})
+}
+
+// addClosableSink adds an output, which is closed on Close (e.g. a log file)
+func (l *jsonLogger) addClosableSink(out io.WriteCloser, level int) {
+	l.sinks = append(l.sinks, &jsonLogSink{out: out, closer: out, level: level, fixedLevel: true})
+}
+
+func (l *jsonLogger) withFields(fields LogFields) lumber.Logger {
+	merged := LogFields{}
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &jsonLogger{
+		Logger:     l.Logger,
+		sinks:      l.sinks,
+		fields:     merged,
+		writeMutex: l.writeMutex,
+	}
+}
+
+func (l *jsonLogger) log(level int, format string, v ...interface{}) {
+	entry := jsonLogEntry{}
+	for k, v := range l.fields {
+		entry[k] = v
+	}
+	entry["time"] = time.Now().Format(time.RFC3339)
+	entry["level"] = strings.ToLower(strings.TrimSpace(lumber.LvlStr(level)))
+	entry["message"] = strings.TrimSpace(fmt.Sprintf(format, v...))
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	l.writeMutex.Lock()
+	defer l.writeMutex.Unlock()
+
+	for _, sink := range l.sinks {
+		if level >= sink.level {
+			_, _ = sink.out.Write(line)
+		}
+	}
+}
+
+func (l *jsonLogger) Fatal(format string, v ...interface{}) {
+	l.log(lumber.FATAL, format, v...)
+}
+
+func (l *jsonLogger) Error(format string, v ...interface{}) {
+	l.log(lumber.ERROR, format, v...)
+}
+
+func (l *jsonLogger) Warn(format string, v ...interface{}) {
+	l.log(lumber.WARN, format, v...)
+}
+
+func (l *jsonLogger) Info(format string, v ...interface{}) {
+	l.log(lumber.INFO, format, v...)
+}
+
+func (l *jsonLogger) Debug(format string, v ...interface{}) {
+	l.log(lumber.DEBUG, format, v...)
+}
+
+func (l *jsonLogger) Trace(format string, v ...interface{}) {
+	l.log(lumber.TRACE, format, v...)
+}
+
+func (l *jsonLogger) Print(level int, v ...interface{}) {
+	l.log(level, "%s", fmt.Sprint(v...))
+}
+
+func (l *jsonLogger) Printf(level int, format string, v ...interface{}) {
+	l.log(level, format, v...)
+}
+
+// Level changes the level of the console. The level of log files stays independent of the verbosity of the console.
+func (l *jsonLogger) Level(level int) {
+	for _, sink := range l.sinks {
+		if !sink.fixedLevel {
+			sink.level = level
+		}
+	}
+}
+
+// GetLevel returns the lowest level of all sinks
+func (l *jsonLogger) GetLevel() int {
+	level := lumber.FATAL
+	for _, sink := range l.sinks {
+		if sink.level < level {
+			level = sink.level
+		}
+	}
+	return level
+}
+
+func (l *jsonLogger) IsFatal() bool {
+	return l.GetLevel() <= lumber.FATAL
+}
+
+func (l *jsonLogger) IsError() bool {
+	return l.GetLevel() <= lumber.ERROR
+}
+
+func (l *jsonLogger) IsWarn() bool {
+	return l.GetLevel() <= lumber.WARN
+}
+
+func (l *jsonLogger) IsInfo() bool {
+	return l.GetLevel() <= lumber.INFO
+}
+
+func (l *jsonLogger) IsDebug() bool {
+	return l.GetLevel() <= lumber.DEBUG
+}
+
+func (l *jsonLogger) IsTrace() bool {
+	return l.GetLevel() <= lumber.TRACE
+}
+
+// Prefix is not supported by the json format
+func (l *jsonLogger) Prefix(string) {}
+
+// TimeFormat is not supported by the json format, which always uses RFC3339 timestamps
+func (l *jsonLogger) TimeFormat(string) {}
+
+func (l *jsonLogger) Close() {
+	for _, sink := range l.sinks {
+		if sink.closer != nil {
+			_ = sink.closer.Close()
+		}
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,106 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jcelliott/lumber"
+	"gotest.tools/assert"
+)
+
+func TestJsonLoggerWritesOneJsonObjectPerEntry(t *testing.T) {
+	buffer := bytes.Buffer{}
+	logger := newJsonLogger()
+	logger.addSink(&buffer, lumber.INFO)
+
+	logger.Info("\t\tProcessing project %s...", "project1")
+	logger.Warn("something is odd")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Equal(t, len(lines), 2)
+
+	var entry map[string]string
+	assert.NilError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, entry["level"], "info")
+	assert.Equal(t, entry["message"], "Processing project project1...")
+	assert.Check(t, entry["time"] != "")
+
+	assert.NilError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, entry["level"], "warn")
+}
+
+func TestJsonLoggerRespectsSinkLevels(t *testing.T) {
+	console := bytes.Buffer{}
+	file := bytes.Buffer{}
+	logger := newJsonLogger()
+	logger.addSink(&console, lumber.INFO)
+	logger.addSink(&file, lumber.DEBUG)
+
+	logger.Debug("debug message")
+
+	assert.Equal(t, console.Len(), 0)
+	assert.Check(t, strings.Contains(file.String(), "debug message"))
+	assert.Equal(t, logger.GetLevel(), lumber.DEBUG)
+	assert.Check(t, logger.IsDebug())
+}
+
+func TestJsonLoggerLevelKeepsLevelOfLogFile(t *testing.T) {
+	console := bytes.Buffer{}
+	file := bytes.Buffer{}
+	logger := newJsonLogger()
+	logger.addSink(&console, lumber.INFO)
+	logger.addClosableSink(nopWriteCloser{&file}, lumber.DEBUG)
+
+	logger.Level(lumber.WARN)
+	logger.Info("info message")
+	logger.Debug("debug message")
+
+	assert.Equal(t, console.Len(), 0)
+	assert.Check(t, strings.Contains(file.String(), "info message"))
+	assert.Check(t, strings.Contains(file.String(), "debug message"))
+}
+
+func TestJsonLoggerAttachesFields(t *testing.T) {
+	buffer := bytes.Buffer{}
+	logger := newJsonLogger()
+	logger.addSink(&buffer, lumber.INFO)
+
+	environmentLog := logger.withFields(LogFields{"environment": "dev"})
+	configLog := environmentLog.(fieldLogger).withFields(LogFields{"config": "project/dashboard/my-dashboard"})
+	configLog.Error("failed")
+
+	var entry map[string]string
+	assert.NilError(t, json.Unmarshal(buffer.Bytes(), &entry))
+	assert.Equal(t, entry["environment"], "dev")
+	assert.Equal(t, entry["config"], "project/dashboard/my-dashboard")
+	assert.Equal(t, entry["level"], "error")
+}
+
+func TestLogWithFieldsReturnsSharedLogForTextFormat(t *testing.T) {
+	defer func(original lumber.Logger) { Log = original }(Log)
+
+	Log = lumber.NewConsoleLogger(lumber.INFO)
+
+	assert.Equal(t, LogWithFields(LogFields{"environment": "dev"}), Log)
+}
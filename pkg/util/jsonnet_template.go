@@ -0,0 +1,135 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/spf13/afero"
+)
+
+// jsonnetEnvVar is the name of the external variable containing the environment variables in jsonnet templates
+const jsonnetEnvVar = "Env"
+
+// IsJsonnetFile returns whether the file is a jsonnet template or library, which is rendered using jsonnet instead of
+// go templates
+func IsJsonnetFile(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	return ext == ".jsonnet" || ext == ".libsonnet"
+}
+
+// jsonnetTemplate renders a jsonnet file to json. The properties of the config are passed as external variables, e.g.
+// std.extVar("name"), the environment variables as the object std.extVar("Env"). Imports are read from the file
+// system, relative to the importing file.
+type jsonnetTemplate struct {
+	fs       afero.Fs
+	fileName string
+}
+
+// newJsonnetTemplate creates a new template for the given jsonnet file. The file is parsed to fail early on syntax
+// errors, like go templates do.
+func newJsonnetTemplate(fs afero.Fs, fileName string) (Template, error) {
+	data, err := afero.ReadFile(fs, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := jsonnet.SnippetToAST(fileName, string(data)); err != nil {
+		return nil, fmt.Errorf("invalid jsonnet template %s: %w", fileName, err)
+	}
+
+	return &jsonnetTemplate{
+		fs:       fs,
+		fileName: fileName,
+	}, nil
+}
+
+// ExecuteTemplate evaluates the jsonnet file. Every execution uses its own vm, as the external variables differ
+// between the environments and configs are rendered concurrently.
+func (t *jsonnetTemplate) ExecuteTemplate(data map[string]string) (string, error) {
+	vm := jsonnet.MakeVM()
+	vm.Importer(&aferoImporter{fs: t.fs, cache: make(map[string]jsonnet.Contents)})
+
+	for key, value := range data {
+		vm.ExtVar(key, value)
+	}
+
+	envVars, err := json.Marshal(environmentVariables())
+	if err != nil {
+		return "", err
+	}
+	vm.ExtCode(jsonnetEnvVar, string(envVars))
+
+	rendered, err := vm.EvaluateFile(t.fileName)
+	if CheckError(err, "Could not execute jsonnet template") {
+		return "", fmt.Errorf("could not render jsonnet template %s: %w", t.fileName, err)
+	}
+
+	return rendered, nil
+}
+
+// ReferencedEnvVars returns no variables, as the environment variables used by a jsonnet template are only known when
+// it's evaluated. Variables which aren't set fail the rendering instead.
+func (t *jsonnetTemplate) ReferencedEnvVars() []string {
+	return nil
+}
+
+// environmentVariables returns the environment variables of the process as a map
+func environmentVariables() map[string]string {
+	envVars := make(map[string]string)
+
+	for _, v := range os.Environ() {
+		split := strings.SplitN(v, "=", 2)
+		if len(split) == 2 {
+			envVars[split[0]] = split[1]
+		}
+	}
+
+	return envVars
+}
+
+// aferoImporter imports jsonnet files from the file system, relative to the folder of the importing file. Imported
+// files are cached, as jsonnet requires an import to return the same contents every time.
+type aferoImporter struct {
+	fs    afero.Fs
+	cache map[string]jsonnet.Contents
+}
+
+func (i *aferoImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	foundAt := filepath.Clean(filepath.FromSlash(importedPath))
+	if !filepath.IsAbs(foundAt) {
+		foundAt = filepath.Join(filepath.Dir(importedFrom), foundAt)
+	}
+
+	if contents, found := i.cache[foundAt]; found {
+		return contents, foundAt, nil
+	}
+
+	data, err := afero.ReadFile(i.fs, foundAt)
+	if err != nil {
+		return jsonnet.Contents{}, "", fmt.Errorf("could not import %s: %w", importedPath, err)
+	}
+
+	contents := jsonnet.MakeContents(string(data))
+	i.cache[foundAt] = contents
+	return contents, foundAt, nil
+}
@@ -0,0 +1,107 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestJsonnetTemplateUsesPropertiesAndEnvVars(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "project/alerting-profile/profile.jsonnet", []byte(`
+local severities = ["AVAILABILITY", "ERROR"];
+{
+  name: std.extVar("name"),
+  owner: std.extVar("Env").MONACO_TEST_JSONNET_OWNER,
+  rules: [{ severityLevel: s, delayInMinutes: 5 } for s in severities],
+}
+`), 0644))
+
+	SetEnv(t, "MONACO_TEST_JSONNET_OWNER", "team-rabbit")
+	defer UnsetEnv(t, "MONACO_TEST_JSONNET_OWNER")
+
+	template, err := NewTemplate(fs, "project/alerting-profile/profile.jsonnet")
+	assert.NilError(t, err)
+
+	result, err := template.ExecuteTemplate(map[string]string{"name": "profile"})
+	assert.NilError(t, err)
+	assert.Equal(t, result, `{
+   "name": "profile",
+   "owner": "team-rabbit",
+   "rules": [
+      {
+         "delayInMinutes": 5,
+         "severityLevel": "AVAILABILITY"
+      },
+      {
+         "delayInMinutes": 5,
+         "severityLevel": "ERROR"
+      }
+   ]
+}
+`)
+	assert.Assert(t, template.ReferencedEnvVars() == nil)
+}
+
+func TestJsonnetTemplateImportsLibrariesRelativeToFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "project/lib/rules.libsonnet", []byte(`{ rule(level):: { severityLevel: level } }`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "project/alerting-profile/profile.jsonnet", []byte(`
+local rules = import "../lib/rules.libsonnet";
+{ rules: [rules.rule("ERROR")] }
+`), 0644))
+
+	template, err := NewTemplate(fs, "project/alerting-profile/profile.jsonnet")
+	assert.NilError(t, err)
+
+	result, err := template.ExecuteTemplate(map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, result, "{\n   \"rules\": [\n      {\n         \"severityLevel\": \"ERROR\"\n      }\n   ]\n}\n")
+}
+
+func TestJsonnetTemplateWithSyntaxErrorLeadsToError(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "profile.jsonnet", []byte(`{ name: }`), 0644))
+
+	_, err := NewTemplate(fs, "profile.jsonnet")
+	assert.ErrorContains(t, err, "invalid jsonnet template profile.jsonnet")
+}
+
+func TestJsonnetTemplateWithMissingVariableLeadsToError(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "profile.jsonnet", []byte(`{ name: std.extVar("name") }`), 0644))
+
+	template, err := NewTemplate(fs, "profile.jsonnet")
+	assert.NilError(t, err)
+
+	_, err = template.ExecuteTemplate(map[string]string{})
+	assert.ErrorContains(t, err, "could not render jsonnet template profile.jsonnet")
+	assert.ErrorContains(t, err, "Undefined external variable: name")
+}
+
+func TestIsJsonnetFile(t *testing.T) {
+	assert.Assert(t, IsJsonnetFile("profile.jsonnet"))
+	assert.Assert(t, IsJsonnetFile("lib/rules.libsonnet"))
+	assert.Assert(t, !IsJsonnetFile("profile.json"))
+	assert.Assert(t, !IsJsonnetFile("jsonnet"))
+}
@@ -0,0 +1,318 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcelliott/lumber"
+	"github.com/spf13/afero"
+)
+
+// Logger carries the logger of messages together with the request and response log files, so several deployments or
+// downloads in one process can log separately. Use NewLogger to create one, or DefaultLogger for the shared Log and
+// the request and response logs set up by SetupLogging. A nil Logger logs like the default logger.
+type Logger struct {
+	// messages logs the messages. It is nil for the default logger, which uses the shared Log at the time of logging,
+	// as SetupLogging replaces it.
+	messages lumber.Logger
+
+	// mutex serializes writes to the request and response log files, as requests are sent concurrently during
+	// parallel deployments. Every entry is written and synced while holding it, so entries are never interleaved.
+	mutex       sync.Mutex
+	requestLog  afero.File
+	responseLog afero.File
+}
+
+// LoggerOptions configure a logger created by NewLogger
+type LoggerOptions struct {
+	// Writer receives the messages of level info and above, or debug if Verbose is set. Messages are discarded if it
+	// is nil. The writer is not closed by the logger.
+	Writer io.Writer
+
+	// Verbose adds debug messages to the messages written to Writer
+	Verbose bool
+
+	// RequestLogFile is the file requests are logged to, if it is not empty. An existing file is truncated.
+	RequestLogFile string
+
+	// ResponseLogFile is the file responses are logged to, if it is not empty. An existing file is truncated.
+	ResponseLogFile string
+}
+
+var defaultLogger = &Logger{}
+
+// DefaultLogger returns the logger writing to the shared Log and the request and response logs set up by SetupLogging
+func DefaultLogger() *Logger {
+	return defaultLogger
+}
+
+// NewLogger creates a logger writing messages to the writer and requests and responses to the log files of the
+// options, which are created in the given filesystem. Close the logger to flush and close the log files.
+func NewLogger(fs afero.Fs, opts LoggerOptions) (*Logger, error) {
+	level := lumber.INFO
+	if opts.Verbose {
+		level = lumber.DEBUG
+	}
+
+	writer := opts.Writer
+	if writer == nil {
+		writer = io.Discard
+	}
+
+	logger := &Logger{messages: lumber.NewBasicLogger(nopWriteCloser{writer}, level)}
+
+	if opts.RequestLogFile != "" {
+		file, err := prepareLogFile(fs, opts.RequestLogFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not create request log %s: %w", opts.RequestLogFile, err)
+		}
+		logger.requestLog = file
+	}
+
+	if opts.ResponseLogFile != "" {
+		file, err := prepareLogFile(fs, opts.ResponseLogFile)
+		if err != nil {
+			logger.Close()
+			return nil, fmt.Errorf("could not create response log %s: %w", opts.ResponseLogFile, err)
+		}
+		logger.responseLog = file
+	}
+
+	return logger, nil
+}
+
+func prepareLogFile(fs afero.Fs, file string) (afero.File, error) {
+	return fs.OpenFile(file, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+func (l *Logger) orDefault() *Logger {
+	if l == nil {
+		return defaultLogger
+	}
+	return l
+}
+
+func (l *Logger) messageLogger() lumber.Logger {
+	if l := l.orDefault(); l.messages != nil {
+		return l.messages
+	}
+	return Log
+}
+
+func (l *Logger) Trace(format string, v ...interface{}) {
+	l.messageLogger().Trace(format, v...)
+}
+
+func (l *Logger) Debug(format string, v ...interface{}) {
+	l.messageLogger().Debug(format, v...)
+}
+
+func (l *Logger) Info(format string, v ...interface{}) {
+	l.messageLogger().Info(format, v...)
+}
+
+func (l *Logger) Warn(format string, v ...interface{}) {
+	l.messageLogger().Warn(format, v...)
+}
+
+func (l *Logger) Error(format string, v ...interface{}) {
+	l.messageLogger().Error(format, v...)
+}
+
+func (l *Logger) Fatal(format string, v ...interface{}) {
+	l.messageLogger().Fatal(format, v...)
+}
+
+// CheckError logs the error with the message and returns true, if err is not nil
+func (l *Logger) CheckError(err error, msg string) bool {
+	if err != nil {
+		l.Error(msg + ": " + err.Error())
+		return true
+	}
+	return false
+}
+
+// WithFields returns a logger of the messages, which attaches the given fields to every log entry. See LogWithFields.
+func (l *Logger) WithFields(fields LogFields) lumber.Logger {
+	return withFields(l.messageLogger(), fields)
+}
+
+// PrintError logs the error like the PrintError function
+func (l *Logger) PrintError(err error) {
+	if ppError, ok := err.(JsonValidationError); ok && ppError.ContainsLineInformation() {
+		ppError.prettyPrint(l)
+	} else {
+		l.Error("\t%s", err)
+	}
+}
+
+func (l *Logger) PrintErrors(errors []error) {
+	for _, err := range errors {
+		l.PrintError(err)
+	}
+}
+
+// IsInteractiveConsole returns whether the messages are written to an interactive console, see IsInteractiveConsole.
+// Only the default logger writes to the console.
+func (l *Logger) IsInteractiveConsole() bool {
+	return l.orDefault() == defaultLogger && IsInteractiveConsole()
+}
+
+func (l *Logger) IsRequestLoggingActive() bool {
+	l = l.orDefault()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.requestLog != nil
+}
+
+func (l *Logger) IsResponseLoggingActive() bool {
+	l = l.orDefault()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.responseLog != nil
+}
+
+func (l *Logger) LogRequest(id string, request *http.Request) error {
+	l = l.orDefault()
+	if !l.IsRequestLoggingActive() {
+		return nil
+	}
+
+	var dumpBody = false
+
+	if contentTypes, ok := request.Header["Content-Type"]; ok {
+		contentType := contentTypes[len(contentTypes)-1]
+
+		dumpBody = shouldDumpBody(contentType)
+	}
+
+	dump, err := httputil.DumpRequestOut(request, dumpBody)
+
+	if err != nil {
+		return err
+	}
+
+	stringDump := truncateBody(redactSecrets(string(dump)), maxLoggedBodyBytes)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// the log might have been closed in the meantime
+	if l.requestLog == nil {
+		return nil
+	}
+
+	_, err = l.requestLog.WriteString(fmt.Sprintf(`Request-ID: %s
+%s
+=========================
+`, id, stringDump))
+
+	if err != nil {
+		return err
+	}
+
+	return l.requestLog.Sync()
+}
+
+// LogResponse writes the response to the response log, together with the duration of the request and its status. The id
+// is the one used to log the request, so both can be matched.
+func (l *Logger) LogResponse(id string, response *http.Response, duration time.Duration) error {
+	l = l.orDefault()
+	if !l.IsResponseLoggingActive() {
+		return nil
+	}
+
+	var dumpBody = false
+
+	if contentTypes, ok := response.Header["Content-Type"]; ok {
+		contentType := contentTypes[len(contentTypes)-1]
+
+		dumpBody = shouldDumpBody(contentType)
+	}
+
+	if dumpBody && strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		decompressed, err := decompressedResponse(response)
+		if err != nil {
+			return err
+		}
+		response = decompressed
+	}
+
+	dump, err := httputil.DumpResponse(response, dumpBody)
+
+	if err != nil {
+		return err
+	}
+
+	stringDump := truncateBody(redactSecrets(string(dump)), maxLoggedBodyBytes)
+
+	var requestId string
+	if id != "" {
+		requestId = fmt.Sprintf("Request-ID: %s\n", id)
+	}
+	summary := fmt.Sprintf("Status: %d\nDuration: %s\n", response.StatusCode, duration.Round(time.Millisecond))
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// the log might have been closed in the meantime
+	if l.responseLog == nil {
+		return nil
+	}
+
+	// a single write, so responses logged concurrently are not interleaved
+	_, err = l.responseLog.WriteString(fmt.Sprintf(`%s%s%s
+=========================
+`, requestId, summary, stringDump))
+
+	if err != nil {
+		return err
+	}
+
+	return l.responseLog.Sync()
+}
+
+// Close flushes and closes the request and response log files. Afterwards, requests and responses are not logged
+// anymore, while messages are still written. It is safe to call more than once.
+func (l *Logger) Close() {
+	l = l.orDefault()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, file := range []*afero.File{&l.requestLog, &l.responseLog} {
+		if *file == nil {
+			continue
+		}
+		if err := (*file).Sync(); err != nil {
+			l.messageLogger().Warn("Could not flush %s: %s", (*file).Name(), err)
+		}
+		if err := (*file).Close(); err != nil {
+			l.messageLogger().Warn("Could not close %s: %s", (*file).Name(), err)
+		}
+		*file = nil
+	}
+}
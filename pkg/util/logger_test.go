@@ -0,0 +1,95 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+func TestNewLoggerWritesMessagesToItsWriter(t *testing.T) {
+	var first, second bytes.Buffer
+
+	firstLog, err := NewLogger(afero.NewMemMapFs(), LoggerOptions{Writer: &first})
+	assert.NilError(t, err)
+	secondLog, err := NewLogger(afero.NewMemMapFs(), LoggerOptions{Writer: &second, Verbose: true})
+	assert.NilError(t, err)
+
+	firstLog.Info("first info")
+	firstLog.Debug("first debug")
+	secondLog.Debug("second debug")
+
+	assert.Check(t, strings.Contains(first.String(), "first info"), first.String())
+	assert.Check(t, !strings.Contains(first.String(), "first debug"), first.String())
+	assert.Check(t, !strings.Contains(first.String(), "second"), first.String())
+	assert.Check(t, strings.Contains(second.String(), "second debug"), second.String())
+	assert.Check(t, !strings.Contains(second.String(), "first"), second.String())
+}
+
+func TestNewLoggerLogsRequestsAndResponsesToFilesOfFs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger, err := NewLogger(fs, LoggerOptions{RequestLogFile: "requests.log", ResponseLogFile: "responses.log"})
+	assert.NilError(t, err)
+
+	assert.Check(t, logger.IsRequestLoggingActive())
+	assert.Check(t, logger.IsResponseLoggingActive())
+	assert.Check(t, !IsRequestLoggingActive())
+
+	request, err := http.NewRequest("GET", "https://my-environment.live.dynatrace.com/api/config/v1/dashboards", nil)
+	assert.NilError(t, err)
+	assert.NilError(t, logger.LogRequest("request-1", request))
+
+	logger.Close()
+	assert.Check(t, !logger.IsRequestLoggingActive())
+	assert.NilError(t, logger.LogRequest("request-2", request))
+
+	content, err := afero.ReadFile(fs, "requests.log")
+	assert.NilError(t, err)
+	assert.Check(t, strings.HasPrefix(string(content), "Request-ID: request-1\nGET /api/config/v1/dashboards HTTP/1.1\r\n"), string(content))
+	assert.Check(t, !strings.Contains(string(content), "request-2"), string(content))
+
+	exists, err := afero.Exists(fs, "responses.log")
+	assert.NilError(t, err)
+	assert.Check(t, exists)
+}
+
+func TestNewLoggerFailsIfRequestLogCanNotBeCreated(t *testing.T) {
+	_, err := NewLogger(afero.NewReadOnlyFs(afero.NewMemMapFs()), LoggerOptions{RequestLogFile: "requests.log"})
+	assert.ErrorContains(t, err, "could not create request log requests.log")
+}
+
+func TestNilLoggerLogsToSharedLog(t *testing.T) {
+	var out bytes.Buffer
+	restore := RedirectLog(&out, false)
+	defer restore()
+
+	var logger *Logger
+	logger.Info("message of nil logger")
+	DefaultLogger().Info("message of default logger")
+
+	assert.Check(t, strings.Contains(out.String(), "message of nil logger"), out.String())
+	assert.Check(t, strings.Contains(out.String(), "message of default logger"), out.String())
+	assert.Check(t, !logger.IsRequestLoggingActive())
+}
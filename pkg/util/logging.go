@@ -17,50 +17,119 @@
 package util
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/jcelliott/lumber"
+	"github.com/spf13/afero"
 )
 
 // Log is the shared Lumber Logger logging to console and after calling SetupLogging also to file
-var Log lumber.Logger = lumber.NewConsoleLogger(lumber.INFO)
+var Log lumber.Logger = lumber.NewBasicLogger(nopWriteCloser{console}, lumber.INFO)
 
-var requestLogFile *os.File
-var responseLogFile *os.File
+// closeSessionLog flushes and closes the session log file. It is nil if no session log file is open.
+var closeSessionLog func()
 
-// SetupLogging is used to initialize the shared file Logger once the necessary setup config is available
-func SetupLogging(verbose bool) error {
-	multiLog := lumber.NewMultiLogger()
-	consoleLog := lumber.NewConsoleLogger(lumber.INFO)
-	if verbose {
-		consoleLog.Level(lumber.DEBUG)
+// alwaysRedactedHeaders contains the headers carrying credentials, which are never written to the request/response logs
+var alwaysRedactedHeaders = []string{"Authorization", "Api-Token", "Proxy-Authorization"}
+
+// redactedHeaders contains the headers which are masked in addition to alwaysRedactedHeaders.
+// It can be overwritten using the MONACO_LOG_REDACT_HEADERS environment variable (comma separated list)
+var redactedHeaders = []string{"Cookie", "Set-Cookie"}
+
+const redactedValue = "*****"
+
+// defaultMaxLoggedBodyBytes is the size after which bodies in the request and response logs are truncated, if
+// MONACO_LOG_MAX_BODY_BYTES is not set
+const defaultMaxLoggedBodyBytes = 8 * 1024
+
+// maxLoggedBodyBytes is the size after which bodies in the request and response logs are truncated. 0 disables
+// truncation.
+var maxLoggedBodyBytes = defaultMaxLoggedBodyBytes
+
+// tokenPattern matches Dynatrace api tokens in the 1.205+ token format
+var tokenPattern = regexp.MustCompile(`dt0c01\.[A-Za-z0-9]+\.[A-Za-z0-9]+`)
+
+// defaultLogDirectory is used for the session log files, if MONACO_LOG_DIR is not set
+const defaultLogDirectory = ".logs"
+
+// defaultLogRetention is the number of session log files kept, if MONACO_LOG_RETENTION is not set
+const defaultLogRetention = 100
+
+// sessionLogPattern matches the names of the session log files created by createSessionLogFile
+var sessionLogPattern = regexp.MustCompile(`^\d{8}-\d{6}\.log$`)
+
+// logFormatJson is the value of MONACO_LOG_FORMAT switching console and file logs to one json object per line
+const logFormatJson = "json"
+
+// quietConsole is set by SetupLogging if only warnings and errors are logged to the console
+var quietConsole bool
+
+// SetupLogging is used to initialize the shared file Logger once the necessary setup config is available.
+// Verbose logs debug messages to the console, quiet only warnings and errors. Without either flag, the console level
+// can be set using MONACO_CONSOLE_LEVEL. The log file contains all messages, unless MONACO_FILE_LEVEL is set.
+// Level labels on the console are colored if it is a terminal, unless noColor is set or NO_COLOR is defined.
+// Timestamps switches console and file log from local time in seconds to ISO-8601 times in milliseconds.
+func SetupLogging(verbose bool, quiet bool, noColor bool, timestamps bool) error {
+	consoleLevel, err := getConsoleLevel(verbose, quiet)
+	if err != nil {
+		return err
 	}
-	multiLog.AddLoggers(consoleLog)
 
-	if _, err := os.Stat(".logs"); os.IsNotExist(err) {
-		err = os.Mkdir(".logs", 0777)
+	fileLevel, err := getFileLevel()
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
-			FailOnError(err, "could not create directory")
-		}
+	retention, err := getLogRetention()
+	if err != nil {
+		return err
 	}
 
-	logName := ".logs" + string(os.PathSeparator) + time.Now().Format("20060102-150405") + ".log"
-	fileLog, err := lumber.NewAppendLogger(logName)
+	logName, err := createSessionLogFile(afero.NewOsFs(), getLogDirectory(), time.Now())
 
 	if err != nil {
 		return err
 	}
 
-	fileLog.Level(lumber.DEBUG)
-	multiLog.AddLoggers(fileLog)
-	Log = multiLog
+	var logger lumber.Logger
+	var closeFile func()
+	if strings.EqualFold(os.Getenv("MONACO_LOG_FORMAT"), logFormatJson) {
+		logger, closeFile, err = newJsonFormatLogger(logName, consoleLevel, fileLevel)
+	} else {
+		logger, closeFile, err = newTextFormatLogger(logName, consoleLevel, fileLevel, shouldUseColors(noColor), getTimeFormat(timestamps))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	Log = logger
+	closeSessionLog = closeFile
+	quietConsole = quiet
+
+	if err := pruneSessionLogs(afero.NewOsFs(), getLogDirectory(), retention); err != nil {
+		Log.Warn("Could not remove old log files: %s", err)
+	}
+
+	setupLogRedaction()
+
+	maxLoggedBodyBytes, err = getMaxLoggedBodyBytes()
+	if err != nil {
+		return err
+	}
 
 	err = setupRequestLog()
 
@@ -71,6 +140,322 @@ func SetupLogging(verbose bool) error {
 	return setupResponseLog()
 }
 
+// logRedirected is set while the log is written to a writer given to RedirectLog instead of the console
+var logRedirected bool
+
+// RedirectLog writes the shared logger to the writer until the returned function is called, which restores the previous
+// logger. Messages of level info and above are written, or debug and above if verbose is set. While the log is
+// redirected, the console is not interactive, so no progress bar is drawn on it.
+func RedirectLog(w io.Writer, verbose bool) (restore func()) {
+	level := lumber.INFO
+	if verbose {
+		level = lumber.DEBUG
+	}
+
+	previous, previousRedirected := Log, logRedirected
+	// the writer is owned by the caller, so it is not closed with the logger
+	Log = lumber.NewBasicLogger(nopWriteCloser{w}, level)
+	logRedirected = true
+
+	return func() {
+		Log, logRedirected = previous, previousRedirected
+	}
+}
+
+// logLevels are the values of MONACO_CONSOLE_LEVEL and MONACO_FILE_LEVEL
+var logLevels = map[string]int{
+	"trace": lumber.TRACE,
+	"debug": lumber.DEBUG,
+	"info":  lumber.INFO,
+	"warn":  lumber.WARN,
+	"error": lumber.ERROR,
+}
+
+// getConsoleLevel returns the level of the console log for the verbose and quiet flags, which can't be combined.
+// If neither flag is set, MONACO_CONSOLE_LEVEL is used, which defaults to info.
+func getConsoleLevel(verbose bool, quiet bool) (int, error) {
+	switch {
+	case verbose && quiet:
+		return 0, fmt.Errorf("--verbose and --quiet can't be combined")
+	case verbose:
+		return lumber.DEBUG, nil
+	case quiet:
+		return lumber.WARN, nil
+	default:
+		return getLevelFromEnv("MONACO_CONSOLE_LEVEL", lumber.INFO)
+	}
+}
+
+// getFileLevel returns the level of the session log file set using MONACO_FILE_LEVEL, which defaults to debug
+func getFileLevel() (int, error) {
+	return getLevelFromEnv("MONACO_FILE_LEVEL", lumber.DEBUG)
+}
+
+func getLevelFromEnv(name string, defaultLevel int) (int, error) {
+	value, found := os.LookupEnv(name)
+	if !found || strings.TrimSpace(value) == "" {
+		return defaultLevel, nil
+	}
+
+	level, known := logLevels[strings.ToLower(strings.TrimSpace(value))]
+	if !known {
+		return 0, fmt.Errorf("invalid log level %s in %s: must be one of trace, debug, info, warn or error", value, name)
+	}
+	return level, nil
+}
+
+// IsQuietConsole returns whether only warnings and errors are written to the console, in which case no progress
+// should be printed either
+func IsQuietConsole() bool {
+	return quietConsole
+}
+
+// IsInteractiveConsole returns whether the console logs are written to a terminal in the human-readable format,
+// which allows to rewrite the current line of the console, e.g. for progress bars
+func IsInteractiveConsole() bool {
+	if logRedirected {
+		return false
+	}
+
+	if strings.EqualFold(os.Getenv("MONACO_LOG_FORMAT"), logFormatJson) {
+		return false
+	}
+
+	return isTerminal(os.Stdout)
+}
+
+// IsInteractiveInput returns whether the standard input is a terminal, so the user can be asked for input
+func IsInteractiveInput() bool {
+	return isTerminal(os.Stdin)
+}
+
+// isTerminal returns whether the file is a terminal, rather than e.g. a pipe or a regular file
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// shouldUseColors returns whether the console log is colored. Colors are only used on a terminal and can be disabled
+// using --no-color or by setting NO_COLOR to any non-empty value (see https://no-color.org).
+func shouldUseColors(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// isoTimeFormat is the ISO-8601 time format of the text logs if --timestamps is set, which allows to correlate log
+// entries with events in other systems. The json logs always contain ISO-8601 times.
+const isoTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// getTimeFormat returns the time format of the text logs
+func getTimeFormat(timestamps bool) string {
+	if timestamps {
+		return isoTimeFormat
+	}
+	return lumber.TIMEFORMAT
+}
+
+// coloredLevels are the level labels of the console log if colors are used, in the order of the lumber levels.
+// They have the same width as the uncolored labels, so messages stay aligned.
+var coloredLevels = []string{
+	"\033[90mTRACE\033[0m",
+	"\033[90mDEBUG\033[0m",
+	"\033[36mINFO \033[0m",
+	"\033[33mWARN \033[0m",
+	"\033[31mERROR\033[0m",
+	"\033[1;31mFATAL\033[0m",
+}
+
+// getLogDirectory returns the directory configured via MONACO_LOG_DIR or the default log directory
+func getLogDirectory() string {
+	if logDir, found := os.LookupEnv("MONACO_LOG_DIR"); found && strings.TrimSpace(logDir) != "" {
+		return logDir
+	}
+	return defaultLogDirectory
+}
+
+// createSessionLogFile creates the log directory (if it does not exist yet) as well as the timestamped log file
+// for this session and returns the path of the log file
+func createSessionLogFile(fs afero.Fs, logDir string, now time.Time) (string, error) {
+	err := fs.MkdirAll(logDir, 0777)
+
+	if err != nil {
+		return "", fmt.Errorf("could not create log directory %s: %w", logDir, err)
+	}
+
+	logName := filepath.Join(logDir, now.Format("20060102-150405")+".log")
+	file, err := fs.OpenFile(logName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return "", fmt.Errorf("log directory %s is not writable: %w", logDir, err)
+	}
+
+	return logName, file.Close()
+}
+
+// getLogRetention returns the number of session log files to keep configured via MONACO_LOG_RETENTION or the default.
+// 0 keeps all log files.
+func getLogRetention() (int, error) {
+	value, found := os.LookupEnv("MONACO_LOG_RETENTION")
+	if !found || strings.TrimSpace(value) == "" {
+		return defaultLogRetention, nil
+	}
+
+	retention, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || retention < 0 {
+		return 0, fmt.Errorf("invalid number of log files %s in MONACO_LOG_RETENTION: must be a number, or 0 to keep all log files", value)
+	}
+	return retention, nil
+}
+
+// pruneSessionLogs removes the oldest session log files in the log directory, so at most retention files are kept.
+// Other files in the directory (e.g. request logs) are never removed. A retention of 0 keeps all files.
+func pruneSessionLogs(fs afero.Fs, logDir string, retention int) error {
+	if retention <= 0 {
+		return nil
+	}
+
+	files, err := afero.ReadDir(fs, logDir)
+	if err != nil {
+		return err
+	}
+
+	var logs []string
+	for _, file := range files {
+		if !file.IsDir() && sessionLogPattern.MatchString(file.Name()) {
+			logs = append(logs, file.Name())
+		}
+	}
+
+	// the names start with the timestamp of the session, so sorting them sorts the files from oldest to newest
+	sort.Strings(logs)
+
+	for len(logs) > retention {
+		if err := fs.Remove(filepath.Join(logDir, logs[0])); err != nil {
+			return err
+		}
+		logs = logs[1:]
+	}
+	return nil
+}
+
+// newTextFormatLogger creates the default human-readable logger writing to console and the given log file. The returned
+// function closes the log file, without closing the console. Colors are only used on the console.
+func newTextFormatLogger(logName string, consoleLevel int, fileLevel int, colors bool, timeFormat string) (lumber.Logger, func(), error) {
+	// the console is shared by all loggers, so it is not closed with the logger
+	consoleLog := lumber.NewBasicLogger(nopWriteCloser{console}, consoleLevel)
+	consoleLog.TimeFormat(timeFormat)
+	if colors {
+		consoleLog.SetLevels(coloredLevels)
+	}
+
+	multiLog := lumber.NewMultiLogger()
+	multiLog.AddLoggers(consoleLog)
+
+	fileLog, err := lumber.NewAppendLogger(logName)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileLog.Level(fileLevel)
+	fileLog.TimeFormat(timeFormat)
+	multiLog.AddLoggers(fileLog)
+
+	return &consoleLevelLogger{MultiLogger: multiLog, console: consoleLog}, fileLog.Close, nil
+}
+
+// consoleLevelLogger is a text format logger, whose level changes only apply to the console, so the level of the log
+// file stays independent of the verbosity of the console
+type consoleLevelLogger struct {
+	*lumber.MultiLogger
+	console lumber.Logger
+}
+
+func (l *consoleLevelLogger) Level(level int) {
+	l.console.Level(level)
+}
+
+// newJsonFormatLogger creates a logger writing one json object per log entry to console and the given log file. The
+// returned function closes the log file.
+func newJsonFormatLogger(logName string, consoleLevel int, fileLevel int) (lumber.Logger, func(), error) {
+	jsonLog := newJsonLogger()
+	jsonLog.addSink(os.Stdout, consoleLevel)
+
+	file, err := os.OpenFile(logName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jsonLog.addClosableSink(file, fileLevel)
+
+	return jsonLog, func() {
+		_ = file.Sync()
+		jsonLog.Close()
+	}, nil
+}
+
+func setupLogRedaction() {
+	if headers, found := os.LookupEnv("MONACO_LOG_REDACT_HEADERS"); found {
+		redactedHeaders = parseHeaderList(headers)
+		Log.Debug("redacting headers %v in request and response logs", redactedHeaders)
+	}
+}
+
+// getMaxLoggedBodyBytes returns the size configured via MONACO_LOG_MAX_BODY_BYTES or the default size
+func getMaxLoggedBodyBytes() (int, error) {
+	value, found := os.LookupEnv("MONACO_LOG_MAX_BODY_BYTES")
+	if !found || strings.TrimSpace(value) == "" {
+		return defaultMaxLoggedBodyBytes, nil
+	}
+
+	size, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %s in MONACO_LOG_MAX_BODY_BYTES: must be a number of bytes, or 0 to log bodies in full", value)
+	}
+	return size, nil
+}
+
+// truncateBody shortens the body of the http dump to maxBytes, appending a marker with the number of omitted bytes.
+// Headers are never truncated. The body is cut at the start of a character, so multi-byte characters stay intact.
+func truncateBody(dump string, maxBytes int) string {
+	headerEnd := strings.Index(dump, "\r\n\r\n")
+	if maxBytes <= 0 || headerEnd < 0 {
+		return dump
+	}
+
+	bodyStart := headerEnd + len("\r\n\r\n")
+	if len(dump)-bodyStart <= maxBytes {
+		return dump
+	}
+
+	end := bodyStart + maxBytes
+	for end > bodyStart && !utf8.RuneStart(dump[end]) {
+		end--
+	}
+
+	return fmt.Sprintf("%s...[truncated %d bytes]", dump[:end], len(dump)-end)
+}
+
+func parseHeaderList(headers string) []string {
+	result := make([]string, 0)
+
+	for _, header := range strings.Split(headers, ",") {
+		header = strings.TrimSpace(header)
+
+		if header != "" {
+			result = append(result, header)
+		}
+	}
+
+	return result
+}
+
 func setupRequestLog() error {
 	if logFilePath, found := os.LookupEnv("MONACO_REQUEST_LOG"); found {
 		logFilePath, err := filepath.Abs(logFilePath)
@@ -80,13 +465,15 @@ func setupRequestLog() error {
 		}
 
 		Log.Debug("request log activated at %s", logFilePath)
-		handle, err := prepareLogFile(logFilePath)
+		handle, err := prepareLogFile(afero.NewOsFs(), logFilePath)
 
 		if err != nil {
 			return err
 		}
 
-		requestLogFile = handle
+		defaultLogger.mutex.Lock()
+		defaultLogger.requestLog = handle
+		defaultLogger.mutex.Unlock()
 	} else {
 		Log.Debug("request log not activated")
 	}
@@ -103,13 +490,15 @@ func setupResponseLog() error {
 		}
 
 		Log.Debug("response log activated at %s", logFilePath)
-		handle, err := prepareLogFile(logFilePath)
+		handle, err := prepareLogFile(afero.NewOsFs(), logFilePath)
 
 		if err != nil {
 			return err
 		}
 
-		responseLogFile = handle
+		defaultLogger.mutex.Lock()
+		defaultLogger.responseLog = handle
+		defaultLogger.mutex.Unlock()
 	} else {
 		Log.Debug("response log not activated")
 	}
@@ -117,102 +506,120 @@ func setupResponseLog() error {
 	return nil
 }
 
-func prepareLogFile(file string) (*os.File, error) {
-	return os.OpenFile(file, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+// CloseLogging flushes and closes the session log file as well as the request and response log files. Afterwards, logs
+// are only written to the console, and requests and responses are not logged anymore. It is safe to call if logging
+// was never set up, and more than once.
+func CloseLogging() {
+	defaultLogger.Close()
+
+	if closeSessionLog != nil {
+		closeSessionLog()
+		closeSessionLog = nil
+	}
 }
 
+// IsRequestLoggingActive returns whether the default logger logs requests
 func IsRequestLoggingActive() bool {
-	return requestLogFile != nil
+	return defaultLogger.IsRequestLoggingActive()
 }
 
+// IsResponseLoggingActive returns whether the default logger logs responses
 func IsResponseLoggingActive() bool {
-	return responseLogFile != nil
+	return defaultLogger.IsResponseLoggingActive()
 }
 
+// LogRequest writes the request to the request log of the default logger
 func LogRequest(id string, request *http.Request) error {
-	if !IsRequestLoggingActive() {
-		return nil
-	}
-
-	var dumpBody = false
+	return defaultLogger.LogRequest(id, request)
+}
 
-	if contentTypes, ok := request.Header["Content-Type"]; ok {
-		contentType := contentTypes[len(contentTypes)-1]
+// LogResponse writes the response to the response log of the default logger, see Logger.LogResponse
+func LogResponse(id string, response *http.Response, duration time.Duration) error {
+	return defaultLogger.LogResponse(id, response, duration)
+}
 
-		dumpBody = shouldDumpBody(contentType)
+// decompressedResponse returns a copy of the gzip encoded response with the decompressed body, to log it readable.
+// The body of the response is restored, so it can still be read afterwards.
+func decompressedResponse(response *http.Response) (*http.Response, error) {
+	compressed, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
 	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(compressed))
 
-	dump, err := httputil.DumpRequestOut(request, dumpBody)
-
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("could not decompress gzip encoded response body: %w", err)
 	}
+	defer reader.Close()
 
-	stringDump := string(dump)
-
-	_, err = requestLogFile.WriteString(fmt.Sprintf(`Request-ID: %s
-%s
-=========================
-`, id, stringDump))
-
+	body, err := ioutil.ReadAll(reader)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("could not decompress gzip encoded response body: %w", err)
 	}
 
-	return requestLogFile.Sync()
+	decompressed := *response
+	decompressed.Header = response.Header.Clone()
+	decompressed.Header.Del("Content-Encoding")
+	decompressed.Header.Del("Content-Length")
+	decompressed.ContentLength = int64(len(body))
+	decompressed.Uncompressed = true
+	decompressed.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return &decompressed, nil
 }
 
-func LogResponse(id string, response *http.Response) error {
-	if !IsResponseLoggingActive() {
-		return nil
+func shouldDumpBody(contentType string) bool {
+	if strings.HasPrefix(contentType, "text/") {
+		return true
 	}
 
-	var dumpBody = false
-
-	if contentTypes, ok := response.Header["Content-Type"]; ok {
-		contentType := contentTypes[len(contentTypes)-1]
+	if strings.HasPrefix(contentType, "application/json") {
+		return true
+	}
 
-		dumpBody = shouldDumpBody(contentType)
+	if strings.HasPrefix(contentType, "application/xml") {
+		return true
 	}
 
-	dump, err := httputil.DumpResponse(response, dumpBody)
+	return false
+}
 
-	if err != nil {
-		return err
-	}
+// redactSecrets masks the values of all redacted headers as well as any api token contained in the given
+// http dump, so that log files can be shared without leaking credentials
+func redactSecrets(dump string) string {
+	lines := strings.Split(dump, "\n")
 
-	if id != "" {
-		_, err = responseLogFile.WriteString(fmt.Sprintf("Request-ID: %s\n", id))
+	// the first line contains the request or status line, headers follow until the first empty line
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\r")
 
-		if err != nil {
-			return err
+		if line == "" {
+			break
 		}
-	}
-
-	stringDump := string(dump)
 
-	_, err = responseLogFile.WriteString(fmt.Sprintf(`%s
-=========================
-`, stringDump))
+		separatorIndex := strings.Index(line, ":")
 
-	if err != nil {
-		return err
+		if separatorIndex > 0 && isRedactedHeader(line[:separatorIndex]) {
+			lines[i] = line[:separatorIndex] + ": " + redactedValue + strings.TrimPrefix(lines[i], line)
+		}
 	}
 
-	return responseLogFile.Sync()
+	return tokenPattern.ReplaceAllString(strings.Join(lines, "\n"), redactedValue)
 }
 
-func shouldDumpBody(contentType string) bool {
-	if strings.HasPrefix("text/", contentType) {
-		return true
-	}
+func isRedactedHeader(header string) bool {
+	header = strings.TrimSpace(header)
 
-	if strings.HasPrefix("application/json", contentType) {
-		return true
+	for _, redacted := range alwaysRedactedHeaders {
+		if strings.EqualFold(header, redacted) {
+			return true
+		}
 	}
 
-	if strings.HasPrefix("application/xml", contentType) {
-		return true
+	for _, redacted := range redactedHeaders {
+		if strings.EqualFold(header, redacted) {
+			return true
+		}
 	}
 
 	return false
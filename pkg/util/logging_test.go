@@ -0,0 +1,563 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcelliott/lumber"
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
+)
+
+const requestDump = "POST /api/config/v1/dashboards HTTP/1.1\r\n" +
+	"Host: my-environment.live.dynatrace.com\r\n" +
+	"Authorization: Api-Token dt0c01.ABCDEFGHIJKLMNOPQRSTUVWX.ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789\r\n" +
+	"Content-Type: application/json\r\n" +
+	"X-Custom-Secret: my-secret\r\n" +
+	"\r\n" +
+	"{\"token\": \"dt0c01.ABCDEFGHIJKLMNOPQRSTUVWX.ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789\", \"authorization\": \"keep\"}"
+
+func TestRedactSecretsMasksAuthorizationHeader(t *testing.T) {
+	redacted := redactSecrets(requestDump)
+
+	assert.Check(t, strings.Contains(redacted, "Authorization: *****\r\n"))
+	assert.Check(t, !strings.Contains(redacted, "ABCDEFGHIJKLMNOPQRSTUVWX"))
+	assert.Check(t, strings.Contains(redacted, "Host: my-environment.live.dynatrace.com\r\n"))
+	assert.Check(t, strings.Contains(redacted, "X-Custom-Secret: my-secret\r\n"))
+}
+
+func TestRedactSecretsMasksProxyAuthorizationHeader(t *testing.T) {
+	dump := "CONNECT my-environment.live.dynatrace.com:443 HTTP/1.1\r\n" +
+		"Host: my-environment.live.dynatrace.com:443\r\n" +
+		"Proxy-Authorization: Basic dXNlcjpzZWNyZXQ=\r\n" +
+		"\r\n"
+
+	redacted := redactSecrets(dump)
+
+	assert.Check(t, strings.Contains(redacted, "Proxy-Authorization: *****\r\n"))
+	assert.Check(t, !strings.Contains(redacted, "dXNlcjpzZWNyZXQ="))
+}
+
+func TestRedactSecretsMasksTokensInBody(t *testing.T) {
+	redacted := redactSecrets(requestDump)
+
+	assert.Check(t, strings.HasSuffix(redacted, "{\"token\": \"*****\", \"authorization\": \"keep\"}"))
+}
+
+func TestRedactSecretsMasksConfiguredHeaders(t *testing.T) {
+	defer func(original []string) { redactedHeaders = original }(redactedHeaders)
+
+	redactedHeaders = parseHeaderList(" x-custom-secret, ,Cookie")
+	assert.DeepEqual(t, redactedHeaders, []string{"x-custom-secret", "Cookie"})
+
+	redacted := redactSecrets(requestDump)
+
+	assert.Check(t, strings.Contains(redacted, "X-Custom-Secret: *****\r\n"))
+	assert.Check(t, strings.Contains(redacted, "Authorization: *****\r\n"))
+}
+
+func TestRedactSecretsDoesNotTouchBodyLookingLikeHeader(t *testing.T) {
+	dump := "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\n\r\nAuthorization: not-a-header"
+
+	assert.Equal(t, redactSecrets(dump), dump)
+}
+
+func TestShouldDumpBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"text/plain", true},
+		{"text/html; charset=utf-8", true},
+		{"application/xml", true},
+		{"application/octet-stream", false},
+		{"multipart/form-data; boundary=abc", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			assert.Equal(t, shouldDumpBody(test.contentType), test.expected)
+		})
+	}
+}
+
+func TestCreateSessionLogFileCreatesFileInConfiguredDirectory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2022, 5, 4, 13, 37, 42, 0, time.UTC)
+
+	logName, err := createSessionLogFile(fs, "/var/log/monaco", now)
+	assert.NilError(t, err)
+	assert.Equal(t, logName, filepath.Join("/var/log/monaco", "20220504-133742.log"))
+
+	exists, err := afero.Exists(fs, logName)
+	assert.NilError(t, err)
+	assert.Check(t, exists)
+}
+
+func TestCreateSessionLogFileWorksWithExistingDirectory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, fs.MkdirAll(".logs", 0777))
+
+	logName, err := createSessionLogFile(fs, ".logs", time.Now())
+	assert.NilError(t, err)
+
+	exists, err := afero.Exists(fs, logName)
+	assert.NilError(t, err)
+	assert.Check(t, exists)
+}
+
+func TestCreateSessionLogFileFailsOnReadOnlyFilesystem(t *testing.T) {
+	fs := afero.NewReadOnlyFs(afero.NewMemMapFs())
+
+	_, err := createSessionLogFile(fs, ".logs", time.Now())
+	assert.ErrorContains(t, err, "could not create log directory .logs")
+}
+
+func TestGetLogDirectory(t *testing.T) {
+	defer os.Unsetenv("MONACO_LOG_DIR")
+
+	os.Setenv("MONACO_LOG_DIR", "")
+	assert.Equal(t, getLogDirectory(), ".logs")
+
+	os.Setenv("MONACO_LOG_DIR", "/tmp/monaco-logs")
+	assert.Equal(t, getLogDirectory(), "/tmp/monaco-logs")
+}
+
+func TestCloseLoggingFlushesRequestLog(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "requests.log")
+	SetEnv(t, "MONACO_REQUEST_LOG", logFile)
+	defer UnsetEnv(t, "MONACO_REQUEST_LOG")
+	assert.NilError(t, setupRequestLog())
+
+	request, err := http.NewRequest("GET", "https://my-environment.live.dynatrace.com/api/config/v1/dashboards", nil)
+	assert.NilError(t, err)
+	assert.NilError(t, LogRequest("request-1", request))
+
+	CloseLogging()
+	assert.Check(t, !IsRequestLoggingActive())
+
+	content, err := ioutil.ReadFile(logFile)
+	assert.NilError(t, err)
+	assert.Check(t, strings.HasPrefix(string(content), "Request-ID: request-1\nGET /api/config/v1/dashboards HTTP/1.1\r\n"), string(content))
+	assert.Check(t, strings.HasSuffix(string(content), "=========================\n"), string(content))
+
+	// requests logged after closing are dropped
+	assert.NilError(t, LogRequest("request-2", request))
+}
+
+func TestCloseLoggingFlushesSessionLog(t *testing.T) {
+	logName := filepath.Join(t.TempDir(), "session.log")
+
+	logger, closeFile, err := newTextFormatLogger(logName, lumber.ERROR, lumber.DEBUG, false, lumber.TIMEFORMAT)
+	assert.NilError(t, err)
+
+	previous := Log
+	Log, closeSessionLog = logger, closeFile
+	defer func() { Log = previous }()
+
+	Log.Info("last entry before exit")
+	CloseLogging()
+
+	content, err := ioutil.ReadFile(logName)
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(string(content), "last entry before exit"), string(content))
+	assert.Check(t, closeSessionLog == nil)
+}
+
+func TestCloseLoggingWithoutActiveLogsDoesNothing(t *testing.T) {
+	CloseLogging()
+	CloseLogging()
+}
+
+func TestGetConsoleLevel(t *testing.T) {
+	level, err := getConsoleLevel(false, false)
+	assert.NilError(t, err)
+	assert.Equal(t, level, lumber.INFO)
+
+	level, err = getConsoleLevel(true, false)
+	assert.NilError(t, err)
+	assert.Equal(t, level, lumber.DEBUG)
+
+	level, err = getConsoleLevel(false, true)
+	assert.NilError(t, err)
+	assert.Equal(t, level, lumber.WARN)
+
+	_, err = getConsoleLevel(true, true)
+	assert.ErrorContains(t, err, "--verbose and --quiet can't be combined")
+}
+
+func TestSetupLoggingQuietStillWritesInfoToLogFile(t *testing.T) {
+	logDir := t.TempDir()
+	SetEnv(t, "MONACO_LOG_DIR", logDir)
+	defer UnsetEnv(t, "MONACO_LOG_DIR")
+
+	previous := Log
+	defer func() {
+		CloseLogging()
+		Log = previous
+		quietConsole = false
+	}()
+
+	assert.NilError(t, SetupLogging(false, true, false, false))
+	assert.Check(t, IsQuietConsole())
+
+	Log.Info("info entry in quiet mode")
+	CloseLogging()
+
+	files, err := ioutil.ReadDir(logDir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 1)
+
+	content, err := ioutil.ReadFile(filepath.Join(logDir, files[0].Name()))
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(string(content), "info entry in quiet mode"), string(content))
+}
+
+func TestGetConsoleLevelFromEnvironment(t *testing.T) {
+	SetEnv(t, "MONACO_CONSOLE_LEVEL", "Warn")
+	defer UnsetEnv(t, "MONACO_CONSOLE_LEVEL")
+
+	level, err := getConsoleLevel(false, false)
+	assert.NilError(t, err)
+	assert.Equal(t, level, lumber.WARN)
+
+	// flags take precedence over the environment variable
+	level, err = getConsoleLevel(true, false)
+	assert.NilError(t, err)
+	assert.Equal(t, level, lumber.DEBUG)
+}
+
+func TestGetFileLevel(t *testing.T) {
+	defer UnsetEnv(t, "MONACO_FILE_LEVEL")
+
+	UnsetEnv(t, "MONACO_FILE_LEVEL")
+	level, err := getFileLevel()
+	assert.NilError(t, err)
+	assert.Equal(t, level, lumber.DEBUG)
+
+	SetEnv(t, "MONACO_FILE_LEVEL", "info")
+	level, err = getFileLevel()
+	assert.NilError(t, err)
+	assert.Equal(t, level, lumber.INFO)
+
+	SetEnv(t, "MONACO_FILE_LEVEL", "verbose")
+	_, err = getFileLevel()
+	assert.ErrorContains(t, err, "invalid log level verbose in MONACO_FILE_LEVEL")
+}
+
+func TestFileLevelIsIndependentOfConsoleLevel(t *testing.T) {
+	logName := filepath.Join(t.TempDir(), "session.log")
+
+	logger, closeFile, err := newTextFormatLogger(logName, lumber.DEBUG, lumber.WARN, false, lumber.TIMEFORMAT)
+	assert.NilError(t, err)
+
+	logger.Debug("debug entry")
+	logger.Warn("warn entry")
+	closeFile()
+
+	content, err := ioutil.ReadFile(logName)
+	assert.NilError(t, err)
+	assert.Check(t, !strings.Contains(string(content), "debug entry"), string(content))
+	assert.Check(t, strings.Contains(string(content), "warn entry"), string(content))
+}
+
+func TestShouldUseColorsIsDisabledByNoColor(t *testing.T) {
+	SetEnv(t, "NO_COLOR", "1")
+	defer UnsetEnv(t, "NO_COLOR")
+
+	assert.Check(t, !shouldUseColors(false))
+	assert.Check(t, !shouldUseColors(true))
+}
+
+func TestShouldUseColorsIsDisabledByFlag(t *testing.T) {
+	UnsetEnv(t, "NO_COLOR")
+
+	assert.Check(t, !shouldUseColors(true))
+}
+
+func TestIsTerminalIsFalseForRegularFiles(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	assert.NilError(t, err)
+	defer file.Close()
+
+	assert.Check(t, !isTerminal(file))
+}
+
+func TestColoredLevelsHaveSameWidthAsUncoloredLevels(t *testing.T) {
+	color := regexp.MustCompile("\033\\[[0-9;]*m")
+
+	for i, label := range coloredLevels {
+		assert.Equal(t, color.ReplaceAllString(label, ""), lumber.LvlStr(i))
+	}
+}
+
+func TestFileLogIsNotColored(t *testing.T) {
+	logName := filepath.Join(t.TempDir(), "session.log")
+
+	logger, closeFile, err := newTextFormatLogger(logName, lumber.FATAL, lumber.DEBUG, true, lumber.TIMEFORMAT)
+	assert.NilError(t, err)
+
+	logger.Warn("warn entry")
+	closeFile()
+
+	content, err := ioutil.ReadFile(logName)
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(string(content), "WARN  warn entry"), string(content))
+	assert.Check(t, !strings.Contains(string(content), "\033["), string(content))
+}
+
+func TestTimestampsUseIsoTimeFormat(t *testing.T) {
+	assert.Equal(t, getTimeFormat(false), lumber.TIMEFORMAT)
+	assert.Equal(t, getTimeFormat(true), isoTimeFormat)
+
+	logName := filepath.Join(t.TempDir(), "session.log")
+
+	logger, closeFile, err := newTextFormatLogger(logName, lumber.FATAL, lumber.DEBUG, true, getTimeFormat(true))
+	assert.NilError(t, err)
+
+	logger.Info("info entry")
+	closeFile()
+
+	content, err := ioutil.ReadFile(logName)
+	assert.NilError(t, err)
+	iso := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}(Z|[+-]\d{2}:\d{2}) INFO  info entry\n`)
+	assert.Check(t, iso.MatchString(string(content)), string(content))
+}
+
+func TestLogResponseContainsStatusAndDuration(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "responses.log")
+	SetEnv(t, "MONACO_RESPONSE_LOG", logFile)
+	defer UnsetEnv(t, "MONACO_RESPONSE_LOG")
+	assert.NilError(t, setupResponseLog())
+
+	response := &http.Response{
+		Status:     "404 Not Found",
+		StatusCode: 404,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+	assert.NilError(t, LogResponse("request-1", response, 1234567*time.Microsecond))
+	CloseLogging()
+
+	content, err := ioutil.ReadFile(logFile)
+	assert.NilError(t, err)
+	assert.Check(t, strings.HasPrefix(string(content), "Request-ID: request-1\nStatus: 404\nDuration: 1.235s\nHTTP/1.1 404 Not Found\r\n"), string(content))
+}
+
+func TestConcurrentRequestAndResponseLogEntriesAreIntact(t *testing.T) {
+	requestLog := filepath.Join(t.TempDir(), "requests.log")
+	responseLog := filepath.Join(t.TempDir(), "responses.log")
+	SetEnv(t, "MONACO_REQUEST_LOG", requestLog)
+	SetEnv(t, "MONACO_RESPONSE_LOG", responseLog)
+	defer UnsetEnv(t, "MONACO_REQUEST_LOG")
+	defer UnsetEnv(t, "MONACO_RESPONSE_LOG")
+	assert.NilError(t, setupRequestLog())
+	assert.NilError(t, setupResponseLog())
+
+	const count = 100
+	body := strings.Repeat("x", 4096)
+
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			id := fmt.Sprintf("request-%d", i)
+
+			request, err := http.NewRequest("POST", "https://my-environment.live.dynatrace.com/api/config/v1/dashboards", strings.NewReader(body))
+			assert.Check(t, err)
+			request.Header.Set("Content-Type", "text/plain")
+			assert.Check(t, LogRequest(id, request))
+
+			response := &http.Response{
+				Status:     "200 OK",
+				StatusCode: 200,
+				Proto:      "HTTP/1.1",
+				ProtoMajor: 1,
+				ProtoMinor: 1,
+				Header:     http.Header{"Content-Type": {"text/plain"}},
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}
+			assert.Check(t, LogResponse(id, response, time.Millisecond))
+		}(i)
+	}
+	wg.Wait()
+	CloseLogging()
+
+	for _, logFile := range []string{requestLog, responseLog} {
+		content, err := ioutil.ReadFile(logFile)
+		assert.NilError(t, err)
+
+		entries := strings.Split(strings.TrimSuffix(string(content), "=========================\n"), "\n=========================\n")
+		assert.Equal(t, len(entries), count, logFile)
+
+		ids := make(map[string]struct{}, count)
+		for _, entry := range entries {
+			assert.Check(t, strings.HasPrefix(entry, "Request-ID: request-"), entry)
+			assert.Check(t, strings.Contains(entry, "\r\n\r\n"+body), entry)
+			assert.Check(t, strings.Count(entry, "Request-ID: ") == 1, entry)
+
+			ids[strings.SplitN(entry, "\n", 2)[0]] = struct{}{}
+		}
+		assert.Equal(t, len(ids), count, logFile)
+	}
+}
+
+func TestLogResponseDecompressesGzipEncodedBody(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "responses.log")
+	SetEnv(t, "MONACO_RESPONSE_LOG", logFile)
+	defer UnsetEnv(t, "MONACO_RESPONSE_LOG")
+	assert.NilError(t, setupResponseLog())
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write([]byte(`{"id": "my-id", "name": "my-dashboard"}`))
+	assert.NilError(t, err)
+	assert.NilError(t, writer.Close())
+
+	response := &http.Response{
+		Status:        "200 OK",
+		StatusCode:    200,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {"gzip"}},
+		ContentLength: int64(compressed.Len()),
+		Body:          ioutil.NopCloser(bytes.NewReader(compressed.Bytes())),
+	}
+	assert.NilError(t, LogResponse("request-1", response, time.Millisecond))
+	CloseLogging()
+
+	content, err := ioutil.ReadFile(logFile)
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(string(content), "\r\n\r\n"+`{"id": "my-id", "name": "my-dashboard"}`), string(content))
+
+	// the body can still be read after logging
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, body, compressed.Bytes())
+}
+
+func TestTruncateBody(t *testing.T) {
+	dump := "GET /api HTTP/1.1\r\nHost: example.com\r\n\r\n0123456789"
+
+	assert.Equal(t, truncateBody(dump, 4), "GET /api HTTP/1.1\r\nHost: example.com\r\n\r\n0123...[truncated 6 bytes]")
+	assert.Equal(t, truncateBody(dump, 10), dump)
+	assert.Equal(t, truncateBody(dump, 0), dump, "0 disables truncation")
+}
+
+func TestTruncateBodyKeepsHeadersWithoutBody(t *testing.T) {
+	dump := "HTTP/1.1 204 No Content\r\nX-Long-Header: " + strings.Repeat("x", 100) + "\r\n\r\n"
+
+	assert.Equal(t, truncateBody(dump, 4), dump)
+}
+
+func TestTruncateBodyDoesNotSplitCharacters(t *testing.T) {
+	dump := "HTTP/1.1 200 OK\r\n\r\naäb"
+
+	assert.Equal(t, truncateBody(dump, 2), "HTTP/1.1 200 OK\r\n\r\na...[truncated 3 bytes]")
+}
+
+func TestGetMaxLoggedBodyBytes(t *testing.T) {
+	defer UnsetEnv(t, "MONACO_LOG_MAX_BODY_BYTES")
+
+	UnsetEnv(t, "MONACO_LOG_MAX_BODY_BYTES")
+	size, err := getMaxLoggedBodyBytes()
+	assert.NilError(t, err)
+	assert.Equal(t, size, defaultMaxLoggedBodyBytes)
+
+	SetEnv(t, "MONACO_LOG_MAX_BODY_BYTES", "0")
+	size, err = getMaxLoggedBodyBytes()
+	assert.NilError(t, err)
+	assert.Equal(t, size, 0)
+
+	SetEnv(t, "MONACO_LOG_MAX_BODY_BYTES", "-1")
+	_, err = getMaxLoggedBodyBytes()
+	assert.ErrorContains(t, err, "invalid size -1 in MONACO_LOG_MAX_BODY_BYTES")
+}
+
+func TestPruneSessionLogsRemovesOldestLogs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, name := range []string{"20220101-100000.log", "20220102-100000.log", "20220103-100000.log", "20220104-100000.log"} {
+		assert.NilError(t, afero.WriteFile(fs, filepath.Join(".logs", name), []byte("log"), 0644))
+	}
+	assert.NilError(t, afero.WriteFile(fs, filepath.Join(".logs", "requests.log"), []byte("requests"), 0644))
+
+	assert.NilError(t, pruneSessionLogs(fs, ".logs", 2))
+
+	files, err := afero.ReadDir(fs, ".logs")
+	assert.NilError(t, err)
+
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	assert.DeepEqual(t, names, []string{"20220103-100000.log", "20220104-100000.log", "requests.log"})
+}
+
+func TestPruneSessionLogsWithoutRetentionKeepsAllLogs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, name := range []string{"20220101-100000.log", "20220102-100000.log"} {
+		assert.NilError(t, afero.WriteFile(fs, filepath.Join(".logs", name), []byte("log"), 0644))
+	}
+
+	assert.NilError(t, pruneSessionLogs(fs, ".logs", 0))
+
+	files, err := afero.ReadDir(fs, ".logs")
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 2)
+}
+
+func TestGetLogRetention(t *testing.T) {
+	defer UnsetEnv(t, "MONACO_LOG_RETENTION")
+
+	UnsetEnv(t, "MONACO_LOG_RETENTION")
+	retention, err := getLogRetention()
+	assert.NilError(t, err)
+	assert.Equal(t, retention, defaultLogRetention)
+
+	SetEnv(t, "MONACO_LOG_RETENTION", "10")
+	retention, err = getLogRetention()
+	assert.NilError(t, err)
+	assert.Equal(t, retention, 10)
+
+	SetEnv(t, "MONACO_LOG_RETENTION", "many")
+	_, err = getLogRetention()
+	assert.ErrorContains(t, err, "invalid number of log files many in MONACO_LOG_RETENTION")
+}
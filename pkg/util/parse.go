@@ -49,10 +49,14 @@ func UnmarshalYaml(text string, fileName string) (error, map[string]map[string]s
 	m := make(map[string]interface{})
 
 	err = yaml.Unmarshal([]byte(text), &m)
-	FailOnError(err, "Failed to unmarshal yaml\n"+text+"\nerror:")
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal yaml file %s: %w", fileName, err), make(map[string]map[string]string)
+	}
 
 	err, typed := convert(m)
-	FailOnError(err, "YAML file "+fileName+" could not be parsed")
+	if err != nil {
+		return fmt.Errorf("YAML file %s could not be parsed: %w", fileName, err), make(map[string]map[string]string)
+	}
 
 	return nil, typed
 }
@@ -75,10 +79,17 @@ func putOrGet(m map[string]map[string]string, key string) map[string]string {
 	return m2
 }
 
+// defaultValueKey is the key of the default value of a parameter defined with per-environment values. As ids of
+// environments and groups can't start with a dot, it never collides with the value of an environment.
+const defaultValueKey = ".default"
+
 func convert(original map[string]interface{}) (err error, typed map[string]map[string]string) {
 
 	m2 := make(map[string]map[string]string)
 
+	// perEnvironment contains the per-environment values of parameters, keyed by the section they override
+	perEnvironment := make(map[string]map[string]string)
+
 	for k1, v1 := range original {
 		switch v2 := v1.(type) {
 		case []interface{}:
@@ -91,10 +102,11 @@ func convert(original map[string]interface{}) (err error, typed map[string]map[s
 						case string:
 							switch v3 := v3.(type) {
 							case string:
-								if referencesConfigJSON(k1, v3) || appearsToReferenceVariableInAnotherYaml(v3) {
-									m2Inner[k3] = ReplacePathSeparators(v3)
-								} else {
-									m2Inner[k3] = v3
+								m2Inner[k3] = convertValue(k1, v3)
+							case map[interface{}]interface{}:
+								err := convertPerEnvironmentValues(k1, k3, v3, m2Inner, perEnvironment)
+								if err != nil {
+									return err, m2
 								}
 							default:
 								return fmt.Errorf("cannot convert YAML on level 4: value of key '%s' has unexpected type", k3), m2
@@ -111,9 +123,54 @@ func convert(original map[string]interface{}) (err error, typed map[string]map[s
 			return fmt.Errorf("cannot convert YAML on level 1: value of key '%s' has unexpected type", k1), m2
 		}
 	}
+
+	// per-environment values are merged into the environment and group sections, once all of them are known
+	for section, values := range perEnvironment {
+		m2Inner := putOrGet(m2, section)
+		for key, value := range values {
+			if _, defined := m2Inner[key]; defined {
+				return fmt.Errorf("parameter '%s' of '%s' is defined both as per-environment value and in section '%s'", key, strings.SplitN(section, ".", 2)[0], section), m2
+			}
+			m2Inner[key] = value
+		}
+	}
+
 	return nil, m2
 }
 
+func convertValue(section string, value string) string {
+	if referencesConfigJSON(section, value) || appearsToReferenceVariableInAnotherYaml(value) {
+		return ReplacePathSeparators(value)
+	}
+	return value
+}
+
+// convertPerEnvironmentValues converts a parameter defined as a map of environment or group names to values. The
+// default value is added to the section itself, all others to perEnvironment, keyed by the section they override,
+// e.g. the value for dev of parameter threshold in section profile is stored as threshold in section profile.dev.
+func convertPerEnvironmentValues(section string, key string, values map[interface{}]interface{},
+	m2Inner map[string]string, perEnvironment map[string]map[string]string) error {
+
+	for k, v := range values {
+		environment, ok := k.(string)
+		if !ok {
+			return fmt.Errorf("cannot convert YAML on level 5: invalid environment '%v' of key '%s'", k, key)
+		}
+
+		value, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("cannot convert YAML on level 5: value of environment '%s' of key '%s' has unexpected type", environment, key)
+		}
+
+		if environment == defaultValueKey {
+			m2Inner[key] = convertValue(section, value)
+		} else {
+			putOrGet(perEnvironment, section+"."+environment)[key] = convertValue(section, value)
+		}
+	}
+	return nil
+}
+
 func appearsToReferenceVariableInAnotherYaml(s string) bool {
 	if containsColon(s) {
 		// A path to another yaml can never ever contain a colon. Therefore, bailing out if s contains one.
@@ -130,7 +187,7 @@ func referencesConfigJSON(yamlSection, s string) bool {
 	if yamlSection != "config" {
 		return false
 	}
-	return strings.HasSuffix(s, ".json")
+	return strings.HasSuffix(s, ".json") || IsJsonnetFile(s)
 }
 
 func containsColon(s string) bool {
@@ -127,6 +127,75 @@ envVars:
     - env-var-with-content: "{{ .Env.TEST_ENV_VAR }} Or am I?"
 `
 
+const testYamlPerEnvironmentValues = `
+profile:
+    - name: "Profile"
+    - threshold:
+        .default: "10"
+        dev: "5"
+        production: "20"
+    - zone:
+        dev: "/infrastructure\\management-zone\\zone.id"
+profile.prod:
+    - name: "Production Profile"
+`
+
+func TestUnmarshalYamlConvertsPerEnvironmentValuesToEnvironmentSections(t *testing.T) {
+	e, result := UnmarshalYaml(testYamlPerEnvironmentValues, "test-yaml")
+	assert.NilError(t, e)
+
+	assert.DeepEqual(t, result, map[string]map[string]string{
+		"profile":            {"name": "Profile", "threshold": "10"},
+		"profile.dev":        {"threshold": "5", "zone": ReplacePathSeparators("/infrastructure/management-zone/zone.id")},
+		"profile.production": {"threshold": "20"},
+		"profile.prod":       {"name": "Production Profile"},
+	})
+}
+
+func TestUnmarshalYamlConvertsPerEnvironmentValueOfEnvironmentNamedDefault(t *testing.T) {
+	e, result := UnmarshalYaml(`
+profile:
+    - threshold:
+        .default: "10"
+        default: "5"
+`, "test-yaml")
+	assert.NilError(t, e)
+
+	assert.DeepEqual(t, result, map[string]map[string]string{
+		"profile":         {"threshold": "10"},
+		"profile.default": {"threshold": "5"},
+	})
+}
+
+func TestUnmarshalConvertYamlFailsOnPerEnvironmentValueDefinedInEnvironmentSection(t *testing.T) {
+	m := make(map[string]interface{})
+	yaml.Unmarshal([]byte(`
+profile:
+    - threshold:
+        dev: "5"
+profile.dev:
+    - threshold: "7"
+`), &m)
+
+	e, _ := convert(m)
+
+	assert.ErrorContains(t, e, "parameter 'threshold' of 'profile' is defined both as per-environment value and in section 'profile.dev'")
+}
+
+func TestUnmarshalConvertYamlFailsOnPerEnvironmentValueWithUnexpectedType(t *testing.T) {
+	m := make(map[string]interface{})
+	yaml.Unmarshal([]byte(`
+profile:
+    - threshold:
+        dev:
+          - "5"
+`), &m)
+
+	e, _ := convert(m)
+
+	assert.ErrorContains(t, e, "cannot convert YAML on level 5: value of environment 'dev' of key 'threshold' has unexpected type")
+}
+
 func TestReplaceEnvVarWhenVarIsPresent(t *testing.T) {
 
 	SetEnv(t, "TEST_ENV_VAR", "I'm the king of the World!")
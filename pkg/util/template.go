@@ -18,9 +18,14 @@ package util
 
 import (
 	"bytes"
+	"encoding/base64"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
+	"text/template/parse"
 
 	"github.com/spf13/afero"
 )
@@ -29,16 +34,24 @@ import (
 // It is intended to be language-agnostic, the file type does not matter (yaml, json, ...)
 type Template interface {
 	ExecuteTemplate(data map[string]string) (string, error)
+
+	// ReferencedEnvVars returns the sorted names of all environment variables the template requires, i.e. all
+	// variables referenced using {{ .Env.X }} or {{ env "X" }} without a default value
+	ReferencedEnvVars() []string
 }
 
 type templateImpl struct {
 	template *template.Template
 }
 
-// NewTemplateFromString creates a new template for the given string content
+// NewTemplateFromString creates a new template for the given string content. It can't reference assets, as it is
+// not read from a file.
 func NewTemplateFromString(name string, content string) (Template, error) {
+	return newTemplateFromString(name, content, noAssets)
+}
 
-	templ := template.New(name).Option("missingkey=error")
+func newTemplateFromString(name string, content string, asset interface{}) (Template, error) {
+	templ := template.New(name).Option("missingkey=error").Funcs(templateFunctions).Funcs(template.FuncMap{"asset": asset})
 	templ, err := templ.Parse(content)
 
 	if err != nil {
@@ -48,15 +61,20 @@ func NewTemplateFromString(name string, content string) (Template, error) {
 	return newTemplate(templ), nil
 }
 
-// NewTemplate creates a new template for the given file
+// NewTemplate creates a new template for the given file. Assets referenced by the template are read from the given
+// file system, relative to the folder of the file. Jsonnet files are rendered using jsonnet instead of go templates.
 func NewTemplate(fs afero.Fs, fileName string) (Template, error) {
+	if IsJsonnetFile(fileName) {
+		return newJsonnetTemplate(fs, fileName)
+	}
+
 	data, err := afero.ReadFile(fs, fileName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return NewTemplateFromString(fileName, string(data))
+	return newTemplateFromString(fileName, string(data), assetFunction(fs, fileName))
 }
 
 func newTemplate(templ *template.Template) Template {
@@ -81,6 +99,9 @@ func (t *templateImpl) ExecuteTemplate(data map[string]string) (string, error) {
 	dataForTemplating := addEnvVars(data)
 
 	err := t.template.Execute(&tpl, dataForTemplating)
+	if err != nil {
+		err = describeMissingEnvVars(t.template.Name(), t.ReferencedEnvVars(), err)
+	}
 	if CheckError(err, "Could not execute template") {
 		return "", err
 	}
@@ -114,3 +135,173 @@ func addEnvVars(properties map[string]string) map[string]interface{} {
 
 	return data
 }
+
+func (t *templateImpl) ReferencedEnvVars() []string {
+	referenced := make(map[string]struct{})
+
+	for _, templ := range t.template.Templates() {
+		if templ.Tree != nil {
+			collectEnvVars(templ.Tree.Root, referenced)
+		}
+	}
+
+	names := make([]string, 0, len(referenced))
+	for name := range referenced {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func collectEnvVars(node parse.Node, referenced map[string]struct{}) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			collectEnvVars(n, referenced)
+		}
+	case *parse.ActionNode:
+		collectEnvVars(node.Pipe, referenced)
+	case *parse.IfNode:
+		collectEnvVarsOfBranch(&node.BranchNode, referenced)
+	case *parse.RangeNode:
+		collectEnvVarsOfBranch(&node.BranchNode, referenced)
+	case *parse.WithNode:
+		collectEnvVarsOfBranch(&node.BranchNode, referenced)
+	case *parse.TemplateNode:
+		collectEnvVars(node.Pipe, referenced)
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, command := range node.Cmds {
+			collectEnvVars(command, referenced)
+		}
+	case *parse.CommandNode:
+		if name, found := requiredEnvFunctionArgument(node); found {
+			referenced[name] = struct{}{}
+		}
+		for _, arg := range node.Args {
+			collectEnvVars(arg, referenced)
+		}
+	case *parse.ChainNode:
+		collectEnvVars(node.Node, referenced)
+	case *parse.FieldNode:
+		if len(node.Ident) >= 2 && node.Ident[0] == "Env" {
+			referenced[node.Ident[1]] = struct{}{}
+		}
+	case *parse.VariableNode:
+		if len(node.Ident) >= 3 && node.Ident[0] == "$" && node.Ident[1] == "Env" {
+			referenced[node.Ident[2]] = struct{}{}
+		}
+	}
+}
+
+func collectEnvVarsOfBranch(node *parse.BranchNode, referenced map[string]struct{}) {
+	collectEnvVars(node.Pipe, referenced)
+	collectEnvVars(node.List, referenced)
+	collectEnvVars(node.ElseList, referenced)
+}
+
+// requiredEnvFunctionArgument returns the variable name of an {{ env "X" }} call without a default value
+func requiredEnvFunctionArgument(node *parse.CommandNode) (string, bool) {
+	if len(node.Args) != 2 {
+		return "", false
+	}
+
+	function, isIdentifier := node.Args[0].(*parse.IdentifierNode)
+	name, isString := node.Args[1].(*parse.StringNode)
+	if !isIdentifier || !isString || function.Ident != "env" {
+		return "", false
+	}
+	return name.Text, true
+}
+
+// templateFunctions are the functions available in all templates. Only functions without side effects are
+// available, none of them executes commands. Only the asset function, which is added per template, reads files.
+var templateFunctions = template.FuncMap{
+	"env":     env,
+	"default": defaultValue,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"replace": replace,
+	"add":     add,
+	"sub":     sub,
+	"mul":     mul,
+	"div":     div,
+}
+
+// env resolves the environment variable with the given name. If the variable is not set or empty, the default value
+// is returned. If no default value is given, an unset variable leads to an error.
+//
+// Usage: {{ env "MY_VAR" }} or {{ env "MY_VAR" "fallback" }}
+func env(name string, defaultValue ...string) (string, error) {
+	if len(defaultValue) > 1 {
+		return "", fmt.Errorf("only one default value allowed for environment variable %s, got %d", name, len(defaultValue))
+	}
+
+	value, found := os.LookupEnv(name)
+	if found && value != "" {
+		return value, nil
+	}
+
+	if len(defaultValue) == 1 {
+		return defaultValue[0], nil
+	}
+
+	if !found {
+		return "", fmt.Errorf("environment variable %s is not set and no default value is defined", name)
+	}
+	return value, nil
+}
+
+// assetFunction returns the asset function of the template file. It reads binary files, e.g. images, which don't fit
+// into a json template and returns their content encoded as base64. The path is relative to the folder of the template
+// and must not leave it, so templates can only read the files shipped with them.
+//
+// Usage: {{ asset "logo.png" }}
+func assetFunction(fs afero.Fs, templateFile string) func(path string) (string, error) {
+	return func(path string) (string, error) {
+		cleaned := filepath.Clean(filepath.FromSlash(path))
+		if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("invalid asset %s: must be a path relative to the folder of %s, inside of it", path, templateFile)
+		}
+
+		content, err := afero.ReadFile(fs, filepath.Join(filepath.Dir(templateFile), cleaned))
+		if err != nil {
+			return "", fmt.Errorf("could not read asset %s of %s: %w", path, templateFile, err)
+		}
+		return base64.StdEncoding.EncodeToString(content), nil
+	}
+}
+
+// noAssets is the asset function of templates not read from files
+func noAssets(path string) (string, error) {
+	return "", fmt.Errorf("asset %s can't be read, as the template is not read from a file", path)
+}
+
+// describeMissingEnvVars names the unset environment variables and the template if the template failed to render.
+// The variables required by the template are taken from the parsed template, as the messages of the errors of the
+// template package are not part of its API.
+func describeMissingEnvVars(templateName string, referenced []string, err error) error {
+	missing := make([]string, 0)
+	for _, name := range referenced {
+		if _, found := os.LookupEnv(name); !found {
+			missing = append(missing, name)
+		}
+	}
+
+	switch len(missing) {
+	case 0:
+		return err
+	case 1:
+		return fmt.Errorf("environment variable %s referenced in %s is not set, use {{ env \"%s\" \"<default>\" }} to define a default value: %w",
+			missing[0], templateName, missing[0], err)
+	default:
+		return fmt.Errorf("environment variables %s referenced in %s are not set, use {{ env \"<name>\" \"<default>\" }} to define default values: %w",
+			strings.Join(missing, ", "), templateName, err)
+	}
+}
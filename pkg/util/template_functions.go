@@ -0,0 +1,113 @@
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// defaultValue returns the value, or the fallback if the value is empty. The fallback comes first, so the value
+// can be piped into the function, like in sprig.
+//
+// Usage: {{ default "fallback" .value }} or {{ .value | default "fallback" }}
+func defaultValue(fallback interface{}, value interface{}) interface{} {
+	if value == nil || fmt.Sprint(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+// replace replaces all occurrences of old in the value with new. The value comes last, so it can be piped into the
+// function.
+//
+// Usage: {{ replace "-" "_" .value }} or {{ .value | replace "-" "_" }}
+func replace(old string, new string, value string) string {
+	return strings.ReplaceAll(value, old, new)
+}
+
+// add returns the sum of the numbers. Numbers can be passed as strings, as all properties are strings.
+//
+// Usage: {{ add .threshold 10 }}
+func add(a interface{}, b interface{}) (interface{}, error) {
+	return calculate(a, b, func(x, y float64) float64 { return x + y })
+}
+
+// sub returns the difference of the numbers
+//
+// Usage: {{ sub .threshold 10 }}
+func sub(a interface{}, b interface{}) (interface{}, error) {
+	return calculate(a, b, func(x, y float64) float64 { return x - y })
+}
+
+// mul returns the product of the numbers
+//
+// Usage: {{ mul .threshold 2 }}
+func mul(a interface{}, b interface{}) (interface{}, error) {
+	return calculate(a, b, func(x, y float64) float64 { return x * y })
+}
+
+// div returns the quotient of the numbers. Dividing by zero leads to an error.
+//
+// Usage: {{ div .threshold 2 }}
+func div(a interface{}, b interface{}) (interface{}, error) {
+	if divisor, err := toNumber(b); err == nil && divisor == 0 {
+		return nil, fmt.Errorf("division of %v by zero", a)
+	}
+	return calculate(a, b, func(x, y float64) float64 { return x / y })
+}
+
+// calculate applies the operation to the numbers. The result is an integer if it has no fraction, so it is
+// rendered without decimal places.
+func calculate(a interface{}, b interface{}, operation func(x, y float64) float64) (interface{}, error) {
+	x, err := toNumber(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toNumber(b)
+	if err != nil {
+		return nil, err
+	}
+
+	result := operation(x, y)
+	if result == math.Trunc(result) && math.Abs(result) < math.MaxInt64 {
+		return int64(result), nil
+	}
+	return result, nil
+}
+
+// toNumber converts the template value to a number. Strings are parsed, as all properties are strings.
+func toNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", v)
+		}
+		return number, nil
+	default:
+		return 0, fmt.Errorf("%v is not a number", value)
+	}
+}
@@ -0,0 +1,85 @@
+//go:build unit
+// +build unit
+
+/**
+ * @license
+ * Copyright 2022 Dynatrace LLC
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestTemplateFunctions(t *testing.T) {
+	properties := map[string]string{
+		"name":      "  My-Dashboard ",
+		"empty":     "",
+		"threshold": "40",
+		"ratio":     "0.5",
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{`{{ upper .name }}`, "  MY-DASHBOARD "},
+		{`{{ .name | lower }}`, "  my-dashboard "},
+		{`{{ trim .name }}`, "My-Dashboard"},
+		{`{{ .name | trim | replace "-" " " }}`, "My Dashboard"},
+		{`{{ default "fallback" .empty }}`, "fallback"},
+		{`{{ .threshold | default "fallback" }}`, "40"},
+		{`{{ add .threshold 10 }}`, "50"},
+		{`{{ sub .threshold 50 }}`, "-10"},
+		{`{{ mul .threshold .ratio }}`, "20"},
+		{`{{ div .threshold 3 }}`, "13.333333333333334"},
+		{`{{ div .threshold "8" }}`, "5"},
+		{`{{ add .ratio 1 }}`, "1.5"},
+		{`{{ env "TEMPLATE_FUNCTIONS_TEST" | upper }}`, "VALUE"},
+	}
+
+	SetEnv(t, "TEMPLATE_FUNCTIONS_TEST", "value")
+	defer UnsetEnv(t, "TEMPLATE_FUNCTIONS_TEST")
+
+	for _, test := range tests {
+		template, err := NewTemplateFromString("template_test", test.template)
+		assert.NilError(t, err, test.template)
+
+		result, err := template.ExecuteTemplate(properties)
+		assert.NilError(t, err, test.template)
+		assert.Equal(t, result, test.expected, test.template)
+	}
+}
+
+func TestTemplateFunctionsFailOnInvalidNumbers(t *testing.T) {
+	properties := map[string]string{"threshold": "a lot"}
+
+	for _, content := range []string{`{{ add .threshold 1 }}`, `{{ div 1 0 }}`, `{{ div 1 "0" }}`} {
+		template, err := NewTemplateFromString("template_test", content)
+		assert.NilError(t, err, content)
+
+		_, err = template.ExecuteTemplate(properties)
+		assert.Check(t, err != nil, content)
+	}
+}
+
+func TestTemplatesCanNotUseFunctionsAccessingTheSystem(t *testing.T) {
+	for _, content := range []string{`{{ readFile "/etc/passwd" }}`, `{{ exec "ls" }}`, `{{ expandenv "$HOME" }}`} {
+		_, err := NewTemplateFromString("template_test", content)
+		assert.ErrorContains(t, err, "not defined", content)
+	}
+}
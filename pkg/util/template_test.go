@@ -20,8 +20,12 @@
 package util
 
 import (
-	"gotest.tools/assert"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/spf13/afero"
+	"gotest.tools/assert"
 )
 
 const testMatrixTemplateWithEnvVar = "Follow the {{.color}} {{ .Env.ANIMAL }}"
@@ -89,6 +93,197 @@ func TestGetStringWithEnvVarIncludingEqualSigns(t *testing.T) {
 	assert.Equal(t, "Follow the white cow=rabbit=chicken", result)
 }
 
+const testMatrixTemplateWithEnvFunction = `Follow the {{.color}} {{ env "ANIMAL" "rabbit" }}`
+
+func TestGetStringWithEnvFunction(t *testing.T) {
+
+	template, err := NewTemplateFromString("template_test", testMatrixTemplateWithEnvFunction)
+	assert.NilError(t, err)
+
+	SetEnv(t, "ANIMAL", "cow")
+	result, err := template.ExecuteTemplate(getTemplateTestProperties())
+	UnsetEnv(t, "ANIMAL")
+
+	assert.NilError(t, err)
+	assert.Equal(t, "Follow the white cow", result)
+}
+
+func TestGetStringWithEnvFunctionUsesDefaultIfEnvVarNotPresent(t *testing.T) {
+
+	template, err := NewTemplateFromString("template_test", testMatrixTemplateWithEnvFunction)
+	assert.NilError(t, err)
+
+	UnsetEnv(t, "ANIMAL")
+	result, err := template.ExecuteTemplate(getTemplateTestProperties())
+
+	assert.NilError(t, err)
+	assert.Equal(t, "Follow the white rabbit", result)
+}
+
+func TestGetStringWithEnvFunctionUsesDefaultIfEnvVarIsEmpty(t *testing.T) {
+
+	template, err := NewTemplateFromString("template_test", testMatrixTemplateWithEnvFunction)
+	assert.NilError(t, err)
+
+	SetEnv(t, "ANIMAL", "")
+	result, err := template.ExecuteTemplate(getTemplateTestProperties())
+	UnsetEnv(t, "ANIMAL")
+
+	assert.NilError(t, err)
+	assert.Equal(t, "Follow the white rabbit", result)
+}
+
+func TestGetStringWithEnvFunctionWithoutDefault(t *testing.T) {
+
+	template, err := NewTemplateFromString("template_test", `Follow the {{ env "ANIMAL" }}`)
+	assert.NilError(t, err)
+
+	SetEnv(t, "ANIMAL", "")
+	result, err := template.ExecuteTemplate(getTemplateTestProperties())
+	UnsetEnv(t, "ANIMAL")
+
+	assert.NilError(t, err)
+	assert.Equal(t, "Follow the ", result)
+}
+
+func TestGetStringWithEnvFunctionLeadsToErrorIfEnvVarNotPresentAndNoDefault(t *testing.T) {
+
+	template, err := NewTemplateFromString("project/alerting-profile/profile.json", `Follow the {{ env "ANIMAL" }}`)
+	assert.NilError(t, err)
+
+	UnsetEnv(t, "ANIMAL")
+	_, err = template.ExecuteTemplate(getTemplateTestProperties())
+
+	assert.ErrorContains(t, err, "project/alerting-profile/profile.json")
+	assert.ErrorContains(t, err, "environment variable ANIMAL is not set and no default value is defined")
+}
+
+func TestGetStringWithEnvFunctionLeadsToErrorOnMultipleDefaults(t *testing.T) {
+
+	template, err := NewTemplateFromString("template_test", `Follow the {{ env "ANIMAL" "rabbit" "cow" }}`)
+	assert.NilError(t, err)
+
+	_, err = template.ExecuteTemplate(getTemplateTestProperties())
+
+	assert.ErrorContains(t, err, "only one default value allowed for environment variable ANIMAL, got 2")
+}
+
+func TestGetStringWithEnvVarNamesMissingEnvVarAndTemplate(t *testing.T) {
+
+	template, err := NewTemplateFromString("project/alerting-profile/profile.json", testMatrixTemplateWithEnvVar)
+	assert.NilError(t, err)
+
+	UnsetEnv(t, "ANIMAL")
+	_, err = template.ExecuteTemplate(getTemplateTestProperties())
+
+	assert.ErrorContains(t, err, "environment variable ANIMAL referenced in project/alerting-profile/profile.json is not set")
+}
+
+func TestGetStringWithEnvVarsNamesAllMissingEnvVars(t *testing.T) {
+
+	template, err := NewTemplateFromString("profile.json", `{{ .Env.ANIMAL }} {{ env "COLOR" }} {{ .Env.SIZE }}`)
+	assert.NilError(t, err)
+
+	UnsetEnv(t, "ANIMAL")
+	UnsetEnv(t, "COLOR")
+	SetEnv(t, "SIZE", "small")
+	_, err = template.ExecuteTemplate(getTemplateTestProperties())
+
+	assert.ErrorContains(t, err, "environment variables ANIMAL, COLOR referenced in profile.json are not set")
+}
+
+func TestGetStringWithMissingPropertyDoesNotBlameEnvVars(t *testing.T) {
+
+	template, err := NewTemplateFromString("profile.json", `{{ .Env.ANIMAL }} {{ .missing }}`)
+	assert.NilError(t, err)
+
+	SetEnv(t, "ANIMAL", "cow")
+	_, err = template.ExecuteTemplate(getTemplateTestProperties())
+
+	assert.Assert(t, err != nil)
+	assert.Assert(t, !strings.Contains(err.Error(), "environment variable"), err.Error())
+}
+
+func TestReferencedEnvVars(t *testing.T) {
+
+	content := `{{ .Env.ANIMAL }} {{ if .Env.COLOR }}{{ $.Env.SIZE }}{{ else }}{{ env "FALLBACK" }}{{ end }}
+{{ with .Env.ITEM }}{{ . | printf "%s" }}{{ end }} {{ .Env.ANIMAL }} {{ env "OPTIONAL" "default" }} {{ .name }}`
+
+	template, err := NewTemplateFromString("template_test", content)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, template.ReferencedEnvVars(), []string{"ANIMAL", "COLOR", "FALLBACK", "ITEM", "SIZE"})
+}
+
+func TestReferencedEnvVarsWithoutEnvVars(t *testing.T) {
+
+	template, err := NewTemplateFromString("template_test", testMatrixTemplateWithProperty)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, template.ReferencedEnvVars(), []string{})
+}
+
+func TestAssetIsInlinedAsBase64(t *testing.T) {
+
+	template, err := NewTemplate(afero.NewOsFs(), filepath.Join("test-resources", "assets", "dashboard.json"))
+	assert.NilError(t, err)
+
+	result, err := template.ExecuteTemplate(map[string]string{"name": "Dashboard"})
+	assert.NilError(t, err)
+	assert.Equal(t, result, `{"name": "Dashboard", "image": "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="}`+"\n")
+}
+
+func TestAssetIsReadRelativeToTemplateFile(t *testing.T) {
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "project/dashboard/template.json", []byte(`{{ asset "./assets/../logo.bin" }}`), 0644))
+	assert.NilError(t, afero.WriteFile(fs, "project/dashboard/logo.bin", []byte{0x00, 0xff, 0x10}, 0644))
+
+	template, err := NewTemplate(fs, "project/dashboard/template.json")
+	assert.NilError(t, err)
+
+	result, err := template.ExecuteTemplate(map[string]string{})
+	assert.NilError(t, err)
+	assert.Equal(t, result, "AP8Q")
+}
+
+func TestMissingAssetLeadsToError(t *testing.T) {
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "project/dashboard/template.json", []byte(`{{ asset "missing.png" }}`), 0644))
+
+	template, err := NewTemplate(fs, "project/dashboard/template.json")
+	assert.NilError(t, err)
+
+	_, err = template.ExecuteTemplate(map[string]string{})
+	assert.ErrorContains(t, err, "could not read asset missing.png of project/dashboard/template.json")
+}
+
+func TestAssetOutsideOfTemplateFolderLeadsToError(t *testing.T) {
+
+	fs := afero.NewMemMapFs()
+	assert.NilError(t, afero.WriteFile(fs, "project/secret.bin", []byte{0x01}, 0644))
+
+	for _, path := range []string{"../secret.bin", "/project/secret.bin"} {
+		assert.NilError(t, afero.WriteFile(fs, "project/dashboard/template.json", []byte(`{{ asset "`+path+`" }}`), 0644))
+
+		template, err := NewTemplate(fs, "project/dashboard/template.json")
+		assert.NilError(t, err)
+
+		_, err = template.ExecuteTemplate(map[string]string{})
+		assert.ErrorContains(t, err, "invalid asset "+path+": must be a path relative to the folder of project/dashboard/template.json")
+	}
+}
+
+func TestAssetOfTemplateFromStringLeadsToError(t *testing.T) {
+
+	template, err := NewTemplateFromString("template_test", `{{ asset "logo.png" }}`)
+	assert.NilError(t, err)
+
+	_, err = template.ExecuteTemplate(map[string]string{})
+	assert.ErrorContains(t, err, "asset logo.png can't be read, as the template is not read from a file")
+}
+
 func getTemplateTestProperties() map[string]string {
 
 	m := make(map[string]string)
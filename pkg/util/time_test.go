@@ -20,6 +20,7 @@
 package util
 
 import (
+	"context"
 	"gotest.tools/assert"
 	"testing"
 	"time"
@@ -56,3 +57,11 @@ func TestTimelineProviderReturnsUTC(t *testing.T) {
 	location, _ := time.LoadLocation("UTC")
 	assert.Equal(t, now.UnixNano(), now.In(location).UnixNano())
 }
+
+func TestTimelineProviderSleepReturnsErrorOfCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := NewTimelineProvider().Sleep(ctx, time.Minute)
+	assert.Equal(t, err, context.Canceled)
+}